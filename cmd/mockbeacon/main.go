@@ -0,0 +1,61 @@
+// Package main runs a mock Beaconcha v2 API serving demo data, for running the
+// server without an API key or network access:
+//
+//	go run ./cmd/mockbeacon -addr :9090
+//	BEACONCHAIN_BASE_URL=http://localhost:9090 BEACONCHAIN_RATE_LIMIT=1ms go run ./cmd/server
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/mock"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "listen address")
+	latency := flag.Duration("latency", 0, "delay added to every response")
+	rateLimitEvery := flag.Int("rate-limit-every", 0, "answer every nth request with 429 (0 disables)")
+	pageSize := flag.Int("page-size", 0, "maximum page size of paginated endpoints (0 uses the requested size)")
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+	slog.SetDefault(logger)
+
+	server := mock.New(mock.Demo(time.Now()))
+	server.SetLatency(*latency)
+	server.SetRateLimitEvery(*rateLimitEvery)
+	server.SetMaxPageSize(*pageSize)
+
+	srv := &http.Server{Addr: *addr, Handler: server}
+	go func() {
+		slog.Info("mock beaconcha listening",
+			"addr", *addr,
+			"validators", mock.DemoValidators,
+			"withdrawal_address", mock.DemoWithdrawalAddress,
+		)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("server error", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("server shutdown error", "error", err)
+		os.Exit(1)
+	}
+}
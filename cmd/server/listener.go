@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// sdListenFdsStart is the first file descriptor passed by systemd socket activation.
+const sdListenFdsStart = 3
+
+// listen returns the listener the server should accept connections on.
+// A socket passed by systemd (LISTEN_FDS) takes precedence over addr.
+// Otherwise addr is either a TCP address (":8080") or a unix socket path
+// prefixed with "unix:" ("unix:/run/validator-dashboard.sock").
+func listen(addr string) (net.Listener, error) {
+	ln, err := systemdListener()
+	if err != nil {
+		return nil, fmt.Errorf("systemd socket activation: %w", err)
+	}
+	if ln != nil {
+		return ln, nil
+	}
+
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		return listenUnix(path)
+	}
+
+	return net.Listen("tcp", addr)
+}
+
+// listenUnix listens on a unix socket, removing a stale socket file left by a previous run.
+func listenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Stat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Allow a reverse proxy running as a different user in the same group to connect
+	if err := os.Chmod(path, 0o660); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("chmod socket: %w", err)
+	}
+
+	return ln, nil
+}
+
+// systemdListener returns the socket passed via systemd socket activation, or nil
+// if the process was not socket activated. Exactly one socket must be passed.
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	// The server accepts on a single socket, so more sockets in the unit would
+	// never be served
+	nfds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || nfds != 1 {
+		return nil, fmt.Errorf("LISTEN_FDS must be 1, got %q", os.Getenv("LISTEN_FDS"))
+	}
+
+	// Don't pass the sockets on to child processes
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(uintptr(sdListenFdsStart), "LISTEN_FD_3")
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, err
+	}
+	// FileListener dups the descriptor, so the original can be closed
+	f.Close()
+
+	return ln, nil
+}
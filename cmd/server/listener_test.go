@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestListen_Unix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.sock")
+
+	// A socket file left by a previous run is replaced
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected the stale socket to remain: %v", err)
+	}
+
+	ln, err := listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0o660 {
+		t.Errorf("expected mode 0660, got %o", perm)
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	conn.Close()
+}
+
+func TestListen_UnixNotASocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.sock")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := listen("unix:" + path); err == nil {
+		t.Fatal("expected an error for a regular file")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "data" {
+		t.Errorf("expected the file to be left alone, got %q, %v", data, err)
+	}
+}
+
+func TestSystemdListener(t *testing.T) {
+	tests := []struct {
+		name    string
+		pid     string
+		fds     string
+		wantErr bool
+	}{
+		{"not activated", "", "", false},
+		{"other process", strconv.Itoa(os.Getpid() + 1), "1", false},
+		{"no sockets", strconv.Itoa(os.Getpid()), "0", true},
+		{"several sockets", strconv.Itoa(os.Getpid()), "2", true},
+		{"malformed", strconv.Itoa(os.Getpid()), "one", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LISTEN_PID", tt.pid)
+			t.Setenv("LISTEN_FDS", tt.fds)
+
+			ln, err := systemdListener()
+			if ln != nil {
+				ln.Close()
+				t.Fatal("expected no listener")
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -3,21 +3,36 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"flag"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/Marketen/validator-dashboard-beaconcha/internal/api"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/anomaly"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/backup"
 	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/budget"
 	"github.com/Marketen/validator-dashboard-beaconcha/internal/config"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/diversity"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/eigenlayer"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ens"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/price"
 	"github.com/Marketen/validator-dashboard-beaconcha/internal/ratelimiter"
-	"github.com/Marketen/validator-dashboard-beaconcha/internal/service"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/relays"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/tenant"
 )
 
 func main() {
+	migrate := flag.Bool("migrate", false, "apply the pending migrations of the history store and exit")
+	flag.Parse()
+
 	// Initialize structured logger
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
@@ -32,40 +47,270 @@ func main() {
 	}
 
 	slog.Info("starting validator-dashboard",
-		"port", cfg.Port,
+		"addr", cfg.Addr,
 		"beaconcha_base_url", cfg.BeaconchainBaseURL,
 	)
 
-	// Initialize global rate limiter for Beaconcha API (1 req/sec)
-	beaconchainRateLimiter := ratelimiter.NewGlobalRateLimiter(cfg.BeaconchainRateLimit)
+	// Connect to Beaconcha through the configured proxy and CAs, sharing one connection pool
+	var transport http.RoundTripper
+	transport, err = beaconcha.NewTransport(beaconcha.TransportConfig{
+		ProxyURL:            cfg.BeaconchainProxyURL,
+		CAFile:              cfg.BeaconchainCAFile,
+		MaxIdleConns:        cfg.BeaconchainMaxIdleConns,
+		MaxIdleConnsPerHost: cfg.BeaconchainMaxIdleConnsPerHost,
+		MaxConnsPerHost:     cfg.BeaconchainMaxConnsPerHost,
+		IdleConnTimeout:     cfg.BeaconchainIdleConnTimeout,
+		KeepAlive:           cfg.BeaconchainKeepAlive,
+	})
+	if err != nil {
+		slog.Error("failed to configure beaconcha transport", "error", err)
+		os.Exit(1)
+	}
 
-	// Initialize Beaconcha client
-	beaconchainClient := beaconcha.NewClient(
-		cfg.BeaconchainBaseURL,
-		cfg.BeaconchainAPIKey,
-		beaconchainRateLimiter,
-		cfg.BeaconchainTimeout,
-	)
+	// Replay recorded upstream traffic instead of calling Beaconcha
+	if cfg.BeaconchaReplayDir != "" {
+		transport, err = beaconcha.NewReplayTransport(cfg.BeaconchaReplayDir, cfg.BeaconchaReplayMode, transport)
+		if err != nil {
+			slog.Error("failed to set up beaconcha replay", "error", err)
+			os.Exit(1)
+		}
+		slog.Warn("beaconcha replay enabled", "dir", cfg.BeaconchaReplayDir, "mode", cfg.BeaconchaReplayMode)
+	}
+
+	// Limit the upstream credits spent per day
+	var creditBudget *budget.Manager
+	if cfg.BeaconchainDailyCredits > 0 {
+		creditBudget = budget.NewManager(int64(cfg.BeaconchainDailyCredits), cfg.BeaconchainCreditReserve, cfg.BeaconchainCreditCosts)
+	}
+
+	// Initialize a Beaconcha client with its own rate limiter (1 req/sec by default)
+	// per upstream and API key, so a chain hitting its quota does not stall the others
+	newBeaconchainClient := func(upstream config.ChainUpstream) *beaconcha.Client {
+		client := beaconcha.NewClient(
+			upstream.BaseURL,
+			upstream.APIKey,
+			cfg.BeaconchainAPIVersion,
+			ratelimiter.NewGlobalRateLimiter(upstream.RateLimit),
+			cfg.BeaconchainTimeout,
+		)
+		client.SetTransport(transport)
+		client.SetBudget(creditBudget)
+		return client
+	}
+	defaultUpstream := config.ChainUpstream{
+		BaseURL:   cfg.BeaconchainBaseURL,
+		APIKey:    cfg.BeaconchainAPIKey,
+		RateLimit: cfg.BeaconchainRateLimit,
+	}
+	upstreamClients := map[config.ChainUpstream]*beaconcha.Client{defaultUpstream: newBeaconchainClient(defaultUpstream)}
+	beaconchainClient := beaconcha.NewChainClients(upstreamClients[defaultUpstream])
+	for chain, upstream := range cfg.BeaconchainChains {
+		client, ok := upstreamClients[upstream]
+		if !ok {
+			client = newBeaconchainClient(upstream)
+			upstreamClients[upstream] = client
+		}
+		beaconchainClient.Set(chain, client)
+	}
+	slog.Info("beaconcha upstreams configured", "clients", len(upstreamClients))
+
+	// Load known network incidents to exclude from testnet aggregates
+	anomalyFilter, err := anomaly.LoadFile(cfg.AnomalyWindowsFile)
+	if err != nil {
+		slog.Error("failed to load anomaly windows", "error", err)
+		os.Exit(1)
+	}
+
+	// Load the network client shares fleets are compared against
+	networkDiversity, err := diversity.LoadFile(cfg.ClientDiversityFile)
+	if err != nil {
+		slog.Error("failed to load client diversity", "error", err)
+		os.Exit(1)
+	}
+
+	// Background jobs run until shutdown, which waits for them to return
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	var background sync.WaitGroup
+	runBackground := func(job func(ctx context.Context)) {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			job(bgCtx)
+		}()
+	}
+
+	// Resolve ENS names of withdrawal addresses and registries, and detect
+	// EigenPods among withdrawal addresses, on mainnet
+	var names *ens.Resolver
+	var restaking *eigenlayer.Checker
+	if cfg.ExecutionRPCURL != "" {
+		names = ens.NewResolver(cfg.ExecutionRPCURL, cfg.ENSCacheTTL, 10*time.Second)
+		restaking = eigenlayer.NewChecker(cfg.ExecutionRPCURL, cfg.RestakingCacheTTL, 10*time.Second)
+	}
+
+	// Check the MEV-boost relay registrations of mainnet validators
+	var relayChecker *relays.Checker
+	if len(cfg.MEVRelays) > 0 {
+		relayChecker = relays.NewChecker(cfg.MEVRelays, cfg.MEVRelayCacheTTL, 10*time.Second)
+	}
+
+	// Initialize fiat price providers in failover order
+	priceProviders, err := price.NewProviders(cfg.PriceProviders, price.ProviderConfig{
+		CoinGeckoBaseURL: cfg.CoinGeckoBaseURL,
+		CoinGeckoAPIKey:  cfg.CoinGeckoAPIKey,
+		KrakenBaseURL:    cfg.KrakenBaseURL,
+		CoinbaseBaseURL:  cfg.CoinbaseBaseURL,
+		ExecutionRPCURL:  cfg.ExecutionRPCURL,
+		ChainlinkFeed:    cfg.ChainlinkETHUSDFeed,
+		StaticPrices:     cfg.PriceStaticPrices,
+		Timeout:          cfg.PriceTimeout,
+	})
+	if err != nil {
+		slog.Error("failed to configure price providers", "error", err)
+		os.Exit(1)
+	}
+	priceService := price.NewService(priceProviders, cfg.PriceCacheTTL, cfg.PriceMaxStaleness)
+	priceService.SetRateLimits(cfg.PriceRateLimits)
+
+	// Keep the daily price history next to the snapshots so it survives restarts,
+	// after moving a state import into place
+	if cfg.DataDir != "" {
+		if _, err := backup.ApplyStaged(cfg.DataDir); err != nil {
+			slog.Error("failed to apply imported state", "error", err)
+			os.Exit(1)
+		}
+		if err := priceService.LoadHistory(filepath.Join(cfg.DataDir, "prices.json")); err != nil {
+			slog.Error("failed to load price history", "error", err)
+			os.Exit(1)
+		}
+	}
 
-	// Initialize validator service
-	validatorService := service.NewValidatorService(beaconchainClient)
+	// Share the snapshot history between instances through PostgreSQL if configured
+	var database *sql.DB
+	if cfg.HistoryDatabaseURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		database, err = store.OpenPostgres(ctx, cfg.HistoryDatabaseURL)
+		cancel()
+		if err != nil {
+			slog.Error("failed to open database", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+	}
 
-	// Initialize API handler
-	handler := api.NewHandler(validatorService, cfg)
+	// Bring the schema of the history stores up to date before opening them, or
+	// make sure it is when another instance or a release step migrates
+	tenants, err := tenant.LoadFile(cfg.TenantsFile)
+	if err != nil {
+		slog.Error("failed to load tenants", "error", err)
+		os.Exit(1)
+	}
+	migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), 10*time.Minute)
+	err = migrateStores(migrateCtx, storeMigrators(cfg, database, tenants), *migrate || cfg.MigrateOnStart)
+	cancelMigrate()
+	if err != nil {
+		slog.Error("failed to migrate history store", "error", err)
+		os.Exit(1)
+	}
+	if *migrate {
+		slog.Info("history store migrated")
+		return
+	}
+
+	// Components every stack shares, so tenants draw on one upstream rate limit and budget
+	sh := shared{
+		cfg:              cfg,
+		client:           beaconchainClient,
+		budget:           creditBudget,
+		anomalyFilter:    anomalyFilter,
+		networkDiversity: networkDiversity,
+		prices:           priceService,
+		names:            names,
+		restaking:        restaking,
+		relays:           relayChecker,
+		database:         database,
+		runBackground:    runBackground,
+	}
+
+	// Serve one operator, or each tenant from its own stack behind its API key
+	var stacks []*stack
+	defer func() {
+		for _, st := range stacks {
+			st.close()
+		}
+	}()
+	var router http.Handler
+	if len(tenants) == 0 {
+		st, err := newStack(sh, stackFiles{
+			portfolios:      cfg.PortfoliosFile,
+			alertRules:      cfg.AlertRulesFile,
+			reportSchedules: cfg.ReportSchedulesFile,
+			dataDir:         cfg.DataDir,
+			parquetDir:      cfg.ParquetExportDir,
+			heartbeatURL:    cfg.HeartbeatURL,
+			heartbeatFail:   cfg.HeartbeatFailURL,
+		})
+		if err != nil {
+			slog.Error("failed to set up service", "error", err)
+			os.Exit(1)
+		}
+		stacks = append(stacks, st)
+		router = st.handler.Router()
+	} else {
+		var tenantRouter *tenant.Router
+		for _, t := range tenants {
+			files := stackFiles{
+				portfolios:      t.PortfoliosFile,
+				alertRules:      t.AlertRulesFile,
+				reportSchedules: t.ReportSchedulesFile,
+				coldPrefix:      "tenants/" + t.Name,
+				tenant:          t.Name,
+				heartbeatURL:    t.HeartbeatURL,
+				heartbeatFail:   t.HeartbeatFailURL,
+			}
+			if cfg.DataDir != "" {
+				files.dataDir = filepath.Join(cfg.DataDir, "tenants", t.Name)
+			}
+			if cfg.ParquetExportDir != "" {
+				files.parquetDir = filepath.Join(cfg.ParquetExportDir, "tenants", t.Name)
+			}
+			st, err := newStack(sh, files)
+			if err != nil {
+				slog.Error("failed to set up tenant", "tenant", t.Name, "error", err)
+				os.Exit(1)
+			}
+			stacks = append(stacks, st)
+			if tenantRouter == nil {
+				// The health check and the UI are the same for every tenant
+				tenantRouter = tenant.NewRouter(st.handler.Router())
+			}
+			tenantRouter.Add(t, st.handler.Router())
+		}
+		router = tenantRouter
+		slog.Info("serving tenants", "count", len(tenants))
+	}
 
 	// Create HTTP server
 	srv := &http.Server{
-		Addr:         ":" + cfg.Port,
-		Handler:      handler.Router(),
+		Addr:         cfg.Addr,
+		Handler:      router,
 		ReadTimeout:  cfg.ServerReadTimeout,
 		WriteTimeout: cfg.ServerWriteTimeout,
 		IdleTimeout:  cfg.ServerIdleTimeout,
 	}
 
+	// Listen on TCP, a unix socket, or a socket passed by systemd
+	ln, err := listen(cfg.Addr)
+	if err != nil {
+		slog.Error("failed to listen", "addr", cfg.Addr, "error", err)
+		os.Exit(1)
+	}
+
 	// Start server in goroutine
 	go func() {
-		slog.Info("server listening", "addr", srv.Addr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		slog.Info("server listening", "addr", ln.Addr().String(), "network", ln.Addr().Network())
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
 			slog.Error("server error", "error", err)
 			os.Exit(1)
 		}
@@ -75,15 +320,43 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
+	signal.Stop(quit)
 
-	slog.Info("shutting down server...")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	slog.Info("shutting down server...", "drainTimeout", cfg.ShutdownDrainTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownDrainTimeout)
 	defer cancel()
 
+	// Stop accepting connections and let in-flight requests finish
 	if err := srv.Shutdown(ctx); err != nil {
 		slog.Error("server shutdown error", "error", err)
-		os.Exit(1)
+	}
+
+	// Stop the background jobs, then let queued upstream work finish
+	stopBackground()
+	for _, st := range stacks {
+		if err := st.service.Drain(ctx); err != nil {
+			slog.Warn("request queue not drained", "error", err)
+		}
+	}
+	drained := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		slog.Warn("background jobs still running at shutdown")
+	}
+
+	// Flush state kept in memory
+	for _, st := range stacks {
+		if st.cachePath == "" {
+			continue
+		}
+		if err := st.service.SaveCache(st.cachePath); err != nil {
+			slog.Error("failed to save cache", "error", err)
+		}
 	}
 
 	slog.Info("server stopped")
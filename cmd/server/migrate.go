@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/backup"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/config"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/tenant"
+)
+
+// storeMigrator is the migrator of a history store, with the data directory a
+// file store lives in.
+type storeMigrator struct {
+	store.Migrator
+	name    string // For logs
+	dataDir string // Empty for the database
+}
+
+// storeMigrators returns the migrators of the configured history stores: the
+// database, or the file store of every stack.
+func storeMigrators(cfg *config.Config, database *sql.DB, tenants []tenant.Tenant) []storeMigrator {
+	if database != nil {
+		return []storeMigrator{{Migrator: store.NewPostgresMigrator(database), name: "database"}}
+	}
+	if cfg.DataDir == "" {
+		return nil
+	}
+	if len(tenants) == 0 {
+		return []storeMigrator{{Migrator: store.NewFileMigrator(cfg.DataDir), name: "files", dataDir: cfg.DataDir}}
+	}
+	var migrators []storeMigrator
+	for _, t := range tenants {
+		dir := filepath.Join(cfg.DataDir, "tenants", t.Name)
+		migrators = append(migrators, storeMigrator{Migrator: store.NewFileMigrator(dir), name: "files/" + t.Name, dataDir: dir})
+	}
+	return migrators
+}
+
+// migrateStores applies the pending migrations of the history stores if apply
+// is set, and fails if any are pending otherwise. It runs before the stores
+// are opened.
+func migrateStores(ctx context.Context, migrators []storeMigrator, apply bool) error {
+	for _, m := range migrators {
+		// Migrate the files of an import staged by an older version along with the rest
+		if m.dataDir != "" {
+			applied, err := backup.ApplyStaged(m.dataDir)
+			if err != nil {
+				return err
+			}
+			if applied {
+				slog.Info("applied imported state", "dir", m.dataDir)
+			}
+		}
+
+		if !apply {
+			pending, err := m.Pending(ctx)
+			if err != nil {
+				return fmt.Errorf("%s: %w", m.name, err)
+			}
+			if len(pending) > 0 {
+				return fmt.Errorf("%s: migrations %s pending, run with --migrate", m.name, strings.Join(pending, ", "))
+			}
+			continue
+		}
+
+		applied, err := m.Migrate(ctx)
+		for _, version := range applied {
+			slog.Info("applied storage migration", "store", m.name, "version", version)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", m.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/alerts"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/anomaly"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/api"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/audit"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/backup"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/budget"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/config"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/diversity"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/eigenlayer"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ens"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/exits"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/export"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/heartbeat"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/jobs"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/labels"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/metrics"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/mqtt"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/notes"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/objectstore"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/price"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/relays"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/reports"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/service"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ssv"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/vcmetrics"
+)
+
+// shared holds the components every stack uses: the upstream client with its
+// rate limit and credit budget, and the data that is the same for all operators.
+type shared struct {
+	cfg              *config.Config
+	client           beaconcha.Provider
+	budget           *budget.Manager
+	anomalyFilter    *anomaly.Filter
+	networkDiversity *diversity.Network
+	prices           *price.Service
+	names            *ens.Resolver
+	restaking        *eigenlayer.Checker
+	relays           *relays.Checker
+	database         *sql.DB // Shared snapshot history; nil keeps it in the data directory
+	runBackground    func(job func(ctx context.Context))
+}
+
+// stackFiles locates the configuration and data of a stack.
+type stackFiles struct {
+	portfolios      string
+	alertRules      string
+	reportSchedules string
+	dataDir         string // Empty keeps all state in memory
+	parquetDir      string // Empty disables parquet exports
+	coldPrefix      string // Key prefix of archived history in cold storage
+	tenant          string // Empty without tenants
+	heartbeatURL    string // Empty disables the heartbeat
+	heartbeatFail   string
+}
+
+// stack serves one operator: the whole deployment without tenants, or a tenant.
+type stack struct {
+	service   *service.ValidatorService
+	handler   *api.Handler
+	fileStore *store.FileStore
+	audit     *audit.Log
+	cachePath string
+}
+
+// newStack builds the service and handler of an operator, loading its state from
+// files.dataDir and starting its background jobs.
+func newStack(sh shared, files stackFiles) (_ *stack, err error) {
+	cfg := sh.cfg
+	st := &stack{}
+	defer func() {
+		if err != nil {
+			st.close()
+		}
+	}()
+
+	// Move a state import staged by POST /admin/import into place before loading state
+	if files.dataDir != "" {
+		applied, err := backup.ApplyStaged(files.dataDir)
+		if err != nil {
+			return nil, err
+		}
+		if applied {
+			slog.Info("applied imported state", "dir", files.dataDir)
+		}
+	}
+
+	// Load the portfolios shown on the dashboard
+	portfolios, err := portfolio.LoadFile(files.portfolios)
+	if err != nil {
+		return nil, fmt.Errorf("load portfolios: %w", err)
+	}
+	if files.dataDir != "" {
+		if err := portfolios.LoadImports(filepath.Join(files.dataDir, "imported-validators.json")); err != nil {
+			return nil, fmt.Errorf("load portfolios: %w", err)
+		}
+	}
+
+	// Open the snapshot history store: the shared database if configured, the data
+	// directory otherwise
+	var snapshotStore store.Store
+	if sh.database != nil {
+		snapshotStore = store.NewPostgresStore(sh.database, files.tenant)
+	} else if files.dataDir != "" {
+		fileStore, err := store.NewFileStore(files.dataDir)
+		if err != nil {
+			return nil, fmt.Errorf("open snapshot store: %w", err)
+		}
+		st.fileStore = fileStore
+		snapshotStore = fileStore
+	}
+
+	// Move aged history to cold storage to keep local disk usage bounded
+	var cold store.ObjectStorage
+	if cfg.ColdStorageEndpoint != "" && st.fileStore != nil {
+		cold = objectstore.NewClient(
+			cfg.ColdStorageEndpoint,
+			cfg.ColdStorageBucket,
+			cfg.ColdStorageRegion,
+			cfg.ColdStorageAccessKey,
+			cfg.ColdStorageSecretKey,
+			5*time.Minute,
+		)
+		if files.coldPrefix != "" {
+			cold = store.WithPrefix(cold, files.coldPrefix)
+		}
+		fileStore := st.fileStore
+		fileStore.SetColdStorage(cold, cfg.LocalRetentionMonths)
+		sh.runBackground(func(ctx context.Context) { fileStore.RunArchiver(ctx, cfg.ColdStorageArchiveInterval) })
+	}
+
+	if files.parquetDir != "" && snapshotStore != nil {
+		exporter, err := export.NewParquetExporter(snapshotStore, files.parquetDir)
+		if err != nil {
+			return nil, fmt.Errorf("create parquet exporter: %w", err)
+		}
+		if cold != nil {
+			exporter.SetColdStorage(cold, cfg.LocalRetentionMonths)
+		}
+		sh.runBackground(func(ctx context.Context) { exporter.Run(ctx, cfg.ParquetExportInterval) })
+	}
+
+	// Follow the operator registries, keystore directories and SSV operators of portfolios
+	if portfolios.HasRegistries() {
+		syncer := portfolio.NewRegistrySyncer(portfolios, sh.client, snapshotStore, sh.names)
+		sh.runBackground(func(ctx context.Context) { syncer.Run(ctx, cfg.RegistrySyncInterval) })
+	}
+	if portfolios.HasKeystores() {
+		scanner := portfolio.NewKeystoreSyncer(portfolios, sh.client)
+		sh.runBackground(func(ctx context.Context) { scanner.Run(ctx, cfg.KeystoreScanInterval) })
+	}
+	if portfolios.HasSSVOperators() {
+		syncer := portfolio.NewSSVSyncer(portfolios, sh.client, ssv.NewClient(cfg.SSVAPIURL, 30*time.Second))
+		sh.runBackground(func(ctx context.Context) { syncer.Run(ctx, cfg.SSVSyncInterval) })
+	}
+
+	// Initialize validator service
+	validatorService := service.NewValidatorService(sh.client, sh.anomalyFilter, snapshotStore, sh.prices, portfolios, sh.names)
+	validatorService.SetNetworkDiversity(sh.networkDiversity)
+	if sh.relays != nil {
+		validatorService.SetRelays(sh.relays, cfg.MEVRegistrationMaxAge)
+	}
+	if sh.restaking != nil {
+		validatorService.SetRestaking(sh.restaking)
+	}
+	validatorService.SetBudget(sh.budget)
+	validatorService.SetCacheLimits(cfg.CacheMaxEntries, int64(cfg.CacheMaxBytes))
+	validatorService.SetSLAWindows(cfg.SLAWindows)
+	validatorService.SetHealthWeights(cfg.HealthWeights)
+	validatorService.SetRewardAnomalies(service.RewardAnomalyConfig{
+		Window:    cfg.RewardAnomalyWindow,
+		Baseline:  cfg.RewardAnomalyBaseline,
+		Threshold: cfg.RewardAnomalyThreshold,
+	})
+	st.service = validatorService
+
+	// Write the snapshots of every fetch to InfluxDB
+	if cfg.InfluxDBURL != "" {
+		influx := export.NewInfluxExporter(cfg.InfluxDBURL, cfg.InfluxDBOrg, cfg.InfluxDBBucket, cfg.InfluxDBToken, 10*time.Second)
+		if files.tenant != "" {
+			influx.SetTags(map[string]string{"tenant": files.tenant})
+		}
+		validatorService.SetSnapshotExporter(influx)
+		sh.runBackground(influx.Run)
+	}
+
+	// Keep the caches on disk so a restart does not refetch the whole fleet
+	if files.dataDir != "" && cfg.CacheSnapshotInterval > 0 {
+		cachePath := filepath.Join(files.dataDir, "cache.json")
+		if err := validatorService.LoadCache(cachePath); err != nil {
+			// A lost cache only costs upstream requests
+			slog.Warn("failed to load cache", "error", err)
+		}
+		sh.runBackground(func(ctx context.Context) {
+			validatorService.RunCacheSnapshots(ctx, cachePath, cfg.CacheSnapshotInterval)
+		})
+		st.cachePath = cachePath
+	}
+
+	// Keep validator labels, notes and pre-signed exits next to the snapshots so they survive restarts
+	if files.dataDir != "" {
+		labelStore := labels.NewStore()
+		if err := labelStore.Load(filepath.Join(files.dataDir, "labels.json")); err != nil {
+			return nil, fmt.Errorf("load labels: %w", err)
+		}
+		validatorService.SetLabels(labelStore)
+
+		noteStore := notes.NewStore()
+		if err := noteStore.Load(filepath.Join(files.dataDir, "notes.json")); err != nil {
+			return nil, fmt.Errorf("load notes: %w", err)
+		}
+		validatorService.SetNotes(noteStore)
+
+		exitStore := exits.NewStore()
+		if err := exitStore.Load(filepath.Join(files.dataDir, "exits.json")); err != nil {
+			return nil, fmt.Errorf("load pre-signed exits: %w", err)
+		}
+		validatorService.SetExits(exitStore)
+	}
+
+	// Check alert rules against the portfolios
+	alertConfig, err := alerts.LoadFile(files.alertRules)
+	if err != nil {
+		return nil, fmt.Errorf("load alert rules: %w", err)
+	}
+	notifiers, err := alerts.NewNotifiers(alertConfig.Channels, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("configure alert channels: %w", err)
+	}
+	alertEngine := alerts.NewEngine(portfolios, validatorService, notifiers)
+	if files.dataDir != "" {
+		if err := alertEngine.LoadHistory(filepath.Join(files.dataDir, "alerts.json")); err != nil {
+			return nil, fmt.Errorf("load alert history: %w", err)
+		}
+	}
+	for _, rule := range alertConfig.Rules {
+		if err := alertEngine.SetRule(rule); err != nil {
+			return nil, fmt.Errorf("load alert rule %q: %w", rule.Name, err)
+		}
+	}
+	sh.runBackground(func(ctx context.Context) { alertEngine.Run(ctx, cfg.AlertCheckInterval) })
+
+	// Flag portfolio validators whose attestations conflict, for the doppelganger metric
+	if cfg.DoppelgangerCheckInterval > 0 && len(portfolios.All()) > 0 {
+		sh.runBackground(func(ctx context.Context) { validatorService.RunDoppelgangerChecks(ctx, cfg.DoppelgangerCheckInterval) })
+	}
+
+	// Compare the signing of validator clients with the attestations included on chain
+	var clientMetrics *vcmetrics.Correlator
+	if portfolios.HasClientMetrics() {
+		clientMetrics = vcmetrics.NewCorrelator(portfolios, sh.client, 10*time.Second)
+		sh.runBackground(func(ctx context.Context) { clientMetrics.Run(ctx, cfg.ClientMetricsInterval) })
+	}
+
+	// Push portfolio metrics to Prometheus for setups it cannot scrape
+	var pushers []metrics.Pusher
+	if cfg.MetricsPushgatewayURL != "" {
+		pushers = append(pushers, metrics.NewPushgatewayPusher(cfg.MetricsPushgatewayURL, cfg.MetricsPushJob, 10*time.Second))
+	}
+	if cfg.MetricsRemoteWriteURL != "" {
+		pushers = append(pushers, metrics.NewRemoteWritePusher(cfg.MetricsRemoteWriteURL, cfg.MetricsPushJob, 10*time.Second))
+	}
+	if len(pushers) > 0 && len(portfolios.All()) > 0 {
+		publisher := metrics.NewPublisher(portfolios, validatorService, pushers...)
+		if files.tenant != "" {
+			publisher.SetLabels([]metrics.Label{{Name: "tenant", Value: files.tenant}})
+		}
+		sh.runBackground(func(ctx context.Context) { publisher.Run(ctx, cfg.MetricsPushInterval) })
+	}
+
+	// Publish validator states and events to MQTT for home automation
+	if cfg.MQTTBrokerURL != "" && len(portfolios.All()) > 0 {
+		client, err := mqtt.NewClient(cfg.MQTTBrokerURL, 10*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("create mqtt client: %w", err)
+		}
+		prefix := cfg.MQTTTopicPrefix
+		if files.tenant != "" {
+			prefix += "/" + files.tenant
+		}
+		publisher := mqtt.NewPublisher(portfolios, validatorService, client, prefix)
+		sh.runBackground(func(ctx context.Context) { publisher.Run(ctx, cfg.MQTTPublishInterval) })
+	}
+
+	// Ping a dead man's switch while the service runs and the fleet is healthy
+	if files.heartbeatURL != "" {
+		monitor := heartbeat.NewMonitor(portfolios, validatorService, files.heartbeatURL, files.heartbeatFail, cfg.HeartbeatMaxOffline, 10*time.Second)
+		sh.runBackground(func(ctx context.Context) { monitor.Run(ctx, cfg.HeartbeatInterval) })
+	}
+
+	// Generate recurring portfolio reports and deliver them over the alert channels
+	reportConfig, err := reports.LoadFile(files.reportSchedules)
+	if err != nil {
+		return nil, fmt.Errorf("load report schedules: %w", err)
+	}
+	reportScheduler, err := reports.NewScheduler(reportConfig, portfolios, notifiers, validatorService)
+	if err != nil {
+		return nil, fmt.Errorf("configure report schedules: %w", err)
+	}
+	reportScheduler.SetAlerts(alertEngine)
+	if files.dataDir != "" {
+		if err := reportScheduler.LoadReports(filepath.Join(files.dataDir, "reports.json")); err != nil {
+			return nil, fmt.Errorf("load reports: %w", err)
+		}
+	}
+	sh.runBackground(reportScheduler.Run)
+
+	// Fetch the portfolios in the background so the first requests find them cached
+	if len(cfg.CacheWarmRanges) > 0 {
+		sh.runBackground(func(ctx context.Context) { validatorService.WarmCache(ctx, cfg.CacheWarmRanges) })
+	}
+
+	// Backfill the history of portfolio validators while the queue is idle
+	if snapshotStore != nil && cfg.BackfillDays > 0 && len(portfolios.All()) > 0 {
+		sh.runBackground(func(ctx context.Context) {
+			validatorService.RunBackfill(ctx, cfg.BackfillDays, cfg.BackfillInterval)
+		})
+	}
+
+	// Run reports over large fleets in the background
+	jobManager := jobs.NewManager(cfg.JobRetention, cfg.MaxQueuedJobs)
+	sh.runBackground(jobManager.Run)
+
+	// Initialize API handler
+	st.handler = api.NewHandler(validatorService, cfg)
+	st.handler.SetAlerts(alertEngine)
+	st.handler.SetJobs(jobManager)
+	st.handler.SetReports(reportScheduler)
+	if clientMetrics != nil {
+		st.handler.SetClientMetrics(clientMetrics)
+	}
+
+	// Record the changes made through the API next to the snapshots
+	if files.dataDir != "" {
+		st.audit, err = audit.Open(filepath.Join(files.dataDir, "audit.jsonl"))
+		if err != nil {
+			return nil, err
+		}
+		st.handler.SetAudit(st.audit)
+
+		// Export the state for moving to another machine, with the portfolios and alert
+		// rules, which live outside the data directory, for reference
+		archive := backup.New(files.dataDir)
+		if st.fileStore != nil {
+			archive.SetFreeze(st.fileStore.Freeze)
+		}
+		if files.portfolios != "" {
+			archive.AddConfig("portfolios.json", func() ([]byte, error) { return os.ReadFile(files.portfolios) })
+		}
+		archive.AddConfig("alert-rules.json", func() ([]byte, error) {
+			rules := []models.AlertRule{}
+			for _, r := range alertEngine.Rules() {
+				rules = append(rules, r.AlertRule)
+			}
+			// Channels are left out, their settings hold credentials
+			return json.MarshalIndent(alerts.Config{Rules: rules}, "", "  ")
+		})
+		st.handler.SetBackup(archive)
+	}
+	return st, nil
+}
+
+// close closes the snapshot store and the audit log of the stack.
+func (st *stack) close() {
+	if st.fileStore != nil {
+		st.fileStore.Close()
+	}
+	if st.audit != nil {
+		st.audit.Close()
+	}
+}
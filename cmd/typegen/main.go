@@ -0,0 +1,37 @@
+// Command typegen writes the TypeScript declarations of the API models.
+//
+// It is run through go generate in internal/web, which serves the result at /types.ts:
+//
+//	go generate ./internal/web
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/tsgen"
+)
+
+func main() {
+	in := flag.String("in", "internal/models/api.go", "Go source file with the API models")
+	out := flag.String("out", "internal/web/static/types.ts", "TypeScript file to write")
+	flag.Parse()
+
+	if err := run(*in, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "typegen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out string) error {
+	src, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	ts, err := tsgen.Generate(in, src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, ts, 0o644)
+}
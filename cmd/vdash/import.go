@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/config"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ratelimiter"
+)
+
+// importDashboards creates portfolios from the validator dashboards of a
+// beaconcha.in account and returns the exit code.
+func importDashboards(args []string) int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "vdash: load configuration:", err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("import-dashboards", flag.ExitOnError)
+	apiKey := fs.String("api-key", cfg.BeaconchainAPIKey, "API key of the beaconcha.in account whose dashboards are imported")
+	path := fs.String("portfolios", cfg.PortfoliosFile, "portfolios file the dashboards are added to (required)")
+	splitGroups := fs.Bool("groups", false, "create a portfolio per dashboard group instead of per dashboard")
+	dryRun := fs.Bool("dry-run", false, "list the portfolios without writing them")
+	fs.Parse(args)
+
+	if *apiKey == "" {
+		fmt.Fprintln(os.Stderr, "vdash: -api-key is required")
+		return 2
+	}
+	if *path == "" && !*dryRun {
+		fmt.Fprintln(os.Stderr, "vdash: -portfolios is required")
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	client := beaconcha.NewClient(cfg.BeaconchainBaseURL, *apiKey, cfg.BeaconchainAPIVersion,
+		ratelimiter.NewGlobalRateLimiter(cfg.BeaconchainRateLimit), cfg.BeaconchainTimeout)
+	portfolios, skipped, err := portfolio.ImportDashboards(ctx, client, *splitGroups)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "vdash:", err)
+		return 1
+	}
+	for _, reason := range skipped {
+		fmt.Fprintln(os.Stderr, "skipped", reason)
+	}
+	if *dryRun {
+		for _, p := range portfolios {
+			fmt.Printf("%s\t%s\t%d validators\n", p.Name, p.Chain, len(p.ValidatorIds))
+		}
+		return 0
+	}
+
+	added, err := portfolio.MergeFile(*path, portfolios)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "vdash:", err)
+		return 1
+	}
+	isAdded := make(map[string]bool, len(added))
+	for _, name := range added {
+		isAdded[name] = true
+	}
+	for _, p := range portfolios {
+		if isAdded[p.Name] {
+			fmt.Printf("added\t%s\t%s\t%d validators\n", p.Name, p.Chain, len(p.ValidatorIds))
+		} else {
+			fmt.Printf("exists\t%s\n", p.Name)
+		}
+	}
+	if len(added) > 0 {
+		fmt.Printf("\nRestart the server to load the %d new portfolios from %s\n", len(added), *path)
+	}
+	return 0
+}
@@ -0,0 +1,203 @@
+// Package main is the entry point for vdash, a command line client for validator data.
+//
+// vdash either queries a running validator-dashboard server (-server or VDASH_SERVER,
+// with -api-key or VDASH_API_KEY when it serves tenants) or talks to Beaconcha directly using the same client and service as the server.
+//
+// Usage:
+//
+//	vdash <overview|rewards|performance|watch> -ids 1,2,3 [-chain mainnet] [-range 7d] [-server URL [-api-key KEY]] [-o table|json]
+//	vdash import-dashboards -api-key KEY -portfolios portfolios.json [-groups]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+const usage = `Usage: vdash <command> [flags]
+
+Commands:
+  overview      Per-validator status and balances
+  rewards       Aggregated rewards for all validators
+  performance   Aggregated performance for all validators
+  watch         Print the overview after every epoch
+
+  import-dashboards  Add the dashboards of a beaconcha.in account to a portfolios file
+
+Run "vdash <command> -h" for the flags of a command.
+`
+
+// options holds the flags shared by all commands.
+type options struct {
+	ids        string
+	chain      string
+	evalRange  string
+	server     string
+	apiKey     string
+	output     string
+	interval   time.Duration
+	epochDelay time.Duration
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command := os.Args[1]
+	if command == "-h" || command == "--help" || command == "help" {
+		fmt.Print(usage)
+		return
+	}
+
+	if command == "import-dashboards" {
+		os.Exit(importDashboards(os.Args[2:]))
+	}
+
+	var render func(io.Writer, models.ValidatorResponse, string) error
+	switch command {
+	case "overview", "watch":
+		render = renderOverview
+	case "rewards":
+		render = renderRewards
+	case "performance":
+		render = renderPerformance
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+
+	opts := options{}
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	fs.StringVar(&opts.ids, "ids", "", "comma-separated validator indices (required)")
+	fs.StringVar(&opts.chain, "chain", "mainnet", "chain: mainnet or hoodi")
+	fs.StringVar(&opts.evalRange, "range", "all_time", "evaluation window: 24h, 7d, 30d, 90d, all_time")
+	fs.StringVar(&opts.server, "server", os.Getenv("VDASH_SERVER"), "validator-dashboard server URL; queries Beaconcha directly when empty")
+	fs.StringVar(&opts.apiKey, "api-key", os.Getenv("VDASH_API_KEY"), "API key of the server's tenant, if it serves tenants")
+	fs.StringVar(&opts.output, "o", "table", "output format: table or json")
+	if command == "watch" {
+		fs.DurationVar(&opts.interval, "interval", 0, "fixed refresh interval; 0 refreshes after every epoch")
+		fs.DurationVar(&opts.epochDelay, "epoch-delay", time.Minute, "wait after each epoch ends before refreshing, so upstream has processed it")
+	}
+	fs.Parse(os.Args[2:])
+
+	req, err := buildRequest(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "vdash:", err)
+		os.Exit(2)
+	}
+	if opts.output != "table" && opts.output != "json" {
+		fmt.Fprintln(os.Stderr, "vdash: -o must be one of: table, json")
+		os.Exit(2)
+	}
+
+	src, err := newSource(opts.server, opts.apiKey)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "vdash:", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if command == "watch" {
+		err = watch(ctx, src, req, opts, render)
+	} else {
+		err = run(ctx, src, req, opts.output, render)
+	}
+	if err != nil && ctx.Err() == nil {
+		fmt.Fprintln(os.Stderr, "vdash:", err)
+		os.Exit(1)
+	}
+}
+
+// buildRequest parses and validates the shared flags.
+func buildRequest(opts options) (models.ValidatorRequest, error) {
+	if opts.ids == "" {
+		return models.ValidatorRequest{}, fmt.Errorf("-ids is required")
+	}
+
+	var ids []int
+	for _, part := range strings.Split(opts.ids, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		if err != nil || id < 0 {
+			return models.ValidatorRequest{}, fmt.Errorf("invalid validator ID: %s", part)
+		}
+		ids = append(ids, id)
+	}
+
+	return models.ValidatorRequest{
+		ValidatorIds:     ids,
+		Chain:            opts.chain,
+		Range:            opts.evalRange,
+		ExcludeAnomalies: opts.chain == "hoodi",
+	}, nil
+}
+
+// run fetches the data once and renders it.
+func run(ctx context.Context, src source, req models.ValidatorRequest, output string, render func(io.Writer, models.ValidatorResponse, string) error) error {
+	resp, err := src.Fetch(ctx, req)
+	if err != nil {
+		return err
+	}
+	return render(os.Stdout, resp, output)
+}
+
+// watch fetches and renders the data until the context is canceled, either after
+// every epoch or every interval when one is set.
+func watch(ctx context.Context, src source, req models.ValidatorRequest, opts options, render func(io.Writer, models.ValidatorResponse, string) error) error {
+	refresh := func(label string) {
+		if opts.output == "table" {
+			fmt.Printf("%s\n\n", label)
+		}
+		if err := run(ctx, src, req, opts.output, render); err != nil {
+			// Keep watching through transient upstream errors
+			fmt.Fprintln(os.Stderr, "vdash:", err)
+		}
+		if opts.output == "table" {
+			fmt.Println()
+		}
+	}
+
+	refresh(time.Now().Format(time.RFC3339))
+
+	if opts.interval > 0 {
+		ticker := time.NewTicker(opts.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				refresh(time.Now().Format(time.RFC3339))
+			}
+		}
+	}
+
+	// New data only becomes available once an epoch ends
+	spec, err := chainspec.ForChain(req.Chain)
+	if err != nil {
+		return err
+	}
+	for epoch := range spec.EpochTicker(ctx, opts.epochDelay) {
+		refresh(fmt.Sprintf("%s  epoch %d", time.Now().Format(time.RFC3339), epoch))
+	}
+	return ctx.Err()
+}
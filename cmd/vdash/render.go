@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// weiPerEth is the number of wei in one ETH.
+var weiPerEth = new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+
+// renderOverview prints the per-validator overview.
+func renderOverview(w io.Writer, resp models.ValidatorResponse, output string) error {
+	if output == "json" {
+		return writeJSON(w, resp.Validators)
+	}
+
+	ids := make([]int, 0, len(resp.Validators))
+	for id := range resp.Validators {
+		if i, err := strconv.Atoi(id); err == nil {
+			ids = append(ids, i)
+		}
+	}
+	sort.Ints(ids)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "INDEX\tSTATUS\tONLINE\tSLASHED\tBALANCE (ETH)\tEFFECTIVE (ETH)\tACTIVATION\tEXIT")
+	for _, id := range ids {
+		v := resp.Validators[strconv.Itoa(id)]
+		fmt.Fprintf(tw, "%d\t%s\t%t\t%t\t%s\t%s\t%d\t%d\n",
+			id, v.Status, v.Online, v.Slashed,
+			formatEth(v.CurrentBalance, 5), formatEth(v.EffectiveBalance, 2),
+			v.ActivationEpoch, v.ExitEpoch)
+	}
+	return tw.Flush()
+}
+
+// renderRewards prints the aggregated rewards.
+func renderRewards(w io.Writer, resp models.ValidatorResponse, output string) error {
+	if output == "json" {
+		return writeJSON(w, resp.Rewards)
+	}
+
+	r := resp.Rewards
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	rows := [][2]string{
+		{"Net rewards", r.Total},
+		{"Rewards", r.TotalReward},
+		{"Penalties", r.TotalPenalty},
+		{"Missed", r.TotalMissed},
+		{"Attestations", r.Attestations.Total},
+		{"Proposals", r.Proposals.Total},
+		{"  Execution layer", r.Proposals.ExecutionLayerReward},
+		{"Sync committees", r.SyncCommittees.Total},
+	}
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%12s ETH\n", row[0], formatEth(row[1], 6))
+	}
+	return tw.Flush()
+}
+
+// renderPerformance prints the aggregated performance.
+func renderPerformance(w io.Writer, resp models.ValidatorResponse, output string) error {
+	if output == "json" {
+		return writeJSON(w, resp.Performance)
+	}
+
+	p := resp.Performance
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "BeaconScore\t%s\n", formatScore(p.Beaconscore))
+	fmt.Fprintf(tw, "Attestations\t%d/%d included, %d missed, avg delay %.3f (%s)\n",
+		p.Attestations.Included, p.Attestations.Assigned, p.Attestations.Missed,
+		p.Attestations.AvgInclusionDelay, formatScore(p.Attestations.Beaconscore))
+	fmt.Fprintf(tw, "Proposals\t%d/%d successful, %d missed (%s)\n",
+		p.Proposals.Successful, p.Proposals.Assigned, p.Proposals.Missed, formatScore(p.Proposals.Beaconscore))
+	fmt.Fprintf(tw, "Sync committees\t%d/%d successful, %d missed (%s)\n",
+		p.SyncCommittees.Successful, p.SyncCommittees.Assigned, p.SyncCommittees.Missed, formatScore(p.SyncCommittees.Beaconscore))
+	return tw.Flush()
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// formatEth converts a wei string into ETH with the given number of decimals.
+func formatEth(wei string, decimals int) string {
+	v, ok := new(big.Int).SetString(wei, 10)
+	if !ok {
+		return "-"
+	}
+	eth := new(big.Float).Quo(new(big.Float).SetInt(v), weiPerEth)
+	return eth.Text('f', decimals)
+}
+
+// formatScore formats a BeaconScore as a percentage.
+func formatScore(score *float64) string {
+	if score == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", *score*100)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/anomaly"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/config"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ratelimiter"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/service"
+	"github.com/Marketen/validator-dashboard-beaconcha/pkg/client"
+)
+
+// source fetches validator data either from a running server or from Beaconcha directly.
+type source interface {
+	Fetch(ctx context.Context, req models.ValidatorRequest) (models.ValidatorResponse, error)
+}
+
+// newSource returns a server source when serverURL is set, authenticating with
+// apiKey if set, and a direct Beaconcha source otherwise.
+func newSource(serverURL, apiKey string) (source, error) {
+	if serverURL != "" {
+		c := client.New(serverURL, 5*time.Minute)
+		c.SetAPIKey(apiKey)
+		return &serverSource{client: c}, nil
+	}
+
+	// Direct mode uses the same environment variables as the server
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load configuration: %w", err)
+	}
+	anomalyFilter, err := anomaly.LoadFile(cfg.AnomalyWindowsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	client := beaconcha.NewClient(
+		cfg.BeaconchainBaseURL,
+		cfg.BeaconchainAPIKey,
+		cfg.BeaconchainAPIVersion,
+		ratelimiter.NewGlobalRateLimiter(cfg.BeaconchainRateLimit),
+		cfg.BeaconchainTimeout,
+	)
+	if cfg.BeaconchaReplayDir != "" {
+		replay, err := beaconcha.NewReplayTransport(cfg.BeaconchaReplayDir, cfg.BeaconchaReplayMode, nil)
+		if err != nil {
+			return nil, err
+		}
+		client.SetTransport(replay)
+	}
+
+	return &directSource{service: service.NewValidatorService(client, anomalyFilter, nil, nil, nil, nil)}, nil
+}
+
+// serverSource queries GET /validator on a running validator-dashboard server.
+type serverSource struct {
+	client *client.Client
+}
+
+// Fetch implements source.
+func (s *serverSource) Fetch(ctx context.Context, req models.ValidatorRequest) (models.ValidatorResponse, error) {
+	return s.client.GetValidators(ctx, req)
+}
+
+// directSource queries Beaconcha directly through the validator service.
+type directSource struct {
+	service *service.ValidatorService
+}
+
+// Fetch implements source.
+func (s *directSource) Fetch(ctx context.Context, req models.ValidatorRequest) (models.ValidatorResponse, error) {
+	return s.service.GetValidatorData(ctx, req)
+}
@@ -0,0 +1,220 @@
+package alerts
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// Metrics a condition can compare.
+const (
+	MetricBeaconscore        = "beaconscore"         // Portfolio BeaconScore over the rule's range, 0-1
+	MetricBalanceDrop        = "balance_drop"        // Largest balance loss of a validator since the previous check, in ETH
+	MetricMissedProposals    = "missed_proposals"    // Missed block proposals over the rule's range
+	MetricMissedAttestations = "missed_attestations" // Missed attestations over the rule's range
+	MetricMissedSync         = "missed_sync"         // Missed sync committee duties over the rule's range
+	MetricOffline            = "offline"             // Active validators currently offline
+	MetricSlashed            = "slashed"             // Validators slashed
+	MetricDoppelganger       = "doppelganger"        // Validators with conflicting attestations
+	MetricRewardAnomalies    = "reward_anomalies"    // Validators whose recent reward rate deviates
+)
+
+var metrics = []string{
+	MetricBeaconscore,
+	MetricBalanceDrop,
+	MetricMissedProposals,
+	MetricMissedAttestations,
+	MetricMissedSync,
+	MetricOffline,
+	MetricSlashed,
+	MetricDoppelganger,
+	MetricRewardAnomalies,
+}
+
+var operators = []string{"<", "<=", ">", ">=", "==", "!="}
+
+// Condition compares a metric with a threshold, and holds once the comparison
+// was true for For consecutive checks.
+type Condition struct {
+	Metric    string
+	Operator  string
+	Threshold float64
+	For       int
+}
+
+// ParseCondition parses a condition of the form
+// "<metric> <operator> <threshold> [ETH] [for <n> checks]", e.g.
+// "beaconscore < 0.9 for 3 checks" or "balance drop > 0.01 ETH". Words of the
+// metric may be separated by spaces or underscores.
+func ParseCondition(s string) (Condition, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	op := slices.IndexFunc(fields, func(f string) bool { return slices.Contains(operators, f) })
+	if op < 1 || op+1 >= len(fields) {
+		return Condition{}, fmt.Errorf("invalid condition %q: expected <metric> <operator> <threshold>", s)
+	}
+
+	c := Condition{Metric: strings.Join(fields[:op], "_"), Operator: fields[op], For: 1}
+	if !slices.Contains(metrics, c.Metric) {
+		return Condition{}, fmt.Errorf("invalid condition %q: unknown metric %q, must be one of: %s", s, c.Metric, strings.Join(metrics, ", "))
+	}
+	threshold, err := strconv.ParseFloat(fields[op+1], 64)
+	if err != nil {
+		return Condition{}, fmt.Errorf("invalid condition %q: threshold must be a number", s)
+	}
+	c.Threshold = threshold
+
+	rest := fields[op+2:]
+	if len(rest) > 0 && rest[0] == "eth" {
+		if c.Metric != MetricBalanceDrop {
+			return Condition{}, fmt.Errorf("invalid condition %q: only %s is measured in ETH", s, MetricBalanceDrop)
+		}
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		if len(rest) != 3 || rest[0] != "for" || (rest[2] != "checks" && rest[2] != "check") {
+			return Condition{}, fmt.Errorf("invalid condition %q: expected \"for <n> checks\" after the threshold", s)
+		}
+		n, err := strconv.Atoi(rest[1])
+		if err != nil || n < 1 {
+			return Condition{}, fmt.Errorf("invalid condition %q: number of checks must be a positive integer", s)
+		}
+		c.For = n
+	}
+	return c, nil
+}
+
+// compare reports whether value satisfies the comparison.
+func (c Condition) compare(value float64) bool {
+	switch c.Operator {
+	case "<":
+		return value < c.Threshold
+	case "<=":
+		return value <= c.Threshold
+	case ">":
+		return value > c.Threshold
+	case ">=":
+		return value >= c.Threshold
+	case "==":
+		return value == c.Threshold
+	default:
+		return value != c.Threshold
+	}
+}
+
+// weiPerETH converts balances in wei to ETH.
+var weiPerETH = new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+
+// perValidator reports whether metric is measured per validator, rather than
+// aggregated over the portfolio upstream.
+func perValidator(metric string) bool {
+	return metric == MetricBalanceDrop || metric == MetricOffline || metric == MetricSlashed || metric == MetricDoppelganger ||
+		metric == MetricRewardAnomalies
+}
+
+// measure returns the value of metric in data and the validators contributing to
+// it, leaving out muted validators. balances holds the validator balances of the
+// previous check and is updated to the current ones. ok is false if data has no
+// value for the metric.
+func measure(metric string, data models.ValidatorResponse, balances map[string]*big.Int, muted map[string]bool) (value float64, validators []int, ok bool) {
+	switch metric {
+	case MetricBeaconscore:
+		if data.Performance.Beaconscore == nil {
+			return 0, nil, false
+		}
+		return *data.Performance.Beaconscore, nil, true
+	case MetricMissedProposals:
+		return float64(data.Performance.Proposals.Missed), nil, true
+	case MetricMissedAttestations:
+		return float64(data.Performance.Attestations.Missed), nil, true
+	case MetricMissedSync:
+		return float64(data.Performance.SyncCommittees.Missed), nil, true
+	case MetricOffline:
+		for id, v := range data.Validators {
+			if !v.Online && strings.HasPrefix(v.Status, "active") && !muted[id] {
+				validators = appendID(validators, id)
+			}
+		}
+		return float64(len(validators)), sortedIDs(validators), true
+	case MetricSlashed:
+		for id, v := range data.Validators {
+			if v.Slashed && !muted[id] {
+				validators = appendID(validators, id)
+			}
+		}
+		return float64(len(validators)), sortedIDs(validators), true
+	case MetricDoppelganger:
+		for id, v := range data.Validators {
+			if v.Doppelganger != nil && !muted[id] {
+				validators = appendID(validators, id)
+			}
+		}
+		return float64(len(validators)), sortedIDs(validators), true
+	case MetricRewardAnomalies:
+		if data.Anomalies != nil {
+			for _, a := range data.Anomalies.RewardRates {
+				if !muted[strconv.Itoa(a.ValidatorIndex)] {
+					validators = append(validators, a.ValidatorIndex)
+				}
+			}
+		}
+		return float64(len(validators)), validators, true
+	case MetricBalanceDrop:
+		return balanceDrop(data, balances, muted)
+	}
+	return 0, nil, false
+}
+
+// balanceDrop returns the largest loss of a validator's balance since the previous
+// check, in ETH. Withdrawals only sweep the balance above the effective balance,
+// so only losses below the lower of the previous and effective balance count.
+// Balances of muted validators are tracked without counting their losses.
+func balanceDrop(data models.ValidatorResponse, balances map[string]*big.Int, muted map[string]bool) (float64, []int, bool) {
+	first := len(balances) == 0
+	maxDrop := new(big.Int)
+	var validators []int
+	for id, v := range data.Validators {
+		current, ok := new(big.Int).SetString(v.CurrentBalance, 10)
+		if !ok {
+			continue
+		}
+		previous, seen := balances[id]
+		balances[id] = current
+		if !seen || muted[id] {
+			continue
+		}
+
+		floor := previous
+		if effective, ok := new(big.Int).SetString(v.EffectiveBalance, 10); ok && effective.Cmp(floor) < 0 {
+			floor = effective
+		}
+		drop := new(big.Int).Sub(floor, current)
+		if drop.Sign() > 0 {
+			validators = appendID(validators, id)
+			if drop.Cmp(maxDrop) > 0 {
+				maxDrop = drop
+			}
+		}
+	}
+	if first {
+		return 0, nil, false
+	}
+
+	eth, _ := new(big.Float).Quo(new(big.Float).SetInt(maxDrop), weiPerETH).Float64()
+	return eth, sortedIDs(validators), true
+}
+
+func appendID(ids []int, id string) []int {
+	if n, err := strconv.Atoi(id); err == nil {
+		ids = append(ids, n)
+	}
+	return ids
+}
+
+func sortedIDs(ids []int) []int {
+	slices.Sort(ids)
+	return ids
+}
@@ -0,0 +1,73 @@
+package alerts
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Condition
+		wantErr bool
+	}{
+		{input: "beaconscore < 0.9 for 3 checks", want: Condition{Metric: MetricBeaconscore, Operator: "<", Threshold: 0.9, For: 3}},
+		{input: "balance drop > 0.01 ETH", want: Condition{Metric: MetricBalanceDrop, Operator: ">", Threshold: 0.01, For: 1}},
+		{input: "missed_proposals >= 1", want: Condition{Metric: MetricMissedProposals, Operator: ">=", Threshold: 1, For: 1}},
+		{input: "Offline > 0 for 1 check", want: Condition{Metric: MetricOffline, Operator: ">", Threshold: 0, For: 1}},
+		{input: "reward anomalies > 0", want: Condition{Metric: MetricRewardAnomalies, Operator: ">", Threshold: 0, For: 1}},
+		{input: "beaconscore", wantErr: true},
+		{input: "uptime < 0.9", wantErr: true},
+		{input: "beaconscore < high", wantErr: true},
+		{input: "offline > 0 ETH", wantErr: true},
+		{input: "offline > 0 for 0 checks", wantErr: true},
+		{input: "offline > 0 for a while", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseCondition(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error: %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMeasure_BalanceDrop(t *testing.T) {
+	overview := func(balance string) models.ValidatorOverview {
+		return models.ValidatorOverview{CurrentBalance: balance, EffectiveBalance: "32000000000000000000"}
+	}
+	balances := make(map[string]*big.Int)
+
+	// The first check only records the balances
+	data := models.ValidatorResponse{Validators: map[string]models.ValidatorOverview{
+		"1": overview("32050000000000000000"),
+		"2": overview("32010000000000000000"),
+	}}
+	if _, _, ok := measure(MetricBalanceDrop, data, balances, nil); ok {
+		t.Fatal("expected no value on the first check")
+	}
+
+	// Validator 1 is swept down to its effective balance, validator 2 is penalized
+	data = models.ValidatorResponse{Validators: map[string]models.ValidatorOverview{
+		"1": overview("32000000000000000000"),
+		"2": overview("31980000000000000000"),
+	}}
+	value, validators, ok := measure(MetricBalanceDrop, data, balances, nil)
+	if !ok {
+		t.Fatal("expected a value on the second check")
+	}
+	if value != 0.02 {
+		t.Errorf("expected a drop of 0.02 ETH, got %g", value)
+	}
+	if !reflect.DeepEqual(validators, []int{2}) {
+		t.Errorf("expected validator 2, got %v", validators)
+	}
+}
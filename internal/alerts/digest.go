@@ -0,0 +1,89 @@
+package alerts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/units"
+)
+
+// digestTimeFormat formats the times of alerts and events in digests.
+const digestTimeFormat = "2006-01-02 15:04 UTC"
+
+// ReportDigest renders a report as a plain-text digest for chat and on-call
+// channels: earnings, uptime, misses, and the alerts and events of the period.
+// Lines without data, such as proposals when none were assigned, are left out.
+func ReportDigest(r models.PortfolioReport) string {
+	var b strings.Builder
+	period := "Daily"
+	if r.Period == "weekly" {
+		period = "Weekly"
+	}
+	fmt.Fprintf(&b, "%s digest of %s (%s), last %s\n", period, r.Portfolio, r.Chain, r.Range)
+	fmt.Fprintf(&b, "Earnings: %s ETH\n", units.Format(zeroIfEmpty(r.Rewards.Total), units.ETH))
+	fmt.Fprintf(&b, "Validators: %d, %d online, %d offline, %d slashed\n", r.Validators, r.Online, r.Offline, r.Slashed)
+	if r.Uptime != nil && r.Uptime.Uptime != nil {
+		fmt.Fprintf(&b, "Uptime: %s%%\n", formatPercent(*r.Uptime.Uptime))
+	}
+	p := r.Performance
+	if p.Beaconscore != nil {
+		fmt.Fprintf(&b, "BeaconScore: %s%%\n", formatPercent(100**p.Beaconscore))
+	}
+	if p.Attestations.Assigned > 0 {
+		fmt.Fprintf(&b, "Attestations: %d of %d missed\n", p.Attestations.Missed, p.Attestations.Assigned)
+	}
+	if p.Proposals.Assigned > 0 {
+		fmt.Fprintf(&b, "Proposals: %d of %d missed\n", p.Proposals.Missed, p.Proposals.Assigned)
+	}
+	if p.SyncCommittees.Assigned > 0 {
+		fmt.Fprintf(&b, "Sync committee duties: %d of %d missed\n", p.SyncCommittees.Missed, p.SyncCommittees.Assigned)
+	}
+
+	if len(r.Alerts) > 0 {
+		fmt.Fprintf(&b, "Alerts (%d):\n", len(r.Alerts))
+		for _, a := range r.Alerts {
+			fmt.Fprintf(&b, "- [%s] %s fired %s, %s\n", a.Severity, a.Rule, a.FiredAt.UTC().Format(digestTimeFormat), a.Status)
+		}
+	}
+	if len(r.Events) > 0 {
+		fmt.Fprintf(&b, "Events (%d):\n", len(r.Events))
+		for _, e := range r.Events {
+			fmt.Fprintf(&b, "- %s validator %d %s", e.Time.UTC().Format(digestTimeFormat), e.ValidatorIndex, e.Type)
+			if e.From != "" || e.To != "" {
+				fmt.Fprintf(&b, ": %s → %s", e.From, e.To)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// digestTitle is the one-line title of a report digest.
+func digestTitle(r models.PortfolioReport) string {
+	return fmt.Sprintf("%s report of %s: %s ETH earned, %d of %d validators online",
+		r.Period, r.Portfolio, units.Format(zeroIfEmpty(r.Rewards.Total), units.ETH), r.Online, r.Validators)
+}
+
+// formatPercent formats a percentage with up to two decimals.
+func formatPercent(v float64) string {
+	return strconv.FormatFloat(float64(int64(v*100+0.5))/100, 'f', -1, 64)
+}
+
+// zeroIfEmpty returns "0" for a missing amount.
+func zeroIfEmpty(wei string) string {
+	if wei == "" {
+		return "0"
+	}
+	return wei
+}
+
+// reportTime is the time of a report, for the on-call channels.
+func reportTime(r models.PortfolioReport) time.Time {
+	if r.GeneratedAt.IsZero() {
+		return time.Now().UTC()
+	}
+	return r.GeneratedAt
+}
@@ -0,0 +1,49 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestReportDigest(t *testing.T) {
+	uptime, score := 99.8765, 0.9812
+	fired := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	r := models.PortfolioReport{
+		Portfolio:       "home",
+		Chain:           "mainnet",
+		Period:          "daily",
+		Range:           "24h",
+		DashboardTotals: models.DashboardTotals{Validators: 10, Online: 9, Offline: 1},
+		Rewards:         models.ValidatorRewards{Total: "12300000000000000"},
+		Performance: models.ValidatorPerformance{
+			Beaconscore:  &score,
+			Attestations: models.AttestationDuties{Assigned: 2250, Missed: 3},
+			Proposals:    models.ProposalDuties{Assigned: 1},
+		},
+		Uptime: &models.Uptime{Uptime: &uptime},
+		Alerts: []models.Alert{{Rule: "offline", Severity: SeverityWarning, Status: "open", FiredAt: fired}},
+		Events: []models.DashboardEvent{{Time: fired.Add(-2 * time.Minute), ValidatorIndex: 4, Type: "online_changed", From: "online", To: "offline"}},
+	}
+
+	want := `Daily digest of home (mainnet), last 24h
+Earnings: 0.0123 ETH
+Validators: 10, 9 online, 1 offline, 0 slashed
+Uptime: 99.88%
+BeaconScore: 98.12%
+Attestations: 3 of 2250 missed
+Proposals: 0 of 1 missed
+Alerts (1):
+- [warning] offline fired 2026-03-01 09:30 UTC, open
+Events (1):
+- 2026-03-01 09:28 UTC validator 4 online_changed: online → offline`
+	if got := ReportDigest(r); got != want {
+		t.Errorf("unexpected digest:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Sections without data are left out
+	if got := ReportDigest(models.PortfolioReport{Portfolio: "lab", Chain: "hoodi", Period: "weekly", Range: "7d"}); got != "Weekly digest of lab (hoodi), last 7d\nEarnings: 0 ETH\nValidators: 0, 0 online, 0 offline, 0 slashed" {
+		t.Errorf("unexpected empty digest:\n%s", got)
+	}
+}
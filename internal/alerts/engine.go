@@ -0,0 +1,355 @@
+// Package alerts checks declarative rules against portfolio data periodically and
+// notifies channels when a rule starts firing and when it resolves. Rules only
+// name the channels they notify, so detection and delivery are configured
+// independently.
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+// ErrInvalidRule is returned for rules that cannot be checked.
+var ErrInvalidRule = errors.New("invalid alert rule")
+
+// Severities of a rule.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Source provides the portfolio data rules are checked against.
+type Source interface {
+	GetPortfolioData(ctx context.Context, name, evalRange string) (models.ValidatorResponse, error)
+}
+
+// Config is the content of an alert rules file.
+type Config struct {
+	Channels []ChannelConfig    `json:"channels"`
+	Rules    []models.AlertRule `json:"rules"`
+}
+
+// LoadFile reads channels and rules from a JSON file. An empty path returns an
+// empty configuration.
+func LoadFile(path string) (Config, error) {
+	if path == "" {
+		return Config{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read alert rules: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("decode alert rules: %w", err)
+	}
+	return cfg, nil
+}
+
+// Engine checks rules and notifies their channels. It is safe for concurrent use.
+type Engine struct {
+	portfolios *portfolio.Registry
+	source     Source
+	channels   map[string]Notifier
+
+	mu    sync.Mutex
+	rules []*ruleState // In the order they were added
+	mutes []models.AlertMute
+
+	history     []models.Alert // Oldest first
+	historyPath string
+}
+
+// ruleState is a rule and the outcome of its checks so far.
+type ruleState struct {
+	rule      models.AlertRule
+	condition Condition
+	matches   int
+	firing    bool
+	alertID   string // Alert of the current firing
+	muted     bool
+	value     *float64
+	lastCheck time.Time
+	lastErr   string
+	balances  map[string]*big.Int // Validator balances of the previous check
+}
+
+// NewEngine creates an engine checking rules against the portfolios in registry,
+// notifying the given channels by name.
+func NewEngine(registry *portfolio.Registry, source Source, channels map[string]Notifier) *Engine {
+	return &Engine{portfolios: registry, source: source, channels: channels}
+}
+
+// SetRule adds a rule, or replaces the rule with the same name and its state. An
+// unresolved alert of the rule continues until a check no longer matches.
+func (e *Engine) SetRule(rule models.AlertRule) error {
+	if rule.Range == "" {
+		rule.Range = "24h"
+	}
+	if rule.Severity == "" {
+		rule.Severity = defaultSeverity(rule.Condition)
+	}
+	condition, err := e.validate(rule)
+	if err != nil {
+		return err
+	}
+
+	state := &ruleState{rule: rule, condition: condition, balances: make(map[string]*big.Int)}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if id, ok := e.firingAlert(rule.Name); ok {
+		state.firing = true
+		state.alertID = id
+	}
+	for i, r := range e.rules {
+		if r.rule.Name == rule.Name {
+			e.rules[i] = state
+			return nil
+		}
+	}
+	e.rules = append(e.rules, state)
+	return nil
+}
+
+// defaultSeverity returns the severity of a rule that sets none. Slashings and
+// the doppelgangers preceding them are critical.
+func defaultSeverity(condition string) string {
+	if c, err := ParseCondition(condition); err == nil && (c.Metric == MetricSlashed || c.Metric == MetricDoppelganger) {
+		return SeverityCritical
+	}
+	return SeverityWarning
+}
+
+// validate checks the rule and parses its condition.
+func (e *Engine) validate(rule models.AlertRule) (Condition, error) {
+	if rule.Name == "" {
+		return Condition{}, fmt.Errorf("%w: name is required", ErrInvalidRule)
+	}
+	if _, ok := e.portfolios.Get(rule.Portfolio); !ok {
+		return Condition{}, fmt.Errorf("%w: unknown portfolio %q", ErrInvalidRule, rule.Portfolio)
+	}
+	switch rule.Range {
+	case "24h", "7d", "30d", "90d", "all_time":
+	default:
+		return Condition{}, fmt.Errorf("%w: range must be one of: 24h, 7d, 30d, 90d, all_time", ErrInvalidRule)
+	}
+	switch rule.Severity {
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+	default:
+		return Condition{}, fmt.Errorf("%w: severity must be one of: info, warning, critical", ErrInvalidRule)
+	}
+	if len(rule.Channels) == 0 {
+		return Condition{}, fmt.Errorf("%w: at least one channel is required", ErrInvalidRule)
+	}
+	for _, name := range rule.Channels {
+		if _, ok := e.channels[name]; !ok {
+			return Condition{}, fmt.Errorf("%w: unknown channel %q", ErrInvalidRule, name)
+		}
+	}
+	condition, err := ParseCondition(rule.Condition)
+	if err != nil {
+		return Condition{}, fmt.Errorf("%w: %w", ErrInvalidRule, err)
+	}
+	return condition, nil
+}
+
+// DeleteRule removes the named rule and reports whether it existed. The alert of a
+// firing rule is resolved without a notification.
+func (e *Engine) DeleteRule(name string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, r := range e.rules {
+		if r.rule.Name == name {
+			if r.firing {
+				e.resolveAlert(r.alertID, time.Now())
+			}
+			e.rules = slices.Delete(e.rules, i, i+1)
+			return true
+		}
+	}
+	return false
+}
+
+// Rules returns the rules with the outcome of their latest check.
+func (e *Engine) Rules() []models.AlertRuleStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	result := make([]models.AlertRuleStatus, 0, len(e.rules))
+	for _, r := range e.rules {
+		status := models.AlertRuleStatus{
+			AlertRule: r.rule,
+			Firing:    r.firing,
+			Matches:   r.matches,
+			LastError: r.lastErr,
+			Muted:     r.muted,
+		}
+		if r.value != nil {
+			value := *r.value
+			status.Value = &value
+		}
+		if !r.lastCheck.IsZero() {
+			lastCheck := r.lastCheck
+			status.LastCheck = &lastCheck
+		}
+		result = append(result, status)
+	}
+	return result
+}
+
+// Run checks all rules immediately and then every interval until the context is canceled.
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		e.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check checks every rule once and sends the resulting notifications. Portfolio
+// data is fetched once per portfolio and range. Rules whose data cannot be
+// fetched keep their state until the next check.
+func (e *Engine) Check(ctx context.Context) {
+	e.mu.Lock()
+	rules := slices.Clone(e.rules)
+	e.mu.Unlock()
+
+	type dataKey struct{ portfolio, evalRange string }
+	type result struct {
+		data models.ValidatorResponse
+		err  error
+	}
+	fetched := make(map[dataKey]result)
+
+	for _, r := range rules {
+		key := dataKey{r.rule.Portfolio, r.rule.Range}
+		res, ok := fetched[key]
+		if !ok {
+			res.data, res.err = e.source.GetPortfolioData(ctx, key.portfolio, key.evalRange)
+			fetched[key] = res
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if n, ok := e.evaluate(r, res.data, res.err); ok {
+			e.notify(ctx, r.rule.Channels, n)
+		}
+	}
+}
+
+// evaluate updates the state of r with the outcome of a check and returns the
+// notification to send, if the rule started firing, is still firing or resolved.
+func (e *Engine) evaluate(r *ruleState, data models.ValidatorResponse, fetchErr error) (Notification, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	r.lastCheck = now
+	if fetchErr != nil {
+		slog.Error("failed to check alert rule", "rule", r.rule.Name, "portfolio", r.rule.Portfolio, "error", fetchErr)
+		r.lastErr = fetchErr.Error()
+		return Notification{}, false
+	}
+	r.lastErr = ""
+
+	p, _ := e.portfolios.Get(r.rule.Portfolio)
+	portfolioMuted, mutedValidators := e.muted(r.rule.Portfolio, p.Chain, now)
+	r.muted = portfolioMuted || (len(mutedValidators) > 0 && !perValidator(r.condition.Metric))
+
+	value, validators, ok := measure(r.condition.Metric, data, r.balances, mutedValidators)
+	if !ok {
+		r.value = nil
+		return Notification{}, false
+	}
+	r.value = &value
+
+	// A muted rule keeps its state, and only matches after the window count
+	if r.muted {
+		if !r.firing {
+			r.matches = 0
+		}
+		return Notification{}, false
+	}
+
+	if r.condition.compare(value) {
+		r.matches++
+	} else {
+		r.matches = 0
+	}
+
+	n := Notification{
+		AlertID:    r.alertID,
+		Rule:       r.rule.Name,
+		Portfolio:  r.rule.Portfolio,
+		Chain:      p.Chain,
+		Severity:   r.rule.Severity,
+		Condition:  r.rule.Condition,
+		Value:      value,
+		Validators: validators,
+		Time:       now,
+	}
+	switch {
+	case !r.firing && r.matches >= r.condition.For:
+		n.State = StateFiring
+		n.AlertID = e.openAlert(n)
+		r.firing = true
+		r.alertID = n.AlertID
+	case r.firing && r.matches == 0:
+		n.State = StateResolved
+		e.resolveAlert(r.alertID, now)
+		r.firing = false
+		r.alertID = ""
+	case r.firing:
+		n.State = StateOngoing
+	default:
+		return Notification{}, false
+	}
+	return n, true
+}
+
+// notify sends n to the named channels. Ongoing alerts only go to channels that
+// follow up on them. Failures are logged and not retried.
+func (e *Engine) notify(ctx context.Context, channels []string, n Notification) {
+	for _, name := range channels {
+		notifier, ok := e.channels[name]
+		if !ok {
+			continue
+		}
+		var err error
+		if n.State == StateOngoing {
+			updater, ok := notifier.(UpdateNotifier)
+			if !ok {
+				continue
+			}
+			err = updater.NotifyUpdate(ctx, n)
+		} else {
+			err = notifier.Notify(ctx, n)
+		}
+		if err != nil {
+			slog.Error("failed to send alert", "rule", n.Rule, "channel", name, "error", err)
+		}
+	}
+}
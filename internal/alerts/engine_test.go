@@ -0,0 +1,384 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+// fakeSource serves a fixed response per portfolio.
+type fakeSource struct {
+	mu    sync.Mutex
+	data  map[string]models.ValidatorResponse
+	err   error
+	calls int
+}
+
+func (f *fakeSource) GetPortfolioData(ctx context.Context, name, evalRange string) (models.ValidatorResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.data[name], f.err
+}
+
+func (f *fakeSource) setScore(name string, score float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data[name] = models.ValidatorResponse{Performance: models.ValidatorPerformance{Beaconscore: &score}}
+}
+
+// recorder collects the notifications it receives.
+type recorder struct {
+	mu            sync.Mutex
+	notifications []Notification
+}
+
+func (r *recorder) Notify(ctx context.Context, n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications = append(r.notifications, n)
+	return nil
+}
+
+func (r *recorder) states() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var states []string
+	for _, n := range r.notifications {
+		states = append(states, n.State)
+	}
+	return states
+}
+
+func newTestEngine(t *testing.T) (*Engine, *fakeSource, *recorder) {
+	t.Helper()
+	registry, err := portfolio.NewRegistry([]portfolio.Portfolio{
+		{Name: "home", Chain: "mainnet", ValidatorIds: []int{1, 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &fakeSource{data: make(map[string]models.ValidatorResponse)}
+	rec := &recorder{}
+	return NewEngine(registry, source, map[string]Notifier{"test": rec}), source, rec
+}
+
+func TestEngine_FiresAndResolves(t *testing.T) {
+	engine, source, rec := newTestEngine(t)
+	if err := engine.SetRule(models.AlertRule{
+		Name:      "low-score",
+		Portfolio: "home",
+		Condition: "beaconscore < 0.9 for 2 checks",
+		Channels:  []string{"test"},
+	}); err != nil {
+		t.Fatalf("SetRule failed: %v", err)
+	}
+	ctx := context.Background()
+
+	scores := []float64{0.85, 0.95, 0.85, 0.8, 0.7, 0.92}
+	want := [][]string{nil, nil, nil, {"firing"}, {"firing"}, {"firing", "resolved"}}
+	for i, score := range scores {
+		source.setScore("home", score)
+		engine.Check(ctx)
+		if got := rec.states(); !equalStates(got, want[i]) {
+			t.Fatalf("check %d: expected notifications %v, got %v", i+1, want[i], got)
+		}
+	}
+
+	n := rec.notifications[0]
+	if n.Rule != "low-score" || n.Chain != "mainnet" || n.Severity != SeverityWarning || n.Value != 0.8 {
+		t.Errorf("unexpected notification: %+v", n)
+	}
+
+	rules := engine.Rules()
+	if len(rules) != 1 || rules[0].Firing || rules[0].Range != "24h" || rules[0].LastCheck == nil {
+		t.Errorf("unexpected rule status: %+v", rules)
+	}
+}
+
+// updateRecorder also collects the updates of ongoing alerts.
+type updateRecorder struct {
+	recorder
+}
+
+func (r *updateRecorder) NotifyUpdate(ctx context.Context, n Notification) error {
+	return r.Notify(ctx, n)
+}
+
+func TestEngine_Updates(t *testing.T) {
+	registry, err := portfolio.NewRegistry([]portfolio.Portfolio{{Name: "home", Chain: "mainnet", ValidatorIds: []int{1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &fakeSource{data: make(map[string]models.ValidatorResponse)}
+	plain, updater := &recorder{}, &updateRecorder{}
+	engine := NewEngine(registry, source, map[string]Notifier{"plain": plain, "updater": updater})
+	if err := engine.SetRule(models.AlertRule{Name: "low-score", Portfolio: "home", Condition: "beaconscore < 0.9", Channels: []string{"plain", "updater"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, score := range []float64{0.8, 0.7, 0.85, 0.95} {
+		source.setScore("home", score)
+		engine.Check(context.Background())
+	}
+
+	if got := plain.states(); !equalStates(got, []string{"firing", "resolved"}) {
+		t.Errorf("expected only state changes on a plain channel, got %v", got)
+	}
+	if got := updater.states(); !equalStates(got, []string{"firing", "ongoing", "ongoing", "resolved"}) {
+		t.Errorf("expected updates while firing, got %v", got)
+	}
+	if n := updater.notifications[1]; n.AlertID == "" || n.AlertID != updater.notifications[0].AlertID || n.Value != 0.7 {
+		t.Errorf("expected the update to carry the alert and its latest value, got %+v", n)
+	}
+}
+
+func TestEngine_Doppelganger(t *testing.T) {
+	engine, source, rec := newTestEngine(t)
+	if err := engine.SetRule(models.AlertRule{
+		Name:      "doppelganger",
+		Portfolio: "home",
+		Condition: "doppelganger > 0",
+		Channels:  []string{"test"},
+	}); err != nil {
+		t.Fatalf("SetRule failed: %v", err)
+	}
+	source.data["home"] = models.ValidatorResponse{Validators: map[string]models.ValidatorOverview{
+		"1": {Doppelganger: &models.DoppelgangerSuspicion{Epoch: 100, Attestations: 2}},
+		"2": {},
+	}}
+
+	engine.Check(context.Background())
+
+	if got := rec.states(); !equalStates(got, []string{"firing"}) {
+		t.Fatalf("expected a firing notification, got %v", got)
+	}
+	// Doppelganger rules default to critical
+	n := rec.notifications[0]
+	if n.Severity != SeverityCritical || n.Value != 1 || len(n.Validators) != 1 || n.Validators[0] != 1 {
+		t.Errorf("unexpected notification: %+v", n)
+	}
+}
+
+func TestEngine_FetchError(t *testing.T) {
+	engine, source, rec := newTestEngine(t)
+	for _, name := range []string{"a", "b"} {
+		if err := engine.SetRule(models.AlertRule{Name: name, Portfolio: "home", Condition: "offline > 0", Channels: []string{"test"}}); err != nil {
+			t.Fatalf("SetRule failed: %v", err)
+		}
+	}
+	source.err = errors.New("upstream down")
+
+	engine.Check(context.Background())
+
+	// Rules on the same portfolio and range share one fetch
+	if source.calls != 1 {
+		t.Errorf("expected 1 fetch, got %d", source.calls)
+	}
+	if got := engine.Rules()[0].LastError; got != "upstream down" {
+		t.Errorf("expected the fetch error to be reported, got %q", got)
+	}
+	if len(rec.states()) != 0 {
+		t.Errorf("expected no notifications, got %v", rec.states())
+	}
+}
+
+func TestEngine_SetRule_Validation(t *testing.T) {
+	engine, _, _ := newTestEngine(t)
+	valid := models.AlertRule{Name: "r", Portfolio: "home", Condition: "slashed > 0", Channels: []string{"test"}}
+
+	tests := []struct {
+		name   string
+		modify func(*models.AlertRule)
+	}{
+		{name: "unknown portfolio", modify: func(r *models.AlertRule) { r.Portfolio = "office" }},
+		{name: "unknown channel", modify: func(r *models.AlertRule) { r.Channels = []string{"pager"} }},
+		{name: "no channels", modify: func(r *models.AlertRule) { r.Channels = nil }},
+		{name: "invalid condition", modify: func(r *models.AlertRule) { r.Condition = "slashed" }},
+		{name: "invalid range", modify: func(r *models.AlertRule) { r.Range = "1y" }},
+		{name: "invalid severity", modify: func(r *models.AlertRule) { r.Severity = "fatal" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := valid
+			tt.modify(&rule)
+			if err := engine.SetRule(rule); !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("expected ErrInvalidRule, got %v", err)
+			}
+		})
+	}
+
+	if err := engine.SetRule(valid); err != nil {
+		t.Errorf("expected valid rule to be accepted, got %v", err)
+	}
+	if !engine.DeleteRule("r") || engine.DeleteRule("r") {
+		t.Error("expected the rule to be deleted once")
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode notification: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	n := Notification{Rule: "offline", Portfolio: "home", State: StateFiring, Validators: []int{2}, Time: time.Now()}
+	if err := NewWebhookNotifier(server.URL, time.Second).Notify(context.Background(), n); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if got.Rule != "offline" || got.State != StateFiring || len(got.Validators) != 1 {
+		t.Errorf("unexpected notification received: %+v", got)
+	}
+}
+
+func equalStates(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEngine_Mute(t *testing.T) {
+	engine, source, rec := newTestEngine(t)
+	for _, rule := range []models.AlertRule{
+		{Name: "low-score", Portfolio: "home", Condition: "beaconscore < 0.9", Channels: []string{"test"}},
+		{Name: "offline", Portfolio: "home", Condition: "offline > 0", Channels: []string{"test"}},
+	} {
+		if err := engine.SetRule(rule); err != nil {
+			t.Fatalf("SetRule failed: %v", err)
+		}
+	}
+	score := 0.5
+	source.data["home"] = models.ValidatorResponse{
+		Performance: models.ValidatorPerformance{Beaconscore: &score},
+		Validators: map[string]models.ValidatorOverview{
+			"1": {Status: "active_online", Online: true},
+			"2": {Status: "active_offline"},
+		},
+	}
+
+	// Muting validator 2 silences the offline rule, and the portfolio BeaconScore it drags down
+	mute, err := engine.AddMute(models.AlertMute{Chain: "mainnet", Validators: []int{2}, End: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("AddMute failed: %v", err)
+	}
+	engine.Check(context.Background())
+	if len(rec.states()) != 0 {
+		t.Fatalf("expected no notifications while muted, got %v", rec.states())
+	}
+	if rules := engine.Rules(); !rules[0].Muted || rules[1].Muted || *rules[1].Value != 0 {
+		t.Errorf("unexpected rule status while muted: %+v", rules)
+	}
+
+	if !engine.DeleteMute(mute.ID) {
+		t.Fatal("expected the mute to be deleted")
+	}
+	engine.Check(context.Background())
+	if got := rec.states(); !equalStates(got, []string{"firing", "firing"}) {
+		t.Errorf("expected both rules to fire after the mute, got %v", got)
+	}
+}
+
+func TestEngine_AddMute_Validation(t *testing.T) {
+	engine, _, _ := newTestEngine(t)
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		mute models.AlertMute
+	}{
+		{name: "no target", mute: models.AlertMute{End: now.Add(time.Hour)}},
+		{name: "portfolio and validators", mute: models.AlertMute{Portfolio: "home", Chain: "mainnet", Validators: []int{1}, End: now.Add(time.Hour)}},
+		{name: "unknown portfolio", mute: models.AlertMute{Portfolio: "office", End: now.Add(time.Hour)}},
+		{name: "unknown chain", mute: models.AlertMute{Chain: "sepolia", Validators: []int{1}, End: now.Add(time.Hour)}},
+		{name: "no end", mute: models.AlertMute{Portfolio: "home"}},
+		{name: "end before start", mute: models.AlertMute{Portfolio: "home", Start: now.Add(2 * time.Hour), End: now.Add(time.Hour)}},
+		{name: "ended", mute: models.AlertMute{Portfolio: "home", Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := engine.AddMute(tt.mute); !errors.Is(err, ErrInvalidMute) {
+				t.Errorf("expected ErrInvalidMute, got %v", err)
+			}
+		})
+	}
+
+	scheduled, err := engine.AddMute(models.AlertMute{Portfolio: "home", Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)})
+	if err != nil {
+		t.Fatalf("AddMute failed: %v", err)
+	}
+	if mutes := engine.Mutes(); len(mutes) != 1 || mutes[0].ID != scheduled.ID || scheduled.ID == "" {
+		t.Errorf("unexpected mutes: %+v", mutes)
+	}
+}
+
+func TestEngine_History(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	rule := models.AlertRule{Name: "low-score", Portfolio: "home", Condition: "beaconscore < 0.9", Channels: []string{"test"}}
+	ctx := context.Background()
+
+	engine, source, rec := newTestEngine(t)
+	if err := engine.LoadHistory(path); err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if err := engine.SetRule(rule); err != nil {
+		t.Fatalf("SetRule failed: %v", err)
+	}
+	source.setScore("home", 0.5)
+	engine.Check(ctx)
+
+	open := engine.Alerts(StatusOpen)
+	if len(open) != 1 || open[0].ID != rec.notifications[0].AlertID {
+		t.Fatalf("expected the notified alert to be open, got %+v", open)
+	}
+	acked, err := engine.Ack(open[0].ID, "alice")
+	if err != nil || acked.Status != StatusAcknowledged || acked.AckedBy != "alice" {
+		t.Fatalf("unexpected acknowledgement: %+v, %v", acked, err)
+	}
+	if _, err := engine.Ack("unknown", ""); !errors.Is(err, ErrAlertNotFound) {
+		t.Errorf("expected ErrAlertNotFound, got %v", err)
+	}
+
+	// After a restart the acknowledged alert continues without notifying again
+	restarted, source, rec := newTestEngine(t)
+	if err := restarted.LoadHistory(path); err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if err := restarted.SetRule(rule); err != nil {
+		t.Fatalf("SetRule failed: %v", err)
+	}
+	source.setScore("home", 0.5)
+	restarted.Check(ctx)
+	if len(rec.states()) != 0 {
+		t.Fatalf("expected no notifications for the continued alert, got %v", rec.states())
+	}
+
+	source.setScore("home", 0.95)
+	restarted.Check(ctx)
+	if len(rec.notifications) != 1 || rec.notifications[0].State != StateResolved || rec.notifications[0].AlertID != open[0].ID {
+		t.Fatalf("expected the alert to resolve, got %+v", rec.notifications)
+	}
+	resolved := restarted.Alerts(StatusResolved)
+	if len(resolved) != 1 || resolved[0].ResolvedAt == nil || resolved[0].AckedBy != "alice" {
+		t.Errorf("unexpected resolved alerts: %+v", resolved)
+	}
+}
@@ -0,0 +1,163 @@
+package alerts
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// ErrAlertNotFound is returned when acknowledging an unknown alert.
+var ErrAlertNotFound = errors.New("alert not found")
+
+// Alert statuses.
+const (
+	StatusOpen         = "open"
+	StatusAcknowledged = "acknowledged"
+	StatusResolved     = "resolved"
+)
+
+// maxHistory is the number of alerts kept. The oldest resolved alerts are dropped first.
+const maxHistory = 1000
+
+// LoadHistory reads the alert history from path and persists changes to it. Alerts
+// still firing when the history was saved continue when their rule is set, instead
+// of firing and notifying again. A missing file starts an empty history.
+func (e *Engine) LoadHistory(path string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.historyPath = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read alert history: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &e.history); err != nil {
+		return fmt.Errorf("decode alert history: %w", err)
+	}
+	return nil
+}
+
+// Alerts returns the alerts with the given status, or all of them if status is
+// empty, most recently fired first.
+func (e *Engine) Alerts(status string) []models.Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	result := []models.Alert{}
+	for i := len(e.history) - 1; i >= 0; i-- {
+		if status == "" || e.history[i].Status == status {
+			result = append(result, e.history[i])
+		}
+	}
+	return result
+}
+
+// Ack acknowledges the alert with the given ID. Acknowledging an alert twice keeps
+// the first acknowledgement.
+func (e *Engine) Ack(id, by string) (models.Alert, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	i := slices.IndexFunc(e.history, func(a models.Alert) bool { return a.ID == id })
+	if i < 0 {
+		return models.Alert{}, fmt.Errorf("%w: %s", ErrAlertNotFound, id)
+	}
+
+	a := &e.history[i]
+	if a.AckedAt == nil {
+		now := time.Now().UTC()
+		a.AckedAt = &now
+		a.AckedBy = by
+		if a.Status == StatusOpen {
+			a.Status = StatusAcknowledged
+		}
+		e.saveHistory()
+	}
+	return *a, nil
+}
+
+// openAlert records a new alert for the notification of a rule that started
+// firing and returns its ID. e.mu must be held.
+func (e *Engine) openAlert(n Notification) string {
+	var id [8]byte
+	rand.Read(id[:])
+
+	e.history = append(e.history, models.Alert{
+		ID:         hex.EncodeToString(id[:]),
+		Rule:       n.Rule,
+		Portfolio:  n.Portfolio,
+		Chain:      n.Chain,
+		Severity:   n.Severity,
+		Condition:  n.Condition,
+		Status:     StatusOpen,
+		Value:      n.Value,
+		Validators: n.Validators,
+		FiredAt:    n.Time.UTC(),
+	})
+	if len(e.history) > maxHistory {
+		if i := slices.IndexFunc(e.history, func(a models.Alert) bool { return a.Status == StatusResolved }); i >= 0 {
+			e.history = slices.Delete(e.history, i, i+1)
+		}
+	}
+	e.saveHistory()
+	return e.history[len(e.history)-1].ID
+}
+
+// resolveAlert marks the alert with the given ID resolved. e.mu must be held.
+func (e *Engine) resolveAlert(id string, t time.Time) {
+	i := slices.IndexFunc(e.history, func(a models.Alert) bool { return a.ID == id })
+	if i < 0 {
+		return
+	}
+	resolved := t.UTC()
+	e.history[i].Status = StatusResolved
+	e.history[i].ResolvedAt = &resolved
+	e.saveHistory()
+}
+
+// firingAlert returns the ID of the unresolved alert of the named rule, if any.
+// e.mu must be held.
+func (e *Engine) firingAlert(rule string) (string, bool) {
+	for i := len(e.history) - 1; i >= 0; i-- {
+		if e.history[i].Rule == rule && e.history[i].Status != StatusResolved {
+			return e.history[i].ID, true
+		}
+	}
+	return "", false
+}
+
+// saveHistory atomically writes the alert history, if a path is configured.
+// Failures are logged, the history stays available in memory. e.mu must be held.
+func (e *Engine) saveHistory() {
+	if e.historyPath == "" {
+		return
+	}
+
+	data, err := json.Marshal(e.history)
+	if err != nil {
+		slog.Error("failed to encode alert history", "error", err)
+		return
+	}
+
+	tmp := e.historyPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		slog.Error("failed to write alert history", "error", err)
+		return
+	}
+	if err := os.Rename(tmp, e.historyPath); err != nil {
+		slog.Error("failed to write alert history", "error", err)
+	}
+}
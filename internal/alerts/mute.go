@@ -0,0 +1,126 @@
+package alerts
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// ErrInvalidMute is returned for mute windows that cannot be applied.
+var ErrInvalidMute = errors.New("invalid mute window")
+
+// AddMute schedules a mute window and returns it with its ID. Checks in the window
+// neither fire nor resolve the rules of a muted portfolio. Muted validators are left
+// out of per-validator metrics, and mute the aggregate metrics of their portfolios.
+func (e *Engine) AddMute(m models.AlertMute) (models.AlertMute, error) {
+	now := time.Now()
+	if m.Start.IsZero() {
+		m.Start = now
+	}
+	if err := e.validateMute(m, now); err != nil {
+		return models.AlertMute{}, err
+	}
+
+	var id [8]byte
+	rand.Read(id[:])
+	m.ID = hex.EncodeToString(id[:])
+	m.Validators = slices.Clone(m.Validators)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pruneMutes(now)
+	e.mutes = append(e.mutes, m)
+	return m, nil
+}
+
+// validateMute checks that m targets a known portfolio or chain and ends in the future.
+func (e *Engine) validateMute(m models.AlertMute, now time.Time) error {
+	switch {
+	case m.Portfolio == "" && len(m.Validators) == 0:
+		return fmt.Errorf("%w: portfolio or validators is required", ErrInvalidMute)
+	case m.Portfolio != "" && (len(m.Validators) > 0 || m.Chain != ""):
+		return fmt.Errorf("%w: set either portfolio or chain and validators", ErrInvalidMute)
+	case m.End.IsZero():
+		return fmt.Errorf("%w: end is required", ErrInvalidMute)
+	case !m.End.After(m.Start):
+		return fmt.Errorf("%w: end must be after start", ErrInvalidMute)
+	case !m.End.After(now):
+		return fmt.Errorf("%w: end must be in the future", ErrInvalidMute)
+	}
+
+	if m.Portfolio != "" {
+		if _, ok := e.portfolios.Get(m.Portfolio); !ok {
+			return fmt.Errorf("%w: unknown portfolio %q", ErrInvalidMute, m.Portfolio)
+		}
+		return nil
+	}
+	if _, err := chainspec.ForChain(m.Chain); err != nil {
+		return fmt.Errorf("%w: chain must be one of: mainnet, hoodi", ErrInvalidMute)
+	}
+	for _, id := range m.Validators {
+		if id < 0 {
+			return fmt.Errorf("%w: validator indices must be non-negative", ErrInvalidMute)
+		}
+	}
+	return nil
+}
+
+// DeleteMute ends the mute window with the given ID and reports whether it existed.
+func (e *Engine) DeleteMute(id string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pruneMutes(time.Now())
+	for i, m := range e.mutes {
+		if m.ID == id {
+			e.mutes = slices.Delete(e.mutes, i, i+1)
+			return true
+		}
+	}
+	return false
+}
+
+// Mutes returns the current and scheduled mute windows by start time.
+func (e *Engine) Mutes() []models.AlertMute {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pruneMutes(time.Now())
+
+	result := slices.Clone(e.mutes)
+	if result == nil {
+		result = []models.AlertMute{}
+	}
+	slices.SortStableFunc(result, func(a, b models.AlertMute) int { return a.Start.Compare(b.Start) })
+	return result
+}
+
+// pruneMutes drops the windows that ended. e.mu must be held.
+func (e *Engine) pruneMutes(now time.Time) {
+	e.mutes = slices.DeleteFunc(e.mutes, func(m models.AlertMute) bool { return !m.End.After(now) })
+}
+
+// muted returns whether portfolio is muted at now, and otherwise its muted
+// validators on chain. e.mu must be held.
+func (e *Engine) muted(portfolio, chain string, now time.Time) (bool, map[string]bool) {
+	validators := make(map[string]bool)
+	for _, m := range e.mutes {
+		if now.Before(m.Start) || !now.Before(m.End) {
+			continue
+		}
+		if m.Portfolio == portfolio {
+			return true, nil
+		}
+		if m.Chain == chain {
+			for _, id := range m.Validators {
+				validators[strconv.Itoa(id)] = true
+			}
+		}
+	}
+	return false, validators
+}
@@ -0,0 +1,237 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// Notification states.
+const (
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+	StateOngoing  = "ongoing" // Still firing, only sent to an UpdateNotifier
+)
+
+// Notification is sent to the channels of a rule when it starts firing and when
+// its condition no longer holds. Channels following up on alerts also receive it
+// on every check while the rule keeps firing.
+type Notification struct {
+	AlertID    string    `json:"alertId"`
+	Rule       string    `json:"rule"`
+	Portfolio  string    `json:"portfolio"`
+	Chain      string    `json:"chain"`
+	Severity   string    `json:"severity"`
+	State      string    `json:"state"` // firing or resolved
+	Condition  string    `json:"condition"`
+	Value      float64   `json:"value"`
+	Validators []int     `json:"validators,omitempty"` // Validators contributing to the value, for per-validator metrics
+	Time       time.Time `json:"time"`
+}
+
+// Message returns a one-line summary of the notification.
+func (n Notification) Message() string {
+	msg := fmt.Sprintf("[%s] %s %s on %s: %s (value %s)",
+		n.Severity, n.Rule, n.State, n.Portfolio, n.Condition, strconv.FormatFloat(n.Value, 'f', -1, 64))
+	if len(n.Validators) > 0 {
+		ids := make([]string, len(n.Validators))
+		for i, id := range n.Validators {
+			ids[i] = strconv.Itoa(id)
+		}
+		msg += ", validators " + strings.Join(ids, ",")
+	}
+	return msg
+}
+
+// Notifier delivers notifications over a channel.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// UpdateNotifier is a channel that follows up on firing alerts. It receives the
+// outcome of every check while a rule keeps firing, and decides when to post.
+type UpdateNotifier interface {
+	NotifyUpdate(ctx context.Context, n Notification) error
+}
+
+// ReportNotifier delivers scheduled reports over a channel. The log and webhook
+// channels implement it.
+type ReportNotifier interface {
+	NotifyReport(ctx context.Context, r models.PortfolioReport) error
+}
+
+// LogNotifier writes notifications to the log.
+type LogNotifier struct{}
+
+// Notify implements Notifier.
+func (LogNotifier) Notify(ctx context.Context, n Notification) error {
+	level := slog.LevelWarn
+	if n.State == StateResolved {
+		level = slog.LevelInfo
+	}
+	slog.Log(ctx, level, "alert "+n.State,
+		"rule", n.Rule,
+		"portfolio", n.Portfolio,
+		"severity", n.Severity,
+		"condition", n.Condition,
+		"value", n.Value,
+		"validators", n.Validators,
+	)
+	return nil
+}
+
+// NotifyReport implements ReportNotifier.
+func (LogNotifier) NotifyReport(ctx context.Context, r models.PortfolioReport) error {
+	slog.InfoContext(ctx, "report "+r.Schedule,
+		"portfolio", r.Portfolio,
+		"period", r.Period,
+		"validators", r.Validators,
+		"online", r.Online,
+		"rewards", r.Rewards.Total,
+		"beaconscore", r.Performance.Beaconscore,
+		"id", r.ID,
+	)
+	return nil
+}
+
+// WebhookNotifier posts notifications as JSON to a URL.
+type WebhookNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url.
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{url: url, httpClient: &http.Client{Timeout: timeout}}
+}
+
+// Notify implements Notifier.
+func (w *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("marshal notification: %w", err)
+	}
+	return w.post(ctx, body)
+}
+
+// NotifyReport implements ReportNotifier.
+func (w *WebhookNotifier) NotifyReport(ctx context.Context, r models.PortfolioReport) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshal report: %w", err)
+	}
+	return w.post(ctx, body)
+}
+
+// post sends a JSON body to the webhook.
+func (w *WebhookNotifier) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ChannelConfig configures a notification channel.
+type ChannelConfig struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`                 // log, webhook, pagerduty, opsgenie or slack
+	URL        string `json:"url,omitempty"`        // Optional for pagerduty, opsgenie and slack apps, to override their endpoint
+	RoutingKey string `json:"routingKey,omitempty"` // Integration key of a PagerDuty service
+	APIKey     string `json:"apiKey,omitempty"`     // Key of an Opsgenie API integration
+
+	// Slack app posting to a channel with threaded follow-ups, or an incoming
+	// webhook in url without a token
+	Token       string `json:"token,omitempty"`       // Bot token of the app
+	Channel     string `json:"channel,omitempty"`     // Channel ID the app posts to
+	RemindAfter string `json:"remindAfter,omitempty"` // Duration between reminders of a firing alert, 1h by default
+}
+
+// NewNotifiers creates the configured channels by name. A "log" channel writing
+// to the log is always available.
+func NewNotifiers(channels []ChannelConfig, timeout time.Duration) (map[string]Notifier, error) {
+	notifiers := map[string]Notifier{"log": LogNotifier{}}
+	for _, c := range channels {
+		if c.Name == "" {
+			return nil, fmt.Errorf("channel must have a name")
+		}
+		if _, ok := notifiers[c.Name]; ok && c.Name != "log" {
+			return nil, fmt.Errorf("duplicate channel %q", c.Name)
+		}
+		if c.URL != "" && !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+			return nil, fmt.Errorf("channel %q needs an http(s) url", c.Name)
+		}
+		switch c.Type {
+		case "log":
+			notifiers[c.Name] = LogNotifier{}
+		case "webhook":
+			if !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+				return nil, fmt.Errorf("channel %q needs an http(s) url", c.Name)
+			}
+			notifiers[c.Name] = NewWebhookNotifier(c.URL, timeout)
+		case "pagerduty":
+			if c.RoutingKey == "" {
+				return nil, fmt.Errorf("channel %q needs a routingKey", c.Name)
+			}
+			notifiers[c.Name] = NewPagerDutyNotifier(c.URL, c.RoutingKey, timeout)
+		case "opsgenie":
+			if c.APIKey == "" {
+				return nil, fmt.Errorf("channel %q needs an apiKey", c.Name)
+			}
+			notifiers[c.Name] = NewOpsgenieNotifier(c.URL, c.APIKey, timeout)
+		case "slack":
+			n, err := newSlackNotifier(c, timeout)
+			if err != nil {
+				return nil, err
+			}
+			notifiers[c.Name] = n
+		default:
+			return nil, fmt.Errorf("channel %q has unknown type %q, must be one of: log, webhook, pagerduty, opsgenie, slack", c.Name, c.Type)
+		}
+	}
+	return notifiers, nil
+}
+
+// newSlackNotifier creates the notifier of a slack channel: an app when a token
+// is set, otherwise an incoming webhook.
+func newSlackNotifier(c ChannelConfig, timeout time.Duration) (*SlackNotifier, error) {
+	if c.Token == "" {
+		if c.URL == "" {
+			return nil, fmt.Errorf("channel %q needs a token and channel, or a webhook url", c.Name)
+		}
+		return NewSlackWebhookNotifier(c.URL, timeout), nil
+	}
+	if c.Channel == "" {
+		return nil, fmt.Errorf("channel %q needs the channel ID to post to", c.Name)
+	}
+	var remindAfter time.Duration
+	if c.RemindAfter != "" {
+		d, err := time.ParseDuration(c.RemindAfter)
+		if err != nil || d < time.Minute {
+			return nil, fmt.Errorf("channel %q: remindAfter must be a duration of at least 1m, got %q", c.Name, c.RemindAfter)
+		}
+		remindAfter = d
+	}
+	return NewSlackNotifier(c.URL, c.Token, c.Channel, remindAfter, timeout), nil
+}
@@ -0,0 +1,241 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// Default endpoints of the on-call services.
+const (
+	pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	opsgenieAPIURL     = "https://api.opsgenie.com"
+)
+
+// PagerDutyNotifier sends notifications as PagerDuty Events API v2 events. The
+// alert ID is the dedup key, so a resolved notification resolves the incident
+// its firing notification opened.
+type PagerDutyNotifier struct {
+	url        string
+	routingKey string
+	httpClient *http.Client
+}
+
+// NewPagerDutyNotifier creates a notifier sending events with the routing key of
+// a service integration. An empty url uses the PagerDuty endpoint.
+func NewPagerDutyNotifier(url, routingKey string, timeout time.Duration) *PagerDutyNotifier {
+	if url == "" {
+		url = pagerDutyEventsURL
+	}
+	return &PagerDutyNotifier{url: url, routingKey: routingKey, httpClient: &http.Client{Timeout: timeout}}
+}
+
+// pagerDutyEvent is an Events API v2 event.
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"` // trigger or resolve
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string       `json:"summary"`
+	Source        string       `json:"source"`
+	Severity      string       `json:"severity"`
+	Timestamp     time.Time    `json:"timestamp"`
+	Component     string       `json:"component,omitempty"`
+	Group         string       `json:"group,omitempty"`
+	Class         string       `json:"class,omitempty"`
+	CustomDetails Notification `json:"custom_details"`
+}
+
+// Notify implements Notifier.
+func (p *PagerDutyNotifier) Notify(ctx context.Context, n Notification) error {
+	event := pagerDutyEvent{RoutingKey: p.routingKey, EventAction: "trigger", DedupKey: n.AlertID}
+	if n.State == StateResolved {
+		event.EventAction = "resolve"
+	} else {
+		event.Payload = &pagerDutyPayload{
+			Summary:       n.Message(),
+			Source:        "validator-dashboard",
+			Severity:      pagerDutySeverity(n.Severity),
+			Timestamp:     n.Time,
+			Component:     n.Portfolio,
+			Group:         n.Chain,
+			Class:         n.Rule,
+			CustomDetails: n,
+		}
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	return postJSON(ctx, p.httpClient, p.url, body, nil)
+}
+
+// NotifyReport implements ReportNotifier, sending the digest as a change event,
+// which shows in the service's timeline without paging anyone.
+func (p *PagerDutyNotifier) NotifyReport(ctx context.Context, r models.PortfolioReport) error {
+	body, err := json.Marshal(map[string]any{
+		"routing_key": p.routingKey,
+		"payload": map[string]any{
+			"summary":   digestTitle(r),
+			"source":    "validator-dashboard",
+			"timestamp": reportTime(r),
+			"custom_details": map[string]string{
+				"digest": ReportDigest(r),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("marshal change event: %w", err)
+	}
+	return postJSON(ctx, p.httpClient, strings.TrimSuffix(p.url, "/enqueue")+"/change/enqueue", body, nil)
+}
+
+// pagerDutySeverity maps a rule severity to a PagerDuty severity, which has the
+// same critical, warning and info levels.
+func pagerDutySeverity(severity string) string {
+	switch severity {
+	case SeverityCritical, SeverityInfo:
+		return severity
+	default:
+		return SeverityWarning
+	}
+}
+
+// OpsgenieNotifier creates an Opsgenie alert when a rule fires and closes it when
+// the rule resolves. The alert ID is the Opsgenie alias, which also deduplicates
+// repeated firing notifications.
+type OpsgenieNotifier struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewOpsgenieNotifier creates a notifier with the API key of an API integration.
+// An empty url uses the Opsgenie API, https://api.eu.opsgenie.com serves EU
+// accounts.
+func NewOpsgenieNotifier(url, apiKey string, timeout time.Duration) *OpsgenieNotifier {
+	if url == "" {
+		url = opsgenieAPIURL
+	}
+	return &OpsgenieNotifier{url: strings.TrimSuffix(url, "/"), apiKey: apiKey, httpClient: &http.Client{Timeout: timeout}}
+}
+
+// opsgenieAlert is the request body that creates an alert.
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description"`
+	Tags        []string          `json:"tags"`
+	Entity      string            `json:"entity"`
+	Source      string            `json:"source"`
+	Priority    string            `json:"priority"`
+	Details     map[string]string `json:"details"`
+}
+
+// opsgenieMessageLimit is the longest message Opsgenie accepts.
+const opsgenieMessageLimit = 130
+
+// Notify implements Notifier.
+func (o *OpsgenieNotifier) Notify(ctx context.Context, n Notification) error {
+	headers := map[string]string{"Authorization": "GenieKey " + o.apiKey}
+	if n.State == StateResolved {
+		target := o.url + "/v2/alerts/" + url.PathEscape(n.AlertID) + "/close?identifierType=alias"
+		body, _ := json.Marshal(map[string]string{"source": "validator-dashboard", "note": n.Message()})
+		return postJSON(ctx, o.httpClient, target, body, headers)
+	}
+
+	message := n.Rule + " firing on " + n.Portfolio
+	if len(message) > opsgenieMessageLimit {
+		message = message[:opsgenieMessageLimit]
+	}
+	body, err := json.Marshal(opsgenieAlert{
+		Message:     message,
+		Alias:       n.AlertID,
+		Description: n.Message(),
+		Tags:        []string{n.Severity, n.Chain},
+		Entity:      n.Portfolio,
+		Source:      "validator-dashboard",
+		Priority:    opsgeniePriority(n.Severity),
+		Details: map[string]string{
+			"rule":      n.Rule,
+			"portfolio": n.Portfolio,
+			"chain":     n.Chain,
+			"condition": n.Condition,
+			"value":     fmt.Sprint(n.Value),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("marshal alert: %w", err)
+	}
+	return postJSON(ctx, o.httpClient, o.url+"/v2/alerts", body, headers)
+}
+
+// NotifyReport implements ReportNotifier, creating an informational P5 alert
+// tagged report with the digest as its description.
+func (o *OpsgenieNotifier) NotifyReport(ctx context.Context, r models.PortfolioReport) error {
+	message := digestTitle(r)
+	if len(message) > opsgenieMessageLimit {
+		message = message[:opsgenieMessageLimit]
+	}
+	body, err := json.Marshal(opsgenieAlert{
+		Message:     message,
+		Alias:       "report-" + r.ID,
+		Description: ReportDigest(r),
+		Tags:        []string{"report", r.Period, r.Chain},
+		Entity:      r.Portfolio,
+		Source:      "validator-dashboard",
+		Priority:    "P5",
+		Details:     map[string]string{"portfolio": r.Portfolio, "schedule": r.Schedule, "period": r.Period},
+	})
+	if err != nil {
+		return fmt.Errorf("marshal alert: %w", err)
+	}
+	return postJSON(ctx, o.httpClient, o.url+"/v2/alerts", body, map[string]string{"Authorization": "GenieKey " + o.apiKey})
+}
+
+// opsgeniePriority maps a rule severity to an Opsgenie priority.
+func opsgeniePriority(severity string) string {
+	switch severity {
+	case SeverityCritical:
+		return "P1"
+	case SeverityInfo:
+		return "P5"
+	default:
+		return "P3"
+	}
+}
+
+// postJSON posts a JSON body with extra headers and fails on any status but 2xx.
+func postJSON(ctx context.Context, client *http.Client, target string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send alert: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("send alert: status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// recordedRequest is a request received by a fake on-call service.
+type recordedRequest struct {
+	uri  string
+	auth string
+	body map[string]any
+}
+
+func newOnCallServer(t *testing.T) (*httptest.Server, *[]recordedRequest) {
+	t.Helper()
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		requests = append(requests, recordedRequest{r.URL.RequestURI(), r.Header.Get("Authorization"), body})
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestPagerDutyNotifier(t *testing.T) {
+	server, requests := newOnCallServer(t)
+	p := NewPagerDutyNotifier(server.URL, "routing-key", time.Second)
+
+	n := Notification{AlertID: "a1", Rule: "slashed", Portfolio: "home", Chain: "mainnet", Severity: SeverityCritical, State: StateFiring, Condition: "slashed > 0", Value: 1, Time: time.Now()}
+	if err := p.Notify(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	n.State = StateResolved
+	if err := p.Notify(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*requests) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(*requests))
+	}
+	trigger, resolve := (*requests)[0].body, (*requests)[1].body
+	payload, _ := trigger["payload"].(map[string]any)
+	if trigger["event_action"] != "trigger" || trigger["dedup_key"] != "a1" || trigger["routing_key"] != "routing-key" || payload["severity"] != "critical" || payload["component"] != "home" {
+		t.Errorf("unexpected trigger event: %v", trigger)
+	}
+	if resolve["event_action"] != "resolve" || resolve["dedup_key"] != "a1" || resolve["payload"] != nil {
+		t.Errorf("unexpected resolve event: %v", resolve)
+	}
+}
+
+func TestOpsgenieNotifier(t *testing.T) {
+	server, requests := newOnCallServer(t)
+	o := NewOpsgenieNotifier(server.URL+"/", "api-key", time.Second)
+
+	n := Notification{AlertID: "a2", Rule: "offline", Portfolio: "home", Chain: "mainnet", Severity: SeverityWarning, State: StateFiring, Condition: "offline > 0", Value: 2, Validators: []int{3, 4}, Time: time.Now()}
+	if err := o.Notify(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	n.State = StateResolved
+	if err := o.Notify(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(*requests))
+	}
+	create, closing := (*requests)[0], (*requests)[1]
+	if create.uri != "/v2/alerts" || create.auth != "GenieKey api-key" || create.body["alias"] != "a2" || create.body["priority"] != "P3" {
+		t.Errorf("unexpected create request: %+v", create)
+	}
+	if desc, _ := create.body["description"].(string); !strings.Contains(desc, "validators 3,4") {
+		t.Errorf("expected the description to name the validators, got %q", desc)
+	}
+	if closing.uri != "/v2/alerts/a2/close?identifierType=alias" || closing.auth != "GenieKey api-key" {
+		t.Errorf("unexpected close request: %+v", closing)
+	}
+}
+
+func TestSeverityMapping(t *testing.T) {
+	tests := []struct {
+		severity, pagerDuty, opsgenie string
+	}{
+		{SeverityCritical, "critical", "P1"},
+		{SeverityWarning, "warning", "P3"},
+		{SeverityInfo, "info", "P5"},
+	}
+	for _, tt := range tests {
+		if got := pagerDutySeverity(tt.severity); got != tt.pagerDuty {
+			t.Errorf("pagerDutySeverity(%q) = %q, want %q", tt.severity, got, tt.pagerDuty)
+		}
+		if got := opsgeniePriority(tt.severity); got != tt.opsgenie {
+			t.Errorf("opsgeniePriority(%q) = %q, want %q", tt.severity, got, tt.opsgenie)
+		}
+	}
+	if defaultSeverity("slashed > 0") != SeverityCritical || defaultSeverity("offline > 0") != SeverityWarning {
+		t.Error("expected slashing rules to default to critical and offline rules to warning")
+	}
+}
+
+func TestNewNotifiers_OnCall(t *testing.T) {
+	tests := []struct {
+		channel ChannelConfig
+		wantErr string
+	}{
+		{ChannelConfig{Name: "pd", Type: "pagerduty", RoutingKey: "k"}, ""},
+		{ChannelConfig{Name: "pd", Type: "pagerduty"}, "routingKey"},
+		{ChannelConfig{Name: "og", Type: "opsgenie", APIKey: "k", URL: "https://api.eu.opsgenie.com"}, ""},
+		{ChannelConfig{Name: "og", Type: "opsgenie", APIKey: "k", URL: "api.eu.opsgenie.com"}, "http(s) url"},
+		{ChannelConfig{Name: "og", Type: "opsgenie"}, "apiKey"},
+	}
+	for _, tt := range tests {
+		_, err := NewNotifiers([]ChannelConfig{tt.channel}, time.Second)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%+v: unexpected error %v", tt.channel, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%+v: expected error %q, got %v", tt.channel, tt.wantErr, err)
+		}
+	}
+}
+
+func TestOnCallNotifiers_Reports(t *testing.T) {
+	server, requests := newOnCallServer(t)
+	r := models.PortfolioReport{ID: "r1", Schedule: "daily-home", Portfolio: "home", Chain: "mainnet", Period: "daily", Range: "24h", Rewards: models.ValidatorRewards{Total: "1000000000000000"}}
+
+	if err := NewPagerDutyNotifier(server.URL+"/v2/enqueue", "routing-key", time.Second).NotifyReport(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewOpsgenieNotifier(server.URL, "api-key", time.Second).NotifyReport(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+
+	change, alert := (*requests)[0], (*requests)[1]
+	payload, _ := change.body["payload"].(map[string]any)
+	if change.uri != "/v2/change/enqueue" || payload["summary"] != "daily report of home: 0.001 ETH earned, 0 of 0 validators online" {
+		t.Errorf("expected a change event with the digest, got %+v", change)
+	}
+	if alert.uri != "/v2/alerts" || alert.body["priority"] != "P5" || alert.body["alias"] != "report-r1" {
+		t.Errorf("expected an informational alert, got %+v", alert)
+	}
+}
@@ -0,0 +1,238 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// slackAPIURL is the default base URL of the Slack Web API.
+const slackAPIURL = "https://slack.com/api"
+
+// defaultSlackRemindAfter is how long a firing alert stays quiet before a
+// follow-up is posted in its thread.
+const defaultSlackRemindAfter = time.Hour
+
+// SlackNotifier posts alerts to a Slack channel through a Slack app, and threads
+// their follow-ups under the original message: a reminder every remindAfter
+// while the alert keeps firing, and its recovery, which also marks the original
+// message resolved. A long outage so takes a single message in the channel.
+//
+// Threads are kept in memory, so the recovery of an alert fired before a restart
+// is posted as a new message. Through an incoming webhook, which cannot thread,
+// alerts and recoveries are posted as separate messages without reminders.
+type SlackNotifier struct {
+	url         string
+	webhookURL  string // Set for an incoming webhook instead of an app
+	token       string
+	channel     string
+	remindAfter time.Duration
+	httpClient  *http.Client
+
+	mu      sync.Mutex
+	threads map[string]*slackThread // By alert ID
+}
+
+// slackThread is the message of a firing alert.
+type slackThread struct {
+	ts       string // Timestamp of the original message, which identifies it
+	text     string
+	started  time.Time
+	lastPost time.Time
+}
+
+// NewSlackNotifier creates a notifier posting to channel with the bot token of a
+// Slack app, which needs the chat:write scope. An empty url uses the Slack API,
+// and a zero remindAfter reminds every hour.
+func NewSlackNotifier(url, token, channel string, remindAfter, timeout time.Duration) *SlackNotifier {
+	if url == "" {
+		url = slackAPIURL
+	}
+	if remindAfter <= 0 {
+		remindAfter = defaultSlackRemindAfter
+	}
+	return &SlackNotifier{
+		url:         strings.TrimSuffix(url, "/"),
+		token:       token,
+		channel:     channel,
+		remindAfter: remindAfter,
+		httpClient:  &http.Client{Timeout: timeout},
+		threads:     make(map[string]*slackThread),
+	}
+}
+
+// NewSlackWebhookNotifier creates a notifier posting to a Slack incoming webhook.
+func NewSlackWebhookNotifier(webhookURL string, timeout time.Duration) *SlackNotifier {
+	return &SlackNotifier{webhookURL: webhookURL, httpClient: &http.Client{Timeout: timeout}, threads: make(map[string]*slackThread)}
+}
+
+// Notify implements Notifier.
+func (s *SlackNotifier) Notify(ctx context.Context, n Notification) error {
+	if s.webhookURL != "" {
+		emoji := slackSeverityEmoji(n.Severity)
+		if n.State == StateResolved {
+			emoji = slackResolvedEmoji
+		}
+		body, _ := json.Marshal(map[string]string{"text": emoji + " " + n.Message()})
+		return postJSON(ctx, s.httpClient, s.webhookURL, body, nil)
+	}
+
+	s.mu.Lock()
+	thread := s.threads[n.AlertID]
+	s.mu.Unlock()
+
+	if n.State == StateResolved {
+		if thread == nil {
+			_, err := s.post(ctx, slackResolvedEmoji+" "+n.Message(), "")
+			return err
+		}
+		elapsed := formatElapsed(n.Time.Sub(thread.started))
+		if _, err := s.post(ctx, slackResolvedEmoji+" Recovered after "+elapsed+": "+n.Message(), thread.ts); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		delete(s.threads, n.AlertID)
+		s.mu.Unlock()
+		return s.call(ctx, "chat.update", map[string]string{
+			"channel": s.channel,
+			"ts":      thread.ts,
+			"text":    slackResolvedEmoji + " ~" + thread.text + "~ resolved after " + elapsed,
+		}, nil)
+	}
+
+	text := slackSeverityEmoji(n.Severity) + " " + n.Message()
+	ts, err := s.post(ctx, text, "")
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.threads[n.AlertID] = &slackThread{ts: ts, text: text, started: n.Time, lastPost: n.Time}
+	s.mu.Unlock()
+	return nil
+}
+
+// NotifyReport implements ReportNotifier, posting the digest of the report.
+func (s *SlackNotifier) NotifyReport(ctx context.Context, r models.PortfolioReport) error {
+	text := ":bar_chart: " + ReportDigest(r)
+	if s.webhookURL != "" {
+		body, _ := json.Marshal(map[string]string{"text": text})
+		return postJSON(ctx, s.httpClient, s.webhookURL, body, nil)
+	}
+	_, err := s.post(ctx, text, "")
+	return err
+}
+
+// NotifyUpdate implements UpdateNotifier, posting a reminder in the thread of
+// the alert once remindAfter passed since the last post.
+func (s *SlackNotifier) NotifyUpdate(ctx context.Context, n Notification) error {
+	if s.webhookURL != "" {
+		return nil
+	}
+	s.mu.Lock()
+	thread := s.threads[n.AlertID]
+	if thread == nil || n.Time.Sub(thread.lastPost) < s.remindAfter {
+		s.mu.Unlock()
+		return nil
+	}
+	thread.lastPost = n.Time
+	ts, started := thread.ts, thread.started
+	s.mu.Unlock()
+
+	_, err := s.post(ctx, slackSeverityEmoji(n.Severity)+" Still firing after "+formatElapsed(n.Time.Sub(started))+": "+n.Message(), ts)
+	return err
+}
+
+// post posts a message, in the thread of threadTS if set, and returns its
+// timestamp.
+func (s *SlackNotifier) post(ctx context.Context, text, threadTS string) (string, error) {
+	msg := map[string]string{"channel": s.channel, "text": text}
+	if threadTS != "" {
+		msg["thread_ts"] = threadTS
+	}
+	var resp struct {
+		TS string `json:"ts"`
+	}
+	if err := s.call(ctx, "chat.postMessage", msg, &resp); err != nil {
+		return "", err
+	}
+	return resp.TS, nil
+}
+
+// call calls a Web API method. Slack reports errors in the body of 200 responses.
+func (s *SlackNotifier) call(ctx context.Context, method string, args map[string]string, result any) error {
+	body, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", method, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("call %s: status %d", method, resp.StatusCode)
+	}
+
+	var envelope struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	raw := json.RawMessage{}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return fmt.Errorf("decode %s: %w", method, err)
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return fmt.Errorf("decode %s: %w", method, err)
+	}
+	if !envelope.OK {
+		return fmt.Errorf("call %s: %s", method, envelope.Error)
+	}
+	if result != nil {
+		if err := json.Unmarshal(raw, result); err != nil {
+			return fmt.Errorf("decode %s: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// slackResolvedEmoji marks resolved alerts.
+const slackResolvedEmoji = ":white_check_mark:"
+
+// slackSeverityEmoji returns the emoji prefixing messages of a severity.
+func slackSeverityEmoji(severity string) string {
+	switch severity {
+	case SeverityCritical:
+		return ":rotating_light:"
+	case SeverityInfo:
+		return ":information_source:"
+	default:
+		return ":warning:"
+	}
+}
+
+// formatElapsed formats a duration in whole minutes, e.g. 1h or 2h30m.
+func formatElapsed(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "less than a minute"
+	}
+	s := strings.TrimSuffix(d.String(), "0s")
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,116 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slackCall is a Web API call received by the fake Slack.
+type slackCall struct {
+	method string
+	auth   string
+	args   map[string]string
+}
+
+func TestSlackNotifier_Threads(t *testing.T) {
+	var calls []slackCall
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var args map[string]string
+		json.NewDecoder(r.Body).Decode(&args)
+		calls = append(calls, slackCall{strings.TrimPrefix(r.URL.Path, "/"), r.Header.Get("Authorization"), args})
+		if args["channel"] != "C123" {
+			w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"ts":"1700000000.000100"}`))
+	}))
+	defer server.Close()
+
+	s := NewSlackNotifier(server.URL, "xoxb-token", "C123", time.Hour, time.Second)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	n := Notification{AlertID: "a1", Rule: "offline", Portfolio: "home", Severity: SeverityWarning, State: StateFiring, Condition: "offline > 0", Value: 1, Time: start}
+	ctx := context.Background()
+
+	if err := s.Notify(ctx, n); err != nil {
+		t.Fatal(err)
+	}
+	// Checks within the hour stay quiet, the first one after it reminds
+	n.State = StateOngoing
+	for _, after := range []time.Duration{10 * time.Minute, 50 * time.Minute, 61 * time.Minute, 70 * time.Minute} {
+		n.Time = start.Add(after)
+		if err := s.NotifyUpdate(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n.State = StateResolved
+	n.Time = start.Add(90 * time.Minute)
+	if err := s.Notify(ctx, n); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(calls) != 4 {
+		t.Fatalf("expected a post, a reminder, a recovery and an update, got %d calls: %+v", len(calls), calls)
+	}
+	if c := calls[0]; c.method != "chat.postMessage" || c.auth != "Bearer xoxb-token" || c.args["thread_ts"] != "" || !strings.HasPrefix(c.args["text"], ":warning: [warning] offline firing on home") {
+		t.Errorf("unexpected alert post: %+v", c)
+	}
+	if c := calls[1]; c.args["thread_ts"] != "1700000000.000100" || !strings.HasPrefix(c.args["text"], ":warning: Still firing after 1h1m:") {
+		t.Errorf("unexpected reminder: %+v", c)
+	}
+	if c := calls[2]; c.args["thread_ts"] != "1700000000.000100" || !strings.HasPrefix(c.args["text"], ":white_check_mark: Recovered after 1h30m:") {
+		t.Errorf("unexpected recovery: %+v", c)
+	}
+	if c := calls[3]; c.method != "chat.update" || c.args["ts"] != "1700000000.000100" || !strings.HasSuffix(c.args["text"], "~ resolved after 1h30m") {
+		t.Errorf("unexpected update of the original message: %+v", c)
+	}
+
+	// Slack errors come in 200 responses
+	bad := NewSlackNotifier(server.URL, "xoxb-token", "C999", time.Hour, time.Second)
+	if err := bad.Notify(ctx, Notification{AlertID: "a2", State: StateFiring}); err == nil || !strings.Contains(err.Error(), "channel_not_found") {
+		t.Errorf("expected the Slack error, got %v", err)
+	}
+}
+
+func TestSlackWebhookNotifier(t *testing.T) {
+	var texts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		texts = append(texts, body["text"])
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	s := NewSlackWebhookNotifier(server.URL, time.Second)
+	n := Notification{AlertID: "a1", Rule: "slashed", Portfolio: "home", Severity: SeverityCritical, State: StateFiring, Time: time.Now()}
+	s.Notify(context.Background(), n)
+	n.State = StateOngoing
+	n.Time = n.Time.Add(2 * time.Hour)
+	s.NotifyUpdate(context.Background(), n)
+	n.State = StateResolved
+	s.Notify(context.Background(), n)
+
+	if len(texts) != 2 || !strings.HasPrefix(texts[0], ":rotating_light:") || !strings.HasPrefix(texts[1], ":white_check_mark:") {
+		t.Errorf("expected an alert and a recovery without reminders, got %q", texts)
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	tests := map[time.Duration]string{
+		20 * time.Second:                 "less than a minute",
+		5 * time.Minute:                  "5m",
+		time.Hour + 20*time.Second:       "1h",
+		2*time.Hour + 30*time.Minute:     "2h30m",
+		26*time.Hour + 4*time.Minute + 1: "26h4m",
+	}
+	for d, want := range tests {
+		if got := formatElapsed(d); got != want {
+			t.Errorf("formatElapsed(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Package amount provides exact arithmetic on ether amounts in wei, which the API
+// and upstream exchange as decimal strings. Amounts may be negative, e.g. net
+// rewards of a validator whose penalties exceed its rewards.
+package amount
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	weiPerGwei = big.NewInt(1e9)
+	weiPerEth  = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	weiPerEthF = new(big.Float).SetInt(weiPerEth)
+	hundredF   = big.NewFloat(100)
+	zeroBigInt = new(big.Int)
+)
+
+// ErrEmpty is returned by Parse for an empty string, which upstream uses for
+// amounts it left out.
+var ErrEmpty = errors.New("empty amount")
+
+// Amount is an amount of wei. The zero value is zero wei. Amounts are immutable:
+// arithmetic returns a new Amount.
+type Amount struct {
+	v *big.Int // nil is zero
+}
+
+// Parse parses a decimal wei amount with an optional sign, e.g. "-1500".
+func Parse(s string) (Amount, error) {
+	if s == "" {
+		return Amount{}, ErrEmpty
+	}
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+	return Amount{v: v}, nil
+}
+
+// ParseOrZero parses a decimal wei amount, returning zero for empty or invalid
+// input. It suits upstream fields that are left out when there is nothing to
+// report.
+func ParseOrZero(s string) Amount {
+	a, _ := Parse(s)
+	return a
+}
+
+// Wei returns an amount of n wei.
+func Wei(n int64) Amount {
+	return Amount{v: big.NewInt(n)}
+}
+
+// Gwei returns an amount of n gwei.
+func Gwei(n int64) Amount {
+	return Amount{v: new(big.Int).Mul(big.NewInt(n), weiPerGwei)}
+}
+
+// Ether returns an amount of n ETH.
+func Ether(n int64) Amount {
+	return Amount{v: new(big.Int).Mul(big.NewInt(n), weiPerEth)}
+}
+
+// FromBig returns an amount of v wei. v is copied.
+func FromBig(v *big.Int) Amount {
+	return Amount{v: new(big.Int).Set(v)}
+}
+
+// Sum returns the sum of amounts.
+func Sum(amounts ...Amount) Amount {
+	total := new(big.Int)
+	for _, a := range amounts {
+		total.Add(total, a.big())
+	}
+	return Amount{v: total}
+}
+
+// big returns the value of a without copying it, so it must not be modified.
+func (a Amount) big() *big.Int {
+	if a.v == nil {
+		return zeroBigInt
+	}
+	return a.v
+}
+
+// Big returns a copy of the value of a.
+func (a Amount) Big() *big.Int {
+	return new(big.Int).Set(a.big())
+}
+
+// Add returns a + b.
+func (a Amount) Add(b Amount) Amount {
+	return Amount{v: new(big.Int).Add(a.big(), b.big())}
+}
+
+// Sub returns a - b.
+func (a Amount) Sub(b Amount) Amount {
+	return Amount{v: new(big.Int).Sub(a.big(), b.big())}
+}
+
+// Neg returns -a.
+func (a Amount) Neg() Amount {
+	return Amount{v: new(big.Int).Neg(a.big())}
+}
+
+// Abs returns |a|.
+func (a Amount) Abs() Amount {
+	return Amount{v: new(big.Int).Abs(a.big())}
+}
+
+// Div returns a divided by n, rounded toward zero. It panics if n is zero.
+func (a Amount) Div(n int64) Amount {
+	return Amount{v: new(big.Int).Quo(a.big(), big.NewInt(n))}
+}
+
+// Scale returns a multiplied by f, rounded toward zero, e.g. to annualize the
+// rewards of a shorter period.
+func (a Amount) Scale(f float64) Amount {
+	product := new(big.Float).SetPrec(256).Mul(new(big.Float).SetInt(a.big()), big.NewFloat(f))
+	scaled, _ := product.Int(nil)
+	return Amount{v: scaled}
+}
+
+// Percent returns a as a percentage of total, e.g. 25 for a quarter. It reports
+// false if total is zero.
+func (a Amount) Percent(total Amount) (float64, bool) {
+	if total.IsZero() {
+		return 0, false
+	}
+	ratio := new(big.Float).Quo(new(big.Float).SetInt(a.big()), new(big.Float).SetInt(total.big()))
+	percent, _ := ratio.Mul(ratio, hundredF).Float64()
+	return percent, true
+}
+
+// Cmp compares a and b, returning -1, 0 or +1.
+func (a Amount) Cmp(b Amount) int {
+	return a.big().Cmp(b.big())
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of a.
+func (a Amount) Sign() int {
+	return a.big().Sign()
+}
+
+// IsZero reports whether a is zero.
+func (a Amount) IsZero() bool {
+	return a.Sign() == 0
+}
+
+// ETH returns a in ETH, rounded to the nearest float64.
+func (a Amount) ETH() float64 {
+	eth, _ := new(big.Float).Quo(new(big.Float).SetInt(a.big()), weiPerEthF).Float64()
+	return eth
+}
+
+// String returns a as a decimal wei string.
+func (a Amount) String() string {
+	return a.big().String()
+}
+
+// MarshalJSON encodes a as a decimal wei string, the format of the API.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON decodes a decimal wei string or a JSON integer. An empty string
+// is zero.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := strings.TrimSpace(string(data))
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			*a = Amount{}
+			return nil
+		}
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
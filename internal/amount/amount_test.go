@@ -0,0 +1,115 @@
+package amount
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"1500", "1500", false},
+		{"-1500", "-1500", false},
+		{"+7", "7", false},
+		{"0", "0", false},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639935", "115792089237316195423570985008687907853269984665640564039457584007913129639935", false},
+		{"", "", true},
+		{"1.5", "", true},
+		{"0x10", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+	if _, err := Parse(""); !errors.Is(err, ErrEmpty) {
+		t.Errorf("expected ErrEmpty for an empty amount, got %v", err)
+	}
+	if got := ParseOrZero("n/a"); !got.IsZero() {
+		t.Errorf("expected zero for an invalid amount, got %s", got)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	reward, penalty := Wei(1000), Wei(1500)
+
+	net := reward.Sub(penalty)
+	if net.String() != "-500" || net.Sign() != -1 {
+		t.Errorf("expected -500, got %s", net)
+	}
+	if got := net.Abs(); got.String() != "500" {
+		t.Errorf("expected 500, got %s", got)
+	}
+	if got := net.Neg(); got.String() != "500" {
+		t.Errorf("expected 500, got %s", got)
+	}
+	if got := Sum(reward, penalty, net); got.String() != "2000" {
+		t.Errorf("expected 2000, got %s", got)
+	}
+	if got := net.Div(3); got.String() != "-166" {
+		t.Errorf("expected division toward zero, got %s", got)
+	}
+	if got := Ether(1_000_001).Scale(0.75); got.String() != "750000750000000000000000" {
+		t.Errorf("expected exact scaling, got %s", got)
+	}
+	if got := net.Scale(0.5); got.String() != "-250" {
+		t.Errorf("expected -250, got %s", got)
+	}
+	if reward.String() != "1000" || penalty.String() != "1500" {
+		t.Error("expected the operands to be unchanged")
+	}
+	if got := (Amount{}).Add(Wei(1)); got.String() != "1" {
+		t.Errorf("expected the zero value to be zero, got %s", got)
+	}
+	if Ether(32).Cmp(Gwei(32_000_000_000)) != 0 {
+		t.Error("expected 32 ETH to equal 32e9 gwei")
+	}
+	if got := Ether(-3).ETH(); got != -3 {
+		t.Errorf("expected -3 ETH, got %v", got)
+	}
+}
+
+func TestPercent(t *testing.T) {
+	if got, ok := Wei(-25).Percent(Wei(100)); !ok || got != -25 {
+		t.Errorf("expected -25%%, got %v (%t)", got, ok)
+	}
+	if _, ok := Wei(25).Percent(Amount{}); ok {
+		t.Error("expected no percentage of zero")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		Total   Amount `json:"total"`
+		Penalty Amount `json:"penalty"`
+		Missed  Amount `json:"missed"`
+		Omitted Amount `json:"omitted"`
+	}
+	if err := json.Unmarshal([]byte(`{"total":"-1500","penalty":2000,"missed":"","omitted":null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Total.String() != "-1500" || v.Penalty.String() != "2000" || !v.Missed.IsZero() || !v.Omitted.IsZero() {
+		t.Errorf("unexpected amounts %+v", v)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"total":"-1500","penalty":"2000","missed":"0","omitted":"0"}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	if err := json.Unmarshal([]byte(`{"total":"1e18"}`), &v); err == nil {
+		t.Error("expected an error for a non-integer amount")
+	}
+}
@@ -0,0 +1,136 @@
+// Package anomaly describes known network incidents that distort aggregate statistics.
+//
+// Testnets regularly go through incidents (mass slashings, non-finality with
+// inactivity leaks) that say nothing about how well an operator runs their
+// validators. A Filter holds the epoch windows of such incidents per chain so
+// they can be excluded from aggregates.
+package anomaly
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// knownWindows are the incident windows shipped with the service, used when no
+// windows file is configured.
+//
+//go:embed windows.json
+var knownWindows []byte
+
+// Kind identifies the type of incident.
+type Kind string
+
+const (
+	// KindMassSlashing marks a window in which many validators were slashed at once.
+	// Validators slashed during the window are excluded from aggregates.
+	KindMassSlashing Kind = "mass_slashing"
+	// KindInactivityLeak marks a window of non-finality.
+	// Inactivity leak penalties are excluded from rewards when the window overlaps the request range.
+	KindInactivityLeak Kind = "inactivity_leak"
+)
+
+// Window is an incident on a chain between two epochs (inclusive).
+type Window struct {
+	Chain      string `json:"chain"`
+	Name       string `json:"name"`
+	Kind       Kind   `json:"kind"`
+	StartEpoch int64  `json:"startEpoch"`
+	EndEpoch   int64  `json:"endEpoch"`
+}
+
+// Contains reports whether epoch lies within the window.
+func (w Window) Contains(epoch int64) bool {
+	return epoch >= w.StartEpoch && epoch <= w.EndEpoch
+}
+
+// Overlaps reports whether the window intersects the epoch range [start, end].
+func (w Window) Overlaps(start, end int64) bool {
+	return w.StartEpoch <= end && w.EndEpoch >= start
+}
+
+// Filter holds the known incident windows.
+type Filter struct {
+	windows []Window
+}
+
+// NewFilter creates a filter from the given windows.
+func NewFilter(windows []Window) (*Filter, error) {
+	for _, w := range windows {
+		if w.Chain == "" || w.Name == "" {
+			return nil, fmt.Errorf("anomaly window must have a chain and a name")
+		}
+		if w.Kind != KindMassSlashing && w.Kind != KindInactivityLeak {
+			return nil, fmt.Errorf("anomaly window %q: unknown kind %q", w.Name, w.Kind)
+		}
+		if w.EndEpoch < w.StartEpoch {
+			return nil, fmt.Errorf("anomaly window %q: end epoch %d before start epoch %d", w.Name, w.EndEpoch, w.StartEpoch)
+		}
+	}
+	return &Filter{windows: windows}, nil
+}
+
+// LoadFile reads incident windows from a JSON file containing an array of windows.
+// An empty path returns a filter with the known windows shipped with the service.
+func LoadFile(path string) (*Filter, error) {
+	if path == "" {
+		return parse(knownWindows)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read anomaly windows: %w", err)
+	}
+	return parse(data)
+}
+
+// parse decodes a JSON array of windows into a filter.
+func parse(data []byte) (*Filter, error) {
+	var windows []Window
+	if err := json.Unmarshal(data, &windows); err != nil {
+		return nil, fmt.Errorf("decode anomaly windows: %w", err)
+	}
+
+	return NewFilter(windows)
+}
+
+// MassSlashing returns the mass slashing window on chain containing epoch, if any.
+func (f *Filter) MassSlashing(chain string, epoch int64) (Window, bool) {
+	if f == nil {
+		return Window{}, false
+	}
+	for _, w := range f.windows {
+		if w.Chain == chain && w.Kind == KindMassSlashing && w.Contains(epoch) {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
+
+// HasMassSlashings reports whether any mass slashing window is known on chain.
+func (f *Filter) HasMassSlashings(chain string) bool {
+	if f == nil {
+		return false
+	}
+	for _, w := range f.windows {
+		if w.Chain == chain && w.Kind == KindMassSlashing {
+			return true
+		}
+	}
+	return false
+}
+
+// InactivityLeaks returns the inactivity leak windows on chain overlapping the epoch range [start, end].
+func (f *Filter) InactivityLeaks(chain string, start, end int64) []Window {
+	if f == nil {
+		return nil
+	}
+	var result []Window
+	for _, w := range f.windows {
+		if w.Chain == chain && w.Kind == KindInactivityLeak && w.Overlaps(start, end) {
+			result = append(result, w)
+		}
+	}
+	return result
+}
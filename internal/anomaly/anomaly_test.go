@@ -0,0 +1,34 @@
+package anomaly
+
+import "testing"
+
+func TestNewFilter_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		window Window
+	}{
+		{name: "missing chain", window: Window{Name: "x", Kind: KindMassSlashing}},
+		{name: "unknown kind", window: Window{Chain: "hoodi", Name: "x", Kind: "outage"}},
+		{name: "reversed epochs", window: Window{Chain: "hoodi", Name: "x", Kind: KindInactivityLeak, StartEpoch: 10, EndEpoch: 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewFilter([]Window{tt.window}); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoadFile_Default(t *testing.T) {
+	f, err := LoadFile("")
+	if err != nil {
+		t.Fatalf("known windows are invalid: %v", err)
+	}
+	for _, w := range f.windows {
+		if w.Chain != "hoodi" {
+			t.Errorf("known window %q is on chain %q, want hoodi", w.Name, w.Chain)
+		}
+	}
+}
@@ -2,99 +2,2002 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/alerts"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/audit"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/backup"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/budget"
 	"github.com/Marketen/validator-dashboard-beaconcha/internal/config"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/deposit"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/exits"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/jobs"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/labels"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/locale"
 	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/notes"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/reports"
 	"github.com/Marketen/validator-dashboard-beaconcha/internal/service"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/tenant"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/tracing"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/units"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/vcmetrics"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/web"
 )
 
 // Handler provides HTTP handlers for the API.
 type Handler struct {
 	validatorService *service.ValidatorService
 	config           *config.Config
+	alerts           *alerts.Engine        // Optional, see SetAlerts
+	jobs             *jobs.Manager         // Optional, see SetJobs
+	reports          *reports.Scheduler    // Optional, see SetReports
+	audit            *audit.Log            // Optional, see SetAudit
+	backup           *backup.Archive       // Optional, see SetBackup
+	clientMetrics    *vcmetrics.Correlator // Optional, see SetClientMetrics
+	idempotency      *idempotencyStore     // Nil when IDEMPOTENCY_WINDOW is 0
 }
 
 // NewHandler creates a new API handler.
 func NewHandler(validatorService *service.ValidatorService, cfg *config.Config) *Handler {
-	return &Handler{
+	h := &Handler{
 		validatorService: validatorService,
 		config:           cfg,
 	}
+	if cfg.IdempotencyWindow > 0 {
+		h.idempotency = newIdempotencyStore(cfg.IdempotencyWindow)
+	}
+	return h
+}
+
+// SetAlerts enables the alert endpoints, managing the rules and mutes of engine.
+func (h *Handler) SetAlerts(engine *alerts.Engine) {
+	h.alerts = engine
+}
+
+// SetJobs enables the background job endpoints, queueing jobs on manager.
+func (h *Handler) SetJobs(manager *jobs.Manager) {
+	h.jobs = manager
+}
+
+// SetReports enables the report endpoints, serving the reports kept by scheduler.
+func (h *Handler) SetReports(scheduler *reports.Scheduler) {
+	h.reports = scheduler
+}
+
+// SetAudit records the changes made through the API in trail and enables the
+// audit endpoint.
+func (h *Handler) SetAudit(trail *audit.Log) {
+	h.audit = trail
+}
+
+// SetBackup enables the state export and import endpoints, archiving the data
+// directory of archive.
+func (h *Handler) SetBackup(archive *backup.Archive) {
+	h.backup = archive
+}
+
+// SetClientMetrics enables the validator client miss analysis of correlator.
+func (h *Handler) SetClientMetrics(correlator *vcmetrics.Correlator) {
+	h.clientMetrics = correlator
+}
+
+// Router returns the HTTP router with all routes configured.
+func (h *Handler) Router() http.Handler {
+	mux := http.NewServeMux()
+
+	// Health check endpoint
+	mux.HandleFunc("GET /health", h.handleHealth)
+
+	// Validator endpoint (GET for cacheability)
+	mux.Handle("GET /validator", h.costMiddleware(h.idempotencyMiddleware(http.HandlerFunc(h.handleValidator))))
+
+	// Daily income time series
+	mux.Handle("GET /validator/income/daily", h.costMiddleware(http.HandlerFunc(h.handleDailyIncome)))
+
+	// Per-epoch balance history of a single validator
+	mux.Handle("GET /validator/{id}/balance-history", h.costMiddleware(http.HandlerFunc(h.handleBalanceHistory)))
+
+	// Status and online transitions of a single validator, from recorded history
+	mux.Handle("GET /validator/{id}/timeline", h.costMiddleware(http.HandlerFunc(h.handleTimeline)))
+
+	// Slashing details of a single validator
+	mux.Handle("GET /validator/{id}/slashing", h.costMiddleware(http.HandlerFunc(h.handleSlashing)))
+
+	// Attestation effectiveness trend from recorded history
+	mux.Handle("GET /validator/attestations/trend", h.costMiddleware(http.HandlerFunc(h.handleAttestationTrend)))
+
+	// Validators changed since a time, from recorded history
+	mux.Handle("GET /validator/delta", h.costMiddleware(http.HandlerFunc(h.handleValidatorDelta)))
+
+	// Projected earnings at recent and network reward rates
+	mux.Handle("GET /validator/projection", h.costMiddleware(http.HandlerFunc(h.handleProjection)))
+
+	// Composite health score per validator and for the fleet
+	mux.Handle("GET /validator/health", h.costMiddleware(http.HandlerFunc(h.handleHealthScore)))
+
+	// Uptime per validator and group over SLA windows, from recorded history
+	mux.Handle("GET /sla", h.costMiddleware(http.HandlerFunc(h.handleSLA)))
+
+	// Grafana JSON datasource over recorded history
+	mux.HandleFunc("GET /grafana", h.handleGrafanaTest)
+	mux.HandleFunc("GET /grafana/{$}", h.handleGrafanaTest)
+	mux.HandleFunc("POST /grafana/search", h.handleGrafanaSearch)
+	mux.HandleFunc("POST /grafana/query", h.handleGrafanaQuery)
+
+	// Sync committee assignments and participation
+	mux.Handle("GET /validator/sync-committees", h.costMiddleware(http.HandlerFunc(h.handleSyncCommittees)))
+
+	// Graffiti and inferred clients of proposed blocks
+	mux.Handle("GET /validator/graffiti", h.costMiddleware(http.HandlerFunc(h.handleGraffiti)))
+
+	// Current ETH fiat price
+	mux.Handle("GET /price", h.costMiddleware(http.HandlerFunc(h.handlePrice)))
+
+	// Income reconciliation against withdrawals
+	mux.Handle("GET /validator/reconciliation", h.costMiddleware(http.HandlerFunc(h.handleReconciliation)))
+
+	// Combined summary of configured portfolios for the landing page
+	mux.Handle("GET /dashboard", h.costMiddleware(http.HandlerFunc(h.handleDashboard)))
+
+	// Distributed validator clusters, aggregated per cluster
+	mux.Handle("GET /clusters", h.costMiddleware(http.HandlerFunc(h.handleClusters)))
+
+	// Side-by-side aggregates of portfolios and label groups
+	mux.Handle("GET /compare", h.costMiddleware(http.HandlerFunc(h.handleCompare)))
+
+	// Upstream credit usage against the daily budget
+	mux.Handle("GET /admin/usage", h.adminTokenMiddleware(http.HandlerFunc(h.handleUsage)))
+	mux.Handle("GET /admin/cache", h.adminTokenMiddleware(http.HandlerFunc(h.handleCacheStats)))
+	mux.Handle("GET /admin/audit", h.adminTokenMiddleware(http.HandlerFunc(h.handleAudit)))
+	mux.Handle("GET /admin/export", h.adminTokenMiddleware(http.HandlerFunc(h.handleExport)))
+	mux.Handle("POST /admin/import", h.adminTokenMiddleware(http.HandlerFunc(h.handleImport)))
+
+	// Alerts fired by the rules, and their acknowledgement
+	mux.HandleFunc("GET /alerts", h.handleAlerts)
+	mux.HandleFunc("POST /alerts/{id}/ack", h.handleAckAlert)
+
+	// Alert rules checked against portfolios
+	mux.HandleFunc("GET /alerts/rules", h.handleAlertRules)
+	mux.HandleFunc("PUT /alerts/rules/{name}", h.handlePutAlertRule)
+	mux.HandleFunc("DELETE /alerts/rules/{name}", h.handleDeleteAlertRule)
+
+	// Mute windows silencing alerts during planned downtime
+	mux.HandleFunc("GET /alerts/mute", h.handleAlertMutes)
+	mux.HandleFunc("POST /alerts/mute", h.handleAddAlertMute)
+	mux.HandleFunc("DELETE /alerts/mute/{id}", h.handleDeleteAlertMute)
+
+	// Background jobs for reports over more validators than a request may ask for
+	mux.HandleFunc("POST /jobs/validator-report", h.handleSubmitValidatorReport)
+	mux.HandleFunc("GET /jobs/{id}", h.handleJob)
+	mux.HandleFunc("GET /jobs/{id}/result", h.handleJobResult)
+
+	// Scheduled portfolio reports
+	mux.HandleFunc("GET /reports", h.handleReports)
+	mux.HandleFunc("GET /reports/{$}", h.handleReports)
+	mux.HandleFunc("GET /reports/{id}", h.handleReport)
+
+	// User-defined validator labels
+	mux.HandleFunc("GET /labels", h.handleLabels)
+	mux.HandleFunc("PUT /validator/{id}/labels", h.handlePutLabels)
+
+	// Freeform validator notes, and search by labels and notes
+	mux.HandleFunc("PUT /validator/{id}/notes", h.handlePutNotes)
+	mux.HandleFunc("GET /validator/search", h.handleValidatorSearch)
+
+	// Search across portfolios, validators, labels and notes
+	mux.HandleFunc("GET /search", h.handleSearch)
+
+	// Bulk import of validators into a portfolio from deposit data or public keys
+	mux.Handle("POST /watchlist/{name}/import", h.costMiddleware(http.HandlerFunc(h.handleWatchlistImport)))
+
+	// Client distribution of the fleet against the network
+	mux.HandleFunc("GET /diversity", h.handleDiversity)
+
+	// Validator client signing against on-chain inclusion
+	mux.HandleFunc("GET /validator-client/misses", h.handleClientMisses)
+
+	// Pre-signed voluntary exit metadata
+	mux.HandleFunc("GET /exits", h.handlePresignedExits)
+	mux.HandleFunc("PUT /validator/{id}/exit", h.handlePutPresignedExit)
+	mux.HandleFunc("DELETE /validator/{id}/exit", h.handleDeletePresignedExit)
+
+	// Serve the API under the prefix of every version, and unversioned in the default one
+	root := versionedRouter(mux)
+
+	// Embedded dashboard UI
+	ui := web.Handler()
+	root.Handle("GET /{$}", ui)
+	root.Handle("GET /assets/", ui)
+	root.Handle("GET /types.ts", ui)
+
+	// Apply middleware
+	handler := h.formatMiddleware(root)
+	handler = h.recoveryMiddleware(handler)
+	handler = h.auditMiddleware(handler)
+	handler = h.loggingMiddleware(handler)
+	handler = h.corsMiddleware(handler)
+	handler = h.maxBodySizeMiddleware(handler, 1<<20) // 1 MB max body size
+	handler = tracing.NewSampler(h.config.TraceSampler, h.config.TraceSampleRatio).Middleware(handler)
+
+	return handler
+}
+
+// handleHealth returns API health status.
+func (h *Handler) handleHealth(w http.ResponseWriter, r *http.Request) {
+	h.jsonResponse(w, r, http.StatusOK, map[string]string{
+		"status": "healthy",
+		"time":   time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// handleUsage returns the upstream credits spent today against the daily budget.
+func (h *Handler) handleUsage(w http.ResponseWriter, r *http.Request) {
+	h.jsonResponse(w, r, http.StatusOK, h.validatorService.Usage())
+}
+
+// handleCacheStats returns the size, limits and counters of the in-memory caches.
+func (h *Handler) handleCacheStats(w http.ResponseWriter, r *http.Request) {
+	h.jsonResponse(w, r, http.StatusOK, h.validatorService.CacheStats())
+}
+
+// handleAudit handles GET /admin/audit requests.
+func (h *Handler) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if h.audit == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "audit_disabled", "The audit log requires DATA_DIR")
+		return
+	}
+
+	query := r.URL.Query()
+	q := audit.Query{Limit: 100}
+	if v := query.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "since: must be an RFC 3339 time")
+			return
+		}
+		q.Since = since
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "limit: must be between 1 and "+strconv.Itoa(maxAuditLimit))
+			return
+		}
+		q.Limit = limit
+	}
+	h.jsonResponse(w, r, http.StatusOK, models.AuditResponse{Entries: h.audit.Entries(q)})
+}
+
+// handleAlerts handles GET /alerts requests.
+func (h *Handler) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	if h.alerts == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "alerts_disabled", "Alerts are not enabled")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", alerts.StatusOpen, alerts.StatusAcknowledged, alerts.StatusResolved:
+	default:
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "status: must be one of: open, acknowledged, resolved")
+		return
+	}
+	h.jsonResponse(w, r, http.StatusOK, models.AlertsResponse{Alerts: h.alerts.Alerts(status)})
+}
+
+// handleAckAlert handles POST /alerts/{id}/ack requests. The body is optional.
+func (h *Handler) handleAckAlert(w http.ResponseWriter, r *http.Request) {
+	if h.alerts == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "alerts_disabled", "Alerts are not enabled")
+		return
+	}
+
+	var ack models.AlertAckRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&ack); err != nil && !errors.Is(err, io.EOF) {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "invalid acknowledgement: "+err.Error())
+		return
+	}
+
+	alert, err := h.alerts.Ack(r.PathValue("id"), ack.By)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusNotFound, "not_found", "Alert "+r.PathValue("id")+" not found")
+		return
+	}
+	h.jsonResponse(w, r, http.StatusOK, alert)
+}
+
+// handleAlertRules handles GET /alerts/rules requests.
+func (h *Handler) handleAlertRules(w http.ResponseWriter, r *http.Request) {
+	if h.alerts == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "alerts_disabled", "Alerts are not enabled")
+		return
+	}
+	h.jsonResponse(w, r, http.StatusOK, models.AlertRulesResponse{Rules: h.alerts.Rules()})
+}
+
+// handlePutAlertRule handles PUT /alerts/rules/{name} requests, adding or replacing a rule.
+func (h *Handler) handlePutAlertRule(w http.ResponseWriter, r *http.Request) {
+	if h.alerts == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "alerts_disabled", "Alerts are not enabled")
+		return
+	}
+
+	var rule models.AlertRule
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rule); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "invalid rule: "+err.Error())
+		return
+	}
+	name := r.PathValue("name")
+	if rule.Name != "" && rule.Name != name {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "name: must match the rule name in the path")
+		return
+	}
+	rule.Name = name
+
+	if err := h.alerts.SetRule(rule); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	for _, status := range h.alerts.Rules() {
+		if status.Name == name {
+			h.jsonResponse(w, r, http.StatusOK, status)
+			return
+		}
+	}
+}
+
+// handleDeleteAlertRule handles DELETE /alerts/rules/{name} requests.
+func (h *Handler) handleDeleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	if h.alerts == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "alerts_disabled", "Alerts are not enabled")
+		return
+	}
+	if !h.alerts.DeleteRule(r.PathValue("name")) {
+		h.errorResponse(w, r, http.StatusNotFound, "not_found", "Alert rule "+r.PathValue("name")+" not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAlertMutes handles GET /alerts/mute requests.
+func (h *Handler) handleAlertMutes(w http.ResponseWriter, r *http.Request) {
+	if h.alerts == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "alerts_disabled", "Alerts are not enabled")
+		return
+	}
+	h.jsonResponse(w, r, http.StatusOK, models.AlertMutesResponse{Mutes: h.alerts.Mutes()})
+}
+
+// handleAddAlertMute handles POST /alerts/mute requests, scheduling a mute window.
+func (h *Handler) handleAddAlertMute(w http.ResponseWriter, r *http.Request) {
+	if h.alerts == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "alerts_disabled", "Alerts are not enabled")
+		return
+	}
+
+	var mute models.AlertMute
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&mute); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "invalid mute window: "+err.Error())
+		return
+	}
+
+	mute, err := h.alerts.AddMute(mute)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	h.jsonResponse(w, r, http.StatusCreated, mute)
+}
+
+// handleDeleteAlertMute handles DELETE /alerts/mute/{id} requests, ending a mute window.
+func (h *Handler) handleDeleteAlertMute(w http.ResponseWriter, r *http.Request) {
+	if h.alerts == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "alerts_disabled", "Alerts are not enabled")
+		return
+	}
+	if !h.alerts.DeleteMute(r.PathValue("id")) {
+		h.errorResponse(w, r, http.StatusNotFound, "not_found", "Mute window "+r.PathValue("id")+" not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// jobTypeValidatorReport is the type of the jobs submitted to POST /jobs/validator-report.
+const jobTypeValidatorReport = "validator-report"
+
+// handleSubmitValidatorReport handles POST /jobs/validator-report requests, queueing
+// a report over up to MAX_REPORT_VALIDATOR_IDS validators.
+func (h *Handler) handleSubmitValidatorReport(w http.ResponseWriter, r *http.Request) {
+	if h.jobs == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "jobs_disabled", "Background jobs are not enabled")
+		return
+	}
+
+	var body models.ValidatorReportRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "invalid report request: "+err.Error())
+		return
+	}
+
+	// Same defaults as GET /validator
+	if body.Range == "" {
+		body.Range = "all_time"
+	}
+	if body.Anomalies == "" {
+		body.Anomalies = "include"
+		if body.Chain == "hoodi" {
+			body.Anomalies = "exclude"
+		}
+	}
+	if body.Anomalies != "include" && body.Anomalies != "exclude" {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "anomalies: must be one of: include, exclude")
+		return
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds:     body.ValidatorIds,
+		Chain:            body.Chain,
+		Range:            body.Range,
+		ExcludeAnomalies: body.Anomalies == "exclude",
+	}
+	if err := h.validateRequest(req, h.config.MaxReportValidatorIDs); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	job, err := h.jobs.Submit(jobTypeValidatorReport, len(req.ValidatorIds), func(ctx context.Context, progress func(done int)) (any, error) {
+		return h.validatorService.GetValidatorReport(ctx, req, progress)
+	})
+	if errors.Is(err, jobs.ErrQueueFull) {
+		h.errorResponse(w, r, http.StatusServiceUnavailable, "queue_full", "Too many jobs are waiting to run, try again later")
+		return
+	}
+	if err != nil {
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to submit job")
+		return
+	}
+
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	h.jsonResponse(w, r, http.StatusAccepted, job)
+}
+
+// handleJob handles GET /jobs/{id} requests.
+func (h *Handler) handleJob(w http.ResponseWriter, r *http.Request) {
+	if h.jobs == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "jobs_disabled", "Background jobs are not enabled")
+		return
+	}
+
+	job, ok := h.jobs.Get(r.PathValue("id"))
+	if !ok {
+		h.errorResponse(w, r, http.StatusNotFound, "not_found", "Job "+r.PathValue("id")+" not found")
+		return
+	}
+	if job.Status == jobs.StatusDone {
+		job.ResultURL = "/jobs/" + job.ID + "/result"
+	}
+	h.jsonResponse(w, r, http.StatusOK, job)
+}
+
+// handleJobResult handles GET /jobs/{id}/result requests, serving the result of a
+// finished job as a download.
+func (h *Handler) handleJobResult(w http.ResponseWriter, r *http.Request) {
+	if h.jobs == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "jobs_disabled", "Background jobs are not enabled")
+		return
+	}
+
+	job, result, ok := h.jobs.Result(r.PathValue("id"))
+	if !ok {
+		h.errorResponse(w, r, http.StatusNotFound, "not_found", "Job "+r.PathValue("id")+" not found")
+		return
+	}
+	switch job.Status {
+	case jobs.StatusDone:
+	case jobs.StatusFailed:
+		h.errorResponse(w, r, http.StatusConflict, "job_failed", "Job "+job.ID+" failed: "+job.Error)
+		return
+	default:
+		h.errorResponse(w, r, http.StatusConflict, "job_not_done", "Job "+job.ID+" is "+job.Status)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="`+job.Type+"-"+job.ID+`.json"`)
+	h.jsonResponse(w, r, http.StatusOK, result)
+}
+
+// handleReports handles GET /reports requests, listing the stored reports.
+func (h *Handler) handleReports(w http.ResponseWriter, r *http.Request) {
+	if h.reports == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "reports_disabled", "Reports are not enabled")
+		return
+	}
+	h.jsonResponse(w, r, http.StatusOK, models.ReportsResponse{Reports: h.reports.Reports()})
+}
+
+// handleReport handles GET /reports/{id} requests, serving a report as a download.
+func (h *Handler) handleReport(w http.ResponseWriter, r *http.Request) {
+	if h.reports == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "reports_disabled", "Reports are not enabled")
+		return
+	}
+
+	report, ok := h.reports.Report(r.PathValue("id"))
+	if !ok {
+		h.errorResponse(w, r, http.StatusNotFound, "not_found", "Report "+r.PathValue("id")+" not found")
+		return
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+report.Schedule+"-"+report.GeneratedAt.Format("2006-01-02")+`.json"`)
+	h.jsonResponse(w, r, http.StatusOK, report)
+}
+
+// budgetExhaustedResponse answers a request that needs upstream data after the
+// daily budget is spent.
+func (h *Handler) budgetExhaustedResponse(w http.ResponseWriter, r *http.Request) {
+	usage := h.validatorService.Usage()
+	w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(usage.ResetsAt).Seconds())+1))
+	h.errorResponse(w, r, http.StatusServiceUnavailable, "budget_exhausted", "Daily upstream budget exhausted, try again after "+usage.ResetsAt.Format(time.RFC3339))
+}
+
+// handleValidator handles GET /validator requests.
+func (h *Handler) handleValidator(w http.ResponseWriter, r *http.Request) {
+	// Parse query parameters
+	idsParam := r.URL.Query().Get("ids")
+	chain := r.URL.Query().Get("chain")
+	evalRange := r.URL.Query().Get("range")
+	anomalies := r.URL.Query().Get("anomalies")
+	currency := strings.ToLower(r.URL.Query().Get("currency"))
+	labelParams := r.URL.Query()["label"]
+	groupBy := r.URL.Query().Get("groupBy")
+	compare := r.URL.Query().Get("compare")
+
+	fields, err := service.ParseFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "fields: "+err.Error())
+		return
+	}
+
+	// Default range to all_time if not specified. A custom window replaces it once
+	// the rest of the request is validated.
+	window := hasWindow(r.URL.Query())
+	if window && evalRange != "" {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "range: cannot be combined with from, to, fromEpoch or toEpoch")
+		return
+	}
+	if evalRange == "" {
+		evalRange = "all_time"
+	}
+
+	// Known incidents are excluded by default on testnets only
+	if anomalies == "" {
+		anomalies = "include"
+		if chain == "hoodi" {
+			anomalies = "exclude"
+		}
+	}
+	if anomalies != "include" && anomalies != "exclude" {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "anomalies: must be one of: include, exclude")
+		return
+	}
+	if compare != "" && compare != "previous" {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "compare: must be previous")
+		return
+	}
+	if compare != "" && window {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "compare: is not available for custom windows")
+		return
+	}
+
+	// Parse validator IDs from comma-separated string
+	validatorIds, err := h.parseValidatorIds(idsParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	if groupBy != "" {
+		if err := labels.ValidateKey(groupBy); err != nil {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "groupBy: "+err.Error())
+			return
+		}
+	}
+
+	// Labels select validators instead of, or among, the listed IDs
+	if len(labelParams) > 0 {
+		selectors := make([]labels.Selector, 0, len(labelParams))
+		for _, p := range labelParams {
+			sel, err := labels.ParseSelector(p)
+			if err != nil {
+				h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "label: "+err.Error())
+				return
+			}
+			selectors = append(selectors, sel)
+		}
+		labeled := h.validatorService.LabeledValidators(chain, selectors)
+		if idsParam != "" {
+			labeled = slices.DeleteFunc(labeled, func(id int) bool { return !slices.Contains(validatorIds, id) })
+		}
+		if len(labeled) == 0 {
+			h.errorResponse(w, r, http.StatusNotFound, "not_found", "No validators on "+chain+" match the labels")
+			return
+		}
+		validatorIds = labeled
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds:     validatorIds,
+		Chain:            chain,
+		Range:            evalRange,
+		ExcludeAnomalies: anomalies == "exclude",
+		Currency:         currency,
+		Labels:           labelParams,
+		GroupBy:          groupBy,
+		Fields:           fields,
+		ComparePrevious:  compare == "previous",
+	}
+
+	// Validate request
+	if err := h.validateValidatorRequest(req); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if window {
+		if req.Range, err = parseWindow(r.URL.Query(), req.Chain, time.Now()); err != nil {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+		if req.Fields, err = service.WindowFields(req.Fields); err != nil {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "fields: "+err.Error())
+			return
+		}
+	}
+	timeout, err := h.requestTimeout(r)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	// Nothing can have changed if the client's copy is from after the latest refresh
+	// and that refresh already included the latest epoch
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		if h.validatorService.UnchangedSince(r.Context(), req.Chain, req.ValidatorIds, since) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	// Fetch validator data, returning what was fetched when the timeout passes
+	ctx := r.Context()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+		w.Header().Set("X-Request-Timeout", timeout.String())
+	}
+	response, err := h.validatorService.GetValidatorData(ctx, req)
+	if errors.Is(err, budget.ErrExhausted) {
+		h.budgetExhaustedResponse(w, r)
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		h.errorResponse(w, r, http.StatusGatewayTimeout, "timeout", "Validator data could not be fetched within "+timeout.String())
+		return
+	}
+	if err != nil {
+		slog.Error("failed to fetch validator data", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to fetch validator data")
+		return
+	}
+
+	if refreshed := h.validatorService.LastRefresh(r.Context(), req.Chain, req.ValidatorIds); !refreshed.IsZero() {
+		w.Header().Set("Last-Modified", refreshed.UTC().Format(http.TimeFormat))
+	}
+	versioned := requestVersion(r).validator(response)
+	if len(req.Fields) > 0 {
+		// Units are converted before the selection, which drops the types of the fields
+		converted, err := convertAmounts(r, versioned)
+		if err != nil {
+			slog.Error("failed to convert response units", "error", err)
+			h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to fetch validator data")
+			return
+		}
+		selected, err := service.SelectFields(converted, req.Fields)
+		if err != nil {
+			slog.Error("failed to select response fields", "error", err)
+			h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to fetch validator data")
+			return
+		}
+		h.jsonResponse(w, r, http.StatusOK, selected)
+		return
+	}
+	h.jsonResponse(w, r, http.StatusOK, versioned)
+}
+
+// handleDailyIncome handles GET /validator/income/daily requests.
+func (h *Handler) handleDailyIncome(w http.ResponseWriter, r *http.Request) {
+	idsParam := r.URL.Query().Get("ids")
+	chain := r.URL.Query().Get("chain")
+	daysParam := r.URL.Query().Get("days")
+	currency := strings.ToLower(r.URL.Query().Get("currency"))
+
+	validatorIds, err := h.parseValidatorIds(idsParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	// Default to the last 30 days; the upstream history covers at most 90 days
+	days := 30
+	if daysParam != "" {
+		days, err = strconv.Atoi(daysParam)
+		if err != nil || days < 1 || days > 90 {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "days: must be an integer between 1 and 90")
+			return
+		}
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds: validatorIds,
+		Chain:        chain,
+		Range:        "all_time",
+		Currency:     currency,
+	}
+
+	if err := h.validateValidatorRequest(req); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	response, err := h.validatorService.GetDailyIncome(r.Context(), req.Chain, req.ValidatorIds, days, req.Currency)
+	if errors.Is(err, budget.ErrExhausted) {
+		h.budgetExhaustedResponse(w, r)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to fetch daily income", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to fetch daily income")
+		return
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handleLabels handles GET /labels requests.
+func (h *Handler) handleLabels(w http.ResponseWriter, r *http.Request) {
+	chain := r.URL.Query().Get("chain")
+	if chain != "mainnet" && chain != "hoodi" {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "chain: must be one of: mainnet, hoodi")
+		return
+	}
+	h.jsonResponse(w, r, http.StatusOK, h.validatorService.Labels(chain))
+}
+
+// handlePutLabels handles PUT /validator/{id}/labels requests, replacing the
+// labels of a validator with the key/value object in the body.
+func (h *Handler) handlePutLabels(w http.ResponseWriter, r *http.Request) {
+	idParam := r.PathValue("id")
+	chain := r.URL.Query().Get("chain")
+
+	validatorId, err := strconv.Atoi(idParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "invalid validator ID: "+idParam)
+		return
+	}
+
+	var body map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "labels must be an object of string values: "+err.Error())
+		return
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds: []int{validatorId},
+		Chain:        chain,
+		Range:        "all_time",
+	}
+	if err := h.validateValidatorRequest(req); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	response, err := h.validatorService.SetValidatorLabels(chain, validatorId, body)
+	if errors.Is(err, labels.ErrInvalid) {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if err != nil {
+		slog.Error("failed to save labels", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to save labels")
+		return
+	}
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handlePutNotes handles PUT /validator/{id}/notes requests, replacing the note
+// of a validator with the text in the body.
+func (h *Handler) handlePutNotes(w http.ResponseWriter, r *http.Request) {
+	idParam := r.PathValue("id")
+	chain := r.URL.Query().Get("chain")
+
+	validatorId, err := strconv.Atoi(idParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "invalid validator ID: "+idParam)
+		return
+	}
+
+	var body struct {
+		Text string `json:"text"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "body must be an object with a text string: "+err.Error())
+		return
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds: []int{validatorId},
+		Chain:        chain,
+		Range:        "all_time",
+	}
+	if err := h.validateValidatorRequest(req); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	response, err := h.validatorService.SetValidatorNote(chain, validatorId, body.Text)
+	if errors.Is(err, notes.ErrInvalid) {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if err != nil {
+		slog.Error("failed to save notes", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to save notes")
+		return
+	}
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// maxSearchQuery is the maximum length of the text searched in validator notes.
+const maxSearchQuery = 256
+
+// handleValidatorSearch handles GET /validator/search requests, listing the
+// validators whose labels match every meta=key:value and whose notes or label
+// values contain q.
+func (h *Handler) handleValidatorSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	chain := query.Get("chain")
+	meta := query["meta"]
+	text := strings.TrimSpace(query.Get("q"))
+
+	if chain != "mainnet" && chain != "hoodi" {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "chain: must be one of: mainnet, hoodi")
+		return
+	}
+	if len(meta) == 0 && text == "" {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "meta or q is required")
+		return
+	}
+	if len(text) > maxSearchQuery {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "q: must be at most "+strconv.Itoa(maxSearchQuery)+" characters")
+		return
+	}
+	selectors := make([]labels.Selector, 0, len(meta))
+	for _, m := range meta {
+		sel, err := labels.ParseSelector(m)
+		if err != nil {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "meta: "+err.Error())
+			return
+		}
+		selectors = append(selectors, sel)
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, h.validatorService.SearchValidators(chain, meta, selectors, text))
+}
+
+// Limits of the results of GET /search.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// handleSearch handles GET /search requests, matching q against the portfolios
+// and the indices, public keys, withdrawal addresses, labels and notes of their
+// validators and of labeled or noted ones.
+func (h *Handler) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	chain := query.Get("chain")
+	text := strings.TrimSpace(query.Get("q"))
+
+	if text == "" {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "q is required")
+		return
+	}
+	if len(text) > maxSearchQuery {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "q: must be at most "+strconv.Itoa(maxSearchQuery)+" characters")
+		return
+	}
+	if chain != "" && chain != "mainnet" && chain != "hoodi" {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "chain: must be one of: mainnet, hoodi")
+		return
+	}
+	limit := defaultSearchLimit
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "limit: must be between 1 and "+strconv.Itoa(maxSearchLimit))
+			return
+		}
+		limit = n
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, h.validatorService.Search(chain, text, limit))
+}
+
+// maxKeyFileBytes is the largest deposit data file or CSV POST
+// /watchlist/{name}/import accepts.
+const maxKeyFileBytes = 16 << 20
+
+// handleWatchlistImport handles POST /watchlist/{name}/import requests. The body
+// is a deposit_data-*.json file or a CSV of public keys, sent as is or as the file
+// field of a multipart form.
+func (h *Handler) handleWatchlistImport(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	body := io.Reader(r.Body)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "multipart body must have a file field: "+err.Error())
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+	data, err := io.ReadAll(body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		h.errorResponse(w, r, http.StatusRequestEntityTooLarge, "file_too_large", "The file must be at most "+strconv.FormatInt(tooLarge.Limit, 10)+" bytes")
+		return
+	}
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "failed to read body: "+err.Error())
+		return
+	}
+	keys, err := deposit.Parse(data)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	response, err := h.validatorService.ImportValidators(r.Context(), name, keys)
+	switch {
+	case errors.Is(err, service.ErrUnknownPortfolio):
+		h.errorResponse(w, r, http.StatusNotFound, "not_found", "unknown watchlist: "+name)
+		return
+	case errors.Is(err, service.ErrWrongNetwork):
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	case errors.Is(err, budget.ErrExhausted):
+		h.budgetExhaustedResponse(w, r)
+		return
+	case err != nil:
+		slog.Error("failed to import validators", "watchlist", name, "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to import validators")
+		return
+	}
+	slog.Info("imported validators", "watchlist", name, "keys", response.Keys, "added", len(response.Added), "notActivated", len(response.NotActivated))
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handleClientMisses handles GET /validator-client/misses requests.
+func (h *Handler) handleClientMisses(w http.ResponseWriter, r *http.Request) {
+	if h.clientMetrics == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "client_metrics_disabled", "No portfolio has client metrics")
+		return
+	}
+	name := r.URL.Query().Get("portfolio")
+	if name == "" {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "portfolio is required")
+		return
+	}
+
+	response, ok := h.clientMetrics.Misses(name)
+	if !ok {
+		h.errorResponse(w, r, http.StatusNotFound, "not_found", "no portfolio with client metrics: "+name)
+		return
+	}
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handleDiversity handles GET /diversity requests.
+func (h *Handler) handleDiversity(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	chain := query.Get("chain")
+	name := query.Get("portfolio")
+	label := query.Get("label")
+	if label == "" {
+		label = "client"
+	}
+
+	if err := labels.ValidateKey(label); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "label: "+err.Error())
+		return
+	}
+	// Portfolios know their chain
+	if name == "" || chain != "" {
+		if chain != "mainnet" && chain != "hoodi" {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "chain: must be one of: mainnet, hoodi")
+			return
+		}
+	}
+
+	response, err := h.validatorService.GetDiversity(chain, name, label)
+	if errors.Is(err, service.ErrUnknownPortfolio) {
+		h.errorResponse(w, r, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+	if err != nil {
+		slog.Error("failed to get client diversity", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to get client diversity")
+		return
+	}
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handlePresignedExits handles GET /exits requests.
+func (h *Handler) handlePresignedExits(w http.ResponseWriter, r *http.Request) {
+	chain := r.URL.Query().Get("chain")
+	if chain != "mainnet" && chain != "hoodi" {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "chain: must be one of: mainnet, hoodi")
+		return
+	}
+	h.jsonResponse(w, r, http.StatusOK, h.validatorService.PresignedExits(chain))
+}
+
+// exitValidator parses the validator and chain of a pre-signed exit request,
+// writing an error response if they are invalid.
+func (h *Handler) exitValidator(w http.ResponseWriter, r *http.Request) (int, string, bool) {
+	idParam := r.PathValue("id")
+	chain := r.URL.Query().Get("chain")
+
+	validatorId, err := strconv.Atoi(idParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "invalid validator ID: "+idParam)
+		return 0, "", false
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds: []int{validatorId},
+		Chain:        chain,
+		Range:        "all_time",
+	}
+	if err := h.validateValidatorRequest(req); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return 0, "", false
+	}
+	return validatorId, chain, true
+}
+
+// handlePutPresignedExit handles PUT /validator/{id}/exit requests, recording that
+// the validator has a pre-signed exit stored. The body holds metadata only; signed
+// messages are rejected as unknown fields.
+func (h *Handler) handlePutPresignedExit(w http.ResponseWriter, r *http.Request) {
+	validatorId, chain, ok := h.exitValidator(w, r)
+	if !ok {
+		return
+	}
+
+	var body models.PresignedExit
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "invalid pre-signed exit: "+err.Error())
+		return
+	}
+	if body.StoredAt.IsZero() {
+		body.StoredAt = time.Now().UTC()
+	}
+
+	response, err := h.validatorService.SetPresignedExit(chain, validatorId, body)
+	if errors.Is(err, exits.ErrInvalid) {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if err != nil {
+		slog.Error("failed to save pre-signed exit", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to save pre-signed exit")
+		return
+	}
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handleDeletePresignedExit handles DELETE /validator/{id}/exit requests.
+func (h *Handler) handleDeletePresignedExit(w http.ResponseWriter, r *http.Request) {
+	validatorId, chain, ok := h.exitValidator(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.validatorService.DeletePresignedExit(chain, validatorId)
+	if err != nil {
+		slog.Error("failed to save pre-signed exits", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to save pre-signed exits")
+		return
+	}
+	if !deleted {
+		h.errorResponse(w, r, http.StatusNotFound, "not_found", "No pre-signed exit recorded for validator "+strconv.Itoa(validatorId))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleBalanceHistory handles GET /validator/{id}/balance-history requests.
+func (h *Handler) handleBalanceHistory(w http.ResponseWriter, r *http.Request) {
+	idParam := r.PathValue("id")
+	chain := r.URL.Query().Get("chain")
+	epochsParam := r.URL.Query().Get("epochs")
+
+	validatorId, err := strconv.Atoi(idParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "invalid validator ID: "+idParam)
+		return
+	}
+
+	// Default to one day of epochs; at most one week is served
+	epochs := 225
+	if epochsParam != "" {
+		epochs, err = strconv.Atoi(epochsParam)
+		if err != nil || epochs < 1 || epochs > 1575 {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "epochs: must be an integer between 1 and 1575")
+			return
+		}
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds: []int{validatorId},
+		Chain:        chain,
+		Range:        "all_time",
+	}
+
+	if err := h.validateValidatorRequest(req); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	response, err := h.validatorService.GetBalanceHistory(r.Context(), req.Chain, validatorId, epochs)
+	if errors.Is(err, budget.ErrExhausted) {
+		h.budgetExhaustedResponse(w, r)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to fetch balance history", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to fetch balance history")
+		return
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handleTimeline handles GET /validator/{id}/timeline requests.
+func (h *Handler) handleTimeline(w http.ResponseWriter, r *http.Request) {
+	idParam := r.PathValue("id")
+	chain := r.URL.Query().Get("chain")
+	daysParam := r.URL.Query().Get("days")
+
+	validatorId, err := strconv.Atoi(idParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "invalid validator ID: "+idParam)
+		return
+	}
+
+	days := 30
+	if daysParam != "" {
+		days, err = strconv.Atoi(daysParam)
+		if err != nil || days < 1 || days > 365 {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "days: must be an integer between 1 and 365")
+			return
+		}
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds: []int{validatorId},
+		Chain:        chain,
+		Range:        "all_time",
+	}
+
+	if err := h.validateValidatorRequest(req); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	response, err := h.validatorService.GetTimeline(r.Context(), req.Chain, validatorId, days)
+	if errors.Is(err, service.ErrHistoryDisabled) {
+		h.errorResponse(w, r, http.StatusNotImplemented, "history_disabled", "Timelines require DATA_DIR or HISTORY_DATABASE_URL to be set")
+		return
+	}
+	if err != nil {
+		slog.Error("failed to fetch timeline", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to fetch timeline")
+		return
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handleSlashing handles GET /validator/{id}/slashing requests.
+func (h *Handler) handleSlashing(w http.ResponseWriter, r *http.Request) {
+	idParam := r.PathValue("id")
+	chain := r.URL.Query().Get("chain")
+
+	validatorId, err := strconv.Atoi(idParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "invalid validator ID: "+idParam)
+		return
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds: []int{validatorId},
+		Chain:        chain,
+		Range:        "all_time",
+	}
+
+	if err := h.validateValidatorRequest(req); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	response, err := h.validatorService.GetSlashing(r.Context(), req.Chain, validatorId)
+	if errors.Is(err, service.ErrValidatorNotFound) {
+		h.errorResponse(w, r, http.StatusNotFound, "not_found", "Validator "+idParam+" not found")
+		return
+	}
+	if errors.Is(err, budget.ErrExhausted) {
+		h.budgetExhaustedResponse(w, r)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to fetch slashing", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to fetch slashing")
+		return
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handleDashboard handles GET /dashboard requests.
+func (h *Handler) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	portfoliosParam := r.URL.Query().Get("portfolios")
+	evalRange := r.URL.Query().Get("range")
+
+	if evalRange == "" {
+		evalRange = "30d"
+	}
+	validRanges := map[string]bool{"24h": true, "7d": true, "30d": true, "90d": true, "all_time": true}
+	if !validRanges[evalRange] {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "range: must be one of: 24h, 7d, 30d, 90d, all_time")
+		return
+	}
+
+	// No portfolios selects all of them
+	var names []string
+	for _, name := range strings.Split(portfoliosParam, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	response, err := h.validatorService.GetDashboard(r.Context(), names, evalRange)
+	if errors.Is(err, service.ErrUnknownPortfolio) {
+		h.errorResponse(w, r, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+	if err != nil {
+		slog.Error("failed to build dashboard", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to build dashboard")
+		return
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handleClusters handles GET /clusters requests.
+func (h *Handler) handleClusters(w http.ResponseWriter, r *http.Request) {
+	clustersParam := r.URL.Query().Get("clusters")
+	evalRange := r.URL.Query().Get("range")
+
+	if evalRange == "" {
+		evalRange = "30d"
+	}
+	validRanges := map[string]bool{"24h": true, "7d": true, "30d": true, "90d": true, "all_time": true}
+	if !validRanges[evalRange] {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "range: must be one of: 24h, 7d, 30d, 90d, all_time")
+		return
+	}
+
+	// No clusters selects all of them
+	var names []string
+	for _, name := range strings.Split(clustersParam, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	response, err := h.validatorService.GetClusters(r.Context(), names, evalRange)
+	if errors.Is(err, service.ErrUnknownCluster) {
+		h.errorResponse(w, r, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+	if err != nil {
+		slog.Error("failed to get clusters", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to get clusters")
+		return
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// maxCompareGroups limits the groups of a single comparison.
+const maxCompareGroups = 10
+
+// handleCompare handles GET /compare requests.
+func (h *Handler) handleCompare(w http.ResponseWriter, r *http.Request) {
+	groupsParam := r.URL.Query().Get("groups")
+	chain := r.URL.Query().Get("chain")
+	evalRange := r.URL.Query().Get("range")
+
+	if evalRange == "" {
+		evalRange = "30d"
+	}
+	validRanges := map[string]bool{"24h": true, "7d": true, "30d": true, "90d": true, "all_time": true}
+	if !validRanges[evalRange] {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "range: must be one of: 24h, 7d, 30d, 90d, all_time")
+		return
+	}
+
+	var groups []string
+	labelGroups := false
+	for _, group := range strings.Split(groupsParam, ",") {
+		if group = strings.TrimSpace(group); group == "" {
+			continue
+		}
+		if slices.Contains(groups, group) {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "groups: must be unique")
+			return
+		}
+		if service.IsLabelGroup(group) {
+			if _, err := labels.ParseSelector(group); err != nil {
+				h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "groups: "+err.Error())
+				return
+			}
+			labelGroups = true
+		}
+		groups = append(groups, group)
+	}
+	if len(groups) == 0 || len(groups) > maxCompareGroups {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "groups: must contain 1 to 10 portfolio names or key:value labels")
+		return
+	}
+
+	// Portfolios know their chain, label groups are looked up on the requested one
+	if labelGroups || chain != "" {
+		if chain != "mainnet" && chain != "hoodi" {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "chain: must be one of: mainnet, hoodi")
+			return
+		}
+	}
+
+	response, err := h.validatorService.Compare(r.Context(), chain, groups, evalRange)
+	if errors.Is(err, service.ErrUnknownPortfolio) || errors.Is(err, service.ErrEmptyGroup) {
+		h.errorResponse(w, r, http.StatusNotFound, "not_found", err.Error())
+		return
+	}
+	if err != nil {
+		slog.Error("failed to compare groups", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to compare groups")
+		return
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handleAttestationTrend handles GET /validator/attestations/trend requests.
+func (h *Handler) handleAttestationTrend(w http.ResponseWriter, r *http.Request) {
+	idsParam := r.URL.Query().Get("ids")
+	chain := r.URL.Query().Get("chain")
+	evalRange := r.URL.Query().Get("range")
+	bucket := r.URL.Query().Get("bucket")
+	daysParam := r.URL.Query().Get("days")
+
+	// Samples of the 24h range react fastest to degradations
+	if evalRange == "" {
+		evalRange = "24h"
+	}
+	if bucket == "" {
+		bucket = "hour"
+	}
+	if bucket != "hour" && bucket != "day" {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "bucket: must be one of: hour, day")
+		return
+	}
+
+	validatorIds, err := h.parseValidatorIds(idsParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	days := 7
+	if daysParam != "" {
+		days, err = strconv.Atoi(daysParam)
+		if err != nil || days < 1 || days > 90 {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "days: must be an integer between 1 and 90")
+			return
+		}
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds: validatorIds,
+		Chain:        chain,
+		Range:        evalRange,
+	}
+
+	if err := h.validateValidatorRequest(req); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	response, err := h.validatorService.GetAttestationTrend(r.Context(), req.Chain, req.ValidatorIds, req.Range, bucket, days)
+	if errors.Is(err, service.ErrHistoryDisabled) {
+		h.errorResponse(w, r, http.StatusNotImplemented, "history_disabled", "Attestation trends require DATA_DIR or HISTORY_DATABASE_URL to be set")
+		return
+	}
+	if err != nil {
+		slog.Error("failed to fetch attestation trend", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to fetch attestation trend")
+		return
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// maxDeltaAge is how far back the since time of a delta request may lie.
+const maxDeltaAge = 7 * 24 * time.Hour
+
+// handleValidatorDelta handles GET /validator/delta requests.
+func (h *Handler) handleValidatorDelta(w http.ResponseWriter, r *http.Request) {
+	idsParam := r.URL.Query().Get("ids")
+	chain := r.URL.Query().Get("chain")
+	sinceParam := r.URL.Query().Get("since")
+
+	validatorIds, err := h.parseValidatorIds(idsParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	since, err := time.Parse(time.RFC3339, sinceParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "since: must be an RFC 3339 time")
+		return
+	}
+	if time.Since(since) > maxDeltaAge {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "since: must be within the last 7 days")
+		return
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds: validatorIds,
+		Chain:        chain,
+		Range:        "all_time",
+	}
+
+	if err := h.validateValidatorRequest(req); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	response, err := h.validatorService.GetValidatorDelta(r.Context(), req.Chain, req.ValidatorIds, since.UTC())
+	if errors.Is(err, service.ErrHistoryDisabled) {
+		h.errorResponse(w, r, http.StatusNotImplemented, "history_disabled", "Validator deltas require DATA_DIR or HISTORY_DATABASE_URL to be set")
+		return
+	}
+	if err != nil {
+		slog.Error("failed to compute validator delta", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to compute validator delta")
+		return
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handleProjection handles GET /validator/projection requests.
+func (h *Handler) handleProjection(w http.ResponseWriter, r *http.Request) {
+	idsParam := r.URL.Query().Get("ids")
+	chain := r.URL.Query().Get("chain")
+	evalRange := r.URL.Query().Get("range")
+	currency := strings.ToLower(r.URL.Query().Get("currency"))
+
+	// A month of rewards evens out proposal luck without lagging far behind
+	if evalRange == "" {
+		evalRange = "30d"
+	}
+	if evalRange == "all_time" {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "range: must be one of: 24h, 7d, 30d, 90d")
+		return
+	}
+
+	validatorIds, err := h.parseValidatorIds(idsParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds: validatorIds,
+		Chain:        chain,
+		Range:        evalRange,
+		Currency:     currency,
+	}
+
+	if err := h.validateValidatorRequest(req); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	response, err := h.validatorService.GetProjection(r.Context(), req)
+	if errors.Is(err, budget.ErrExhausted) {
+		h.budgetExhaustedResponse(w, r)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to project earnings", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to project earnings")
+		return
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handleHealthScore handles GET /validator/health requests.
+func (h *Handler) handleHealthScore(w http.ResponseWriter, r *http.Request) {
+	idsParam := r.URL.Query().Get("ids")
+	chain := r.URL.Query().Get("chain")
+
+	validatorIds, err := h.parseValidatorIds(idsParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds: validatorIds,
+		Chain:        chain,
+		Range:        "all_time",
+	}
+
+	if err := h.validateValidatorRequest(req); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	response, err := h.validatorService.GetHealth(r.Context(), req.Chain, req.ValidatorIds)
+	if errors.Is(err, budget.ErrExhausted) {
+		h.budgetExhaustedResponse(w, r)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to score validator health", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to score validator health")
+		return
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handleSLA handles GET /sla requests.
+func (h *Handler) handleSLA(w http.ResponseWriter, r *http.Request) {
+	idsParam := r.URL.Query().Get("ids")
+	chain := r.URL.Query().Get("chain")
+	windowsParam := r.URL.Query().Get("windows")
+	groupBy := r.URL.Query().Get("groupBy")
+
+	validatorIds, err := h.parseValidatorIds(idsParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	windows := h.validatorService.SLAWindows()
+	if windowsParam != "" {
+		windows = strings.Split(windowsParam, ",")
+	}
+	if len(windows) == 0 {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "windows: must list at least one of: 24h, 7d, 30d")
+		return
+	}
+	for _, window := range windows {
+		if !service.ValidSLAWindow(window) {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "windows: must be a comma-separated list of: 24h, 7d, 30d")
+			return
+		}
+	}
+
+	if groupBy != "" {
+		if err := labels.ValidateKey(groupBy); err != nil {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "groupBy: "+err.Error())
+			return
+		}
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds: validatorIds,
+		Chain:        chain,
+		Range:        "all_time",
+	}
+
+	if err := h.validateValidatorRequest(req); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	response, err := h.validatorService.GetSLA(r.Context(), req.Chain, req.ValidatorIds, windows, groupBy)
+	if errors.Is(err, service.ErrHistoryDisabled) {
+		h.errorResponse(w, r, http.StatusNotImplemented, "history_disabled", "Uptime SLAs require DATA_DIR or HISTORY_DATABASE_URL to be set")
+		return
+	}
+	if err != nil {
+		slog.Error("failed to compute SLA", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to compute SLA")
+		return
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// Limits of Grafana queries.
+const (
+	minGrafanaInterval = time.Minute
+	maxGrafanaRange    = 90 * 24 * time.Hour
+)
+
+// historyDisabledGrafana is the message of Grafana requests without a history store.
+const historyDisabledGrafana = "Grafana queries require DATA_DIR or HISTORY_DATABASE_URL to be set"
+
+// handleGrafanaTest handles GET /grafana, the connection test of the Grafana JSON
+// datasources.
+func (h *Handler) handleGrafanaTest(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.validatorService.GrafanaSearch(""); errors.Is(err, service.ErrHistoryDisabled) {
+		h.errorResponse(w, r, http.StatusNotImplemented, "history_disabled", historyDisabledGrafana)
+		return
+	}
+	h.jsonResponse(w, r, http.StatusOK, map[string]string{"status": "ok"})
 }
 
-// Router returns the HTTP router with all routes configured.
-func (h *Handler) Router() http.Handler {
-	mux := http.NewServeMux()
+// handleGrafanaSearch handles POST /grafana/search requests, listing the targets
+// of the configured portfolios.
+func (h *Handler) handleGrafanaSearch(w http.ResponseWriter, r *http.Request) {
+	var body models.GrafanaSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "invalid search request: "+err.Error())
+		return
+	}
 
-	// Health check endpoint
-	mux.HandleFunc("GET /health", h.handleHealth)
+	targets, err := h.validatorService.GrafanaSearch(body.Target)
+	if errors.Is(err, service.ErrHistoryDisabled) {
+		h.errorResponse(w, r, http.StatusNotImplemented, "history_disabled", historyDisabledGrafana)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to search Grafana targets", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to search targets")
+		return
+	}
+	h.jsonResponse(w, r, http.StatusOK, targets)
+}
 
-	// Validator endpoint (GET for cacheability)
-	mux.HandleFunc("GET /validator", h.handleValidator)
+// handleGrafanaQuery handles POST /grafana/query requests, returning a time series
+// per target from recorded history.
+func (h *Handler) handleGrafanaQuery(w http.ResponseWriter, r *http.Request) {
+	var body models.GrafanaQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "invalid query request: "+err.Error())
+		return
+	}
 
-	// Apply middleware
-	handler := h.recoveryMiddleware(mux)
-	handler = h.loggingMiddleware(handler)
-	handler = h.corsMiddleware(handler)
-	handler = h.maxBodySizeMiddleware(handler, 1<<20) // 1 MB max body size
+	from, to := body.Range.From.UTC(), body.Range.To.UTC()
+	if from.IsZero() || !to.After(from) {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "range: from must be before to")
+		return
+	}
+	if to.Sub(from) > maxGrafanaRange {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "range: must span at most 90 days")
+		return
+	}
+	interval := grafanaInterval(body, to.Sub(from))
 
-	return handler
+	targets := make([]service.GrafanaTarget, 0, len(body.Targets))
+	names := make([]string, 0, len(body.Targets))
+	for _, t := range body.Targets {
+		if t.Hide || t.Target == "" {
+			continue
+		}
+		target, err := service.ParseGrafanaTarget(t.Target)
+		if err != nil {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+		if target.Portfolio == "" {
+			req := models.ValidatorRequest{ValidatorIds: target.ValidatorIds, Chain: target.Chain, Range: "all_time"}
+			if err := h.validateValidatorRequest(req); err != nil {
+				h.errorResponse(w, r, http.StatusBadRequest, "validation_error", t.Target+": "+err.Error())
+				return
+			}
+		}
+		targets = append(targets, target)
+		names = append(names, t.Target)
+	}
+
+	response := make([]models.GrafanaTimeSeries, 0, len(targets))
+	for i, target := range targets {
+		points, err := h.validatorService.GetGrafanaSeries(r.Context(), target, from, to, interval)
+		if errors.Is(err, service.ErrHistoryDisabled) {
+			h.errorResponse(w, r, http.StatusNotImplemented, "history_disabled", historyDisabledGrafana)
+			return
+		}
+		if errors.Is(err, service.ErrUnknownPortfolio) {
+			h.errorResponse(w, r, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		if err != nil {
+			slog.Error("failed to query Grafana series", "target", names[i], "error", err)
+			h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to query series")
+			return
+		}
+		response = append(response, models.GrafanaTimeSeries{Target: names[i], Datapoints: points})
+	}
+	h.jsonResponse(w, r, http.StatusOK, response)
 }
 
-// handleHealth returns API health status.
-func (h *Handler) handleHealth(w http.ResponseWriter, r *http.Request) {
-	h.jsonResponse(w, http.StatusOK, map[string]string{
-		"status": "healthy",
-		"time":   time.Now().UTC().Format(time.RFC3339),
-	})
+// grafanaInterval returns the spacing of the points of a query: the interval
+// Grafana suggests, widened to fit the most points the panel can show, rounded up
+// to whole minutes.
+func grafanaInterval(body models.GrafanaQueryRequest, span time.Duration) time.Duration {
+	interval := time.Duration(body.IntervalMs) * time.Millisecond
+	if body.MaxDataPoints > 0 {
+		interval = max(interval, span/time.Duration(body.MaxDataPoints))
+	}
+	return max((interval + time.Minute - 1).Truncate(time.Minute), minGrafanaInterval)
 }
 
-// handleValidator handles GET /validator requests.
-func (h *Handler) handleValidator(w http.ResponseWriter, r *http.Request) {
+// handleSyncCommittees handles GET /validator/sync-committees requests.
+func (h *Handler) handleSyncCommittees(w http.ResponseWriter, r *http.Request) {
+	idsParam := r.URL.Query().Get("ids")
+	chain := r.URL.Query().Get("chain")
+
+	validatorIds, err := h.parseValidatorIds(idsParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds: validatorIds,
+		Chain:        chain,
+		Range:        "all_time",
+	}
+
+	if err := h.validateValidatorRequest(req); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	response, err := h.validatorService.GetSyncCommittees(r.Context(), req.Chain, req.ValidatorIds)
+	if errors.Is(err, budget.ErrExhausted) {
+		h.budgetExhaustedResponse(w, r)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to fetch sync committees", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to fetch sync committees")
+		return
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// maxGraffitiPolicy is the maximum length of a graffiti policy pattern.
+const maxGraffitiPolicy = 256
+
+// handleGraffiti handles GET /validator/graffiti requests.
+func (h *Handler) handleGraffiti(w http.ResponseWriter, r *http.Request) {
+	idsParam := r.URL.Query().Get("ids")
+	chain := r.URL.Query().Get("chain")
+	evalRange := r.URL.Query().Get("range")
+	policyParam := r.URL.Query().Get("policy")
+
+	if evalRange == "" {
+		evalRange = "30d"
+	}
+
+	validatorIds, err := h.parseValidatorIds(idsParam)
+	if err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	req := models.ValidatorRequest{
+		ValidatorIds: validatorIds,
+		Chain:        chain,
+		Range:        evalRange,
+	}
+
+	if err := h.validateValidatorRequest(req); err != nil {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	var policy *regexp.Regexp
+	if policyParam != "" {
+		if len(policyParam) > maxGraffitiPolicy {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "policy: must be at most "+strconv.Itoa(maxGraffitiPolicy)+" characters")
+			return
+		}
+		if policy, err = regexp.Compile(policyParam); err != nil {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "policy: must be a valid regular expression")
+			return
+		}
+	}
+
+	response, err := h.validatorService.GetGraffiti(r.Context(), req.Chain, req.ValidatorIds, req.Range, policy)
+	if errors.Is(err, budget.ErrExhausted) {
+		h.budgetExhaustedResponse(w, r)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to fetch graffiti", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to fetch graffiti")
+		return
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handlePrice handles GET /price requests.
+func (h *Handler) handlePrice(w http.ResponseWriter, r *http.Request) {
+	currency := strings.ToLower(r.URL.Query().Get("currency"))
+	if currency == "" {
+		currency = "usd"
+	}
+	if !isCurrencyCode(currency) {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "currency: must be a 3 letter currency code")
+		return
+	}
+
+	response, err := h.validatorService.GetPrice(r.Context(), currency)
+	if err != nil {
+		slog.Error("failed to fetch price", "currency", currency, "error", err)
+		h.errorResponse(w, r, http.StatusServiceUnavailable, "price_unavailable", "No price available for "+currency)
+		return
+	}
+
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// handleReconciliation handles GET /validator/reconciliation requests.
+func (h *Handler) handleReconciliation(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
 	idsParam := r.URL.Query().Get("ids")
 	chain := r.URL.Query().Get("chain")
 	evalRange := r.URL.Query().Get("range")
+	toleranceParam := r.URL.Query().Get("tolerance")
 
 	// Default range to all_time if not specified
 	if evalRange == "" {
 		evalRange = "all_time"
 	}
 
-	// Parse validator IDs from comma-separated string
 	validatorIds, err := h.parseValidatorIds(idsParam)
 	if err != nil {
-		h.errorResponse(w, http.StatusBadRequest, "invalid_request", err.Error())
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", err.Error())
 		return
 	}
 
+	// Tolerance is given in gwei and defaults to the configured value
+	toleranceGwei := int64(h.config.ReconciliationToleranceGwei)
+	if toleranceParam != "" {
+		toleranceGwei, err = strconv.ParseInt(toleranceParam, 10, 64)
+		if err != nil || toleranceGwei < 0 {
+			h.errorResponse(w, r, http.StatusBadRequest, "invalid_request", "tolerance: must be a non-negative integer amount in gwei")
+			return
+		}
+	}
+
 	req := models.ValidatorRequest{
 		ValidatorIds: validatorIds,
 		Chain:        chain,
 		Range:        evalRange,
 	}
 
-	// Validate request
 	if err := h.validateValidatorRequest(req); err != nil {
-		h.errorResponse(w, http.StatusBadRequest, "validation_error", err.Error())
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	// Reconciliation costs one upstream call per validator, so it has a lower limit
+	if len(req.ValidatorIds) > h.config.MaxReconciliationIDs {
+		h.errorResponse(w, r, http.StatusBadRequest, "validation_error",
+			"validatorIds: must contain at most "+strconv.Itoa(h.config.MaxReconciliationIDs)+" validator IDs for reconciliation")
 		return
 	}
 
-	// Fetch validator data
-	response, err := h.validatorService.GetValidatorData(r.Context(), req.Chain, req.ValidatorIds, req.Range)
+	response, err := h.validatorService.ReconcileIncome(r.Context(), req.Chain, req.ValidatorIds, req.Range, amount.Gwei(toleranceGwei))
+	if errors.Is(err, budget.ErrExhausted) {
+		h.budgetExhaustedResponse(w, r)
+		return
+	}
 	if err != nil {
-		slog.Error("failed to fetch validator data", "error", err)
-		h.errorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to fetch validator data")
+		slog.Error("failed to reconcile income", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to reconcile income")
 		return
 	}
 
-	h.jsonResponse(w, http.StatusOK, response)
+	h.jsonResponse(w, r, http.StatusOK, response)
+}
+
+// requestTimeout returns how long a request may take, from the timeout query
+// parameter or the X-Request-Timeout header, capped at the configured maximum.
+// Either accepts a duration such as "10s" or a number of seconds. Zero means the
+// request has no timeout.
+func (h *Handler) requestTimeout(r *http.Request) (time.Duration, error) {
+	value := r.URL.Query().Get("timeout")
+	if value == "" {
+		value = r.Header.Get("X-Request-Timeout")
+	}
+	if value == "" {
+		return h.config.MaxRequestTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.ParseFloat(value, 64)
+		if convErr != nil {
+			return 0, &ValidationError{Field: "timeout", Message: "invalid duration: " + value}
+		}
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+	if timeout <= 0 {
+		return 0, &ValidationError{Field: "timeout", Message: "must be positive"}
+	}
+	if limit := h.config.MaxRequestTimeout; limit > 0 && timeout > limit {
+		return limit, nil
+	}
+	return timeout, nil
 }
 
 // parseValidatorIds parses a comma-separated string of validator IDs.
@@ -123,12 +2026,17 @@ func (h *Handler) parseValidatorIds(idsParam string) ([]int, error) {
 
 // validateValidatorRequest validates the incoming validator request.
 func (h *Handler) validateValidatorRequest(req models.ValidatorRequest) error {
+	return h.validateRequest(req, h.config.MaxValidatorIDs)
+}
+
+// validateRequest validates a validator request for up to maxIDs validators.
+func (h *Handler) validateRequest(req models.ValidatorRequest, maxIDs int) error {
 	if len(req.ValidatorIds) == 0 {
 		return &ValidationError{Field: "validatorIds", Message: "must contain at least 1 validator ID"}
 	}
 
-	if len(req.ValidatorIds) > h.config.MaxValidatorIDs {
-		return &ValidationError{Field: "validatorIds", Message: "must contain at most 100 validator IDs"}
+	if len(req.ValidatorIds) > maxIDs {
+		return &ValidationError{Field: "validatorIds", Message: "must contain at most " + strconv.Itoa(maxIDs) + " validator IDs"}
 	}
 
 	// Check for duplicates and validate each ID
@@ -151,6 +2059,11 @@ func (h *Handler) validateValidatorRequest(req models.ValidatorRequest) error {
 		return &ValidationError{Field: "chain", Message: "must be one of: mainnet, hoodi"}
 	}
 
+	// Validate currency
+	if req.Currency != "" && !isCurrencyCode(req.Currency) {
+		return &ValidationError{Field: "currency", Message: "must be a 3 letter currency code"}
+	}
+
 	// Validate range
 	validRanges := map[string]bool{"24h": true, "7d": true, "30d": true, "90d": true, "all_time": true}
 	if !validRanges[req.Range] {
@@ -160,6 +2073,19 @@ func (h *Handler) validateValidatorRequest(req models.ValidatorRequest) error {
 	return nil
 }
 
+// isCurrencyCode reports whether s looks like a lowercase ISO 4217 currency code.
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, c := range s {
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
 // ValidationError represents a validation error.
 type ValidationError struct {
 	Field   string
@@ -170,24 +2096,59 @@ func (e *ValidationError) Error() string {
 	return e.Field + ": " + e.Message
 }
 
-// jsonResponse writes a JSON response.
-func (h *Handler) jsonResponse(w http.ResponseWriter, status int, data interface{}) {
+// jsonResponse writes a JSON response. Output is compact unless the request asks
+// for pretty=true, and successful responses are wrapped in an Envelope when it
+// asks for envelope=true. Errors are never wrapped. Amounts of successful
+// responses are converted into the unit the request asks for with units, and
+// written with the separators of its locale. Successful responses are written as
+// CSV, NDJSON or MessagePack instead when the Accept header prefers one.
+func (h *Handler) jsonResponse(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	query := r.URL.Query()
+	if status < http.StatusBadRequest {
+		converted, err := convertAmounts(r, data)
+		if err != nil {
+			slog.Error("failed to convert response units", "error", err)
+			h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to convert amounts")
+			return
+		}
+		data = converted
+	}
+	w.Header().Add("Vary", "Accept")
+	if format := negotiateFormat(r); format != formatJSON && status < http.StatusBadRequest {
+		h.formattedResponse(w, r, status, format, data)
+		return
+	}
+	if queryFlag(query, "envelope") && status < http.StatusBadRequest {
+		data = models.Envelope{Data: data}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(data); err != nil {
+	enc := json.NewEncoder(w)
+	if queryFlag(query, "pretty") {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(data); err != nil {
 		slog.Error("failed to encode response", "error", err)
 	}
 }
 
 // errorResponse writes an error JSON response.
-func (h *Handler) errorResponse(w http.ResponseWriter, status int, errorCode, message string) {
-	h.jsonResponse(w, status, models.APIError{
+func (h *Handler) errorResponse(w http.ResponseWriter, r *http.Request, status int, errorCode, message string) {
+	h.jsonResponse(w, r, status, models.APIError{
 		Error:   errorCode,
 		Message: message,
 		Code:    status,
 	})
 }
 
+// queryFlag reports whether the boolean query parameter name is set to a true
+// value such as "true" or "1".
+func queryFlag(query url.Values, name string) bool {
+	v, err := strconv.ParseBool(query.Get(name))
+	return err == nil && v
+}
+
 // Middleware functions
 
 // getClientIP extracts the client IP from the request.
@@ -229,6 +2190,7 @@ func (h *Handler) loggingMiddleware(next http.Handler) http.Handler {
 			"status", wrapped.statusCode,
 			"duration", time.Since(start).String(),
 			"ip", h.getClientIP(r),
+			"trace_id", tracing.SpanFromContext(r.Context()).Context().TraceIDString(),
 		)
 	})
 }
@@ -244,25 +2206,243 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// databaseBackupMessage explains why state export and import are refused with
+// HISTORY_DATABASE_URL: archives only hold the data directory, not the database history.
+const databaseBackupMessage = "State export and import do not cover the HISTORY_DATABASE_URL history; back up the database with pg_dump instead"
+
+// handleExport handles GET /admin/export requests, streaming an archive of the
+// persisted state.
+func (h *Handler) handleExport(w http.ResponseWriter, r *http.Request) {
+	if h.config.HistoryDatabaseURL != "" {
+		h.errorResponse(w, r, http.StatusNotImplemented, "backup_unsupported", databaseBackupMessage)
+		return
+	}
+	if h.backup == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "backup_disabled", "State export requires DATA_DIR")
+		return
+	}
+
+	// Large data directories take longer than the server write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		slog.Warn("failed to lift the write deadline of the state export", "error", err)
+	}
+	name := "validator-dashboard-state-" + time.Now().UTC().Format("20060102T150405Z") + ".tar.gz"
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	if err := h.backup.Export(w); err != nil {
+		// The archive has started, so the client sees a truncated download
+		slog.Error("failed to export state", "error", err)
+	}
+}
+
+// handleImport handles POST /admin/import requests, staging an archive of
+// GET /admin/export to replace the persisted state on the next start.
+func (h *Handler) handleImport(w http.ResponseWriter, r *http.Request) {
+	if h.config.HistoryDatabaseURL != "" {
+		h.errorResponse(w, r, http.StatusNotImplemented, "backup_unsupported", databaseBackupMessage)
+		return
+	}
+	if h.backup == nil {
+		h.errorResponse(w, r, http.StatusNotImplemented, "backup_disabled", "State import requires DATA_DIR")
+		return
+	}
+
+	if err := http.NewResponseController(w).SetReadDeadline(time.Time{}); err != nil {
+		slog.Warn("failed to lift the read deadline of the state import", "error", err)
+	}
+	manifest, err := h.backup.Import(r.Body)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		h.errorResponse(w, r, http.StatusRequestEntityTooLarge, "archive_too_large",
+			"The archive must be at most "+strconv.FormatInt(tooLarge.Limit, 10)+" bytes, see STATE_IMPORT_MAX_BYTES")
+		return
+	case errors.Is(err, backup.ErrInvalidArchive):
+		h.errorResponse(w, r, http.StatusBadRequest, "invalid_archive", err.Error())
+		return
+	case err != nil:
+		slog.Error("failed to import state", "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to import state")
+		return
+	}
+
+	slog.Info("state import staged", "files", len(manifest.Files), "bytes", manifest.Bytes, "createdAt", manifest.CreatedAt)
+	h.jsonResponse(w, r, http.StatusAccepted, models.StateImport{
+		CreatedAt:       manifest.CreatedAt,
+		Files:           len(manifest.Files),
+		Bytes:           manifest.Bytes,
+		Config:          manifest.Config,
+		RestartRequired: true,
+	})
+}
+
+// Limits of the audit log.
+const (
+	maxAuditLimit = 1000
+	maxAuditBody  = 8 << 10
+)
+
+// auditMiddleware records every request that may change state, that is any
+// request but GET, HEAD and OPTIONS, in the audit log once it is answered.
+func (h *Handler) auditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.audit == nil || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Keep a copy of the start of the body for the entry, enough to tell whether
+		// it can be recorded, and pass the rest on unread; a body over the size limit
+		// still fails when the handler reads it
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBody+1))
+		rest := io.Reader(r.Body)
+		if err != nil {
+			rest = errReader{err}
+		}
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), rest))
+
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r)
+
+		entry := models.AuditEntry{
+			Time:     time.Now().UTC(),
+			Tenant:   tenant.FromContext(r.Context()),
+			ClientIP: h.getClientIP(r),
+			Method:   r.Method,
+			Path:     r.URL.Path,
+			Query:    r.URL.RawQuery,
+			Status:   wrapped.statusCode,
+		}
+		var compact bytes.Buffer
+		if err == nil && len(body) <= maxAuditBody && json.Compact(&compact, body) == nil {
+			entry.Body = compact.Bytes()
+		}
+		if err := h.audit.Record(entry); err != nil {
+			slog.Error("failed to record audit entry", "method", r.Method, "path", r.URL.Path, "error", err)
+		}
+	})
+}
+
+// errReader returns err from every read, or io.EOF if err is nil.
+type errReader struct{ err error }
+
+func (e errReader) Read([]byte) (int, error) {
+	if e.err == nil {
+		return 0, io.EOF
+	}
+	return 0, e.err
+}
+
 // recoveryMiddleware recovers from panics.
 func (h *Handler) recoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
 				slog.Error("panic recovered", "error", err)
-				h.errorResponse(w, http.StatusInternalServerError, "internal_error", "Internal server error")
+				h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Internal server error")
 			}
 		}()
 		next.ServeHTTP(w, r)
 	})
 }
 
-// corsMiddleware adds CORS headers.
+// convertAmounts converts the amounts of a response into the unit and locale the
+// request asks for, which formatMiddleware validated.
+func convertAmounts(r *http.Request, data any) (any, error) {
+	unit, _ := units.Parse(r.URL.Query().Get("units"))
+	loc, _ := locale.Parse(r.URL.Query().Get("locale"))
+	return units.ConvertLocalized(data, unit, loc)
+}
+
+// formatMiddleware rejects requests for an unknown amount unit or locale before
+// they are served, since amounts are only converted when the response is written.
+func (h *Handler) formatMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := units.Parse(r.URL.Query().Get("units")); err != nil {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "units: "+err.Error())
+			return
+		}
+		if _, err := locale.Parse(r.URL.Query().Get("locale")); err != nil {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "locale: "+err.Error())
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// costMiddleware reports the upstream calls and cache hits made while serving a
+// data request in the X-Upstream-Calls and X-Cache-Hits response headers.
+func (h *Handler) costMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, counter := cost.WithCounter(r.Context())
+		next.ServeHTTP(&costWriter{ResponseWriter: w, counter: counter}, r.WithContext(ctx))
+	})
+}
+
+// costWriter sets the cost headers when the response header is written,
+// since they cannot be added once the body has started.
+type costWriter struct {
+	http.ResponseWriter
+	counter     *cost.Counter
+	wroteHeader bool
+}
+
+func (cw *costWriter) WriteHeader(code int) {
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		cw.Header().Set("X-Upstream-Calls", strconv.FormatInt(cw.counter.UpstreamCalls(), 10))
+		cw.Header().Set("X-Cache-Hits", strconv.FormatInt(cw.counter.CacheHits(), 10))
+		cw.Header().Set("X-Stale-Hits", strconv.FormatInt(cw.counter.StaleHits(), 10))
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *costWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// adminTokenMiddleware serves requests carrying ADMIN_TOKEN in the X-Admin-Token
+// header, and refuses all requests when no token is configured.
+func (h *Handler) adminTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.config.AdminToken == "" {
+			h.errorResponse(w, r, http.StatusForbidden, "admin_disabled", "Admin endpoints require ADMIN_TOKEN")
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Admin-Token")), []byte(h.config.AdminToken)) != 1 {
+			h.errorResponse(w, r, http.StatusUnauthorized, "unauthorized", "Missing or invalid X-Admin-Token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isStateTransfer reports whether r exports or imports the persisted state.
+func isStateTransfer(r *http.Request) bool {
+	path := unversionedPath(r.URL.Path)
+	return path == "/admin/export" || path == "/admin/import"
+}
+
+// corsMiddleware adds CORS headers, except to state exports and imports, which
+// browsers of other origins must not reach.
 func (h *Handler) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isStateTransfer(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, Idempotency-Key, X-Request-Timeout")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Upstream-Calls, X-Cache-Hits, X-Stale-Hits, Idempotent-Replayed, X-Request-Timeout, Location")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
@@ -273,9 +2453,21 @@ func (h *Handler) corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// maxBodySizeMiddleware limits the request body size.
+// maxBodySizeMiddleware limits the request body size. State imports are limited
+// to STATE_IMPORT_MAX_BYTES and key files to maxKeyFileBytes instead.
 func (h *Handler) maxBodySizeMiddleware(next http.Handler, maxBytes int64) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := unversionedPath(r.URL.Path)
+		if r.Method == http.MethodPost && path == "/admin/import" {
+			r.Body = http.MaxBytesReader(w, r.Body, h.config.StateImportMaxBytes)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.Method == http.MethodPost && strings.HasPrefix(path, "/watchlist/") && strings.HasSuffix(path, "/import") {
+			r.Body = http.MaxBytesReader(w, r.Body, maxKeyFileBytes)
+			next.ServeHTTP(w, r)
+			return
+		}
 		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
 		next.ServeHTTP(w, r)
 	})
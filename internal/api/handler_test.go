@@ -2,14 +2,37 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/alerts"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/audit"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/backup"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/budget"
 	"github.com/Marketen/validator-dashboard-beaconcha/internal/config"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/jobs"
 	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/reports"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/service"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/tenant"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/vcmetrics"
 )
 
 func TestParseValidatorIds(t *testing.T) {
@@ -188,6 +211,40 @@ func TestHandler_Health(t *testing.T) {
 	}
 }
 
+func TestJSONResponse_Format(t *testing.T) {
+	h := &Handler{config: &config.Config{MaxValidatorIDs: 100}}
+
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		data     any
+		expected string
+	}{
+		{"compact by default", "", http.StatusOK, map[string]int{"a": 1}, `{"a":1}` + "\n"},
+		{"pretty", "?pretty=true", http.StatusOK, map[string]int{"a": 1}, "{\n  \"a\": 1\n}\n"},
+		{"pretty disabled", "?pretty=false", http.StatusOK, map[string]int{"a": 1}, `{"a":1}` + "\n"},
+		{"envelope", "?envelope=1", http.StatusOK, map[string]int{"a": 1}, `{"data":{"a":1}}` + "\n"},
+		{"errors are not wrapped", "?envelope=true", http.StatusBadRequest, models.APIError{Error: "e", Code: 400}, `{"error":"e","code":400}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/health"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			h.jsonResponse(w, req, tt.status, tt.data)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+			if got := w.Body.String(); got != tt.expected {
+				t.Errorf("expected body %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
 func TestHandler_Validator_MissingIds(t *testing.T) {
 	h := &Handler{
 		config: &config.Config{
@@ -340,3 +397,1605 @@ func makeRange(start, end int) []int {
 func containsString(s, substr string) bool {
 	return bytes.Contains([]byte(s), []byte(substr))
 }
+
+func TestHandler_Reconciliation_Validation(t *testing.T) {
+	h := &Handler{
+		config: &config.Config{
+			MaxValidatorIDs:      100,
+			MaxReconciliationIDs: 2,
+		},
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		errorCode string
+	}{
+		{name: "too many validators", query: "ids=1,2,3&chain=mainnet", errorCode: "validation_error"},
+		{name: "negative tolerance", query: "ids=1&chain=mainnet&tolerance=-1", errorCode: "invalid_request"},
+		{name: "non-numeric tolerance", query: "ids=1&chain=mainnet&tolerance=abc", errorCode: "invalid_request"},
+		{name: "missing chain", query: "ids=1", errorCode: "validation_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/validator/reconciliation?"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			h.handleReconciliation(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", w.Code)
+			}
+
+			var response models.APIError
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+
+			if response.Error != tt.errorCode {
+				t.Errorf("expected error '%s', got '%s'", tt.errorCode, response.Error)
+			}
+		})
+	}
+}
+
+func TestRouter_ServesUI(t *testing.T) {
+	h := &Handler{
+		config: &config.Config{MaxValidatorIDs: 100},
+	}
+	router := h.Router()
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+	}{
+		{path: "/", status: http.StatusOK, contentType: "text/html; charset=utf-8"},
+		{path: "/assets/app.js", status: http.StatusOK, contentType: "text/javascript; charset=utf-8"},
+		{path: "/types.ts", status: http.StatusOK, contentType: "application/typescript; charset=utf-8"},
+		{path: "/unknown", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+			if tt.contentType != "" && w.Header().Get("Content-Type") != tt.contentType {
+				t.Errorf("expected content type '%s', got '%s'", tt.contentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestHandler_BalanceHistory_Validation(t *testing.T) {
+	h := &Handler{
+		config: &config.Config{MaxValidatorIDs: 100},
+	}
+	router := h.Router()
+
+	tests := []struct {
+		name      string
+		path      string
+		errorCode string
+	}{
+		{name: "non-numeric id", path: "/validator/abc/balance-history?chain=mainnet", errorCode: "invalid_request"},
+		{name: "negative id", path: "/validator/-1/balance-history?chain=mainnet", errorCode: "validation_error"},
+		{name: "missing chain", path: "/validator/1/balance-history", errorCode: "validation_error"},
+		{name: "too many epochs", path: "/validator/1/balance-history?chain=mainnet&epochs=5000", errorCode: "validation_error"},
+		{name: "zero epochs", path: "/validator/1/balance-history?chain=mainnet&epochs=0", errorCode: "validation_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", w.Code)
+			}
+
+			var response models.APIError
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+
+			if response.Error != tt.errorCode {
+				t.Errorf("expected error '%s', got '%s'", tt.errorCode, response.Error)
+			}
+		})
+	}
+}
+
+func TestHandler_ValidatorDelta_Validation(t *testing.T) {
+	h := &Handler{
+		config: &config.Config{MaxValidatorIDs: 100},
+	}
+	router := h.Router()
+	recent := url.QueryEscape(time.Now().Add(-time.Hour).Format(time.RFC3339))
+
+	tests := []struct {
+		name      string
+		path      string
+		errorCode string
+	}{
+		{name: "non-numeric id", path: "/validator/delta?ids=abc&chain=mainnet&since=" + recent, errorCode: "invalid_request"},
+		{name: "missing since", path: "/validator/delta?ids=1&chain=mainnet", errorCode: "validation_error"},
+		{name: "invalid since", path: "/validator/delta?ids=1&chain=mainnet&since=yesterday", errorCode: "validation_error"},
+		{name: "since too old", path: "/validator/delta?ids=1&chain=mainnet&since=2020-01-01T00:00:00Z", errorCode: "validation_error"},
+		{name: "missing chain", path: "/validator/delta?ids=1&since=" + recent, errorCode: "validation_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", w.Code)
+			}
+
+			var response models.APIError
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+
+			if response.Error != tt.errorCode {
+				t.Errorf("expected error '%s', got '%s'", tt.errorCode, response.Error)
+			}
+		})
+	}
+}
+
+func TestHandler_GrafanaQuery_Validation(t *testing.T) {
+	h := &Handler{
+		config: &config.Config{MaxValidatorIDs: 2},
+	}
+	router := h.Router()
+	const valid = `"range":{"from":"2026-03-10T00:00:00Z","to":"2026-03-11T00:00:00Z"}`
+
+	tests := []struct {
+		name      string
+		body      string
+		errorCode string
+	}{
+		{name: "invalid json", body: `{"range":`, errorCode: "invalid_request"},
+		{name: "missing range", body: `{"targets":[{"target":"main/balance"}]}`, errorCode: "validation_error"},
+		{name: "inverted range", body: `{"range":{"from":"2026-03-11T00:00:00Z","to":"2026-03-10T00:00:00Z"}}`, errorCode: "validation_error"},
+		{name: "range too long", body: `{"range":{"from":"2025-01-01T00:00:00Z","to":"2026-03-10T00:00:00Z"}}`, errorCode: "validation_error"},
+		{name: "unknown metric", body: `{` + valid + `,"targets":[{"target":"main/apr"}]}`, errorCode: "validation_error"},
+		{name: "unknown chain", body: `{` + valid + `,"targets":[{"target":"goerli:1/balance"}]}`, errorCode: "validation_error"},
+		{name: "too many ids", body: `{` + valid + `,"targets":[{"target":"mainnet:1,2,3/balance"}]}`, errorCode: "validation_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/grafana/query", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", w.Code)
+			}
+
+			var response models.APIError
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+
+			if response.Error != tt.errorCode {
+				t.Errorf("expected error '%s', got '%s'", tt.errorCode, response.Error)
+			}
+		})
+	}
+}
+
+func TestGrafanaInterval(t *testing.T) {
+	tests := []struct {
+		name string
+		body models.GrafanaQueryRequest
+		span time.Duration
+		want time.Duration
+	}{
+		{name: "suggested", body: models.GrafanaQueryRequest{IntervalMs: 300000, MaxDataPoints: 1000}, span: 24 * time.Hour, want: 5 * time.Minute},
+		{name: "below minimum", body: models.GrafanaQueryRequest{IntervalMs: 15000}, span: time.Hour, want: time.Minute},
+		{name: "too many points", body: models.GrafanaQueryRequest{IntervalMs: 60000, MaxDataPoints: 100}, span: 24 * time.Hour, want: 15 * time.Minute},
+		{name: "rounded up", body: models.GrafanaQueryRequest{MaxDataPoints: 7}, span: time.Hour, want: 9 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grafanaInterval(tt.body, tt.span); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRouter_CostHeaders(t *testing.T) {
+	h := &Handler{
+		config: &config.Config{MaxValidatorIDs: 100},
+	}
+	router := h.Router()
+
+	// Rejected requests make no upstream calls but still report their cost
+	req := httptest.NewRequest(http.MethodGet, "/validator?ids=abc&chain=mainnet", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get("X-Upstream-Calls"); got != "0" {
+		t.Errorf("expected X-Upstream-Calls 0, got %q", got)
+	}
+	if got := w.Header().Get("X-Cache-Hits"); got != "0" {
+		t.Errorf("expected X-Cache-Hits 0, got %q", got)
+	}
+	if got := w.Header().Get("X-Stale-Hits"); got != "0" {
+		t.Errorf("expected X-Stale-Hits 0, got %q", got)
+	}
+
+	// Non-data endpoints carry no cost headers
+	req = httptest.NewRequest(http.MethodGet, "/health", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get("X-Upstream-Calls"); got != "" {
+		t.Errorf("expected no X-Upstream-Calls header on /health, got %q", got)
+	}
+}
+
+func TestHandler_Usage(t *testing.T) {
+	b := budget.NewManager(100, 0.1, map[string]int64{"validators/rewards-aggregate": 5})
+	b.Charge("validators/rewards-aggregate")
+	svc := service.NewValidatorService(beaconchatest.New(), nil, nil, nil, nil, nil)
+	svc.SetBudget(b)
+	h := NewHandler(svc, &config.Config{MaxValidatorIDs: 100, AdminToken: "secret"})
+
+	w := httptest.NewRecorder()
+	h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/usage", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 without admin token, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.Router().ServeHTTP(w, adminRequest(http.MethodGet, "/admin/usage", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var usage models.UsageResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &usage); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !usage.Enabled || usage.Spent != 5 || usage.Remaining != 95 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+	if got := usage.Endpoints["validators/rewards-aggregate"]; got.Requests != 1 {
+		t.Errorf("expected 1 rewards request, got %+v", got)
+	}
+}
+
+func TestHandler_CacheStats(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2)...)
+	svc := service.NewValidatorService(fake, nil, nil, nil, nil, nil)
+	svc.SetCacheLimits(1, 0)
+	h := NewHandler(svc, &config.Config{MaxValidatorIDs: 100, AdminToken: "secret"})
+
+	for _, id := range []int{1, 2} {
+		req := models.ValidatorRequest{ValidatorIds: []int{id}, Chain: "mainnet", Range: "24h"}
+		if _, err := svc.GetValidatorData(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/cache", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 without admin token, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.Router().ServeHTTP(w, adminRequest(http.MethodGet, "/admin/cache", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var stats models.CacheStatsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if got := stats.Responses; got.Entries != 1 || got.MaxEntries != 1 || got.Evictions != 1 || got.Bytes == 0 {
+		t.Errorf("unexpected response cache stats: %+v", got)
+	}
+}
+
+func TestHandler_Idempotency(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2)...)
+	svc := service.NewValidatorService(fake, nil, nil, nil, nil, nil)
+	h := NewHandler(svc, &config.Config{MaxValidatorIDs: 100, IdempotencyWindow: time.Minute})
+	router := h.Router()
+
+	get := func(key, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/validator?"+query, nil)
+		req.Header.Set("Idempotency-Key", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("retry-1", "ids=1&chain=mainnet&range=24h")
+	if first.Code != http.StatusOK || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("expected a fetched response, got status %d", first.Code)
+	}
+
+	// Query parameters in another order are the same request
+	calls := fake.Calls(beaconchatest.MethodGetValidators)
+	retry := get("retry-1", "range=24h&chain=mainnet&ids=1")
+	if retry.Code != http.StatusOK || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected a replayed response, got status %d", retry.Code)
+	}
+	if retry.Body.String() != first.Body.String() || retry.Header().Get("X-Cache-Hits") != "1" {
+		t.Errorf("expected the same body served as a cache hit")
+	}
+	if got := fake.Calls(beaconchatest.MethodGetValidators); got != calls {
+		t.Errorf("expected no upstream calls, got %d", got-calls)
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		query  string
+		status int
+	}{
+		{"key reused for another request", "retry-1", "ids=2&chain=mainnet&range=24h", http.StatusUnprocessableEntity},
+		{"failed request", "retry-2", "ids=1&chain=mainnet&range=1y", http.StatusBadRequest},
+		{"key of a failed request reused", "retry-2", "ids=2&chain=mainnet&range=24h", http.StatusOK},
+		{"key too long", strings.Repeat("k", 256), "ids=1&chain=mainnet", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := get(tt.key, tt.query); w.Code != tt.status {
+				t.Errorf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_IdempotencyPanic(t *testing.T) {
+	h := NewHandler(service.NewValidatorService(beaconchatest.New(), nil, nil, nil, nil, nil), &config.Config{MaxValidatorIDs: 100, IdempotencyWindow: time.Minute})
+	var calls atomic.Int32
+	handler := h.recoveryMiddleware(h.idempotencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// Panicking after a 2xx status was written
+			w.WriteHeader(http.StatusOK)
+			panic("handler failed")
+		}
+		w.Write([]byte("ok"))
+	})))
+
+	// A retry waiting for a wedged key would time out
+	get := func() *httptest.ResponseRecorder {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		req := httptest.NewRequest(http.MethodGet, "/validator?ids=1", nil).WithContext(ctx)
+		req.Header.Set("Idempotency-Key", "panic-1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	get()
+	// The retry runs the request again instead of replaying the panicked one
+	if w := get(); w.Body.String() != "ok" || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("expected the retry to run, got %d: %q", w.Code, w.Body.String())
+	}
+	if replay := get(); replay.Header().Get("Idempotent-Replayed") != "true" || replay.Body.String() != "ok" {
+		t.Errorf("expected the successful retry to be replayed, got %q", replay.Body.String())
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("expected 2 calls, got %d", n)
+	}
+}
+
+func TestHandler_AlertRules(t *testing.T) {
+	registry, err := portfolio.NewRegistry([]portfolio.Portfolio{{Name: "home", Chain: "mainnet", ValidatorIds: []int{1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewValidatorService(beaconchatest.New(), nil, nil, nil, nil, nil)
+	h := NewHandler(svc, &config.Config{MaxValidatorIDs: 100})
+	h.SetAlerts(alerts.NewEngine(registry, svc, map[string]alerts.Notifier{"log": alerts.LogNotifier{}}))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "add rule", method: http.MethodPut, path: "/alerts/rules/offline", body: `{"portfolio":"home","condition":"offline > 0","channels":["log"]}`, wantStatus: http.StatusOK},
+		{name: "name mismatch", method: http.MethodPut, path: "/alerts/rules/offline", body: `{"name":"other","portfolio":"home","condition":"offline > 0","channels":["log"]}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown field", method: http.MethodPut, path: "/alerts/rules/offline", body: `{"portfolio":"home","condition":"offline > 0","channels":["log"],"when":"now"}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid condition", method: http.MethodPut, path: "/alerts/rules/offline", body: `{"portfolio":"home","condition":"uptime < 1","channels":["log"]}`, wantStatus: http.StatusBadRequest},
+		{name: "list rules", method: http.MethodGet, path: "/alerts/rules", wantStatus: http.StatusOK},
+		{name: "delete rule", method: http.MethodDelete, path: "/alerts/rules/offline", wantStatus: http.StatusNoContent},
+		{name: "delete missing rule", method: http.MethodDelete, path: "/alerts/rules/offline", wantStatus: http.StatusNotFound},
+		{name: "mute portfolio", method: http.MethodPost, path: "/alerts/mute", body: `{"portfolio":"home","end":"2999-01-01T00:00:00Z","reason":"client upgrade"}`, wantStatus: http.StatusCreated},
+		{name: "mute without end", method: http.MethodPost, path: "/alerts/mute", body: `{"portfolio":"home"}`, wantStatus: http.StatusBadRequest},
+		{name: "list mutes", method: http.MethodGet, path: "/alerts/mute", wantStatus: http.StatusOK},
+		{name: "delete missing mute", method: http.MethodDelete, path: "/alerts/mute/unknown", wantStatus: http.StatusNotFound},
+		{name: "list open alerts", method: http.MethodGet, path: "/alerts?status=open", wantStatus: http.StatusOK},
+		{name: "invalid alert status", method: http.MethodGet, path: "/alerts?status=closed", wantStatus: http.StatusBadRequest},
+		{name: "ack missing alert", method: http.MethodPost, path: "/alerts/unknown/ack", body: `{"by":"alice"}`, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			h.Router().ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.path == "/alerts/rules" {
+				var resp models.AlertRulesResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if len(resp.Rules) != 1 || resp.Rules[0].Severity != "warning" {
+					t.Errorf("unexpected rules: %+v", resp.Rules)
+				}
+			}
+			if tt.path == "/alerts/mute" && tt.method == http.MethodGet {
+				var resp models.AlertMutesResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if len(resp.Mutes) != 1 || resp.Mutes[0].Reason != "client upgrade" {
+					t.Errorf("unexpected mutes: %+v", resp.Mutes)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_Labels(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Build(),
+		beaconchatest.Validator(2).Offline().Build(),
+		beaconchatest.Validator(3).Build(),
+	)
+	svc := service.NewValidatorService(fake, nil, nil, nil, nil, nil)
+	router := NewHandler(svc, &config.Config{MaxValidatorIDs: 100}).Router()
+
+	for id, body := range map[int]string{
+		1: `{"machine":"node-3","client":"lighthouse"}`,
+		2: `{"machine":"node-3","client":"teku"}`,
+		3: `{"machine":"node-4"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPut, "/validator/"+strconv.Itoa(id)+"/labels?chain=mainnet", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200 labeling validator %d, got %d: %s", id, w.Code, w.Body.String())
+		}
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantIds    []string
+		wantGroups map[string]int
+	}{
+		{name: "by label", query: "label=machine:node-3&chain=mainnet", wantStatus: http.StatusOK, wantIds: []string{"1", "2"}},
+		{name: "by labels and ids", query: "ids=2,3&label=machine:node-3&label=client:teku&chain=mainnet", wantStatus: http.StatusOK, wantIds: []string{"2"}},
+		{name: "grouped", query: "ids=1,2,3&groupBy=machine&chain=mainnet", wantStatus: http.StatusOK, wantIds: []string{"1", "2", "3"}, wantGroups: map[string]int{"node-3": 2, "node-4": 1}},
+		{name: "no match", query: "label=machine:node-9&chain=mainnet", wantStatus: http.StatusNotFound},
+		{name: "invalid selector", query: "label=machine&chain=mainnet", wantStatus: http.StatusBadRequest},
+		{name: "invalid group", query: "ids=1&groupBy=Machine&chain=mainnet", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/validator?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp models.ValidatorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			var ids []string
+			for id := range resp.Validators {
+				ids = append(ids, id)
+			}
+			slices.Sort(ids)
+			if !reflect.DeepEqual(ids, tt.wantIds) {
+				t.Errorf("expected validators %v, got %v", tt.wantIds, ids)
+			}
+			if resp.Validators["1"].Labels["machine"] != "node-3" && slices.Contains(ids, "1") {
+				t.Errorf("expected validator 1 to carry its labels, got %v", resp.Validators["1"].Labels)
+			}
+			for value, count := range tt.wantGroups {
+				if got := resp.Groups[value].Validators; got != count {
+					t.Errorf("expected %d validators in group %q, got %d", count, value, got)
+				}
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/labels?chain=mainnet", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var labels models.LabelsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &labels); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(labels.Validators) != 3 || labels.Validators["3"]["machine"] != "node-4" {
+		t.Errorf("unexpected labels: %+v", labels)
+	}
+}
+
+func TestHandler_Notes(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2, 3)...)
+	svc := service.NewValidatorService(fake, nil, nil, nil, nil, nil)
+	router := NewHandler(svc, &config.Config{MaxValidatorIDs: 100}).Router()
+
+	put := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	for id, body := range map[int]string{
+		1: `{"customer":"acme","location":"fra"}`,
+		2: `{"customer":"acme","location":"ams"}`,
+		3: `{"customer":"globex"}`,
+	} {
+		if w := put("/validator/"+strconv.Itoa(id)+"/labels?chain=mainnet", body); w.Code != http.StatusOK {
+			t.Fatalf("expected status 200 labeling validator %d, got %d: %s", id, w.Code, w.Body.String())
+		}
+	}
+	if w := put("/validator/2/notes?chain=mainnet", `{"text":"Moved to new NVMe disk"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 saving notes, got %d: %s", w.Code, w.Body.String())
+	}
+
+	t.Run("put", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			path       string
+			body       string
+			wantStatus int
+		}{
+			{name: "valid", path: "/validator/3/notes?chain=mainnet", body: `{"text":"spare"}`, wantStatus: http.StatusOK},
+			{name: "invalid id", path: "/validator/abc/notes?chain=mainnet", body: `{"text":"x"}`, wantStatus: http.StatusBadRequest},
+			{name: "invalid chain", path: "/validator/1/notes?chain=sepolia", body: `{"text":"x"}`, wantStatus: http.StatusBadRequest},
+			{name: "not an object", path: "/validator/1/notes?chain=mainnet", body: `"x"`, wantStatus: http.StatusBadRequest},
+			{name: "unknown field", path: "/validator/1/notes?chain=mainnet", body: `{"note":"x"}`, wantStatus: http.StatusBadRequest},
+			{name: "control characters", path: "/validator/1/notes?chain=mainnet", body: `{"text":"a\u0000b"}`, wantStatus: http.StatusBadRequest},
+			{name: "too long", path: "/validator/1/notes?chain=mainnet", body: `{"text":"` + strings.Repeat("a", 5000) + `"}`, wantStatus: http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if w := put(tt.path, tt.body); w.Code != tt.wantStatus {
+					t.Errorf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			query      string
+			wantStatus int
+			wantIds    []int
+		}{
+			{name: "by meta", query: "meta=customer:acme&chain=mainnet", wantStatus: http.StatusOK, wantIds: []int{1, 2}},
+			{name: "by metas", query: "meta=customer:acme&meta=location:ams&chain=mainnet", wantStatus: http.StatusOK, wantIds: []int{2}},
+			{name: "by notes", query: "q=nvme&chain=mainnet", wantStatus: http.StatusOK, wantIds: []int{2}},
+			{name: "by label value", query: "q=GLOB&chain=mainnet", wantStatus: http.StatusOK, wantIds: []int{3}},
+			{name: "by meta and notes", query: "meta=customer:acme&q=spare&chain=mainnet", wantStatus: http.StatusOK, wantIds: nil},
+			{name: "missing filter", query: "chain=mainnet", wantStatus: http.StatusBadRequest},
+			{name: "invalid meta", query: "meta=customer&chain=mainnet", wantStatus: http.StatusBadRequest},
+			{name: "invalid chain", query: "meta=customer:acme&chain=sepolia", wantStatus: http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "/validator/search?"+tt.query, nil)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				if w.Code != tt.wantStatus {
+					t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+				}
+				if tt.wantStatus != http.StatusOK {
+					return
+				}
+				var resp models.ValidatorSearchResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				var ids []int
+				for _, v := range resp.Validators {
+					ids = append(ids, v.ValidatorIndex)
+				}
+				if !reflect.DeepEqual(ids, tt.wantIds) {
+					t.Errorf("expected validators %v, got %v", tt.wantIds, ids)
+				}
+			})
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/validator?ids=2&chain=mainnet", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp models.ValidatorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if got := resp.Validators["2"].Notes; got != "Moved to new NVMe disk" {
+		t.Errorf("expected validator 2 to carry its notes, got %q", got)
+	}
+}
+
+func TestHandler_Search(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2)...)
+	svc := service.NewValidatorService(fake, nil, nil, nil, nil, nil)
+	router := NewHandler(svc, &config.Config{MaxValidatorIDs: 100}).Router()
+
+	req := httptest.NewRequest(http.MethodPut, "/validator/2/labels?chain=mainnet", bytes.NewBufferString(`{"customer":"acme"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 labeling validator 2, got %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantResults int
+	}{
+		{name: "match", query: "q=acme", wantStatus: http.StatusOK, wantResults: 1},
+		{name: "match on chain", query: "q=acme&chain=mainnet&limit=5", wantStatus: http.StatusOK, wantResults: 1},
+		{name: "other chain", query: "q=acme&chain=hoodi", wantStatus: http.StatusOK, wantResults: 0},
+		{name: "missing query", query: "q=%20", wantStatus: http.StatusBadRequest},
+		{name: "query too long", query: "q=" + strings.Repeat("a", 257), wantStatus: http.StatusBadRequest},
+		{name: "invalid chain", query: "q=acme&chain=sepolia", wantStatus: http.StatusBadRequest},
+		{name: "invalid limit", query: "q=acme&limit=0", wantStatus: http.StatusBadRequest},
+		{name: "limit too high", query: "q=acme&limit=101", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/search?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp models.SearchResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Results) != tt.wantResults {
+				t.Errorf("expected %d results, got %+v", tt.wantResults, resp.Results)
+			}
+		})
+	}
+}
+
+func TestHandler_WatchlistImport(t *testing.T) {
+	key := func(c string) string { return "0x" + strings.Repeat(c, 96) }
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Pubkey(key("1")).Build(),
+		beaconchatest.Validator(2).Pubkey(key("2")).Pending(1).Build(),
+	)
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{{Name: "ops", Chain: "mainnet", ValidatorIds: []int{1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewValidatorService(fake, nil, nil, nil, portfolios, nil)
+	router := NewHandler(svc, &config.Config{MaxValidatorIDs: 100}).Router()
+
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	part, _ := mw.CreateFormFile("file", "deposit_data-1700000000.json")
+	part.Write([]byte(`[{"pubkey":"` + strings.Repeat("2", 96) + `","network_name":"mainnet"}]`))
+	mw.Close()
+
+	tests := []struct {
+		name             string
+		path             string
+		contentType      string
+		body             string
+		wantStatus       int
+		wantAdded        []int
+		wantNotActivated int
+	}{
+		{name: "multipart deposit data", path: "/watchlist/ops/import", contentType: mw.FormDataContentType(), body: form.String(), wantStatus: http.StatusOK, wantAdded: []int{2}, wantNotActivated: 1},
+		{name: "csv", path: "/watchlist/ops/import", contentType: "text/csv", body: "pubkey\n" + key("1") + "\n" + key("3") + "\n", wantStatus: http.StatusOK, wantAdded: []int{}, wantNotActivated: 1},
+		{name: "wrong network", path: "/watchlist/ops/import", contentType: "application/json", body: `[{"pubkey":"` + key("1") + `","network_name":"hoodi"}]`, wantStatus: http.StatusBadRequest},
+		{name: "invalid key", path: "/watchlist/ops/import", contentType: "text/csv", body: "0x1234\n", wantStatus: http.StatusBadRequest},
+		{name: "multipart without file", path: "/watchlist/ops/import", contentType: "multipart/form-data; boundary=x", body: "--x--\r\n", wantStatus: http.StatusBadRequest},
+		{name: "unknown watchlist", path: "/watchlist/other/import", contentType: "text/csv", body: key("1"), wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp models.WatchlistImportResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(resp.Added, tt.wantAdded) {
+				t.Errorf("expected validators %v added, got %v", tt.wantAdded, resp.Added)
+			}
+			if len(resp.NotActivated) != tt.wantNotActivated {
+				t.Errorf("expected %d keys not activated, got %+v", tt.wantNotActivated, resp.NotActivated)
+			}
+		})
+	}
+}
+
+func TestHandler_ClientMisses(t *testing.T) {
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{
+		{Name: "node", Chain: "mainnet", ValidatorIds: []int{1}, ClientMetricsURL: "http://localhost:5064/metrics"},
+		{Name: "ops", Chain: "mainnet", ValidatorIds: []int{2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := beaconchatest.New()
+	svc := service.NewValidatorService(fake, nil, nil, nil, portfolios, nil)
+
+	disabled := NewHandler(svc, &config.Config{MaxValidatorIDs: 100}).Router()
+	w := httptest.NewRecorder()
+	disabled.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/validator-client/misses?portfolio=node", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected status 501 without client metrics, got %d", w.Code)
+	}
+
+	h := NewHandler(svc, &config.Config{MaxValidatorIDs: 100})
+	h.SetClientMetrics(vcmetrics.NewCorrelator(portfolios, fake, time.Second))
+	router := h.Router()
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{name: "portfolio with client metrics", query: "?portfolio=node", wantStatus: http.StatusOK},
+		{name: "portfolio without client metrics", query: "?portfolio=ops", wantStatus: http.StatusNotFound},
+		{name: "missing portfolio", query: "", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/validator-client/misses"+tt.query, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp models.ClientMissesResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Portfolio != "node" || resp.Chain != "mainnet" || resp.Windows == nil {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
+func TestHandler_Clusters(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2)...)
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{
+		{Name: "dv", Chain: "mainnet", ValidatorIds: []int{1, 2}, Cluster: &portfolio.Cluster{
+			Protocol:  portfolio.ProtocolSSV,
+			Operators: []portfolio.ClusterOperator{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewValidatorService(fake, nil, nil, nil, portfolios, nil)
+	router := NewHandler(svc, &config.Config{MaxValidatorIDs: 100}).Router()
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{name: "all clusters", query: "", wantStatus: http.StatusOK},
+		{name: "named cluster", query: "?clusters=dv&range=7d", wantStatus: http.StatusOK},
+		{name: "unknown cluster", query: "?clusters=other", wantStatus: http.StatusNotFound},
+		{name: "invalid range", query: "?range=1y", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/clusters"+tt.query, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp models.ClustersResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Clusters) != 1 || resp.Clusters[0].Validators != 2 || len(resp.Clusters[0].Operators) != 4 {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
+func TestHandler_PresignedExits(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2)...)
+	svc := service.NewValidatorService(fake, nil, nil, nil, nil, nil)
+	router := NewHandler(svc, &config.Config{MaxValidatorIDs: 100}).Router()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "register", method: http.MethodPut, path: "/validator/1/exit?chain=mainnet", body: `{"storedAt":"2025-06-01T12:00:00Z","location":"vault:ops/exits/1"}`, wantStatus: http.StatusOK},
+		{name: "register without time", method: http.MethodPut, path: "/validator/2/exit?chain=mainnet", body: `{}`, wantStatus: http.StatusOK},
+		{name: "signed message", method: http.MethodPut, path: "/validator/2/exit?chain=mainnet", body: `{"signature":"0xabc"}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid chain", method: http.MethodPut, path: "/validator/2/exit?chain=sepolia", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "delete", method: http.MethodDelete, path: "/validator/2/exit?chain=mainnet", wantStatus: http.StatusNoContent},
+		{name: "delete missing", method: http.MethodDelete, path: "/validator/2/exit?chain=mainnet", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/validator?ids=1,2&chain=mainnet", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp models.ValidatorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !resp.Validators["1"].PresignedExit || resp.Validators["2"].PresignedExit {
+		t.Errorf("expected only validator 1 to have a pre-signed exit, got %+v", resp.Validators)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/exits?chain=mainnet", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var list models.PresignedExitsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(list.Validators) != 1 || list.Validators["1"].Location != "vault:ops/exits/1" {
+		t.Errorf("unexpected pre-signed exits: %+v", list)
+	}
+}
+
+func TestHandler_RequestTimeout(t *testing.T) {
+	h := &Handler{config: &config.Config{MaxRequestTimeout: 30 * time.Second}}
+
+	tests := []struct {
+		name    string
+		query   string
+		header  string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default", want: 30 * time.Second},
+		{name: "query duration", query: "timeout=10s", want: 10 * time.Second},
+		{name: "header seconds", header: "2.5", want: 2500 * time.Millisecond},
+		{name: "query takes precedence", query: "timeout=5s", header: "20s", want: 5 * time.Second},
+		{name: "capped at maximum", query: "timeout=5m", want: 30 * time.Second},
+		{name: "invalid", query: "timeout=soon", wantErr: true},
+		{name: "not positive", header: "0s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/validator?"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Request-Timeout", tt.header)
+			}
+
+			got, err := h.requestTimeout(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHandler_ValidatorReportJob(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2, 3)...)
+	svc := service.NewValidatorService(fake, nil, nil, nil, nil, nil)
+	h := NewHandler(svc, &config.Config{MaxValidatorIDs: 100, MaxReportValidatorIDs: 3})
+	manager := jobs.NewManager(time.Hour, 10)
+	h.SetJobs(manager)
+	router := h.Router()
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"too many validators", `{"validatorIds":[1,2,3,4],"chain":"mainnet"}`, http.StatusBadRequest},
+		{"unknown field", `{"ids":[1],"chain":"mainnet"}`, http.StatusBadRequest},
+		{"invalid range", `{"validatorIds":[1],"chain":"mainnet","range":"1y"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(http.MethodPost, "/jobs/validator-report", tt.body); w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+
+	w := do(http.MethodPost, "/jobs/validator-report", `{"validatorIds":[1,2,3],"chain":"mainnet","range":"7d"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d", w.Code)
+	}
+	var job models.Job
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatal(err)
+	}
+	if w.Header().Get("Location") != "/jobs/"+job.ID || job.Total != 3 {
+		t.Errorf("expected a queued job over 3 validators, got %+v", job)
+	}
+
+	// The result is not available before the job has run
+	if w := do(http.MethodGet, "/jobs/"+job.ID+"/result", ""); w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 for a queued job, got %d", w.Code)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go manager.Run(ctx)
+	for i := 0; i < 100 && job.Status != jobs.StatusDone; i++ {
+		time.Sleep(5 * time.Millisecond)
+		if err := json.Unmarshal(do(http.MethodGet, "/jobs/"+job.ID, "").Body.Bytes(), &job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if job.Status != jobs.StatusDone || job.ResultURL != "/jobs/"+job.ID+"/result" {
+		t.Fatalf("expected a finished job with a result URL, got %+v", job)
+	}
+
+	w = do(http.MethodGet, job.ResultURL, "")
+	var report models.ValidatorReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || len(report.Validators) != 3 || report.Range != "7d" {
+		t.Errorf("expected the report of 3 validators, got status %d with %d validators", w.Code, len(report.Validators))
+	}
+	if w := do(http.MethodGet, "/jobs/unknown", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown job, got %d", w.Code)
+	}
+}
+
+func TestHandler_Reports(t *testing.T) {
+	registry, err := portfolio.NewRegistry([]portfolio.Portfolio{{Name: "home", Chain: "mainnet", ValidatorIds: []int{1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1)...)
+	svc := service.NewValidatorService(fake, nil, nil, nil, registry, nil)
+	schedule := reports.ScheduleConfig{Name: "daily-home", Portfolio: "home", Period: "daily", Schedule: "@daily"}
+	scheduler, err := reports.NewScheduler(reports.Config{Reports: []reports.ScheduleConfig{schedule}}, registry, nil, svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, ok := scheduler.Generate(context.Background(), schedule)
+	if !ok {
+		t.Fatal("expected a report")
+	}
+	h := NewHandler(svc, &config.Config{MaxValidatorIDs: 100})
+	h.SetReports(scheduler)
+
+	for _, path := range []string{"/reports", "/reports/"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		h.Router().ServeHTTP(w, req)
+
+		var resp models.ReportsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if w.Code != http.StatusOK || len(resp.Reports) != 1 || resp.Reports[0].URL != "/reports/"+report.ID {
+			t.Errorf("%s: expected the report listed, got status %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/reports/"+report.ID, nil)
+	w := httptest.NewRecorder()
+	h.Router().ServeHTTP(w, req)
+	var got models.PortfolioReport
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if got.Portfolio != "home" || got.Range != "24h" || got.Validators != 1 {
+		t.Errorf("unexpected report: %+v", got)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment") {
+		t.Error("expected the report served as a download")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/reports/unknown", nil)
+	w = httptest.NewRecorder()
+	h.Router().ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+// adminRequest returns a test request carrying the admin token "secret".
+func adminRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set("X-Admin-Token", "secret")
+	return req
+}
+
+func TestHandler_Audit(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1)...)
+	svc := service.NewValidatorService(fake, nil, nil, nil, nil, nil)
+	h := NewHandler(svc, &config.Config{MaxValidatorIDs: 100, AdminToken: "secret"})
+
+	// The log is only served with the admin token
+	w := httptest.NewRecorder()
+	h.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/audit", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 without admin token, got %d", w.Code)
+	}
+
+	// Without an audit log the endpoint is disabled
+	w = httptest.NewRecorder()
+	h.Router().ServeHTTP(w, adminRequest(http.MethodGet, "/admin/audit", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected status 501 without audit log, got %d", w.Code)
+	}
+
+	trail, err := audit.Open(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer trail.Close()
+	h.SetAudit(trail)
+	router := h.Router()
+
+	requests := []struct {
+		method string
+		path   string
+		body   string
+		tenant string
+	}{
+		{http.MethodPut, "/validator/1/exit?chain=mainnet", `{"location": "vault:ops/exits/1"}`, "acme"},
+		{http.MethodGet, "/exits?chain=mainnet", "", "acme"},
+		{http.MethodPut, "/validator/1/exit?chain=mainnet", `{"signature":"0xabc"}`, ""},
+		{http.MethodDelete, "/validator/1/exit?chain=mainnet", "", ""},
+	}
+	for _, rq := range requests {
+		req := httptest.NewRequest(rq.method, rq.path, bytes.NewBufferString(rq.body))
+		if rq.tenant != "" {
+			req = req.WithContext(tenant.NewContext(req.Context(), rq.tenant))
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCount  int
+	}{
+		{"all", "", http.StatusOK, 3},
+		{"limit", "?limit=1", http.StatusOK, 1},
+		{"since", "?since=2099-01-01T00:00:00Z", http.StatusOK, 0},
+		{"invalid limit", "?limit=0", http.StatusBadRequest, 0},
+		{"invalid since", "?since=yesterday", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, adminRequest(http.MethodGet, "/admin/audit"+tt.query, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp models.AuditResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Entries) != tt.wantCount {
+				t.Errorf("expected %d entries, got %d", tt.wantCount, len(resp.Entries))
+			}
+		})
+	}
+
+	entries := trail.Entries(audit.Query{})
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+	if e := entries[0]; e.Method != http.MethodDelete || e.Status != http.StatusNoContent || e.Body != nil {
+		t.Errorf("unexpected delete entry: %+v", e)
+	}
+	if e := entries[1]; e.Status != http.StatusBadRequest || string(e.Body) != `{"signature":"0xabc"}` {
+		t.Errorf("unexpected rejected entry: %+v", e)
+	}
+	if e := entries[2]; e.Tenant != "acme" || e.Path != "/validator/1/exit" || e.Query != "chain=mainnet" || string(e.Body) != `{"location":"vault:ops/exits/1"}` {
+		t.Errorf("unexpected put entry: %+v", e)
+	}
+}
+
+func TestHandler_StateExportImport(t *testing.T) {
+	fake := beaconchatest.New()
+	svc := service.NewValidatorService(fake, nil, nil, nil, nil, nil)
+	h := NewHandler(svc, &config.Config{MaxValidatorIDs: 100, StateImportMaxBytes: 1 << 20})
+	// Without an admin token the endpoints are disabled
+	w := httptest.NewRecorder()
+	h.Router().ServeHTTP(w, adminRequest(http.MethodGet, "/admin/export", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403 without an admin token, got %d", w.Code)
+	}
+
+	h.config.AdminToken = "secret"
+	for _, token := range []string{"", "wrong"} {
+		for _, req := range []*http.Request{
+			httptest.NewRequest(http.MethodGet, "/admin/export", nil),
+			httptest.NewRequest(http.MethodPost, "/admin/import", strings.NewReader("archive")),
+		} {
+			if token != "" {
+				req.Header.Set("X-Admin-Token", token)
+			}
+			w := httptest.NewRecorder()
+			h.Router().ServeHTTP(w, req)
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("%s %s with token %q: expected status 401, got %d", req.Method, req.URL.Path, token, w.Code)
+			}
+			if w.Header().Get("Access-Control-Allow-Origin") != "" {
+				t.Errorf("%s %s: expected no CORS headers", req.Method, req.URL.Path)
+			}
+		}
+	}
+
+	// Archives would miss the history of a database, so they are refused
+	h.config.HistoryDatabaseURL = "postgres://db/vdash"
+	for _, req := range []*http.Request{
+		adminRequest(http.MethodGet, "/admin/export", nil),
+		adminRequest(http.MethodPost, "/admin/import", strings.NewReader("archive")),
+	} {
+		w := httptest.NewRecorder()
+		h.Router().ServeHTTP(w, req)
+		if w.Code != http.StatusNotImplemented || !strings.Contains(w.Body.String(), "backup_unsupported") {
+			t.Errorf("%s %s: expected 501 backup_unsupported with a database, got %d %s", req.Method, req.URL.Path, w.Code, w.Body.String())
+		}
+	}
+	h.config.HistoryDatabaseURL = ""
+
+	// Without a data directory the endpoints are disabled
+	w = httptest.NewRecorder()
+	h.Router().ServeHTTP(w, adminRequest(http.MethodGet, "/admin/export", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected status 501 without data directory, got %d", w.Code)
+	}
+
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "labels.json"), []byte(`{"mainnet":{"1":{"machine":"node-1"}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h.SetBackup(backup.New(src))
+	w = httptest.NewRecorder()
+	h.Router().ServeHTTP(w, adminRequest(http.MethodGet, "/admin/export", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/gzip" {
+		t.Fatalf("expected a gzip archive, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	archive := w.Body.Bytes()
+
+	dst := t.TempDir()
+	h.SetBackup(backup.New(dst))
+	tests := []struct {
+		name       string
+		body       []byte
+		limit      int64
+		wantStatus int
+	}{
+		{"import", archive, int64(len(archive)), http.StatusAccepted},
+		{"invalid", []byte("not an archive"), 1 << 20, http.StatusBadRequest},
+		{"too large", archive, int64(len(archive)) - 1, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h.config.StateImportMaxBytes = tt.limit
+			w := httptest.NewRecorder()
+			h.Router().ServeHTTP(w, adminRequest(http.MethodPost, "/admin/import", bytes.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusAccepted {
+				return
+			}
+			var resp models.StateImport
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Files != 1 || !resp.RestartRequired {
+				t.Errorf("expected one staged file, got %+v", resp)
+			}
+		})
+	}
+
+	// The last valid import is applied on the next start
+	if applied, err := backup.ApplyStaged(dst); err != nil || !applied {
+		t.Fatalf("expected a staged import, got %v, %v", applied, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "labels.json")); err != nil || !strings.Contains(string(data), "node-1") {
+		t.Errorf("expected the imported labels, got %q, %v", data, err)
+	}
+}
+
+func TestHandler_ValidatorFields(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1)...)
+	svc := service.NewValidatorService(fake, nil, nil, nil, nil, nil)
+	router := NewHandler(svc, &config.Config{MaxValidatorIDs: 100}).Router()
+
+	req := httptest.NewRequest(http.MethodGet, "/validator?ids=1&chain=mainnet&fields=unknown", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown field, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/validator?ids=1&chain=mainnet&fields=overview.status", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body)
+	}
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response) != 1 || string(response["validators"]) != `{"1":{"status":"active_online"}}` {
+		t.Errorf("expected only the validator statuses, got %s", w.Body)
+	}
+}
+
+func TestHandler_UnitsAndLocale(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1)...)
+	svc := service.NewValidatorService(fake, nil, nil, nil, nil, nil)
+	router := NewHandler(svc, &config.Config{MaxValidatorIDs: 100}).Router()
+
+	tests := []struct {
+		name    string
+		query   string
+		status  int
+		balance string
+	}{
+		{"default wei", "", http.StatusOK, `"32000000000000000000"`},
+		{"eth", "&units=eth", http.StatusOK, `"32"`},
+		{"gwei with fields", "&units=gwei&fields=overview.currentBalance", http.StatusOK, `"32000000000"`},
+		{"unknown unit", "&units=finney", http.StatusBadRequest, ""},
+		{"gwei in german", "&units=gwei&locale=de-DE", http.StatusOK, `"32.000.000.000"`},
+		{"wei in german", "&locale=de", http.StatusOK, `"32000000000000000000"`},
+		{"unknown locale", "&locale=tlh", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/validator?ids=1&chain=mainnet"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, w.Code, w.Body)
+			}
+			if tt.balance == "" {
+				return
+			}
+			var response struct {
+				Validators map[string]map[string]json.RawMessage `json:"validators"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(response.Validators["1"]["currentBalance"]); got != tt.balance {
+				t.Errorf("expected balance %s, got %s", tt.balance, got)
+			}
+		})
+	}
+}
+
+func TestHandler_Versions(t *testing.T) {
+	deprecated := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC)
+	previous := apiVersions
+	apiVersions = []apiVersion{
+		{name: "v1", deprecated: deprecated, sunset: sunset, successor: "v2", validator: currentSchema},
+		{name: "v2", validator: func(r models.ValidatorResponse) any {
+			return map[string]any{"validators": len(r.Validators)}
+		}},
+	}
+	defer func() { apiVersions = previous }()
+
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1)...)
+	svc := service.NewValidatorService(fake, nil, nil, nil, nil, nil)
+	router := NewHandler(svc, &config.Config{MaxValidatorIDs: 100}).Router()
+
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		wantVersion string
+		wantSunset  string
+		wantLink    string
+		wantBody    string
+	}{
+		{name: "unversioned", path: "/health", wantStatus: http.StatusOK, wantVersion: "v1",
+			wantSunset: "Thu, 01 Apr 2027 00:00:00 GMT", wantLink: `</v2/health>; rel="successor-version"`},
+		{name: "deprecated", path: "/v1/validator?ids=1&chain=mainnet&fields=overview.status", wantStatus: http.StatusOK, wantVersion: "v1",
+			wantSunset: "Thu, 01 Apr 2027 00:00:00 GMT", wantLink: `</v2/validator>; rel="successor-version"`},
+		{name: "current", path: "/v2/validator?ids=1&chain=mainnet", wantStatus: http.StatusOK, wantVersion: "v2",
+			wantBody: `{"validators":1}` + "\n"},
+		{name: "unknown version", path: "/v9/health", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("X-API-Version"); got != tt.wantVersion {
+				t.Errorf("expected version %q, got %q", tt.wantVersion, got)
+			}
+			if got := w.Header().Get("Sunset"); got != tt.wantSunset {
+				t.Errorf("expected Sunset %q, got %q", tt.wantSunset, got)
+			}
+			if got := w.Header().Get("Link"); got != tt.wantLink {
+				t.Errorf("expected Link %q, got %q", tt.wantLink, got)
+			}
+			if got := w.Header().Get("Deprecation"); (got != "") != (tt.wantSunset != "") {
+				t.Errorf("unexpected Deprecation %q", got)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{"", formatJSON},
+		{"text/html,application/xhtml+xml,*/*;q=0.8", formatJSON},
+		{"text/csv", formatCSV},
+		{"application/x-ndjson", formatNDJSON},
+		{"application/x-msgpack", formatMsgpack},
+		{"application/json;q=0.5, application/msgpack", formatMsgpack},
+		{"application/msgpack;q=0.2, */*;q=0.9", formatJSON},
+		{"image/png", formatJSON},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/validator", nil)
+		req.Header.Set("Accept", tt.accept)
+		if got := negotiateFormat(req); got != tt.expected {
+			t.Errorf("Accept %q: expected %s, got %s", tt.accept, tt.expected, got)
+		}
+	}
+}
+
+func TestHandler_ContentNegotiation(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2)...)
+	svc := service.NewValidatorService(fake, nil, nil, nil, nil, nil)
+	router := NewHandler(svc, &config.Config{MaxValidatorIDs: 100}).Router()
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		return w
+	}
+	const path = "/validator?ids=1,2&chain=mainnet&fields=overview.status,overview.withdrawalCredentials.type"
+
+	// One row per validator, with nested fields flattened
+	w := get(path, "text/csv")
+	if got := w.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("expected CSV, got %q", got)
+	}
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	expected := [][]string{{"key", "status", "withdrawalCredentials.type"}, {"1", "active_online", ""}, {"2", "active_online", ""}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %v, got %v", expected, rows)
+	}
+
+	w = get(path, "application/x-ndjson")
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"key":"2"`) {
+		t.Errorf("expected a line per validator, got %q", w.Body.String())
+	}
+
+	w = get(path, "application/msgpack")
+	if got := w.Header().Get("Content-Type"); got != formatMsgpack {
+		t.Errorf("expected MessagePack, got %q", got)
+	}
+	if w.Body.Len() == 0 || w.Body.Bytes()[0]&0xf0 != 0x80 {
+		t.Errorf("expected a MessagePack map, got %x", w.Body.Bytes())
+	}
+
+	// Errors stay JSON
+	req := httptest.NewRequest(http.MethodGet, "/validator?chain=mainnet", nil)
+	req.Header.Set("Accept", "text/csv")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected a JSON error, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestHandler_CustomWindow(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2)...)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	var entries []models.BeaconchainRewardsHistoryEntry
+	for i, total := range []string{"700", "300", "1000"} {
+		start := today.AddDate(0, 0, i-3)
+		entries = append(entries, models.BeaconchainRewardsHistoryEntry{
+			Range:   models.BeaconchainResultRange{Timestamp: models.BeaconchainTimestampRange{Start: start.Unix(), End: start.AddDate(0, 0, 1).Unix()}},
+			Rewards: models.BeaconchainRewardsData{Total: total, TotalReward: total, TotalPenalty: "0", TotalMissed: "0"},
+		})
+	}
+	fake.SetDailyRewards("mainnet", entries...)
+	svc := service.NewValidatorService(fake, nil, nil, nil, nil, nil)
+	router := NewHandler(svc, &config.Config{MaxValidatorIDs: 100}).Router()
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/validator?ids=1,2&chain=mainnet&"+query, nil))
+		return w
+	}
+
+	from, to := today.AddDate(0, 0, -3).Format("2006-01-02"), today.AddDate(0, 0, -1).Format("2006-01-02")
+	w := get("from=" + from + "&to=" + to)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	var rewards models.ValidatorRewards
+	json.Unmarshal(resp["rewards"], &rewards)
+	if rewards.Total != "1000" {
+		t.Errorf("expected the rewards of the 2 days, got %q", rewards.Total)
+	}
+	var window models.EvaluationWindow
+	if err := json.Unmarshal(resp["window"], &window); err != nil || !window.From.Equal(today.AddDate(0, 0, -3)) {
+		t.Errorf("expected the window to be described, got %s", resp["window"])
+	}
+	if _, ok := resp["performance"]; ok {
+		t.Error("expected no performance for a custom window")
+	}
+
+	// The window and the selection survive field selection
+	w = get("from=" + from + "&fields=rewards.total")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"window"`) {
+		t.Errorf("expected a selection with the window, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Epoch windows cover whole days, up to yesterday by default
+	lastEpoch, _ := service.LastDayEpoch("mainnet", today.AddDate(0, 0, -3).Add(time.Hour))
+	fromEpoch := strconv.FormatInt(lastEpoch+1, 10)
+	w = get("fromEpoch=" + fromEpoch)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	json.Unmarshal(resp["rewards"], &rewards)
+	if rewards.Total != "2000" {
+		t.Errorf("expected the rewards of the 3 days, got %q", rewards.Total)
+	}
+
+	for _, query := range []string{
+		"from=" + from + "&range=7d",
+		"fromEpoch=" + strconv.FormatInt(lastEpoch+2, 10),
+		"from=" + from + "&fromEpoch=1",
+		"from=yesterday",
+		"from=" + to + "&to=" + from,
+		"from=" + today.AddDate(0, 0, -100).Format("2006-01-02"),
+		"from=" + from + "&to=" + today.AddDate(0, 0, 2).Format("2006-01-02"),
+		"fromEpoch=10&toEpoch=5",
+		"from=" + from + "&fields=performance",
+		"from=" + from + "&compare=previous",
+	} {
+		if w := get(query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d: %s", query, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+)
+
+// Limits of idempotency keys.
+const (
+	maxIdempotencyKeyLength = 255
+	maxIdempotencyEntries   = 1000 // Requests with new keys are not recorded beyond this
+)
+
+// idempotencyStore keeps the successful responses of requests made with an
+// Idempotency-Key header for a window, so retries are answered without spending
+// upstream credits again.
+type idempotencyStore struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*idempotentResponse
+}
+
+// idempotentResponse is the response to the first request made with a key.
+type idempotentResponse struct {
+	request string        // Method, path and query of the request
+	done    chan struct{} // Closed once the response is complete
+	created time.Time
+
+	// Set before done is closed
+	status int
+	header http.Header
+	body   []byte
+}
+
+func newIdempotencyStore(window time.Duration) *idempotencyStore {
+	return &idempotencyStore{window: window, entries: make(map[string]*idempotentResponse)}
+}
+
+// idempotencyMiddleware replays the response to an earlier identical request made
+// with the same Idempotency-Key within the window. A retry arriving while the first
+// request is still running waits for its response. Only 2xx responses are kept, so
+// failed requests can be retried. Reusing a key for a different request is an error.
+func (h *Handler) idempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || h.idempotency == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			h.errorResponse(w, r, http.StatusBadRequest, "validation_error", "Idempotency-Key: must be at most 255 characters")
+			return
+		}
+
+		request := r.Method + " " + r.URL.Path + "?" + r.URL.Query().Encode() + " " + negotiateFormat(r)
+		for {
+			entry, first := h.idempotency.begin(key, request)
+			switch {
+			case entry == nil:
+				// Too many keys in use to record another one
+				next.ServeHTTP(w, r)
+				return
+			case entry.request != request:
+				h.errorResponse(w, r, http.StatusUnprocessableEntity, "idempotency_key_reused", "Idempotency-Key was already used for a different request")
+				return
+			case first:
+				defer func() {
+					if p := recover(); p != nil {
+						// Release waiting retries before the recovery middleware answers
+						h.idempotency.drop(key, entry)
+						panic(p)
+					}
+				}()
+				rec := &recordingWriter{ResponseWriter: w}
+				next.ServeHTTP(rec, r)
+				h.idempotency.finish(key, entry, rec)
+				return
+			}
+
+			select {
+			case <-entry.done:
+			case <-r.Context().Done():
+				return
+			}
+			if entry.status != 0 {
+				cost.AddCacheHit(r.Context())
+				for k, v := range entry.header {
+					w.Header()[k] = v
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(entry.status)
+				w.Write(entry.body)
+				return
+			}
+			// The first request failed and was not kept; run this one instead
+		}
+	})
+}
+
+// begin returns the entry of key, and whether it was created for this request. It
+// returns nil if the key is new and the store is full.
+func (s *idempotencyStore) begin(key, request string) (*idempotentResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if e, ok := s.entries[key]; ok && now.Sub(e.created) <= s.window {
+		return e, false
+	}
+	for k, e := range s.entries {
+		if now.Sub(e.created) > s.window {
+			delete(s.entries, k)
+		}
+	}
+	if len(s.entries) >= maxIdempotencyEntries {
+		return nil, false
+	}
+
+	e := &idempotentResponse{request: request, done: make(chan struct{}), created: now}
+	s.entries[key] = e
+	return e, true
+}
+
+// finish records the response to the first request of key. Responses other than
+// 2xx are dropped, so the key can be retried.
+func (s *idempotencyStore) finish(key string, e *idempotentResponse, rec *recordingWriter) {
+	if rec.status < 200 || rec.status >= 300 {
+		s.drop(key, e)
+		return
+	}
+	e.status = rec.status
+	e.header = rec.header
+	e.body = rec.body.Bytes()
+	close(e.done)
+}
+
+// drop forgets the unfinished entry of key, so waiting retries and later ones run
+// the request themselves.
+func (s *idempotencyStore) drop(key string, e *idempotentResponse) {
+	s.mu.Lock()
+	if s.entries[key] == e {
+		delete(s.entries, key)
+	}
+	s.mu.Unlock()
+	close(e.done)
+}
+
+// recordingWriter passes a response through while keeping a copy of it. The
+// headers are copied when written, before outer middleware such as the cost
+// headers add their own.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	if rw.status == 0 {
+		rw.status = code
+		rw.header = rw.Header().Clone()
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
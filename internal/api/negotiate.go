@@ -0,0 +1,288 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/msgpack"
+)
+
+// Response formats, by media type.
+const (
+	formatJSON    = "application/json"
+	formatCSV     = "text/csv"
+	formatNDJSON  = "application/x-ndjson"
+	formatMsgpack = "application/msgpack"
+)
+
+// mediaFormats are the formats of the media types accepted, including aliases
+// and ranges that JSON satisfies.
+var mediaFormats = map[string]string{
+	"application/json":      formatJSON,
+	"application/*":         formatJSON,
+	"*/*":                   formatJSON,
+	"text/csv":              formatCSV,
+	"application/x-ndjson":  formatNDJSON,
+	"application/jsonl":     formatNDJSON,
+	"application/msgpack":   formatMsgpack,
+	"application/x-msgpack": formatMsgpack,
+}
+
+// negotiateFormat returns the format of the media type with the highest quality
+// in the Accept header of r, the first one listed on ties. Requests without a
+// supported media type get JSON.
+func negotiateFormat(r *http.Request) string {
+	best, bestQ := formatJSON, 0.0
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, _ := strings.Cut(accepted, ";")
+		format, ok := mediaFormats[strings.ToLower(strings.TrimSpace(mediaType))]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+// formattedResponse writes data in a format other than JSON. MessagePack
+// encodes the whole response; CSV and NDJSON write its records, see records.
+func (h *Handler) formattedResponse(w http.ResponseWriter, r *http.Request, status int, format string, data any) {
+	var body []byte
+	var err error
+	switch format {
+	case formatMsgpack:
+		if queryFlag(r.URL.Query(), "envelope") {
+			data = models.Envelope{Data: data}
+		}
+		body, err = msgpack.Marshal(data)
+	case formatCSV, formatNDJSON:
+		var doc any
+		if doc, err = decodeDocument(data); err == nil {
+			if format == formatCSV {
+				body, err = encodeCSV(records(doc))
+			} else {
+				body, err = encodeNDJSON(records(doc))
+			}
+		}
+	}
+	if err != nil {
+		slog.Error("failed to encode response", "format", format, "error", err)
+		h.errorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode response")
+		return
+	}
+
+	contentType := format
+	if format == formatCSV {
+		contentType += "; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// decodeDocument returns the JSON document of data, keeping numbers as written.
+func decodeDocument(data any) (any, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var doc any
+	err = decoder.Decode(&doc)
+	return doc, err
+}
+
+// records returns the rows of a document: the elements of a top-level array, or
+// of an object, the entries of its only collection, an array of objects or an
+// object of objects such as the validators of GET /validator. Entries of an
+// object of objects carry their name as key. Any other document is one record.
+func records(doc any) []any {
+	switch v := doc.(type) {
+	case []any:
+		return v
+	case map[string]any:
+		var collection any
+		found := 0
+		for _, field := range v {
+			if isCollection(field) {
+				collection = field
+				found++
+			}
+		}
+		if found != 1 {
+			break
+		}
+		if list, ok := collection.([]any); ok {
+			return list
+		}
+		entries := collection.(map[string]any)
+		keys := make([]string, 0, len(entries))
+		for k := range entries {
+			keys = append(keys, k)
+		}
+		sortKeys(keys)
+		rows := make([]any, len(keys))
+		for i, k := range keys {
+			row := map[string]any{"key": k}
+			for field, value := range entries[k].(map[string]any) {
+				row[field] = value
+			}
+			rows[i] = row
+		}
+		return rows
+	}
+	return []any{doc}
+}
+
+// isCollection reports whether v is an array of objects or a non-empty object
+// of objects.
+func isCollection(v any) bool {
+	switch v := v.(type) {
+	case []any:
+		for _, e := range v {
+			if _, ok := e.(map[string]any); !ok {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		for _, e := range v {
+			if _, ok := e.(map[string]any); !ok {
+				return false
+			}
+		}
+		return len(v) > 0
+	}
+	return false
+}
+
+// sortKeys sorts keys numerically if they all are integers, such as validator
+// indices, and lexically otherwise.
+func sortKeys(keys []string) {
+	numeric := true
+	for _, k := range keys {
+		if _, err := strconv.ParseInt(k, 10, 64); err != nil {
+			numeric = false
+			break
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if numeric {
+			a, _ := strconv.ParseInt(keys[i], 10, 64)
+			b, _ := strconv.ParseInt(keys[j], 10, 64)
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+}
+
+// encodeNDJSON writes each record as a line of JSON.
+func encodeNDJSON(rows []any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeCSV writes the records as CSV with a header. Nested objects are
+// flattened into columns named by their path, such as
+// withdrawalCredentials.type, and arrays are written as JSON. The columns are
+// sorted, after key.
+func encodeCSV(rows []any) ([]byte, error) {
+	flat := make([]map[string]string, len(rows))
+	columns := make(map[string]bool)
+	for i, row := range rows {
+		flat[i] = make(map[string]string)
+		if err := flatten(flat[i], "", row); err != nil {
+			return nil, err
+		}
+		for column := range flat[i] {
+			columns[column] = true
+		}
+	}
+	header := make([]string, 0, len(columns))
+	for column := range columns {
+		header = append(header, column)
+	}
+	sort.Slice(header, func(i, j int) bool {
+		if (header[i] == "key") != (header[j] == "key") {
+			return header[i] == "key"
+		}
+		return header[i] < header[j]
+	})
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if len(header) > 0 {
+		writer.Write(header)
+	}
+	record := make([]string, len(header))
+	for _, row := range flat {
+		for i, column := range header {
+			record[i] = row[column]
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// flatten sets the cells of value, a decoded JSON value, at path in row. A
+// scalar at the top level is written to a value column.
+func flatten(row map[string]string, path string, value any) error {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, e := range v {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			if err := flatten(row, child, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if path == "" {
+		path = "value"
+	}
+	switch v := value.(type) {
+	case nil:
+		row[path] = ""
+	case string:
+		row[path] = v
+	case json.Number:
+		row[path] = v.String()
+	case bool:
+		row[path] = strconv.FormatBool(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		row[path] = string(encoded)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// apiVersion is a version of the response schemas, served under /{name}/. Each
+// version builds its responses from the current schema, so a new version only
+// adds builders for the responses it changes.
+type apiVersion struct {
+	name string
+	// deprecated and sunset are when the version was deprecated and when it is
+	// removed, zero while it is supported. successor is the version replacing it.
+	deprecated time.Time
+	sunset     time.Time
+	successor  string
+	// validator builds the GET /validator response of the version.
+	validator func(models.ValidatorResponse) any
+}
+
+// currentSchema returns the response as is.
+func currentSchema(response models.ValidatorResponse) any { return response }
+
+// apiVersions are the versions served, oldest first.
+var apiVersions = []apiVersion{
+	{name: "v1", validator: currentSchema},
+}
+
+// defaultVersion is served on unversioned routes, which predate versioning, so
+// frontends built against them keep their schema.
+const defaultVersion = "v1"
+
+type versionKey struct{}
+
+// versionMiddleware serves the requests of version v, announcing its
+// deprecation and removal in the Deprecation, Sunset and Link headers.
+func versionMiddleware(v apiVersion, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !v.deprecated.IsZero() {
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(v.deprecated.Unix(), 10))
+			if !v.sunset.IsZero() {
+				w.Header().Set("Sunset", v.sunset.UTC().Format(http.TimeFormat))
+			}
+			if v.successor != "" {
+				w.Header().Set("Link", "</"+v.successor+unversionedPath(r.URL.Path)+">; rel=\"successor-version\"")
+			}
+		}
+		w.Header().Set("X-API-Version", v.name)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, v)))
+	})
+}
+
+// requestVersion returns the version a request is served in.
+func requestVersion(r *http.Request) apiVersion {
+	if v, ok := r.Context().Value(versionKey{}).(apiVersion); ok {
+		return v
+	}
+	v, _ := lookupVersion(defaultVersion)
+	return v
+}
+
+// lookupVersion returns the version named name.
+func lookupVersion(name string) (apiVersion, bool) {
+	for _, v := range apiVersions {
+		if v.name == name {
+			return v, true
+		}
+	}
+	return apiVersion{}, false
+}
+
+// unversionedPath returns path without the prefix of its version, if any.
+func unversionedPath(path string) string {
+	for _, v := range apiVersions {
+		if rest, ok := strings.CutPrefix(path, "/"+v.name+"/"); ok {
+			return "/" + rest
+		}
+	}
+	return path
+}
+
+// versionedRouter serves api under the prefix of every version, and unversioned
+// at the root in the default version.
+func versionedRouter(api http.Handler) *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, v := range apiVersions {
+		mux.Handle("/"+v.name+"/", versionMiddleware(v, http.StripPrefix("/"+v.name, api)))
+	}
+	v, _ := lookupVersion(defaultVersion)
+	mux.Handle("/", versionMiddleware(v, api))
+	return mux
+}
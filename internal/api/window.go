@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/service"
+)
+
+// windowParams are the query parameters of a custom evaluation window.
+var windowParams = []string{"from", "to", "fromEpoch", "toEpoch"}
+
+// hasWindow reports whether query asks for a custom evaluation window.
+func hasWindow(query url.Values) bool {
+	for _, p := range windowParams {
+		if query.Has(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWindow returns the evaluation range of the custom window of query on
+// chain: from from to to, RFC 3339 times or dates with to excluded and defaulting
+// to now, or from fromEpoch to toEpoch inclusive, defaulting to the last epoch of
+// yesterday.
+func parseWindow(query url.Values, chain string, now time.Time) (string, error) {
+	if query.Has("fromEpoch") || query.Has("toEpoch") {
+		if query.Has("from") || query.Has("to") {
+			return "", &ValidationError{Field: "from", Message: "cannot be combined with fromEpoch or toEpoch"}
+		}
+		start, err := strconv.ParseInt(query.Get("fromEpoch"), 10, 64)
+		if err != nil {
+			return "", &ValidationError{Field: "fromEpoch", Message: "must be an epoch number"}
+		}
+		end, err := service.LastDayEpoch(chain, now)
+		if err != nil {
+			return "", &ValidationError{Field: "toEpoch", Message: err.Error()}
+		}
+		if query.Has("toEpoch") {
+			if end, err = strconv.ParseInt(query.Get("toEpoch"), 10, 64); err != nil {
+				return "", &ValidationError{Field: "toEpoch", Message: "must be an epoch number"}
+			}
+		}
+		window, err := service.EpochWindowRange(chain, start, end, now)
+		return window, windowError(err)
+	}
+
+	from, err := parseWindowTime(query.Get("from"))
+	if err != nil {
+		return "", &ValidationError{Field: "from", Message: "must be an RFC 3339 time or a date like 2026-01-31"}
+	}
+	to := now
+	if query.Has("to") {
+		if to, err = parseWindowTime(query.Get("to")); err != nil {
+			return "", &ValidationError{Field: "to", Message: "must be an RFC 3339 time or a date like 2026-01-31"}
+		}
+	}
+	window, err := service.WindowRange(from, to, now)
+	return window, windowError(err)
+}
+
+// parseWindowTime parses an RFC 3339 time, or a date as the start of its UTC day.
+func parseWindowTime(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// windowError returns the validation error of a window that cannot be served.
+func windowError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ValidationError{Field: "window", Message: err.Error()}
+}
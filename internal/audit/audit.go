@@ -0,0 +1,117 @@
+// Package audit keeps an append-only trail of the changes made through the API,
+// such as alert rule, label and pre-signed exit edits, recording who made each
+// change and when.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// maxEntries is the number of entries kept in memory for queries. The file keeps
+// every entry.
+const maxEntries = 10_000
+
+// Query selects audit entries.
+type Query struct {
+	Since time.Time // Entries at or after Since; zero for all
+	Limit int       // Maximum number of entries; 0 for all
+}
+
+// Log is an audit trail appended to a JSON lines file. It is safe for concurrent
+// use.
+type Log struct {
+	mu      sync.Mutex
+	file    *os.File
+	entries []models.AuditEntry // Oldest first
+}
+
+// Open opens the audit trail in the file at path, creating it if needed, and
+// loads its latest entries.
+func Open(path string) (*Log, error) {
+	l := &Log{}
+
+	f, err := os.Open(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+	if err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+		for scanner.Scan() {
+			var e models.AuditEntry
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				return nil, fmt.Errorf("decode audit log: %w", err)
+			}
+			l.keep(e)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("read audit log: %w", err)
+		}
+	}
+
+	l.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	return l, nil
+}
+
+// Record appends an entry to the trail.
+func (l *Log) Record(e models.AuditEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encode audit entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	l.keep(e)
+	return nil
+}
+
+// keep adds an entry to the entries in memory, dropping the oldest beyond maxEntries.
+func (l *Log) keep(e models.AuditEntry) {
+	l.entries = append(l.entries, e)
+	if len(l.entries) > maxEntries {
+		l.entries = l.entries[len(l.entries)-maxEntries:]
+	}
+}
+
+// Entries returns the entries matching q, newest first.
+func (l *Log) Entries(q Query) []models.AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := []models.AuditEntry{}
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		e := l.entries[i]
+		if e.Time.Before(q.Since) {
+			break
+		}
+		if q.Limit > 0 && len(result) == q.Limit {
+			break
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+// Close closes the file of the trail.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range []string{"/alerts/rules/low", "/validator/1/labels", "/validator/1/exit"} {
+		e := models.AuditEntry{Time: base.Add(time.Duration(i) * time.Minute), Tenant: "acme", Method: "PUT", Path: p, Status: 200}
+		if i == 1 {
+			e.Body = json.RawMessage(`{"client":"lighthouse"}`)
+		}
+		if err := l.Record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Entries survive reopening, and new ones are appended
+	l, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := l.Record(models.AuditEntry{Time: base.Add(3 * time.Minute), Method: "DELETE", Path: "/validator/1/exit", Status: 204}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		query     Query
+		wantPaths []string
+	}{
+		{"all", Query{}, []string{"/validator/1/exit", "/validator/1/exit", "/validator/1/labels", "/alerts/rules/low"}},
+		{"limit", Query{Limit: 2}, []string{"/validator/1/exit", "/validator/1/exit"}},
+		{"since", Query{Since: base.Add(time.Minute)}, []string{"/validator/1/exit", "/validator/1/exit", "/validator/1/labels"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := l.Entries(tt.query)
+			if len(entries) != len(tt.wantPaths) {
+				t.Fatalf("got %d entries, want %d", len(entries), len(tt.wantPaths))
+			}
+			for i, e := range entries {
+				if e.Path != tt.wantPaths[i] {
+					t.Errorf("entry %d path = %s, want %s", i, e.Path, tt.wantPaths[i])
+				}
+			}
+		})
+	}
+
+	entries := l.Entries(Query{})
+	if entries[0].Method != "DELETE" || string(entries[2].Body) != `{"client":"lighthouse"}` || entries[2].Tenant != "acme" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
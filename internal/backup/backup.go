@@ -0,0 +1,359 @@
+// Package backup moves the state a deployment keeps in its data directory to
+// another deployment as a single archive.
+//
+// An archive is a gzipped tar file holding:
+//
+//	manifest.json      Version, creation time and files of the archive
+//	data/...           The files of the data directory
+//	config/...         Configuration for reference, e.g. the portfolios
+//
+// Imports are staged next to the data directory and applied on the next start,
+// before any state is loaded, so running components do not overwrite them.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// version is the archive format version.
+const version = 1
+
+// Staging directories within the data directory, left out of exports.
+const (
+	stagingDir = "import"
+	partialDir = "import.tmp"
+)
+
+// Manifest describes an archive.
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Files     []string  `json:"files"`  // Under data/, slash-separated
+	Config    []string  `json:"config"` // Under config/
+	Bytes     int64     `json:"bytes"`  // Total size of the data files
+}
+
+// Archive exports and stages imports of the state in a data directory.
+type Archive struct {
+	dir    string
+	freeze func(fn func() error) error       // Optional, see SetFreeze
+	config map[string]func() ([]byte, error) // By file name, see AddConfig
+
+	importMu sync.Mutex // Serializes imports, which share the staging directory
+}
+
+// New creates an archive of the state in dir.
+func New(dir string) *Archive {
+	return &Archive{dir: dir, config: make(map[string]func() ([]byte, error))}
+}
+
+// SetFreeze makes exports open the data files within freeze, which runs its
+// function while no records are written to them.
+func (a *Archive) SetFreeze(freeze func(fn func() error) error) {
+	a.freeze = freeze
+}
+
+// AddConfig adds the file name to the config/ directory of exports, with the
+// content returned by read. Config files are not imported.
+func (a *Archive) AddConfig(name string, read func() ([]byte, error)) {
+	a.config[name] = read
+}
+
+// Export writes an archive of the data directory to w. The data files are
+// opened within freeze and copied up to their size at that time, so a slow reader
+// does not hold up writes and records appended meanwhile are left out.
+func (a *Archive) Export(w io.Writer) error {
+	manifest := Manifest{Version: version, CreatedAt: time.Now().UTC(), Files: []string{}, Config: []string{}}
+	config := make(map[string][]byte, len(a.config))
+	for name, read := range a.config {
+		data, err := read()
+		if err != nil {
+			return fmt.Errorf("read config %s: %w", name, err)
+		}
+		config[name] = data
+		manifest.Config = append(manifest.Config, name)
+	}
+	sort.Strings(manifest.Config)
+
+	var files map[string]dataFile
+	open := func() (err error) {
+		files, err = a.openDataFiles()
+		return err
+	}
+	var err error
+	if a.freeze != nil {
+		err = a.freeze(open)
+	} else {
+		err = open()
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for name, f := range files {
+		manifest.Files = append(manifest.Files, name)
+		manifest.Bytes += f.size
+	}
+	sort.Strings(manifest.Files)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := writeFile(tw, "manifest.json", data); err != nil {
+		return err
+	}
+	for _, name := range manifest.Config {
+		if err := writeFile(tw, "config/"+name, config[name]); err != nil {
+			return err
+		}
+	}
+	for _, name := range manifest.Files {
+		f := files[name]
+		if err := tw.WriteHeader(&tar.Header{Name: "data/" + name, Mode: 0o644, Size: f.size, ModTime: f.modTime}); err != nil {
+			return fmt.Errorf("write archive: %w", err)
+		}
+		if _, err := io.CopyN(tw, f, f.size); err != nil {
+			return fmt.Errorf("copy %s: %w", name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("write archive: %w", err)
+	}
+	return nil
+}
+
+// dataFile is an open data file with its size when it was opened.
+type dataFile struct {
+	*os.File
+	size    int64
+	modTime time.Time
+}
+
+// openDataFiles opens the regular files in the data directory, by slash-separated
+// path, without staged imports and partially written files. On error, no file is
+// left open.
+func (a *Archive) openDataFiles() (_ map[string]dataFile, err error) {
+	files := make(map[string]dataFile)
+	defer func() {
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+		}
+	}()
+	err = filepath.WalkDir(a.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(a.dir, p)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if rel == stagingDir || rel == partialDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || strings.HasSuffix(rel, ".tmp") {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return err
+		}
+		files[filepath.ToSlash(rel)] = dataFile{File: f, size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open data directory: %w", err)
+	}
+	return files, nil
+}
+
+// writeFile writes a file with data to tw.
+func writeFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: time.Now()}); err != nil {
+		return fmt.Errorf("write archive: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("write archive: %w", err)
+	}
+	return nil
+}
+
+// ErrInvalidArchive is returned for archives that cannot be imported.
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// Import reads an archive from r and stages its data files to replace those in
+// the data directory on the next start, see ApplyStaged. An import staged before
+// is discarded.
+func (a *Archive) Import(r io.Reader) (Manifest, error) {
+	a.importMu.Lock()
+	defer a.importMu.Unlock()
+
+	partial := filepath.Join(a.dir, partialDir)
+	if err := os.RemoveAll(partial); err != nil {
+		return Manifest{}, fmt.Errorf("clear staging directory: %w", err)
+	}
+	manifest, err := extract(r, partial)
+	if err != nil {
+		os.RemoveAll(partial)
+		return Manifest{}, err
+	}
+
+	staged := filepath.Join(a.dir, stagingDir)
+	if err := os.RemoveAll(staged); err != nil {
+		return Manifest{}, fmt.Errorf("clear staged import: %w", err)
+	}
+	if err := os.Rename(partial, staged); err != nil {
+		return Manifest{}, fmt.Errorf("stage import: %w", err)
+	}
+	return manifest, nil
+}
+
+// extract writes the data files of the archive read from r to dir and checks
+// them against its manifest.
+func extract(r io.Reader, dir string) (Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+	tr := tar.NewReader(gz)
+
+	var manifest *Manifest
+	extracted := make(map[string]bool)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Manifest{}, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+		}
+		if h.Typeflag == tar.TypeDir || strings.HasPrefix(h.Name, "config/") {
+			continue
+		}
+		if h.Typeflag != tar.TypeReg {
+			return Manifest{}, fmt.Errorf("%w: %s is not a regular file", ErrInvalidArchive, h.Name)
+		}
+
+		if h.Name == "manifest.json" {
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return Manifest{}, fmt.Errorf("%w: decode manifest: %w", ErrInvalidArchive, err)
+			}
+			if manifest.Version != version {
+				return Manifest{}, fmt.Errorf("%w: unsupported version %d", ErrInvalidArchive, manifest.Version)
+			}
+			continue
+		}
+
+		name, ok := strings.CutPrefix(h.Name, "data/")
+		if !ok || name != path.Clean(name) || !filepath.IsLocal(filepath.FromSlash(name)) {
+			return Manifest{}, fmt.Errorf("%w: unexpected file %s", ErrInvalidArchive, h.Name)
+		}
+		if err := writeStaged(filepath.Join(dir, filepath.FromSlash(name)), tr); err != nil {
+			return Manifest{}, err
+		}
+		extracted[name] = true
+	}
+
+	// Reading to the end verifies the gzip checksum of a truncated archive
+	if _, err := io.Copy(io.Discard, gz); err != nil {
+		return Manifest{}, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+	if manifest == nil {
+		return Manifest{}, fmt.Errorf("%w: missing manifest", ErrInvalidArchive)
+	}
+	if len(extracted) != len(manifest.Files) {
+		return Manifest{}, fmt.Errorf("%w: %d data files, manifest lists %d", ErrInvalidArchive, len(extracted), len(manifest.Files))
+	}
+	for _, name := range manifest.Files {
+		if !extracted[name] {
+			return Manifest{}, fmt.Errorf("%w: missing %s", ErrInvalidArchive, name)
+		}
+	}
+	return *manifest, nil
+}
+
+// writeStaged writes the content of r to the file at p.
+func writeStaged(p string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("create staging directory: %w", err)
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("stage file: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("stage file: %w", err)
+	}
+	return nil
+}
+
+// ApplyStaged moves the files of an import staged in dir into place, replacing
+// the files of the same name. Files the archive did not contain are kept. It must
+// be called before the state in dir is loaded, and reports whether an import was
+// staged.
+func ApplyStaged(dir string) (bool, error) {
+	staged := filepath.Join(dir, stagingDir)
+	if _, err := os.Stat(staged); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	err := filepath.WalkDir(staged, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(staged, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		return os.Rename(p, target)
+	})
+	if err != nil {
+		return false, fmt.Errorf("apply staged import: %w", err)
+	}
+	if err := os.RemoveAll(staged); err != nil {
+		return false, fmt.Errorf("remove staged import: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,134 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestArchive_ExportImport(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"labels.json":                "{}",
+		"snapshots/2026-01.jsonl":    "{\"validatorIndex\":1}\n",
+		"latest.json.tmp":            "partial",
+		"import/labels.json":         "staged elsewhere",
+		"tenants/acme/exits.json":    "{}",
+		"attestations/2026-01.jsonl": "",
+	})
+	froze := false
+	a := New(src)
+	a.SetFreeze(func(fn func() error) error {
+		froze = true
+		return fn()
+	})
+	a.AddConfig("portfolios.json", func() ([]byte, error) { return []byte(`{"portfolios":[]}`), nil })
+
+	var buf bytes.Buffer
+	if err := a.Export(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !froze {
+		t.Error("expected the export to freeze writes")
+	}
+
+	dst := t.TempDir()
+	writeFiles(t, dst, map[string]string{"labels.json": "old", "reports.json": "kept"})
+	manifest, err := New(dst).Import(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"attestations/2026-01.jsonl", "labels.json", "snapshots/2026-01.jsonl", "tenants/acme/exits.json"}
+	if len(manifest.Files) != len(want) || manifest.Bytes != 25 || len(manifest.Config) != 1 {
+		t.Fatalf("expected files %v of 25 bytes and one config file, got %+v", want, manifest)
+	}
+	for i, name := range want {
+		if manifest.Files[i] != name {
+			t.Errorf("file %d: expected %s, got %s", i, name, manifest.Files[i])
+		}
+	}
+
+	// Nothing changes until the import is applied
+	if data, _ := os.ReadFile(filepath.Join(dst, "labels.json")); string(data) != "old" {
+		t.Errorf("expected labels before applying, got %q", data)
+	}
+	applied, err := ApplyStaged(dst)
+	if err != nil || !applied {
+		t.Fatalf("expected the import to be applied, got %v, %v", applied, err)
+	}
+	for name, content := range map[string]string{
+		"labels.json":             "{}",
+		"snapshots/2026-01.jsonl": "{\"validatorIndex\":1}\n",
+		"tenants/acme/exits.json": "{}",
+		"reports.json":            "kept",
+	} {
+		if data, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(name))); err != nil || string(data) != content {
+			t.Errorf("%s: expected %q, got %q (%v)", name, content, data, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "portfolios.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("expected config files not to be imported")
+	}
+	if applied, err := ApplyStaged(dst); applied || err != nil {
+		t.Errorf("expected nothing staged after applying, got %v, %v", applied, err)
+	}
+}
+
+func TestArchive_ImportInvalid(t *testing.T) {
+	archive := func(files map[string]string) []byte {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		for name, content := range files {
+			tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+			tw.Write([]byte(content))
+		}
+		tw.Close()
+		gz.Close()
+		return buf.Bytes()
+	}
+	manifest := `{"version":1,"files":["labels.json"]}`
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "not gzip", data: []byte("labels")},
+		{name: "no manifest", data: archive(map[string]string{"data/labels.json": "{}"})},
+		{name: "unsupported version", data: archive(map[string]string{"manifest.json": `{"version":2}`})},
+		{name: "missing file", data: archive(map[string]string{"manifest.json": manifest})},
+		{name: "path traversal", data: archive(map[string]string{"manifest.json": manifest, "data/labels.json": "{}", "data/../escape.json": "{}"})},
+		{name: "outside data", data: archive(map[string]string{"manifest.json": manifest, "data/labels.json": "{}", "labels.json": "{}"})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if _, err := New(dir).Import(bytes.NewReader(tt.data)); !errors.Is(err, ErrInvalidArchive) {
+				t.Fatalf("expected an invalid archive, got %v", err)
+			}
+			if applied, _ := ApplyStaged(dir); applied {
+				t.Error("expected nothing staged")
+			}
+			if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape.json")); err == nil {
+				t.Error("expected no file outside the data directory")
+			}
+		})
+	}
+}
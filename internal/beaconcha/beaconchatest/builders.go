@@ -0,0 +1,174 @@
+package beaconchatest
+
+import (
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// OneEthWei is 1 ETH in wei, for building balance fixtures.
+const OneEthWei = "1000000000000000000"
+
+// ValidatorBuilder builds validator fixtures. The zero configuration is an active,
+// online validator with a 32 ETH balance, activated at epoch 0.
+type ValidatorBuilder struct {
+	v models.BeaconchainValidatorData
+}
+
+// Validator starts building a validator with the given index.
+func Validator(index int) *ValidatorBuilder {
+	online := true
+	activation := int64(0)
+	return &ValidatorBuilder{v: models.BeaconchainValidatorData{
+		Validator: models.BeaconchainValidatorInfo{Index: &index},
+		Status:    "active_online",
+		Online:    &online,
+		LifeCycleEpochs: models.BeaconchainLifeCycleEpochs{
+			Activation: &activation,
+		},
+		Balances: models.BeaconchainValidatorBalances{
+			Current:   "32000000000000000000",
+			Effective: "32000000000000000000",
+		},
+	}}
+}
+
+// Status sets the validator status, e.g. "active_offline" or "exited".
+func (b *ValidatorBuilder) Status(status string) *ValidatorBuilder {
+	b.v.Status = status
+	return b
+}
+
+// Offline marks the validator offline.
+func (b *ValidatorBuilder) Offline() *ValidatorBuilder {
+	online := false
+	b.v.Online = &online
+	b.v.Status = "active_offline"
+	return b
+}
+
+// Slashed marks the validator slashed at the given epoch, which becomes its exit epoch.
+func (b *ValidatorBuilder) Slashed(epoch int64) *ValidatorBuilder {
+	b.v.Slashed = true
+	b.v.Status = "slashed"
+	b.v.LifeCycleEpochs.Exit = &epoch
+	return b
+}
+
+// Balances sets the current and effective balances in wei.
+func (b *ValidatorBuilder) Balances(current, effective string) *ValidatorBuilder {
+	b.v.Balances = models.BeaconchainValidatorBalances{Current: current, Effective: effective}
+	return b
+}
+
+// Pending marks the validator as waiting in the entry queue at the given 1-based position.
+func (b *ValidatorBuilder) Pending(position int) *ValidatorBuilder {
+	online := false
+	b.v.Status = "pending_queued"
+	b.v.Online = &online
+	b.v.LifeCycleEpochs.Activation = nil
+	b.v.QueuePosition = &position
+	return b
+}
+
+// Activation sets the activation epoch.
+func (b *ValidatorBuilder) Activation(epoch int64) *ValidatorBuilder {
+	b.v.LifeCycleEpochs.Activation = &epoch
+	return b
+}
+
+// Exit sets the exit epoch.
+func (b *ValidatorBuilder) Exit(epoch int64) *ValidatorBuilder {
+	b.v.LifeCycleEpochs.Exit = &epoch
+	return b
+}
+
+// Withdrawable sets the withdrawable epoch.
+func (b *ValidatorBuilder) Withdrawable(epoch int64) *ValidatorBuilder {
+	b.v.LifeCycleEpochs.Withdrawable = &epoch
+	return b
+}
+
+// Pubkey sets the validator public key.
+func (b *ValidatorBuilder) Pubkey(pubkey string) *ValidatorBuilder {
+	b.v.Validator.PublicKey = pubkey
+	return b
+}
+
+// WithdrawalAddress sets 0x01 withdrawal credentials pointing at address.
+func (b *ValidatorBuilder) WithdrawalAddress(address string) *ValidatorBuilder {
+	b.v.WithdrawalCredentials = models.BeaconchainWithdrawalCreds{
+		Type:    "execution",
+		Prefix:  "0x01",
+		Address: &address,
+	}
+	return b
+}
+
+// Compounding sets 0x02 withdrawal credentials pointing at address.
+func (b *ValidatorBuilder) Compounding(address string) *ValidatorBuilder {
+	b.v.WithdrawalCredentials = models.BeaconchainWithdrawalCreds{
+		Type:    "compounding",
+		Prefix:  "0x02",
+		Address: &address,
+	}
+	return b
+}
+
+// Build returns the validator.
+func (b *ValidatorBuilder) Build() models.BeaconchainValidatorData {
+	return b.v
+}
+
+// Validators builds default validators for each index.
+func Validators(indices ...int) []models.BeaconchainValidatorData {
+	result := make([]models.BeaconchainValidatorData, 0, len(indices))
+	for _, i := range indices {
+		result = append(result, Validator(i).Build())
+	}
+	return result
+}
+
+// Block builds a locally built block proposed by the validator at slot, paying
+// priorityFees wei to the fee recipient.
+func Block(validatorIndex int, slot int64, priorityFees string) models.BeaconchainBlock {
+	return models.BeaconchainBlock{
+		Validator:       models.BeaconchainValidatorInfo{Index: &validatorIndex},
+		Slot:            slot,
+		Epoch:           slot / 32,
+		Status:          "proposed",
+		ExecutionReward: priorityFees,
+		MevReward:       "0",
+	}
+}
+
+// RelayedBlock builds a block proposed by the validator at slot that was delivered
+// by relay, with the builder paying payment wei to the fee recipient.
+func RelayedBlock(validatorIndex int, slot int64, payment, relay string) models.BeaconchainBlock {
+	b := Block(validatorIndex, slot, "0")
+	b.MevReward = payment
+	b.Relay = &relay
+	return b
+}
+
+// Attestation builds an attestation of the validator for slot, included in the
+// next slot, voting for head.
+func Attestation(validatorIndex int, slot int64, head string) models.BeaconchainAttestation {
+	return models.BeaconchainAttestation{
+		Validator:       models.BeaconchainValidatorInfo{Index: &validatorIndex},
+		Epoch:           slot / 32,
+		Slot:            slot,
+		InclusionSlot:   slot + 1,
+		BeaconBlockRoot: head,
+		SourceRoot:      "0x01",
+		TargetRoot:      "0x02",
+	}
+}
+
+// Withdrawal builds a withdrawal of amount wei for the validator at epoch.
+func Withdrawal(validatorIndex int, epoch int64, amount string) models.BeaconchainWithdrawal {
+	return models.BeaconchainWithdrawal{
+		Validator: models.BeaconchainValidatorInfo{Index: &validatorIndex},
+		Epoch:     epoch,
+		Slot:      epoch * 32,
+		Amount:    amount,
+	}
+}
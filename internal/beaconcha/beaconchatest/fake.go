@@ -0,0 +1,531 @@
+// Package beaconchatest provides an in-memory beaconcha.Provider for tests.
+package beaconchatest
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// Method names accepted by the failure injection knobs and Calls.
+const (
+	MethodGetValidators           = "GetValidators"
+	MethodGetValidatorsByAddress  = "GetValidatorsByWithdrawalAddress"
+	MethodGetValidatorsByKey      = "GetValidatorsByPublicKey"
+	MethodGetRewardsAggregate     = "GetRewardsAggregate"
+	MethodGetPerformanceAggregate = "GetPerformanceAggregate"
+	MethodGetWithdrawals          = "GetWithdrawals"
+	MethodGetBlocks               = "GetBlocks"
+	MethodGetAttestations         = "GetAttestations"
+	MethodGetDailyRewards         = "GetDailyRewards"
+	MethodGetBalanceHistory       = "GetBalanceHistory"
+	MethodGetSyncCommittees       = "GetSyncCommittees"
+	MethodGetSlashings            = "GetSlashings"
+	MethodGetQueues               = "GetQueues"
+	MethodGetNetworkPerformance   = "GetNetworkPerformance"
+	MethodGetNetworkSize          = "GetNetworkSize"
+	MethodGetExecutionBlocks      = "GetExecutionBlocks"
+)
+
+// Fake is an in-memory beaconcha.Provider. Data is stored per chain and returned
+// for the requested validators. All methods are safe for concurrent use, so a
+// single Fake can back parallel subtests.
+type Fake struct {
+	mu             sync.Mutex
+	validators     map[string]map[int]models.BeaconchainValidatorData
+	rewards        map[string]models.BeaconchainRewardsAggregateResponse     // keyed by chain/range
+	performance    map[string]models.BeaconchainPerformanceAggregateResponse // keyed by chain/range
+	withdrawals    map[string][]models.BeaconchainWithdrawal
+	blocks         map[string][]models.BeaconchainBlock
+	attestations   map[string][]models.BeaconchainAttestation
+	dailyRewards   map[string][]models.BeaconchainRewardsHistoryEntry
+	balanceHistory map[string]map[int][]models.BeaconchainBalanceHistoryEntry
+	syncCommittees map[string][]models.BeaconchainSyncCommitteeAssignment
+	slashings      map[string][]models.BeaconchainSlashing
+	queues         map[string]models.BeaconchainQueues
+	network        map[string]models.BeaconchainNetworkPerformanceResponse // keyed by chain/range
+	networkSizes   map[string]models.BeaconchainNetworkSize
+	execBlocks     map[string]map[int64]models.BeaconchainExecutionBlock
+
+	errs     map[string]error // returned on every call
+	failNext map[string][]error
+	latency  time.Duration
+	calls    map[string]int
+}
+
+var _ beaconcha.Provider = (*Fake)(nil)
+
+// New creates an empty fake.
+func New() *Fake {
+	return &Fake{
+		validators:     make(map[string]map[int]models.BeaconchainValidatorData),
+		rewards:        make(map[string]models.BeaconchainRewardsAggregateResponse),
+		performance:    make(map[string]models.BeaconchainPerformanceAggregateResponse),
+		withdrawals:    make(map[string][]models.BeaconchainWithdrawal),
+		blocks:         make(map[string][]models.BeaconchainBlock),
+		attestations:   make(map[string][]models.BeaconchainAttestation),
+		dailyRewards:   make(map[string][]models.BeaconchainRewardsHistoryEntry),
+		balanceHistory: make(map[string]map[int][]models.BeaconchainBalanceHistoryEntry),
+		syncCommittees: make(map[string][]models.BeaconchainSyncCommitteeAssignment),
+		slashings:      make(map[string][]models.BeaconchainSlashing),
+		queues:         make(map[string]models.BeaconchainQueues),
+		network:        make(map[string]models.BeaconchainNetworkPerformanceResponse),
+		networkSizes:   make(map[string]models.BeaconchainNetworkSize),
+		execBlocks:     make(map[string]map[int64]models.BeaconchainExecutionBlock),
+		errs:           make(map[string]error),
+		failNext:       make(map[string][]error),
+		calls:          make(map[string]int),
+	}
+}
+
+// AddValidators stores validators on chain, replacing any with the same index.
+// Validators without an index are ignored.
+func (f *Fake) AddValidators(chain string, validators ...models.BeaconchainValidatorData) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.validators[chain] == nil {
+		f.validators[chain] = make(map[int]models.BeaconchainValidatorData)
+	}
+	for _, v := range validators {
+		if v.Validator.Index != nil {
+			f.validators[chain][*v.Validator.Index] = v
+		}
+	}
+}
+
+// RemoveValidators removes validators from chain, e.g. to simulate keys leaving a registry.
+func (f *Fake) RemoveValidators(chain string, indices ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, i := range indices {
+		delete(f.validators[chain], i)
+	}
+}
+
+// SetRewards sets the rewards aggregate returned for chain and evalRange.
+func (f *Fake) SetRewards(chain, evalRange string, rewards models.BeaconchainRewardsAggregateResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rewards[chain+"/"+evalRange] = rewards
+}
+
+// SetPerformance sets the performance aggregate returned for chain and evalRange.
+func (f *Fake) SetPerformance(chain, evalRange string, performance models.BeaconchainPerformanceAggregateResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.performance[chain+"/"+evalRange] = performance
+}
+
+// AddWithdrawals stores withdrawals on chain.
+func (f *Fake) AddWithdrawals(chain string, withdrawals ...models.BeaconchainWithdrawal) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.withdrawals[chain] = append(f.withdrawals[chain], withdrawals...)
+}
+
+// AddBlocks stores block proposals on chain.
+func (f *Fake) AddBlocks(chain string, blocks ...models.BeaconchainBlock) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.blocks[chain] = append(f.blocks[chain], blocks...)
+}
+
+// AddAttestations stores included attestations on chain.
+func (f *Fake) AddAttestations(chain string, attestations ...models.BeaconchainAttestation) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attestations[chain] = append(f.attestations[chain], attestations...)
+}
+
+// SetDailyRewards sets the daily rewards buckets returned for chain.
+func (f *Fake) SetDailyRewards(chain string, entries ...models.BeaconchainRewardsHistoryEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dailyRewards[chain] = entries
+}
+
+// AddBalanceHistory stores epoch balances of a validator on chain.
+func (f *Fake) AddBalanceHistory(chain string, validatorId int, entries ...models.BeaconchainBalanceHistoryEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.balanceHistory[chain] == nil {
+		f.balanceHistory[chain] = make(map[int][]models.BeaconchainBalanceHistoryEntry)
+	}
+	f.balanceHistory[chain][validatorId] = append(f.balanceHistory[chain][validatorId], entries...)
+}
+
+// AddSyncCommittees stores sync committee assignments on chain.
+func (f *Fake) AddSyncCommittees(chain string, assignments ...models.BeaconchainSyncCommitteeAssignment) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.syncCommittees[chain] = append(f.syncCommittees[chain], assignments...)
+}
+
+// AddSlashings stores slashings on chain.
+func (f *Fake) AddSlashings(chain string, slashings ...models.BeaconchainSlashing) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.slashings[chain] = append(f.slashings[chain], slashings...)
+}
+
+// SetQueues sets the network queues returned for chain.
+func (f *Fake) SetQueues(chain string, queues models.BeaconchainQueues) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queues[chain] = queues
+}
+
+// SetNetworkPerformance sets the network performance returned for chain and evalRange.
+func (f *Fake) SetNetworkPerformance(chain, evalRange string, performance models.BeaconchainNetworkPerformanceResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.network[chain+"/"+evalRange] = performance
+}
+
+// SetNetworkSize sets the network size returned for chain.
+func (f *Fake) SetNetworkSize(chain string, size models.BeaconchainNetworkSize) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.networkSizes[chain] = size
+}
+
+// AddExecutionBlocks stores execution layer blocks of the network on chain,
+// replacing any with the same number.
+func (f *Fake) AddExecutionBlocks(chain string, blocks ...models.BeaconchainExecutionBlock) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.execBlocks[chain] == nil {
+		f.execBlocks[chain] = make(map[int64]models.BeaconchainExecutionBlock)
+	}
+	for _, b := range blocks {
+		f.execBlocks[chain][b.BlockNumber] = b
+	}
+}
+
+// SetError makes every call to method fail with err until cleared with a nil err.
+func (f *Fake) SetError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs[method] = err
+}
+
+// FailNext makes the next len(errs) calls to method fail with the given errors in order,
+// e.g. to simulate a transient outage followed by recovery.
+func (f *Fake) FailNext(method string, errs ...error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failNext[method] = append(f.failNext[method], errs...)
+}
+
+// SetLatency delays every call by d, or until the context is done.
+func (f *Fake) SetLatency(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency = d
+}
+
+// Calls returns how many times method was called, including failed calls.
+func (f *Fake) Calls(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+// begin records a call to method and returns the error it should fail with, if any.
+func (f *Fake) begin(ctx context.Context, method string) error {
+	f.mu.Lock()
+	f.calls[method]++
+	latency := f.latency
+	err := f.errs[method]
+	if queued := f.failNext[method]; err == nil && len(queued) > 0 {
+		err = queued[0]
+		f.failNext[method] = queued[1:]
+	}
+	f.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// GetValidators implements beaconcha.Provider. Unknown validators are left out, like the live API does.
+func (f *Fake) GetValidators(ctx context.Context, chain string, validatorIds []int) ([]models.BeaconchainValidatorData, error) {
+	if err := f.begin(ctx, MethodGetValidators); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []models.BeaconchainValidatorData
+	for _, id := range validatorIds {
+		if v, ok := f.validators[chain][id]; ok {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+// GetValidatorsByWithdrawalAddress implements beaconcha.Provider. Validators are
+// matched on their withdrawal address case-insensitively and ordered by index.
+func (f *Fake) GetValidatorsByWithdrawalAddress(ctx context.Context, chain, address string) ([]models.BeaconchainValidatorData, error) {
+	if err := f.begin(ctx, MethodGetValidatorsByAddress); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []models.BeaconchainValidatorData
+	for _, v := range f.validators[chain] {
+		if a := v.WithdrawalCredentials.Address; a != nil && strings.EqualFold(*a, address) {
+			result = append(result, v)
+		}
+	}
+	slices.SortFunc(result, func(a, b models.BeaconchainValidatorData) int { return *a.Validator.Index - *b.Validator.Index })
+	return result, nil
+}
+
+// GetValidatorsByPublicKey implements beaconcha.Provider. Validators are matched
+// on their public key case-insensitively and returned in the order of the keys.
+func (f *Fake) GetValidatorsByPublicKey(ctx context.Context, chain string, publicKeys []string) ([]models.BeaconchainValidatorData, error) {
+	if err := f.begin(ctx, MethodGetValidatorsByKey); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []models.BeaconchainValidatorData
+	for _, key := range publicKeys {
+		for _, v := range f.validators[chain] {
+			if v.Validator.PublicKey != "" && strings.EqualFold(v.Validator.PublicKey, key) {
+				result = append(result, v)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// GetRewardsAggregate implements beaconcha.Provider. It returns an empty aggregate unless one was set.
+func (f *Fake) GetRewardsAggregate(ctx context.Context, chain string, validatorIds []int, evalRange string) (*models.BeaconchainRewardsAggregateResponse, error) {
+	if err := f.begin(ctx, MethodGetRewardsAggregate); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rewards := f.rewards[chain+"/"+evalRange]
+	return &rewards, nil
+}
+
+// GetPerformanceAggregate implements beaconcha.Provider. It returns an empty aggregate unless one was set.
+func (f *Fake) GetPerformanceAggregate(ctx context.Context, chain string, validatorIds []int, evalRange string) (*models.BeaconchainPerformanceAggregateResponse, error) {
+	if err := f.begin(ctx, MethodGetPerformanceAggregate); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	performance := f.performance[chain+"/"+evalRange]
+	return &performance, nil
+}
+
+// GetWithdrawals implements beaconcha.Provider. The evaluation range is ignored.
+func (f *Fake) GetWithdrawals(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainWithdrawal, error) {
+	if err := f.begin(ctx, MethodGetWithdrawals); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []models.BeaconchainWithdrawal
+	for _, w := range f.withdrawals[chain] {
+		if w.Validator.Index != nil && slices.Contains(validatorIds, *w.Validator.Index) {
+			result = append(result, w)
+		}
+	}
+	return result, nil
+}
+
+// GetBlocks implements beaconcha.Provider. The evaluation range is ignored.
+func (f *Fake) GetBlocks(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainBlock, error) {
+	if err := f.begin(ctx, MethodGetBlocks); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []models.BeaconchainBlock
+	for _, b := range f.blocks[chain] {
+		if b.Validator.Index != nil && slices.Contains(validatorIds, *b.Validator.Index) {
+			result = append(result, b)
+		}
+	}
+	return result, nil
+}
+
+// GetAttestations implements beaconcha.Provider.
+func (f *Fake) GetAttestations(ctx context.Context, chain string, validatorIds []int, startEpoch, endEpoch int64) ([]models.BeaconchainAttestation, error) {
+	if err := f.begin(ctx, MethodGetAttestations); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []models.BeaconchainAttestation
+	for _, a := range f.attestations[chain] {
+		if a.Validator.Index != nil && slices.Contains(validatorIds, *a.Validator.Index) && a.Epoch >= startEpoch && a.Epoch <= endEpoch {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+// GetDailyRewards implements beaconcha.Provider. The stored buckets are returned regardless of the validators.
+func (f *Fake) GetDailyRewards(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainRewardsHistoryEntry, error) {
+	if err := f.begin(ctx, MethodGetDailyRewards); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.dailyRewards[chain]), nil
+}
+
+// GetBalanceHistory implements beaconcha.Provider.
+func (f *Fake) GetBalanceHistory(ctx context.Context, chain string, validatorId int, startEpoch, endEpoch int64) ([]models.BeaconchainBalanceHistoryEntry, error) {
+	if err := f.begin(ctx, MethodGetBalanceHistory); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []models.BeaconchainBalanceHistoryEntry
+	for _, e := range f.balanceHistory[chain][validatorId] {
+		if e.Epoch >= startEpoch && e.Epoch <= endEpoch {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// GetSyncCommittees implements beaconcha.Provider.
+func (f *Fake) GetSyncCommittees(ctx context.Context, chain string, validatorIds []int) ([]models.BeaconchainSyncCommitteeAssignment, error) {
+	if err := f.begin(ctx, MethodGetSyncCommittees); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []models.BeaconchainSyncCommitteeAssignment
+	for _, a := range f.syncCommittees[chain] {
+		if a.Validator.Index != nil && slices.Contains(validatorIds, *a.Validator.Index) {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+// GetSlashings implements beaconcha.Provider.
+func (f *Fake) GetSlashings(ctx context.Context, chain string, validatorId int) ([]models.BeaconchainSlashing, error) {
+	if err := f.begin(ctx, MethodGetSlashings); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []models.BeaconchainSlashing
+	for _, s := range f.slashings[chain] {
+		if s.Validator.Index != nil && *s.Validator.Index == validatorId {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+// GetQueues implements beaconcha.Provider. It returns empty queues unless they were set.
+func (f *Fake) GetQueues(ctx context.Context, chain string) (*models.BeaconchainQueues, error) {
+	if err := f.begin(ctx, MethodGetQueues); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queues := f.queues[chain]
+	return &queues, nil
+}
+
+// GetNetworkPerformance implements beaconcha.Provider. It returns an empty
+// performance unless one was set.
+func (f *Fake) GetNetworkPerformance(ctx context.Context, chain, evalRange string) (*models.BeaconchainNetworkPerformanceResponse, error) {
+	if err := f.begin(ctx, MethodGetNetworkPerformance); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	performance := f.network[chain+"/"+evalRange]
+	return &performance, nil
+}
+
+// GetNetworkSize implements beaconcha.Provider. It returns an empty network unless
+// its size was set.
+func (f *Fake) GetNetworkSize(ctx context.Context, chain string) (*models.BeaconchainNetworkSize, error) {
+	if err := f.begin(ctx, MethodGetNetworkSize); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	size := f.networkSizes[chain]
+	return &size, nil
+}
+
+// GetExecutionBlocks implements beaconcha.Provider. Blocks that were not stored
+// are left out.
+func (f *Fake) GetExecutionBlocks(ctx context.Context, chain string, blockNumbers []int64) ([]models.BeaconchainExecutionBlock, error) {
+	if err := f.begin(ctx, MethodGetExecutionBlocks); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []models.BeaconchainExecutionBlock
+	for _, n := range blockNumbers {
+		if b, ok := f.execBlocks[chain][n]; ok {
+			result = append(result, b)
+		}
+	}
+	return result, nil
+}
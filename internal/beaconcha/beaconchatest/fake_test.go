@@ -0,0 +1,57 @@
+package beaconchatest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFake_FailureInjection(t *testing.T) {
+	fake := New()
+	fake.AddValidators("mainnet", Validators(1, 2)...)
+
+	errDown := errors.New("down")
+	fake.FailNext(MethodGetValidators, errDown)
+
+	if _, err := fake.GetValidators(context.Background(), "mainnet", []int{1}); !errors.Is(err, errDown) {
+		t.Fatalf("expected injected error, got %v", err)
+	}
+	got, err := fake.GetValidators(context.Background(), "mainnet", []int{1, 2})
+	if err != nil || len(got) != 2 {
+		t.Fatalf("expected recovery after injected failure, got %d validators, err %v", len(got), err)
+	}
+
+	fake.SetLatency(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := fake.GetValidators(ctx, "mainnet", []int{1}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	if calls := fake.Calls(MethodGetValidators); calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestFake_Concurrent(t *testing.T) {
+	fake := New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			fake.AddValidators("hoodi", Validator(i).Build())
+			if _, err := fake.GetValidators(context.Background(), "hoodi", []int{i}); err != nil {
+				t.Errorf("GetValidators failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if calls := fake.Calls(MethodGetValidators); calls != 20 {
+		t.Errorf("expected 20 calls, got %d", calls)
+	}
+}
@@ -0,0 +1,101 @@
+package beaconcha
+
+import (
+	"context"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+var _ Provider = (*ChainClients)(nil)
+
+// ChainClients dispatches requests to the client of their chain, so chains served
+// by different upstreams or API keys are rate limited independently and a chain
+// hitting its limit does not stall the others.
+type ChainClients struct {
+	fallback *Client
+	clients  map[string]*Client
+}
+
+// NewChainClients creates a provider that uses fallback for chains without a
+// client of their own.
+func NewChainClients(fallback *Client) *ChainClients {
+	return &ChainClients{fallback: fallback, clients: make(map[string]*Client)}
+}
+
+// Set serves the requests of chain with client. It must be called before the
+// provider is used.
+func (c *ChainClients) Set(chain string, client *Client) {
+	c.clients[chain] = client
+}
+
+// client returns the client of chain.
+func (c *ChainClients) client(chain string) *Client {
+	if client, ok := c.clients[chain]; ok {
+		return client
+	}
+	return c.fallback
+}
+
+func (c *ChainClients) GetValidators(ctx context.Context, chain string, validatorIds []int) ([]models.BeaconchainValidatorData, error) {
+	return c.client(chain).GetValidators(ctx, chain, validatorIds)
+}
+
+func (c *ChainClients) GetValidatorsByWithdrawalAddress(ctx context.Context, chain, address string) ([]models.BeaconchainValidatorData, error) {
+	return c.client(chain).GetValidatorsByWithdrawalAddress(ctx, chain, address)
+}
+
+func (c *ChainClients) GetValidatorsByPublicKey(ctx context.Context, chain string, publicKeys []string) ([]models.BeaconchainValidatorData, error) {
+	return c.client(chain).GetValidatorsByPublicKey(ctx, chain, publicKeys)
+}
+
+func (c *ChainClients) GetRewardsAggregate(ctx context.Context, chain string, validatorIds []int, evalRange string) (*models.BeaconchainRewardsAggregateResponse, error) {
+	return c.client(chain).GetRewardsAggregate(ctx, chain, validatorIds, evalRange)
+}
+
+func (c *ChainClients) GetPerformanceAggregate(ctx context.Context, chain string, validatorIds []int, evalRange string) (*models.BeaconchainPerformanceAggregateResponse, error) {
+	return c.client(chain).GetPerformanceAggregate(ctx, chain, validatorIds, evalRange)
+}
+
+func (c *ChainClients) GetWithdrawals(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainWithdrawal, error) {
+	return c.client(chain).GetWithdrawals(ctx, chain, validatorIds, evalRange)
+}
+
+func (c *ChainClients) GetBlocks(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainBlock, error) {
+	return c.client(chain).GetBlocks(ctx, chain, validatorIds, evalRange)
+}
+
+func (c *ChainClients) GetAttestations(ctx context.Context, chain string, validatorIds []int, startEpoch, endEpoch int64) ([]models.BeaconchainAttestation, error) {
+	return c.client(chain).GetAttestations(ctx, chain, validatorIds, startEpoch, endEpoch)
+}
+
+func (c *ChainClients) GetDailyRewards(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainRewardsHistoryEntry, error) {
+	return c.client(chain).GetDailyRewards(ctx, chain, validatorIds, evalRange)
+}
+
+func (c *ChainClients) GetBalanceHistory(ctx context.Context, chain string, validatorId int, startEpoch, endEpoch int64) ([]models.BeaconchainBalanceHistoryEntry, error) {
+	return c.client(chain).GetBalanceHistory(ctx, chain, validatorId, startEpoch, endEpoch)
+}
+
+func (c *ChainClients) GetSyncCommittees(ctx context.Context, chain string, validatorIds []int) ([]models.BeaconchainSyncCommitteeAssignment, error) {
+	return c.client(chain).GetSyncCommittees(ctx, chain, validatorIds)
+}
+
+func (c *ChainClients) GetSlashings(ctx context.Context, chain string, validatorId int) ([]models.BeaconchainSlashing, error) {
+	return c.client(chain).GetSlashings(ctx, chain, validatorId)
+}
+
+func (c *ChainClients) GetQueues(ctx context.Context, chain string) (*models.BeaconchainQueues, error) {
+	return c.client(chain).GetQueues(ctx, chain)
+}
+
+func (c *ChainClients) GetNetworkPerformance(ctx context.Context, chain, evalRange string) (*models.BeaconchainNetworkPerformanceResponse, error) {
+	return c.client(chain).GetNetworkPerformance(ctx, chain, evalRange)
+}
+
+func (c *ChainClients) GetNetworkSize(ctx context.Context, chain string) (*models.BeaconchainNetworkSize, error) {
+	return c.client(chain).GetNetworkSize(ctx, chain)
+}
+
+func (c *ChainClients) GetExecutionBlocks(ctx context.Context, chain string, blockNumbers []int64) ([]models.BeaconchainExecutionBlock, error) {
+	return c.client(chain).GetExecutionBlocks(ctx, chain, blockNumbers)
+}
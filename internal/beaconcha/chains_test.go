@@ -0,0 +1,60 @@
+package beaconcha
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ratelimiter"
+)
+
+func TestChainClients_IndependentRateLimits(t *testing.T) {
+	// Mainnet answers once and then tells the client its quota is spent for a minute
+	mainnetCalls := 0
+	mainnet := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mainnetCalls++
+		w.Header().Set("ratelimit-remaining", "0")
+		w.Header().Set("ratelimit-reset", "60")
+		w.Write([]byte(`{"data":[{"validator":{"index":1}}]}`))
+	}))
+	defer mainnet.Close()
+	hoodiCalls := 0
+	hoodi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hoodiCalls++
+		w.Write([]byte(`{"data":[{"validator":{"index":1}}]}`))
+	}))
+	defer hoodi.Close()
+
+	newClient := func(url string) *Client {
+		return NewClient(url, "", APIVersionV2, ratelimiter.NewGlobalRateLimiter(time.Millisecond), 5*time.Second)
+	}
+	clients := NewChainClients(newClient(mainnet.URL))
+	clients.Set("hoodi", newClient(hoodi.URL))
+
+	ctx := context.Background()
+	if _, err := clients.GetValidators(ctx, "mainnet", []int{1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mainnet waits for its quota to reset
+	short, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err := clients.GetValidators(short, "mainnet", []int{1}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected mainnet to wait for its quota, got %v", err)
+	}
+
+	// Hoodi is served right away
+	start := time.Now()
+	if _, err := clients.GetValidators(ctx, "hoodi", []int{1}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("hoodi request took %s, expected it not to wait for mainnet", elapsed)
+	}
+	if mainnetCalls != 1 || hoodiCalls != 1 {
+		t.Errorf("expected 1 call to each upstream, got %d to mainnet and %d to hoodi", mainnetCalls, hoodiCalls)
+	}
+}
@@ -0,0 +1,162 @@
+package beaconcha
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// chunkIDs splits the unique validator IDs of ids into chunks of at most
+// validatorsPerRequest, the most a v2 request accepts.
+func chunkIDs(ids []int) [][]int {
+	unique := uniqueIDs(ids)
+	var chunks [][]int
+	for start := 0; start < len(unique); start += validatorsPerRequest {
+		chunks = append(chunks, unique[start:min(start+validatorsPerRequest, len(unique))])
+	}
+	return chunks
+}
+
+// fetchChunks calls fetch for each chunk of ids and concatenates the results in
+// chunk order.
+func fetchChunks[T any](ids []int, fetch func(chunk []int) ([]T, error)) ([]T, error) {
+	var all []T
+	for _, chunk := range chunkIDs(ids) {
+		data, err := fetch(chunk)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, data...)
+	}
+	return all, nil
+}
+
+// AddRewards adds the wei amounts of src to those of dst. Every string field of
+// the rewards models but Finality is an amount; amounts left out in both stay
+// empty. Finality is left to the caller.
+func AddRewards(dst *models.BeaconchainRewardsData, src models.BeaconchainRewardsData) {
+	addAmounts(reflect.ValueOf(dst).Elem(), reflect.ValueOf(src))
+}
+
+func addAmounts(dst, src reflect.Value) {
+	for i := 0; i < src.NumField(); i++ {
+		name := src.Type().Field(i).Name
+		d, v := dst.Field(i), src.Field(i)
+		switch v.Kind() {
+		case reflect.String:
+			if name == "Finality" || v.String() == "" {
+				continue
+			}
+			d.SetString(amount.ParseOrZero(d.String()).Add(amount.ParseOrZero(v.String())).String())
+		case reflect.Struct:
+			addAmounts(d, v)
+		case reflect.Pointer:
+			if v.IsNil() {
+				continue
+			}
+			if d.IsNil() {
+				d.Set(reflect.New(v.Type().Elem()))
+			}
+			addAmounts(d.Elem(), v.Elem())
+		}
+	}
+}
+
+// mergeFinality keeps the finality of src in dst if src is not finalized, so a
+// merge is only final when all its parts are.
+func mergeFinality(dst *string, src string) {
+	if src != "" && src != "finalized" {
+		*dst = src
+	}
+}
+
+// mergeRewards adds the rewards aggregate of another chunk of validators to dst.
+func mergeRewards(dst, src *models.BeaconchainRewardsAggregateResponse) {
+	AddRewards(&dst.Data, src.Data)
+	mergeFinality(&dst.Data.Finality, src.Data.Finality)
+}
+
+// mergePerformance adds the performance aggregate of srcValidators validators to
+// dst, the aggregate of dstValidators. Duties add up; BeaconScores and inclusion
+// delays are averaged weighted by the duties they cover.
+func mergePerformance(dst, src *models.BeaconchainPerformanceAggregateResponse, dstValidators, srcValidators int) {
+	d, s := &dst.Data, src.Data
+	da, sa := &d.Duties.Attestation, s.Duties.Attestation
+
+	d.Beaconscore.Total = weightedMean(d.Beaconscore.Total, s.Beaconscore.Total, dstValidators, srcValidators, dstValidators, srcValidators)
+	d.Beaconscore.Attestation = weightedMean(d.Beaconscore.Attestation, s.Beaconscore.Attestation, da.Assigned, sa.Assigned, dstValidators, srcValidators)
+	d.Beaconscore.Proposal = weightedMean(d.Beaconscore.Proposal, s.Beaconscore.Proposal, d.Duties.Proposal.Assigned, s.Duties.Proposal.Assigned, dstValidators, srcValidators)
+	d.Beaconscore.SyncCommittee = weightedMean(d.Beaconscore.SyncCommittee, s.Beaconscore.SyncCommittee, d.Duties.SyncCommittee.Assigned, s.Duties.SyncCommittee.Assigned, dstValidators, srcValidators)
+
+	if included := da.Included + sa.Included; included > 0 {
+		da.AvgInclusionDelay = (da.AvgInclusionDelay*float64(da.Included) + sa.AvgInclusionDelay*float64(sa.Included)) / float64(included)
+		da.AvgInclusionDelayExcludingMissedSlots = (da.AvgInclusionDelayExcludingMissedSlots*float64(da.Included) + sa.AvgInclusionDelayExcludingMissedSlots*float64(sa.Included)) / float64(included)
+	}
+	da.Included += sa.Included
+	da.Assigned += sa.Assigned
+	da.CorrectHead += sa.CorrectHead
+	da.CorrectSource += sa.CorrectSource
+	da.CorrectTarget += sa.CorrectTarget
+	da.ValuableCorrectHead += sa.ValuableCorrectHead
+	da.ValuableCorrectSource += sa.ValuableCorrectSource
+	da.ValuableCorrectTarget += sa.ValuableCorrectTarget
+	da.Missed += sa.Missed
+
+	dp, sp := &d.Duties.Proposal, s.Duties.Proposal
+	dp.Successful += sp.Successful
+	dp.Assigned += sp.Assigned
+	dp.Missed += sp.Missed
+	dp.IncludedSlashings += sp.IncludedSlashings
+
+	ds, ss := &d.Duties.SyncCommittee, s.Duties.SyncCommittee
+	ds.Successful += ss.Successful
+	ds.Assigned += ss.Assigned
+	ds.Missed += ss.Missed
+
+	mergeFinality(&d.Finality, s.Finality)
+}
+
+// weightedMean returns the mean of a and b weighted by wa and wb, or by the
+// fallback weights fa and fb when neither has weight. A missing value is left out.
+func weightedMean(a, b *float64, wa, wb, fa, fb int) *float64 {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	if wa+wb == 0 {
+		wa, wb = fa, fb
+	}
+	if wa+wb == 0 {
+		return a
+	}
+	mean := (*a*float64(wa) + *b*float64(wb)) / float64(wa+wb)
+	return &mean
+}
+
+// mergeDailyRewards adds the daily rewards of another chunk of validators to
+// entries, summing the rewards of the same day. The result is ordered by day.
+func mergeDailyRewards(entries, chunk []models.BeaconchainRewardsHistoryEntry) []models.BeaconchainRewardsHistoryEntry {
+	days := make(map[int64]int, len(entries))
+	for i, e := range entries {
+		days[e.Range.Timestamp.Start] = i
+	}
+	for _, e := range chunk {
+		i, ok := days[e.Range.Timestamp.Start]
+		if !ok {
+			days[e.Range.Timestamp.Start] = len(entries)
+			entries = append(entries, e)
+			continue
+		}
+		AddRewards(&entries[i].Rewards, e.Rewards)
+		mergeFinality(&entries[i].Rewards.Finality, e.Rewards.Finality)
+	}
+	slices.SortStableFunc(entries, func(a, b models.BeaconchainRewardsHistoryEntry) int {
+		return cmp.Compare(a.Range.Timestamp.Start, b.Range.Timestamp.Start)
+	})
+	return entries
+}
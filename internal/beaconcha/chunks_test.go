@@ -0,0 +1,42 @@
+package beaconcha
+
+import (
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestMergePerformance(t *testing.T) {
+	score := func(v float64) *float64 { return &v }
+	aggregate := func(total, attestation float64, assigned, included int, delay float64, finality string) *models.BeaconchainPerformanceAggregateResponse {
+		return &models.BeaconchainPerformanceAggregateResponse{Data: models.BeaconchainPerformanceData{
+			Beaconscore: models.BeaconchainBeaconscore{Total: score(total), Attestation: score(attestation)},
+			Duties: models.BeaconchainPerformanceDuties{
+				Attestation: models.BeaconchainAttestationDuties{Assigned: assigned, Included: included, AvgInclusionDelay: delay},
+				Proposal:    models.BeaconchainProposalDuties{Assigned: 1, Successful: 1},
+			},
+			Finality: finality,
+		}}
+	}
+
+	// 100 validators with 300 attestations and 50 with 100
+	dst := aggregate(0.9, 0.8, 300, 300, 1, "finalized")
+	mergePerformance(dst, aggregate(0.6, 0.4, 100, 50, 2, "not_finalized"), 100, 50)
+
+	d := dst.Data
+	if *d.Beaconscore.Total != 0.8 {
+		t.Errorf("expected the total BeaconScore weighted by validators, got %v", *d.Beaconscore.Total)
+	}
+	if *d.Beaconscore.Attestation != 0.7 {
+		t.Errorf("expected the attestation BeaconScore weighted by duties, got %v", *d.Beaconscore.Attestation)
+	}
+	if a := d.Duties.Attestation; a.Assigned != 400 || a.Included != 350 || a.AvgInclusionDelay != 8.0/7 {
+		t.Errorf("unexpected attestation duties %+v", a)
+	}
+	if d.Duties.Proposal.Assigned != 2 || d.Beaconscore.Proposal != nil {
+		t.Errorf("unexpected proposals %+v, %v", d.Duties.Proposal, d.Beaconscore.Proposal)
+	}
+	if d.Finality != "not_finalized" {
+		t.Errorf("expected the merge not to be finalized, got %q", d.Finality)
+	}
+}
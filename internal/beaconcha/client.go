@@ -1,4 +1,5 @@
-// Package beaconcha provides a client for the Beaconcha v2 API.
+// Package beaconcha provides a client for the Beaconcha v2 API, falling back to the
+// v1 API for validator overviews on networks where v2 is unavailable.
 package beaconcha
 
 import (
@@ -12,23 +13,54 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/budget"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
 	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
 	"github.com/Marketen/validator-dashboard-beaconcha/internal/ratelimiter"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/tracing"
 )
 
+// Provider is the Beaconcha data the service depends on. Client implements it
+// against the live API; beaconchatest provides an in-memory fake for tests.
+type Provider interface {
+	GetValidators(ctx context.Context, chain string, validatorIds []int) ([]models.BeaconchainValidatorData, error)
+	GetValidatorsByWithdrawalAddress(ctx context.Context, chain, address string) ([]models.BeaconchainValidatorData, error)
+	GetValidatorsByPublicKey(ctx context.Context, chain string, publicKeys []string) ([]models.BeaconchainValidatorData, error)
+	GetRewardsAggregate(ctx context.Context, chain string, validatorIds []int, evalRange string) (*models.BeaconchainRewardsAggregateResponse, error)
+	GetPerformanceAggregate(ctx context.Context, chain string, validatorIds []int, evalRange string) (*models.BeaconchainPerformanceAggregateResponse, error)
+	GetWithdrawals(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainWithdrawal, error)
+	GetBlocks(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainBlock, error)
+	GetAttestations(ctx context.Context, chain string, validatorIds []int, startEpoch, endEpoch int64) ([]models.BeaconchainAttestation, error)
+	GetDailyRewards(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainRewardsHistoryEntry, error)
+	GetBalanceHistory(ctx context.Context, chain string, validatorId int, startEpoch, endEpoch int64) ([]models.BeaconchainBalanceHistoryEntry, error)
+	GetSyncCommittees(ctx context.Context, chain string, validatorIds []int) ([]models.BeaconchainSyncCommitteeAssignment, error)
+	GetSlashings(ctx context.Context, chain string, validatorId int) ([]models.BeaconchainSlashing, error)
+	GetQueues(ctx context.Context, chain string) (*models.BeaconchainQueues, error)
+	GetNetworkPerformance(ctx context.Context, chain, evalRange string) (*models.BeaconchainNetworkPerformanceResponse, error)
+	GetNetworkSize(ctx context.Context, chain string) (*models.BeaconchainNetworkSize, error)
+	GetExecutionBlocks(ctx context.Context, chain string, blockNumbers []int64) ([]models.BeaconchainExecutionBlock, error)
+}
+
+var _ Provider = (*Client)(nil)
+
 // Client is the Beaconcha API client with built-in rate limiting.
 type Client struct {
 	baseURL     string
 	apiKey      string
+	apiVersion  string
 	httpClient  *http.Client
 	rateLimiter *ratelimiter.GlobalRateLimiter
+	budget      *budget.Manager // Optional, limits the credits spent per day
 }
 
-// NewClient creates a new Beaconcha API client.
-func NewClient(baseURL, apiKey string, rateLimiter *ratelimiter.GlobalRateLimiter, timeout time.Duration) *Client {
+// NewClient creates a new Beaconcha API client. apiVersion is APIVersionV2, which
+// falls back to v1 for validator overviews when v2 fails, or APIVersionV1, which
+// always fetches them from v1. Other data is only available from v2.
+func NewClient(baseURL, apiKey, apiVersion string, rateLimiter *ratelimiter.GlobalRateLimiter, timeout time.Duration) *Client {
 	return &Client{
-		baseURL: strings.TrimSuffix(baseURL, "/"),
-		apiKey:  apiKey,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		apiVersion: apiVersion,
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
@@ -36,172 +68,574 @@ func NewClient(baseURL, apiKey string, rateLimiter *ratelimiter.GlobalRateLimite
 	}
 }
 
-// GetValidators fetches validator overview data for the given indices.
-// Uses POST /api/v2/ethereum/validators with cursor-based pagination.
+// SetTransport replaces the transport used for upstream requests, e.g. with a
+// ReplayTransport. It must be called before the client is used.
+func (c *Client) SetTransport(t http.RoundTripper) {
+	c.httpClient.Transport = t
+}
+
+// validatorsPerRequest is the maximum number of validator identifiers per v2 request,
+// and the page size requested from the validators endpoint.
+const validatorsPerRequest = 100
+
+// SetBudget charges every upstream request against b, refusing requests with
+// budget.ErrExhausted once the daily credits are spent.
+func (c *Client) SetBudget(b *budget.Manager) {
+	c.budget = b
+}
+
+// GetValidators fetches validator overview data for the given indices. Duplicate
+// indices are requested once, in chunks of validatorsPerRequest.
+// Uses POST /api/v2/ethereum/validators with cursor-based pagination, or
+// GET /api/v1/validator/{indices}.
 func (c *Client) GetValidators(ctx context.Context, chain string, validatorIds []int) ([]models.BeaconchainValidatorData, error) {
 	if len(validatorIds) == 0 {
 		return nil, nil
 	}
+	ids := uniqueIDs(validatorIds)
+
+	data, err := c.withFallback(ctx, chain, func() ([]models.BeaconchainValidatorData, error) {
+		var allData []models.BeaconchainValidatorData
+		for start := 0; start < len(ids); start += validatorsPerRequest {
+			chunk := ids[start:min(start+validatorsPerRequest, len(ids))]
+			data, err := c.getValidators(ctx, chain, models.BeaconchainValidatorSelector{ValidatorIdentifiers: chunk})
+			if err != nil {
+				return nil, err
+			}
+			allData = append(allData, data...)
+		}
+		return allData, nil
+	}, func() ([]models.BeaconchainValidatorData, error) {
+		return c.getValidatorsV1(ctx, chain, ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data = uniqueValidators(data)
+	if len(data) < len(ids) {
+		slog.Warn("beaconcha returned fewer validators than requested",
+			"chain", chain,
+			"requested", len(ids),
+			"returned", len(data),
+		)
+	}
+	return data, nil
+}
 
+// GetValidatorsByWithdrawalAddress fetches all validators whose withdrawal credentials
+// point at address, e.g. the contract of a staking operator.
+// Uses POST /api/v2/ethereum/validators with cursor-based pagination, or
+// GET /api/v1/validator/withdrawalCredentials/{address}.
+func (c *Client) GetValidatorsByWithdrawalAddress(ctx context.Context, chain, address string) ([]models.BeaconchainValidatorData, error) {
+	data, err := c.withFallback(ctx, chain, func() ([]models.BeaconchainValidatorData, error) {
+		return c.getValidators(ctx, chain, models.BeaconchainValidatorSelector{WithdrawalAddress: address})
+	}, func() ([]models.BeaconchainValidatorData, error) {
+		return c.getValidatorsByWithdrawalAddressV1(ctx, chain, address)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return uniqueValidators(data), nil
+}
+
+// GetValidatorsByPublicKey fetches validator overview data for the given 0x-prefixed
+// public keys, in chunks of validatorsPerRequest. Keys of deposits the beacon chain
+// has not processed yet are left out.
+// Uses POST /api/v2/ethereum/validators with cursor-based pagination, or
+// GET /api/v1/validator/{pubkeys}.
+func (c *Client) GetValidatorsByPublicKey(ctx context.Context, chain string, publicKeys []string) ([]models.BeaconchainValidatorData, error) {
+	if len(publicKeys) == 0 {
+		return nil, nil
+	}
+
+	data, err := c.withFallback(ctx, chain, func() ([]models.BeaconchainValidatorData, error) {
+		var allData []models.BeaconchainValidatorData
+		for start := 0; start < len(publicKeys); start += validatorsPerRequest {
+			chunk := publicKeys[start:min(start+validatorsPerRequest, len(publicKeys))]
+			data, err := c.getValidators(ctx, chain, models.BeaconchainValidatorSelector{PublicKeys: chunk})
+			if err != nil {
+				return nil, err
+			}
+			allData = append(allData, data...)
+		}
+		return allData, nil
+	}, func() ([]models.BeaconchainValidatorData, error) {
+		return c.getValidatorsV1ByIdentifier(ctx, chain, publicKeys)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return uniqueValidators(data), nil
+}
+
+// getValidators fetches all pages of the validators matching selector.
+func (c *Client) getValidators(ctx context.Context, chain string, selector models.BeaconchainValidatorSelector) ([]models.BeaconchainValidatorData, error) {
 	var allData []models.BeaconchainValidatorData
 	cursor := ""
+	var pages pager
 
 	for {
 		reqBody := models.BeaconchainValidatorsRequest{
+			Chain:     chain,
+			Validator: selector,
+			PageSize:  validatorsPerRequest, // Upstream may serve less; the cursor covers the rest
+			Cursor:    cursor,
+		}
+
+		var response models.BeaconchainValidatorsResponse
+		if err := c.post(ctx, "/api/v2/ethereum/validators", reqBody, &response); err != nil {
+			return nil, fmt.Errorf("fetch validators: %w", err)
+		}
+
+		allData = append(allData, response.Data...)
+
+		next, err := pages.next(response.Paging)
+		if err != nil {
+			return nil, fmt.Errorf("fetch validators: %w", err)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	return allData, nil
+}
+
+// GetRewardsAggregate fetches aggregated rewards for validators, summed over
+// chunks of validatorsPerRequest.
+// Uses POST /api/v2/ethereum/validators/rewards-aggregate
+func (c *Client) GetRewardsAggregate(ctx context.Context, chain string, validatorIds []int, evalRange string) (*models.BeaconchainRewardsAggregateResponse, error) {
+	var result *models.BeaconchainRewardsAggregateResponse
+	for _, chunk := range chunkIDs(validatorIds) {
+		reqBody := models.BeaconchainRewardsAggregateRequest{
+			Chain: chain,
+			Validator: models.BeaconchainValidatorSelector{
+				ValidatorIdentifiers: chunk,
+			},
+			Range: models.BeaconchainTimeRangeSelector{
+				EvaluationWindow: evalRange,
+			},
+		}
+
+		var response models.BeaconchainRewardsAggregateResponse
+		if err := c.post(ctx, "/api/v2/ethereum/validators/rewards-aggregate", reqBody, &response); err != nil {
+			return nil, fmt.Errorf("fetch rewards: %w", err)
+		}
+		if result == nil {
+			result = &response
+			continue
+		}
+		mergeRewards(result, &response)
+	}
+
+	return result, nil
+}
+
+// GetPerformanceAggregate fetches aggregated performance metrics for validators,
+// merged over chunks of validatorsPerRequest.
+// Uses POST /api/v2/ethereum/validators/performance-aggregate
+func (c *Client) GetPerformanceAggregate(ctx context.Context, chain string, validatorIds []int, evalRange string) (*models.BeaconchainPerformanceAggregateResponse, error) {
+	var result *models.BeaconchainPerformanceAggregateResponse
+	merged := 0 // Validators in result
+	for _, chunk := range chunkIDs(validatorIds) {
+		reqBody := models.BeaconchainPerformanceAggregateRequest{
+			Chain: chain,
+			Validator: models.BeaconchainValidatorSelector{
+				ValidatorIdentifiers: chunk,
+			},
+			Range: models.BeaconchainTimeRangeSelector{
+				EvaluationWindow: evalRange,
+			},
+		}
+
+		var response models.BeaconchainPerformanceAggregateResponse
+		if err := c.post(ctx, "/api/v2/ethereum/validators/performance-aggregate", reqBody, &response); err != nil {
+			return nil, fmt.Errorf("fetch performance: %w", err)
+		}
+		if result == nil {
+			result = &response
+		} else {
+			mergePerformance(result, &response, merged, len(chunk))
+		}
+		merged += len(chunk)
+	}
+
+	return result, nil
+}
+
+// GetWithdrawals fetches all withdrawals processed for the given validators within the evaluation window.
+// Chunks of validatorsPerRequest are requested in turn.
+// Uses POST /api/v2/ethereum/validators/withdrawals with cursor-based pagination.
+func (c *Client) GetWithdrawals(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainWithdrawal, error) {
+	return fetchChunks(validatorIds, func(chunk []int) ([]models.BeaconchainWithdrawal, error) {
+		return c.getWithdrawals(ctx, chain, chunk, evalRange)
+	})
+}
+
+// getWithdrawals fetches the withdrawals of at most validatorsPerRequest validators.
+func (c *Client) getWithdrawals(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainWithdrawal, error) {
+	var allData []models.BeaconchainWithdrawal
+	cursor := ""
+	var pages pager
+
+	for {
+		reqBody := models.BeaconchainWithdrawalsRequest{
 			Chain: chain,
 			Validator: models.BeaconchainValidatorSelector{
 				ValidatorIdentifiers: validatorIds,
 			},
-			PageSize: 10, // Max allowed by Beaconcha API
+			Range: models.BeaconchainTimeRangeSelector{
+				EvaluationWindow: evalRange,
+			},
+			PageSize: 100,
 			Cursor:   cursor,
 		}
 
-		bodyBytes, err := json.Marshal(reqBody)
-		if err != nil {
-			return nil, fmt.Errorf("marshal request: %w", err)
+		var response models.BeaconchainWithdrawalsResponse
+		if err := c.post(ctx, "/api/v2/ethereum/validators/withdrawals", reqBody, &response); err != nil {
+			return nil, fmt.Errorf("fetch withdrawals: %w", err)
 		}
 
-		url := fmt.Sprintf("%s/api/v2/ethereum/validators", c.baseURL)
+		allData = append(allData, response.Data...)
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
+		next, err := pages.next(response.Paging)
 		if err != nil {
-			return nil, fmt.Errorf("create request: %w", err)
+			return nil, fmt.Errorf("fetch withdrawals: %w", err)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	return allData, nil
+}
+
+// GetBlocks fetches the block proposals of the given validators within the evaluation window.
+// Chunks of validatorsPerRequest are requested in turn.
+// Uses POST /api/v2/ethereum/validators/blocks with cursor-based pagination.
+func (c *Client) GetBlocks(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainBlock, error) {
+	return fetchChunks(validatorIds, func(chunk []int) ([]models.BeaconchainBlock, error) {
+		return c.getBlocks(ctx, chain, chunk, evalRange)
+	})
+}
+
+// getBlocks fetches the block proposals of at most validatorsPerRequest validators.
+func (c *Client) getBlocks(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainBlock, error) {
+	var allData []models.BeaconchainBlock
+	cursor := ""
+	var pages pager
+
+	for {
+		reqBody := models.BeaconchainBlocksRequest{
+			Chain: chain,
+			Validator: models.BeaconchainValidatorSelector{
+				ValidatorIdentifiers: validatorIds,
+			},
+			Range: models.BeaconchainTimeRangeSelector{
+				EvaluationWindow: evalRange,
+			},
+			PageSize: 100,
+			Cursor:   cursor,
 		}
 
-		c.addHeaders(req)
-		req.Header.Set("Content-Type", "application/json")
+		var response models.BeaconchainBlocksResponse
+		if err := c.post(ctx, "/api/v2/ethereum/validators/blocks", reqBody, &response); err != nil {
+			return nil, fmt.Errorf("fetch blocks: %w", err)
+		}
 
-		slog.Debug("beaconcha request", "method", "POST", "endpoint", "validators", "cursor", cursor)
+		allData = append(allData, response.Data...)
 
-		resp, body, err := c.doRequestWithRetry(ctx, req, bodyBytes, 3)
+		next, err := pages.next(response.Paging)
 		if err != nil {
-			return nil, fmt.Errorf("fetch validators: %w", err)
+			return nil, fmt.Errorf("fetch blocks: %w", err)
 		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	return allData, nil
+}
+
+// GetAttestations fetches the included attestations of the given validators from
+// startEpoch to endEpoch inclusive.
+// Chunks of validatorsPerRequest are requested in turn.
+// Uses POST /api/v2/ethereum/validators/attestations with cursor-based pagination.
+func (c *Client) GetAttestations(ctx context.Context, chain string, validatorIds []int, startEpoch, endEpoch int64) ([]models.BeaconchainAttestation, error) {
+	return fetchChunks(validatorIds, func(chunk []int) ([]models.BeaconchainAttestation, error) {
+		return c.getAttestations(ctx, chain, chunk, startEpoch, endEpoch)
+	})
+}
 
-		if resp.StatusCode != http.StatusOK {
-			slog.Error("beaconcha error response", "status", resp.StatusCode, "body", string(body))
-			return nil, fmt.Errorf("beaconcha returned status %d: %s", resp.StatusCode, string(body))
+// getAttestations fetches the included attestations of at most validatorsPerRequest validators.
+func (c *Client) getAttestations(ctx context.Context, chain string, validatorIds []int, startEpoch, endEpoch int64) ([]models.BeaconchainAttestation, error) {
+	var allData []models.BeaconchainAttestation
+	cursor := ""
+	var pages pager
+
+	for {
+		reqBody := models.BeaconchainAttestationsRequest{
+			Chain: chain,
+			Validator: models.BeaconchainValidatorSelector{
+				ValidatorIdentifiers: validatorIds,
+			},
+			Range: models.BeaconchainEpochRangeSelector{
+				Epoch: models.BeaconchainEpochRange{Start: startEpoch, End: endEpoch},
+			},
+			PageSize: 100,
+			Cursor:   cursor,
 		}
 
-		var response models.BeaconchainValidatorsResponse
-		if err := json.Unmarshal(body, &response); err != nil {
-			return nil, fmt.Errorf("decode response: %w", err)
+		var response models.BeaconchainAttestationsResponse
+		if err := c.post(ctx, "/api/v2/ethereum/validators/attestations", reqBody, &response); err != nil {
+			return nil, fmt.Errorf("fetch attestations: %w", err)
 		}
 
 		allData = append(allData, response.Data...)
 
-		// Check if there are more pages
-		if response.Paging == nil || response.Paging.NextCursor == "" {
+		next, err := pages.next(response.Paging)
+		if err != nil {
+			return nil, fmt.Errorf("fetch attestations: %w", err)
+		}
+		if next == "" {
 			break
 		}
-		cursor = response.Paging.NextCursor
+		cursor = next
 	}
 
 	return allData, nil
 }
 
-// GetRewardsAggregate fetches aggregated rewards for validators.
-// Uses POST /api/v2/ethereum/validators/rewards-aggregate
-func (c *Client) GetRewardsAggregate(ctx context.Context, chain string, validatorIds []int, evalRange string) (*models.BeaconchainRewardsAggregateResponse, error) {
-	if len(validatorIds) == 0 {
-		return nil, nil
+// GetDailyRewards fetches rewards for the given validators bucketed per UTC day within the evaluation window.
+// Chunks of validatorsPerRequest are requested in turn and their days summed.
+// Uses POST /api/v2/ethereum/validators/rewards-history with cursor-based pagination.
+func (c *Client) GetDailyRewards(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainRewardsHistoryEntry, error) {
+	var result []models.BeaconchainRewardsHistoryEntry
+	for _, chunk := range chunkIDs(validatorIds) {
+		entries, err := c.getDailyRewards(ctx, chain, chunk, evalRange)
+		if err != nil {
+			return nil, err
+		}
+		result = mergeDailyRewards(result, entries)
 	}
+	return result, nil
+}
 
-	reqBody := models.BeaconchainRewardsAggregateRequest{
-		Chain: chain,
-		Validator: models.BeaconchainValidatorSelector{
-			ValidatorIdentifiers: validatorIds,
-		},
-		Range: models.BeaconchainTimeRangeSelector{
-			EvaluationWindow: evalRange,
-		},
-	}
+// getDailyRewards fetches the daily rewards of at most validatorsPerRequest validators.
+func (c *Client) getDailyRewards(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainRewardsHistoryEntry, error) {
+	var allData []models.BeaconchainRewardsHistoryEntry
+	cursor := ""
+	var pages pager
 
-	bodyBytes, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
+	for {
+		reqBody := models.BeaconchainRewardsHistoryRequest{
+			Chain: chain,
+			Validator: models.BeaconchainValidatorSelector{
+				ValidatorIdentifiers: validatorIds,
+			},
+			Range: models.BeaconchainTimeRangeSelector{
+				EvaluationWindow: evalRange,
+			},
+			Aggregation: "day",
+			PageSize:    100,
+			Cursor:      cursor,
+		}
+
+		var response models.BeaconchainRewardsHistoryResponse
+		if err := c.post(ctx, "/api/v2/ethereum/validators/rewards-history", reqBody, &response); err != nil {
+			return nil, fmt.Errorf("fetch rewards history: %w", err)
+		}
+
+		allData = append(allData, response.Data...)
+
+		next, err := pages.next(response.Paging)
+		if err != nil {
+			return nil, fmt.Errorf("fetch rewards history: %w", err)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
 	}
 
-	url := fmt.Sprintf("%s/api/v2/ethereum/validators/rewards-aggregate", c.baseURL)
+	return allData, nil
+}
+
+// GetBalanceHistory fetches the balance of a validator at the end of each epoch from startEpoch to endEpoch inclusive.
+// Uses POST /api/v2/ethereum/validators/balance-history with cursor-based pagination.
+func (c *Client) GetBalanceHistory(ctx context.Context, chain string, validatorId int, startEpoch, endEpoch int64) ([]models.BeaconchainBalanceHistoryEntry, error) {
+	var allData []models.BeaconchainBalanceHistoryEntry
+	cursor := ""
+	var pages pager
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+	for {
+		reqBody := models.BeaconchainBalanceHistoryRequest{
+			Chain: chain,
+			Validator: models.BeaconchainValidatorSelector{
+				ValidatorIdentifiers: []int{validatorId},
+			},
+			Range: models.BeaconchainEpochRangeSelector{
+				Epoch: models.BeaconchainEpochRange{Start: startEpoch, End: endEpoch},
+			},
+			PageSize: 100,
+			Cursor:   cursor,
+		}
+
+		var response models.BeaconchainBalanceHistoryResponse
+		if err := c.post(ctx, "/api/v2/ethereum/validators/balance-history", reqBody, &response); err != nil {
+			return nil, fmt.Errorf("fetch balance history: %w", err)
+		}
+
+		allData = append(allData, response.Data...)
+
+		next, err := pages.next(response.Paging)
+		if err != nil {
+			return nil, fmt.Errorf("fetch balance history: %w", err)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
 	}
 
-	c.addHeaders(req)
-	req.Header.Set("Content-Type", "application/json")
+	return allData, nil
+}
 
-	slog.Debug("beaconcha request", "method", "POST", "endpoint", "rewards-aggregate")
+// GetSyncCommittees fetches the past and current sync committee assignments of the given validators.
+// Chunks of validatorsPerRequest are requested in turn.
+// Uses POST /api/v2/ethereum/validators/sync-committees with cursor-based pagination.
+func (c *Client) GetSyncCommittees(ctx context.Context, chain string, validatorIds []int) ([]models.BeaconchainSyncCommitteeAssignment, error) {
+	return fetchChunks(validatorIds, func(chunk []int) ([]models.BeaconchainSyncCommitteeAssignment, error) {
+		return c.getSyncCommittees(ctx, chain, chunk)
+	})
+}
 
-	resp, body, err := c.doRequestWithRetry(ctx, req, bodyBytes, 3)
-	if err != nil {
-		return nil, fmt.Errorf("fetch rewards: %w", err)
+// getSyncCommittees fetches the sync committee assignments of at most validatorsPerRequest validators.
+func (c *Client) getSyncCommittees(ctx context.Context, chain string, validatorIds []int) ([]models.BeaconchainSyncCommitteeAssignment, error) {
+	var allData []models.BeaconchainSyncCommitteeAssignment
+	cursor := ""
+	var pages pager
+
+	for {
+		reqBody := models.BeaconchainSyncCommitteesRequest{
+			Chain: chain,
+			Validator: models.BeaconchainValidatorSelector{
+				ValidatorIdentifiers: validatorIds,
+			},
+			PageSize: 100,
+			Cursor:   cursor,
+		}
+
+		var response models.BeaconchainSyncCommitteesResponse
+		if err := c.post(ctx, "/api/v2/ethereum/validators/sync-committees", reqBody, &response); err != nil {
+			return nil, fmt.Errorf("fetch sync committees: %w", err)
+		}
+
+		allData = append(allData, response.Data...)
+
+		next, err := pages.next(response.Paging)
+		if err != nil {
+			return nil, fmt.Errorf("fetch sync committees: %w", err)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		slog.Error("beaconcha error response", "status", resp.StatusCode, "body", string(body))
-		return nil, fmt.Errorf("beaconcha returned status %d: %s", resp.StatusCode, string(body))
+	return allData, nil
+}
+
+// GetSlashings fetches the slashings in which the given validator was slashed.
+// Uses POST /api/v2/ethereum/validators/slashings.
+func (c *Client) GetSlashings(ctx context.Context, chain string, validatorId int) ([]models.BeaconchainSlashing, error) {
+	reqBody := models.BeaconchainSlashingsRequest{
+		Chain: chain,
+		Validator: models.BeaconchainValidatorSelector{
+			ValidatorIdentifiers: []int{validatorId},
+		},
 	}
 
-	var response models.BeaconchainRewardsAggregateResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	var response models.BeaconchainSlashingsResponse
+	if err := c.post(ctx, "/api/v2/ethereum/validators/slashings", reqBody, &response); err != nil {
+		return nil, fmt.Errorf("fetch slashings: %w", err)
 	}
 
-	return &response, nil
+	return response.Data, nil
 }
 
-// GetPerformanceAggregate fetches aggregated performance metrics for validators.
-// Uses POST /api/v2/ethereum/validators/performance-aggregate
-func (c *Client) GetPerformanceAggregate(ctx context.Context, chain string, validatorIds []int, evalRange string) (*models.BeaconchainPerformanceAggregateResponse, error) {
-	if len(validatorIds) == 0 {
-		return nil, nil
+// GetQueues fetches the current validator entry and exit queues of the network.
+// Uses POST /api/v2/ethereum/network/queues.
+func (c *Client) GetQueues(ctx context.Context, chain string) (*models.BeaconchainQueues, error) {
+	reqBody := models.BeaconchainQueuesRequest{Chain: chain}
+
+	var response models.BeaconchainQueuesResponse
+	if err := c.post(ctx, "/api/v2/ethereum/network/queues", reqBody, &response); err != nil {
+		return nil, fmt.Errorf("fetch queues: %w", err)
 	}
 
-	reqBody := models.BeaconchainPerformanceAggregateRequest{
+	return &response.Data, nil
+}
+
+// GetNetworkPerformance fetches the performance of the whole network over evalRange.
+// Uses POST /api/v2/ethereum/network/performance-aggregate.
+func (c *Client) GetNetworkPerformance(ctx context.Context, chain, evalRange string) (*models.BeaconchainNetworkPerformanceResponse, error) {
+	reqBody := models.BeaconchainNetworkPerformanceRequest{
 		Chain: chain,
-		Validator: models.BeaconchainValidatorSelector{
-			ValidatorIdentifiers: validatorIds,
-		},
-		Range: models.BeaconchainTimeRangeSelector{
-			EvaluationWindow: evalRange,
-		},
+		Range: models.BeaconchainTimeRangeSelector{EvaluationWindow: evalRange},
 	}
 
+	var response models.BeaconchainNetworkPerformanceResponse
+	if err := c.post(ctx, "/api/v2/ethereum/network/performance-aggregate", reqBody, &response); err != nil {
+		return nil, fmt.Errorf("fetch network performance: %w", err)
+	}
+
+	return &response, nil
+}
+
+// post sends reqBody as JSON to the Beaconcha endpoint at path and decodes the response into out.
+// Non-200 responses are returned as errors.
+func (c *Client) post(ctx context.Context, path string, reqBody, out any) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "beaconcha POST "+path)
+	defer func() { span.End(err != nil) }()
+
 	bodyBytes, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
+		return fmt.Errorf("marshal request: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/api/v2/ethereum/validators/performance-aggregate", c.baseURL)
+	url := c.baseURL + path
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return fmt.Errorf("create request: %w", err)
 	}
 
 	c.addHeaders(req)
 	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
 
-	slog.Debug("beaconcha request", "method", "POST", "endpoint", "performance-aggregate")
+	slog.Debug("beaconcha request", "method", "POST", "endpoint", path)
 
 	resp, body, err := c.doRequestWithRetry(ctx, req, bodyBytes, 3)
 	if err != nil {
-		return nil, fmt.Errorf("fetch performance: %w", err)
+		return err
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		slog.Error("beaconcha error response", "status", resp.StatusCode, "body", string(body))
-		return nil, fmt.Errorf("beaconcha returned status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("beaconcha returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var response models.BeaconchainPerformanceAggregateResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
 	}
 
-	return &response, nil
+	return nil
 }
 
 // addHeaders adds required headers to the request.
@@ -229,6 +663,10 @@ func (c *Client) doRequestWithRetry(ctx context.Context, req *http.Request, body
 			reqClone.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 		}
 
+		if err := c.budget.Charge(endpointName(req.URL.Path)); err != nil {
+			return nil, nil, err
+		}
+		cost.AddUpstreamCall(ctx)
 		resp, err := c.httpClient.Do(reqClone)
 		if err != nil {
 			lastErr = fmt.Errorf("http request: %w", err)
@@ -277,3 +715,90 @@ func (c *Client) doRequestWithRetry(ctx context.Context, req *http.Request, body
 
 	return nil, nil, fmt.Errorf("max retries exceeded: %w", lastErr)
 }
+
+// uniqueIDs returns ids without duplicates, in order of first occurrence.
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// uniqueValidators drops validators already seen by index, which pages can
+// repeat when the validator set changes while paginating.
+func uniqueValidators(data []models.BeaconchainValidatorData) []models.BeaconchainValidatorData {
+	seen := make(map[int]bool, len(data))
+	unique := data[:0]
+	for _, v := range data {
+		if v.Validator.Index != nil {
+			if seen[*v.Validator.Index] {
+				continue
+			}
+			seen[*v.Validator.Index] = true
+		}
+		unique = append(unique, v)
+	}
+	return unique
+}
+
+// maxPages bounds the pages fetched for one paginated request, so that an upstream
+// that keeps returning cursors cannot keep a request looping forever.
+const maxPages = 1000
+
+// pager tracks the pages of one paginated request.
+type pager struct {
+	pages int
+	seen  map[string]bool
+}
+
+// next returns the cursor of the page after one with the given paging, or "" if
+// it was the last. It fails if upstream repeats a cursor or maxPages is reached.
+func (p *pager) next(paging *models.BeaconchainPaging) (string, error) {
+	p.pages++
+	if paging == nil || paging.NextCursor == "" {
+		return "", nil
+	}
+	if p.pages >= maxPages {
+		return "", fmt.Errorf("more than %d pages", maxPages)
+	}
+	if p.seen == nil {
+		p.seen = make(map[string]bool)
+	}
+	if p.seen[paging.NextCursor] {
+		return "", fmt.Errorf("cursor %q repeated after %d pages", paging.NextCursor, p.pages)
+	}
+	p.seen[paging.NextCursor] = true
+	return paging.NextCursor, nil
+}
+
+// endpointName names the endpoint at path for budgeting, without the API prefix
+// and path parameters, e.g. "validators/rewards-aggregate" or "v1/validator".
+func endpointName(path string) string {
+	if name, ok := strings.CutPrefix(path, "/api/v2/ethereum/"); ok {
+		return name
+	}
+	if strings.HasPrefix(path, "/api/v2/validator-dashboards/") {
+		return "validator-dashboards/validators"
+	}
+	if name, ok := strings.CutPrefix(path, "/api/v2/"); ok {
+		return name
+	}
+	if strings.HasPrefix(path, "/api/v1/execution/block/") {
+		return "v1/execution/block"
+	}
+	if strings.HasPrefix(path, "/api/v1/epoch/") {
+		return "v1/epoch"
+	}
+	if strings.HasPrefix(path, "/api/v1/validator/withdrawalCredentials/") {
+		return "v1/validator/withdrawalCredentials"
+	}
+	if strings.HasPrefix(path, "/api/v1/validator/") {
+		return "v1/validator"
+	}
+	return strings.TrimPrefix(path, "/")
+}
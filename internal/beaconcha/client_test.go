@@ -0,0 +1,66 @@
+package beaconcha
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ratelimiter"
+)
+
+func TestClient_GetValidators_Pagination(t *testing.T) {
+	tests := []struct {
+		name       string
+		nextCursor func(page int) string // Cursor returned with the given page, counted from 1
+		wantPages  int
+		wantErr    bool
+	}{
+		{name: "all pages", nextCursor: func(page int) string {
+			if page == 3 {
+				return ""
+			}
+			return strconv.Itoa(page)
+		}, wantPages: 3},
+		{name: "repeated cursor", nextCursor: func(page int) string { return "same" }, wantPages: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				pages++
+				fmt.Fprintf(w, `{"data":[{"validator":{"index":%d}}],"paging":{"next_cursor":%q}}`, pages, tt.nextCursor(pages))
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, "", APIVersionV2, ratelimiter.NewGlobalRateLimiter(time.Millisecond), 5*time.Second)
+			validators, err := c.getValidators(context.Background(), "mainnet", models.BeaconchainValidatorSelector{ValidatorIdentifiers: []int{1, 2, 3}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error: %v, got %v", tt.wantErr, err)
+			}
+			if pages != tt.wantPages {
+				t.Errorf("expected %d pages requested, got %d", tt.wantPages, pages)
+			}
+			if !tt.wantErr && len(validators) != tt.wantPages {
+				t.Errorf("expected %d validators, got %d", tt.wantPages, len(validators))
+			}
+		})
+	}
+}
+
+func TestPager_MaxPages(t *testing.T) {
+	var p pager
+	for i := 1; i < maxPages; i++ {
+		if _, err := p.next(&models.BeaconchainPaging{NextCursor: strconv.Itoa(i)}); err != nil {
+			t.Fatalf("page %d failed: %v", i, err)
+		}
+	}
+	if _, err := p.next(&models.BeaconchainPaging{NextCursor: "last"}); err == nil {
+		t.Error("expected an error after maxPages pages")
+	}
+}
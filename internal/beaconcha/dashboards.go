@@ -0,0 +1,96 @@
+package beaconcha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/tracing"
+)
+
+// networkChains are the chains of the chain IDs dashboards are on.
+var networkChains = map[int64]string{
+	1:      "mainnet",
+	560048: "hoodi",
+}
+
+// NetworkChain returns the chain of the chain ID of a dashboard.
+func NetworkChain(network int64) (string, bool) {
+	chain, ok := networkChains[network]
+	return chain, ok
+}
+
+// GetDashboards fetches the validator dashboards of the account whose API key the
+// client uses, from GET /api/v2/users/me/dashboards.
+func (c *Client) GetDashboards(ctx context.Context) ([]models.BeaconchainValidatorDashboard, error) {
+	var response models.BeaconchainDashboardsResponse
+	if err := c.get(ctx, "/api/v2/users/me/dashboards", nil, &response); err != nil {
+		return nil, fmt.Errorf("fetch dashboards: %w", err)
+	}
+	return response.Data.ValidatorDashboards, nil
+}
+
+// GetDashboardValidators fetches all validators of the dashboard id with their
+// groups, from GET /api/v2/validator-dashboards/{id}/validators.
+func (c *Client) GetDashboardValidators(ctx context.Context, id int64) ([]models.BeaconchainDashboardValidator, error) {
+	var validators []models.BeaconchainDashboardValidator
+	var pages pager
+	cursor := ""
+	for {
+		query := url.Values{"limit": {strconv.Itoa(validatorsPerRequest)}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		var response models.BeaconchainDashboardValidatorsResponse
+		if err := c.get(ctx, fmt.Sprintf("/api/v2/validator-dashboards/%d/validators", id), query, &response); err != nil {
+			return nil, fmt.Errorf("fetch validators of dashboard %d: %w", id, err)
+		}
+		validators = append(validators, response.Data...)
+
+		next, err := pages.next(response.Paging)
+		if err != nil {
+			return nil, fmt.Errorf("fetch validators of dashboard %d: %w", id, err)
+		}
+		if next == "" {
+			return validators, nil
+		}
+		cursor = next
+	}
+}
+
+// get fetches the Beaconcha endpoint at path and decodes the response into out.
+// Non-200 responses are returned as errors.
+func (c *Client) get(ctx context.Context, path string, query url.Values, out any) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "beaconcha GET "+path)
+	defer func() { span.End(err != nil) }()
+
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	c.addHeaders(req)
+	tracing.Inject(ctx, req.Header)
+
+	slog.Debug("beaconcha request", "method", "GET", "endpoint", path)
+
+	resp, body, err := c.doRequestWithRetry(ctx, req, nil, 3)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("beaconcha returned status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
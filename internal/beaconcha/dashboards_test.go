@@ -0,0 +1,55 @@
+package beaconcha
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ratelimiter"
+)
+
+func TestClient_Dashboards(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer account-key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v2/users/me/dashboards":
+			fmt.Fprint(w, `{"data":{"validator_dashboards":[{"id":7,"name":"home","network":1,"is_archived":false}]}}`)
+		case "/api/v2/validator-dashboards/7/validators":
+			if r.URL.Query().Get("cursor") == "" {
+				fmt.Fprint(w, `{"data":[{"index":1,"group":{"id":0,"name":"default"}}],"paging":{"next_cursor":"2"}}`)
+				return
+			}
+			fmt.Fprint(w, `{"data":[{"index":2,"group":{"id":1,"name":"node-b"}}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	c := NewClient(server.URL, "account-key", APIVersionV2, ratelimiter.NewGlobalRateLimiter(time.Millisecond), 5*time.Second)
+	ctx := context.Background()
+
+	dashboards, err := c.GetDashboards(ctx)
+	if err != nil {
+		t.Fatalf("GetDashboards failed: %v", err)
+	}
+	if len(dashboards) != 1 || dashboards[0].ID != 7 || dashboards[0].Name != "home" {
+		t.Fatalf("unexpected dashboards %+v", dashboards)
+	}
+	if chain, ok := NetworkChain(dashboards[0].Network); !ok || chain != "mainnet" {
+		t.Errorf("expected mainnet, got %q", chain)
+	}
+
+	validators, err := c.GetDashboardValidators(ctx, 7)
+	if err != nil {
+		t.Fatalf("GetDashboardValidators failed: %v", err)
+	}
+	if len(validators) != 2 || validators[1].Index != 2 || validators[1].Group.Name != "node-b" {
+		t.Errorf("expected validators of both pages, got %+v", validators)
+	}
+}
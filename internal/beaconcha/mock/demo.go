@@ -0,0 +1,207 @@
+package mock
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// DemoWithdrawalAddress is the withdrawal address of all demo validators, usable
+// as a portfolio registry address.
+const DemoWithdrawalAddress = "0x00000000219ab540356cbb839cbe05303d7705fa"
+
+// DemoValidators is the number of demo validators per chain, indexed from 1.
+const DemoValidators = 20
+
+// Per validator and day amounts of the demo data, in wei.
+var (
+	demoDailyReward  = big.NewInt(2_700_000_000_000_000) // 0.0027 ETH
+	demoDailyPenalty = big.NewInt(30_000_000_000_000)    // 0.00003 ETH
+)
+
+// Demo returns a fake seeded with a plausible data set for mainnet and hoodi,
+// relative to now: DemoValidators validators withdrawing to DemoWithdrawalAddress
+// (validator 5 offline, 6 pending, 7 exited and 8 slashed), rewards and performance
+// for every evaluation range, 90 days of daily rewards, regular withdrawals, two
+// days of balance history, a sync committee assignment, network queues and network
+// performance.
+func Demo(now time.Time) *beaconchatest.Fake {
+	fake := beaconchatest.New()
+	for _, chain := range []string{"mainnet", "hoodi"} {
+		spec, err := chainspec.ForChain(chain)
+		if err != nil {
+			continue
+		}
+		head, err := spec.LastCompletedEpoch(now)
+		if err != nil {
+			continue
+		}
+		seedDemo(fake, chain, spec, head)
+	}
+	return fake
+}
+
+func seedDemo(fake *beaconchatest.Fake, chain string, spec chainspec.Spec, head int64) {
+	epochsPerDay := int64(24*time.Hour) / int64(spec.EpochDuration())
+
+	for i := 1; i <= DemoValidators; i++ {
+		b := beaconchatest.Validator(i).WithdrawalAddress(DemoWithdrawalAddress)
+		switch i {
+		case 5:
+			b.Offline()
+		case 6:
+			b.Pending(1200)
+		case 7:
+			b.Status("exited").Exit(head - 10*epochsPerDay).Withdrawable(head - 9*epochsPerDay)
+		case 8:
+			b.Slashed(head - 30*epochsPerDay).Withdrawable(head - 6*epochsPerDay)
+		}
+		fake.AddValidators(chain, b.Build())
+	}
+
+	ranges := map[string]int64{"24h": 1, "7d": 7, "30d": 30, "90d": 90, "all_time": 365}
+	for evalRange, days := range ranges {
+		epochs := models.BeaconchainEpochRange{Start: head - days*epochsPerDay + 1, End: head}
+		fake.SetRewards(chain, evalRange, models.BeaconchainRewardsAggregateResponse{
+			Data:  demoRewards(days * DemoValidators),
+			Range: models.BeaconchainResultRange{Epoch: epochs},
+		})
+		fake.SetPerformance(chain, evalRange, demoPerformance(days*epochsPerDay*DemoValidators, epochs))
+		fake.SetNetworkPerformance(chain, evalRange, demoNetworkPerformance(days*epochsPerDay, epochs))
+	}
+
+	var history []models.BeaconchainRewardsHistoryEntry
+	for day := int64(89); day >= 0; day-- {
+		start := spec.EpochStart(head - day*epochsPerDay).Truncate(24 * time.Hour)
+		history = append(history, models.BeaconchainRewardsHistoryEntry{
+			Range: models.BeaconchainResultRange{Timestamp: models.BeaconchainTimestampRange{
+				Start: start.Unix(),
+				End:   start.Add(24*time.Hour - time.Second).Unix(),
+			}},
+			Rewards: demoRewards(DemoValidators),
+		})
+	}
+	fake.SetDailyRewards(chain, history...)
+
+	// Each validator is swept about every 5 days
+	for day := int64(0); day < 90; day += 5 {
+		for i := 1; i <= DemoValidators; i++ {
+			epoch := head - day*epochsPerDay - int64(i)*10
+			fake.AddWithdrawals(chain, beaconchatest.Withdrawal(i, epoch, "13500000000000000"))
+		}
+	}
+
+	balance := new(big.Int)
+	for i := 1; i <= DemoValidators; i++ {
+		var entries []models.BeaconchainBalanceHistoryEntry
+		for epoch := head - 2*epochsPerDay; epoch <= head; epoch++ {
+			// 32 ETH growing by the daily reward, reset by the sweep every 5 days
+			sinceSweep := (epoch + int64(i)*10) % (5 * epochsPerDay)
+			balance.Mul(demoDailyReward, big.NewInt(sinceSweep))
+			balance.Div(balance, big.NewInt(epochsPerDay))
+			balance.Add(balance, big.NewInt(0).Mul(big.NewInt(32), big.NewInt(1_000_000_000_000_000_000)))
+			entries = append(entries, models.BeaconchainBalanceHistoryEntry{
+				Epoch:            epoch,
+				Balance:          balance.String(),
+				EffectiveBalance: "32000000000000000000",
+			})
+		}
+		fake.AddBalanceHistory(chain, i, entries...)
+	}
+
+	// Sync committee periods are 256 epochs
+	period := (head + 1) / 256
+	index := 3
+	fake.AddSyncCommittees(chain, models.BeaconchainSyncCommitteeAssignment{
+		Validator:  models.BeaconchainValidatorInfo{Index: &index},
+		Period:     period,
+		Epoch:      models.BeaconchainEpochRange{Start: period * 256, End: period*256 + 255},
+		Assigned:   int((head + 1 - period*256) * int64(spec.SlotsPerEpoch)),
+		Successful: int((head + 1 - period*256) * int64(spec.SlotsPerEpoch)),
+		Reward:     "1200000000000000",
+	})
+
+	fake.SetQueues(chain, models.BeaconchainQueues{
+		EntryQueue: models.BeaconchainQueue{Validators: 2400, ChurnLimit: 8},
+		ExitQueue:  models.BeaconchainQueue{Validators: 120, ChurnLimit: 8},
+	})
+	fake.SetNetworkSize(chain, models.BeaconchainNetworkSize{
+		Epoch:                  head,
+		ActiveValidators:       1_000_000,
+		ActiveEffectiveBalance: "32000000000000000000000000", // 32M ETH
+	})
+}
+
+// demoNetworkPerformance returns the performance of an average network validator
+// with the given number of attestation duties, slightly below the demo validators.
+func demoNetworkPerformance(assigned int64, epochs models.BeaconchainEpochRange) models.BeaconchainNetworkPerformanceResponse {
+	missed := assigned / 100
+	score, apr := 0.985, 0.029
+	return models.BeaconchainNetworkPerformanceResponse{
+		Data: models.BeaconchainNetworkPerformance{
+			Beaconscore: models.BeaconchainBeaconscore{Total: &score, Attestation: &score},
+			Duties: models.BeaconchainPerformanceDuties{
+				Attestation: models.BeaconchainAttestationDuties{
+					Included: int(assigned - missed),
+					Assigned: int(assigned),
+					Missed:   int(missed),
+				},
+			},
+			APR: &apr,
+		},
+		Range: models.BeaconchainResultRange{Epoch: epochs},
+	}
+}
+
+// demoRewards returns the rewards of the given number of validator days.
+func demoRewards(validatorDays int64) models.BeaconchainRewardsData {
+	n := big.NewInt(validatorDays)
+	reward := new(big.Int).Mul(demoDailyReward, n)
+	penalty := new(big.Int).Mul(demoDailyPenalty, n)
+	total := new(big.Int).Sub(reward, penalty)
+
+	return models.BeaconchainRewardsData{
+		Total:        total.String(),
+		TotalReward:  reward.String(),
+		TotalPenalty: penalty.String(),
+		TotalMissed:  penalty.String(),
+		Attestation: models.BeaconchainAttestationRewards{
+			Total:                 total.String(),
+			InactivityLeakPenalty: "0",
+		},
+		SyncCommittee: models.BeaconchainSyncCommitteeRewards{Total: "0", Reward: "0", Penalty: "0", MissedReward: "0"},
+		Proposal: models.BeaconchainProposalRewards{
+			Total:                "0",
+			ExecutionLayerReward: "0",
+			MissedCLReward:       "0",
+			MissedELReward:       "0",
+		},
+	}
+}
+
+// demoPerformance returns a performance aggregate with 99.5% of the given
+// attestation duties included.
+func demoPerformance(assigned int64, epochs models.BeaconchainEpochRange) models.BeaconchainPerformanceAggregateResponse {
+	missed := assigned / 200
+	score := 0.995
+	return models.BeaconchainPerformanceAggregateResponse{
+		Data: models.BeaconchainPerformanceData{
+			Beaconscore: models.BeaconchainBeaconscore{Total: &score, Attestation: &score},
+			Duties: models.BeaconchainPerformanceDuties{
+				Attestation: models.BeaconchainAttestationDuties{
+					Included:          int(assigned - missed),
+					Assigned:          int(assigned),
+					CorrectHead:       int(assigned - missed),
+					CorrectSource:     int(assigned - missed),
+					CorrectTarget:     int(assigned - missed),
+					AvgInclusionDelay: 1.02,
+					Missed:            int(missed),
+				},
+			},
+		},
+		Range: models.BeaconchainResultRange{Epoch: epochs},
+	}
+}
@@ -0,0 +1,452 @@
+// Package mock serves the Beaconcha v2 API over HTTP from a beaconcha.Provider,
+// usually a seeded beaconchatest.Fake, so the full stack can run without an API
+// key or network access. Latency, rate limiting and pagination can be tuned to
+// exercise the client.
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// Server is an http.Handler implementing the v2 endpoints used by beaconcha.Client.
+// Any API key is accepted.
+type Server struct {
+	data beaconcha.Provider
+	mux  *http.ServeMux
+
+	mu             sync.Mutex
+	latency        time.Duration
+	rateLimitEvery int
+	maxPageSize    int
+	requests       int
+}
+
+var _ http.Handler = (*Server)(nil)
+
+// New creates a server answering from data.
+func New(data beaconcha.Provider) *Server {
+	s := &Server{data: data, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("POST /api/v2/ethereum/validators", s.handleValidators)
+	s.mux.HandleFunc("POST /api/v2/ethereum/validators/rewards-aggregate", s.handleRewardsAggregate)
+	s.mux.HandleFunc("POST /api/v2/ethereum/validators/performance-aggregate", s.handlePerformanceAggregate)
+	s.mux.HandleFunc("POST /api/v2/ethereum/validators/withdrawals", s.handleWithdrawals)
+	s.mux.HandleFunc("POST /api/v2/ethereum/validators/blocks", s.handleBlocks)
+	s.mux.HandleFunc("POST /api/v2/ethereum/validators/attestations", s.handleAttestations)
+	s.mux.HandleFunc("POST /api/v2/ethereum/validators/rewards-history", s.handleRewardsHistory)
+	s.mux.HandleFunc("POST /api/v2/ethereum/validators/balance-history", s.handleBalanceHistory)
+	s.mux.HandleFunc("POST /api/v2/ethereum/validators/sync-committees", s.handleSyncCommittees)
+	s.mux.HandleFunc("POST /api/v2/ethereum/validators/slashings", s.handleSlashings)
+	s.mux.HandleFunc("POST /api/v2/ethereum/network/queues", s.handleQueues)
+	s.mux.HandleFunc("POST /api/v2/ethereum/network/performance-aggregate", s.handleNetworkPerformance)
+	s.mux.HandleFunc("GET /api/v1/epoch/latest", s.handleLatestEpoch)
+	s.mux.HandleFunc("GET /api/v1/execution/block/{numbers}", s.handleExecutionBlocks)
+
+	return s
+}
+
+// SetLatency delays every response by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// SetRateLimitEvery answers every nth request with 429 Too Many Requests and a
+// ratelimit-reset of one second. Zero disables rate limiting.
+func (s *Server) SetRateLimitEvery(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimitEvery = n
+}
+
+// SetMaxPageSize caps the page size of paginated endpoints below the size the
+// client requests, so that small data sets still span several pages. Zero uses
+// the requested size.
+func (s *Server) SetMaxPageSize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxPageSize = n
+}
+
+// Requests returns the number of requests received, including rate limited ones.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	limited := s.rateLimitEvery > 0 && s.requests%s.rateLimitEvery == 0
+	latency := s.latency
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if limited {
+		w.Header().Set("ratelimit-remaining", "0")
+		w.Header().Set("ratelimit-reset", "1")
+		writeJSON(w, http.StatusTooManyRequests, models.BeaconchainErrorResponse{Message: "rate limit exceeded"})
+		return
+	}
+
+	slog.Debug("mock beaconcha request", "path", r.URL.Path)
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleValidators(w http.ResponseWriter, r *http.Request) {
+	var req models.BeaconchainValidatorsRequest
+	if !decode(w, r, &req) || !checkSelector(w, req.Validator) {
+		return
+	}
+
+	var data []models.BeaconchainValidatorData
+	var err error
+	switch {
+	case req.Validator.WithdrawalAddress != "":
+		data, err = s.data.GetValidatorsByWithdrawalAddress(r.Context(), req.Chain, req.Validator.WithdrawalAddress)
+	case len(req.Validator.PublicKeys) > 0:
+		data, err = s.data.GetValidatorsByPublicKey(r.Context(), req.Chain, req.Validator.PublicKeys)
+		if err == nil && len(req.Validator.ValidatorIdentifiers) > 0 {
+			var byIndex []models.BeaconchainValidatorData
+			byIndex, err = s.data.GetValidators(r.Context(), req.Chain, req.Validator.ValidatorIdentifiers)
+			data = append(byIndex, data...)
+		}
+	default:
+		data, err = s.data.GetValidators(r.Context(), req.Chain, req.Validator.ValidatorIdentifiers)
+	}
+	if !check(w, err) {
+		return
+	}
+
+	data, paging, err := paginate(data, s.pageSize(req.PageSize), req.Cursor)
+	if !check(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, models.BeaconchainValidatorsResponse{Data: data, Paging: paging})
+}
+
+func (s *Server) handleRewardsAggregate(w http.ResponseWriter, r *http.Request) {
+	var req models.BeaconchainRewardsAggregateRequest
+	if !decode(w, r, &req) || !checkSelector(w, req.Validator) {
+		return
+	}
+
+	rewards, err := s.data.GetRewardsAggregate(r.Context(), req.Chain, req.Validator.ValidatorIdentifiers, req.Range.EvaluationWindow)
+	if !check(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, rewards)
+}
+
+func (s *Server) handlePerformanceAggregate(w http.ResponseWriter, r *http.Request) {
+	var req models.BeaconchainPerformanceAggregateRequest
+	if !decode(w, r, &req) || !checkSelector(w, req.Validator) {
+		return
+	}
+
+	performance, err := s.data.GetPerformanceAggregate(r.Context(), req.Chain, req.Validator.ValidatorIdentifiers, req.Range.EvaluationWindow)
+	if !check(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, performance)
+}
+
+func (s *Server) handleWithdrawals(w http.ResponseWriter, r *http.Request) {
+	var req models.BeaconchainWithdrawalsRequest
+	if !decode(w, r, &req) || !checkSelector(w, req.Validator) {
+		return
+	}
+
+	data, err := s.data.GetWithdrawals(r.Context(), req.Chain, req.Validator.ValidatorIdentifiers, req.Range.EvaluationWindow)
+	if !check(w, err) {
+		return
+	}
+	data, paging, err := paginate(data, s.pageSize(req.PageSize), req.Cursor)
+	if !check(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, models.BeaconchainWithdrawalsResponse{Data: data, Paging: paging})
+}
+
+func (s *Server) handleBlocks(w http.ResponseWriter, r *http.Request) {
+	var req models.BeaconchainBlocksRequest
+	if !decode(w, r, &req) || !checkSelector(w, req.Validator) {
+		return
+	}
+
+	data, err := s.data.GetBlocks(r.Context(), req.Chain, req.Validator.ValidatorIdentifiers, req.Range.EvaluationWindow)
+	if !check(w, err) {
+		return
+	}
+	data, paging, err := paginate(data, s.pageSize(req.PageSize), req.Cursor)
+	if !check(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, models.BeaconchainBlocksResponse{Data: data, Paging: paging})
+}
+
+func (s *Server) handleAttestations(w http.ResponseWriter, r *http.Request) {
+	var req models.BeaconchainAttestationsRequest
+	if !decode(w, r, &req) || !checkSelector(w, req.Validator) {
+		return
+	}
+
+	data, err := s.data.GetAttestations(r.Context(), req.Chain, req.Validator.ValidatorIdentifiers, req.Range.Epoch.Start, req.Range.Epoch.End)
+	if !check(w, err) {
+		return
+	}
+	data, paging, err := paginate(data, s.pageSize(req.PageSize), req.Cursor)
+	if !check(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, models.BeaconchainAttestationsResponse{Data: data, Paging: paging})
+}
+
+func (s *Server) handleRewardsHistory(w http.ResponseWriter, r *http.Request) {
+	var req models.BeaconchainRewardsHistoryRequest
+	if !decode(w, r, &req) || !checkSelector(w, req.Validator) {
+		return
+	}
+
+	data, err := s.data.GetDailyRewards(r.Context(), req.Chain, req.Validator.ValidatorIdentifiers, req.Range.EvaluationWindow)
+	if !check(w, err) {
+		return
+	}
+	data, paging, err := paginate(data, s.pageSize(req.PageSize), req.Cursor)
+	if !check(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, models.BeaconchainRewardsHistoryResponse{Data: data, Paging: paging})
+}
+
+func (s *Server) handleBalanceHistory(w http.ResponseWriter, r *http.Request) {
+	var req models.BeaconchainBalanceHistoryRequest
+	if !decode(w, r, &req) || !checkSelector(w, req.Validator) {
+		return
+	}
+	if len(req.Validator.ValidatorIdentifiers) != 1 {
+		writeJSON(w, http.StatusBadRequest, models.BeaconchainErrorResponse{Message: "exactly one validator is required"})
+		return
+	}
+
+	data, err := s.data.GetBalanceHistory(r.Context(), req.Chain, req.Validator.ValidatorIdentifiers[0], req.Range.Epoch.Start, req.Range.Epoch.End)
+	if !check(w, err) {
+		return
+	}
+	data, paging, err := paginate(data, s.pageSize(req.PageSize), req.Cursor)
+	if !check(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, models.BeaconchainBalanceHistoryResponse{Data: data, Paging: paging})
+}
+
+func (s *Server) handleSyncCommittees(w http.ResponseWriter, r *http.Request) {
+	var req models.BeaconchainSyncCommitteesRequest
+	if !decode(w, r, &req) || !checkSelector(w, req.Validator) {
+		return
+	}
+
+	data, err := s.data.GetSyncCommittees(r.Context(), req.Chain, req.Validator.ValidatorIdentifiers)
+	if !check(w, err) {
+		return
+	}
+	data, paging, err := paginate(data, s.pageSize(req.PageSize), req.Cursor)
+	if !check(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, models.BeaconchainSyncCommitteesResponse{Data: data, Paging: paging})
+}
+
+func (s *Server) handleSlashings(w http.ResponseWriter, r *http.Request) {
+	var req models.BeaconchainSlashingsRequest
+	if !decode(w, r, &req) || !checkSelector(w, req.Validator) {
+		return
+	}
+	if len(req.Validator.ValidatorIdentifiers) != 1 {
+		writeJSON(w, http.StatusBadRequest, models.BeaconchainErrorResponse{Message: "exactly one validator is required"})
+		return
+	}
+
+	data, err := s.data.GetSlashings(r.Context(), req.Chain, req.Validator.ValidatorIdentifiers[0])
+	if !check(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, models.BeaconchainSlashingsResponse{Data: data})
+}
+
+func (s *Server) handleQueues(w http.ResponseWriter, r *http.Request) {
+	var req models.BeaconchainQueuesRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	queues, err := s.data.GetQueues(r.Context(), req.Chain)
+	if !check(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, models.BeaconchainQueuesResponse{Data: *queues})
+}
+
+func (s *Server) handleNetworkPerformance(w http.ResponseWriter, r *http.Request) {
+	var req models.BeaconchainNetworkPerformanceRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	performance, err := s.data.GetNetworkPerformance(r.Context(), req.Chain, req.Range.EvaluationWindow)
+	if !check(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, performance)
+}
+
+// handleLatestEpoch serves the network size in the v1 format. v1 selects the chain
+// by host, which the mock does not, so it serves mainnet.
+func (s *Server) handleLatestEpoch(w http.ResponseWriter, r *http.Request) {
+	size, err := s.data.GetNetworkSize(r.Context(), "mainnet")
+	if !check(w, err) {
+		return
+	}
+	epoch := models.BeaconchainV1Epoch{
+		Epoch:           size.Epoch,
+		ValidatorsCount: size.ActiveValidators,
+		EligibleEther:   amount.ParseOrZero(size.ActiveEffectiveBalance).Div(1e9).Big().Int64(),
+	}
+	data, err := json.Marshal(epoch)
+	if !check(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, models.BeaconchainV1Response{Status: "OK", Data: data})
+}
+
+// handleExecutionBlocks serves execution layer blocks in the v1 format, on mainnet
+// like handleLatestEpoch.
+func (s *Server) handleExecutionBlocks(w http.ResponseWriter, r *http.Request) {
+	var numbers []int64
+	for _, n := range strings.Split(r.PathValue("numbers"), ",") {
+		number, err := strconv.ParseInt(n, 10, 64)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, models.BeaconchainV1Response{Status: "ERROR: invalid block number " + n})
+			return
+		}
+		numbers = append(numbers, number)
+	}
+
+	blocks, err := s.data.GetExecutionBlocks(r.Context(), "mainnet", numbers)
+	if !check(w, err) {
+		return
+	}
+	v1Blocks := make([]models.BeaconchainV1ExecutionBlock, 0, len(blocks))
+	for _, b := range blocks {
+		v1 := models.BeaconchainV1ExecutionBlock{BlockNumber: b.BlockNumber, ProducerReward: json.Number(b.ProducerReward)}
+		v1.PosConsensus.Slot = b.Slot
+		if b.Relay != nil {
+			v1.Relay = &models.BeaconchainV1Relay{Tag: *b.Relay}
+		}
+		v1Blocks = append(v1Blocks, v1)
+	}
+	data, err := json.Marshal(v1Blocks)
+	if !check(w, err) {
+		return
+	}
+	writeJSON(w, http.StatusOK, models.BeaconchainV1Response{Status: "OK", Data: data})
+}
+
+// pageSize returns the page size to serve for a request asking for requested.
+func (s *Server) pageSize(requested int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maxPageSize > 0 && (requested <= 0 || requested > s.maxPageSize) {
+		return s.maxPageSize
+	}
+	return requested
+}
+
+// paginate returns the page of items starting at cursor, an opaque offset issued
+// by a previous page, and the paging of the next page if there is one.
+func paginate[T any](items []T, pageSize int, cursor string) ([]T, *models.BeaconchainPaging, error) {
+	offset := 0
+	if cursor != "" {
+		var err error
+		offset, err = strconv.Atoi(cursor)
+		if err != nil || offset < 0 || offset > len(items) {
+			return nil, nil, fmt.Errorf("%w: invalid cursor %q", errBadRequest, cursor)
+		}
+	}
+	if pageSize <= 0 || offset+pageSize >= len(items) {
+		return items[offset:], nil, nil
+	}
+	end := offset + pageSize
+	return items[offset:end], &models.BeaconchainPaging{NextCursor: strconv.Itoa(end)}, nil
+}
+
+// errBadRequest marks errors caused by the request.
+var errBadRequest = errors.New("bad request")
+
+// decode reads the JSON request body into v, answering 400 if it is invalid.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.BeaconchainErrorResponse{Message: "invalid request body: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+// maxValidators is the most validators upstream accepts per request.
+const maxValidators = 100
+
+// checkSelector answers 400, as upstream does, if sel names more than maxValidators
+// validators.
+func checkSelector(w http.ResponseWriter, sel models.BeaconchainValidatorSelector) bool {
+	if n := len(sel.ValidatorIdentifiers) + len(sel.PublicKeys); n > maxValidators {
+		writeJSON(w, http.StatusBadRequest, models.BeaconchainErrorResponse{Message: fmt.Sprintf("at most %d validators per request, got %d", maxValidators, n)})
+		return false
+	}
+	return true
+}
+
+// check answers with an error response if err is set.
+func check(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errBadRequest):
+		writeJSON(w, http.StatusBadRequest, models.BeaconchainErrorResponse{Message: err.Error()})
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		// The client is gone
+	default:
+		writeJSON(w, http.StatusInternalServerError, models.BeaconchainErrorResponse{Message: err.Error()})
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("failed to encode mock response", "error", err)
+	}
+}
@@ -0,0 +1,271 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ratelimiter"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/service"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ssv"
+)
+
+func newClient(t *testing.T, s *Server) *beaconcha.Client {
+	t.Helper()
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return beaconcha.NewClient(server.URL, "", beaconcha.APIVersionV2, ratelimiter.NewGlobalRateLimiter(time.Millisecond), 10*time.Second)
+}
+
+func TestServer_PaginationAndRateLimit(t *testing.T) {
+	s := New(Demo(time.Now()))
+	s.SetMaxPageSize(4)
+	s.SetRateLimitEvery(4)
+	client := newClient(t, s)
+
+	ids := make([]int, DemoValidators)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+
+	validators, err := client.GetValidators(context.Background(), "mainnet", ids)
+	if err != nil {
+		t.Fatalf("GetValidators failed: %v", err)
+	}
+	if len(validators) != DemoValidators {
+		t.Errorf("expected %d validators, got %d", DemoValidators, len(validators))
+	}
+	// 5 pages plus one retry of the rate limited fourth request
+	if got := s.Requests(); got != 6 {
+		t.Errorf("expected 6 requests, got %d", got)
+	}
+
+	byAddress, err := client.GetValidatorsByWithdrawalAddress(context.Background(), "hoodi", DemoWithdrawalAddress)
+	if err != nil {
+		t.Fatalf("GetValidatorsByWithdrawalAddress failed: %v", err)
+	}
+	if len(byAddress) != DemoValidators {
+		t.Errorf("expected %d validators, got %d", DemoValidators, len(byAddress))
+	}
+}
+
+func TestServer_ValidatorsAcrossChunks(t *testing.T) {
+	const validators = 250
+
+	fake := beaconchatest.New()
+	for i := 1; i <= validators; i++ {
+		fake.AddValidators("mainnet", beaconchatest.Validator(i).Build())
+	}
+	s := New(fake)
+	s.SetMaxPageSize(10)
+	client := newClient(t, s)
+
+	// Every validator twice, so duplicates fall into different chunks
+	ids := make([]int, 0, 2*validators)
+	for i := 1; i <= validators; i++ {
+		ids = append(ids, i)
+	}
+	ids = append(ids, ids...)
+
+	data, err := client.GetValidators(context.Background(), "mainnet", ids)
+	if err != nil {
+		t.Fatalf("GetValidators failed: %v", err)
+	}
+	if len(data) != validators {
+		t.Fatalf("expected %d validators, got %d", validators, len(data))
+	}
+	seen := make(map[int]bool)
+	for _, v := range data {
+		if seen[*v.Validator.Index] {
+			t.Errorf("validator %d returned twice", *v.Validator.Index)
+		}
+		seen[*v.Validator.Index] = true
+	}
+	// Chunks of 100, 100 and 50 validators in pages of 10
+	if got := s.Requests(); got != 25 {
+		t.Errorf("expected 25 requests, got %d", got)
+	}
+}
+
+func TestServer_ValidatorsByPublicKey(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Pubkey("0xaa01").Build(),
+		beaconchatest.Validator(2).Pubkey("0xaa02").Build(),
+		beaconchatest.Validator(3).Pubkey("0xaa03").Build(),
+	)
+	client := newClient(t, New(fake))
+
+	// Keys are sent as validator identifiers; unknown keys are left out
+	data, err := client.GetValidatorsByPublicKey(context.Background(), "mainnet", []string{"0xAA03", "0xaa01", "0xbb00"})
+	if err != nil {
+		t.Fatalf("GetValidatorsByPublicKey failed: %v", err)
+	}
+	var got []int
+	for _, v := range data {
+		got = append(got, *v.Validator.Index)
+	}
+	if !slices.Equal(got, []int{3, 1}) {
+		t.Errorf("expected validators [3 1], got %v", got)
+	}
+}
+
+func TestServer_FullStack(t *testing.T) {
+	client := newClient(t, New(Demo(time.Now())))
+	svc := service.NewValidatorService(client, nil, nil, nil, nil, nil)
+
+	response, err := svc.GetValidatorData(context.Background(), models.ValidatorRequest{
+		ValidatorIds: []int{1, 5, 6},
+		Chain:        "mainnet",
+		Range:        "7d",
+	})
+	if err != nil {
+		t.Fatalf("GetValidatorData failed: %v", err)
+	}
+
+	if len(response.Validators) != 3 {
+		t.Fatalf("expected 3 validators, got %d", len(response.Validators))
+	}
+	if response.Validators["5"].Online {
+		t.Error("expected validator 5 offline")
+	}
+	if response.Validators["6"].EntryQueuePosition == nil {
+		t.Error("expected an entry queue position for pending validator 6")
+	}
+	if response.Rewards.Total == "" || response.Rewards.Total == "0" {
+		t.Errorf("expected rewards, got %q", response.Rewards.Total)
+	}
+	if response.Performance.Attestations.Assigned == 0 {
+		t.Error("expected attestation duties")
+	}
+}
+
+func TestServer_AggregatesAcrossChunks(t *testing.T) {
+	const validators = 250
+	score := 0.9
+
+	fake := beaconchatest.New()
+	ids := make([]int, validators)
+	for i := range ids {
+		ids[i] = i + 1
+		fake.AddValidators("mainnet", beaconchatest.Validator(i+1).Build())
+	}
+	// Every chunk of validators gets the same aggregates
+	fake.SetRewards("mainnet", "7d", models.BeaconchainRewardsAggregateResponse{
+		Data: models.BeaconchainRewardsData{Total: "100", Attestation: models.BeaconchainAttestationRewards{Total: "60"}},
+	})
+	fake.SetPerformance("mainnet", "7d", models.BeaconchainPerformanceAggregateResponse{
+		Data: models.BeaconchainPerformanceData{
+			Beaconscore: models.BeaconchainBeaconscore{Total: &score},
+			Duties: models.BeaconchainPerformanceDuties{
+				Attestation: models.BeaconchainAttestationDuties{Assigned: 10, Included: 9, AvgInclusionDelay: 1.5},
+			},
+		},
+	})
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	fake.SetDailyRewards("mainnet",
+		models.BeaconchainRewardsHistoryEntry{Range: models.BeaconchainResultRange{Timestamp: models.BeaconchainTimestampRange{Start: day.Unix()}}, Rewards: models.BeaconchainRewardsData{Total: "10"}},
+		models.BeaconchainRewardsHistoryEntry{Range: models.BeaconchainResultRange{Timestamp: models.BeaconchainTimestampRange{Start: day.AddDate(0, 0, 1).Unix()}}, Rewards: models.BeaconchainRewardsData{Total: "20"}},
+	)
+	first, last := 1, validators
+	fake.AddWithdrawals("mainnet",
+		models.BeaconchainWithdrawal{Validator: models.BeaconchainValidatorInfo{Index: &first}, Amount: "1"},
+		models.BeaconchainWithdrawal{Validator: models.BeaconchainValidatorInfo{Index: &last}, Amount: "2"},
+	)
+	s := New(fake)
+	client := newClient(t, s)
+	ctx := context.Background()
+
+	// The server refuses more than 100 validators per request, so each call takes
+	// 3 chunks
+	rewards, err := client.GetRewardsAggregate(ctx, "mainnet", ids, "7d")
+	if err != nil {
+		t.Fatalf("GetRewardsAggregate failed: %v", err)
+	}
+	if rewards.Data.Total != "300" || rewards.Data.Attestation.Total != "180" {
+		t.Errorf("expected the rewards of the 3 chunks, got %+v", rewards.Data)
+	}
+
+	performance, err := client.GetPerformanceAggregate(ctx, "mainnet", ids, "7d")
+	if err != nil {
+		t.Fatalf("GetPerformanceAggregate failed: %v", err)
+	}
+	attestation := performance.Data.Duties.Attestation
+	if attestation.Assigned != 30 || attestation.Included != 27 || attestation.AvgInclusionDelay != 1.5 {
+		t.Errorf("expected the duties of the 3 chunks, got %+v", attestation)
+	}
+	if b := performance.Data.Beaconscore.Total; b == nil || *b != score {
+		t.Errorf("expected the BeaconScore of the chunks, got %v", b)
+	}
+
+	daily, err := client.GetDailyRewards(ctx, "mainnet", ids, "7d")
+	if err != nil {
+		t.Fatalf("GetDailyRewards failed: %v", err)
+	}
+	if len(daily) != 2 || daily[0].Rewards.Total != "30" || daily[1].Rewards.Total != "60" {
+		t.Errorf("expected 2 days summed over the chunks, got %+v", daily)
+	}
+
+	withdrawals, err := client.GetWithdrawals(ctx, "mainnet", ids, "7d")
+	if err != nil {
+		t.Fatalf("GetWithdrawals failed: %v", err)
+	}
+	if len(withdrawals) != 2 {
+		t.Errorf("expected the withdrawals of the first and last chunk, got %d", len(withdrawals))
+	}
+}
+
+func TestServer_SSVOperatorPortfolio(t *testing.T) {
+	// An operator running 150 validators in its clusters
+	const validators = 150
+	fake := beaconchatest.New()
+	var keys []string
+	for i := 1; i <= validators; i++ {
+		key := fmt.Sprintf("%096x", i)
+		keys = append(keys, fmt.Sprintf(`{"public_key":%q}`, key))
+		fake.AddValidators("mainnet", beaconchatest.Validator(i).Pubkey("0x"+key).Build())
+	}
+	fake.SetRewards("mainnet", "7d", models.BeaconchainRewardsAggregateResponse{Data: models.BeaconchainRewardsData{Total: "100"}})
+	operator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("perPage"))
+		start := min((page-1)*perPage, len(keys))
+		end := min(start+perPage, len(keys))
+		fmt.Fprintf(w, `{"validators":[%s],"pagination":{"page":%d,"pages":%d}}`, strings.Join(keys[start:end], ","), page, (len(keys)+perPage-1)/perPage)
+	}))
+	t.Cleanup(operator.Close)
+
+	client := newClient(t, New(fake))
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{{Name: "operator", Chain: "mainnet", SSVOperatorID: 42}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := portfolios.Get("operator")
+	ctx := context.Background()
+	if err := portfolio.NewSSVSyncer(portfolios, client, ssv.NewClient(operator.URL, time.Second)).Sync(ctx, p); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	svc := service.NewValidatorService(client, nil, nil, nil, portfolios, nil)
+	response, err := svc.GetPortfolioData(ctx, "operator", "7d")
+	if err != nil {
+		t.Fatalf("GetPortfolioData failed: %v", err)
+	}
+	if len(response.Validators) != validators {
+		t.Errorf("expected %d validators, got %d", validators, len(response.Validators))
+	}
+	// The rewards of both chunks of validators
+	if response.Rewards.Total != "200" {
+		t.Errorf("expected the rewards of 2 chunks, got %q", response.Rewards.Total)
+	}
+}
@@ -0,0 +1,185 @@
+package beaconcha
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// Replay modes of ReplayTransport.
+const (
+	ReplayModeRecord = "record" // Forward requests upstream and save the responses
+	ReplayModeReplay = "replay" // Answer from saved responses without network access
+)
+
+// ReplayTransport records upstream exchanges to a directory and replays them later,
+// so that a reported problem can be reproduced with exactly the data upstream
+// returned. Each exchange is stored as <key>-<n>.json, where key identifies the
+// method, path, query and body of the request and n counts identical requests, so
+// responses that changed between identical requests are replayed in order. The
+// last recorded response is repeated once they are used up. API keys are never
+// stored.
+type ReplayTransport struct {
+	dir  string
+	mode string
+	next http.RoundTripper
+
+	mu   sync.Mutex
+	seen map[string]int // Requests per key so far
+}
+
+// replayRecord is the file format of a recorded exchange.
+type replayRecord struct {
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	Body       string              `json:"body,omitempty"`
+	StatusCode int                 `json:"status_code"`
+	Header     map[string][]string `json:"header"`
+	Response   string              `json:"response"`
+}
+
+// NewReplayTransport creates a transport in the given mode that stores exchanges
+// in dir. In record mode requests are sent through next, or the default transport
+// if next is nil.
+func NewReplayTransport(dir, mode string, next http.RoundTripper) (*ReplayTransport, error) {
+	if mode != ReplayModeRecord && mode != ReplayModeReplay {
+		return nil, fmt.Errorf("invalid replay mode %q", mode)
+	}
+	if mode == ReplayModeRecord {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create replay directory: %w", err)
+		}
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &ReplayTransport{dir: dir, mode: mode, next: next, seen: make(map[string]int)}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	u := redactedURL(req)
+	key := replayKey(req.Method, u, body)
+
+	t.mu.Lock()
+	n := t.seen[key]
+	t.seen[key]++
+	t.mu.Unlock()
+
+	if t.mode == ReplayModeReplay {
+		return t.replay(req, key, n)
+	}
+	return t.record(req, key, n, u, body)
+}
+
+// record forwards the request and saves the response as the nth exchange of key.
+func (t *ReplayTransport) record(req *http.Request, key string, n int, u string, body []byte) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	// Cookies are session state, not data
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+
+	data, err := json.MarshalIndent(replayRecord{
+		Method:     req.Method,
+		URL:        u,
+		Body:       string(body),
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Response:   string(respBody),
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal replay record: %w", err)
+	}
+	if err := os.WriteFile(t.path(key, n), data, 0o644); err != nil {
+		return nil, fmt.Errorf("write replay record: %w", err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+// replay answers with the nth recorded exchange of key, or the last one recorded.
+func (t *ReplayTransport) replay(req *http.Request, key string, n int) (*http.Response, error) {
+	data, err := os.ReadFile(t.path(key, n))
+	for errors.Is(err, fs.ErrNotExist) && n > 0 {
+		n--
+		data, err = os.ReadFile(t.path(key, n))
+	}
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("no recorded response for %s %s (%s)", req.Method, req.URL.Path, key)
+		}
+		return nil, fmt.Errorf("read replay record: %w", err)
+	}
+
+	var record replayRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("decode replay record %s: %w", t.path(key, n), err)
+	}
+
+	return &http.Response{
+		Status:        strconv.Itoa(record.StatusCode) + " " + http.StatusText(record.StatusCode),
+		StatusCode:    record.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header(record.Header),
+		Body:          io.NopCloser(bytes.NewReader([]byte(record.Response))),
+		ContentLength: int64(len(record.Response)),
+		Request:       req,
+	}, nil
+}
+
+func (t *ReplayTransport) path(key string, n int) string {
+	return filepath.Join(t.dir, key+"-"+strconv.Itoa(n)+".json")
+}
+
+// replayKey identifies a request by its method, URL and body.
+func replayKey(method, u string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + u + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// redactedURL returns the path and query of req without the v1 API key.
+func redactedURL(req *http.Request) string {
+	query := req.URL.Query()
+	query.Del("apikey")
+	u := req.URL.Path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
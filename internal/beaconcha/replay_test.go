@@ -0,0 +1,90 @@
+package beaconcha
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplayTransport_RecordAndReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Set-Cookie", "session=secret")
+		fmt.Fprintf(w, `{"call":%d}`, calls)
+	}))
+	dir := t.TempDir()
+
+	get := func(rt http.RoundTripper, path string) (string, error) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	recorder, err := NewReplayTransport(dir, ReplayModeRecord, nil)
+	if err != nil {
+		t.Fatalf("NewReplayTransport failed: %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		body, err := get(recorder, "/api/v1/validator/1?apikey=secret")
+		if err != nil {
+			t.Fatalf("record request %d failed: %v", i, err)
+		}
+		if want := fmt.Sprintf(`{"call":%d}`, i); body != want {
+			t.Errorf("record request %d: expected %s, got %s", i, want, body)
+		}
+	}
+	server.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("expected 2 recorded files, got %d", len(files))
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "secret") {
+			t.Errorf("%s contains a secret: %s", file, data)
+		}
+	}
+
+	// A different API key must replay the same exchanges
+	player, err := NewReplayTransport(dir, ReplayModeReplay, nil)
+	if err != nil {
+		t.Fatalf("NewReplayTransport failed: %v", err)
+	}
+	for i, want := range []string{`{"call":1}`, `{"call":2}`, `{"call":2}`} {
+		body, err := get(player, "/api/v1/validator/1?apikey=other")
+		if err != nil {
+			t.Fatalf("replay request %d failed: %v", i+1, err)
+		}
+		if body != want {
+			t.Errorf("replay request %d: expected %s, got %s", i+1, want, body)
+		}
+	}
+
+	if _, err := get(player, "/api/v1/validator/2"); err == nil {
+		t.Error("expected an error for an unrecorded request")
+	}
+}
+
+func TestNewReplayTransport_InvalidMode(t *testing.T) {
+	if _, err := NewReplayTransport(t.TempDir(), "rewind", nil); err == nil {
+		t.Error("expected an error for an invalid mode")
+	}
+}
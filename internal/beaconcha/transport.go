@@ -0,0 +1,62 @@
+package beaconcha
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// TransportConfig configures the connections to the upstream, e.g. to go through
+// a TLS-intercepting corporate proxy.
+type TransportConfig struct {
+	ProxyURL            string // Proxy of all requests; empty honors HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	CAFile              string // PEM file of root CAs trusted besides the system roots
+	MaxIdleConns        int    // Idle connections kept across hosts; 0 means no limit
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int // 0 means no limit
+	IdleConnTimeout     time.Duration
+	KeepAlive           time.Duration // Interval of TCP keep-alive probes; negative disables them
+}
+
+// NewTransport creates the transport of upstream requests. It may be shared by
+// several clients, which then share its connection pool.
+func NewTransport(cfg TransportConfig) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	t.Proxy = http.ProxyFromEnvironment
+	if cfg.ProxyURL != "" {
+		proxy, err := url.Parse(cfg.ProxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", cfg.ProxyURL)
+		}
+		t.Proxy = http.ProxyURL(proxy)
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA file %s contains no PEM certificates", cfg.CAFile)
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: cfg.KeepAlive}
+	t.DialContext = dialer.DialContext
+	t.MaxIdleConns = cfg.MaxIdleConns
+	t.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	t.MaxConnsPerHost = cfg.MaxConnsPerHost
+	t.IdleConnTimeout = cfg.IdleConnTimeout
+	return t, nil
+}
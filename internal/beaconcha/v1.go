@@ -0,0 +1,298 @@
+package beaconcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/budget"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/tracing"
+)
+
+// API versions selectable with NewClient.
+const (
+	APIVersionV2 = "v2" // v2 with a fallback to v1 for validator overviews
+	APIVersionV1 = "v1" // v1 only for validator overviews
+)
+
+// v1PageSize is the maximum number of validators per v1 request.
+const v1PageSize = 100
+
+// v1FarFutureEpoch is the smallest epoch v1 uses for epochs that are not scheduled.
+const v1FarFutureEpoch = 1 << 62
+
+// withFallback runs the v2 request, or the v1 request when the client is set to v1
+// or v2 fails. Only v2 errors are logged when v1 succeeds.
+func (c *Client) withFallback(ctx context.Context, chain string, v2, v1 func() ([]models.BeaconchainValidatorData, error)) ([]models.BeaconchainValidatorData, error) {
+	if c.apiVersion == APIVersionV1 {
+		return v1()
+	}
+
+	data, err := v2()
+	if err == nil || ctx.Err() != nil || errors.Is(err, budget.ErrExhausted) {
+		return data, err
+	}
+
+	slog.Warn("beaconcha v2 request failed, falling back to v1", "chain", chain, "error", err)
+	data, v1Err := v1()
+	if v1Err != nil {
+		return nil, errors.Join(err, fmt.Errorf("v1 fallback: %w", v1Err))
+	}
+	return data, nil
+}
+
+// getValidatorsV1 fetches validators by index from GET /api/v1/validator/{indices}.
+func (c *Client) getValidatorsV1(ctx context.Context, chain string, validatorIds []int) ([]models.BeaconchainValidatorData, error) {
+	ids := make([]string, len(validatorIds))
+	for i, id := range validatorIds {
+		ids[i] = strconv.Itoa(id)
+	}
+	return c.getValidatorsV1ByIdentifier(ctx, chain, ids)
+}
+
+// getValidatorsV1ByIdentifier fetches validators by index or public key from
+// GET /api/v1/validator/{identifiers}.
+func (c *Client) getValidatorsV1ByIdentifier(ctx context.Context, chain string, identifiers []string) ([]models.BeaconchainValidatorData, error) {
+	var allData []models.BeaconchainValidatorData
+	for start := 0; start < len(identifiers); start += v1PageSize {
+		chunk := identifiers[start:min(start+v1PageSize, len(identifiers))]
+
+		var validators []models.BeaconchainV1Validator
+		if err := c.getV1(ctx, chain, "/api/v1/validator/"+strings.Join(chunk, ","), nil, &validators); err != nil {
+			return nil, fmt.Errorf("fetch validators: %w", err)
+		}
+		for _, v := range validators {
+			allData = append(allData, convertV1Validator(v))
+		}
+	}
+	return allData, nil
+}
+
+// getValidatorsByWithdrawalAddressV1 resolves the validators withdrawing to address
+// from GET /api/v1/validator/withdrawalCredentials/{address} and fetches them.
+func (c *Client) getValidatorsByWithdrawalAddressV1(ctx context.Context, chain, address string) ([]models.BeaconchainValidatorData, error) {
+	const limit = 200 // Max allowed by the v1 endpoint
+
+	var ids []int
+	for offset := 0; ; offset += limit {
+		query := url.Values{}
+		query.Set("limit", strconv.Itoa(limit))
+		query.Set("offset", strconv.Itoa(offset))
+
+		var refs []models.BeaconchainV1ValidatorRef
+		if err := c.getV1(ctx, chain, "/api/v1/validator/withdrawalCredentials/"+address, query, &refs); err != nil {
+			return nil, fmt.Errorf("fetch validators by withdrawal address: %w", err)
+		}
+		for _, ref := range refs {
+			ids = append(ids, ref.ValidatorIndex)
+		}
+		if len(refs) < limit {
+			break
+		}
+	}
+
+	return c.getValidatorsV1(ctx, chain, ids)
+}
+
+// GetNetworkSize fetches the active validator set of the network at the latest
+// epoch. v2 has no endpoint for it, so it always uses GET /api/v1/epoch/latest.
+func (c *Client) GetNetworkSize(ctx context.Context, chain string) (*models.BeaconchainNetworkSize, error) {
+	var epochs []models.BeaconchainV1Epoch
+	if err := c.getV1(ctx, chain, "/api/v1/epoch/latest", nil, &epochs); err != nil {
+		return nil, fmt.Errorf("fetch network size: %w", err)
+	}
+	if len(epochs) == 0 {
+		return nil, errors.New("fetch network size: no epoch returned")
+	}
+	e := epochs[0]
+	return &models.BeaconchainNetworkSize{
+		Epoch:                  e.Epoch,
+		ActiveValidators:       e.ValidatorsCount,
+		ActiveEffectiveBalance: gweiToWei(e.EligibleEther),
+	}, nil
+}
+
+// maxV1ExecutionBlocks is the number of blocks GET /api/v1/execution/block returns
+// at once.
+const maxV1ExecutionBlocks = 100
+
+// GetExecutionBlocks fetches execution layer blocks of the network by number,
+// leaving out those that do not exist. v2 only serves the blocks of given
+// validators, so it always uses GET /api/v1/execution/block/{numbers}, in
+// batches of maxV1ExecutionBlocks.
+func (c *Client) GetExecutionBlocks(ctx context.Context, chain string, blockNumbers []int64) ([]models.BeaconchainExecutionBlock, error) {
+	var result []models.BeaconchainExecutionBlock
+	for start := 0; start < len(blockNumbers); start += maxV1ExecutionBlocks {
+		end := min(start+maxV1ExecutionBlocks, len(blockNumbers))
+		numbers := make([]string, 0, end-start)
+		for _, n := range blockNumbers[start:end] {
+			numbers = append(numbers, strconv.FormatInt(n, 10))
+		}
+
+		var blocks []models.BeaconchainV1ExecutionBlock
+		if err := c.getV1(ctx, chain, "/api/v1/execution/block/"+strings.Join(numbers, ","), nil, &blocks); err != nil {
+			return nil, fmt.Errorf("fetch execution blocks: %w", err)
+		}
+		for _, b := range blocks {
+			block := models.BeaconchainExecutionBlock{
+				BlockNumber:    b.BlockNumber,
+				Slot:           b.PosConsensus.Slot,
+				ProducerReward: b.ProducerReward.String(),
+			}
+			if block.ProducerReward == "" {
+				block.ProducerReward = "0"
+			}
+			if b.Relay != nil && b.Relay.Tag != "" {
+				tag := b.Relay.Tag
+				block.Relay = &tag
+			}
+			result = append(result, block)
+		}
+	}
+	return result, nil
+}
+
+// convertV1Validator maps a v1 validator onto the v2 model. v1 has no separate
+// online flag, so it is derived from the status, and no queue position.
+func convertV1Validator(v models.BeaconchainV1Validator) models.BeaconchainValidatorData {
+	index := v.ValidatorIndex
+	data := models.BeaconchainValidatorData{
+		Validator: models.BeaconchainValidatorInfo{Index: &index, PublicKey: v.Pubkey},
+		Slashed:   v.Slashed,
+		Status:    v.Status,
+		LifeCycleEpochs: models.BeaconchainLifeCycleEpochs{
+			ActivationEligibility: v1Epoch(v.ActivationEligibilityEpoch),
+			Activation:            v1Epoch(v.ActivationEpoch),
+			Exit:                  v1Epoch(v.ExitEpoch),
+			Withdrawable:          v1Epoch(v.WithdrawableEpoch),
+		},
+		Balances: models.BeaconchainValidatorBalances{
+			Current:   gweiToWei(v.Balance),
+			Effective: gweiToWei(v.EffectiveBalance),
+		},
+		WithdrawalCredentials: convertV1WithdrawalCredentials(v.WithdrawalCredentials),
+	}
+
+	switch {
+	case strings.HasSuffix(v.Status, "_online"):
+		online := true
+		data.Online = &online
+	case strings.HasSuffix(v.Status, "_offline"):
+		online := false
+		data.Online = &online
+	}
+	return data
+}
+
+// convertV1WithdrawalCredentials splits hex encoded withdrawal credentials into
+// their prefix and, for execution credentials, the withdrawal address.
+func convertV1WithdrawalCredentials(creds string) models.BeaconchainWithdrawalCreds {
+	result := models.BeaconchainWithdrawalCreds{Credential: creds}
+	if len(creds) != 66 || !strings.HasPrefix(creds, "0x") {
+		return result
+	}
+
+	result.Prefix = creds[:4]
+	switch result.Prefix {
+	case "0x00":
+		result.Type = "bls"
+	case "0x01":
+		result.Type = "execution"
+	case "0x02":
+		result.Type = "compounding"
+	}
+	if result.Prefix == "0x01" || result.Prefix == "0x02" {
+		address := "0x" + creds[26:]
+		result.Address = &address
+	}
+	return result
+}
+
+// v1Epoch returns nil for epochs that are not scheduled.
+func v1Epoch(epoch int64) *int64 {
+	if epoch >= v1FarFutureEpoch {
+		return nil
+	}
+	return &epoch
+}
+
+// gweiToWei formats a gwei amount as a wei string, the unit v2 uses.
+func gweiToWei(gwei int64) string {
+	if gwei == 0 {
+		return "0"
+	}
+	return strconv.FormatInt(gwei, 10) + "000000000"
+}
+
+// v1BaseURL returns the base URL of the v1 API for chain. Unlike v2, which selects
+// the chain in the request, v1 is served per network from a subdomain of
+// beaconcha.in. Other base URLs, such as proxies, are used as is.
+func (c *Client) v1BaseURL(chain string) string {
+	u, err := url.Parse(c.baseURL)
+	if err != nil || chain == "" || chain == "mainnet" || u.Host != "beaconcha.in" {
+		return c.baseURL
+	}
+	u.Host = chain + "." + u.Host
+	return u.String()
+}
+
+// getV1 fetches the v1 endpoint at path and decodes the data of the response into
+// out. Single results are decoded as one-element arrays when out is a slice.
+func (c *Client) getV1(ctx context.Context, chain, path string, query url.Values, out any) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "beaconcha GET "+path)
+	defer func() { span.End(err != nil) }()
+
+	if query == nil {
+		query = url.Values{}
+	}
+	if c.apiKey != "" {
+		query.Set("apikey", c.apiKey)
+	}
+	u := c.v1BaseURL(chain) + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	tracing.Inject(ctx, req.Header)
+
+	slog.Debug("beaconcha request", "method", "GET", "endpoint", path)
+
+	resp, body, err := c.doRequestWithRetry(ctx, req, nil, 3)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("beaconcha returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response models.BeaconchainV1Response
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if response.Status != "OK" {
+		return fmt.Errorf("beaconcha returned status %q", response.Status)
+	}
+
+	data := response.Data
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") {
+		data = json.RawMessage("[" + trimmed + "]")
+	} else if trimmed == "" || trimmed == "null" {
+		data = json.RawMessage("[]")
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package beaconcha
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ratelimiter"
+)
+
+// v1Validator is a v1 response for a single active validator, which v1 returns
+// as an object rather than an array.
+const v1Validator = `{"status":"OK","data":{
+	"validatorindex":1,"pubkey":"0xa1d1","status":"active_online","slashed":false,
+	"balance":32001000000,"effectivebalance":32000000000,
+	"activationeligibilityepoch":0,"activationepoch":0,
+	"exitepoch":9223372036854775807,"withdrawableepoch":9223372036854775807,
+	"withdrawalcredentials":"0x010000000000000000000000d8da6bf26964af9d7eed9e03e53415d37aa96045"}}`
+
+func TestClient_GetValidators_V1(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiVersion string
+		v2Status   int
+		wantV2     bool
+	}{
+		{name: "fallback after v2 error", apiVersion: APIVersionV2, v2Status: http.StatusInternalServerError, wantV2: true},
+		{name: "v1 only", apiVersion: APIVersionV1, v2Status: http.StatusOK, wantV2: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v2Calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v2/ethereum/validators":
+					v2Calls++
+					w.WriteHeader(tt.v2Status)
+					w.Write([]byte(`{"data":[]}`))
+				case "/api/v1/validator/1":
+					if r.URL.Query().Get("apikey") != "key" {
+						t.Errorf("expected api key in query, got %q", r.URL.RawQuery)
+					}
+					w.Write([]byte(v1Validator))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, "key", tt.apiVersion, ratelimiter.NewGlobalRateLimiter(time.Millisecond), 5*time.Second)
+			validators, err := c.GetValidators(context.Background(), "mainnet", []int{1})
+			if err != nil {
+				t.Fatalf("GetValidators failed: %v", err)
+			}
+			if (v2Calls > 0) != tt.wantV2 {
+				t.Errorf("expected v2 called: %v, got %d calls", tt.wantV2, v2Calls)
+			}
+			if len(validators) != 1 {
+				t.Fatalf("expected 1 validator, got %d", len(validators))
+			}
+
+			v := validators[0]
+			if v.Validator.Index == nil || *v.Validator.Index != 1 {
+				t.Errorf("unexpected index %v", v.Validator.Index)
+			}
+			if v.Balances.Current != "32001000000000000000" {
+				t.Errorf("expected balance in wei, got %s", v.Balances.Current)
+			}
+			if v.Online == nil || !*v.Online {
+				t.Errorf("expected online, got %v", v.Online)
+			}
+			if v.LifeCycleEpochs.Exit != nil {
+				t.Errorf("expected unscheduled exit, got %d", *v.LifeCycleEpochs.Exit)
+			}
+			creds := v.WithdrawalCredentials
+			if creds.Prefix != "0x01" || creds.Address == nil || *creds.Address != "0xd8da6bf26964af9d7eed9e03e53415d37aa96045" {
+				t.Errorf("unexpected withdrawal credentials %+v", creds)
+			}
+		})
+	}
+}
+
+func TestClient_V1BaseURL(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		chain    string
+		expected string
+	}{
+		{"https://beaconcha.in", "mainnet", "https://beaconcha.in"},
+		{"https://beaconcha.in", "hoodi", "https://hoodi.beaconcha.in"},
+		{"http://proxy.local:8080", "hoodi", "http://proxy.local:8080"},
+	}
+
+	for _, tt := range tests {
+		c := NewClient(tt.baseURL, "", APIVersionV1, nil, time.Second)
+		if got := c.v1BaseURL(tt.chain); got != tt.expected {
+			t.Errorf("v1BaseURL(%q) for %s = %s, expected %s", tt.chain, tt.baseURL, got, tt.expected)
+		}
+	}
+}
+
+func TestClient_GetNetworkSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/epoch/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"status":"OK","data":{"epoch":350000,"validatorscount":1000000,"eligibleether":32000000000000000}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "key", APIVersionV2, ratelimiter.NewGlobalRateLimiter(time.Millisecond), 5*time.Second)
+	size, err := c.GetNetworkSize(context.Background(), "mainnet")
+	if err != nil {
+		t.Fatalf("GetNetworkSize failed: %v", err)
+	}
+	if size.Epoch != 350000 || size.ActiveValidators != 1000000 || size.ActiveEffectiveBalance != "32000000000000000000000000" {
+		t.Errorf("unexpected network size %+v", size)
+	}
+}
+
+func TestClient_GetExecutionBlocks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/execution/block/200,100" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"status":"OK","data":[
+			{"blockNumber":200,"producerReward":12000000000000000000,"relay":{"tag":"flashbots"},"posConsensus":{"slot":300}},
+			{"blockNumber":100,"producerReward":35000000000000000,"relay":null,"posConsensus":{"slot":190}}]}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "key", APIVersionV2, ratelimiter.NewGlobalRateLimiter(time.Millisecond), 5*time.Second)
+	blocks, err := c.GetExecutionBlocks(context.Background(), "mainnet", []int64{200, 100})
+	if err != nil {
+		t.Fatalf("GetExecutionBlocks failed: %v", err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %d", len(blocks))
+	}
+	// Rewards above int64 are kept exact
+	if b := blocks[0]; b.ProducerReward != "12000000000000000000" || b.Slot != 300 || b.Relay == nil || *b.Relay != "flashbots" {
+		t.Errorf("unexpected relayed block %+v", b)
+	}
+	if b := blocks[1]; b.ProducerReward != "35000000000000000" || b.Relay != nil {
+		t.Errorf("unexpected local block %+v", b)
+	}
+}
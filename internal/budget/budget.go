@@ -0,0 +1,147 @@
+// Package budget limits the Beaconcha API credits spent per UTC day, so that a
+// runaway client cannot exhaust the monthly plan.
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// ErrExhausted is returned when an upstream request would exceed the daily budget.
+var ErrExhausted = errors.New("daily upstream credit budget exhausted")
+
+// DefaultCost is the number of credits charged for a request to an endpoint
+// without a configured cost.
+const DefaultCost = 1
+
+// Manager charges upstream requests against a daily credit budget. It is safe
+// for concurrent use. A nil Manager imposes no budget.
+type Manager struct {
+	daily   int64
+	reserve int64
+	costs   map[string]int64
+	now     func() time.Time
+
+	mu        sync.Mutex
+	day       time.Time // Start of the UTC day being counted
+	spent     int64
+	refused   int64
+	endpoints map[string]models.EndpointUsage
+}
+
+// NewManager creates a manager allowing daily credits per UTC day. Once less than
+// the reserve fraction of them remains, Low reports true. costs overrides the
+// credits charged per endpoint.
+func NewManager(daily int64, reserve float64, costs map[string]int64) *Manager {
+	return &Manager{
+		daily:     daily,
+		reserve:   int64(float64(daily) * reserve),
+		costs:     costs,
+		now:       time.Now,
+		endpoints: make(map[string]models.EndpointUsage),
+	}
+}
+
+// Charge records a request to endpoint, or returns ErrExhausted without recording
+// it if its cost would exceed the credits remaining today.
+func (m *Manager) Charge(endpoint string) error {
+	if m == nil {
+		return nil
+	}
+	cost, ok := m.costs[endpoint]
+	if !ok {
+		cost = DefaultCost
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rollover()
+
+	if m.spent+cost > m.daily {
+		m.refused++
+		return fmt.Errorf("%w: %d of %d credits spent", ErrExhausted, m.spent, m.daily)
+	}
+	m.spent += cost
+	usage := m.endpoints[endpoint]
+	usage.Requests++
+	usage.Credits += cost
+	m.endpoints[endpoint] = usage
+	return nil
+}
+
+// Low reports whether the credits remaining today have fallen below the reserve.
+func (m *Manager) Low() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rollover()
+	return m.daily-m.spent < m.reserve || m.spent >= m.daily
+}
+
+// Usage returns the credits spent today.
+func (m *Manager) Usage() models.UsageResponse {
+	if m == nil {
+		return models.UsageResponse{Endpoints: map[string]models.EndpointUsage{}}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rollover()
+
+	endpoints := make(map[string]models.EndpointUsage, len(m.endpoints))
+	for name, usage := range m.endpoints {
+		endpoints[name] = usage
+	}
+	return models.UsageResponse{
+		Enabled:      true,
+		DailyCredits: m.daily,
+		Spent:        m.spent,
+		Remaining:    m.daily - m.spent,
+		Low:          m.daily-m.spent < m.reserve || m.spent >= m.daily,
+		Refused:      m.refused,
+		ResetsAt:     m.day.AddDate(0, 0, 1),
+		Endpoints:    endpoints,
+	}
+}
+
+// rollover starts counting a new day once the current one has passed. The
+// caller must hold m.mu.
+func (m *Manager) rollover() {
+	today := m.now().UTC().Truncate(24 * time.Hour)
+	if today.Equal(m.day) {
+		return
+	}
+	m.day = today
+	m.spent = 0
+	m.refused = 0
+	m.endpoints = make(map[string]models.EndpointUsage)
+}
+
+// ParseCosts parses per-endpoint costs in the form
+// "validators/rewards-aggregate=2,validators/balance-history=5".
+func ParseCosts(s string) (map[string]int64, error) {
+	costs := make(map[string]int64)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		endpoint, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid cost %q: expected endpoint=credits", entry)
+		}
+		cost, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || cost < 0 {
+			return nil, fmt.Errorf("invalid cost %q: credits must be a non-negative integer", entry)
+		}
+		costs[strings.TrimSpace(endpoint)] = cost
+	}
+	return costs, nil
+}
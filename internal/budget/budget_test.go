@@ -0,0 +1,92 @@
+package budget
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestManager_Charge(t *testing.T) {
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	m := NewManager(10, 0.3, map[string]int64{"validators/balance-history": 4})
+	m.now = func() time.Time { return now }
+
+	for _, endpoint := range []string{"validators", "validators/balance-history"} {
+		if err := m.Charge(endpoint); err != nil {
+			t.Fatalf("charge %s failed: %v", endpoint, err)
+		}
+	}
+	if m.Low() {
+		t.Error("expected budget not low with 5 of 10 credits left")
+	}
+
+	if err := m.Charge("validators/balance-history"); err != nil {
+		t.Fatalf("charge failed: %v", err)
+	}
+	if !m.Low() {
+		t.Error("expected budget low with 1 of 10 credits left")
+	}
+	if err := m.Charge("validators/balance-history"); !errors.Is(err, ErrExhausted) {
+		t.Errorf("expected ErrExhausted, got %v", err)
+	}
+
+	usage := m.Usage()
+	if usage.Spent != 9 || usage.Remaining != 1 || usage.Refused != 1 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+	if got := usage.Endpoints["validators/balance-history"]; got.Requests != 2 || got.Credits != 8 {
+		t.Errorf("unexpected endpoint usage: %+v", got)
+	}
+	if want := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC); !usage.ResetsAt.Equal(want) {
+		t.Errorf("expected reset at %s, got %s", want, usage.ResetsAt)
+	}
+
+	// A new UTC day starts with the full budget
+	now = now.Add(2 * time.Hour)
+	if err := m.Charge("validators/balance-history"); err != nil {
+		t.Errorf("charge after rollover failed: %v", err)
+	}
+	if usage := m.Usage(); usage.Spent != 4 || usage.Refused != 0 {
+		t.Errorf("unexpected usage after rollover: %+v", usage)
+	}
+}
+
+func TestManager_Nil(t *testing.T) {
+	var m *Manager
+	if err := m.Charge("validators"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if m.Low() || m.Usage().Enabled {
+		t.Error("expected a nil manager to impose no budget")
+	}
+}
+
+func TestParseCosts(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    map[string]int64
+		wantErr bool
+	}{
+		{input: "", want: map[string]int64{}},
+		{input: "validators=2, validators/balance-history=5", want: map[string]int64{"validators": 2, "validators/balance-history": 5}},
+		{input: "validators", wantErr: true},
+		{input: "validators=-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseCosts(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error: %v, got %v", tt.wantErr, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("expected %s=%d, got %d", k, v, got[k])
+				}
+			}
+		})
+	}
+}
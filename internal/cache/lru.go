@@ -0,0 +1,147 @@
+// Package cache provides an in-memory cache bounded by its number of entries and
+// their total size, evicting the least recently used entries first.
+package cache
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// LRU is a least recently used cache keyed by string. It is safe for concurrent use.
+type LRU[V any] struct {
+	maxEntries int
+	maxBytes   int64
+	size       func(V) int64
+
+	mu        sync.Mutex
+	order     *list.List // Most recently used first
+	items     map[string]*list.Element
+	bytes     int64
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+// entry is a cached value and its size.
+type entry[V any] struct {
+	key   string
+	value V
+	size  int64
+}
+
+// New creates a cache holding at most maxEntries values of at most maxBytes in
+// total, as measured by size. A limit of 0 disables it.
+func New[V any](maxEntries int, maxBytes int64, size func(V) int64) *LRU[V] {
+	return &LRU[V]{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		size:       size,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value of key and marks it as recently used.
+func (c *LRU[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses++
+		var zero V
+		return zero, false
+	}
+	c.hits++
+	c.order.MoveToFront(el)
+	return el.Value.(*entry[V]).value, true
+}
+
+// Add sets the value of key, evicting the least recently used values until the
+// cache is within its limits. A value larger than maxBytes is not cached.
+func (c *LRU[V]) Add(key string, value V) {
+	var size int64
+	if c.size != nil {
+		size = c.size(value)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[V]{key: key, value: value, size: size})
+	c.bytes += size
+	for c.overLimit() {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
+// RemoveFunc removes the values for which fn returns true, e.g. expired ones.
+// Removals are not counted as evictions.
+func (c *LRU[V]) RemoveFunc(fn func(key string, value V) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, el := range c.items {
+		if fn(key, el.Value.(*entry[V]).value) {
+			c.remove(el)
+		}
+	}
+}
+
+// Item is a cached value and its key.
+type Item[V any] struct {
+	Key   string
+	Value V
+}
+
+// Items returns the cached values, least recently used first, so adding them in
+// order to another cache restores their recency.
+func (c *LRU[V]) Items() []Item[V] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	items := make([]Item[V], 0, len(c.items))
+	for el := c.order.Back(); el != nil; el = el.Prev() {
+		e := el.Value.(*entry[V])
+		items = append(items, Item[V]{Key: e.key, Value: e.value})
+	}
+	return items
+}
+
+// Stats returns the size, limits and counters of the cache.
+func (c *LRU[V]) Stats() models.CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return models.CacheStats{
+		Entries:    len(c.items),
+		Bytes:      c.bytes,
+		MaxEntries: c.maxEntries,
+		MaxBytes:   c.maxBytes,
+		Hits:       c.hits,
+		Misses:     c.misses,
+		Evictions:  c.evictions,
+	}
+}
+
+// overLimit reports whether the cache exceeds one of its limits. Callers must hold c.mu.
+func (c *LRU[V]) overLimit() bool {
+	return (c.maxEntries > 0 && len(c.items) > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes)
+}
+
+// remove drops el from the cache. Callers must hold c.mu.
+func (c *LRU[V]) remove(el *list.Element) {
+	e := c.order.Remove(el).(*entry[V])
+	delete(c.items, e.key)
+	c.bytes -= e.size
+}
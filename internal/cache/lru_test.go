@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLRU(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxEntries int
+		maxBytes   int64
+		adds       []string
+		get        string // Marked as used before the last add
+		want       []string
+		evictions  int64
+	}{
+		{
+			name:       "entry limit evicts least recently used",
+			maxEntries: 2,
+			adds:       []string{"a", "b", "c"},
+			want:       []string{"b", "c"},
+			evictions:  1,
+		},
+		{
+			name:       "get marks as recently used",
+			maxEntries: 2,
+			adds:       []string{"a", "b", "c"},
+			get:        "a",
+			want:       []string{"a", "c"},
+			evictions:  1,
+		},
+		{
+			name:      "byte limit",
+			maxBytes:  5,
+			adds:      []string{"aa", "bb", "cc"},
+			want:      []string{"bb", "cc"},
+			evictions: 1,
+		},
+		{
+			name:     "value larger than the byte limit is not cached",
+			maxBytes: 3,
+			adds:     []string{"a", "bbbb"},
+			want:     []string{"a"},
+		},
+		{
+			name: "no limits",
+			adds: []string{"a", "b", "c"},
+			want: []string{"a", "b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(tt.maxEntries, tt.maxBytes, func(v string) int64 { return int64(len(v)) })
+			for i, key := range tt.adds {
+				if i == len(tt.adds)-1 && tt.get != "" {
+					c.Get(tt.get)
+				}
+				c.Add(key, key)
+			}
+
+			stats := c.Stats()
+			if stats.Entries != len(tt.want) {
+				t.Errorf("expected %d entries, got %d", len(tt.want), stats.Entries)
+			}
+			var bytes int64
+			for _, key := range tt.want {
+				if v, ok := c.Get(key); !ok || v != key {
+					t.Errorf("expected %q to be cached", key)
+				}
+				bytes += int64(len(key))
+			}
+			if stats.Bytes != bytes {
+				t.Errorf("expected %d bytes, got %d", bytes, stats.Bytes)
+			}
+			if stats.Evictions != tt.evictions {
+				t.Errorf("expected %d evictions, got %d", tt.evictions, stats.Evictions)
+			}
+		})
+	}
+}
+
+func TestLRU_Items(t *testing.T) {
+	c := New(0, 0, func(v int) int64 { return 8 })
+	for i, key := range []string{"a", "b", "c"} {
+		c.Add(key, i)
+	}
+	c.Get("a")
+
+	var keys []string
+	for _, item := range c.Items() {
+		keys = append(keys, item.Key)
+	}
+	if want := []string{"b", "c", "a"}; !slices.Equal(keys, want) {
+		t.Errorf("expected %v, got %v", want, keys)
+	}
+}
+
+func TestLRU_RemoveFunc(t *testing.T) {
+	c := New(0, 0, func(v int) int64 { return 8 })
+	for i, key := range []string{"a", "b", "c"} {
+		c.Add(key, i)
+	}
+	c.Add("a", 3) // Replaces the value
+
+	c.RemoveFunc(func(key string, v int) bool { return v < 2 })
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be removed")
+	}
+	if v, ok := c.Get("a"); !ok || v != 3 {
+		t.Errorf("expected a to be 3, got %d", v)
+	}
+	stats := c.Stats()
+	if stats.Entries != 2 || stats.Bytes != 16 || stats.Evictions != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("expected 1 hit and 1 miss, got %d and %d", stats.Hits, stats.Misses)
+	}
+}
@@ -0,0 +1,103 @@
+// Package chainspec describes the beacon chain timing of the supported chains and
+// signals epoch boundaries, when new validator data becomes available upstream.
+package chainspec
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Spec holds the timing parameters of a beacon chain.
+type Spec struct {
+	GenesisTime    time.Time
+	SecondsPerSlot int64
+	SlotsPerEpoch  int64
+}
+
+// specs holds the spec of each supported chain.
+var specs = map[string]Spec{
+	"mainnet": {GenesisTime: time.Unix(1606824023, 0), SecondsPerSlot: 12, SlotsPerEpoch: 32},
+	"hoodi":   {GenesisTime: time.Unix(1742213400, 0), SecondsPerSlot: 12, SlotsPerEpoch: 32},
+}
+
+// ForChain returns the spec of chain.
+func ForChain(chain string) (Spec, error) {
+	spec, ok := specs[chain]
+	if !ok {
+		return Spec{}, fmt.Errorf("unknown chain %q", chain)
+	}
+	return spec, nil
+}
+
+// EpochDuration returns the duration of one epoch.
+func (s Spec) EpochDuration() time.Duration {
+	return time.Duration(s.SecondsPerSlot*s.SlotsPerEpoch) * time.Second
+}
+
+// EpochStart returns when epoch starts, which is also when the previous epoch completes.
+func (s Spec) EpochStart(epoch int64) time.Time {
+	return s.GenesisTime.Add(time.Duration(epoch) * s.EpochDuration())
+}
+
+// LastCompletedEpoch returns the most recent epoch that had fully elapsed at now.
+func (s Spec) LastCompletedEpoch(now time.Time) (int64, error) {
+	elapsed := now.Sub(s.GenesisTime)
+	if elapsed < s.EpochDuration() {
+		return 0, fmt.Errorf("no epoch completed yet at %s", now.Format(time.RFC3339))
+	}
+	return int64(elapsed/s.EpochDuration()) - 1, nil
+}
+
+// LastCompletedEpoch returns the most recent epoch of chain that had fully elapsed at now.
+func LastCompletedEpoch(chain string, now time.Time) (int64, error) {
+	spec, err := ForChain(chain)
+	if err != nil {
+		return 0, err
+	}
+	return spec.LastCompletedEpoch(now)
+}
+
+// EpochTicker sends the number of each newly completed epoch, delay after its end,
+// until ctx is done. The delay leaves upstream time to process the epoch.
+//
+// The epoch is derived from the clock when the ticker wakes up, so after a stall
+// (e.g. the machine sleeping) only the latest completed epoch is sent rather than
+// every missed one.
+func (s Spec) EpochTicker(ctx context.Context, delay time.Duration) <-chan int64 {
+	ch := make(chan int64)
+
+	go func() {
+		defer close(ch)
+
+		last := int64(-1)
+		if epoch, err := s.LastCompletedEpoch(time.Now().Add(-delay)); err == nil {
+			last = epoch
+		}
+
+		for {
+			wake := s.EpochStart(last + 2).Add(delay)
+			timer := time.NewTimer(time.Until(wake))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			epoch, err := s.LastCompletedEpoch(time.Now().Add(-delay))
+			if err != nil || epoch <= last {
+				continue
+			}
+			last = epoch
+
+			select {
+			case ch <- epoch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
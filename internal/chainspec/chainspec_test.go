@@ -0,0 +1,67 @@
+package chainspec
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLastCompletedEpoch(t *testing.T) {
+	spec, _ := ForChain("mainnet")
+	genesis := spec.GenesisTime
+	epoch := spec.EpochDuration()
+
+	tests := []struct {
+		name    string
+		chain   string
+		now     time.Time
+		want    int64
+		wantErr bool
+	}{
+		{name: "first epoch completed", chain: "mainnet", now: genesis.Add(epoch), want: 0},
+		{name: "mid epoch", chain: "mainnet", now: genesis.Add(10*epoch + time.Minute), want: 9},
+		{name: "before first epoch", chain: "mainnet", now: genesis.Add(time.Minute), wantErr: true},
+		{name: "unknown chain", chain: "sepolia", now: time.Now(), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LastCompletedEpoch(tt.chain, tt.now)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected epoch %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEpochTicker(t *testing.T) {
+	// One second epochs, halfway through epoch 1
+	spec := Spec{GenesisTime: time.Now().Add(-1500 * time.Millisecond), SecondsPerSlot: 1, SlotsPerEpoch: 1}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ticks := spec.EpochTicker(ctx, 10*time.Millisecond)
+
+	// Epoch 0 completed before the ticker started, so the first tick is epoch 1
+	if epoch := <-ticks; epoch != 1 {
+		t.Errorf("expected first tick for epoch 1, got %d", epoch)
+	}
+	if epoch := <-ticks; epoch != 2 {
+		t.Errorf("expected second tick for epoch 2, got %d", epoch)
+	}
+
+	cancel()
+	if _, ok := <-ticks; ok {
+		t.Error("expected the ticker to close after cancellation")
+	}
+}
@@ -3,47 +3,549 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/budget"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/price"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/relays"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/service"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ssv"
 )
 
+// chains are the chains the API serves.
+var chains = []string{"mainnet", "hoodi"}
+
+// ChainUpstream is the Beaconcha upstream serving a chain.
+type ChainUpstream struct {
+	BaseURL   string
+	APIKey    string
+	RateLimit time.Duration
+}
+
 // Config holds all configuration values for the application.
 type Config struct {
 	// Server configuration
 	Port               string
+	Addr               string // Listen address: host:port or unix:/path/to.sock
 	ServerWriteTimeout time.Duration
 	ServerReadTimeout  time.Duration
 	ServerIdleTimeout  time.Duration
+	// How long shutdown waits for in-flight requests, queued upstream work and
+	// background jobs before flushing state and exiting
+	ShutdownDrainTimeout time.Duration
 
 	// Beaconcha API configuration
-	BeaconchainBaseURL   string
-	BeaconchainAPIKey    string
-	BeaconchainRateLimit time.Duration
-	BeaconchainTimeout   time.Duration
+	BeaconchainBaseURL    string
+	BeaconchainAPIKey     string
+	BeaconchainAPIVersion string // v2 (falls back to v1 for validator overviews) or v1
+	BeaconchainRateLimit  time.Duration
+	BeaconchainTimeout    time.Duration
+	BeaconchaReplayDir    string // Record or replay upstream traffic here; empty disables
+	BeaconchaReplayMode   string // record or replay
+	// Connections to the upstream, e.g. through a TLS-intercepting proxy
+	BeaconchainProxyURL            string // Empty honors HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	BeaconchainCAFile              string // PEM root CAs trusted besides the system roots
+	BeaconchainMaxIdleConns        int
+	BeaconchainMaxIdleConnsPerHost int
+	BeaconchainMaxConnsPerHost     int // 0 disables the limit
+	BeaconchainIdleConnTimeout     time.Duration
+	BeaconchainKeepAlive           time.Duration // Negative disables TCP keep-alives
+	// Upstream of each chain, defaulting to the values above. Chains with the same
+	// base URL and API key share a client and rate limit
+	BeaconchainChains map[string]ChainUpstream
+
+	// Upstream credit budget
+	BeaconchainDailyCredits  int              // Credits per UTC day; 0 disables the budget
+	BeaconchainCreditReserve float64          // Fraction of the credits below which cached data is preferred
+	BeaconchainCreditCosts   map[string]int64 // Credits per endpoint, by default 1
 
 	// Request validation
 	MaxValidatorIDs int
+	// Longest a GET /validator request may wait for the queue and upstream before it
+	// returns partial data; also the timeout of requests that do not set one. 0
+	// disables the limit
+	MaxRequestTimeout time.Duration
+
+	// How long responses to requests with an Idempotency-Key are replayed; 0 disables it
+	IdempotencyWindow time.Duration
+
+	// In-memory caches of validator responses and balance histories
+	CacheMaxEntries int // Entries per cache; 0 disables the limit
+	CacheMaxBytes   int // Approximate bytes per cache; 0 disables the limit
+	// How often the caches are saved to DATA_DIR to survive restarts; 0 disables it
+	CacheSnapshotInterval time.Duration
+	// Ranges of every portfolio fetched in the background on startup; empty disables warming
+	CacheWarmRanges []string
+
+	// Snapshot history
+	DataDir               string
+	HistoryDatabaseURL    string // PostgreSQL database of the snapshot history only, shared between instances; empty keeps it in DataDir
+	MigrateOnStart        bool   // Apply pending history store migrations on startup instead of requiring --migrate
+	StateImportMaxBytes   int64  // Largest archive POST /admin/import accepts
+	AdminToken            string // Token GET /admin/export and POST /admin/import require; empty disables them
+	ParquetExportDir      string
+	ParquetExportInterval time.Duration
+	BackfillDays          int // Days of history backfilled for portfolio validators; 0 disables it
+	BackfillInterval      time.Duration
+
+	// InfluxDB v2 the snapshots of every fetch are written to; an empty URL disables it
+	InfluxDBURL    string
+	InfluxDBToken  string
+	InfluxDBOrg    string
+	InfluxDBBucket string
+
+	// Cold object storage for aged history and exports
+	ColdStorageEndpoint        string
+	ColdStorageBucket          string
+	ColdStorageRegion          string
+	ColdStorageAccessKey       string
+	ColdStorageSecretKey       string
+	ColdStorageArchiveInterval time.Duration
+	LocalRetentionMonths       int
+
+	// Fiat prices
+	PriceProviders      []string
+	PriceCacheTTL       time.Duration
+	PriceMaxStaleness   time.Duration
+	PriceTimeout        time.Duration
+	CoinGeckoBaseURL    string
+	CoinGeckoAPIKey     string
+	KrakenBaseURL       string
+	CoinbaseBaseURL     string
+	ExecutionRPCURL     string
+	ChainlinkETHUSDFeed string
+	PriceStaticPrices   map[string]float64       // Prices of the static provider by currency
+	PriceRateLimits     map[string]time.Duration // Minimum interval between calls by provider
+
+	// ENS names of withdrawal addresses, resolved through EXECUTION_RPC_URL
+	ENSCacheTTL time.Duration
+
+	// EigenPods among withdrawal addresses, detected through EXECUTION_RPC_URL
+	RestakingCacheTTL time.Duration
+
+	// MEV-boost relays validator registrations are checked at; none disables checks
+	MEVRelays             []relays.Relay
+	MEVRelayCacheTTL      time.Duration
+	MEVRegistrationMaxAge time.Duration // 0 never flags registrations as stale
+
+	// Windows of the uptime in performance responses and the default of GET /sla
+	SLAWindows []string
+
+	// Weights of the components of the validator health score
+	HealthWeights map[string]float64
+
+	// Detection of validators whose reward rate deviates, from recorded balances
+	RewardAnomalyWindow    time.Duration // 0 disables detection
+	RewardAnomalyBaseline  time.Duration
+	RewardAnomalyThreshold float64 // In standard deviations
+
+	// Known network incidents excluded from testnet aggregates
+	AnomalyWindowsFile string
+
+	// Network client shares fleets are compared against
+	ClientDiversityFile string
+
+	// Named validator sets shown on the dashboard
+	PortfoliosFile        string
+	RegistrySyncInterval  time.Duration
+	KeystoreScanInterval  time.Duration
+	SSVAPIURL             string
+	SSVSyncInterval       time.Duration
+	ClientMetricsInterval time.Duration
+
+	// Alert rules checked against portfolios
+	AlertRulesFile     string
+	AlertCheckInterval time.Duration
+
+	// Scans of portfolio attestations for doppelgangers, 0 disables them
+	DoppelgangerCheckInterval time.Duration
+
+	// Metrics pushed to Prometheus after each refresh, for a Prometheus that cannot
+	// scrape the service; both URLs empty disables pushing
+	MetricsPushgatewayURL string
+	MetricsRemoteWriteURL string
+	MetricsPushJob        string
+	MetricsPushInterval   time.Duration
+
+	// Validator states and events published to an MQTT broker for home automation;
+	// an empty URL disables publishing
+	MQTTBrokerURL       string
+	MQTTTopicPrefix     string
+	MQTTPublishInterval time.Duration
+
+	// Dead man's switch pinged while the fleet is healthy, e.g. a healthchecks.io
+	// check; an empty URL disables it
+	HeartbeatURL        string
+	HeartbeatFailURL    string
+	HeartbeatInterval   time.Duration
+	HeartbeatMaxOffline int
+
+	// Recurring portfolio reports
+	ReportSchedulesFile string
+
+	// Operators served with isolated data, each with its own API key
+	TenantsFile string
+
+	// Income reconciliation
+	MaxReconciliationIDs        int
+	ReconciliationToleranceGwei int
+
+	// Background jobs for reports too large for a single request
+	MaxReportValidatorIDs int           // Validators per report job
+	MaxQueuedJobs         int           // Jobs waiting to run
+	JobRetention          time.Duration // How long finished jobs and their results are kept
+
+	// Request tracing
+	TraceSampler     string  // always, ratio or errors-only
+	TraceSampleRatio float64 // Fraction of traces recorded in ratio mode
 }
 
 // Load reads configuration from environment variables with sensible defaults.
 func Load() (*Config, error) {
 	cfg := &Config{
-		Port:                 getEnv("PORT", "8080"),
-		ServerWriteTimeout:   getDurationEnv("SERVER_WRITE_TIMEOUT", 60*time.Second),
-		ServerReadTimeout:    getDurationEnv("SERVER_READ_TIMEOUT", 15*time.Second),
-		ServerIdleTimeout:    getDurationEnv("SERVER_IDLE_TIMEOUT", 120*time.Second),
-		BeaconchainBaseURL:   getEnv("BEACONCHAIN_BASE_URL", "https://beaconcha.in"),
-		BeaconchainAPIKey:    getEnv("BEACONCHAIN_API_KEY", ""),
-		BeaconchainRateLimit: getDurationEnv("BEACONCHAIN_RATE_LIMIT", time.Second), // 1 req/sec
-		BeaconchainTimeout:   getDurationEnv("BEACONCHAIN_TIMEOUT", 60*time.Second),
-		MaxValidatorIDs:      getIntEnv("MAX_VALIDATOR_IDS", 100),
+		Port:                  getEnv("PORT", "8080"),
+		Addr:                  getEnv("ADDR", ""),
+		ServerWriteTimeout:    getDurationEnv("SERVER_WRITE_TIMEOUT", 60*time.Second),
+		ServerReadTimeout:     getDurationEnv("SERVER_READ_TIMEOUT", 15*time.Second),
+		ServerIdleTimeout:     getDurationEnv("SERVER_IDLE_TIMEOUT", 120*time.Second),
+		ShutdownDrainTimeout:  getDurationEnv("SHUTDOWN_DRAIN_TIMEOUT", 30*time.Second),
+		BeaconchainBaseURL:    getEnv("BEACONCHAIN_BASE_URL", "https://beaconcha.in"),
+		BeaconchainAPIKey:     getEnv("BEACONCHAIN_API_KEY", ""),
+		BeaconchainAPIVersion: getEnv("BEACONCHAIN_API_VERSION", "v2"),
+		BeaconchainRateLimit:  getDurationEnv("BEACONCHAIN_RATE_LIMIT", time.Second), // 1 req/sec
+		BeaconchainTimeout:    getDurationEnv("BEACONCHAIN_TIMEOUT", 60*time.Second),
+		BeaconchaReplayDir:    getEnv("BEACONCHA_REPLAY_DIR", ""),
+		BeaconchaReplayMode:   getEnv("BEACONCHA_REPLAY_MODE", "replay"),
+
+		BeaconchainProxyURL:            getEnv("BEACONCHAIN_PROXY_URL", ""),
+		BeaconchainCAFile:              getEnv("BEACONCHAIN_CA_FILE", ""),
+		BeaconchainMaxIdleConns:        getIntEnv("BEACONCHAIN_MAX_IDLE_CONNS", 100),
+		BeaconchainMaxIdleConnsPerHost: getIntEnv("BEACONCHAIN_MAX_IDLE_CONNS_PER_HOST", 10),
+		BeaconchainMaxConnsPerHost:     getIntEnv("BEACONCHAIN_MAX_CONNS_PER_HOST", 0),
+		BeaconchainIdleConnTimeout:     getDurationEnv("BEACONCHAIN_IDLE_CONN_TIMEOUT", 90*time.Second),
+		BeaconchainKeepAlive:           getDurationEnv("BEACONCHAIN_KEEP_ALIVE", 30*time.Second),
+
+		BeaconchainDailyCredits:  getIntEnv("BEACONCHAIN_DAILY_CREDITS", 0),
+		BeaconchainCreditReserve: getFloatEnv("BEACONCHAIN_CREDIT_RESERVE", 0.1),
+		MaxValidatorIDs:          getIntEnv("MAX_VALIDATOR_IDS", 100),
+		MaxRequestTimeout:        getDurationEnv("MAX_REQUEST_TIMEOUT", 50*time.Second),
+		IdempotencyWindow:        getDurationEnv("IDEMPOTENCY_WINDOW", 10*time.Minute),
+
+		CacheMaxEntries: getIntEnv("CACHE_MAX_ENTRIES", 1000),
+		CacheMaxBytes:   getIntEnv("CACHE_MAX_BYTES", 64<<20), // 64 MiB
+
+		CacheSnapshotInterval: getDurationEnv("CACHE_SNAPSHOT_INTERVAL", 5*time.Minute),
+		CacheWarmRanges:       getListEnv("CACHE_WARM_RANGES", "30d"), // The dashboard default
+
+		DataDir:               getEnv("DATA_DIR", ""),
+		HistoryDatabaseURL:    getEnv("HISTORY_DATABASE_URL", ""),
+		MigrateOnStart:        getBoolEnv("MIGRATE_ON_START", true),
+		StateImportMaxBytes:   int64(getIntEnv("STATE_IMPORT_MAX_BYTES", 1<<30)), // 1 GiB
+		AdminToken:            getEnv("ADMIN_TOKEN", ""),
+		ParquetExportDir:      getEnv("PARQUET_EXPORT_DIR", ""),
+		ParquetExportInterval: getDurationEnv("PARQUET_EXPORT_INTERVAL", 24*time.Hour),
+		BackfillDays:          getIntEnv("BACKFILL_DAYS", 30),
+		BackfillInterval:      getDurationEnv("BACKFILL_INTERVAL", time.Hour),
+
+		InfluxDBURL:    getEnv("INFLUXDB_URL", ""),
+		InfluxDBToken:  getEnv("INFLUXDB_TOKEN", ""),
+		InfluxDBOrg:    getEnv("INFLUXDB_ORG", ""),
+		InfluxDBBucket: getEnv("INFLUXDB_BUCKET", ""),
+
+		SLAWindows: getListEnv("SLA_WINDOWS", "24h,7d,30d"),
+
+		RewardAnomalyWindow:    getDurationEnv("REWARD_ANOMALY_WINDOW", 6*time.Hour),
+		RewardAnomalyBaseline:  getDurationEnv("REWARD_ANOMALY_BASELINE", 7*24*time.Hour),
+		RewardAnomalyThreshold: getFloatEnv("REWARD_ANOMALY_THRESHOLD", 3),
+
+		ColdStorageEndpoint:        getEnv("COLD_STORAGE_ENDPOINT", ""),
+		ColdStorageBucket:          getEnv("COLD_STORAGE_BUCKET", ""),
+		ColdStorageRegion:          getEnv("COLD_STORAGE_REGION", "us-east-1"),
+		ColdStorageAccessKey:       getEnv("COLD_STORAGE_ACCESS_KEY", ""),
+		ColdStorageSecretKey:       getEnv("COLD_STORAGE_SECRET_KEY", ""),
+		ColdStorageArchiveInterval: getDurationEnv("COLD_STORAGE_ARCHIVE_INTERVAL", 24*time.Hour),
+		LocalRetentionMonths:       getIntEnv("LOCAL_RETENTION_MONTHS", 3),
+
+		PriceProviders:      strings.Split(getEnv("PRICE_PROVIDERS", "coingecko,kraken"), ","),
+		PriceCacheTTL:       getDurationEnv("PRICE_CACHE_TTL", 5*time.Minute),
+		PriceMaxStaleness:   getDurationEnv("PRICE_MAX_STALENESS", 6*time.Hour),
+		PriceTimeout:        getDurationEnv("PRICE_TIMEOUT", 10*time.Second),
+		CoinGeckoBaseURL:    getEnv("COINGECKO_BASE_URL", "https://api.coingecko.com"),
+		CoinGeckoAPIKey:     getEnv("COINGECKO_API_KEY", ""),
+		KrakenBaseURL:       getEnv("KRAKEN_BASE_URL", "https://api.kraken.com"),
+		CoinbaseBaseURL:     getEnv("COINBASE_BASE_URL", "https://api.coinbase.com"),
+		ExecutionRPCURL:     getEnv("EXECUTION_RPC_URL", ""),
+		ChainlinkETHUSDFeed: getEnv("CHAINLINK_ETH_USD_FEED", "0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419"),
+
+		ENSCacheTTL: getDurationEnv("ENS_CACHE_TTL", 24*time.Hour),
+
+		RestakingCacheTTL: getDurationEnv("RESTAKING_CACHE_TTL", time.Hour),
+
+		MEVRelayCacheTTL:      getDurationEnv("MEV_RELAY_CACHE_TTL", time.Hour),
+		MEVRegistrationMaxAge: getDurationEnv("MEV_REGISTRATION_MAX_AGE", 7*24*time.Hour),
+
+		AnomalyWindowsFile: getEnv("ANOMALY_WINDOWS_FILE", ""),
+
+		ClientDiversityFile: getEnv("CLIENT_DIVERSITY_FILE", ""),
+
+		PortfoliosFile:        getEnv("PORTFOLIOS_FILE", ""),
+		RegistrySyncInterval:  getDurationEnv("REGISTRY_SYNC_INTERVAL", time.Hour),
+		KeystoreScanInterval:  getDurationEnv("KEYSTORE_SCAN_INTERVAL", time.Minute),
+		SSVAPIURL:             getEnv("SSV_API_URL", ssv.DefaultURL),
+		SSVSyncInterval:       getDurationEnv("SSV_SYNC_INTERVAL", time.Hour),
+		ClientMetricsInterval: getDurationEnv("CLIENT_METRICS_INTERVAL", 384*time.Second), // One epoch
+
+		AlertRulesFile:     getEnv("ALERT_RULES_FILE", ""),
+		AlertCheckInterval: getDurationEnv("ALERT_CHECK_INTERVAL", 5*time.Minute),
+
+		DoppelgangerCheckInterval: getDurationEnv("DOPPELGANGER_CHECK_INTERVAL", 384*time.Second), // One epoch
+
+		MetricsPushgatewayURL: getEnv("METRICS_PUSHGATEWAY_URL", ""),
+		MetricsRemoteWriteURL: getEnv("METRICS_REMOTE_WRITE_URL", ""),
+		MetricsPushJob:        getEnv("METRICS_PUSH_JOB", "validator_dashboard"),
+		MetricsPushInterval:   getDurationEnv("METRICS_PUSH_INTERVAL", 384*time.Second), // One epoch
+
+		MQTTBrokerURL:       getEnv("MQTT_BROKER_URL", ""),
+		MQTTTopicPrefix:     getEnv("MQTT_TOPIC_PREFIX", "validator-dashboard"),
+		MQTTPublishInterval: getDurationEnv("MQTT_PUBLISH_INTERVAL", 384*time.Second), // One epoch
+
+		HeartbeatURL:        getEnv("HEARTBEAT_URL", ""),
+		HeartbeatFailURL:    getEnv("HEARTBEAT_FAIL_URL", ""),
+		HeartbeatInterval:   getDurationEnv("HEARTBEAT_INTERVAL", 5*time.Minute),
+		HeartbeatMaxOffline: getIntEnv("HEARTBEAT_MAX_OFFLINE", 0),
+
+		ReportSchedulesFile: getEnv("REPORT_SCHEDULES_FILE", ""),
+
+		TenantsFile: getEnv("TENANTS_FILE", ""),
+
+		MaxReconciliationIDs:        getIntEnv("MAX_RECONCILIATION_IDS", 10),
+		ReconciliationToleranceGwei: getIntEnv("RECONCILIATION_TOLERANCE_GWEI", 10_000_000), // 0.01 ETH
+
+		MaxReportValidatorIDs: getIntEnv("MAX_REPORT_VALIDATOR_IDS", 10_000),
+		MaxQueuedJobs:         getIntEnv("MAX_QUEUED_JOBS", 10),
+		JobRetention:          getDurationEnv("JOB_RETENTION", 24*time.Hour),
+
+		TraceSampler:     getEnv("TRACE_SAMPLER", "errors-only"),
+		TraceSampleRatio: getFloatEnv("TRACE_SAMPLE_RATIO", 0.01),
+	}
+
+	// Listen on all interfaces at PORT unless an explicit address is given
+	if cfg.Addr == "" {
+		cfg.Addr = ":" + cfg.Port
 	}
 
 	// Validate configuration
 	if cfg.MaxValidatorIDs < 1 || cfg.MaxValidatorIDs > 100 {
 		return nil, fmt.Errorf("max validator IDs must be between 1 and 100, got %d", cfg.MaxValidatorIDs)
 	}
+	if cfg.BeaconchainAPIVersion != "v1" && cfg.BeaconchainAPIVersion != "v2" {
+		return nil, fmt.Errorf("beaconcha API version must be v1 or v2, got %q", cfg.BeaconchainAPIVersion)
+	}
+	if cfg.BeaconchaReplayDir != "" && cfg.BeaconchaReplayMode != "record" && cfg.BeaconchaReplayMode != "replay" {
+		return nil, fmt.Errorf("beaconcha replay mode must be record or replay, got %q", cfg.BeaconchaReplayMode)
+	}
+	if cfg.BeaconchainDailyCredits < 0 {
+		return nil, fmt.Errorf("daily credits must be non-negative, got %d", cfg.BeaconchainDailyCredits)
+	}
+	if cfg.BeaconchainCreditReserve < 0 || cfg.BeaconchainCreditReserve > 1 {
+		return nil, fmt.Errorf("credit reserve must be between 0 and 1, got %g", cfg.BeaconchainCreditReserve)
+	}
+	if cfg.ShutdownDrainTimeout <= 0 {
+		return nil, fmt.Errorf("shutdown drain timeout must be positive, got %s", cfg.ShutdownDrainTimeout)
+	}
+	if cfg.MaxRequestTimeout < 0 || cfg.MaxRequestTimeout >= cfg.ServerWriteTimeout {
+		return nil, fmt.Errorf("max request timeout must be non-negative and below the server write timeout (%s), got %s", cfg.ServerWriteTimeout, cfg.MaxRequestTimeout)
+	}
+	if cfg.IdempotencyWindow < 0 {
+		return nil, fmt.Errorf("idempotency window must be non-negative, got %s", cfg.IdempotencyWindow)
+	}
+	if cfg.StateImportMaxBytes <= 0 {
+		return nil, fmt.Errorf("state import max bytes must be positive, got %d", cfg.StateImportMaxBytes)
+	}
+	if cfg.CacheMaxEntries < 0 || cfg.CacheMaxBytes < 0 {
+		return nil, fmt.Errorf("cache limits must be non-negative, got %d entries and %d bytes", cfg.CacheMaxEntries, cfg.CacheMaxBytes)
+	}
+	if cfg.CacheSnapshotInterval < 0 {
+		return nil, fmt.Errorf("cache snapshot interval must be non-negative, got %s", cfg.CacheSnapshotInterval)
+	}
+	for _, r := range cfg.CacheWarmRanges {
+		switch r {
+		case "24h", "7d", "30d", "90d", "all_time":
+		default:
+			return nil, fmt.Errorf("cache warm ranges must be 24h, 7d, 30d, 90d or all_time, got %q", r)
+		}
+	}
+	for _, w := range cfg.SLAWindows {
+		switch w {
+		case "24h", "7d", "30d":
+		default:
+			return nil, fmt.Errorf("SLA windows must be 24h, 7d or 30d, got %q", w)
+		}
+	}
+	if cfg.RewardAnomalyWindow < 0 {
+		return nil, fmt.Errorf("reward anomaly window must be non-negative, got %s", cfg.RewardAnomalyWindow)
+	}
+	if cfg.RewardAnomalyWindow > 0 && (cfg.RewardAnomalyBaseline < 0 || cfg.RewardAnomalyThreshold <= 0) {
+		return nil, fmt.Errorf("reward anomaly baseline must be non-negative and threshold positive, got %s and %g", cfg.RewardAnomalyBaseline, cfg.RewardAnomalyThreshold)
+	}
+	if cfg.BeaconchainMaxIdleConns < 0 || cfg.BeaconchainMaxIdleConnsPerHost < 0 || cfg.BeaconchainMaxConnsPerHost < 0 {
+		return nil, fmt.Errorf("upstream connection limits must be non-negative")
+	}
+	if cfg.BeaconchainIdleConnTimeout < 0 {
+		return nil, fmt.Errorf("upstream idle connection timeout must be non-negative, got %s", cfg.BeaconchainIdleConnTimeout)
+	}
+	cfg.BeaconchainChains = make(map[string]ChainUpstream, len(chains))
+	rates := make(map[[2]string]string) // Chain of each base URL and API key
+	for _, chain := range chains {
+		prefix := "BEACONCHAIN_" + strings.ToUpper(chain) + "_"
+		upstream := ChainUpstream{
+			BaseURL:   getEnv(prefix+"BASE_URL", cfg.BeaconchainBaseURL),
+			APIKey:    getEnv(prefix+"API_KEY", cfg.BeaconchainAPIKey),
+			RateLimit: getDurationEnv(prefix+"RATE_LIMIT", cfg.BeaconchainRateLimit),
+		}
+		if upstream.RateLimit <= 0 {
+			return nil, fmt.Errorf("%s upstream rate limit must be positive, got %s", chain, upstream.RateLimit)
+		}
+		quota := [2]string{upstream.BaseURL, upstream.APIKey}
+		if other, ok := rates[quota]; ok && cfg.BeaconchainChains[other].RateLimit != upstream.RateLimit {
+			return nil, fmt.Errorf("%s and %s share an upstream and API key but have different rate limits", other, chain)
+		}
+		rates[quota] = chain
+		cfg.BeaconchainChains[chain] = upstream
+	}
+	costs, err := budget.ParseCosts(getEnv("BEACONCHAIN_CREDIT_COSTS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("credit costs: %w", err)
+	}
+	cfg.BeaconchainCreditCosts = costs
+	if cfg.PriceStaticPrices, err = price.ParseStaticPrices(getEnv("PRICE_STATIC_PRICES", "")); err != nil {
+		return nil, fmt.Errorf("static prices: %w", err)
+	}
+	if cfg.PriceRateLimits, err = price.ParseRateLimits(getEnv("PRICE_RATE_LIMITS", "")); err != nil {
+		return nil, fmt.Errorf("price rate limits: %w", err)
+	}
+	if cfg.MEVRelays, err = relays.ParseRelays(getEnv("MEV_RELAYS", "")); err != nil {
+		return nil, fmt.Errorf("mev relays: %w", err)
+	}
+	if cfg.MEVRelayCacheTTL < 0 || cfg.MEVRegistrationMaxAge < 0 {
+		return nil, fmt.Errorf("mev relay cache TTL and registration max age must be non-negative, got %s and %s", cfg.MEVRelayCacheTTL, cfg.MEVRegistrationMaxAge)
+	}
+	if cfg.HealthWeights, err = service.ParseHealthWeights(getEnv("HEALTH_WEIGHTS", "")); err != nil {
+		return nil, fmt.Errorf("health weights: %w", err)
+	}
+	if cfg.ParquetExportDir != "" && cfg.DataDir == "" && cfg.HistoryDatabaseURL == "" {
+		return nil, fmt.Errorf("parquet export requires DATA_DIR or HISTORY_DATABASE_URL to be set")
+	}
+	if cfg.ParquetExportInterval <= 0 {
+		return nil, fmt.Errorf("parquet export interval must be positive, got %s", cfg.ParquetExportInterval)
+	}
+	if cfg.BackfillDays < 0 || cfg.BackfillDays > 365 {
+		return nil, fmt.Errorf("backfill days must be between 0 and 365, got %d", cfg.BackfillDays)
+	}
+	if cfg.BackfillInterval <= 0 {
+		return nil, fmt.Errorf("backfill interval must be positive, got %s", cfg.BackfillInterval)
+	}
+	if cfg.ColdStorageEndpoint != "" {
+		if cfg.DataDir == "" {
+			return nil, fmt.Errorf("cold storage requires DATA_DIR to be set")
+		}
+		if cfg.HistoryDatabaseURL != "" {
+			return nil, fmt.Errorf("cold storage archives the history in DATA_DIR, not in HISTORY_DATABASE_URL")
+		}
+		if cfg.ColdStorageBucket == "" {
+			return nil, fmt.Errorf("cold storage requires COLD_STORAGE_BUCKET to be set")
+		}
+		if cfg.ColdStorageArchiveInterval <= 0 {
+			return nil, fmt.Errorf("cold storage archive interval must be positive, got %s", cfg.ColdStorageArchiveInterval)
+		}
+		if cfg.LocalRetentionMonths < 0 {
+			return nil, fmt.Errorf("local retention must be non-negative, got %d months", cfg.LocalRetentionMonths)
+		}
+	}
+	if cfg.KeystoreScanInterval <= 0 {
+		return nil, fmt.Errorf("keystore scan interval must be positive, got %s", cfg.KeystoreScanInterval)
+	}
+	if cfg.SSVSyncInterval <= 0 {
+		return nil, fmt.Errorf("ssv sync interval must be positive, got %s", cfg.SSVSyncInterval)
+	}
+	if cfg.ClientMetricsInterval <= 0 {
+		return nil, fmt.Errorf("client metrics interval must be positive, got %s", cfg.ClientMetricsInterval)
+	}
+	if cfg.AlertCheckInterval <= 0 {
+		return nil, fmt.Errorf("alert check interval must be positive, got %s", cfg.AlertCheckInterval)
+	}
+	if cfg.DoppelgangerCheckInterval < 0 {
+		return nil, fmt.Errorf("doppelganger check interval must be non-negative, got %s", cfg.DoppelgangerCheckInterval)
+	}
+	if cfg.InfluxDBURL != "" {
+		if parsed, err := url.Parse(cfg.InfluxDBURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("influxdb URL must be an http or https URL, got %q", cfg.InfluxDBURL)
+		}
+		if cfg.InfluxDBOrg == "" || cfg.InfluxDBBucket == "" || cfg.InfluxDBToken == "" {
+			return nil, fmt.Errorf("INFLUXDB_TOKEN, INFLUXDB_ORG and INFLUXDB_BUCKET are required with INFLUXDB_URL")
+		}
+	}
+	for _, u := range []string{cfg.MetricsPushgatewayURL, cfg.MetricsRemoteWriteURL} {
+		if u == "" {
+			continue
+		}
+		if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("metrics push URL must be an http or https URL, got %q", u)
+		}
+		if cfg.MetricsPushJob == "" || cfg.MetricsPushInterval <= 0 {
+			return nil, fmt.Errorf("metrics push job must be set and interval positive, got %q and %s", cfg.MetricsPushJob, cfg.MetricsPushInterval)
+		}
+	}
+	if cfg.MQTTBrokerURL != "" {
+		if parsed, err := url.Parse(cfg.MQTTBrokerURL); err != nil || !slices.Contains([]string{"mqtt", "mqtts", "tcp", "ssl"}, parsed.Scheme) || parsed.Host == "" {
+			return nil, fmt.Errorf("mqtt broker URL must be an mqtt or mqtts URL, got %q", cfg.MQTTBrokerURL)
+		}
+		if cfg.MQTTTopicPrefix == "" || strings.ContainsAny(cfg.MQTTTopicPrefix, "+#") || cfg.MQTTPublishInterval <= 0 {
+			return nil, fmt.Errorf("mqtt topic prefix must be set without wildcards and interval positive, got %q and %s", cfg.MQTTTopicPrefix, cfg.MQTTPublishInterval)
+		}
+	}
+	for _, u := range []string{cfg.HeartbeatURL, cfg.HeartbeatFailURL} {
+		if u == "" {
+			continue
+		}
+		if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("heartbeat URL must be an http or https URL, got %q", u)
+		}
+	}
+	if cfg.HeartbeatFailURL != "" && cfg.HeartbeatURL == "" {
+		return nil, fmt.Errorf("HEARTBEAT_FAIL_URL requires HEARTBEAT_URL")
+	}
+	if cfg.HeartbeatInterval <= 0 || cfg.HeartbeatMaxOffline < 0 {
+		return nil, fmt.Errorf("heartbeat interval must be positive and max offline non-negative, got %s and %d", cfg.HeartbeatInterval, cfg.HeartbeatMaxOffline)
+	}
+	if cfg.MaxReconciliationIDs < 1 || cfg.MaxReconciliationIDs > cfg.MaxValidatorIDs {
+		return nil, fmt.Errorf("max reconciliation IDs must be between 1 and %d, got %d", cfg.MaxValidatorIDs, cfg.MaxReconciliationIDs)
+	}
+	if cfg.ReconciliationToleranceGwei < 0 {
+		return nil, fmt.Errorf("reconciliation tolerance must be non-negative, got %d", cfg.ReconciliationToleranceGwei)
+	}
+	if cfg.MaxReportValidatorIDs < 1 {
+		return nil, fmt.Errorf("max report validator IDs must be positive, got %d", cfg.MaxReportValidatorIDs)
+	}
+	if cfg.MaxQueuedJobs < 1 {
+		return nil, fmt.Errorf("max queued jobs must be positive, got %d", cfg.MaxQueuedJobs)
+	}
+	if cfg.JobRetention <= 0 {
+		return nil, fmt.Errorf("job retention must be positive, got %s", cfg.JobRetention)
+	}
+	switch cfg.TraceSampler {
+	case "always", "ratio", "errors-only":
+	default:
+		return nil, fmt.Errorf("trace sampler must be one of always, ratio, errors-only, got %q", cfg.TraceSampler)
+	}
+	if cfg.TraceSampleRatio < 0 || cfg.TraceSampleRatio > 1 {
+		return nil, fmt.Errorf("trace sample ratio must be between 0 and 1, got %g", cfg.TraceSampleRatio)
+	}
 
 	return cfg, nil
 }
@@ -72,3 +574,37 @@ func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+// getListEnv splits a comma-separated value. Unlike the other getters, a variable
+// set to an empty value yields an empty list rather than the default.
+func getListEnv(key, defaultValue string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		value = defaultValue
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,53 @@
+// Package cost counts the upstream calls and cache hits made on behalf of a request.
+package cost
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Counter accumulates the cost of a single request. It is safe for concurrent use.
+type Counter struct {
+	upstreamCalls atomic.Int64
+	cacheHits     atomic.Int64
+	staleHits     atomic.Int64
+}
+
+// UpstreamCalls returns the number of HTTP requests made to upstream APIs.
+func (c *Counter) UpstreamCalls() int64 { return c.upstreamCalls.Load() }
+
+// CacheHits returns the number of lookups answered from a cache.
+func (c *Counter) CacheHits() int64 { return c.cacheHits.Load() }
+
+// StaleHits returns the number of cache hits that served data older than the
+// current epoch to save upstream credits.
+func (c *Counter) StaleHits() int64 { return c.staleHits.Load() }
+
+type counterKey struct{}
+
+// WithCounter returns a context carrying a new counter.
+func WithCounter(ctx context.Context) (context.Context, *Counter) {
+	c := &Counter{}
+	return context.WithValue(ctx, counterKey{}, c), c
+}
+
+// AddUpstreamCall records an HTTP request to an upstream API, if ctx carries a counter.
+func AddUpstreamCall(ctx context.Context) {
+	if c, ok := ctx.Value(counterKey{}).(*Counter); ok {
+		c.upstreamCalls.Add(1)
+	}
+}
+
+// AddCacheHit records a lookup answered from a cache, if ctx carries a counter.
+func AddCacheHit(ctx context.Context) {
+	if c, ok := ctx.Value(counterKey{}).(*Counter); ok {
+		c.cacheHits.Add(1)
+	}
+}
+
+// AddStaleHit records a cache hit served from an earlier epoch, if ctx carries a counter.
+func AddStaleHit(ctx context.Context) {
+	if c, ok := ctx.Value(counterKey{}).(*Counter); ok {
+		c.staleHits.Add(1)
+	}
+}
@@ -0,0 +1,148 @@
+// Package deposit reads validator public keys from the deposit data files written
+// by the staking deposit CLI, from CSVs of public keys and from keystore
+// directories of validator clients.
+package deposit
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrInvalid is returned for files that hold no valid public keys.
+var ErrInvalid = errors.New("invalid key file")
+
+// MaxKeys is the maximum number of distinct public keys in a file.
+const MaxKeys = 10000
+
+// publicKeyLength is the length of a BLS public key in bytes.
+const publicKeyLength = 48
+
+// Keys are the public keys read from a file.
+type Keys struct {
+	PublicKeys []string // 0x-prefixed lowercase hex, distinct, in the order of the file
+	Network    string   // network_name of deposit data, empty for CSVs
+
+	seen map[string]bool // Keys added, for deduplication
+}
+
+// depositData is the part of a deposit data entry read.
+type depositData struct {
+	Pubkey      string `json:"pubkey"`
+	NetworkName string `json:"network_name"`
+}
+
+// Parse reads the public keys of a deposit_data-*.json file, a JSON array of
+// deposits, or of a CSV whose pubkey column, or first column without such a
+// header, holds public keys. Keys may be given with or without 0x.
+func Parse(data []byte) (Keys, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return Keys{}, fmt.Errorf("%w: empty file", ErrInvalid)
+	}
+	if trimmed[0] == '[' {
+		return parseDepositData(trimmed)
+	}
+	return parseCSV(trimmed)
+}
+
+// parseDepositData reads the keys of a deposit data file.
+func parseDepositData(data []byte) (Keys, error) {
+	var deposits []depositData
+	if err := json.Unmarshal(data, &deposits); err != nil {
+		return Keys{}, fmt.Errorf("%w: decode deposit data: %w", ErrInvalid, err)
+	}
+
+	var keys Keys
+	for i, d := range deposits {
+		if d.NetworkName != "" && keys.Network != "" && d.NetworkName != keys.Network {
+			return Keys{}, fmt.Errorf("%w: deposit %d is for %s, others for %s", ErrInvalid, i+1, d.NetworkName, keys.Network)
+		}
+		if d.NetworkName != "" {
+			keys.Network = d.NetworkName
+		}
+		if err := keys.add(d.Pubkey); err != nil {
+			return Keys{}, fmt.Errorf("%w: deposit %d: %w", ErrInvalid, i+1, err)
+		}
+	}
+	return keys.check()
+}
+
+// parseCSV reads the keys of a CSV file.
+func parseCSV(data []byte) (Keys, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var keys Keys
+	column := 0
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Keys{}, fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+		if line == 1 {
+			if i := headerColumn(record); i >= 0 {
+				column = i
+				continue
+			}
+		}
+		if column >= len(record) || strings.TrimSpace(record[column]) == "" {
+			continue
+		}
+		if err := keys.add(record[column]); err != nil {
+			return Keys{}, fmt.Errorf("%w: line %d: %w", ErrInvalid, line, err)
+		}
+	}
+	return keys.check()
+}
+
+// headerColumn returns the column of the public keys if record is a header, or
+// -1 otherwise.
+func headerColumn(record []string) int {
+	for i, field := range record {
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "pubkey", "public_key", "publickey", "public key":
+			return i
+		}
+	}
+	return -1
+}
+
+// add adds a public key, unless already added.
+func (k *Keys) add(key string) error {
+	key = strings.ToLower(strings.TrimSpace(key))
+	digits := strings.TrimPrefix(key, "0x")
+	if b, err := hex.DecodeString(digits); err != nil || len(b) != publicKeyLength {
+		return fmt.Errorf("invalid public key %q", key)
+	}
+	key = "0x" + digits
+	if k.seen == nil {
+		k.seen = make(map[string]bool)
+	}
+	if !k.seen[key] {
+		k.seen[key] = true
+		k.PublicKeys = append(k.PublicKeys, key)
+	}
+	return nil
+}
+
+// check returns the keys unless there are none or too many.
+func (k Keys) check() (Keys, error) {
+	if len(k.PublicKeys) == 0 {
+		return Keys{}, fmt.Errorf("%w: no public keys", ErrInvalid)
+	}
+	if len(k.PublicKeys) > MaxKeys {
+		return Keys{}, fmt.Errorf("%w: %d public keys, at most %d are allowed", ErrInvalid, len(k.PublicKeys), MaxKeys)
+	}
+	k.seen = nil
+	return k, nil
+}
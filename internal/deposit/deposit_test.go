@@ -0,0 +1,58 @@
+package deposit
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	key := func(b byte) string { return "0x" + strings.Repeat(string("0123456789abcdef"[b]), 96) }
+	bare := func(b byte) string { return strings.TrimPrefix(key(b), "0x") }
+
+	tests := []struct {
+		name        string
+		data        string
+		wantKeys    []string
+		wantNetwork string
+		wantErr     bool
+	}{
+		{
+			name:        "deposit data",
+			data:        `[{"pubkey":"` + bare(1) + `","amount":32000000000,"network_name":"hoodi"},{"pubkey":"` + bare(2) + `","network_name":"hoodi"}]`,
+			wantKeys:    []string{key(1), key(2)},
+			wantNetwork: "hoodi",
+		},
+		{name: "deposit data duplicates", data: `[{"pubkey":"` + bare(1) + `"},{"pubkey":"0x` + strings.ToUpper(bare(1)) + `"}]`, wantKeys: []string{key(1)}},
+		{name: "mixed networks", data: `[{"pubkey":"` + bare(1) + `","network_name":"hoodi"},{"pubkey":"` + bare(2) + `","network_name":"mainnet"}]`, wantErr: true},
+		{name: "invalid deposit key", data: `[{"pubkey":"abcd"}]`, wantErr: true},
+		{name: "malformed json", data: `[{"pubkey":`, wantErr: true},
+		{name: "csv", data: key(3) + "\n" + bare(4) + "\n\n", wantKeys: []string{key(3), key(4)}},
+		{name: "csv with header", data: "index,pubkey\n7," + key(5) + "\n8," + key(6) + "\n", wantKeys: []string{key(5), key(6)}},
+		{name: "csv invalid key", data: "pubkey\nnot-a-key\n", wantErr: true},
+		{name: "header only", data: "pubkey\n", wantErr: true},
+		{name: "empty", data: "  \n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := Parse([]byte(tt.data))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalid) {
+					t.Fatalf("expected ErrInvalid, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(keys.PublicKeys, tt.wantKeys) {
+				t.Errorf("expected keys %v, got %v", tt.wantKeys, keys.PublicKeys)
+			}
+			if keys.Network != tt.wantNetwork {
+				t.Errorf("expected network %q, got %q", tt.wantNetwork, keys.Network)
+			}
+		})
+	}
+}
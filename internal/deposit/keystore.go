@@ -0,0 +1,60 @@
+package deposit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxKeystoreBytes is the size above which a file is not read as a keystore.
+const maxKeystoreBytes = 1 << 20
+
+// keystore is the part of an EIP-2335 keystore read. The encrypted secret key is
+// only checked for presence, never kept.
+type keystore struct {
+	Pubkey string    `json:"pubkey"`
+	Crypto *struct{} `json:"crypto"`
+}
+
+// ScanKeystores returns the public keys of the EIP-2335 keystores in dir and its
+// subdirectories, as kept by validator clients, in the order of their paths.
+// Other files, including keystores without a public key, are skipped, so an
+// empty directory has no keys rather than an error.
+func ScanKeystores(dir string) (Keys, error) {
+	var keys Keys
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".json") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxKeystoreBytes {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read keystore: %w", err)
+		}
+		var ks keystore
+		if json.Unmarshal(data, &ks) != nil || ks.Crypto == nil || ks.Pubkey == "" {
+			return nil
+		}
+		if err := keys.add(ks.Pubkey); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalid, path, err)
+		}
+		if len(keys.PublicKeys) > MaxKeys {
+			return fmt.Errorf("%w: more than %d public keys", ErrInvalid, MaxKeys)
+		}
+		return nil
+	})
+	if err != nil {
+		return Keys{}, fmt.Errorf("scan keystores: %w", err)
+	}
+	keys.seen = nil
+	return keys, nil
+}
@@ -0,0 +1,60 @@
+package deposit
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScanKeystores(t *testing.T) {
+	key := func(b byte) string { return "0x" + strings.Repeat(string("0123456789abcdef"[b]), 96) }
+	keystore := func(pubkey string) string {
+		return `{"crypto":{"kdf":{},"checksum":{},"cipher":{}},"pubkey":"` + pubkey + `","path":"m/12381/3600/0/0/0","version":4}`
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		// Lighthouse and Lodestar keep a directory per validator
+		"validators/" + key(1) + "/voting-keystore.json": keystore(strings.TrimPrefix(key(1), "0x")),
+		// Teku keeps keystores next to their passwords
+		"keys/validator_2.json":        keystore(strings.ToUpper(key(2))),
+		"keys/validator_2.txt":         "password",
+		"keys/validator_2_copy.json":   keystore(key(2)),
+		"slashing-protection.json":     `{"metadata":{},"data":[]}`,
+		"deposit_data-1700000000.json": `[{"pubkey":"` + key(3) + `"}]`,
+		"no-pubkey.json":               `{"crypto":{},"pubkey":""}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys, err := ScanKeystores(dir)
+	if err != nil {
+		t.Fatalf("ScanKeystores failed: %v", err)
+	}
+	if want := []string{key(2), key(1)}; !reflect.DeepEqual(keys.PublicKeys, want) {
+		t.Errorf("expected keys %v, got %v", want, keys.PublicKeys)
+	}
+
+	if keys, err := ScanKeystores(t.TempDir()); err != nil || len(keys.PublicKeys) != 0 {
+		t.Errorf("expected no keys for an empty directory, got %v, %v", keys.PublicKeys, err)
+	}
+	if _, err := ScanKeystores(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "bad.json"), []byte(keystore("abcd")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ScanKeystores(dir); err == nil {
+		t.Error("expected an error for an invalid public key")
+	}
+}
@@ -0,0 +1,88 @@
+// Package diversity holds the client diversity of the networks, as published by
+// client diversity trackers, so the client distribution of a fleet can be
+// compared against it.
+package diversity
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Stats is the share of validators per client on a chain.
+type Stats struct {
+	Chain     string             `json:"chain"`
+	Label     string             `json:"label"`  // Label key the clients are compared by, e.g. client
+	Shares    map[string]float64 `json:"shares"` // Share of validators per client, 0-1
+	Source    string             `json:"source,omitempty"`
+	UpdatedAt *time.Time         `json:"updatedAt,omitempty"`
+}
+
+// Network holds the client diversity stats per chain and label.
+type Network struct {
+	stats []Stats
+}
+
+// NewNetwork creates a network from the given stats. Client names are compared
+// case-insensitively.
+func NewNetwork(stats []Stats) (*Network, error) {
+	seen := make(map[string]bool)
+	for i, s := range stats {
+		if s.Chain == "" || s.Label == "" {
+			return nil, fmt.Errorf("client diversity stats must have a chain and a label")
+		}
+		if seen[s.Chain+"/"+s.Label] {
+			return nil, fmt.Errorf("duplicate client diversity stats for %s label %q", s.Chain, s.Label)
+		}
+		seen[s.Chain+"/"+s.Label] = true
+
+		var total float64
+		shares := make(map[string]float64, len(s.Shares))
+		for client, share := range s.Shares {
+			if share < 0 || share > 1 {
+				return nil, fmt.Errorf("client diversity stats for %s: share of %q must be between 0 and 1", s.Chain, client)
+			}
+			total += share
+			shares[strings.ToLower(client)] += share
+		}
+		if total > 1.01 { // Published percentages are rounded
+			return nil, fmt.Errorf("client diversity stats for %s: shares add up to %.2f", s.Chain, total)
+		}
+		stats[i].Shares = shares
+	}
+	return &Network{stats: stats}, nil
+}
+
+// LoadFile reads client diversity stats from a JSON file containing an array of
+// stats. An empty path returns a network without any stats.
+func LoadFile(path string) (*Network, error) {
+	if path == "" {
+		return &Network{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read client diversity: %w", err)
+	}
+
+	var stats []Stats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, fmt.Errorf("decode client diversity: %w", err)
+	}
+	return NewNetwork(stats)
+}
+
+// Get returns the stats of chain for the label key.
+func (n *Network) Get(chain, label string) (Stats, bool) {
+	if n == nil {
+		return Stats{}, false
+	}
+	for _, s := range n.stats {
+		if s.Chain == chain && s.Label == label {
+			return s, true
+		}
+	}
+	return Stats{}, false
+}
@@ -0,0 +1,53 @@
+package diversity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "valid", content: `[{"chain":"mainnet","label":"client","shares":{"Lighthouse":0.42,"prysm":0.31,"teku":0.1}}]`},
+		{name: "missing label", content: `[{"chain":"mainnet","shares":{"lighthouse":0.42}}]`, wantErr: true},
+		{name: "share out of range", content: `[{"chain":"mainnet","label":"client","shares":{"lighthouse":42}}]`, wantErr: true},
+		{name: "shares above one", content: `[{"chain":"mainnet","label":"client","shares":{"lighthouse":0.6,"prysm":0.5}}]`, wantErr: true},
+		{name: "duplicate", content: `[{"chain":"mainnet","label":"client","shares":{}},{"chain":"mainnet","label":"client","shares":{}}]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "diversity.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			n, err := LoadFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error: %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			s, ok := n.Get("mainnet", "client")
+			if !ok || s.Shares["lighthouse"] != 0.42 {
+				t.Errorf("expected client names to be lowercased, got %+v", s)
+			}
+			if _, ok := n.Get("hoodi", "client"); ok {
+				t.Error("expected no stats for hoodi")
+			}
+		})
+	}
+
+	// Without a file there are no stats
+	n, err := LoadFile("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := n.Get("mainnet", "client"); ok {
+		t.Error("expected no stats")
+	}
+}
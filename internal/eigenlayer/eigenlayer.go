@@ -0,0 +1,227 @@
+// Package eigenlayer detects EigenPods among withdrawal addresses and reads their
+// restaking status from the EigenLayer contracts through an execution layer
+// JSON-RPC endpoint.
+package eigenlayer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/tracing"
+)
+
+// EigenLayer core contracts on Ethereum mainnet.
+const (
+	EigenPodManagerAddress   = "0x91E677b07F7AF907ec9a428aafA9fc14a0d3A338"
+	DelegationManagerAddress = "0x39053D51B77DC0d36036Fc1fCc8Cb819df8Ef37A"
+)
+
+// Function selectors of the contract methods used.
+const (
+	podOwnerSelector    = "0b18ff66" // podOwner()
+	ownerToPodSelector  = "9ba06275" // ownerToPod(address)
+	delegatedToSelector = "65da1264" // delegatedTo(address)
+)
+
+// Pod is the restaking status of an EigenPod.
+type Pod struct {
+	Address  string // The EigenPod
+	Owner    string // Staker owning the pod
+	Operator string // Operator the owner delegated to, empty if not delegated
+}
+
+// rpcError is an error returned by the node for a call, such as a revert.
+type rpcError struct {
+	message string
+}
+
+func (e *rpcError) Error() string { return "rpc error: " + e.message }
+
+// failureTTL is how long a failed lookup is answered with its error, so a node
+// that is down is not called for every validator.
+const failureTTL = time.Minute
+
+// Checker tells EigenPods from other withdrawal addresses. Results, including
+// addresses that are not pods, are cached for the cache TTL, and failures for at
+// most failureTTL.
+type Checker struct {
+	rpcURL            string
+	podManager        string
+	delegationManager string
+	cacheTTL          time.Duration
+	httpClient        *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	pod     *Pod
+	err     error
+	expires time.Time
+}
+
+// NewChecker creates a checker using the EigenLayer contracts on the chain served
+// by rpcURL, which must be Ethereum mainnet.
+func NewChecker(rpcURL string, cacheTTL, timeout time.Duration) *Checker {
+	return &Checker{
+		rpcURL:            rpcURL,
+		podManager:        EigenPodManagerAddress,
+		delegationManager: DelegationManagerAddress,
+		cacheTTL:          cacheTTL,
+		httpClient:        &http.Client{Timeout: timeout},
+		cache:             make(map[string]cacheEntry),
+	}
+}
+
+// Lookup returns the restaking status of address, or nil if it is not an
+// EigenPod.
+func (c *Checker) Lookup(ctx context.Context, address string) (*Pod, error) {
+	key := strings.ToLower(address)
+	c.mu.Lock()
+	entry, ok := c.cache[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		cost.AddCacheHit(ctx)
+		return entry.pod, entry.err
+	}
+
+	pod, err := c.lookup(ctx, key)
+	entry = cacheEntry{pod: pod, err: err, expires: time.Now().Add(c.cacheTTL)}
+	if err != nil {
+		entry.expires = time.Now().Add(min(failureTTL, c.cacheTTL))
+	}
+	c.mu.Lock()
+	c.cache[key] = entry
+	c.mu.Unlock()
+	return pod, err
+}
+
+// lookup reads the owner of the pod at address and its delegation. Any contract
+// can claim an owner, so address only counts as a pod if the EigenPodManager
+// holds it as the owner's pod.
+func (c *Checker) lookup(ctx context.Context, address string) (*Pod, error) {
+	result, err := c.call(ctx, address, podOwnerSelector, "")
+	var callErr *rpcError
+	if errors.As(err, &callErr) {
+		// Accounts without a podOwner method revert
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("podOwner: %w", err)
+	}
+	owner, err := decodeAddress(result)
+	if err != nil || owner == "" {
+		return nil, err
+	}
+
+	result, err = c.call(ctx, c.podManager, ownerToPodSelector, owner)
+	if err != nil {
+		return nil, fmt.Errorf("ownerToPod: %w", err)
+	}
+	pod, err := decodeAddress(result)
+	if err != nil {
+		return nil, err
+	}
+	if pod != address {
+		return nil, nil
+	}
+
+	result, err = c.call(ctx, c.delegationManager, delegatedToSelector, owner)
+	if err != nil {
+		return nil, fmt.Errorf("delegatedTo: %w", err)
+	}
+	operator, err := decodeAddress(result)
+	if err != nil {
+		return nil, err
+	}
+	return &Pod{Address: address, Owner: owner, Operator: operator}, nil
+}
+
+// call performs an eth_call of the function with the given selector on the
+// contract at to, with an optional address argument.
+func (c *Checker) call(ctx context.Context, to, sel, arg string) (result []byte, err error) {
+	ctx, span := tracing.StartSpan(ctx, "eth_call")
+	defer func() { span.End(err != nil) }()
+
+	data := "0x" + sel
+	if arg != "" {
+		data += strings.Repeat("0", 24) + strings.TrimPrefix(strings.ToLower(arg), "0x")
+	}
+	payload, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "eth_call",
+		"params":  []any{map[string]string{"to": to, "data": data}, "latest"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.rpcURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
+
+	cost.AddUpstreamCall(ctx)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	if response.Error != nil {
+		return nil, &rpcError{message: response.Error.Message}
+	}
+
+	result, err = hex.DecodeString(strings.TrimPrefix(response.Result, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("decode result %q: %w", response.Result, err)
+	}
+	return result, nil
+}
+
+// decodeAddress decodes an ABI encoded address, returning an empty string for no
+// data, as returned by accounts without code, and for the zero address.
+func decodeAddress(result []byte) (string, error) {
+	if len(result) == 0 {
+		return "", nil
+	}
+	if len(result) < 32 {
+		return "", fmt.Errorf("unexpected address result of %d bytes", len(result))
+	}
+	address := result[12:32]
+	if bytes.Equal(address, make([]byte, 20)) {
+		return "", nil
+	}
+	return "0x" + hex.EncodeToString(address), nil
+}
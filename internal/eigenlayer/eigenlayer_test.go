@@ -0,0 +1,163 @@
+package eigenlayer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testPod      = "0x1111111111111111111111111111111111111111"
+	testOwner    = "0x2222222222222222222222222222222222222222"
+	testOperator = "0x3333333333333333333333333333333333333333"
+	fakePod      = "0x4444444444444444444444444444444444444444" // Claims testOwner
+	plainAddress = "0xd8da6bf26964af9d7eed9e03e53415d37aa96045"
+)
+
+// fakeNode is a JSON-RPC server implementing the EigenPodManager, the
+// DelegationManager and the pods of owners.
+type fakeNode struct {
+	owners    map[string]string // pod -> owner returned by podOwner()
+	pods      map[string]string // owner -> pod held by the EigenPodManager
+	operators map[string]string // owner -> operator delegated to
+	calls     atomic.Int64
+}
+
+func (f *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.calls.Add(1)
+	var req struct {
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var call struct {
+		To   string `json:"to"`
+		Data string `json:"data"`
+	}
+	json.Unmarshal(req.Params[0], &call)
+	data := strings.TrimPrefix(call.Data, "0x")
+	arg := ""
+	if len(data) >= 72 {
+		arg = "0x" + data[32:72]
+	}
+
+	var (
+		result string
+		ok     bool
+	)
+	switch {
+	case strings.EqualFold(call.To, EigenPodManagerAddress) && strings.HasPrefix(data, ownerToPodSelector):
+		result, ok = f.pods[arg], true
+	case strings.EqualFold(call.To, DelegationManagerAddress) && strings.HasPrefix(data, delegatedToSelector):
+		result, ok = f.operators[arg], true
+	case strings.HasPrefix(data, podOwnerSelector):
+		result, ok = f.owners[strings.ToLower(call.To)]
+	}
+	if !ok {
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "error": map[string]any{"code": 3, "message": "execution reverted"}})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": "0x" + encodeAddress(result)})
+}
+
+// encodeAddress ABI encodes an address, the zero address if empty.
+func encodeAddress(address string) string {
+	if address == "" {
+		return strings.Repeat("0", 64)
+	}
+	return strings.Repeat("0", 24) + strings.TrimPrefix(address, "0x")
+}
+
+func TestChecker_Lookup(t *testing.T) {
+	node := &fakeNode{
+		owners:    map[string]string{testPod: testOwner, fakePod: testOwner},
+		pods:      map[string]string{testOwner: testPod},
+		operators: map[string]string{testOwner: testOperator},
+	}
+	server := httptest.NewServer(node)
+	defer server.Close()
+	c := NewChecker(server.URL, time.Hour, time.Second)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		address  string
+		expected *Pod
+	}{
+		{"delegated pod", testPod, &Pod{Address: testPod, Owner: testOwner, Operator: testOperator}},
+		{"contract claiming an owner", fakePod, nil},
+		{"not a pod", "0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod, err := c.Lookup(ctx, tt.address)
+			if err != nil {
+				t.Fatalf("Lookup failed: %v", err)
+			}
+			if (pod == nil) != (tt.expected == nil) || (pod != nil && *pod != *tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, pod)
+			}
+		})
+	}
+
+	// Lookups, including of addresses that are not pods, are cached
+	calls := node.calls.Load()
+	for _, address := range []string{testPod, fakePod, plainAddress} {
+		if _, err := c.Lookup(ctx, address); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := node.calls.Load(); got != calls {
+		t.Errorf("expected cached lookups, got %d more calls", got-calls)
+	}
+}
+
+func TestChecker_Undelegated(t *testing.T) {
+	node := &fakeNode{
+		owners: map[string]string{testPod: testOwner},
+		pods:   map[string]string{testOwner: testPod},
+	}
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	pod, err := NewChecker(server.URL, time.Hour, time.Second).Lookup(context.Background(), testPod)
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if pod == nil || pod.Operator != "" {
+		t.Errorf("expected an undelegated pod, got %+v", pod)
+	}
+}
+
+func TestChecker_NodeDown(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := NewChecker(server.URL, time.Hour, time.Second)
+	for i := 0; i < 2; i++ {
+		if _, err := c.Lookup(context.Background(), testPod); err == nil {
+			t.Error("expected error, got nil")
+		}
+	}
+	// The failure is cached briefly
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 call to the node, got %d", n)
+	}
+	c.mu.Lock()
+	expires := c.cache[testPod].expires
+	c.mu.Unlock()
+	if time.Until(expires) > failureTTL {
+		t.Errorf("expected the failure to expire within %s, got %s", failureTTL, time.Until(expires))
+	}
+}
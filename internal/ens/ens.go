@@ -0,0 +1,299 @@
+// Package ens resolves Ethereum Name Service names through an execution layer
+// JSON-RPC endpoint.
+package ens
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/tracing"
+)
+
+// RegistryAddress is the ENS registry on Ethereum mainnet.
+const RegistryAddress = "0x00000000000C2E074eC69A0bFb2997BA6C7d2e1e"
+
+// Function selectors of the registry and resolver methods used.
+var (
+	resolverSelector = selector("resolver(bytes32)")
+	addrSelector     = selector("addr(bytes32)")
+	nameSelector     = selector("name(bytes32)")
+)
+
+// ErrNotFound is returned when a name does not resolve to an address.
+var ErrNotFound = errors.New("ens name not found")
+
+var (
+	addressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	namePattern    = regexp.MustCompile(`^([a-z0-9-]+\.)+[a-z0-9-]+$`)
+)
+
+// IsAddress reports whether s is a hex encoded Ethereum address.
+func IsAddress(s string) bool {
+	return addressPattern.MatchString(s)
+}
+
+// IsName reports whether s looks like an ENS name, such as "rocketpool.eth". Only
+// lowercase ASCII names are accepted, which need no further normalization.
+func IsName(s string) bool {
+	return namePattern.MatchString(s)
+}
+
+// Resolver resolves names to addresses and addresses to their primary names.
+// Results, including the absence of a name, are cached for the cache TTL.
+type Resolver struct {
+	rpcURL     string
+	registry   string
+	cacheTTL   time.Duration
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value   string
+	expires time.Time
+}
+
+// NewResolver creates a resolver using the ENS registry at RegistryAddress on the
+// chain served by rpcURL, which must be Ethereum mainnet.
+func NewResolver(rpcURL string, cacheTTL, timeout time.Duration) *Resolver {
+	return &Resolver{
+		rpcURL:     rpcURL,
+		registry:   RegistryAddress,
+		cacheTTL:   cacheTTL,
+		httpClient: &http.Client{Timeout: timeout},
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+// Resolve returns the address name resolves to, or ErrNotFound.
+func (r *Resolver) Resolve(ctx context.Context, name string) (string, error) {
+	address, err := r.cached(ctx, "name:"+name, func() (string, error) {
+		return r.resolve(ctx, name)
+	})
+	if err != nil {
+		return "", err
+	}
+	if address == "" {
+		return "", ErrNotFound
+	}
+	return address, nil
+}
+
+// LookupAddress returns the primary name of address, or an empty string if it
+// has none. Names are only returned if they resolve back to address.
+func (r *Resolver) LookupAddress(ctx context.Context, address string) (string, error) {
+	if !IsAddress(address) {
+		return "", fmt.Errorf("invalid address %q", address)
+	}
+	return r.cached(ctx, "addr:"+strings.ToLower(address), func() (string, error) {
+		return r.lookupAddress(ctx, address)
+	})
+}
+
+// cached returns the cached value for key, or fetches and caches it. Errors are
+// not cached.
+func (r *Resolver) cached(ctx context.Context, key string, fetch func() (string, error)) (string, error) {
+	r.mu.Lock()
+	entry, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		cost.AddCacheHit(ctx)
+		return entry.value, nil
+	}
+
+	value, err := fetch()
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	r.cache[key] = cacheEntry{value: value, expires: time.Now().Add(r.cacheTTL)}
+	r.mu.Unlock()
+	return value, nil
+}
+
+// resolve returns the address of name, or an empty string if it has none.
+func (r *Resolver) resolve(ctx context.Context, name string) (string, error) {
+	node := Namehash(name)
+	resolver, err := r.resolver(ctx, node)
+	if err != nil || resolver == "" {
+		return "", err
+	}
+
+	result, err := r.call(ctx, resolver, addrSelector, node[:])
+	if err != nil {
+		return "", fmt.Errorf("addr: %w", err)
+	}
+	return decodeAddress(result)
+}
+
+// lookupAddress returns the verified primary name of address, or an empty string.
+func (r *Resolver) lookupAddress(ctx context.Context, address string) (string, error) {
+	node := Namehash(strings.ToLower(strings.TrimPrefix(address, "0x")) + ".addr.reverse")
+	resolver, err := r.resolver(ctx, node)
+	if err != nil || resolver == "" {
+		return "", err
+	}
+
+	result, err := r.call(ctx, resolver, nameSelector, node[:])
+	if err != nil {
+		return "", fmt.Errorf("name: %w", err)
+	}
+	name, err := decodeString(result)
+	if err != nil || name == "" {
+		return "", err
+	}
+
+	// Anyone can claim any name in their reverse record, so it only counts if the
+	// name points back at the address
+	forward, err := r.resolve(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(forward, address) {
+		return "", nil
+	}
+	return name, nil
+}
+
+// resolver returns the resolver of node from the registry, or an empty string if
+// none is set.
+func (r *Resolver) resolver(ctx context.Context, node [32]byte) (string, error) {
+	result, err := r.call(ctx, r.registry, resolverSelector, node[:])
+	if err != nil {
+		return "", fmt.Errorf("resolver: %w", err)
+	}
+	return decodeAddress(result)
+}
+
+// call performs an eth_call of the function with the given selector and ABI
+// encoded arguments on the contract at to.
+func (r *Resolver) call(ctx context.Context, to string, sel, args []byte) (result []byte, err error) {
+	ctx, span := tracing.StartSpan(ctx, "eth_call")
+	defer func() { span.End(err != nil) }()
+
+	payload, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "eth_call",
+		"params": []any{
+			map[string]string{"to": to, "data": "0x" + hex.EncodeToString(append(sel, args...))},
+			"latest",
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.rpcURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
+
+	cost.AddUpstreamCall(ctx)
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("rpc error: %s", response.Error.Message)
+	}
+
+	result, err = hex.DecodeString(strings.TrimPrefix(response.Result, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("decode result %q: %w", response.Result, err)
+	}
+	return result, nil
+}
+
+// Namehash returns the ENS node of name as defined in EIP-137.
+func Namehash(name string) [32]byte {
+	var node [32]byte
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := keccak256([]byte(labels[i]))
+		node = keccak256(append(node[:], label[:]...))
+	}
+	return node
+}
+
+// selector returns the 4 byte function selector of a Solidity signature.
+func selector(signature string) []byte {
+	hash := keccak256([]byte(signature))
+	return hash[:4]
+}
+
+// decodeAddress decodes an ABI encoded address, returning an empty string for the
+// zero address, which ENS uses for unset records.
+func decodeAddress(result []byte) (string, error) {
+	if len(result) == 0 {
+		// Calls to accounts without code return no data
+		return "", nil
+	}
+	if len(result) < 32 {
+		return "", fmt.Errorf("unexpected address result of %d bytes", len(result))
+	}
+	address := result[12:32]
+	if bytes.Equal(address, make([]byte, 20)) {
+		return "", nil
+	}
+	return "0x" + hex.EncodeToString(address), nil
+}
+
+// decodeString decodes a single ABI encoded string return value.
+func decodeString(result []byte) (string, error) {
+	if len(result) == 0 {
+		return "", nil
+	}
+	if len(result) < 64 {
+		return "", fmt.Errorf("unexpected string result of %d bytes", len(result))
+	}
+	offset := new(big.Int).SetBytes(result[:32])
+	if !offset.IsInt64() || offset.Int64() > int64(len(result)-32) {
+		return "", fmt.Errorf("invalid string offset %s", offset)
+	}
+	start := int(offset.Int64())
+	length := new(big.Int).SetBytes(result[start : start+32])
+	if !length.IsInt64() || length.Int64() > int64(len(result)-start-32) {
+		return "", fmt.Errorf("invalid string length %s", length)
+	}
+	return string(result[start+32 : start+32+int(length.Int64())]), nil
+}
@@ -0,0 +1,187 @@
+package ens
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeccak256(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"},
+		{"abc", "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45"},
+	}
+
+	for _, tt := range tests {
+		hash := keccak256([]byte(tt.input))
+		if got := hex.EncodeToString(hash[:]); got != tt.expected {
+			t.Errorf("keccak256(%q) = %s, expected %s", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestNamehash(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"", "0000000000000000000000000000000000000000000000000000000000000000"},
+		{"eth", "93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae"},
+		{"foo.eth", "de9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f"},
+	}
+
+	for _, tt := range tests {
+		node := Namehash(tt.name)
+		if got := hex.EncodeToString(node[:]); got != tt.expected {
+			t.Errorf("Namehash(%q) = %s, expected %s", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	tests := map[string][]byte{
+		"0178b8bf": resolverSelector,
+		"3b3b57de": addrSelector,
+		"691f3431": nameSelector,
+	}
+	for expected, sel := range tests {
+		if got := hex.EncodeToString(sel); got != expected {
+			t.Errorf("expected selector %s, got %s", expected, got)
+		}
+	}
+}
+
+const (
+	testResolver = "0x4976fb03c32e5b8cfe2b6ccb31c09ba78ebaba41"
+	testAddress  = "0xd8da6bf26964af9d7eed9e03e53415d37aa96045"
+	otherAddress = "0x1111111111111111111111111111111111111111"
+)
+
+// fakeNode is a JSON-RPC server implementing the ENS registry and a single public
+// resolver with the given forward and reverse records.
+type fakeNode struct {
+	addrs map[string]string // name -> address
+	names map[string]string // address -> reverse name
+	calls atomic.Int64
+}
+
+func (f *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.calls.Add(1)
+
+	var req struct {
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var call struct {
+		To   string `json:"to"`
+		Data string `json:"data"`
+	}
+	json.Unmarshal(req.Params[0], &call)
+	data, _ := hex.DecodeString(strings.TrimPrefix(call.Data, "0x"))
+	sel, node := hex.EncodeToString(data[:4]), hex.EncodeToString(data[4:])
+
+	nodes := make(map[string]string)
+	for name := range f.addrs {
+		n := Namehash(name)
+		nodes[hex.EncodeToString(n[:])] = "name:" + name
+	}
+	for address := range f.names {
+		n := Namehash(strings.TrimPrefix(address, "0x") + ".addr.reverse")
+		nodes[hex.EncodeToString(n[:])] = "addr:" + address
+	}
+	record, known := nodes[node]
+
+	result := ""
+	switch {
+	case strings.EqualFold(call.To, RegistryAddress) && sel == "0178b8bf":
+		result = word("")
+		if known {
+			result = word(strings.TrimPrefix(testResolver, "0x"))
+		}
+	case call.To == testResolver && sel == "3b3b57de":
+		result = word(strings.TrimPrefix(f.addrs[strings.TrimPrefix(record, "name:")], "0x"))
+	case call.To == testResolver && sel == "691f3431":
+		name := f.names[strings.TrimPrefix(record, "addr:")]
+		result = word("20") + word(hex.EncodeToString([]byte{byte(len(name))}))
+		padded := make([]byte, (len(name)+31)/32*32)
+		copy(padded, name)
+		result += hex.EncodeToString(padded)
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": "0x" + result})
+}
+
+// word left pads a hex string to a 32 byte ABI word.
+func word(h string) string {
+	return strings.Repeat("0", 64-len(h)) + h
+}
+
+func TestResolver(t *testing.T) {
+	node := &fakeNode{
+		addrs: map[string]string{"vitalik.eth": testAddress, "fake.eth": testAddress},
+		names: map[string]string{testAddress: "vitalik.eth", otherAddress: "fake.eth"},
+	}
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	r := NewResolver(server.URL, time.Hour, 5*time.Second)
+	ctx := context.Background()
+
+	address, err := r.Resolve(ctx, "vitalik.eth")
+	if err != nil || address != testAddress {
+		t.Errorf("expected %s, got %q (%v)", testAddress, address, err)
+	}
+	if _, err := r.Resolve(ctx, "unknown.eth"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	name, err := r.LookupAddress(ctx, "0x"+strings.ToUpper(testAddress[2:]))
+	if err != nil || name != "vitalik.eth" {
+		t.Errorf("expected vitalik.eth, got %q (%v)", name, err)
+	}
+
+	// The reverse record of otherAddress claims a name that points elsewhere
+	name, err = r.LookupAddress(ctx, otherAddress)
+	if err != nil || name != "" {
+		t.Errorf("expected no verified name, got %q (%v)", name, err)
+	}
+
+	// Repeated lookups are served from cache
+	calls := node.calls.Load()
+	r.LookupAddress(ctx, testAddress)
+	r.Resolve(ctx, "unknown.eth")
+	if node.calls.Load() != calls {
+		t.Errorf("expected cached lookups, got %d more calls", node.calls.Load()-calls)
+	}
+}
+
+func TestIsName(t *testing.T) {
+	tests := map[string]bool{
+		"rocketpool.eth":   true,
+		"a.b.eth":          true,
+		"eth":              false,
+		"Vitalik.eth":      false,
+		testAddress:        false,
+		"has space.eth":    false,
+		"trailing.eth.":    false,
+		"lido-staking.eth": true,
+	}
+	for s, expected := range tests {
+		if got := IsName(s); got != expected {
+			t.Errorf("IsName(%q) = %v, expected %v", s, got, expected)
+		}
+	}
+}
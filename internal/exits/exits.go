@@ -0,0 +1,72 @@
+// Package exits records which validators have a pre-signed voluntary exit message
+// stored by their operator. Only metadata is kept; the signed messages themselves
+// never reach the server.
+package exits
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/jsonstore"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// ErrInvalid is returned for records that are not well formed.
+var ErrInvalid = errors.New("invalid pre-signed exit")
+
+// MaxLocationLength is the maximum length of the storage location of a message.
+const MaxLocationLength = 256
+
+// Store holds the pre-signed exit records of validators per chain. It is safe for
+// concurrent use.
+type Store struct {
+	records *jsonstore.Map[models.PresignedExit]
+}
+
+// NewStore creates an empty store kept in memory.
+func NewStore() *Store {
+	return &Store{records: jsonstore.New[models.PresignedExit]("pre-signed exits")}
+}
+
+// Load reads the records from path and persists changes to it. A missing file
+// starts an empty store.
+func (s *Store) Load(path string) error {
+	return s.records.Load(path)
+}
+
+// Get returns the record of a validator.
+func (s *Store) Get(chain string, index int) (models.PresignedExit, bool) {
+	return s.records.Get(chain, index)
+}
+
+// Has reports whether a validator has a pre-signed exit stored.
+func (s *Store) Has(chain string, index int) bool {
+	_, ok := s.Get(chain, index)
+	return ok
+}
+
+// Set records that a validator has a pre-signed exit, replacing any earlier record.
+func (s *Store) Set(chain string, index int, r models.PresignedExit) error {
+	if len(r.Location) > MaxLocationLength {
+		return fmt.Errorf("%w: location must be at most %d characters", ErrInvalid, MaxLocationLength)
+	}
+	if strings.IndexFunc(r.Location, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0 {
+		return fmt.Errorf("%w: location must be printable", ErrInvalid)
+	}
+	if r.StoredAt.IsZero() {
+		return fmt.Errorf("%w: storedAt must be set", ErrInvalid)
+	}
+	return s.records.Set(chain, index, r)
+}
+
+// Delete removes the record of a validator and reports whether it had one.
+func (s *Store) Delete(chain string, index int) (bool, error) {
+	return s.records.Delete(chain, index)
+}
+
+// All returns the records of every validator on chain, keyed by index.
+func (s *Store) All(chain string) map[int]models.PresignedExit {
+	return s.records.All(chain)
+}
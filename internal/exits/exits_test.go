@@ -0,0 +1,66 @@
+package exits
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exits.json")
+	s := NewStore()
+	if err := s.Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	storedAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	record := models.PresignedExit{StoredAt: storedAt, Location: "vault:ops/exits/1"}
+	for _, index := range []int{1, 2} {
+		if err := s.Set("mainnet", index, record); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	if err := s.Set("hoodi", 1, models.PresignedExit{StoredAt: storedAt}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		record models.PresignedExit
+	}{
+		{name: "no time", record: models.PresignedExit{Location: "vault"}},
+		{name: "long location", record: models.PresignedExit{StoredAt: storedAt, Location: strings.Repeat("a", MaxLocationLength+1)}},
+		{name: "unprintable location", record: models.PresignedExit{StoredAt: storedAt, Location: "vault\n"}},
+	}
+	for _, tt := range tests {
+		if err := s.Set("mainnet", 3, tt.record); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected ErrInvalid, got %v", tt.name, err)
+		}
+	}
+
+	if deleted, err := s.Delete("mainnet", 2); err != nil || !deleted {
+		t.Fatalf("expected validator 2 to be deleted, got %v, %v", deleted, err)
+	}
+	if deleted, err := s.Delete("mainnet", 2); err != nil || deleted {
+		t.Fatalf("expected nothing to delete, got %v, %v", deleted, err)
+	}
+
+	// A new store loads what the first one saved
+	loaded := NewStore()
+	if err := loaded.Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got, ok := loaded.Get("mainnet", 1); !ok || got != record {
+		t.Errorf("expected %+v for validator 1, got %+v", record, got)
+	}
+	if loaded.Has("mainnet", 2) || loaded.Has("mainnet", 3) {
+		t.Error("expected validators 2 and 3 to have no record")
+	}
+	if all := loaded.All("hoodi"); len(all) != 1 || !all[1].StoredAt.Equal(storedAt) {
+		t.Errorf("expected validator 1 on hoodi, got %v", all)
+	}
+}
@@ -0,0 +1,157 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// influxMeasurement is the measurement validator snapshots are written to.
+const influxMeasurement = "validator"
+
+// influxQueueSize is the number of fetches whose snapshots may wait to be written.
+// Snapshots of further fetches are dropped until the queue drains.
+const influxQueueSize = 64
+
+// InfluxExporter writes the snapshots of every fetch to InfluxDB through its v2
+// write API, one point per validator:
+//
+//	validator,chain=mainnet,validator=1 status="active_online",online=true,slashed=false,balance_gwei=32001234567i,effective_balance_gwei=32000000000i 1767225600000
+//
+// Snapshots are queued and written in the background by Run, so a slow or
+// unreachable InfluxDB never delays a request.
+type InfluxExporter struct {
+	writeURL   string
+	token      string
+	tags       string // Extra tags in line protocol, e.g. ",tenant=acme"
+	httpClient *http.Client
+	queue      chan []store.Snapshot
+}
+
+// NewInfluxExporter creates an exporter writing to bucket of org on the InfluxDB
+// at baseURL, authenticating with token.
+func NewInfluxExporter(baseURL, org, bucket, token string, timeout time.Duration) *InfluxExporter {
+	query := url.Values{"org": {org}, "bucket": {bucket}, "precision": {"ms"}}
+	return &InfluxExporter{
+		writeURL:   strings.TrimSuffix(baseURL, "/") + "/api/v2/write?" + query.Encode(),
+		token:      token,
+		httpClient: &http.Client{Timeout: timeout},
+		queue:      make(chan []store.Snapshot, influxQueueSize),
+	}
+}
+
+// SetTags sets tags added to every point, so that several exporters can write to
+// the same bucket.
+func (e *InfluxExporter) SetTags(tags map[string]string) {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString("," + escapeTag(k) + "=" + escapeTag(tags[k]))
+	}
+	e.tags = b.String()
+}
+
+// ExportSnapshots queues snapshots to be written. It implements
+// service.SnapshotExporter.
+func (e *InfluxExporter) ExportSnapshots(snapshots []store.Snapshot) {
+	select {
+	case e.queue <- snapshots:
+	default:
+		slog.Warn("influxdb export queue full, dropping snapshots", "snapshots", len(snapshots))
+	}
+}
+
+// Run writes queued snapshots until the context is canceled. Snapshots that
+// cannot be written are logged and dropped, like the rest of the history.
+func (e *InfluxExporter) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case snapshots := <-e.queue:
+			if err := e.write(ctx, snapshots); err != nil {
+				slog.Error("failed to write snapshots to influxdb", "snapshots", len(snapshots), "error", err)
+			}
+		}
+	}
+}
+
+// write sends snapshots to InfluxDB in line protocol.
+func (e *InfluxExporter) write(ctx context.Context, snapshots []store.Snapshot) error {
+	var body bytes.Buffer
+	for _, snap := range snapshots {
+		e.appendPoint(&body, snap)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.writeURL, &body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Token "+e.token)
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("write points: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("write points: status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// appendPoint writes the line of a snapshot. Balances are in gwei, like in the
+// Parquet export, so that they are exact integers.
+func (e *InfluxExporter) appendPoint(b *bytes.Buffer, snap store.Snapshot) {
+	b.WriteString(influxMeasurement)
+	b.WriteString(",chain=" + escapeTag(snap.Chain))
+	b.WriteString(e.tags)
+	b.WriteString(",validator=" + strconv.Itoa(snap.ValidatorIndex))
+
+	b.WriteString(` status="` + fieldEscaper.Replace(snap.Status) + `"`)
+	b.WriteString(",online=" + strconv.FormatBool(snap.Online))
+	b.WriteString(",slashed=" + strconv.FormatBool(snap.Slashed))
+	b.WriteString(",balance_gwei=" + gwei(snap.CurrentBalance) + "i")
+	b.WriteString(",effective_balance_gwei=" + gwei(snap.EffectiveBalance) + "i")
+
+	b.WriteString(" " + strconv.FormatInt(snap.Time.UnixMilli(), 10) + "\n")
+}
+
+// gwei converts a decimal wei amount into a decimal gwei amount, rounded toward
+// zero. Invalid amounts are 0.
+func gwei(wei string) string {
+	v, ok := new(big.Int).SetString(wei, 10)
+	if !ok {
+		return "0"
+	}
+	return v.Quo(v, weiPerGwei).String()
+}
+
+// tagEscaper escapes tag keys and values in line protocol.
+var tagEscaper = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
+
+// fieldEscaper escapes string field values in line protocol.
+var fieldEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// escapeTag escapes a tag key or value in line protocol.
+func escapeTag(s string) string {
+	return tagEscaper.Replace(s)
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+func TestInfluxExporter(t *testing.T) {
+	type request struct {
+		path, query, auth, body string
+	}
+	requests := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization"), string(body)}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	e := NewInfluxExporter(server.URL+"/", "home lab", "validators", "secret", time.Second)
+	e.SetTags(map[string]string{"tenant": "acme corp"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.Run(ctx)
+
+	t1 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	e.ExportSnapshots([]store.Snapshot{
+		{Time: t1, Chain: "mainnet", ValidatorIndex: 7, Status: "active_online", Online: true, CurrentBalance: "32004175273123456789", EffectiveBalance: "32000000000000000000"},
+		{Time: t1, Chain: "mainnet", ValidatorIndex: 8, Status: "exited", Slashed: true, CurrentBalance: "0", EffectiveBalance: "0"},
+	})
+
+	var got request
+	select {
+	case got = <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the snapshots to be written")
+	}
+
+	if got.path != "/api/v2/write" || got.query != "bucket=validators&org=home+lab&precision=ms" {
+		t.Errorf("expected a write to the bucket, got %s?%s", got.path, got.query)
+	}
+	if got.auth != "Token secret" {
+		t.Errorf("expected token auth, got %q", got.auth)
+	}
+	want := `validator,chain=mainnet,tenant=acme\ corp,validator=7 status="active_online",online=true,slashed=false,balance_gwei=32004175273i,effective_balance_gwei=32000000000i 1772366400000` + "\n" +
+		`validator,chain=mainnet,tenant=acme\ corp,validator=8 status="exited",online=false,slashed=true,balance_gwei=0i,effective_balance_gwei=0i 1772366400000` + "\n"
+	if got.body != want {
+		t.Errorf("expected points\n%s\ngot\n%s", want, got.body)
+	}
+}
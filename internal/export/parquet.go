@@ -0,0 +1,221 @@
+// Package export writes the snapshot history to files for offline analysis, and
+// to time series databases as it is recorded.
+package export
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// monthLayout names the exported files.
+const monthLayout = "2006-01"
+
+// weiPerGwei converts wei balances into gwei, which fits in an int64 column.
+var weiPerGwei = big.NewInt(1e9)
+
+// SnapshotRow is a row of the snapshots Parquet file.
+type SnapshotRow struct {
+	Time                 time.Time `parquet:"time,timestamp(millisecond)"`
+	Chain                string    `parquet:"chain,dict"`
+	ValidatorIndex       int64     `parquet:"validator_index"`
+	Status               string    `parquet:"status,dict"`
+	Online               bool      `parquet:"online"`
+	Slashed              bool      `parquet:"slashed"`
+	CurrentBalanceGwei   int64     `parquet:"current_balance_gwei"`
+	EffectiveBalanceGwei int64     `parquet:"effective_balance_gwei"`
+	ActivationEpoch      int64     `parquet:"activation_epoch"`
+	ExitEpoch            int64     `parquet:"exit_epoch"`
+}
+
+// EventRow is a row of the events Parquet file.
+type EventRow struct {
+	Time           time.Time `parquet:"time,timestamp(millisecond)"`
+	Chain          string    `parquet:"chain,dict"`
+	ValidatorIndex int64     `parquet:"validator_index"`
+	Type           string    `parquet:"type,dict"`
+	From           string    `parquet:"from"`
+	To             string    `parquet:"to"`
+}
+
+// ParquetExporter writes one snapshots and one events Parquet file per month:
+//
+//	<dir>/snapshots-2026-01.parquet
+//	<dir>/events-2026-01.parquet
+type ParquetExporter struct {
+	store store.Store
+	dir   string
+
+	// Optional cold tier for files older than the local retention
+	cold            store.ObjectStorage
+	retentionMonths int
+}
+
+// NewParquetExporter creates an exporter writing the history in st to dir.
+func NewParquetExporter(st store.Store, dir string) (*ParquetExporter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create export directory: %w", err)
+	}
+	return &ParquetExporter{store: st, dir: dir}, nil
+}
+
+// SetColdStorage enables moving the files of months older than retentionMonths
+// full months, besides the current one, to cold under reports/<file name>.
+// The previous month is always kept, since it is still re-exported.
+func (e *ParquetExporter) SetColdStorage(cold store.ObjectStorage, retentionMonths int) {
+	e.cold = cold
+	e.retentionMonths = retentionMonths
+}
+
+// Run exports the current and previous month immediately and then every interval
+// until the context is canceled. Re-exporting the previous month makes sure the
+// records written after the last run of that month end up in its final file.
+func (e *ParquetExporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now().UTC()
+		current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		for _, month := range []time.Time{current.AddDate(0, -1, 0), current} {
+			if err := e.ExportMonth(ctx, month); err != nil {
+				slog.Error("parquet export failed", "month", month.Format(monthLayout), "error", err)
+			}
+		}
+		if e.cold != nil {
+			cutoff := current.AddDate(0, -max(e.retentionMonths, 1), 0)
+			if err := e.Archive(ctx, cutoff); err != nil {
+				slog.Error("parquet archive failed", "error", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExportMonth writes the snapshots and events of the month containing t.
+// Months without any snapshots are skipped.
+func (e *ParquetExporter) ExportMonth(ctx context.Context, t time.Time) error {
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	q := store.Query{From: from, To: from.AddDate(0, 1, 0)}
+	month := from.Format(monthLayout)
+
+	snapshots, err := e.store.Snapshots(ctx, q)
+	if err != nil {
+		return fmt.Errorf("read snapshots: %w", err)
+	}
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	events, err := e.store.Events(ctx, q)
+	if err != nil {
+		return fmt.Errorf("read events: %w", err)
+	}
+
+	snapshotRows := make([]SnapshotRow, len(snapshots))
+	for i, s := range snapshots {
+		snapshotRows[i] = SnapshotRow{
+			Time:                 s.Time,
+			Chain:                s.Chain,
+			ValidatorIndex:       int64(s.ValidatorIndex),
+			Status:               s.Status,
+			Online:               s.Online,
+			Slashed:              s.Slashed,
+			CurrentBalanceGwei:   weiToGwei(s.CurrentBalance),
+			EffectiveBalanceGwei: weiToGwei(s.EffectiveBalance),
+			ActivationEpoch:      s.ActivationEpoch,
+			ExitEpoch:            s.ExitEpoch,
+		}
+	}
+
+	eventRows := make([]EventRow, len(events))
+	for i, ev := range events {
+		eventRows[i] = EventRow{
+			Time:           ev.Time,
+			Chain:          ev.Chain,
+			ValidatorIndex: int64(ev.ValidatorIndex),
+			Type:           string(ev.Type),
+			From:           ev.From,
+			To:             ev.To,
+		}
+	}
+
+	if err := writeFile(filepath.Join(e.dir, "snapshots-"+month+".parquet"), snapshotRows); err != nil {
+		return fmt.Errorf("write snapshots: %w", err)
+	}
+	if err := writeFile(filepath.Join(e.dir, "events-"+month+".parquet"), eventRows); err != nil {
+		return fmt.Errorf("write events: %w", err)
+	}
+
+	slog.Info("parquet export written", "month", month, "snapshots", len(snapshotRows), "events", len(eventRows))
+	return nil
+}
+
+// Archive uploads the files of months starting before cutoff to cold storage and
+// removes them locally once uploaded.
+func (e *ParquetExporter) Archive(ctx context.Context, cutoff time.Time) error {
+	paths, err := filepath.Glob(filepath.Join(e.dir, "*.parquet"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		name := filepath.Base(path)
+		// Files are named <kind>-<month>.parquet
+		trimmed := strings.TrimSuffix(name, ".parquet")
+		if len(trimmed) < len(monthLayout) {
+			continue
+		}
+		month, err := time.Parse(monthLayout, trimmed[len(trimmed)-len(monthLayout):])
+		if err != nil || !month.Before(cutoff) {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := e.cold.Put(ctx, "reports/"+name, data); err != nil {
+			return fmt.Errorf("upload %s: %w", name, err)
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		slog.Info("archived parquet export", "file", name)
+	}
+	return nil
+}
+
+// writeFile writes rows to a temporary file and renames it into place,
+// so readers never see a partially written file.
+func writeFile[T any](path string, rows []T) error {
+	tmp := path + ".tmp"
+	if err := parquet.WriteFile(tmp, rows); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// weiToGwei converts a decimal wei string into gwei, returning 0 for invalid input.
+func weiToGwei(wei string) int64 {
+	v, ok := new(big.Int).SetString(wei, 10)
+	if !ok {
+		return 0
+	}
+	return v.Quo(v, weiPerGwei).Int64()
+}
@@ -0,0 +1,71 @@
+package export
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+func TestParquetExporter_ExportMonth(t *testing.T) {
+	ctx := context.Background()
+
+	st, err := store.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+
+	t1 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	t2 := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	for _, snap := range []store.Snapshot{
+		{Time: t1, Chain: "mainnet", ValidatorIndex: 7, Status: "active_online", Online: true, CurrentBalance: "32004175273000000000"},
+		{Time: t2, Chain: "mainnet", ValidatorIndex: 7, Status: "active_offline", CurrentBalance: "32004000000000000000"},
+	} {
+		if err := st.RecordSnapshots(ctx, []store.Snapshot{snap}); err != nil {
+			t.Fatalf("RecordSnapshots failed: %v", err)
+		}
+	}
+
+	dir := t.TempDir()
+	exporter, err := NewParquetExporter(st, dir)
+	if err != nil {
+		t.Fatalf("NewParquetExporter failed: %v", err)
+	}
+	if err := exporter.ExportMonth(ctx, t1); err != nil {
+		t.Fatalf("ExportMonth failed: %v", err)
+	}
+
+	snapshots, err := parquet.ReadFile[SnapshotRow](filepath.Join(dir, "snapshots-2026-03.parquet"))
+	if err != nil {
+		t.Fatalf("read snapshots: %v", err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("expected 2 snapshot rows, got %d", len(snapshots))
+	}
+	if snapshots[0].CurrentBalanceGwei != 32004175273 || !snapshots[0].Time.Equal(t1) {
+		t.Errorf("unexpected first snapshot row: %+v", snapshots[0])
+	}
+
+	events, err := parquet.ReadFile[EventRow](filepath.Join(dir, "events-2026-03.parquet"))
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 event rows, got %d", len(events))
+	}
+	if events[0].Type != string(store.EventStatusChanged) || events[0].To != "active_offline" {
+		t.Errorf("unexpected first event row: %+v", events[0])
+	}
+
+	// Months without data produce no files
+	if err := exporter.ExportMonth(ctx, t1.AddDate(0, 1, 0)); err != nil {
+		t.Fatalf("ExportMonth failed: %v", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*2026-04*")); len(matches) != 0 {
+		t.Errorf("expected no files for an empty month, got %v", matches)
+	}
+}
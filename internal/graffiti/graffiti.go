@@ -0,0 +1,75 @@
+// Package graffiti infers the clients that built a block from its graffiti.
+package graffiti
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Clients are the clients a block was built with, empty when unknown.
+type Clients struct {
+	Consensus string
+	Execution string
+}
+
+// Client codes of the client version graffiti consensus clients append by
+// default, e.g. "GE4a2bLH1b2c" for geth at commit 4a2b... with Lighthouse at
+// 1b2c.... Codes are those of engine_getClientVersionV1.
+var (
+	consensusCodes = map[string]string{
+		"GR": "grandine",
+		"LH": "lighthouse",
+		"LS": "lodestar",
+		"NB": "nimbus",
+		"PM": "prysm",
+		"TK": "teku",
+	}
+	executionCodes = map[string]string{
+		"BU": "besu",
+		"EG": "erigon",
+		"EJ": "ethereumjs",
+		"GE": "geth",
+		"NM": "nethermind",
+		"RH": "reth",
+		"TE": "trin",
+	}
+)
+
+var (
+	// versionPattern matches client version graffiti at the end of the graffiti,
+	// with four, two or no commit hex digits depending on the space left.
+	versionPattern = regexp.MustCompile(`(?:^|\s)([A-Z]{2})(?:[0-9a-f]{4}|[0-9a-f]{2})?([A-Z]{2})(?:[0-9a-f]{4}|[0-9a-f]{2})?$`)
+
+	// Client names operators and client defaults put into graffiti, e.g.
+	// "Lighthouse/v5.3.0-d6ba8c3".
+	consensusNames = regexp.MustCompile(`(?i)\b(grandine|lighthouse|lodestar|nimbus|prysm|teku)\b`)
+	executionNames = regexp.MustCompile(`(?i)\b(besu|erigon|geth|nethermind|reth)\b`)
+)
+
+// Fingerprint infers the clients from graffiti, preferring client version codes
+// over client names.
+func Fingerprint(graffiti string) Clients {
+	graffiti = Clean(graffiti)
+	if m := versionPattern.FindStringSubmatch(graffiti); m != nil {
+		execution, okEL := executionCodes[m[1]]
+		consensus, okCL := consensusCodes[m[2]]
+		if okEL && okCL {
+			return Clients{Consensus: consensus, Execution: execution}
+		}
+	}
+
+	var clients Clients
+	if m := consensusNames.FindStringSubmatch(graffiti); m != nil {
+		clients.Consensus = strings.ToLower(m[1])
+	}
+	if m := executionNames.FindStringSubmatch(graffiti); m != nil {
+		clients.Execution = strings.ToLower(m[1])
+	}
+	return clients
+}
+
+// Clean strips the zero padding of the 32 byte graffiti field and surrounding
+// whitespace.
+func Clean(graffiti string) string {
+	return strings.TrimSpace(strings.TrimRight(graffiti, "\x00"))
+}
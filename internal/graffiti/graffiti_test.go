@@ -0,0 +1,25 @@
+package graffiti
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		graffiti string
+		want     Clients
+	}{
+		{graffiti: "GE4a2bLH1b2c", want: Clients{Consensus: "lighthouse", Execution: "geth"}},
+		{graffiti: "stakefish NM4aTK1b", want: Clients{Consensus: "teku", Execution: "nethermind"}},
+		{graffiti: "my node RHPM\x00\x00\x00", want: Clients{Consensus: "prysm", Execution: "reth"}},
+		{graffiti: "Lighthouse/v5.3.0-d6ba8c3", want: Clients{Consensus: "lighthouse"}},
+		{graffiti: "nimbus + besu", want: Clients{Consensus: "nimbus", Execution: "besu"}},
+		{graffiti: "Lodestar-GE4a2bXX1b2c", want: Clients{Consensus: "lodestar"}}, // Unknown code
+		{graffiti: "HELLO", want: Clients{}},
+		{graffiti: "reTHINK", want: Clients{}},
+		{graffiti: "", want: Clients{}},
+	}
+	for _, tt := range tests {
+		if got := Fingerprint(tt.graffiti); got != tt.want {
+			t.Errorf("Fingerprint(%q) = %+v, want %+v", tt.graffiti, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Package heartbeat pings a dead man's switch, such as healthchecks.io, while the
+// dashboard runs and its validators are healthy. When the pings stop, because the
+// dashboard is down or the fleet is not healthy, the service raises the alert.
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+// Range is the evaluation range the portfolio data is checked with.
+const Range = "24h"
+
+// Source provides the portfolio data the fleet health is checked with.
+type Source interface {
+	GetPortfolioData(ctx context.Context, name, evalRange string) (models.ValidatorResponse, error)
+}
+
+// Monitor checks the portfolios and pings url while they are healthy. When they
+// are not, failURL is pinged if set, otherwise the ping is skipped so that the
+// silence raises the alert. Pings are POSTs whose body summarizes the check,
+// which healthchecks.io shows with the ping.
+type Monitor struct {
+	portfolios *portfolio.Registry
+	source     Source
+	url        string
+	failURL    string
+	maxOffline int // Active validators allowed offline across portfolios
+	httpClient *http.Client
+}
+
+// NewMonitor creates a monitor of the portfolios in registry.
+func NewMonitor(registry *portfolio.Registry, source Source, url, failURL string, maxOffline int, timeout time.Duration) *Monitor {
+	return &Monitor{
+		portfolios: registry,
+		source:     source,
+		url:        url,
+		failURL:    failURL,
+		maxOffline: maxOffline,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Run beats immediately and then every interval until the context is canceled.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Beat(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("failed to send heartbeat", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Beat checks the fleet and pings accordingly.
+func (m *Monitor) Beat(ctx context.Context) error {
+	summary, problems := m.check(ctx)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if len(problems) == 0 {
+		return m.ping(ctx, m.url, summary)
+	}
+
+	slog.Warn("fleet unhealthy, withholding heartbeat", "problems", strings.Join(problems, "; "))
+	if m.failURL == "" {
+		return nil
+	}
+	return m.ping(ctx, m.failURL, strings.Join(problems, "\n"))
+}
+
+// check returns a summary of the fleet and its problems: portfolios that cannot
+// be loaded, and more active validators offline than allowed. Data fetched
+// during the current epoch is served from cache.
+func (m *Monitor) check(ctx context.Context) (string, []string) {
+	var problems, offlineProblems []string
+	var active, offline int
+	for _, pf := range m.portfolios.All() {
+		data, err := m.source.GetPortfolioData(ctx, pf.Name, Range)
+		if ctx.Err() != nil {
+			return "", nil
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: failed to load: %v", pf.Name, err))
+			continue
+		}
+
+		var down []int
+		for key, o := range data.Validators {
+			if !strings.HasPrefix(o.Status, "active") {
+				continue
+			}
+			active++
+			if !o.Online {
+				id, _ := strconv.Atoi(key)
+				down = append(down, id)
+			}
+		}
+		if len(down) > 0 {
+			sort.Ints(down)
+			offline += len(down)
+			offlineProblems = append(offlineProblems, fmt.Sprintf("%s: %d validators offline: %s", pf.Name, len(down), joinInts(down)))
+		}
+	}
+	if offline > m.maxOffline {
+		problems = append(problems, offlineProblems...)
+	}
+	return fmt.Sprintf("%d of %d active validators online", active-offline, active), problems
+}
+
+// ping POSTs body to target and fails on any status but 2xx.
+func (m *Monitor) ping(ctx context.Context, target, body string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ping: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("ping: status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// joinInts joins ids with commas.
+func joinInts(ids []int) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.Itoa(id)
+	}
+	return strings.Join(s, ",")
+}
@@ -0,0 +1,94 @@
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+// fakeSource serves portfolio data by name, failing for unknown portfolios.
+type fakeSource map[string]models.ValidatorResponse
+
+func (f fakeSource) GetPortfolioData(ctx context.Context, name, evalRange string) (models.ValidatorResponse, error) {
+	data, ok := f[name]
+	if !ok {
+		return models.ValidatorResponse{}, errors.New("upstream unavailable")
+	}
+	return data, nil
+}
+
+func TestMonitor_Beat(t *testing.T) {
+	healthy := models.ValidatorResponse{Validators: map[string]models.ValidatorOverview{
+		"1": {Status: "active_online", Online: true},
+		"2": {Status: "exited"},
+	}}
+	degraded := models.ValidatorResponse{Validators: map[string]models.ValidatorOverview{
+		"10": {Status: "active_offline"},
+		"3":  {Status: "active_offline"},
+		"4":  {Status: "active_online", Online: true},
+	}}
+
+	tests := []struct {
+		name       string
+		source     fakeSource
+		failURL    bool
+		maxOffline int
+		wantPath   string // Empty expects no ping
+		wantBody   string
+	}{
+		{"healthy", fakeSource{"home": healthy, "lab": healthy}, true, 0, "/ping", "2 of 2 active validators online"},
+		{"offline with fail URL", fakeSource{"home": healthy, "lab": degraded}, true, 0, "/ping/fail", "lab: 2 validators offline: 3,10"},
+		{"offline without fail URL", fakeSource{"home": healthy, "lab": degraded}, false, 0, "", ""},
+		{"offline within tolerance", fakeSource{"home": healthy, "lab": degraded}, true, 2, "/ping", "2 of 4 active validators online"},
+		{"portfolio not loading", fakeSource{"home": healthy}, true, 0, "/ping/fail", "lab: failed to load: upstream unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path, body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				path, body = r.URL.Path, string(b)
+			}))
+			defer server.Close()
+
+			registry, err := portfolio.NewRegistry([]portfolio.Portfolio{
+				{Name: "home", Chain: "mainnet", ValidatorIds: []int{1, 2}},
+				{Name: "lab", Chain: "mainnet", ValidatorIds: []int{3, 4, 10}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			failURL := ""
+			if tt.failURL {
+				failURL = server.URL + "/ping/fail"
+			}
+			m := NewMonitor(registry, tt.source, server.URL+"/ping", failURL, tt.maxOffline, time.Second)
+			if err := m.Beat(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if path != tt.wantPath || body != tt.wantBody {
+				t.Errorf("expected ping %q with %q, got %q with %q", tt.wantPath, tt.wantBody, path, body)
+			}
+		})
+	}
+}
+
+func TestMonitor_Beat_Status(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	registry, _ := portfolio.NewRegistry(nil)
+	m := NewMonitor(registry, fakeSource{}, server.URL, "", 0, time.Second)
+	if err := m.Beat(context.Background()); err == nil {
+		t.Error("expected an error for a rejected ping")
+	}
+}
@@ -0,0 +1,198 @@
+// Package jobs runs requests too large to answer interactively, such as reports
+// over thousands of validators, in the background. Clients submit a job, poll its
+// status and fetch the result once it is done.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// ErrQueueFull is returned when too many jobs are waiting to run.
+var ErrQueueFull = errors.New("job queue full")
+
+// Statuses of a job.
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// pruneInterval is how often finished jobs past their retention are removed.
+const pruneInterval = time.Minute
+
+// Func does the work of a job and returns its result. It reports the number of
+// items done so far through progress.
+type Func func(ctx context.Context, progress func(done int)) (any, error)
+
+// job is a submitted job and its outcome.
+type job struct {
+	status models.Job
+	run    Func
+	result any
+}
+
+// Manager queues jobs and runs them one at a time, so a job shares the upstream
+// rate limit with interactive requests rather than competing with other jobs. It
+// is safe for concurrent use.
+type Manager struct {
+	retention time.Duration
+	maxQueued int
+
+	mu    sync.Mutex
+	jobs  map[string]*job
+	queue []*job // Oldest first
+	wake  chan struct{}
+}
+
+// NewManager creates a manager that keeps finished jobs for retention and accepts
+// at most maxQueued jobs waiting to run.
+func NewManager(retention time.Duration, maxQueued int) *Manager {
+	return &Manager{
+		retention: retention,
+		maxQueued: maxQueued,
+		jobs:      make(map[string]*job),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Submit queues a job of the given type over total items and returns its status.
+func (m *Manager) Submit(jobType string, total int, run Func) (models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.queue) >= m.maxQueued {
+		return models.Job{}, ErrQueueFull
+	}
+
+	var id [8]byte
+	rand.Read(id[:])
+	j := &job{
+		status: models.Job{
+			ID:        hex.EncodeToString(id[:]),
+			Type:      jobType,
+			Status:    StatusQueued,
+			Total:     total,
+			CreatedAt: time.Now().UTC(),
+		},
+		run: run,
+	}
+	m.jobs[j.status.ID] = j
+	m.queue = append(m.queue, j)
+
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+	return j.status, nil
+}
+
+// Get returns the status of a job.
+func (m *Manager) Get(id string) (models.Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return models.Job{}, false
+	}
+	return j.status, true
+}
+
+// Result returns the status of a job and its result, which is nil until the job is done.
+func (m *Manager) Result(id string) (models.Job, any, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return models.Job{}, nil, false
+	}
+	return j.status, j.result, true
+}
+
+// Run runs queued jobs until the context is canceled. A job running at that point
+// fails, and jobs still queued are not started.
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		for j := m.next(); j != nil && ctx.Err() == nil; j = m.next() {
+			m.execute(ctx, j)
+		}
+		m.prune(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// next takes the oldest queued job and marks it running, or returns nil.
+func (m *Manager) next() *job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.queue) == 0 {
+		return nil
+	}
+	j := m.queue[0]
+	m.queue = m.queue[1:]
+
+	now := time.Now().UTC()
+	j.status.Status = StatusRunning
+	j.status.StartedAt = &now
+	return j
+}
+
+// execute runs a job and records its outcome.
+func (m *Manager) execute(ctx context.Context, j *job) {
+	progress := func(done int) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		j.status.Done = done
+	}
+	result, err := j.run(ctx, progress)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	expires := now.Add(m.retention)
+	j.status.FinishedAt = &now
+	j.status.ExpiresAt = &expires
+	j.run = nil
+	if err != nil {
+		slog.Error("job failed", "id", j.status.ID, "type", j.status.Type, "error", err)
+		j.status.Status = StatusFailed
+		j.status.Error = err.Error()
+		return
+	}
+	j.status.Status = StatusDone
+	j.status.Done = j.status.Total
+	j.result = result
+}
+
+// prune removes finished jobs past their retention.
+func (m *Manager) prune(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, j := range m.jobs {
+		if j.status.ExpiresAt != nil && now.After(*j.status.ExpiresAt) {
+			delete(m.jobs, id)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitFor polls the status of a job until it reaches status.
+func waitFor(t *testing.T, m *Manager, id, status string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if job, _ := m.Get(id); job.Status == status {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	job, _ := m.Get(id)
+	t.Fatalf("job %s is %s, expected %s", id, job.Status, status)
+}
+
+func TestManager(t *testing.T) {
+	m := NewManager(time.Hour, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	release := make(chan struct{})
+	first, err := m.Submit("report", 2, func(ctx context.Context, progress func(int)) (any, error) {
+		progress(1)
+		<-release
+		return "result", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := m.Submit("report", 1, func(ctx context.Context, progress func(int)) (any, error) {
+		return nil, errors.New("upstream unavailable")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Status != StatusQueued || first.ID == second.ID {
+		t.Fatalf("expected two queued jobs, got %+v and %+v", first, second)
+	}
+
+	go m.Run(ctx)
+
+	// Jobs run one at a time, reporting progress
+	waitFor(t, m, first.ID, StatusRunning)
+	if job, _ := m.Get(first.ID); job.Done != 1 || job.Total != 2 {
+		t.Errorf("expected 1 of 2 done, got %d of %d", job.Done, job.Total)
+	}
+	if job, _ := m.Get(second.ID); job.Status != StatusQueued {
+		t.Errorf("expected the second job to wait, got %s", job.Status)
+	}
+	close(release)
+
+	waitFor(t, m, first.ID, StatusDone)
+	if job, result, _ := m.Result(first.ID); result != "result" || job.Done != 2 || job.ExpiresAt == nil {
+		t.Errorf("expected the result of a finished job, got %v (%+v)", result, job)
+	}
+
+	waitFor(t, m, second.ID, StatusFailed)
+	if job, result, _ := m.Result(second.ID); result != nil || job.Error != "upstream unavailable" {
+		t.Errorf("expected the error of a failed job, got %v (%+v)", result, job)
+	}
+
+	// Finished jobs are removed after their retention
+	m.prune(time.Now().Add(2 * time.Hour))
+	if _, ok := m.Get(first.ID); ok {
+		t.Error("expected the expired job to be removed")
+	}
+}
+
+func TestManager_QueueFull(t *testing.T) {
+	m := NewManager(time.Hour, 1)
+	run := func(ctx context.Context, progress func(int)) (any, error) { return nil, nil }
+
+	if _, err := m.Submit("report", 1, run); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Submit("report", 1, run); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+}
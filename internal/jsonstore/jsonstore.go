@@ -0,0 +1,141 @@
+// Package jsonstore keeps a value per validator, keyed by chain and index, in
+// memory and optionally in a JSON file that is rewritten on every change.
+package jsonstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Map holds a value of type V per validator. It is safe for concurrent use.
+// Values are returned as stored, so callers must not modify them in place.
+type Map[V any] struct {
+	mu     sync.Mutex
+	name   string // What the values are in errors, e.g. "labels"
+	path   string
+	values map[string]V // keyed by chain/index
+}
+
+// New creates an empty map kept in memory. name describes the values in errors.
+func New[V any](name string) *Map[V] {
+	return &Map[V]{name: name, values: make(map[string]V)}
+}
+
+// Load reads the values from path and persists changes to it. A missing file
+// starts an empty map.
+func (m *Map[V]) Load(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.path = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", m.name, err)
+	}
+
+	if err := json.Unmarshal(data, &m.values); err != nil {
+		return fmt.Errorf("decode %s: %w", m.name, err)
+	}
+	return nil
+}
+
+// Get returns the value of a validator.
+func (m *Map[V]) Get(chain string, index int) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.values[key(chain, index)]
+	return v, ok
+}
+
+// Set replaces the value of a validator. The previous value is kept if the change
+// cannot be saved.
+func (m *Map[V]) Set(chain string, index int, v V) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := key(chain, index)
+	previous, had := m.values[k]
+	m.values[k] = v
+	if err := m.save(); err != nil {
+		if had {
+			m.values[k] = previous
+		} else {
+			delete(m.values, k)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes the value of a validator and reports whether it had one. The
+// value is kept if the change cannot be saved.
+func (m *Map[V]) Delete(chain string, index int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := key(chain, index)
+	previous, ok := m.values[k]
+	if !ok {
+		return false, nil
+	}
+	delete(m.values, k)
+	if err := m.save(); err != nil {
+		m.values[k] = previous
+		return false, err
+	}
+	return true, nil
+}
+
+// All returns the values of every validator on chain, keyed by index.
+func (m *Map[V]) All(chain string) map[int]V {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make(map[int]V)
+	for k, v := range m.values {
+		if c, index, ok := parseKey(k); ok && c == chain {
+			result[index] = v
+		}
+	}
+	return result
+}
+
+// save atomically writes the values, if a path is configured. Callers must hold m.mu.
+func (m *Map[V]) save() error {
+	if m.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(m.values)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", m.name, err)
+	}
+
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", m.name, err)
+	}
+	return os.Rename(tmp, m.path)
+}
+
+func key(chain string, index int) string {
+	return chain + "/" + strconv.Itoa(index)
+}
+
+func parseKey(k string) (string, int, bool) {
+	chain, index, ok := strings.Cut(k, "/")
+	if !ok {
+		return "", 0, false
+	}
+	i, err := strconv.Atoi(index)
+	return chain, i, err == nil
+}
@@ -0,0 +1,84 @@
+package jsonstore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "values.json")
+	m := New[string]("values")
+	if err := m.Load(path); err != nil {
+		t.Fatalf("Load of a missing file failed: %v", err)
+	}
+
+	for _, v := range []struct {
+		chain string
+		index int
+		value string
+	}{
+		{"mainnet", 1, "a"},
+		{"mainnet", 2, "b"},
+		{"hoodi", 1, "c"},
+	} {
+		if err := m.Set(v.chain, v.index, v.value); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	if deleted, err := m.Delete("mainnet", 2); err != nil || !deleted {
+		t.Fatalf("expected validator 2 to be deleted, got %v, %v", deleted, err)
+	}
+	if deleted, err := m.Delete("mainnet", 3); err != nil || deleted {
+		t.Errorf("expected nothing to delete for validator 3, got %v, %v", deleted, err)
+	}
+
+	reloaded := New[string]("values")
+	if err := reloaded.Load(path); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if v, ok := reloaded.Get("mainnet", 1); !ok || v != "a" {
+		t.Errorf("expected the value to survive a reload, got %q, %v", v, ok)
+	}
+	if _, ok := reloaded.Get("mainnet", 2); ok {
+		t.Error("expected the deleted value to stay deleted")
+	}
+	if all := reloaded.All("hoodi"); len(all) != 1 || all[1] != "c" {
+		t.Errorf("expected the value of hoodi only, got %v", all)
+	}
+}
+
+func TestMap_LoadMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "values.json")
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := New[string]("values").Load(path); err == nil || !strings.Contains(err.Error(), "decode values") {
+		t.Errorf("expected a decode error naming the values, got %v", err)
+	}
+}
+
+func TestMap_SaveFailure(t *testing.T) {
+	m := New[string]("values")
+	if err := m.Set("mainnet", 1, "a"); err != nil {
+		t.Fatal(err)
+	}
+	// Saves fail since the directory does not exist
+	if err := m.Load(filepath.Join(t.TempDir(), "missing", "values.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Set("mainnet", 1, "b"); err == nil {
+		t.Error("expected replacing a value to fail")
+	}
+	if err := m.Set("mainnet", 2, "c"); err == nil {
+		t.Error("expected adding a value to fail")
+	}
+	if deleted, err := m.Delete("mainnet", 1); err == nil || deleted {
+		t.Errorf("expected deleting a value to fail, got %v, %v", deleted, err)
+	}
+	if all := m.All("mainnet"); len(all) != 1 || all[1] != "a" {
+		t.Errorf("expected the values before the failed changes, got %v", all)
+	}
+}
@@ -0,0 +1,129 @@
+// Package labels stores user-defined key/value labels of validators, such as
+// machine=node-3 or client=lighthouse, and selects validators by label.
+package labels
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/jsonstore"
+)
+
+// ErrInvalid is returned for labels and selectors that are not well formed.
+var ErrInvalid = errors.New("invalid label")
+
+// Limits of the labels of a validator.
+const (
+	MaxLabels      = 32
+	MaxValueLength = 128
+)
+
+var keyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._/-]{0,62}$`)
+
+// Selector matches validators whose label Key has Value.
+type Selector struct {
+	Key   string
+	Value string
+}
+
+// ParseSelector parses a selector of the form "key:value" or "key=value".
+func ParseSelector(s string) (Selector, error) {
+	i := strings.IndexAny(s, ":=")
+	if i < 0 {
+		return Selector{}, fmt.Errorf("%w: selector %q must be key:value", ErrInvalid, s)
+	}
+	sel := Selector{Key: s[:i], Value: s[i+1:]}
+	if err := validate(sel.Key, sel.Value); err != nil {
+		return Selector{}, err
+	}
+	return sel, nil
+}
+
+// ValidateKey checks that key is a well-formed label key.
+func ValidateKey(key string) error {
+	if !keyPattern.MatchString(key) {
+		return fmt.Errorf("%w: key %q must be 1-63 lowercase letters, digits, '.', '_', '/' or '-'", ErrInvalid, key)
+	}
+	return nil
+}
+
+// validate checks a single label.
+func validate(key, value string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	if value == "" || len(value) > MaxValueLength {
+		return fmt.Errorf("%w: value of %q must be 1-%d characters", ErrInvalid, key, MaxValueLength)
+	}
+	if strings.IndexFunc(value, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0 {
+		return fmt.Errorf("%w: value of %q must be printable", ErrInvalid, key)
+	}
+	return nil
+}
+
+// Store holds the labels of validators per chain. It is safe for concurrent use.
+// Stored label maps are never modified in place, only replaced.
+type Store struct {
+	labels *jsonstore.Map[map[string]string]
+}
+
+// NewStore creates an empty store kept in memory.
+func NewStore() *Store {
+	return &Store{labels: jsonstore.New[map[string]string]("labels")}
+}
+
+// Load reads the labels from path and persists changes to it. A missing file
+// starts an empty store.
+func (s *Store) Load(path string) error {
+	return s.labels.Load(path)
+}
+
+// Get returns the labels of a validator, or nil if it has none.
+func (s *Store) Get(chain string, index int) map[string]string {
+	labels, _ := s.labels.Get(chain, index)
+	return maps.Clone(labels)
+}
+
+// Set replaces the labels of a validator. No labels remove them all.
+func (s *Store) Set(chain string, index int, labels map[string]string) error {
+	if len(labels) > MaxLabels {
+		return fmt.Errorf("%w: at most %d labels per validator", ErrInvalid, MaxLabels)
+	}
+	for k, v := range labels {
+		if err := validate(k, v); err != nil {
+			return err
+		}
+	}
+
+	if len(labels) == 0 {
+		_, err := s.labels.Delete(chain, index)
+		return err
+	}
+	return s.labels.Set(chain, index, maps.Clone(labels))
+}
+
+// All returns the labels of every labeled validator on chain, keyed by index.
+func (s *Store) All(chain string) map[int]map[string]string {
+	result := s.labels.All(chain)
+	for index, labels := range result {
+		result[index] = maps.Clone(labels)
+	}
+	return result
+}
+
+// Select returns the validators on chain matching all selectors, in ascending order.
+func (s *Store) Select(chain string, selectors []Selector) []int {
+	var result []int
+	for index, labels := range s.labels.All(chain) {
+		if !slices.ContainsFunc(selectors, func(sel Selector) bool { return labels[sel.Key] != sel.Value }) {
+			result = append(result, index)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
@@ -0,0 +1,86 @@
+package labels
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Selector
+		wantErr bool
+	}{
+		{input: "machine:node-3", want: Selector{Key: "machine", Value: "node-3"}},
+		{input: "client=lighthouse", want: Selector{Key: "client", Value: "lighthouse"}},
+		{input: "url:http://node:5052", want: Selector{Key: "url", Value: "http://node:5052"}},
+		{input: "machine", wantErr: true},
+		{input: "machine:", wantErr: true},
+		{input: "Machine:node-3", wantErr: true},
+		{input: ":node-3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSelector(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error: %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "labels.json")
+	s := NewStore()
+	if err := s.Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	for index, l := range map[int]map[string]string{
+		1: {"machine": "node-3", "client": "lighthouse"},
+		2: {"machine": "node-3", "client": "teku"},
+		3: {"machine": "node-4", "client": "lighthouse"},
+	} {
+		if err := s.Set("mainnet", index, l); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	if err := s.Set("hoodi", 1, map[string]string{"machine": "node-3"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := s.Set("mainnet", 4, map[string]string{"machine": "bad\nvalue"}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid, got %v", err)
+	}
+
+	// Labels survive a restart
+	reloaded := NewStore()
+	if err := reloaded.Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if got := reloaded.Select("mainnet", []Selector{{Key: "machine", Value: "node-3"}}); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("expected validators 1 and 2 on node-3, got %v", got)
+	}
+	if got := reloaded.Select("mainnet", []Selector{{Key: "machine", Value: "node-3"}, {Key: "client", Value: "lighthouse"}}); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("expected validator 1, got %v", got)
+	}
+
+	if err := reloaded.Set("mainnet", 1, nil); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got := reloaded.Get("mainnet", 1); got != nil {
+		t.Errorf("expected the labels to be removed, got %v", got)
+	}
+	if got := len(reloaded.All("mainnet")); got != 2 {
+		t.Errorf("expected 2 labeled validators, got %d", got)
+	}
+}
@@ -0,0 +1,118 @@
+// Package locale formats the human-readable numbers of API responses with the
+// decimal and thousands separators of a locale, e.g. 1.234,56 in German rather
+// than 1,234.56 in English.
+package locale
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Locale is a convention for writing numbers.
+type Locale struct {
+	Name    string // Name as requested, e.g. "de-DE"
+	Decimal string // Decimal separator
+	Group   string // Thousands separator
+}
+
+// Separators of the supported languages, and of regions that differ from their
+// language.
+var (
+	languages = map[string]Locale{
+		"en": {Decimal: ".", Group: ","},
+		"de": {Decimal: ",", Group: "."},
+		"es": {Decimal: ",", Group: "."},
+		"fr": {Decimal: ",", Group: "\u202f"}, // Narrow no-break space
+		"it": {Decimal: ",", Group: "."},
+		"ja": {Decimal: ".", Group: ","},
+		"nl": {Decimal: ",", Group: "."},
+		"pl": {Decimal: ",", Group: "\u00a0"}, // No-break space
+		"pt": {Decimal: ",", Group: "."},
+		"zh": {Decimal: ".", Group: ","},
+	}
+	regions = map[string]Locale{
+		"de-ch": {Decimal: ".", Group: "\u2019"},
+		"fr-ch": {Decimal: ".", Group: "\u2019"},
+		"it-ch": {Decimal: ".", Group: "\u2019"},
+	}
+)
+
+// Parse parses a locale name, a language optionally followed by a region such as
+// "de" or "de-DE". An empty name is the zero Locale, which leaves numbers as they
+// are.
+func Parse(name string) (Locale, error) {
+	if name == "" {
+		return Locale{}, nil
+	}
+	key := strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+	l, ok := regions[key]
+	if !ok {
+		language, _, _ := strings.Cut(key, "-")
+		l, ok = languages[language]
+	}
+	if !ok {
+		return Locale{}, fmt.Errorf("unsupported locale %q, languages are: de, en, es, fr, it, ja, nl, pl, pt, zh", name)
+	}
+	l.Name = name
+	return l, nil
+}
+
+// IsZero reports whether l is the zero Locale.
+func (l Locale) IsZero() bool {
+	return l.Decimal == ""
+}
+
+// FormatDecimal writes a plain decimal number such as "-1234.5" with the
+// separators of l, e.g. "-1.234,5". Strings that are not plain decimal numbers
+// are returned unchanged, as is everything for the zero Locale.
+func (l Locale) FormatDecimal(s string) string {
+	if l.IsZero() {
+		return s
+	}
+	sign, digits := "", s
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	whole, fraction, hasFraction := strings.Cut(digits, ".")
+	if !isDigits(whole) || (hasFraction && !isDigits(fraction)) {
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, d := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(l.Group)
+		}
+		b.WriteRune(d)
+	}
+	if hasFraction {
+		b.WriteString(l.Decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// FormatMoney writes a fiat amount rounded to cents with the separators of l,
+// e.g. 1234.5 as "1.234,50". Amounts that round to zero have no sign.
+func (l Locale) FormatMoney(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	if s == "-0.00" {
+		s = "0.00"
+	}
+	return l.FormatDecimal(s)
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package locale
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		decimal string
+		group   string
+		wantErr bool
+	}{
+		{"", "", "", false},
+		{"en", ".", ",", false},
+		{"de-DE", ",", ".", false},
+		{"de_AT", ",", ".", false},
+		{"de-CH", ".", "\u2019", false},
+		{"FR", ",", "\u202f", false},
+		{"xx", "", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.name)
+		if (err != nil) != tt.wantErr || got.Decimal != tt.decimal || got.Group != tt.group {
+			t.Errorf("Parse(%q) = %+v, %v, want %q and %q", tt.name, got, err, tt.decimal, tt.group)
+		}
+	}
+}
+
+func TestFormatDecimal(t *testing.T) {
+	en, _ := Parse("en")
+	de, _ := Parse("de")
+	tests := []struct {
+		locale Locale
+		s      string
+		want   string
+	}{
+		{en, "1234.56", "1,234.56"},
+		{de, "1234.56", "1.234,56"},
+		{de, "-1234567", "-1.234.567"},
+		{de, "123", "123"},
+		{de, "0.000000001", "0,000000001"},
+		{de, "", ""},
+		{de, "1e9", "1e9"},
+		{Locale{}, "1234.56", "1234.56"},
+	}
+	for _, tt := range tests {
+		if got := tt.locale.FormatDecimal(tt.s); got != tt.want {
+			t.Errorf("FormatDecimal(%q) in %q = %q, want %q", tt.s, tt.locale.Name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	de, _ := Parse("de")
+	if got := de.FormatMoney(1234.5); got != "1.234,50" {
+		t.Errorf("FormatMoney(1234.5) = %q, want 1.234,50", got)
+	}
+	if got := de.FormatMoney(-0.004); got != "0,00" {
+		t.Errorf("FormatMoney(-0.004) = %q, want 0,00", got)
+	}
+}
@@ -0,0 +1,156 @@
+// Package metrics publishes portfolio and validator metrics to Prometheus for
+// setups where Prometheus cannot scrape the service, e.g. because it runs in a
+// home network: the metrics are pushed to a Pushgateway or a remote-write
+// endpoint after each refresh.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+// Range is the evaluation range of the portfolio rewards and performance pushed.
+const Range = "24h"
+
+// Label is a name and value identifying a series.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is the current value of a gauge.
+type Sample struct {
+	Name   string
+	Labels []Label // Sorted by name
+	Value  float64
+}
+
+// help describes each metric.
+var help = map[string]string{
+	"vdash_validator_balance_eth":           "Balance of the validator in ETH.",
+	"vdash_validator_effective_balance_eth": "Effective balance of the validator in ETH.",
+	"vdash_validator_active":                "Whether the validator is active.",
+	"vdash_validator_online":                "Whether the validator is online.",
+	"vdash_validator_slashed":               "Whether the validator was slashed.",
+	"vdash_portfolio_rewards_eth":           "Net rewards of the portfolio over the range in ETH.",
+	"vdash_portfolio_beaconscore":           "BeaconScore of the portfolio over the range, from 0 to 1.",
+	"vdash_portfolio_attestations_assigned": "Attestations assigned to the portfolio over the range.",
+	"vdash_portfolio_attestations_missed":   "Attestations the portfolio missed over the range.",
+	"vdash_portfolio_validators":            "Validators of the portfolio.",
+	"vdash_portfolio_validators_online":     "Active validators of the portfolio that are online.",
+	"vdash_portfolio_balance_eth":           "Summed balance of the portfolio in ETH.",
+	"vdash_portfolio_effective_balance_eth": "Summed effective balance of the portfolio in ETH.",
+}
+
+// Collect returns the samples of a portfolio from its data over Range: one series
+// per validator and metric, and the portfolio totals.
+func Collect(p portfolio.Portfolio, data models.ValidatorResponse) []Sample {
+	var samples []Sample
+	gauge := func(name string, value float64, labels ...Label) {
+		labels = append([]Label{{"chain", p.Chain}, {"portfolio", p.Name}}, labels...)
+		sortLabels(labels)
+		samples = append(samples, Sample{Name: name, Labels: labels, Value: value})
+	}
+
+	ids := make([]string, 0, len(data.Validators))
+	for id := range data.Validators {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.Atoi(ids[i])
+		b, _ := strconv.Atoi(ids[j])
+		return a < b
+	})
+
+	var balance, effective amount.Amount
+	var online int
+	for _, id := range ids {
+		o := data.Validators[id]
+		validator := Label{"validator", id}
+		active := strings.HasPrefix(o.Status, "active")
+		gauge("vdash_validator_balance_eth", amount.ParseOrZero(o.CurrentBalance).ETH(), validator)
+		gauge("vdash_validator_effective_balance_eth", amount.ParseOrZero(o.EffectiveBalance).ETH(), validator)
+		gauge("vdash_validator_active", boolValue(active), validator)
+		gauge("vdash_validator_online", boolValue(o.Online), validator)
+		gauge("vdash_validator_slashed", boolValue(o.Slashed), validator)
+
+		balance = balance.Add(amount.ParseOrZero(o.CurrentBalance))
+		effective = effective.Add(amount.ParseOrZero(o.EffectiveBalance))
+		if active && o.Online {
+			online++
+		}
+	}
+
+	evalRange := Label{"range", Range}
+	gauge("vdash_portfolio_validators", float64(len(ids)))
+	gauge("vdash_portfolio_validators_online", float64(online))
+	gauge("vdash_portfolio_balance_eth", balance.ETH())
+	gauge("vdash_portfolio_effective_balance_eth", effective.ETH())
+	gauge("vdash_portfolio_rewards_eth", amount.ParseOrZero(data.Rewards.Total).ETH(), evalRange)
+	if b := data.Performance.Beaconscore; b != nil {
+		gauge("vdash_portfolio_beaconscore", *b, evalRange)
+	}
+	gauge("vdash_portfolio_attestations_assigned", float64(data.Performance.Attestations.Assigned), evalRange)
+	gauge("vdash_portfolio_attestations_missed", float64(data.Performance.Attestations.Missed), evalRange)
+	return samples
+}
+
+// WriteText writes samples in the Prometheus text exposition format, grouped by
+// metric.
+func WriteText(w io.Writer, samples []Sample) error {
+	sorted := make([]Sample, len(samples))
+	copy(sorted, samples)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	bw := bufio.NewWriter(w)
+	for i, s := range sorted {
+		if i == 0 || sorted[i-1].Name != s.Name {
+			if h, ok := help[s.Name]; ok {
+				fmt.Fprintf(bw, "# HELP %s %s\n", s.Name, h)
+			}
+			fmt.Fprintf(bw, "# TYPE %s gauge\n", s.Name)
+		}
+		bw.WriteString(s.Name)
+		if len(s.Labels) > 0 {
+			bw.WriteByte('{')
+			for j, l := range s.Labels {
+				if j > 0 {
+					bw.WriteByte(',')
+				}
+				fmt.Fprintf(bw, "%s=\"%s\"", l.Name, escapeLabelValue(l.Value))
+			}
+			bw.WriteByte('}')
+		}
+		fmt.Fprintf(bw, " %s\n", strconv.FormatFloat(s.Value, 'g', -1, 64))
+	}
+	return bw.Flush()
+}
+
+// sortLabels sorts labels by name.
+func sortLabels(labels []Label) {
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+}
+
+// labelValueEscaper escapes label values for the text exposition format.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue escapes a label value for the text exposition format.
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+// boolValue returns 1 for true and 0 for false.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+func testData() models.ValidatorResponse {
+	score := 0.98
+	return models.ValidatorResponse{
+		Validators: map[string]models.ValidatorOverview{
+			"10": {Status: "active_online", Online: true, CurrentBalance: "32010000000000000000", EffectiveBalance: "32000000000000000000"},
+			"9":  {Status: "exited", CurrentBalance: "0", EffectiveBalance: "0"},
+		},
+		Rewards:     models.ValidatorRewards{Total: "5000000000000000"},
+		Performance: models.ValidatorPerformance{Beaconscore: &score, Attestations: models.AttestationDuties{Assigned: 225, Missed: 1}},
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	samples := Collect(portfolio.Portfolio{Name: "home", Chain: "mainnet"}, testData())
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, samples); err != nil {
+		t.Fatal(err)
+	}
+	text := buf.String()
+	for _, want := range []string{
+		"# HELP vdash_validator_balance_eth Balance of the validator in ETH.\n# TYPE vdash_validator_balance_eth gauge\n" +
+			`vdash_validator_balance_eth{chain="mainnet",portfolio="home",validator="9"} 0` + "\n" +
+			`vdash_validator_balance_eth{chain="mainnet",portfolio="home",validator="10"} 32.01` + "\n",
+		`vdash_validator_active{chain="mainnet",portfolio="home",validator="9"} 0` + "\n",
+		`vdash_portfolio_validators_online{chain="mainnet",portfolio="home"} 1` + "\n",
+		`vdash_portfolio_rewards_eth{chain="mainnet",portfolio="home",range="24h"} 0.005` + "\n",
+		`vdash_portfolio_beaconscore{chain="mainnet",portfolio="home",range="24h"} 0.98` + "\n",
+		`vdash_portfolio_attestations_missed{chain="mainnet",portfolio="home",range="24h"} 1` + "\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, text)
+		}
+	}
+	if n := strings.Count(text, "# TYPE vdash_validator_online gauge"); n != 1 {
+		t.Errorf("expected one TYPE line per metric, got %d", n)
+	}
+
+	buf.Reset()
+	WriteText(&buf, []Sample{{Name: "m", Labels: []Label{{"l", "a\"b\\c\nd"}}, Value: 1}})
+	if want := `m{l="a\"b\\c\nd"} 1` + "\n"; !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("expected escaped label value %q, got %q", want, buf.String())
+	}
+}
+
+func TestEncodeWriteRequest(t *testing.T) {
+	label := append([]byte{0x0a, 8}, "__name__"...)
+	label = append(append(label, 0x12, 2), "up"...)
+	sample := []byte{0x09, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f, 0x10, 0xe8, 0x07} // 1.0 at 1000
+	series := append(append([]byte{0x0a, byte(len(label))}, label...), append([]byte{0x12, byte(len(sample))}, sample...)...)
+	want := append([]byte{0x0a, byte(len(series))}, series...)
+
+	if got := encodeWriteRequest([]Sample{{Name: "up", Value: 1}}, 1000, nil); !bytes.Equal(got, want) {
+		t.Errorf("expected %x, got %x", want, got)
+	}
+}
+
+func TestEncodeSnappy(t *testing.T) {
+	tests := []struct {
+		size   int
+		header []byte
+	}{
+		{size: 3, header: []byte{3, 2 << 2}},
+		{size: 100, header: []byte{100, 60 << 2, 99}},
+		{size: 70000, header: []byte{0xf0, 0xa2, 0x04, 61 << 2, 0xff, 0xff}},
+	}
+	for _, tt := range tests {
+		data := bytes.Repeat([]byte{'x'}, tt.size)
+		got := encodeSnappy(data)
+		if !bytes.HasPrefix(got, tt.header) {
+			t.Errorf("size %d: expected header %x, got %x", tt.size, tt.header, got[:len(tt.header)])
+		}
+	}
+	// The rest of a long input follows in a second literal
+	got := encodeSnappy(bytes.Repeat([]byte{'x'}, 70000))
+	if rest := got[6+1<<16:]; !bytes.Equal(rest[:3], []byte{61 << 2, 0x6f, 0x11}) || len(rest) != 3+4464 {
+		t.Errorf("expected a second literal of 4464 bytes, got header %x and %d bytes", rest[:3], len(rest))
+	}
+}
+
+// fakeSource serves fixed portfolio data.
+type fakeSource struct {
+	data models.ValidatorResponse
+}
+
+func (f fakeSource) GetPortfolioData(ctx context.Context, name, evalRange string) (models.ValidatorResponse, error) {
+	return f.data, nil
+}
+
+func TestPublisher(t *testing.T) {
+	type request struct {
+		method, path string
+		header       http.Header
+		body         []byte
+	}
+	var mu sync.Mutex
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, request{r.Method, r.URL.EscapedPath(), r.Header, body})
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	registry, err := portfolio.NewRegistry([]portfolio.Portfolio{{Name: "home/lab", Chain: "mainnet", ValidatorIds: []int{9, 10}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher := NewPublisher(registry, fakeSource{testData()},
+		NewPushgatewayPusher(server.URL+"/", "vdash", time.Second),
+		NewRemoteWritePusher(server.URL+"/api/v1/write", "vdash", time.Second))
+	publisher.SetLabels([]Label{{"tenant", "acme"}})
+	publisher.Publish(context.Background())
+
+	if len(requests) != 2 {
+		t.Fatalf("expected a push to each endpoint, got %d", len(requests))
+	}
+	push := requests[0]
+	if push.method != http.MethodPut || push.path != "/metrics/job/vdash/tenant/acme/portfolio@base64/aG9tZS9sYWI" {
+		t.Errorf("expected a PUT to the grouping key of the portfolio, got %s %s", push.method, push.path)
+	}
+	if want := `vdash_portfolio_validators{chain="mainnet",portfolio="home/lab",tenant="acme"} 2`; !strings.Contains(string(push.body), want) {
+		t.Errorf("expected the pushed metrics to contain %q, got:\n%s", want, push.body)
+	}
+
+	write := requests[1]
+	if write.method != http.MethodPost || write.path != "/api/v1/write" || write.header.Get("Content-Encoding") != "snappy" || write.header.Get("Content-Type") != "application/x-protobuf" {
+		t.Errorf("expected a snappy protobuf POST to the remote-write endpoint, got %s %s %v", write.method, write.path, write.header)
+	}
+	for _, want := range []string{"vdash_validator_online", "job", "tenant", "acme"} {
+		if !bytes.Contains(write.body, []byte(want)) {
+			t.Errorf("expected the write request to contain %q", want)
+		}
+	}
+}
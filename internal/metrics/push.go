@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+// Pusher sends samples to Prometheus. group identifies the samples, a portfolio,
+// among those pushed.
+type Pusher interface {
+	Push(ctx context.Context, group []Label, samples []Sample, at time.Time) error
+}
+
+// PushgatewayPusher pushes samples to a Prometheus Pushgateway, grouped by job and
+// group. Each push replaces the previous samples of its group, so series of
+// validators that left a portfolio disappear.
+type PushgatewayPusher struct {
+	url        string
+	job        string
+	httpClient *http.Client
+}
+
+// NewPushgatewayPusher creates a pusher to the Pushgateway at baseURL.
+func NewPushgatewayPusher(baseURL, job string, timeout time.Duration) *PushgatewayPusher {
+	return &PushgatewayPusher{url: strings.TrimSuffix(baseURL, "/"), job: job, httpClient: &http.Client{Timeout: timeout}}
+}
+
+// Push implements Pusher. The Pushgateway timestamps samples when it receives them.
+func (p *PushgatewayPusher) Push(ctx context.Context, group []Label, samples []Sample, _ time.Time) error {
+	var body bytes.Buffer
+	if err := WriteText(&body, samples); err != nil {
+		return fmt.Errorf("encode samples: %w", err)
+	}
+	target := p.url + "/metrics/" + groupingPath(Label{"job", p.job})
+	for _, l := range group {
+		target += "/" + groupingPath(l)
+	}
+	return send(ctx, p.httpClient, http.MethodPut, target, &body, map[string]string{
+		"Content-Type": "text/plain; version=0.0.4",
+	})
+}
+
+// groupingPath returns a grouping key label as a Pushgateway URL path, base64
+// encoding values that contain a slash.
+func groupingPath(l Label) string {
+	if strings.Contains(l.Value, "/") {
+		return l.Name + "@base64/" + base64.RawURLEncoding.EncodeToString([]byte(l.Value))
+	}
+	return l.Name + "/" + url.PathEscape(l.Value)
+}
+
+// RemoteWritePusher sends samples to a Prometheus remote-write endpoint, such as
+// Prometheus with the remote-write receiver enabled, Mimir or Grafana Cloud.
+// Credentials can be given in the URL.
+type RemoteWritePusher struct {
+	url        string
+	job        string
+	httpClient *http.Client
+}
+
+// NewRemoteWritePusher creates a pusher to the remote-write endpoint at url,
+// labeling series with job like a scrape would.
+func NewRemoteWritePusher(url, job string, timeout time.Duration) *RemoteWritePusher {
+	return &RemoteWritePusher{url: url, job: job, httpClient: &http.Client{Timeout: timeout}}
+}
+
+// Push implements Pusher.
+func (p *RemoteWritePusher) Push(ctx context.Context, _ []Label, samples []Sample, at time.Time) error {
+	body := encodeSnappy(encodeWriteRequest(samples, at.UnixMilli(), []Label{{"job", p.job}}))
+	return send(ctx, p.httpClient, http.MethodPost, p.url, bytes.NewReader(body), map[string]string{
+		"Content-Type":                      "application/x-protobuf",
+		"Content-Encoding":                  "snappy",
+		"X-Prometheus-Remote-Write-Version": "0.1.0",
+	})
+}
+
+// send sends a request and fails on any status but 2xx.
+func send(ctx context.Context, client *http.Client, method, target string, body io.Reader, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("push metrics: status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Source provides the portfolio data the metrics are collected from.
+type Source interface {
+	GetPortfolioData(ctx context.Context, name, evalRange string) (models.ValidatorResponse, error)
+}
+
+// Publisher collects the metrics of every portfolio and pushes them.
+type Publisher struct {
+	portfolios *portfolio.Registry
+	source     Source
+	pushers    []Pusher
+	labels     []Label // Added to every series, e.g. the tenant
+}
+
+// NewPublisher creates a publisher pushing the metrics of the portfolios in
+// registry to pushers.
+func NewPublisher(registry *portfolio.Registry, source Source, pushers ...Pusher) *Publisher {
+	return &Publisher{portfolios: registry, source: source, pushers: pushers}
+}
+
+// SetLabels sets labels added to every series and grouping key, so that several
+// publishers can push to the same endpoint.
+func (p *Publisher) SetLabels(labels []Label) {
+	p.labels = labels
+}
+
+// Run publishes immediately and then every interval until the context is canceled.
+func (p *Publisher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.Publish(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Publish refreshes the data of every portfolio and pushes its metrics. Data
+// fetched during the current epoch is served from cache. A portfolio that fails
+// to load is skipped, keeping its last pushed metrics, and a failed push is
+// logged and retried on the next run.
+func (p *Publisher) Publish(ctx context.Context) {
+	for _, pf := range p.portfolios.All() {
+		data, err := p.source.GetPortfolioData(ctx, pf.Name, Range)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			slog.Warn("failed to load portfolio metrics", "portfolio", pf.Name, "error", err)
+			continue
+		}
+
+		samples := Collect(pf, data)
+		for i := range samples {
+			samples[i].Labels = mergeLabels(p.labels, samples[i].Labels)
+		}
+		group := append(slices.Clone(p.labels), Label{"portfolio", pf.Name})
+		now := time.Now()
+		for _, pusher := range p.pushers {
+			if err := pusher.Push(ctx, group, samples, now); err != nil {
+				slog.Warn("failed to push portfolio metrics", "portfolio", pf.Name, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// The Prometheus remote-write protocol sends a snappy-compressed protobuf
+// WriteRequest. Both encodings are small enough to write here rather than pull in
+// their libraries:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// encodeWriteRequest encodes samples as a WriteRequest, each with the metric name
+// in the __name__ label and the value at the timestamp in Unix milliseconds.
+// extra labels are added to every series.
+func encodeWriteRequest(samples []Sample, timestamp int64, extra []Label) []byte {
+	var req []byte
+	for _, s := range samples {
+		labels := mergeLabels(append([]Label{{"__name__", s.Name}}, extra...), s.Labels)
+
+		var series []byte
+		for _, l := range labels {
+			var label []byte
+			label = appendBytesField(label, 1, []byte(l.Name))
+			label = appendBytesField(label, 2, []byte(l.Value))
+			series = appendBytesField(series, 1, label)
+		}
+		var sample []byte
+		sample = appendTag(sample, 1, wireFixed64)
+		sample = binary.LittleEndian.AppendUint64(sample, math.Float64bits(s.Value))
+		sample = appendTag(sample, 2, wireVarint)
+		sample = binary.AppendUvarint(sample, uint64(timestamp))
+		series = appendBytesField(series, 2, sample)
+
+		req = appendBytesField(req, 1, series)
+	}
+	return req
+}
+
+// mergeLabels merges two lists of labels sorted by name, as remote write requires.
+// Labels of a take precedence.
+func mergeLabels(a, b []Label) []Label {
+	merged := make([]Label, 0, len(a)+len(b))
+	seen := make(map[string]bool, len(a))
+	for _, l := range a {
+		seen[l.Name] = true
+		merged = append(merged, l)
+	}
+	for _, l := range b {
+		if !seen[l.Name] {
+			merged = append(merged, l)
+		}
+	}
+	sortLabels(merged)
+	return merged
+}
+
+// appendTag appends the tag of a protobuf field.
+func appendTag(b []byte, field int, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wireType))
+}
+
+// appendBytesField appends a length-delimited protobuf field.
+func appendBytesField(b []byte, field int, value []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// maxSnappyLiteral is the longest literal encodeSnappy writes in one element.
+const maxSnappyLiteral = 1 << 16
+
+// encodeSnappy encodes data in the snappy block format without compressing it,
+// as a sequence of literals. Remote-write bodies are small and sent once per
+// refresh, so compression is not worth its code; every snappy decoder reads them.
+func encodeSnappy(data []byte) []byte {
+	b := binary.AppendUvarint(make([]byte, 0, len(data)+len(data)/maxSnappyLiteral*3+8), uint64(len(data)))
+	for len(data) > 0 {
+		n := min(len(data), maxSnappyLiteral)
+		switch {
+		case n <= 60:
+			b = append(b, byte(n-1)<<2)
+		case n <= 1<<8:
+			b = append(b, 60<<2, byte(n-1))
+		default:
+			b = append(b, 61<<2, byte(n-1), byte((n-1)>>8))
+		}
+		b = append(b, data[:n]...)
+		data = data[n:]
+	}
+	return b
+}
@@ -1,6 +1,11 @@
 // Package models contains data structures for the validator-dashboard API.
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // ValidatorRequest represents the incoming request body for POST /validator.
 type ValidatorRequest struct {
 	// ValidatorIds is a list of unique validator indices.
@@ -8,8 +13,25 @@ type ValidatorRequest struct {
 	ValidatorIds []int `json:"validatorIds"`
 	// Chain is the target chain for the request. Allowed values: "mainnet", "hoodi".
 	Chain string `json:"chain"`
-	// Range is the evaluation window for aggregates. Allowed values: "24h", "7d", "30d", "90d", "all_time".
+	// Range is the evaluation window for aggregates. Allowed values: "24h", "7d", "30d", "90d", "all_time",
+	// or a custom window of UTC days such as "2026-01-01/2026-04-01", the end date excluded.
 	Range string `json:"range"`
+	// ExcludeAnomalies excludes known network incidents from the aggregates.
+	ExcludeAnomalies bool `json:"excludeAnomalies"`
+	// Currency optionally requests fiat values in the given currency, e.g. "usd".
+	Currency string `json:"currency,omitempty"`
+	// Labels optionally selects the validators with all of these labels, as
+	// "key:value", instead of or in addition to ValidatorIds.
+	Labels []string `json:"labels,omitempty"`
+	// GroupBy optionally requests totals per value of the label with this key.
+	GroupBy string `json:"groupBy,omitempty"`
+	// Fields optionally limits the response to these sections or paths into them,
+	// e.g. "overview" or "performance.attestations". Sections left out are not
+	// fetched upstream.
+	Fields []string `json:"fields,omitempty"`
+	// ComparePrevious requests the aggregates of the period before the range, of
+	// the same length, along with their changes.
+	ComparePrevious bool `json:"comparePrevious,omitempty"`
 }
 
 // ValidatorResponse contains per-validator overviews and aggregated rewards/performance.
@@ -20,6 +42,76 @@ type ValidatorResponse struct {
 	Rewards ValidatorRewards `json:"rewards"`
 	// Performance contains aggregated performance for all requested validators.
 	Performance ValidatorPerformance `json:"performance"`
+	// Anomalies describes the known incidents excluded from the aggregates, if any.
+	Anomalies *AnomalyReport `json:"anomalies,omitempty"`
+	// Fiat contains fiat values of the balances and rewards when a currency was requested.
+	Fiat *FiatValues `json:"fiat,omitempty"`
+	// Benchmark compares the aggregates with the average network validator over the
+	// same range, when the network averages are available.
+	Benchmark *Benchmark `json:"benchmark,omitempty"`
+	// Groups contains totals per value of the groupBy label when requested. Validators
+	// without the label are grouped under an empty key.
+	Groups map[string]DashboardTotals `json:"groups,omitempty"`
+	// Previous contains the aggregates of the period before the range when requested.
+	Previous *PreviousPeriod `json:"previous,omitempty"`
+	// TimedOutSections lists the sections left out because the request timeout passed
+	// before they were fetched, e.g. "rewards" or "benchmark".
+	TimedOutSections []string `json:"timedOutSections,omitempty"`
+	// FallbackSections lists the sections whose Beaconcha aggregate failed and that
+	// were computed from per-day data or taken from an earlier fetch instead.
+	FallbackSections []string `json:"fallbackSections,omitempty"`
+	// Window describes the custom evaluation window of the aggregates, when one
+	// was requested.
+	Window *EvaluationWindow `json:"window,omitempty"`
+}
+
+// EvaluationWindow is a custom evaluation window: the UTC days from From up to
+// To, and the epochs starting in them.
+type EvaluationWindow struct {
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"` // Excluded
+	StartEpoch int64     `json:"startEpoch"`
+	EndEpoch   int64     `json:"endEpoch"`
+}
+
+// AnomalyReport describes which known network incidents were excluded from the
+// aggregates, and which validators earn at an anomalous rate.
+type AnomalyReport struct {
+	Windows                       []string            `json:"windows"`                                  // Names of the incident windows applied
+	ExcludedValidators            []int               `json:"excludedValidators"`                       // Validators slashed during a mass slashing
+	InactivityLeakPenaltyExcluded string              `json:"inactivityLeakPenaltyExcluded" unit:"wei"` // Leak penalty removed from rewards in wei
+	RewardRates                   []RewardRateAnomaly `json:"rewardRates,omitempty"`                    // Validators whose recent reward rate deviates
+}
+
+// RewardRateAnomaly is a validator whose reward rate over the recent window
+// deviates from its own baseline or from the fleet median. Rates are in wei per
+// day and deviations in standard deviations; the comparisons without enough
+// recorded history are left out.
+type RewardRateAnomaly struct {
+	ValidatorIndex    int      `json:"validatorIndex"`
+	Direction         string   `json:"direction"` // above or below, of the larger deviation
+	Rate              string   `json:"rate" unit:"wei"`
+	Baseline          *string  `json:"baseline,omitempty" unit:"wei"` // Mean rate of the earlier windows
+	BaselineDeviation *float64 `json:"baselineDeviation,omitempty"`
+	FleetMedian       *string  `json:"fleetMedian,omitempty" unit:"wei"`
+	FleetDeviation    *float64 `json:"fleetDeviation,omitempty"`
+}
+
+// PreviousPeriod contains the aggregates of the period of the same length before
+// the evaluation range, e.g. the 7 days before the last 7, and the percent change
+// of the current aggregates over them. A change is null when either value is
+// unknown or the previous one is zero.
+type PreviousPeriod struct {
+	From                           time.Time          `json:"from"`
+	To                             time.Time          `json:"to"`
+	Rewards                        *string            `json:"rewards" unit:"wei"`       // Net rewards of the UTC days in the period, null when unavailable
+	RewardsChange                  *float64           `json:"rewardsChange"`            // Percent change of the net rewards
+	MissedRewards                  *string            `json:"missedRewards" unit:"wei"` // Missed rewards of the UTC days in the period
+	MissedRewardsChange            *float64           `json:"missedRewardsChange"`
+	AttestationEffectiveness       *float64           `json:"attestationEffectiveness"` // Included over assigned attestations, from the recorded history
+	AttestationEffectivenessChange *float64           `json:"attestationEffectivenessChange"`
+	Uptime                         map[string]Uptime  `json:"uptime,omitempty"`       // By SLA window, each over the window before it
+	UptimeChange                   map[string]float64 `json:"uptimeChange,omitempty"` // By SLA window, left out when unknown
 }
 
 // ValidatorOverview contains basic validator state information.
@@ -29,9 +121,82 @@ type ValidatorOverview struct {
 	WithdrawalCredentials WithdrawalCredentials `json:"withdrawalCredentials"`
 	ActivationEpoch       int64                 `json:"activationEpoch"`
 	ExitEpoch             int64                 `json:"exitEpoch"`
-	CurrentBalance        string                `json:"currentBalance"`   // in wei
-	EffectiveBalance      string                `json:"effectiveBalance"` //in wei
+	CurrentBalance        string                `json:"currentBalance" unit:"wei"`   // in wei
+	EffectiveBalance      string                `json:"effectiveBalance" unit:"wei"` //in wei
 	Online                bool                  `json:"online"`
+	Labels                map[string]string     `json:"labels,omitempty"`    // User-defined labels, e.g. machine=node-3
+	Notes                 string                `json:"notes,omitempty"`     // User-defined freeform notes
+	PresignedExit         bool                  `json:"presignedExit"`       // A pre-signed voluntary exit is stored
+	Finalized             *bool                 `json:"finalized,omitempty"` // false until the state is finalized
+
+	// Effective balance headroom, only set for pending and active validators that are not slashed
+	EffectiveBalanceHeadroom *EffectiveBalanceHeadroom `json:"effectiveBalanceHeadroom,omitempty"`
+
+	// Set while the validator is suspected to run in two places
+	Doppelganger *DoppelgangerSuspicion `json:"doppelganger,omitempty"`
+
+	// MEV-boost relay registrations, only set for pending and active validators on
+	// mainnet when relay checks are enabled
+	MEV *MEVRegistrations `json:"mev,omitempty"`
+
+	// Restaking status of the EigenPod the validator withdraws to, only set on
+	// mainnet when an execution RPC is configured
+	Restaking *Restaking `json:"restaking,omitempty"`
+
+	// Queue estimates, only set for pending and exiting validators
+	EntryQueuePosition        *int       `json:"entryQueuePosition,omitempty"`
+	EstimatedActivationTime   *time.Time `json:"estimatedActivationTime,omitempty"`
+	EstimatedExitTime         *time.Time `json:"estimatedExitTime,omitempty"`
+	EstimatedWithdrawableTime *time.Time `json:"estimatedWithdrawableTime,omitempty"`
+}
+
+// EffectiveBalanceHeadroom describes how much of the maximum effective balance a
+// validator earns on, and what it takes to raise its effective balance.
+type EffectiveBalanceHeadroom struct {
+	MaxEffectiveBalance     string   `json:"maxEffectiveBalance" unit:"wei"`          // 32 ETH, or 2048 ETH for compounding (0x02) credentials, in wei
+	Headroom                string   `json:"headroom" unit:"wei"`                     // Max minus effective balance in wei
+	Utilization             float64  `json:"utilization"`                             // Effective over max effective balance
+	PendingEffectiveBalance string   `json:"pendingEffectiveBalance" unit:"wei"`      // Effective balance after the next hysteresis update in wei
+	TopUpAmount             string   `json:"topUpAmount,omitempty" unit:"wei"`        // Deposit that restores a 32 ETH effective balance, in wei
+	NextIncrementTopUp      string   `json:"nextIncrementTopUp,omitempty" unit:"wei"` // Deposit that raises a compounding validator's effective balance by 1 ETH, in wei
+	Flags                   []string `json:"flags,omitempty"`                         // topup_recommended, effective_balance_decreasing
+}
+
+// DoppelgangerSuspicion describes conflicting attestations of a validator, which
+// mean its keys sign in two places and precede a slashing.
+type DoppelgangerSuspicion struct {
+	Epoch        int64     `json:"epoch"`        // Latest epoch with conflicting attestations
+	Attestations int       `json:"attestations"` // Distinct attestations included for that epoch
+	DetectedAt   time.Time `json:"detectedAt"`
+}
+
+// MEVRegistrations describes the registrations of a validator at MEV-boost relays.
+type MEVRegistrations struct {
+	Relays               []string            `json:"relays"`                         // Relays the validator is registered with
+	Registrations        []RelayRegistration `json:"registrations"`                  // At every relay checked
+	Stale                bool                `json:"stale,omitempty"`                // Some registration is older than the maximum age
+	FeeRecipientMismatch bool                `json:"feeRecipientMismatch,omitempty"` // Relays hold different fee recipients
+}
+
+// RelayRegistration is the registration of a validator at one relay.
+type RelayRegistration struct {
+	Relay        string     `json:"relay"`
+	Registered   bool       `json:"registered"`
+	FeeRecipient string     `json:"feeRecipient,omitempty"`
+	GasLimit     uint64     `json:"gasLimit,omitempty"`
+	Timestamp    *time.Time `json:"timestamp,omitempty"` // When the validator signed the registration
+	Stale        bool       `json:"stale,omitempty"`
+	Error        string     `json:"error,omitempty"` // Set when the relay could not be queried
+}
+
+// Restaking describes the EigenLayer restaking of a validator whose withdrawal
+// credentials point to an EigenPod.
+type Restaking struct {
+	Protocol  string `json:"protocol"` // eigenlayer
+	EigenPod  string `json:"eigenPod"`
+	PodOwner  string `json:"podOwner"`
+	Delegated bool   `json:"delegated"`
+	Operator  string `json:"operator,omitempty"` // Operator the pod owner delegated to
 }
 
 // WithdrawalCredentials contains the type and address for withdrawals.
@@ -40,45 +205,60 @@ type WithdrawalCredentials struct {
 	Prefix     string  `json:"prefix"`
 	Credential string  `json:"credential"`
 	Address    *string `json:"address,omitempty"`
+	// ENSName is the primary ENS name of Address, resolved on mainnet when an
+	// execution RPC is configured.
+	ENSName string `json:"ensName,omitempty"`
 }
 
 // ValidatorRewards contains all-time reward/penalty information.
 type ValidatorRewards struct {
-	Total          string               `json:"total"`        // Net rewards (rewards - penalties) in wei
-	TotalReward    string               `json:"totalReward"`  // Total rewards earned in wei
-	TotalPenalty   string               `json:"totalPenalty"` // Total penalties in wei
-	TotalMissed    string               `json:"totalMissed"`  // Total missed rewards in wei
+	Total          string               `json:"total" unit:"wei"`        // Net rewards (rewards - penalties) in wei
+	TotalReward    string               `json:"totalReward" unit:"wei"`  // Total rewards earned in wei
+	TotalPenalty   string               `json:"totalPenalty" unit:"wei"` // Total penalties in wei
+	TotalMissed    string               `json:"totalMissed" unit:"wei"`  // Total missed rewards in wei
 	Proposals      ProposalRewards      `json:"proposals"`
 	Attestations   AttestationRewards   `json:"attestations"`
 	SyncCommittees SyncCommitteeRewards `json:"syncCommittees"`
+	Income         *IncomeSplit         `json:"income,omitempty"`    // Net rewards by revenue stream
+	Finalized      *bool                `json:"finalized,omitempty"` // false until the range is finalized
+}
+
+// IncomeSplit attributes the rewards of a window to consensus layer issuance,
+// execution layer priority fees and MEV relay payments.
+type IncomeSplit struct {
+	Consensus    string `json:"consensus" unit:"wei"`    // Net CL rewards (total minus EL rewards) in wei
+	PriorityFees string `json:"priorityFees" unit:"wei"` // Priority fees of locally built blocks in wei
+	MEV          string `json:"mev" unit:"wei"`          // Builder payments of relayed blocks in wei
+	Blocks       int    `json:"blocks"`                  // Blocks proposed in the window
+	MEVBlocks    int    `json:"mevBlocks"`               // Blocks delivered by a relay
 }
 
 // ProposalRewards contains reward breakdown for block proposals.
 type ProposalRewards struct {
-	Total                      string `json:"total"`                      // Total proposal rewards in wei
-	ExecutionLayerReward       string `json:"executionLayerReward"`       // EL rewards in wei
-	AttestationInclusionReward string `json:"attestationInclusionReward"` // Attestation inclusion in wei
-	SyncInclusionReward        string `json:"syncInclusionReward"`        // Sync inclusion in wei
-	SlashingInclusionReward    string `json:"slashingInclusionReward"`    // Slashing inclusion in wei
-	MissedCLReward             string `json:"missedClReward"`             // Missed CL rewards in wei
-	MissedELReward             string `json:"missedElReward"`             // Missed EL rewards in wei
+	Total                      string `json:"total" unit:"wei"`                      // Total proposal rewards in wei
+	ExecutionLayerReward       string `json:"executionLayerReward" unit:"wei"`       // EL rewards in wei
+	AttestationInclusionReward string `json:"attestationInclusionReward" unit:"wei"` // Attestation inclusion in wei
+	SyncInclusionReward        string `json:"syncInclusionReward" unit:"wei"`        // Sync inclusion in wei
+	SlashingInclusionReward    string `json:"slashingInclusionReward" unit:"wei"`    // Slashing inclusion in wei
+	MissedCLReward             string `json:"missedClReward" unit:"wei"`             // Missed CL rewards in wei
+	MissedELReward             string `json:"missedElReward" unit:"wei"`             // Missed EL rewards in wei
 }
 
 // AttestationRewards contains reward breakdown for attestations.
 type AttestationRewards struct {
-	Total                 string `json:"total"`                 // Total attestation rewards in wei
-	Head                  string `json:"head"`                  // Head vote rewards in wei
-	Source                string `json:"source"`                // Source vote rewards in wei
-	Target                string `json:"target"`                // Target vote rewards in wei
-	InactivityLeakPenalty string `json:"inactivityLeakPenalty"` // Inactivity leak penalty in wei
+	Total                 string `json:"total" unit:"wei"`                 // Total attestation rewards in wei
+	Head                  string `json:"head" unit:"wei"`                  // Head vote rewards in wei
+	Source                string `json:"source" unit:"wei"`                // Source vote rewards in wei
+	Target                string `json:"target" unit:"wei"`                // Target vote rewards in wei
+	InactivityLeakPenalty string `json:"inactivityLeakPenalty" unit:"wei"` // Inactivity leak penalty in wei
 }
 
 // SyncCommitteeRewards contains reward breakdown for sync committee duties.
 type SyncCommitteeRewards struct {
-	Total        string `json:"total"`        // Net sync committee rewards in wei
-	Reward       string `json:"reward"`       // Sync committee rewards in wei
-	Penalty      string `json:"penalty"`      // Sync committee penalties in wei
-	MissedReward string `json:"missedReward"` // Missed sync committee rewards in wei
+	Total        string `json:"total" unit:"wei"`        // Net sync committee rewards in wei
+	Reward       string `json:"reward" unit:"wei"`       // Sync committee rewards in wei
+	Penalty      string `json:"penalty" unit:"wei"`      // Sync committee penalties in wei
+	MissedReward string `json:"missedReward" unit:"wei"` // Missed sync committee rewards in wei
 }
 
 // ValidatorPerformance contains all-time performance metrics.
@@ -87,6 +267,40 @@ type ValidatorPerformance struct {
 	Attestations   AttestationDuties   `json:"attestations"`
 	SyncCommittees SyncCommitteeDuties `json:"syncCommittees"`
 	Proposals      ProposalDuties      `json:"proposals"`
+	Finalized      *bool               `json:"finalized,omitempty"` // false until the range is finalized
+	// Uptime of the validators by SLA window, e.g. "7d", from the recorded history
+	Uptime map[string]Uptime `json:"uptime,omitempty"`
+	// Luck compares the proposal and sync committee duties with their expected
+	// number, when the network size is available
+	Luck *Luck `json:"luck,omitempty"`
+}
+
+// Luck compares the duties the validators were assigned with the number expected
+// from their share of the network's effective balance over the range, and the
+// execution layer rewards of their blocks with the blocks of the network.
+type Luck struct {
+	Proposals      *DutyLuck       `json:"proposals,omitempty"`
+	SyncCommittees *DutyLuck       `json:"syncCommittees,omitempty"` // In slots, like the sync committee duties
+	BlockValue     *BlockValueLuck `json:"blockValue,omitempty"`
+}
+
+// DutyLuck is the expected and actual number of duties of one kind.
+type DutyLuck struct {
+	Expected float64  `json:"expected"`
+	Actual   int      `json:"actual"`
+	Luck     *float64 `json:"luck"` // Actual over expected in percent, null when none were expected
+}
+
+// BlockValueLuck compares the average execution layer reward of the blocks the
+// validators proposed with the median block of the network.
+type BlockValueLuck struct {
+	Blocks          int      `json:"blocks"`                    // Proposed blocks
+	RelayedBlocks   int      `json:"relayedBlocks"`             // Proposed blocks delivered by a relay
+	Average         string   `json:"average" unit:"wei"`        // Priority fees and builder payments per block in wei
+	NetworkMedian   string   `json:"networkMedian" unit:"wei"`  // Reward of the median network block in wei
+	NetworkSample   int      `json:"networkSample"`             // Network blocks the median is taken over
+	Luck            *float64 `json:"luck"`                      // Average over network median in percent, null when the median is zero
+	MevBoostSuspect bool     `json:"mevBoostSuspect,omitempty"` // Enough blocks far enough below the network to suggest a broken MEV-boost setup
 }
 
 // AttestationDuties contains attestation performance metrics.
@@ -118,9 +332,802 @@ type ProposalDuties struct {
 	Beaconscore       *float64 `json:"beaconscore"`       // Proposal-specific BeaconScore
 }
 
+// Envelope wraps a successful response when requested with envelope=true.
+type Envelope struct {
+	Data any `json:"data"`
+}
+
 // APIError represents an error response from the API.
 type APIError struct {
 	Error   string `json:"error"`
 	Message string `json:"message,omitempty"`
 	Code    int    `json:"code"`
 }
+
+// ReconciliationResponse compares accrued consensus layer rewards against withdrawn amounts.
+type ReconciliationResponse struct {
+	// Range is the evaluation window the comparison covers.
+	Range string `json:"range"`
+	// Tolerance is the maximum absolute difference in wei before a validator is flagged.
+	Tolerance string `json:"tolerance" unit:"wei"`
+	// Validators contains the per-validator reconciliation keyed by validator ID.
+	Validators map[string]ValidatorReconciliation `json:"validators"`
+	// Total contains the reconciliation summed over all requested validators.
+	Total ValidatorReconciliation `json:"total"`
+}
+
+// ValidatorReconciliation contains accrued vs withdrawn amounts for a single validator or a group.
+type ValidatorReconciliation struct {
+	AccruedCLReward string `json:"accruedClReward" unit:"wei"` // CL rewards accrued in the window, in wei
+	Withdrawn       string `json:"withdrawn" unit:"wei"`       // Rewards withdrawn in the window (principal excluded), in wei
+	Withdrawals     int    `json:"withdrawals"`                // Number of withdrawals processed in the window
+	Difference      string `json:"difference" unit:"wei"`      // Accrued minus withdrawn, in wei
+	Flagged         bool   `json:"flagged"`                    // Whether the difference exceeds the tolerance
+}
+
+// FiatPrice is the price of one ETH in a fiat currency.
+type FiatPrice struct {
+	Currency  string    `json:"currency"`
+	Price     float64   `json:"price" unit:"fiat"`
+	Provider  string    `json:"provider"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Stale     bool      `json:"stale"` // Set when all providers failed and the last known price is used
+}
+
+// FiatValues contains fiat values of the validator balances and aggregated rewards.
+type FiatValues struct {
+	FiatPrice
+	TotalBalance float64 `json:"totalBalance" unit:"fiat"` // Sum of current balances
+	Rewards      float64 `json:"rewards" unit:"fiat"`      // Net rewards in the evaluation window
+}
+
+// DailyIncomeResponse contains the combined income of the requested validators per UTC day.
+type DailyIncomeResponse struct {
+	// Currency is the fiat currency of the price and value fields, if requested.
+	Currency string `json:"currency,omitempty"`
+	// CurrentPrice is the ETH price now, which CurrentValue fields are valued at.
+	CurrentPrice *float64 `json:"currentPrice,omitempty" unit:"fiat"`
+	// CostBasis is the income of all days valued at the price of the day it was
+	// earned. Only set when every day with income has a known price.
+	CostBasis *float64 `json:"costBasis,omitempty" unit:"fiat"`
+	// CurrentValue is the income of all days valued at the current price.
+	CurrentValue *float64 `json:"currentValue,omitempty" unit:"fiat"`
+	// Days contains one entry per day, oldest first, including days without income.
+	Days []DailyIncome `json:"days"`
+}
+
+// DailyIncome contains the income earned on a single UTC day.
+type DailyIncome struct {
+	Date           string `json:"date"`                      // UTC day formatted as YYYY-MM-DD
+	ConsensusLayer string `json:"consensusLayer" unit:"wei"` // Net CL rewards in wei
+	ExecutionLayer string `json:"executionLayer" unit:"wei"` // EL proposal rewards in wei
+	Total          string `json:"total" unit:"wei"`          // CL + EL income in wei
+
+	// Price is the ETH price on that day, when a currency was requested and a price is known.
+	Price *float64 `json:"price,omitempty" unit:"fiat"`
+	// FiatValue is the total income valued at that day's price, its cost basis.
+	FiatValue *float64 `json:"fiatValue,omitempty" unit:"fiat"`
+	// CurrentValue is the total income valued at the current price.
+	CurrentValue *float64 `json:"currentValue,omitempty" unit:"fiat"`
+}
+
+// BalanceHistoryResponse is the per-epoch balance history of a single validator.
+type BalanceHistoryResponse struct {
+	ValidatorIndex int            `json:"validatorIndex"`
+	Chain          string         `json:"chain"`
+	Epochs         []EpochBalance `json:"epochs"` // Oldest first
+}
+
+// EpochBalance contains a validator's balances at the end of an epoch.
+type EpochBalance struct {
+	Epoch            int64  `json:"epoch"`
+	Balance          string `json:"balance" unit:"wei"`          // in wei
+	EffectiveBalance string `json:"effectiveBalance" unit:"wei"` // in wei
+}
+
+// ValidatorDeltaResponse lists the validators whose status, balance or online flag
+// changed since a time, based on the recorded snapshots.
+type ValidatorDeltaResponse struct {
+	Since      time.Time                 `json:"since"`
+	AsOf       time.Time                 `json:"asOf"`       // Latest snapshot of the validators; pass as since on the next request
+	Validators map[string]ValidatorDelta `json:"validators"` // Changed validators by index
+}
+
+// ValidatorDelta is the latest recorded state of a changed validator.
+type ValidatorDelta struct {
+	Status         string    `json:"status"`
+	CurrentBalance string    `json:"currentBalance" unit:"wei"` // in wei
+	Online         bool      `json:"online"`
+	Changed        []string  `json:"changed"` // "status", "balance" and/or "online"; all three for validators not recorded by since
+	Time           time.Time `json:"time"`    // When the state was recorded
+}
+
+// TimelineResponse lists the recorded state transitions of a single validator.
+type TimelineResponse struct {
+	ValidatorIndex int                  `json:"validatorIndex"`
+	Chain          string               `json:"chain"`
+	Since          time.Time            `json:"since"`          // Start of the timeline
+	OfflineSeconds int64                `json:"offlineSeconds"` // Time offline since the first recorded online flip, up to now
+	Transitions    []TimelineTransition `json:"transitions"`    // Oldest first
+}
+
+// TimelineTransition is a change of a validator's state, detected between two
+// snapshots or reported by an operator registry.
+type TimelineTransition struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"` // status_changed, online_changed, slashed, registry_key_added or registry_key_removed
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	// Seconds the validator stayed in To, until the next transition of the same
+	// type. Only set for status and online transitions, and omitted while the
+	// validator is still in To.
+	DurationSeconds *int64 `json:"durationSeconds,omitempty"`
+}
+
+// SLAResponse is the uptime of validators over SLA windows, each map keyed by
+// window, e.g. "30d".
+type SLAResponse struct {
+	Chain      string                       `json:"chain"`
+	Total      map[string]Uptime            `json:"total"`            // All requested validators
+	Validators map[string]map[string]Uptime `json:"validators"`       // By validator index
+	Groups     map[string]map[string]Uptime `json:"groups,omitempty"` // By value of the groupBy label
+}
+
+// Uptime is how long validators were online while active over a window.
+type Uptime struct {
+	Uptime         *float64 `json:"uptime"`         // Percentage of the active time spent online; null without recorded active time
+	ActiveSeconds  int64    `json:"activeSeconds"`  // Time spent active, summed over validators
+	OfflineSeconds int64    `json:"offlineSeconds"` // Time spent active but offline, summed over validators
+}
+
+// HealthResponse scores the health of validators from 0 to 100.
+type HealthResponse struct {
+	Chain      string                 `json:"chain"`
+	Range      string                 `json:"range"`      // Window of the performance components
+	Weights    map[string]float64     `json:"weights"`    // Weight of each component
+	Fleet      HealthScore            `json:"fleet"`      // Mean of the scored validators
+	Validators map[string]HealthScore `json:"validators"` // By validator index
+}
+
+// HealthScore is a weighted mean of health components, each scored from 0 to 1.
+// Only active validators are scored.
+type HealthScore struct {
+	Score      *float64           `json:"score"`                // 0-100, null when not scored
+	Components map[string]float64 `json:"components,omitempty"` // Known components only
+}
+
+// AttestationTrendResponse is the attestation effectiveness of a set of validators over time.
+type AttestationTrendResponse struct {
+	Range   string                   `json:"range"`  // Evaluation range of the underlying samples
+	Bucket  string                   `json:"bucket"` // "hour" or "day"
+	Buckets []AttestationTrendBucket `json:"buckets"`
+}
+
+// AttestationTrendBucket averages the samples recorded within one bucket.
+// Buckets without samples are omitted.
+type AttestationTrendBucket struct {
+	Start             time.Time `json:"start"`
+	Samples           int       `json:"samples"`
+	Effectiveness     float64   `json:"effectiveness"` // Mean included/assigned ratio, 0-1
+	AvgInclusionDelay float64   `json:"avgInclusionDelay"`
+}
+
+// SyncCommitteesResponse lists the sync committee assignments of the requested validators.
+type SyncCommitteesResponse struct {
+	// Assignments are ordered newest period first, then by validator index.
+	Assignments []SyncCommitteeAssignment `json:"assignments"`
+}
+
+// SyncCommitteeAssignment is a validator's membership in the sync committee of one period.
+type SyncCommitteeAssignment struct {
+	ValidatorIndex    int      `json:"validatorIndex"`
+	Period            int64    `json:"period"`
+	StartEpoch        int64    `json:"startEpoch"`
+	EndEpoch          int64    `json:"endEpoch"`
+	Current           bool     `json:"current"` // The period is still in progress
+	Assigned          int      `json:"assigned"`
+	Successful        int      `json:"successful"`
+	Missed            int      `json:"missed"`
+	ParticipationRate *float64 `json:"participationRate"`  // successful/assigned, null before the first slot
+	Rewards           string   `json:"rewards" unit:"wei"` // in wei
+}
+
+// GraffitiResponse lists the graffiti of the blocks the requested validators
+// proposed over a range, with the clients inferred from it.
+type GraffitiResponse struct {
+	Range      string                       `json:"range"`
+	Policy     string                       `json:"policy,omitempty"`     // Pattern the graffiti was checked against
+	Validators map[string]ValidatorGraffiti `json:"validators"`           // By validator index, only validators that proposed
+	Clients    map[string]int               `json:"clients"`              // Blocks per consensus client, "unknown" when not inferred
+	Violations int                          `json:"violations,omitempty"` // Blocks whose graffiti does not match the policy
+}
+
+// ValidatorGraffiti is the graffiti of the blocks a validator proposed.
+type ValidatorGraffiti struct {
+	Blocks          []BlockGraffiti `json:"blocks"`                    // Latest first
+	ConsensusClient string          `json:"consensusClient,omitempty"` // Of the latest block it could be inferred from
+	ExecutionClient string          `json:"executionClient,omitempty"` // Of the latest block it could be inferred from
+	Compliant       *bool           `json:"compliant,omitempty"`       // All blocks match the policy, only set with a policy
+}
+
+// BlockGraffiti is the graffiti of a proposed block.
+type BlockGraffiti struct {
+	Slot            int64  `json:"slot"`
+	BlockNumber     int64  `json:"blockNumber,omitempty"`
+	Graffiti        string `json:"graffiti"`
+	ConsensusClient string `json:"consensusClient,omitempty"`
+	ExecutionClient string `json:"executionClient,omitempty"`
+	Compliant       *bool  `json:"compliant,omitempty"` // Only set with a policy
+}
+
+// SlashingResponse describes whether and how a validator was slashed.
+type SlashingResponse struct {
+	ValidatorIndex int  `json:"validatorIndex"`
+	Slashed        bool `json:"slashed"`
+	// Slashing is null when the validator is not slashed or the details are not available yet.
+	Slashing *SlashingDetails `json:"slashing"`
+}
+
+// SlashingDetails describes the slashing of a validator.
+type SlashingDetails struct {
+	Slot       int64     `json:"slot"`
+	Epoch      int64     `json:"epoch"`
+	Time       time.Time `json:"time"`
+	Reason     string    `json:"reason"`    // "attester_slashing" or "proposer_slashing"
+	Violation  string    `json:"violation"` // "double_proposal", "double_vote" or "surround_vote"
+	IncludedBy int       `json:"includedBy"`
+	Penalty    string    `json:"penalty" unit:"wei"` // in wei
+
+	// The conflicting messages signed by the validator, depending on the reason
+	ConflictingHeaders      []SlashingBlockHeader `json:"conflictingHeaders,omitempty"`
+	ConflictingAttestations []SlashingAttestation `json:"conflictingAttestations,omitempty"`
+}
+
+// SlashingBlockHeader is a block header signed by a slashed proposer.
+type SlashingBlockHeader struct {
+	Slot       int64  `json:"slot"`
+	ParentRoot string `json:"parentRoot"`
+	StateRoot  string `json:"stateRoot"`
+	BodyRoot   string `json:"bodyRoot"`
+}
+
+// SlashingAttestation is attestation data signed by a slashed attester.
+type SlashingAttestation struct {
+	Slot            int64  `json:"slot"`
+	BeaconBlockRoot string `json:"beaconBlockRoot"`
+	SourceEpoch     int64  `json:"sourceEpoch"`
+	TargetEpoch     int64  `json:"targetEpoch"`
+	TargetRoot      string `json:"targetRoot"`
+}
+
+// DashboardResponse is everything a landing page needs for a set of portfolios.
+type DashboardResponse struct {
+	Range      string             `json:"range"`
+	Portfolios []PortfolioSummary `json:"portfolios"`
+	// Rollup combines all portfolios per chain. Validators in several portfolios are counted once.
+	Rollup       map[string]DashboardTotals `json:"rollup"`
+	RecentEvents []DashboardEvent           `json:"recentEvents"`
+}
+
+// DashboardTotals counts validators by state and sums their balances.
+type DashboardTotals struct {
+	Validators   int    `json:"validators"`
+	Online       int    `json:"online"`
+	Offline      int    `json:"offline"`
+	Slashed      int    `json:"slashed"`
+	TotalBalance string `json:"totalBalance" unit:"wei"` // in wei
+	// PresignedExits counts the validators with a pre-signed voluntary exit stored
+	PresignedExits int `json:"presignedExits"`
+}
+
+// PortfolioSummary summarizes a single portfolio.
+type PortfolioSummary struct {
+	Name  string `json:"name"`
+	Chain string `json:"chain"`
+	DashboardTotals
+	Rewards     string   `json:"rewards" unit:"wei"` // total over the range in wei
+	Beaconscore *float64 `json:"beaconscore"`
+	// Error is set when the portfolio could not be fetched; the other portfolios are still returned.
+	Error string `json:"error,omitempty"`
+}
+
+// DashboardEvent is a recent change in the state of a portfolio validator.
+type DashboardEvent struct {
+	Time           time.Time `json:"time"`
+	Chain          string    `json:"chain"`
+	ValidatorIndex int       `json:"validatorIndex"`
+	Portfolios     []string  `json:"portfolios"`
+	Type           string    `json:"type"`
+	From           string    `json:"from"`
+	To             string    `json:"to"`
+}
+
+// UsageResponse reports the upstream credits spent today against the daily budget.
+type UsageResponse struct {
+	Enabled      bool  `json:"enabled"` // False when no daily budget is configured
+	DailyCredits int64 `json:"dailyCredits"`
+	Spent        int64 `json:"spent"`
+	Remaining    int64 `json:"remaining"`
+	// Low is set once the remaining credits fall below the reserve; cached data is then preferred.
+	Low       bool                     `json:"low"`
+	Refused   int64                    `json:"refused"` // Upstream requests refused today for lack of credits
+	ResetsAt  time.Time                `json:"resetsAt"`
+	Endpoints map[string]EndpointUsage `json:"endpoints"`
+}
+
+// EndpointUsage is the upstream usage of a single endpoint today.
+type EndpointUsage struct {
+	Requests int64 `json:"requests"`
+	Credits  int64 `json:"credits"`
+}
+
+// CacheStatsResponse reports the in-memory caches of the service.
+type CacheStatsResponse struct {
+	Responses      CacheStats `json:"responses"`      // Validator responses
+	Aggregates     CacheStats `json:"aggregates"`     // Rewards and performance aggregates
+	BalanceHistory CacheStats `json:"balanceHistory"` // Balance histories
+}
+
+// CacheStats reports the size, limits and counters of a cache since startup.
+type CacheStats struct {
+	Entries    int   `json:"entries"`
+	Bytes      int64 `json:"bytes"`      // Approximate size of the cached values
+	MaxEntries int   `json:"maxEntries"` // 0 when unlimited
+	MaxBytes   int64 `json:"maxBytes"`   // 0 when unlimited
+	Hits       int64 `json:"hits"`
+	Misses     int64 `json:"misses"`
+	Evictions  int64 `json:"evictions"` // Entries dropped to stay within the limits
+}
+
+// AlertRule is a condition checked periodically against the data of a portfolio,
+// e.g. "beaconscore < 0.9 for 3 checks", with the channels notified when it fires
+// and resolves.
+type AlertRule struct {
+	Name      string   `json:"name"`
+	Portfolio string   `json:"portfolio"`
+	Condition string   `json:"condition"`
+	Range     string   `json:"range,omitempty"`    // Evaluation range of rewards and performance, 24h by default
+	Severity  string   `json:"severity,omitempty"` // info, warning or critical; warning by default
+	Channels  []string `json:"channels"`
+}
+
+// AlertRuleStatus is a rule with the outcome of its latest check.
+type AlertRuleStatus struct {
+	AlertRule
+	Firing    bool       `json:"firing"`
+	Matches   int        `json:"matches"`         // Consecutive checks the condition held
+	Value     *float64   `json:"value,omitempty"` // Metric value at the latest check
+	LastCheck *time.Time `json:"lastCheck,omitempty"`
+	LastError string     `json:"lastError,omitempty"`
+	Muted     bool       `json:"muted"` // Whether the latest check fell in a mute window
+}
+
+// AlertRulesResponse lists the alert rules in the order they were added.
+type AlertRulesResponse struct {
+	Rules []AlertRuleStatus `json:"rules"`
+}
+
+// Alert is an incident of a rule firing, from the check it fired on until it resolved.
+type Alert struct {
+	ID         string     `json:"id"`
+	Rule       string     `json:"rule"`
+	Portfolio  string     `json:"portfolio"`
+	Chain      string     `json:"chain"`
+	Severity   string     `json:"severity"`
+	Condition  string     `json:"condition"`
+	Status     string     `json:"status"` // open, acknowledged or resolved
+	Value      float64    `json:"value"`  // Metric value when the rule fired
+	Validators []int      `json:"validators,omitempty"`
+	FiredAt    time.Time  `json:"firedAt"`
+	AckedAt    *time.Time `json:"ackedAt,omitempty"`
+	AckedBy    string     `json:"ackedBy,omitempty"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// AlertsResponse lists alerts, most recently fired first.
+type AlertsResponse struct {
+	Alerts []Alert `json:"alerts"`
+}
+
+// AlertAckRequest is the optional body of an acknowledgement.
+type AlertAckRequest struct {
+	By string `json:"by,omitempty"` // Who acknowledged the alert
+}
+
+// AlertMute silences alerts on a portfolio, or on validators of a chain, between
+// Start and End, e.g. during planned client upgrades.
+type AlertMute struct {
+	ID         string    `json:"id"`
+	Portfolio  string    `json:"portfolio,omitempty"`
+	Chain      string    `json:"chain,omitempty"` // Chain of Validators
+	Validators []int     `json:"validators,omitempty"`
+	Start      time.Time `json:"start"` // Now if not set when the mute is created
+	End        time.Time `json:"end"`
+	Reason     string    `json:"reason,omitempty"`
+}
+
+// AlertMutesResponse lists the current and scheduled mute windows by start time.
+type AlertMutesResponse struct {
+	Mutes []AlertMute `json:"mutes"`
+}
+
+// ValidatorLabels are the user-defined labels of a validator.
+type ValidatorLabels struct {
+	Chain          string            `json:"chain"`
+	ValidatorIndex int               `json:"validatorIndex"`
+	Labels         map[string]string `json:"labels"`
+}
+
+// LabelsResponse lists the labels of every labeled validator on a chain, keyed by
+// validator index.
+type LabelsResponse struct {
+	Chain      string                       `json:"chain"`
+	Validators map[string]map[string]string `json:"validators"`
+}
+
+// Note is the freeform note of a validator.
+type Note struct {
+	Text      string    `json:"text"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ValidatorNote is the note of a validator.
+type ValidatorNote struct {
+	Chain          string `json:"chain"`
+	ValidatorIndex int    `json:"validatorIndex"`
+	Note
+}
+
+// ValidatorSearchResponse lists the validators on a chain whose labels and notes
+// match a search, by index.
+type ValidatorSearchResponse struct {
+	Chain      string              `json:"chain"`
+	Meta       []string            `json:"meta,omitempty"` // key:value labels all matched
+	Query      string              `json:"q,omitempty"`    // Text matched in notes or label values
+	Validators []ValidatorMetadata `json:"validators"`
+}
+
+// ValidatorMetadata is the user-defined metadata of a validator.
+type ValidatorMetadata struct {
+	ValidatorIndex int               `json:"validatorIndex"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	Notes          string            `json:"notes,omitempty"`
+}
+
+// SearchResponse lists the portfolios and validators matching a search, best
+// matches first.
+type SearchResponse struct {
+	Query     string         `json:"q"`
+	Results   []SearchResult `json:"results"`
+	Truncated bool           `json:"truncated,omitempty"` // More matched than the limit
+}
+
+// SearchResult is a portfolio or a validator matching a search.
+type SearchResult struct {
+	Type           string `json:"type"` // portfolio or validator
+	Chain          string `json:"chain"`
+	Portfolio      string `json:"portfolio,omitempty"`
+	ValidatorIds   []int  `json:"validatorIds,omitempty"` // Validators of a portfolio
+	ValidatorIndex *int   `json:"validatorIndex,omitempty"`
+	Match          string `json:"match"` // Field matched: name, index, pubkey, withdrawalAddress, label or notes
+	Value          string `json:"value"` // Content of the field matched, e.g. the label as key:value
+}
+
+// WatchlistImportResponse reports the validators of uploaded public keys added to
+// a watchlist, that is a portfolio.
+type WatchlistImportResponse struct {
+	Watchlist    string        `json:"watchlist"`
+	Chain        string        `json:"chain"`
+	Keys         int           `json:"keys"`         // Distinct public keys uploaded
+	Added        []int         `json:"added"`        // Validators added to the watchlist
+	Existing     []int         `json:"existing"`     // Validators already in the watchlist
+	NotActivated []ImportedKey `json:"notActivated"` // Keys without an activated validator yet
+}
+
+// ImportedKey is an uploaded public key whose validator is not activated yet.
+type ImportedKey struct {
+	PublicKey      string `json:"publicKey"`
+	ValidatorIndex *int   `json:"validatorIndex,omitempty"` // Unset until the deposit is processed
+	Status         string `json:"status"`                   // Upstream status, or unknown before the deposit is processed
+}
+
+// PresignedExit is the metadata of a pre-signed voluntary exit message kept by the
+// operator. The message itself is never sent to the server.
+type PresignedExit struct {
+	StoredAt time.Time `json:"storedAt"`           // When the message was signed and stored
+	Location string    `json:"location,omitempty"` // Where the message is kept, e.g. a vault path
+}
+
+// ValidatorPresignedExit is the pre-signed exit record of a validator.
+type ValidatorPresignedExit struct {
+	Chain          string `json:"chain"`
+	ValidatorIndex int    `json:"validatorIndex"`
+	PresignedExit
+}
+
+// PresignedExitsResponse lists the pre-signed exit records of a chain, keyed by
+// validator index.
+type PresignedExitsResponse struct {
+	Chain      string                   `json:"chain"`
+	Validators map[string]PresignedExit `json:"validators"`
+}
+
+// StateImport describes a state archive staged by POST /admin/import.
+type StateImport struct {
+	CreatedAt       time.Time `json:"createdAt"` // When the archive was exported
+	Files           int       `json:"files"`     // Data files staged
+	Bytes           int64     `json:"bytes"`     // Total size of the data files
+	Config          []string  `json:"config"`    // Configuration files in the archive, not imported
+	RestartRequired bool      `json:"restartRequired"`
+}
+
+// AuditEntry records a change made through the API: who made it, when, and the
+// request that made it.
+type AuditEntry struct {
+	Time     time.Time       `json:"time"`
+	Tenant   string          `json:"tenant,omitempty"` // Tenant whose API key was used
+	ClientIP string          `json:"clientIp"`
+	Method   string          `json:"method"`
+	Path     string          `json:"path"`
+	Query    string          `json:"query,omitempty"`
+	Body     json.RawMessage `json:"body,omitempty"` // JSON request body, omitted when larger than 8 KiB
+	Status   int             `json:"status"`
+}
+
+// AuditResponse lists audit entries, newest first.
+type AuditResponse struct {
+	Entries []AuditEntry `json:"entries"`
+}
+
+// DiversityResponse compares the client distribution of a fleet, taken from a
+// validator label, with the network.
+type DiversityResponse struct {
+	Chain            string        `json:"chain"`
+	Portfolio        string        `json:"portfolio,omitempty"`
+	Label            string        `json:"label"`                      // Label key holding the client
+	Validators       int           `json:"validators"`                 // Validators with the label
+	Unlabeled        int           `json:"unlabeled"`                  // Validators without the label
+	Clients          []ClientShare `json:"clients"`                    // Most validators first
+	NetworkSource    string        `json:"networkSource,omitempty"`    // Publisher of the network shares
+	NetworkUpdatedAt *time.Time    `json:"networkUpdatedAt,omitempty"` // When the network shares were published
+}
+
+// ClientShare is the share of a client in a fleet and in the network.
+type ClientShare struct {
+	Client       string   `json:"client"`
+	Validators   int      `json:"validators"`
+	Share        float64  `json:"share"`                  // Of the validators with the label, 0-1
+	NetworkShare *float64 `json:"networkShare,omitempty"` // Of the network validators, when known
+	OverOneThird bool     `json:"overOneThird"`           // The fleet share exceeds 1/3
+}
+
+// CompareResponse compares the aggregates of groups of validators side by side.
+type CompareResponse struct {
+	Range  string            `json:"range"`
+	Groups []GroupComparison `json:"groups"` // In the requested order
+}
+
+// GroupComparison is the aggregated performance of a group of validators: a
+// portfolio, or the validators with a label.
+type GroupComparison struct {
+	Group string `json:"group"` // Portfolio name or key:value label selector
+	Chain string `json:"chain"`
+	DashboardTotals
+	Rewards             string   `json:"rewards" unit:"wei"`             // Net rewards over the range in wei
+	RewardsPerValidator string   `json:"rewardsPerValidator" unit:"wei"` // in wei, for groups of different sizes
+	Beaconscore         *float64 `json:"beaconscore"`
+	AttestationMissRate *float64 `json:"attestationMissRate"` // Missed over assigned duties, null without duties
+	ProposalMissRate    *float64 `json:"proposalMissRate"`
+	SyncMissRate        *float64 `json:"syncMissRate"`
+	// Error is set when the group could not be fetched; the other groups are still returned.
+	Error string `json:"error,omitempty"`
+}
+
+// Benchmark compares the requested validators with the average validator of the
+// network over the same range.
+type Benchmark struct {
+	Fleet   BenchmarkValues `json:"fleet"`
+	Network BenchmarkValues `json:"network"`
+}
+
+// BenchmarkValues are performance figures of a set of validators over a range.
+type BenchmarkValues struct {
+	Beaconscore              *float64 `json:"beaconscore"`
+	AttestationEffectiveness *float64 `json:"attestationEffectiveness"` // Included over assigned attestations
+	APR                      *float64 `json:"apr"`                      // Annualized net rewards over effective balance
+}
+
+// ProjectionResponse projects the earnings of validators from their recent rewards
+// and from the network APR.
+type ProjectionResponse struct {
+	Chain         string     `json:"chain"`
+	Range         string     `json:"range"`                    // Window of the recent rewards
+	StakedBalance string     `json:"stakedBalance" unit:"wei"` // Effective balance of the active validators in wei
+	Price         *FiatPrice `json:"price,omitempty"`          // Set when a currency was requested and a price is available
+	Fleet         *Earnings  `json:"fleet"`                    // At the validators' own reward rate; null when the rewards are unavailable
+	Network       *Earnings  `json:"network"`                  // At the network APR; null when it is unavailable
+}
+
+// Earnings are projected earnings at a constant rate. Months are a twelfth of a year.
+type Earnings struct {
+	APR     *float64      `json:"apr"` // Annualized net rewards over the staked balance
+	Daily   string        `json:"daily" unit:"wei"`
+	Monthly string        `json:"monthly" unit:"wei"`
+	Annual  string        `json:"annual" unit:"wei"`
+	Fiat    *FiatEarnings `json:"fiat,omitempty"` // In the requested currency
+}
+
+// FiatEarnings are projected earnings valued at the current price.
+type FiatEarnings struct {
+	Daily   float64 `json:"daily" unit:"fiat"`
+	Monthly float64 `json:"monthly" unit:"fiat"`
+	Annual  float64 `json:"annual" unit:"fiat"`
+}
+
+// ValidatorReportRequest is the body of POST /jobs/validator-report.
+type ValidatorReportRequest struct {
+	ValidatorIds []int  `json:"validatorIds"`
+	Chain        string `json:"chain"`
+	Range        string `json:"range,omitempty"`     // Defaults to all_time
+	Anomalies    string `json:"anomalies,omitempty"` // include or exclude, with the same default as GET /validator
+}
+
+// Job is the status of a background job.
+type Job struct {
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`   // e.g. validator-report
+	Status     string     `json:"status"` // queued, running, done or failed
+	Done       int        `json:"done"`   // Items processed so far, e.g. validators
+	Total      int        `json:"total"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"` // When a finished job and its result are removed
+	ResultURL  string     `json:"resultUrl,omitempty"` // Set once the job is done
+}
+
+// ValidatorReport is the result of a validator report job: the data of every
+// requested validator, fetched in batches of up to 100 validators.
+type ValidatorReport struct {
+	Chain      string                       `json:"chain"`
+	Range      string                       `json:"range"`
+	Validators map[string]ValidatorOverview `json:"validators"`
+	Totals     DashboardTotals              `json:"totals"`
+	Rewards    string                       `json:"rewards" unit:"wei"` // Net rewards of all batches in wei
+	// Batches holds the rewards and performance of each batch, since upstream
+	// aggregates them per request.
+	Batches []ValidatorReportBatch `json:"batches"`
+}
+
+// ValidatorReportBatch is the aggregated rewards and performance of a batch of a report.
+type ValidatorReportBatch struct {
+	ValidatorIds []int                `json:"validatorIds"`
+	Rewards      ValidatorRewards     `json:"rewards"`
+	Performance  ValidatorPerformance `json:"performance"`
+}
+
+// GrafanaSearchRequest is the body of POST /grafana/search.
+type GrafanaSearchRequest struct {
+	Target string `json:"target"` // Text the listed targets must contain
+}
+
+// GrafanaQueryRequest is the body of POST /grafana/query, as sent by the Grafana
+// JSON datasources. Fields the service does not use are ignored.
+type GrafanaQueryRequest struct {
+	Range         GrafanaRange    `json:"range"`
+	IntervalMs    int64           `json:"intervalMs"`    // Suggested spacing of the points
+	MaxDataPoints int             `json:"maxDataPoints"` // Most points the panel can show
+	Targets       []GrafanaTarget `json:"targets"`
+}
+
+// GrafanaRange is the time range of a Grafana query.
+type GrafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// GrafanaTarget is a queried series, e.g. "main/balance".
+type GrafanaTarget struct {
+	Target string `json:"target"`
+	RefID  string `json:"refId"`
+	Hide   bool   `json:"hide"` // Hidden targets are not queried
+}
+
+// GrafanaTimeSeries is a series of [value, Unix milliseconds] points.
+type GrafanaTimeSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// PortfolioReport summarizes the income and performance of a portfolio over the
+// period of a report schedule.
+type PortfolioReport struct {
+	ID          string    `json:"id"`
+	Schedule    string    `json:"schedule"` // Name of the schedule that produced the report
+	Portfolio   string    `json:"portfolio"`
+	Chain       string    `json:"chain"`
+	Period      string    `json:"period"` // daily or weekly
+	Range       string    `json:"range"`  // Evaluation window of the figures: 24h or 7d
+	GeneratedAt time.Time `json:"generatedAt"`
+	DashboardTotals
+	Rewards     ValidatorRewards     `json:"rewards"`
+	Performance ValidatorPerformance `json:"performance"`
+
+	// Uptime over the range and the notable events of the period, newest first,
+	// from the recorded history
+	Uptime *Uptime          `json:"uptime,omitempty"`
+	Events []DashboardEvent `json:"events,omitempty"`
+	Alerts []Alert          `json:"alerts,omitempty"` // Fired during the period or still unresolved
+}
+
+// ReportSummary identifies a stored report in a listing.
+type ReportSummary struct {
+	ID          string    `json:"id"`
+	Schedule    string    `json:"schedule"`
+	Portfolio   string    `json:"portfolio"`
+	Period      string    `json:"period"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	URL         string    `json:"url"`
+}
+
+// ReportsResponse lists the stored reports, newest first.
+type ReportsResponse struct {
+	Reports []ReportSummary `json:"reports"`
+}
+
+// ClientMissesResponse compares the attestations signed by the validator client of
+// a portfolio with those included on chain, over about the last day.
+type ClientMissesResponse struct {
+	Portfolio   string             `json:"portfolio"`
+	Chain       string             `json:"chain"`
+	Client      string             `json:"client,omitempty"`      // Client recognized from the metrics
+	ScrapeError string             `json:"scrapeError,omitempty"` // Error of the last scrape, if it failed
+	Total       ClientMissWindow   `json:"total"`
+	Windows     []ClientMissWindow `json:"windows"` // Oldest first
+}
+
+// ClientMissWindow counts the attestation duties of a portfolio in the epochs
+// between two scrapes of its validator client.
+type ClientMissWindow struct {
+	StartEpoch  int64 `json:"startEpoch"`
+	EndEpoch    int64 `json:"endEpoch"`
+	Expected    int   `json:"expected"`    // Duties of the active validators
+	Included    int   `json:"included"`    // Attestations included on chain
+	Signed      int   `json:"signed"`      // Attestations the client signed
+	Failed      int   `json:"failed"`      // Attestations the client failed to sign or publish
+	NotSigned   int   `json:"notSigned"`   // Missed because the client did not sign
+	NotIncluded int   `json:"notIncluded"` // Missed although signed, not included by the network
+}
+
+// ClustersResponse is the response of GET /clusters.
+type ClustersResponse struct {
+	Range    string           `json:"range"`
+	Clusters []ClusterSummary `json:"clusters"`
+}
+
+// ClusterSummary aggregates the validators of a distributed validator cluster,
+// which its operators run together rather than one per validator.
+type ClusterSummary struct {
+	Name           string `json:"name"` // Portfolio name
+	Chain          string `json:"chain"`
+	Protocol       string `json:"protocol"`       // obol or ssv
+	Threshold      int    `json:"threshold"`      // Operators needed to sign
+	FaultTolerance int    `json:"faultTolerance"` // Operators that may be down without missing duties
+	DashboardTotals
+	Rewards      string            `json:"rewards" unit:"wei"` // Net rewards over the range in wei
+	Beaconscore  *float64          `json:"beaconscore"`
+	Attestations AttestationDuties `json:"attestations"`
+	Proposals    ProposalDuties    `json:"proposals"`
+	Operators    []ClusterOperator `json:"operators"`
+	// Error is set when the cluster could not be fetched; the other clusters are still returned.
+	Error string `json:"error,omitempty"`
+}
+
+// ClusterOperator is an operator of a cluster with its share of the rewards.
+type ClusterOperator struct {
+	Name    string  `json:"name"`
+	ID      string  `json:"id,omitempty"`
+	Share   float64 `json:"share"`              // Share of the cluster's rewards, 0-1
+	Rewards string  `json:"rewards" unit:"wei"` // Share of the net rewards over the range in wei
+}
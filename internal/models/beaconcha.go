@@ -1,6 +1,12 @@
 // Package models contains Beaconcha API response structures.
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
 // BeaconchainValidatorsRequest represents the request body for POST /api/v2/ethereum/validators.
 type BeaconchainValidatorsRequest struct {
 	Chain     string                       `json:"chain,omitempty"`
@@ -31,6 +37,7 @@ type BeaconchainValidatorData struct {
 	LifeCycleEpochs       BeaconchainLifeCycleEpochs   `json:"life_cycle_epochs"`
 	Balances              BeaconchainValidatorBalances `json:"balances"`
 	Finality              string                       `json:"finality,omitempty"`
+	QueuePosition         *int                         `json:"queue_position,omitempty"` // 1-based entry queue position of pending validators
 }
 
 // BeaconchainValidatorInfo contains the validator index and public key.
@@ -77,7 +84,56 @@ type BeaconchainPerformanceAggregateRequest struct {
 
 // BeaconchainValidatorSelector selects validators by identifiers.
 type BeaconchainValidatorSelector struct {
-	ValidatorIdentifiers []int `json:"validator_identifiers"`
+	ValidatorIdentifiers []int    `json:"validator_identifiers,omitempty"`
+	PublicKeys           []string `json:"-"`                            // Sent among the validator identifiers
+	WithdrawalAddress    string   `json:"withdrawal_address,omitempty"` // Selects all validators withdrawing to the address
+}
+
+// beaconchainValidatorSelectorJSON is the encoding of BeaconchainValidatorSelector,
+// whose identifiers are indices or public keys.
+type beaconchainValidatorSelectorJSON struct {
+	ValidatorIdentifiers []json.RawMessage `json:"validator_identifiers,omitempty"`
+	WithdrawalAddress    string            `json:"withdrawal_address,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, sending the indices and then the public
+// keys as validator identifiers.
+func (s BeaconchainValidatorSelector) MarshalJSON() ([]byte, error) {
+	out := beaconchainValidatorSelectorJSON{WithdrawalAddress: s.WithdrawalAddress}
+	for _, id := range s.ValidatorIdentifiers {
+		out.ValidatorIdentifiers = append(out.ValidatorIdentifiers, json.RawMessage(strconv.Itoa(id)))
+	}
+	for _, key := range s.PublicKeys {
+		data, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		out.ValidatorIdentifiers = append(out.ValidatorIdentifiers, data)
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, splitting the validator identifiers
+// into indices and public keys.
+func (s *BeaconchainValidatorSelector) UnmarshalJSON(data []byte) error {
+	var in beaconchainValidatorSelectorJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*s = BeaconchainValidatorSelector{WithdrawalAddress: in.WithdrawalAddress}
+	for _, raw := range in.ValidatorIdentifiers {
+		var key string
+		if err := json.Unmarshal(raw, &key); err == nil {
+			s.PublicKeys = append(s.PublicKeys, key)
+			continue
+		}
+		var id int
+		if err := json.Unmarshal(raw, &id); err != nil {
+			return fmt.Errorf("validator identifier %s: %w", raw, err)
+		}
+		s.ValidatorIdentifiers = append(s.ValidatorIdentifiers, id)
+	}
+	return nil
 }
 
 // BeaconchainTimeRangeSelector specifies the time range for aggregation.
@@ -235,3 +291,362 @@ type BeaconchainProposalDuties struct {
 type BeaconchainErrorResponse struct {
 	Message string `json:"message"`
 }
+
+// BeaconchainWithdrawalsRequest represents the request body for POST /api/v2/ethereum/validators/withdrawals.
+type BeaconchainWithdrawalsRequest struct {
+	Chain     string                       `json:"chain,omitempty"`
+	Validator BeaconchainValidatorSelector `json:"validator"`
+	Range     BeaconchainTimeRangeSelector `json:"range"`
+	PageSize  int                          `json:"page_size,omitempty"`
+	Cursor    string                       `json:"cursor,omitempty"`
+}
+
+// BeaconchainWithdrawalsResponse represents the response from the withdrawals endpoint.
+type BeaconchainWithdrawalsResponse struct {
+	Data   []BeaconchainWithdrawal `json:"data"`
+	Range  BeaconchainResultRange  `json:"range,omitempty"`
+	Paging *BeaconchainPaging      `json:"paging,omitempty"`
+}
+
+// BeaconchainWithdrawal represents a single withdrawal processed for a validator.
+type BeaconchainWithdrawal struct {
+	Validator BeaconchainValidatorInfo `json:"validator"`
+	Index     int64                    `json:"index"`
+	Slot      int64                    `json:"slot"`
+	Epoch     int64                    `json:"epoch"`
+	Timestamp int64                    `json:"timestamp"`
+	Address   string                   `json:"address"`
+	Amount    string                   `json:"amount"` // in wei
+	Finality  string                   `json:"finality,omitempty"`
+}
+
+// BeaconchainBlocksRequest represents the request body for POST /api/v2/ethereum/validators/blocks.
+type BeaconchainBlocksRequest struct {
+	Chain     string                       `json:"chain,omitempty"`
+	Validator BeaconchainValidatorSelector `json:"validator"`
+	Range     BeaconchainTimeRangeSelector `json:"range"`
+	PageSize  int                          `json:"page_size,omitempty"`
+	Cursor    string                       `json:"cursor,omitempty"`
+}
+
+// BeaconchainBlocksResponse represents the response from the blocks endpoint.
+type BeaconchainBlocksResponse struct {
+	Data   []BeaconchainBlock     `json:"data"`
+	Range  BeaconchainResultRange `json:"range,omitempty"`
+	Paging *BeaconchainPaging     `json:"paging,omitempty"`
+}
+
+// BeaconchainBlock represents a block proposal duty of a validator.
+type BeaconchainBlock struct {
+	Validator       BeaconchainValidatorInfo `json:"validator"`
+	Slot            int64                    `json:"slot"`
+	Epoch           int64                    `json:"epoch"`
+	BlockNumber     int64                    `json:"block_number,omitempty"`
+	Status          string                   `json:"status"`             // proposed, missed or orphaned
+	ExecutionReward string                   `json:"execution_reward"`   // Priority fees paid to the fee recipient in wei
+	MevReward       string                   `json:"mev_reward"`         // Builder payment to the fee recipient in wei, for relayed blocks
+	Relay           *string                  `json:"relay,omitempty"`    // Tag of the relay that delivered the block
+	Graffiti        string                   `json:"graffiti,omitempty"` // Graffiti as text
+	Finality        string                   `json:"finality,omitempty"`
+}
+
+// BeaconchainAttestationsRequest represents the request body for POST /api/v2/ethereum/validators/attestations.
+type BeaconchainAttestationsRequest struct {
+	Chain     string                        `json:"chain,omitempty"`
+	Validator BeaconchainValidatorSelector  `json:"validator"`
+	Range     BeaconchainEpochRangeSelector `json:"range"`
+	PageSize  int                           `json:"page_size,omitempty"`
+	Cursor    string                        `json:"cursor,omitempty"`
+}
+
+// BeaconchainAttestationsResponse represents the response from the attestations endpoint.
+type BeaconchainAttestationsResponse struct {
+	Data   []BeaconchainAttestation `json:"data"`
+	Paging *BeaconchainPaging       `json:"paging,omitempty"`
+}
+
+// BeaconchainAttestation represents an attestation of a validator included on chain.
+// A validator attests once per epoch, so several included attestations with
+// different data for the same epoch mean it signed from more than one place.
+type BeaconchainAttestation struct {
+	Validator       BeaconchainValidatorInfo `json:"validator"`
+	Epoch           int64                    `json:"epoch"`
+	Slot            int64                    `json:"slot"`
+	InclusionSlot   int64                    `json:"inclusion_slot"`
+	CommitteeIndex  int                      `json:"committee_index"`
+	BeaconBlockRoot string                   `json:"beacon_block_root"`
+	SourceRoot      string                   `json:"source_root"`
+	TargetRoot      string                   `json:"target_root"`
+}
+
+// BeaconchainRewardsHistoryRequest represents the request body for the rewards history endpoint.
+type BeaconchainRewardsHistoryRequest struct {
+	Chain       string                       `json:"chain,omitempty"`
+	Validator   BeaconchainValidatorSelector `json:"validator"`
+	Range       BeaconchainTimeRangeSelector `json:"range"`
+	Aggregation string                       `json:"aggregation"` // Bucket size, e.g. "day"
+	PageSize    int                          `json:"page_size,omitempty"`
+	Cursor      string                       `json:"cursor,omitempty"`
+}
+
+// BeaconchainRewardsHistoryResponse represents the response from the rewards history endpoint.
+// Each entry contains the rewards of all requested validators combined within one bucket.
+type BeaconchainRewardsHistoryResponse struct {
+	Data   []BeaconchainRewardsHistoryEntry `json:"data"`
+	Paging *BeaconchainPaging               `json:"paging,omitempty"`
+}
+
+// BeaconchainRewardsHistoryEntry contains the rewards within a single bucket.
+type BeaconchainRewardsHistoryEntry struct {
+	Range   BeaconchainResultRange `json:"range"`
+	Rewards BeaconchainRewardsData `json:"rewards"`
+}
+
+// BeaconchainBalanceHistoryRequest represents the request body for the balance history endpoint.
+type BeaconchainBalanceHistoryRequest struct {
+	Chain     string                        `json:"chain,omitempty"`
+	Validator BeaconchainValidatorSelector  `json:"validator"`
+	Range     BeaconchainEpochRangeSelector `json:"range"`
+	PageSize  int                           `json:"page_size,omitempty"`
+	Cursor    string                        `json:"cursor,omitempty"`
+}
+
+// BeaconchainEpochRangeSelector selects an inclusive range of epochs.
+type BeaconchainEpochRangeSelector struct {
+	Epoch BeaconchainEpochRange `json:"epoch"`
+}
+
+// BeaconchainBalanceHistoryResponse represents the response from the balance history endpoint.
+type BeaconchainBalanceHistoryResponse struct {
+	Data   []BeaconchainBalanceHistoryEntry `json:"data"`
+	Paging *BeaconchainPaging               `json:"paging,omitempty"`
+}
+
+// BeaconchainBalanceHistoryEntry contains a validator's balance at the end of an epoch.
+type BeaconchainBalanceHistoryEntry struct {
+	Epoch            int64  `json:"epoch"`
+	Balance          string `json:"balance"`
+	EffectiveBalance string `json:"effective_balance"`
+}
+
+// BeaconchainSyncCommitteesRequest represents the request body for the sync committees endpoint.
+type BeaconchainSyncCommitteesRequest struct {
+	Chain     string                       `json:"chain,omitempty"`
+	Validator BeaconchainValidatorSelector `json:"validator"`
+	PageSize  int                          `json:"page_size,omitempty"`
+	Cursor    string                       `json:"cursor,omitempty"`
+}
+
+// BeaconchainSyncCommitteesResponse represents the response from the sync committees endpoint.
+type BeaconchainSyncCommitteesResponse struct {
+	Data   []BeaconchainSyncCommitteeAssignment `json:"data"`
+	Paging *BeaconchainPaging                   `json:"paging,omitempty"`
+}
+
+// BeaconchainSyncCommitteeAssignment is a validator's membership in the sync committee of one period.
+type BeaconchainSyncCommitteeAssignment struct {
+	Validator  BeaconchainValidatorInfo `json:"validator"`
+	Period     int64                    `json:"period"`
+	Epoch      BeaconchainEpochRange    `json:"epoch"`
+	Assigned   int                      `json:"assigned"`   // Slots elapsed in the period so far
+	Successful int                      `json:"successful"` // Slots with a sync committee signature included
+	Missed     int                      `json:"missed"`
+	Reward     string                   `json:"reward"` // Net sync committee reward in wei
+}
+
+// BeaconchainSlashingsRequest represents the request body for the slashings endpoint.
+type BeaconchainSlashingsRequest struct {
+	Chain     string                       `json:"chain,omitempty"`
+	Validator BeaconchainValidatorSelector `json:"validator"`
+}
+
+// BeaconchainSlashingsResponse represents the response from the slashings endpoint.
+type BeaconchainSlashingsResponse struct {
+	Data []BeaconchainSlashing `json:"data"`
+}
+
+// BeaconchainSlashing is a slashing of a validator included on chain.
+type BeaconchainSlashing struct {
+	Validator        BeaconchainValidatorInfo     `json:"validator"`
+	Slot             int64                        `json:"slot"`
+	Epoch            int64                        `json:"epoch"`
+	Timestamp        int64                        `json:"timestamp"`
+	Type             string                       `json:"type"`     // "attester_slashing" or "proposer_slashing"
+	Proposer         int                          `json:"proposer"` // Index of the validator that included the slashing
+	Penalty          string                       `json:"penalty"`  // in wei
+	ProposerSlashing *BeaconchainProposerSlashing `json:"proposer_slashing,omitempty"`
+	AttesterSlashing *BeaconchainAttesterSlashing `json:"attester_slashing,omitempty"`
+}
+
+// BeaconchainProposerSlashing holds the two conflicting block headers signed for the same slot.
+type BeaconchainProposerSlashing struct {
+	Header1 BeaconchainBlockHeader `json:"header_1"`
+	Header2 BeaconchainBlockHeader `json:"header_2"`
+}
+
+// BeaconchainBlockHeader is a signed beacon block header.
+type BeaconchainBlockHeader struct {
+	Slot       int64  `json:"slot"`
+	ParentRoot string `json:"parent_root"`
+	StateRoot  string `json:"state_root"`
+	BodyRoot   string `json:"body_root"`
+}
+
+// BeaconchainAttesterSlashing holds the two conflicting attestations.
+type BeaconchainAttesterSlashing struct {
+	Attestation1 BeaconchainAttestationData `json:"attestation_1"`
+	Attestation2 BeaconchainAttestationData `json:"attestation_2"`
+}
+
+// BeaconchainAttestationData is the data signed in an attestation.
+type BeaconchainAttestationData struct {
+	Slot            int64  `json:"slot"`
+	BeaconBlockRoot string `json:"beacon_block_root"`
+	SourceEpoch     int64  `json:"source_epoch"`
+	TargetEpoch     int64  `json:"target_epoch"`
+	TargetRoot      string `json:"target_root"`
+}
+
+// BeaconchainQueuesRequest represents the request body for the network queues endpoint.
+type BeaconchainQueuesRequest struct {
+	Chain string `json:"chain,omitempty"`
+}
+
+// BeaconchainQueuesResponse represents the response from the network queues endpoint.
+type BeaconchainQueuesResponse struct {
+	Data BeaconchainQueues `json:"data"`
+}
+
+// BeaconchainQueues describes the validator entry and exit queues of the network.
+type BeaconchainQueues struct {
+	EntryQueue BeaconchainQueue `json:"entry_queue"`
+	ExitQueue  BeaconchainQueue `json:"exit_queue"`
+}
+
+// BeaconchainQueue is a validator queue and the number of validators it processes per epoch.
+type BeaconchainQueue struct {
+	Validators int `json:"validators"`
+	ChurnLimit int `json:"churn_limit"` // Validators dequeued per epoch
+}
+
+// BeaconchainNetworkPerformanceRequest represents the request body for the network
+// performance endpoint.
+type BeaconchainNetworkPerformanceRequest struct {
+	Chain string                       `json:"chain,omitempty"`
+	Range BeaconchainTimeRangeSelector `json:"range"`
+}
+
+// BeaconchainNetworkPerformanceResponse represents the response from the network
+// performance endpoint.
+type BeaconchainNetworkPerformanceResponse struct {
+	Data  BeaconchainNetworkPerformance `json:"data"`
+	Range BeaconchainResultRange        `json:"range"`
+}
+
+// BeaconchainNetworkPerformance contains the performance of all active validators
+// of the network, per validator where averaged.
+type BeaconchainNetworkPerformance struct {
+	Beaconscore BeaconchainBeaconscore       `json:"beaconscore"`
+	Duties      BeaconchainPerformanceDuties `json:"duties"`
+	APR         *float64                     `json:"apr"` // Annualized net rewards over effective balance
+}
+
+// BeaconchainNetworkSize describes the active validator set of the network.
+type BeaconchainNetworkSize struct {
+	Epoch                  int64  `json:"epoch"`
+	ActiveValidators       int    `json:"active_validators"`
+	ActiveEffectiveBalance string `json:"active_effective_balance"` // in wei
+}
+
+// BeaconchainV1Epoch represents an epoch from GET /api/v1/epoch/{epoch}. Balances
+// are in gwei.
+type BeaconchainV1Epoch struct {
+	Epoch           int64 `json:"epoch"`
+	ValidatorsCount int   `json:"validatorscount"` // Active validators
+	EligibleEther   int64 `json:"eligibleether"`   // Effective balance of the active validators
+}
+
+// BeaconchainExecutionBlock is an execution layer block of the network.
+type BeaconchainExecutionBlock struct {
+	BlockNumber    int64   `json:"block_number"`
+	Slot           int64   `json:"slot"`
+	ProducerReward string  `json:"producer_reward"` // Paid to the fee recipient of the proposer in wei, priority fees or builder payment
+	Relay          *string `json:"relay,omitempty"` // Tag of the relay that delivered the block
+}
+
+// BeaconchainV1ExecutionBlock represents a block from GET
+// /api/v1/execution/block/{numbers}. Rewards are in wei, as JSON numbers that may
+// exceed int64.
+type BeaconchainV1ExecutionBlock struct {
+	BlockNumber    int64               `json:"blockNumber"`
+	ProducerReward json.Number         `json:"producerReward"`
+	Relay          *BeaconchainV1Relay `json:"relay"` // Set for blocks delivered by a relay
+	PosConsensus   struct {
+		Slot int64 `json:"slot"`
+	} `json:"posConsensus"`
+}
+
+// BeaconchainV1Relay is the relay that delivered a v1 execution block.
+type BeaconchainV1Relay struct {
+	Tag string `json:"tag"`
+}
+
+// BeaconchainV1Response is the envelope of Beaconcha v1 API responses. Data is an
+// object for single results and an array otherwise.
+type BeaconchainV1Response struct {
+	Status string          `json:"status"` // "OK" on success, an error message otherwise
+	Data   json.RawMessage `json:"data"`
+}
+
+// BeaconchainV1Validator represents a validator from GET /api/v1/validator/{indices}.
+// Balances are in gwei.
+type BeaconchainV1Validator struct {
+	ValidatorIndex             int    `json:"validatorindex"`
+	Pubkey                     string `json:"pubkey"`
+	Status                     string `json:"status"`
+	Slashed                    bool   `json:"slashed"`
+	Balance                    int64  `json:"balance"`
+	EffectiveBalance           int64  `json:"effectivebalance"`
+	ActivationEligibilityEpoch int64  `json:"activationeligibilityepoch"`
+	ActivationEpoch            int64  `json:"activationepoch"`
+	ExitEpoch                  int64  `json:"exitepoch"`
+	WithdrawableEpoch          int64  `json:"withdrawableepoch"`
+	WithdrawalCredentials      string `json:"withdrawalcredentials"`
+}
+
+// BeaconchainV1ValidatorRef identifies a validator in GET /api/v1/validator/withdrawalCredentials/{address}.
+type BeaconchainV1ValidatorRef struct {
+	PublicKey      string `json:"publickey"`
+	ValidatorIndex int    `json:"validatorindex"`
+}
+
+// BeaconchainDashboardsResponse represents the response from GET /api/v2/users/me/dashboards.
+type BeaconchainDashboardsResponse struct {
+	Data struct {
+		ValidatorDashboards []BeaconchainValidatorDashboard `json:"validator_dashboards"`
+	} `json:"data"`
+}
+
+// BeaconchainValidatorDashboard is a validator dashboard of a beaconcha.in account.
+type BeaconchainValidatorDashboard struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Network    int64  `json:"network"` // Chain ID, e.g. 1 for mainnet
+	IsArchived bool   `json:"is_archived"`
+}
+
+// BeaconchainDashboardValidatorsResponse represents the response from
+// GET /api/v2/validator-dashboards/{id}/validators.
+type BeaconchainDashboardValidatorsResponse struct {
+	Data   []BeaconchainDashboardValidator `json:"data"`
+	Paging *BeaconchainPaging              `json:"paging,omitempty"`
+}
+
+// BeaconchainDashboardValidator is a validator of a dashboard and its group.
+type BeaconchainDashboardValidator struct {
+	Index int `json:"index"`
+	Group struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	} `json:"group"`
+}
@@ -0,0 +1,282 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+// Range is the evaluation range the portfolio data is fetched with. Proposals are
+// looked up over the same range.
+const Range = "24h"
+
+// Event types published to the events topic.
+const (
+	EventOnline        = "online"
+	EventOffline       = "offline"
+	EventSlashed       = "slashed"
+	EventStatusChanged = "status_changed"
+	EventProposal      = "proposal"
+)
+
+// Event is the JSON payload of a message on the events topic of a validator.
+type Event struct {
+	Type      string    `json:"type"`
+	Portfolio string    `json:"portfolio"`
+	Chain     string    `json:"chain"`
+	Validator int       `json:"validator"`
+	Status    string    `json:"status,omitempty"`   // New status, or the proposal outcome: proposed, missed or orphaned
+	Previous  string    `json:"previous,omitempty"` // Status before a status change
+	Slot      int64     `json:"slot,omitempty"`     // Slot of a proposal
+	Time      time.Time `json:"time"`
+}
+
+// Broker publishes messages to an MQTT broker.
+type Broker interface {
+	Publish(ctx context.Context, messages []Message) error
+}
+
+// Source provides the portfolio data and proposals changes are detected from.
+type Source interface {
+	GetPortfolioData(ctx context.Context, name, evalRange string) (models.ValidatorResponse, error)
+	GetBlocks(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainBlock, error)
+}
+
+// validatorState is the published state of a validator.
+type validatorState struct {
+	status  string
+	online  bool
+	slashed bool
+}
+
+// proposalState tracks the proposals of a portfolio already seen.
+type proposalState struct {
+	assigned int            // Proposal duties in the range when last fetched
+	seen     map[int64]bool // Slots of the proposals in the range
+}
+
+// Publisher publishes the state of every portfolio validator to retained topics,
+// and an event whenever it changes or the validator has a proposal:
+//
+//	<prefix>/<portfolio>/<validator>/online   online or offline
+//	<prefix>/<portfolio>/<validator>/status   e.g. active_online
+//	<prefix>/<portfolio>/<validator>/slashed  true or false
+//	<prefix>/<portfolio>/<validator>/events   Event as JSON, not retained
+//
+// The first run publishes the states without events.
+type Publisher struct {
+	portfolios *portfolio.Registry
+	source     Source
+	broker     Broker
+	prefix     string
+
+	states    map[string]map[int]validatorState // By portfolio and validator
+	proposals map[string]*proposalState         // By portfolio
+}
+
+// NewPublisher creates a publisher publishing the validators of the portfolios in
+// registry to broker, under the topic prefix.
+func NewPublisher(registry *portfolio.Registry, source Source, broker Broker, prefix string) *Publisher {
+	return &Publisher{
+		portfolios: registry,
+		source:     source,
+		broker:     broker,
+		prefix:     strings.TrimSuffix(prefix, "/"),
+		states:     make(map[string]map[int]validatorState),
+		proposals:  make(map[string]*proposalState),
+	}
+}
+
+// Run publishes immediately and then every interval until the context is canceled.
+func (p *Publisher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.Publish(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Publish refreshes the data of every portfolio and publishes what changed since
+// the last run. Data fetched during the current epoch is served from cache. A
+// portfolio that fails to load or publish is skipped, and its changes are
+// published on the next run.
+func (p *Publisher) Publish(ctx context.Context) {
+	for _, pf := range p.portfolios.All() {
+		data, err := p.source.GetPortfolioData(ctx, pf.Name, Range)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			slog.Warn("failed to load portfolio for mqtt", "portfolio", pf.Name, "error", err)
+			continue
+		}
+
+		now := time.Now()
+		states, messages := p.stateMessages(pf, data, now)
+		proposals, events := p.proposalMessages(ctx, pf, data, now)
+		messages = append(messages, events...)
+
+		if err := p.broker.Publish(ctx, messages); err != nil {
+			slog.Warn("failed to publish portfolio to mqtt", "portfolio", pf.Name, "error", err)
+			continue
+		}
+		p.states[pf.Name] = states
+		if proposals != nil {
+			p.proposals[pf.Name] = proposals
+		}
+	}
+}
+
+// stateMessages returns the states of the portfolio validators and the messages
+// of those that changed. Topics of validators that left the portfolio are cleared.
+func (p *Publisher) stateMessages(pf portfolio.Portfolio, data models.ValidatorResponse, now time.Time) (map[int]validatorState, []Message) {
+	prev, known := p.states[pf.Name]
+	states := make(map[int]validatorState, len(data.Validators))
+	var messages []Message
+
+	for key, v := range data.Validators {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		states[id] = validatorState{status: v.Status, online: v.Online, slashed: v.Slashed}
+	}
+
+	for _, id := range sortedIds(states) {
+		cur := states[id]
+		old, ok := prev[id]
+		event := Event{Portfolio: pf.Name, Chain: pf.Chain, Validator: id, Time: now}
+
+		if !ok || old.online != cur.online {
+			messages = append(messages, p.retained(pf.Name, id, "online", onlinePayload(cur.online)))
+			if ok {
+				event.Type = EventOffline
+				if cur.online {
+					event.Type = EventOnline
+				}
+				messages = append(messages, p.event(event))
+			}
+		}
+		if !ok || old.status != cur.status {
+			messages = append(messages, p.retained(pf.Name, id, "status", cur.status))
+			if ok {
+				event.Type, event.Status, event.Previous = EventStatusChanged, cur.status, old.status
+				messages = append(messages, p.event(event))
+			}
+		}
+		if !ok || old.slashed != cur.slashed {
+			messages = append(messages, p.retained(pf.Name, id, "slashed", strconv.FormatBool(cur.slashed)))
+			if ok && cur.slashed {
+				event.Type, event.Status, event.Previous = EventSlashed, cur.status, ""
+				messages = append(messages, p.event(event))
+			}
+		}
+	}
+
+	if known {
+		for _, id := range sortedIds(prev) {
+			if _, ok := states[id]; !ok {
+				for _, name := range []string{"online", "status", "slashed"} {
+					messages = append(messages, p.retained(pf.Name, id, name, ""))
+				}
+			}
+		}
+	}
+	return states, messages
+}
+
+// proposalMessages returns the proposals of the portfolio and the events of those
+// not seen before. Blocks are only fetched when the number of proposal duties in
+// the range changed, which is rare, so that most runs cost no extra request.
+// Returns a nil state if the proposals did not change or could not be fetched.
+func (p *Publisher) proposalMessages(ctx context.Context, pf portfolio.Portfolio, data models.ValidatorResponse, now time.Time) (*proposalState, []Message) {
+	assigned := data.Performance.Proposals.Assigned
+	prev := p.proposals[pf.Name]
+	if prev != nil && prev.assigned == assigned {
+		return nil, nil
+	}
+	if assigned == 0 {
+		return &proposalState{}, nil
+	}
+
+	blocks, err := p.source.GetBlocks(ctx, pf.Chain, pf.ValidatorIds, Range)
+	if err != nil {
+		slog.Warn("failed to fetch proposals for mqtt", "portfolio", pf.Name, "error", err)
+		return nil, nil
+	}
+
+	state := &proposalState{assigned: assigned, seen: make(map[int64]bool, len(blocks))}
+	var messages []Message
+	slices.SortFunc(blocks, func(a, b models.BeaconchainBlock) int { return int(a.Slot - b.Slot) })
+	for _, b := range blocks {
+		state.seen[b.Slot] = true
+		// Proposals before the first run are not news
+		if prev == nil || prev.seen[b.Slot] || b.Validator.Index == nil {
+			continue
+		}
+		messages = append(messages, p.event(Event{
+			Type:      EventProposal,
+			Portfolio: pf.Name,
+			Chain:     pf.Chain,
+			Validator: *b.Validator.Index,
+			Status:    b.Status,
+			Slot:      b.Slot,
+			Time:      now,
+		}))
+	}
+	return state, messages
+}
+
+// retained returns a retained message on a state topic of a validator. An empty
+// payload clears the topic.
+func (p *Publisher) retained(portfolioName string, id int, name, payload string) Message {
+	return Message{Topic: p.topic(portfolioName, id, name), Payload: []byte(payload), Retain: true}
+}
+
+// event returns a message on the events topic of the validator of e.
+func (p *Publisher) event(e Event) Message {
+	payload, _ := json.Marshal(e)
+	return Message{Topic: p.topic(e.Portfolio, e.Validator, "events"), Payload: payload}
+}
+
+// topic returns the topic of a validator. Topic separators and wildcards in the
+// portfolio name are replaced, so that every validator has its own topics.
+func (p *Publisher) topic(portfolioName string, id int, name string) string {
+	return p.prefix + "/" + topicEscaper.Replace(portfolioName) + "/" + strconv.Itoa(id) + "/" + name
+}
+
+// topicEscaper replaces the characters with a meaning in topics.
+var topicEscaper = strings.NewReplacer("/", "_", "+", "_", "#", "_")
+
+// onlinePayload returns the payload of the online topic.
+func onlinePayload(online bool) string {
+	if online {
+		return "online"
+	}
+	return "offline"
+}
+
+// sortedIds returns the validators of states in ascending order.
+func sortedIds(states map[int]validatorState) []int {
+	ids := make([]int, 0, len(states))
+	for id := range states {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,55 @@
+package msgpack
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{"nil", nil, "c0"},
+		{"bools", []bool{true, false}, "92c3c2"},
+		{"positive fixint", 7, "07"},
+		{"negative fixint", -3, "fd"},
+		{"int8", -100, "d09c"},
+		{"int16", 1000, "d103e8"},
+		{"int32", 100000, "d2000186a0"},
+		{"int64", int64(1) << 40, "d30000010000000000"},
+		{"uint64", uint64(1) << 63, "cf8000000000000000"},
+		{"float", 1.5, "cb3ff8000000000000"},
+		{"fixstr", "abc", "a3616263"},
+		{"str8", strings.Repeat("a", 32), "d920" + strings.Repeat("61", 32)},
+		{"sorted map", map[string]int{"b": 2, "a": 1}, "82a16101a16202"},
+		{"struct tags", struct {
+			Index int    `json:"index"`
+			Skip  string `json:"skip,omitempty"`
+		}{Index: 5}, "81a5696e64657805"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			if got := hex.EncodeToString(data); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMarshal_LongArray(t *testing.T) {
+	data, err := Marshal(make([]int, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(data[:3]); got != "dc0014" || len(data) != 23 {
+		t.Errorf("expected an array16 of 20 elements, got %x", data)
+	}
+}
@@ -0,0 +1,65 @@
+// Package notes stores freeform notes of validators, such as the hardware they
+// run on or the customer they belong to.
+package notes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/jsonstore"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// ErrInvalid is returned for notes that are not well formed.
+var ErrInvalid = errors.New("invalid note")
+
+// MaxLength is the maximum length of a note in bytes.
+const MaxLength = 4096
+
+// Store holds the notes of validators per chain. It is safe for concurrent use.
+type Store struct {
+	notes *jsonstore.Map[models.Note]
+}
+
+// NewStore creates an empty store kept in memory.
+func NewStore() *Store {
+	return &Store{notes: jsonstore.New[models.Note]("notes")}
+}
+
+// Load reads the notes from path and persists changes to it. A missing file
+// starts an empty store.
+func (s *Store) Load(path string) error {
+	return s.notes.Load(path)
+}
+
+// Get returns the note of a validator.
+func (s *Store) Get(chain string, index int) (models.Note, bool) {
+	return s.notes.Get(chain, index)
+}
+
+// Set replaces the note of a validator. An empty text removes it.
+func (s *Store) Set(chain string, index int, n models.Note) error {
+	if len(n.Text) > MaxLength {
+		return fmt.Errorf("%w: text must be at most %d bytes", ErrInvalid, MaxLength)
+	}
+	if !utf8.ValidString(n.Text) {
+		return fmt.Errorf("%w: text must be valid UTF-8", ErrInvalid)
+	}
+	if strings.IndexFunc(n.Text, func(r rune) bool { return !unicode.IsPrint(r) && !unicode.IsSpace(r) }) >= 0 {
+		return fmt.Errorf("%w: text must be printable", ErrInvalid)
+	}
+
+	if strings.TrimSpace(n.Text) == "" {
+		_, err := s.notes.Delete(chain, index)
+		return err
+	}
+	return s.notes.Set(chain, index, n)
+}
+
+// All returns the notes of every validator on chain, keyed by index.
+func (s *Store) All(chain string) map[int]models.Note {
+	return s.notes.All(chain)
+}
@@ -0,0 +1,63 @@
+package notes
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.json")
+	s := NewStore()
+	if err := s.Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	updatedAt := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	note := models.Note{Text: "Customer acme, rack 4\nMigrated to NUC in May", UpdatedAt: updatedAt}
+	for _, index := range []int{1, 2} {
+		if err := s.Set("mainnet", index, note); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	if err := s.Set("hoodi", 1, models.Note{Text: "test", UpdatedAt: updatedAt}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{name: "too long", text: strings.Repeat("a", MaxLength+1)},
+		{name: "unprintable", text: "rack\x00"},
+		{name: "invalid utf-8", text: "rack \xff"},
+	}
+	for _, tt := range tests {
+		if err := s.Set("mainnet", 3, models.Note{Text: tt.text}); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected ErrInvalid, got %v", tt.name, err)
+		}
+	}
+
+	// An empty note removes it
+	if err := s.Set("mainnet", 2, models.Note{Text: " \n"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	reloaded := NewStore()
+	if err := reloaded.Load(path); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if got, ok := reloaded.Get("mainnet", 1); !ok || got.Text != note.Text || !got.UpdatedAt.Equal(updatedAt) {
+		t.Errorf("expected the note to survive a reload, got %+v", got)
+	}
+	if _, ok := reloaded.Get("mainnet", 2); ok {
+		t.Error("expected the removed note to stay removed")
+	}
+	if all := reloaded.All("hoodi"); len(all) != 1 || all[1].Text != "test" {
+		t.Errorf("expected one hoodi note, got %v", all)
+	}
+}
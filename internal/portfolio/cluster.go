@@ -0,0 +1,80 @@
+package portfolio
+
+import (
+	"fmt"
+	"math"
+)
+
+// Distributed validator protocols.
+const (
+	ProtocolObol = "obol"
+	ProtocolSSV  = "ssv"
+)
+
+// shareTolerance is how far shares may add up from 1, for rounded percentages.
+const shareTolerance = 0.0001
+
+// Cluster describes the distributed validator cluster, such as an Obol or SSV
+// cluster, whose operators run a portfolio's validators together.
+type Cluster struct {
+	Protocol string `json:"protocol"` // obol or ssv
+	// Threshold is the number of operators needed to sign, by default the
+	// Byzantine fault tolerant majority of the operators.
+	Threshold int               `json:"threshold,omitempty"`
+	Operators []ClusterOperator `json:"operators"`
+}
+
+// ClusterOperator is an operator holding a key share of every cluster validator.
+type ClusterOperator struct {
+	Name string `json:"name"`
+	ID   string `json:"id,omitempty"` // e.g. SSV operator ID or Obol ENR
+	// Share is the operator's share of the cluster's rewards, 0-1. Operators share
+	// equally when no shares are set.
+	Share float64 `json:"share,omitempty"`
+}
+
+// FaultTolerance is the number of operators that may be down without the
+// cluster missing duties.
+func (c *Cluster) FaultTolerance() int {
+	return len(c.Operators) - c.Threshold
+}
+
+// normalize validates the cluster and sets the default threshold and shares.
+func (c *Cluster) normalize() error {
+	if c.Protocol != ProtocolObol && c.Protocol != ProtocolSSV {
+		return fmt.Errorf("cluster protocol must be %s or %s, got %q", ProtocolObol, ProtocolSSV, c.Protocol)
+	}
+	n := len(c.Operators)
+	if n == 0 {
+		return fmt.Errorf("cluster has no operators")
+	}
+	if c.Threshold == 0 {
+		c.Threshold = n - (n-1)/3
+	}
+	if c.Threshold < 1 || c.Threshold > n {
+		return fmt.Errorf("cluster threshold must be between 1 and %d operators, got %d", n, c.Threshold)
+	}
+
+	names := make(map[string]bool, n)
+	var total float64
+	for _, o := range c.Operators {
+		if o.Name == "" || names[o.Name] {
+			return fmt.Errorf("cluster operators must have distinct names, got %q", o.Name)
+		}
+		names[o.Name] = true
+		if o.Share < 0 || o.Share > 1 {
+			return fmt.Errorf("share of cluster operator %q must be between 0 and 1", o.Name)
+		}
+		total += o.Share
+	}
+	if total == 0 {
+		for i := range c.Operators {
+			c.Operators[i].Share = 1 / float64(n)
+		}
+		return nil
+	}
+	if math.Abs(total-1) > shareTolerance {
+		return fmt.Errorf("shares of cluster operators add up to %.4f, not 1", total)
+	}
+	return nil
+}
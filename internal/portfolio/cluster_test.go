@@ -0,0 +1,60 @@
+package portfolio
+
+import (
+	"testing"
+)
+
+func TestNewRegistry_Cluster(t *testing.T) {
+	operators := func(shares ...float64) []ClusterOperator {
+		ops := make([]ClusterOperator, len(shares))
+		for i, share := range shares {
+			ops[i] = ClusterOperator{Name: string(rune('a' + i)), Share: share}
+		}
+		return ops
+	}
+
+	tests := []struct {
+		name          string
+		cluster       Cluster
+		wantThreshold int
+		wantShares    []float64
+		wantErr       bool
+	}{
+		{name: "defaults for four operators", cluster: Cluster{Protocol: ProtocolObol, Operators: operators(0, 0, 0, 0)}, wantThreshold: 3, wantShares: []float64{0.25, 0.25, 0.25, 0.25}},
+		{name: "defaults for seven operators", cluster: Cluster{Protocol: ProtocolSSV, Operators: operators(0, 0, 0, 0, 0, 0, 0)}, wantThreshold: 5},
+		{name: "explicit shares", cluster: Cluster{Protocol: ProtocolSSV, Threshold: 2, Operators: operators(0.5, 0.3, 0.2)}, wantThreshold: 2, wantShares: []float64{0.5, 0.3, 0.2}},
+		{name: "shares not adding up", cluster: Cluster{Protocol: ProtocolObol, Operators: operators(0.5, 0.3)}, wantErr: true},
+		{name: "threshold above operators", cluster: Cluster{Protocol: ProtocolObol, Threshold: 5, Operators: operators(0, 0, 0, 0)}, wantErr: true},
+		{name: "unknown protocol", cluster: Cluster{Protocol: "lido", Operators: operators(0)}, wantErr: true},
+		{name: "no operators", cluster: Cluster{Protocol: ProtocolObol}, wantErr: true},
+		{name: "duplicate operators", cluster: Cluster{Protocol: ProtocolObol, Operators: []ClusterOperator{{Name: "a"}, {Name: "a"}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := tt.cluster
+			r, err := NewRegistry([]Portfolio{{Name: "dv", Chain: "mainnet", ValidatorIds: []int{1}, Cluster: &cluster}})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			p, _ := r.Get("dv")
+			if p.Cluster.Threshold != tt.wantThreshold {
+				t.Errorf("expected threshold %d, got %d", tt.wantThreshold, p.Cluster.Threshold)
+			}
+			if p.Cluster.FaultTolerance() != len(p.Cluster.Operators)-tt.wantThreshold {
+				t.Errorf("unexpected fault tolerance %d", p.Cluster.FaultTolerance())
+			}
+			for i, share := range tt.wantShares {
+				if got := p.Cluster.Operators[i].Share; got != share {
+					t.Errorf("operator %d: expected share %v, got %v", i, share, got)
+				}
+			}
+		})
+	}
+}
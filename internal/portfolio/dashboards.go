@@ -0,0 +1,136 @@
+package portfolio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// DashboardSource provides the validator dashboards of a beaconcha.in account.
+type DashboardSource interface {
+	GetDashboards(ctx context.Context) ([]models.BeaconchainValidatorDashboard, error)
+	GetDashboardValidators(ctx context.Context, id int64) ([]models.BeaconchainDashboardValidator, error)
+}
+
+// ImportDashboards returns a portfolio of the validators of each dashboard of the
+// account, or of each group of a dashboard with splitGroups, named
+// "dashboard/group". Archived and empty dashboards, and those on chains that are
+// not supported, are skipped with the reason in skipped.
+func ImportDashboards(ctx context.Context, src DashboardSource, splitGroups bool) (portfolios []Portfolio, skipped []string, err error) {
+	dashboards, err := src.GetDashboards(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, d := range dashboards {
+		name := strings.TrimSpace(d.Name)
+		if name == "" {
+			name = fmt.Sprintf("dashboard-%d", d.ID)
+		}
+		chain, ok := beaconcha.NetworkChain(d.Network)
+		switch {
+		case d.IsArchived:
+			skipped = append(skipped, fmt.Sprintf("%s: archived", name))
+			continue
+		case !ok:
+			skipped = append(skipped, fmt.Sprintf("%s: unsupported network %d", name, d.Network))
+			continue
+		}
+
+		validators, err := src.GetDashboardValidators(ctx, d.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(validators) == 0 {
+			skipped = append(skipped, fmt.Sprintf("%s: no validators", name))
+			continue
+		}
+
+		byName := make(map[string]int) // Index into portfolios of the dashboard's portfolios
+		for _, v := range validators {
+			pname := name
+			if splitGroups {
+				group := strings.TrimSpace(v.Group.Name)
+				if group == "" {
+					group = fmt.Sprintf("group-%d", v.Group.ID)
+				}
+				pname = name + "/" + group
+			}
+			i, ok := byName[pname]
+			if !ok {
+				i = len(portfolios)
+				byName[pname] = i
+				portfolios = append(portfolios, Portfolio{Name: pname, Chain: chain})
+			}
+			portfolios[i].ValidatorIds = append(portfolios[i].ValidatorIds, v.Index)
+		}
+	}
+	for i := range portfolios {
+		slices.Sort(portfolios[i].ValidatorIds)
+		portfolios[i].ValidatorIds = slices.Compact(portfolios[i].ValidatorIds)
+	}
+	return portfolios, skipped, nil
+}
+
+// MergeFile adds the portfolios to the JSON portfolios file at path, creating it
+// if missing, and returns the names of those added. Portfolios whose name is
+// already taken are left out. The file is only written if the merged portfolios
+// are valid.
+func MergeFile(path string, portfolios []Portfolio) ([]string, error) {
+	var existing []Portfolio
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("read portfolios: %w", err)
+	default:
+		if err := json.Unmarshal(data, &existing); err != nil {
+			return nil, fmt.Errorf("decode portfolios: %w", err)
+		}
+	}
+
+	taken := make(map[string]bool, len(existing))
+	for _, p := range existing {
+		taken[p.Name] = true
+	}
+	var added []string
+	for _, p := range portfolios {
+		if taken[p.Name] {
+			continue
+		}
+		taken[p.Name] = true
+		existing = append(existing, p)
+		added = append(added, p.Name)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	data, err = json.MarshalIndent(existing, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode portfolios: %w", err)
+	}
+	// Validate a copy, as the registry fills in defaults that should not be written
+	var merged []Portfolio
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, fmt.Errorf("decode portfolios: %w", err)
+	}
+	if _, err := NewRegistry(merged); err != nil {
+		return nil, err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return nil, fmt.Errorf("write portfolios: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, fmt.Errorf("write portfolios: %w", err)
+	}
+	return added, nil
+}
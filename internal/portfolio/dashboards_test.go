@@ -0,0 +1,126 @@
+package portfolio
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// fakeDashboards serves dashboards and their validators, by dashboard ID and
+// then group name.
+type fakeDashboards struct {
+	dashboards []models.BeaconchainValidatorDashboard
+	validators map[int64]map[string][]int
+}
+
+func (f *fakeDashboards) GetDashboards(ctx context.Context) ([]models.BeaconchainValidatorDashboard, error) {
+	return f.dashboards, nil
+}
+
+func (f *fakeDashboards) GetDashboardValidators(ctx context.Context, id int64) ([]models.BeaconchainDashboardValidator, error) {
+	var validators []models.BeaconchainDashboardValidator
+	for group, ids := range f.validators[id] {
+		for _, index := range ids {
+			v := models.BeaconchainDashboardValidator{Index: index}
+			v.Group.Name = group
+			validators = append(validators, v)
+		}
+	}
+	return validators, nil
+}
+
+func TestImportDashboards(t *testing.T) {
+	src := &fakeDashboards{
+		dashboards: []models.BeaconchainValidatorDashboard{
+			{ID: 1, Name: "home", Network: 1},
+			{ID: 2, Name: "testnet", Network: 560048},
+			{ID: 3, Name: "old", Network: 1, IsArchived: true},
+			{ID: 4, Name: "gnosis", Network: 100},
+			{ID: 5, Name: "empty", Network: 1},
+		},
+		validators: map[int64]map[string][]int{
+			1: {"node-a": {3, 1}, "node-b": {2}},
+			2: {"default": {9}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		splitGroups bool
+		expected    []Portfolio
+	}{
+		{"by dashboard", false, []Portfolio{
+			{Name: "home", Chain: "mainnet", ValidatorIds: []int{1, 2, 3}},
+			{Name: "testnet", Chain: "hoodi", ValidatorIds: []int{9}},
+		}},
+		{"by group", true, []Portfolio{
+			{Name: "home/node-a", Chain: "mainnet", ValidatorIds: []int{1, 3}},
+			{Name: "home/node-b", Chain: "mainnet", ValidatorIds: []int{2}},
+			{Name: "testnet/default", Chain: "hoodi", ValidatorIds: []int{9}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			portfolios, skipped, err := ImportDashboards(context.Background(), src, tt.splitGroups)
+			if err != nil {
+				t.Fatalf("ImportDashboards failed: %v", err)
+			}
+			slices.SortFunc(portfolios, func(a, b Portfolio) int { return strings.Compare(a.Name, b.Name) })
+			if !reflect.DeepEqual(portfolios, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, portfolios)
+			}
+			if len(skipped) != 3 {
+				t.Errorf("expected the archived, unsupported and empty dashboards skipped, got %v", skipped)
+			}
+		})
+	}
+}
+
+func TestMergeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "portfolios.json")
+
+	added, err := MergeFile(path, []Portfolio{{Name: "home", Chain: "mainnet", ValidatorIds: []int{1}}})
+	if err != nil {
+		t.Fatalf("MergeFile failed: %v", err)
+	}
+	if !slices.Equal(added, []string{"home"}) {
+		t.Errorf("expected home added, got %v", added)
+	}
+
+	// Taken names are left out and the existing portfolios kept as they are
+	added, err = MergeFile(path, []Portfolio{
+		{Name: "home", Chain: "mainnet", ValidatorIds: []int{2}},
+		{Name: "testnet", Chain: "hoodi", ValidatorIds: []int{9}},
+	})
+	if err != nil {
+		t.Fatalf("MergeFile failed: %v", err)
+	}
+	if !slices.Equal(added, []string{"testnet"}) {
+		t.Errorf("expected testnet added, got %v", added)
+	}
+	r, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := r.Get("home"); !slices.Equal(p.ValidatorIds, []int{1}) {
+		t.Errorf("expected home unchanged, got %v", p.ValidatorIds)
+	}
+
+	// Invalid portfolios are not written
+	before, _ := os.ReadFile(path)
+	if _, err := MergeFile(path, []Portfolio{{Name: "broken", Chain: "mainnet"}}); err == nil {
+		t.Error("expected error, got nil")
+	}
+	after, _ := os.ReadFile(path)
+	var portfolios []Portfolio
+	if err := json.Unmarshal(after, &portfolios); err != nil || string(before) != string(after) {
+		t.Errorf("expected file unchanged, got %s", after)
+	}
+}
@@ -0,0 +1,88 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// LoadImports restores the validators imported into the portfolios from path and
+// keeps later imports there. A missing file is not an error. Imports of
+// portfolios that are no longer configured are kept but not applied.
+func (r *Registry) LoadImports(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.importsPath = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read imported validators: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.imported); err != nil {
+		return fmt.Errorf("decode imported validators: %w", err)
+	}
+	return nil
+}
+
+// Import adds the validators ids to the portfolio name and returns those it did
+// not contain yet, in order.
+func (r *Registry) Import(name string, ids []int) ([]int, error) {
+	if r == nil {
+		return nil, fmt.Errorf("unknown portfolio %q", name)
+	}
+	i, ok := r.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown portfolio %q", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	contained := make(map[int]bool)
+	for _, id := range r.withResolvedLocked(r.portfolios[i]).ValidatorIds {
+		contained[id] = true
+	}
+	var added []int
+	for _, id := range ids {
+		if !contained[id] {
+			contained[id] = true
+			added = append(added, id)
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	previous := r.imported[name]
+	r.imported[name] = append(slices.Clone(previous), added...)
+	if err := r.saveImports(); err != nil {
+		r.imported[name] = previous
+		return nil, err
+	}
+	return added, nil
+}
+
+// saveImports atomically writes the imported validators, if a path is
+// configured. Callers must hold r.mu.
+func (r *Registry) saveImports() error {
+	if r.importsPath == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(r.imported)
+	if err != nil {
+		return fmt.Errorf("encode imported validators: %w", err)
+	}
+
+	tmp := r.importsPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write imported validators: %w", err)
+	}
+	return os.Rename(tmp, r.importsPath)
+}
@@ -0,0 +1,46 @@
+package portfolio
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRegistry_Import(t *testing.T) {
+	portfolios := []Portfolio{{Name: "a", Chain: "mainnet", ValidatorIds: []int{1, 2}}}
+	r, err := NewRegistry(portfolios)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "imports.json")
+	if err := r.LoadImports(path); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := r.Import("a", []int{2, 3, 4, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(added, []int{3, 4}) {
+		t.Errorf("expected validators 3 and 4 added, got %v", added)
+	}
+	if added, _ := r.Import("a", []int{1, 4}); len(added) != 0 {
+		t.Errorf("expected no validators added again, got %v", added)
+	}
+	if _, err := r.Import("b", []int{5}); err == nil {
+		t.Error("expected an error importing into an unknown portfolio")
+	}
+
+	// Imports survive a restart
+	restored, err := NewRegistry(portfolios)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.LoadImports(path); err != nil {
+		t.Fatal(err)
+	}
+	p, _ := restored.Get("a")
+	if !slices.Equal(p.ValidatorIds, []int{1, 2, 3, 4}) {
+		t.Errorf("expected validators [1 2 3 4], got %v", p.ValidatorIds)
+	}
+}
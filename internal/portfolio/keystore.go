@@ -0,0 +1,154 @@
+package portfolio
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/deposit"
+)
+
+// KeystoreSyncer keeps the portfolios with a keystore directory in sync with the
+// validators whose keystores it holds.
+type KeystoreSyncer struct {
+	registry *Registry
+	keys     *keyResolver
+}
+
+// NewKeystoreSyncer creates a syncer for the portfolios in r.
+func NewKeystoreSyncer(r *Registry, client beaconcha.Provider) *KeystoreSyncer {
+	return &KeystoreSyncer{registry: r, keys: newKeyResolver(client)}
+}
+
+// HasKeystores reports whether any portfolio in r has a keystore directory.
+func (r *Registry) HasKeystores() bool {
+	for _, p := range r.All() {
+		if p.KeystoreDir != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Run scans immediately and then every interval until the context is canceled.
+func (s *KeystoreSyncer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.SyncAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncAll syncs every portfolio with a keystore directory. Failures are logged
+// and retried on the next run, keeping the last known validators.
+func (s *KeystoreSyncer) SyncAll(ctx context.Context) {
+	for _, p := range s.registry.portfolios {
+		if p.KeystoreDir == "" {
+			continue
+		}
+		if err := s.Sync(ctx, p); err != nil {
+			slog.Error("keystore sync failed", "portfolio", p.Name, "dir", p.KeystoreDir, "error", err)
+		}
+	}
+}
+
+// Sync scans the portfolio's keystore directory and replaces its keystore
+// validators with those of the keystores found. See keyResolver for how keys are
+// looked up.
+func (s *KeystoreSyncer) Sync(ctx context.Context, p Portfolio) error {
+	keys, err := deposit.ScanKeystores(p.KeystoreDir)
+	if err != nil {
+		return err
+	}
+
+	current, pending, err := s.keys.resolve(ctx, p.Chain, keys.PublicKeys)
+	if err != nil {
+		return fmt.Errorf("resolve keystores: %w", err)
+	}
+
+	previous, known := s.registry.scannedValidators(p.Name)
+	s.registry.setScanned(p.Name, current)
+	if !known || !slices.Equal(previous, current) {
+		slog.Info("keystores scanned", "portfolio", p.Name, "keys", len(keys.PublicKeys), "validators", len(current), "pending", pending)
+	}
+	return nil
+}
+
+// scannedValidators returns the keystore validators last set for the portfolio
+// and whether any were set yet.
+func (r *Registry) scannedValidators(name string) ([]int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids, ok := r.scanned[name]
+	return ids, ok
+}
+
+// setScanned replaces the keystore validators of the portfolio.
+func (r *Registry) setScanned(name string, ids []int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scanned[name] = ids
+}
+
+// keyResolver resolves public keys to validators. Only keys not resolved before
+// are looked up upstream; keys whose deposits are not processed yet have no
+// validator and are looked up again on the next resolve.
+type keyResolver struct {
+	client  beaconcha.Provider
+	indices map[string]map[string]int // Validator of each public key resolved, by chain
+}
+
+// newKeyResolver creates a resolver looking keys up through client.
+func newKeyResolver(client beaconcha.Provider) *keyResolver {
+	return &keyResolver{client: client, indices: make(map[string]map[string]int)}
+}
+
+// resolve returns the sorted validators of the 0x-prefixed lowercase keys on
+// chain, and the number of keys without a validator yet.
+func (k *keyResolver) resolve(ctx context.Context, chain string, keys []string) ([]int, int, error) {
+	indices := k.indices[chain]
+	if indices == nil {
+		indices = make(map[string]int)
+		k.indices[chain] = indices
+	}
+	var unresolved []string
+	for _, key := range keys {
+		if _, ok := indices[key]; !ok {
+			unresolved = append(unresolved, key)
+		}
+	}
+	if len(unresolved) > 0 {
+		validators, err := k.client.GetValidatorsByPublicKey(ctx, chain, unresolved)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, v := range validators {
+			if v.Validator.Index != nil {
+				indices[strings.ToLower(v.Validator.PublicKey)] = *v.Validator.Index
+			}
+		}
+	}
+
+	current := make([]int, 0, len(keys))
+	pending := 0
+	for _, key := range keys {
+		if index, ok := indices[key]; ok {
+			current = append(current, index)
+		} else {
+			pending++
+		}
+	}
+	slices.Sort(current)
+	return slices.Compact(current), pending, nil
+}
@@ -0,0 +1,151 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/deposit"
+)
+
+// writeTestKeystore writes a keystore of testPublicKey(b) to dir.
+func writeTestKeystore(t *testing.T, dir string, b byte) {
+	t.Helper()
+	data := `{"crypto":{},"pubkey":"` + strings.TrimPrefix(testPublicKey(b), "0x") + `","version":4}`
+	if err := os.WriteFile(filepath.Join(dir, "keystore-"+string("0123456789abcdef"[b])+".json"), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeystoreSyncer_Sync(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Pubkey(testPublicKey(1)).Build(),
+		beaconchatest.Validator(2).Pubkey(testPublicKey(2)).Build(),
+	)
+	r := newTestRegistry(t, Portfolio{Name: "node", Chain: "mainnet", ValidatorIds: []int{7}, KeystoreDir: dir})
+	if !r.HasKeystores() {
+		t.Fatal("expected a portfolio with keystores")
+	}
+	syncer := NewKeystoreSyncer(r, fake)
+	p, _ := r.Get("node")
+
+	// Key 3 has no validator yet
+	writeTestKeystore(t, dir, 1)
+	writeTestKeystore(t, dir, 2)
+	writeTestKeystore(t, dir, 3)
+	if err := syncer.Sync(ctx, p); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got, _ := r.Get("node"); !slices.Equal(got.ValidatorIds, []int{7, 1, 2}) {
+		t.Errorf("expected configured and keystore validators, got %v", got.ValidatorIds)
+	}
+
+	// Resolved keys are not looked up again, pending ones are, and removed keys
+	// leave the portfolio
+	fake.AddValidators("mainnet", beaconchatest.Validator(3).Pubkey(testPublicKey(3)).Build())
+	if err := os.Remove(filepath.Join(dir, "keystore-1.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncer.Sync(ctx, p); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got, _ := r.Get("node"); !slices.Equal(got.ValidatorIds, []int{7, 2, 3}) {
+		t.Errorf("expected validator 1 replaced by 3, got %v", got.ValidatorIds)
+	}
+	if n := fake.Calls(beaconchatest.MethodGetValidatorsByKey); n != 2 {
+		t.Errorf("expected 2 lookups, got %d", n)
+	}
+	if err := syncer.Sync(ctx, p); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if n := fake.Calls(beaconchatest.MethodGetValidatorsByKey); n != 2 {
+		t.Errorf("expected no lookup once all keys are resolved, got %d lookups", n)
+	}
+
+	// Removing every keystore empties the keystore validators
+	for _, b := range []byte{2, 3} {
+		if err := os.Remove(filepath.Join(dir, "keystore-"+string("0123456789abcdef"[b])+".json")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := syncer.Sync(ctx, p); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got, _ := r.Get("node"); !slices.Equal(got.ValidatorIds, []int{7}) {
+		t.Errorf("expected the configured validator only, got %v", got.ValidatorIds)
+	}
+}
+
+func TestKeystoreSyncer_ScanErrors(t *testing.T) {
+	ctx := context.Background()
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Pubkey(testPublicKey(1)).Build(),
+		beaconchatest.Validator(2).Pubkey(testPublicKey(2)).Build(),
+	)
+
+	t.Run("malformed keystore", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestKeystore(t, dir, 1)
+		writeTestKeystore(t, dir, 2)
+		// Files that are not keystores are skipped
+		if err := os.WriteFile(filepath.Join(dir, "deposit_data.json"), []byte(`[{"pubkey":`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		r := newTestRegistry(t, Portfolio{Name: "node", Chain: "mainnet", KeystoreDir: dir})
+		syncer := NewKeystoreSyncer(r, fake)
+		p, _ := r.Get("node")
+		if err := syncer.Sync(ctx, p); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+		if got, _ := r.Get("node"); !slices.Equal(got.ValidatorIds, []int{1, 2}) {
+			t.Errorf("expected the validators of the keystores, got %v", got.ValidatorIds)
+		}
+
+		// A keystore with an invalid public key fails the scan and keeps the validators
+		bad := `{"crypto":{},"pubkey":"not-hex","version":4}`
+		if err := os.WriteFile(filepath.Join(dir, "keystore-bad.json"), []byte(bad), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := syncer.Sync(ctx, p); !errors.Is(err, deposit.ErrInvalid) {
+			t.Errorf("expected deposit.ErrInvalid, got %v", err)
+		}
+		if got, _ := r.Get("node"); !slices.Equal(got.ValidatorIds, []int{1, 2}) {
+			t.Errorf("expected validators unchanged, got %v", got.ValidatorIds)
+		}
+	})
+
+	t.Run("unreadable directory", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "keys")
+		if err := os.Mkdir(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		writeTestKeystore(t, dir, 1)
+		r := newTestRegistry(t, Portfolio{Name: "node", Chain: "mainnet", KeystoreDir: dir})
+		syncer := NewKeystoreSyncer(r, fake)
+		p, _ := r.Get("node")
+		if err := syncer.Sync(ctx, p); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+
+		// An unmounted or removed directory is an error, not an empty portfolio
+		if err := os.RemoveAll(dir); err != nil {
+			t.Fatal(err)
+		}
+		if err := syncer.Sync(ctx, p); err == nil {
+			t.Error("expected an error for a missing directory")
+		}
+		if got, _ := r.Get("node"); !slices.Equal(got.ValidatorIds, []int{1}) {
+			t.Errorf("expected validators unchanged, got %v", got.ValidatorIds)
+		}
+	})
+}
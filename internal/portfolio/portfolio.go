@@ -0,0 +1,200 @@
+// Package portfolio holds named sets of validators that are monitored together,
+// e.g. all validators of one client or one node.
+package portfolio
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ens"
+)
+
+// Portfolio is a named set of validators on a chain.
+type Portfolio struct {
+	Name         string `json:"name"`
+	Chain        string `json:"chain"`
+	ValidatorIds []int  `json:"validatorIds"`
+	// RegistryAddress is an operator registry, such as a staking pool contract, whose
+	// validators withdraw to it. Its current validators are added to the portfolio.
+	// On mainnet it may be given as an ENS name.
+	RegistryAddress string `json:"registryAddress,omitempty"`
+	// KeystoreDir is the keystore directory of a validator client on this machine.
+	// The validators of its keystores are added to the portfolio.
+	KeystoreDir string `json:"keystoreDir,omitempty"`
+	// SSVOperatorID is an SSV network operator whose managed validators are added
+	// to the portfolio.
+	SSVOperatorID int `json:"ssvOperatorId,omitempty"`
+	// ClientMetricsURL is the Prometheus metrics endpoint of the validator client
+	// running the portfolio's validators, whose signing is compared with the
+	// attestations included on chain.
+	ClientMetricsURL string `json:"clientMetricsUrl,omitempty"`
+	// Cluster describes the distributed validator cluster running the validators,
+	// if any.
+	Cluster *Cluster `json:"cluster,omitempty"`
+}
+
+// Registry holds the configured portfolios. A nil Registry has no portfolios.
+type Registry struct {
+	portfolios []Portfolio
+	byName     map[string]int
+
+	mu          sync.RWMutex
+	resolved    map[string][]int // Validators of each portfolio's operator registry, sorted
+	imported    map[string][]int // Validators imported into each portfolio, see Import
+	scanned     map[string][]int // Validators of each portfolio's keystores, sorted
+	operated    map[string][]int // Validators of each portfolio's SSV operator, sorted
+	importsPath string           // Optional, see LoadImports
+}
+
+// NewRegistry creates a registry from the given portfolios.
+func NewRegistry(portfolios []Portfolio) (*Registry, error) {
+	r := &Registry{
+		portfolios: portfolios,
+		byName:     make(map[string]int, len(portfolios)),
+		resolved:   make(map[string][]int),
+		imported:   make(map[string][]int),
+		scanned:    make(map[string][]int),
+		operated:   make(map[string][]int),
+	}
+	for i, p := range portfolios {
+		if p.Name == "" || p.Chain == "" {
+			return nil, fmt.Errorf("portfolio must have a name and a chain")
+		}
+		if len(p.ValidatorIds) == 0 && p.RegistryAddress == "" && p.KeystoreDir == "" && p.SSVOperatorID == 0 {
+			return nil, fmt.Errorf("portfolio %q has no validators, registry address, keystore directory or SSV operator", p.Name)
+		}
+		if p.SSVOperatorID < 0 {
+			return nil, fmt.Errorf("portfolio %q has an invalid SSV operator ID %d", p.Name, p.SSVOperatorID)
+		}
+		if p.RegistryAddress != "" && !ens.IsAddress(p.RegistryAddress) {
+			if !ens.IsName(p.RegistryAddress) {
+				return nil, fmt.Errorf("portfolio %q has an invalid registry address %q", p.Name, p.RegistryAddress)
+			}
+			if p.Chain != "mainnet" {
+				return nil, fmt.Errorf("portfolio %q uses ENS name %q on %s; ENS names are only supported on mainnet", p.Name, p.RegistryAddress, p.Chain)
+			}
+		}
+		if p.ClientMetricsURL != "" {
+			if u, err := url.Parse(p.ClientMetricsURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, fmt.Errorf("portfolio %q has an invalid client metrics URL %q", p.Name, p.ClientMetricsURL)
+			}
+		}
+		if p.Cluster != nil {
+			if err := p.Cluster.normalize(); err != nil {
+				return nil, fmt.Errorf("portfolio %q: %w", p.Name, err)
+			}
+		}
+		if _, ok := r.byName[p.Name]; ok {
+			return nil, fmt.Errorf("duplicate portfolio %q", p.Name)
+		}
+		r.byName[p.Name] = i
+	}
+	return r, nil
+}
+
+// LoadFile reads portfolios from a JSON file containing an array of portfolios.
+// An empty path returns an empty registry.
+func LoadFile(path string) (*Registry, error) {
+	if path == "" {
+		return NewRegistry(nil)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read portfolios: %w", err)
+	}
+
+	var portfolios []Portfolio
+	if err := json.Unmarshal(data, &portfolios); err != nil {
+		return nil, fmt.Errorf("decode portfolios: %w", err)
+	}
+
+	return NewRegistry(portfolios)
+}
+
+// Get returns the portfolio with the given name. Its validators include those
+// currently resolved from its operator registry, keystores and SSV operator.
+func (r *Registry) Get(name string) (Portfolio, bool) {
+	if r == nil {
+		return Portfolio{}, false
+	}
+	i, ok := r.byName[name]
+	if !ok {
+		return Portfolio{}, false
+	}
+	return r.withResolved(r.portfolios[i]), true
+}
+
+// All returns the portfolios in the order they were configured.
+func (r *Registry) All() []Portfolio {
+	if r == nil {
+		return nil
+	}
+	result := make([]Portfolio, len(r.portfolios))
+	for i, p := range r.portfolios {
+		result[i] = r.withResolved(p)
+	}
+	return result
+}
+
+// HasClientMetrics reports whether any portfolio in r has client metrics.
+func (r *Registry) HasClientMetrics() bool {
+	for _, p := range r.All() {
+		if p.ClientMetricsURL != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// withResolved returns a copy of p with the resolved registry validators, the
+// imported ones and those of its keystores and SSV operator appended to the
+// configured ones.
+func (r *Registry) withResolved(p Portfolio) Portfolio {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.withResolvedLocked(p)
+}
+
+// withResolvedLocked is withResolved for callers holding r.mu.
+func (r *Registry) withResolvedLocked(p Portfolio) Portfolio {
+	resolved, imported, scanned, operated := r.resolved[p.Name], r.imported[p.Name], r.scanned[p.Name], r.operated[p.Name]
+	if len(resolved) == 0 && len(imported) == 0 && len(scanned) == 0 && len(operated) == 0 {
+		p.ValidatorIds = slices.Clone(p.ValidatorIds)
+		return p
+	}
+
+	ids := slices.Clone(p.ValidatorIds)
+	seen := make(map[int]bool, len(ids)+len(resolved)+len(imported)+len(scanned)+len(operated))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	for _, id := range slices.Concat(resolved, imported, scanned, operated) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	p.ValidatorIds = ids
+	return p
+}
+
+// resolvedValidators returns the registry validators last set for the portfolio
+// and whether any were set yet.
+func (r *Registry) resolvedValidators(name string) ([]int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids, ok := r.resolved[name]
+	return ids, ok
+}
+
+// setResolved replaces the registry validators of the portfolio.
+func (r *Registry) setResolved(name string, ids []int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resolved[name] = ids
+}
@@ -0,0 +1,49 @@
+package portfolio
+
+import "testing"
+
+func TestNewRegistry(t *testing.T) {
+	tests := []struct {
+		name       string
+		portfolios []Portfolio
+		wantErr    bool
+	}{
+		{name: "valid", portfolios: []Portfolio{{Name: "a", Chain: "mainnet", ValidatorIds: []int{1}}, {Name: "b", Chain: "hoodi", ValidatorIds: []int{2}}}},
+		{name: "empty", portfolios: nil},
+		{name: "missing name", portfolios: []Portfolio{{Chain: "mainnet", ValidatorIds: []int{1}}}, wantErr: true},
+		{name: "missing chain", portfolios: []Portfolio{{Name: "a", ValidatorIds: []int{1}}}, wantErr: true},
+		{name: "no validators", portfolios: []Portfolio{{Name: "a", Chain: "mainnet"}}, wantErr: true},
+		{name: "registry only", portfolios: []Portfolio{{Name: "a", Chain: "mainnet", RegistryAddress: "0x00000000219ab540356cbb839cbe05303d7705fa"}}},
+		{name: "invalid registry address", portfolios: []Portfolio{{Name: "a", Chain: "mainnet", RegistryAddress: "0x1234"}}, wantErr: true},
+		{name: "ens registry", portfolios: []Portfolio{{Name: "a", Chain: "mainnet", RegistryAddress: "pool.eth"}}},
+		{name: "ens registry on testnet", portfolios: []Portfolio{{Name: "a", Chain: "hoodi", RegistryAddress: "pool.eth"}}, wantErr: true},
+		{name: "duplicate name", portfolios: []Portfolio{{Name: "a", Chain: "mainnet", ValidatorIds: []int{1}}, {Name: "a", Chain: "hoodi", ValidatorIds: []int{2}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRegistry(tt.portfolios)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			for _, p := range tt.portfolios {
+				if got, ok := r.Get(p.Name); !ok || got.Chain != p.Chain {
+					t.Errorf("expected portfolio %q, got %+v", p.Name, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistry_Nil(t *testing.T) {
+	var r *Registry
+	if _, ok := r.Get("a"); ok {
+		t.Error("expected no portfolio in a nil registry")
+	}
+	if len(r.All()) != 0 {
+		t.Error("expected no portfolios in a nil registry")
+	}
+}
@@ -0,0 +1,101 @@
+package portfolio
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ssv"
+)
+
+// SSVSyncer keeps the portfolios with an SSV operator in sync with the validators
+// the operator manages.
+type SSVSyncer struct {
+	registry  *Registry
+	operators *ssv.Client
+	keys      *keyResolver
+}
+
+// NewSSVSyncer creates a syncer for the portfolios in r, reading operators from
+// the SSV API through operators.
+func NewSSVSyncer(r *Registry, client beaconcha.Provider, operators *ssv.Client) *SSVSyncer {
+	return &SSVSyncer{registry: r, operators: operators, keys: newKeyResolver(client)}
+}
+
+// HasSSVOperators reports whether any portfolio in r has an SSV operator.
+func (r *Registry) HasSSVOperators() bool {
+	for _, p := range r.All() {
+		if p.SSVOperatorID != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Run syncs immediately and then every interval until the context is canceled.
+func (s *SSVSyncer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.SyncAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncAll syncs every portfolio with an SSV operator. Failures are logged and
+// retried on the next run, keeping the last known validators.
+func (s *SSVSyncer) SyncAll(ctx context.Context) {
+	for _, p := range s.registry.portfolios {
+		if p.SSVOperatorID == 0 {
+			continue
+		}
+		if err := s.Sync(ctx, p); err != nil {
+			slog.Error("ssv operator sync failed", "portfolio", p.Name, "operator", p.SSVOperatorID, "error", err)
+		}
+	}
+}
+
+// Sync reads the validators of the portfolio's SSV operator and replaces its
+// operator validators with them. See keyResolver for how keys are looked up.
+func (s *SSVSyncer) Sync(ctx context.Context, p Portfolio) error {
+	keys, err := s.operators.OperatorValidators(ctx, p.Chain, p.SSVOperatorID)
+	if err != nil {
+		return fmt.Errorf("read operator validators: %w", err)
+	}
+	current, pending, err := s.keys.resolve(ctx, p.Chain, keys)
+	if err != nil {
+		return fmt.Errorf("resolve operator validators: %w", err)
+	}
+
+	previous, known := s.registry.operatedValidators(p.Name)
+	s.registry.setOperated(p.Name, current)
+	if !known || !slices.Equal(previous, current) {
+		slog.Info("ssv operator synced", "portfolio", p.Name, "operator", p.SSVOperatorID, "keys", len(keys), "validators", len(current), "pending", pending)
+	}
+	return nil
+}
+
+// operatedValidators returns the SSV operator validators last set for the
+// portfolio and whether any were set yet.
+func (r *Registry) operatedValidators(name string) ([]int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids, ok := r.operated[name]
+	return ids, ok
+}
+
+// setOperated replaces the SSV operator validators of the portfolio.
+func (r *Registry) setOperated(name string, ids []int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.operated[name] = ids
+}
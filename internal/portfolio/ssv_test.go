@@ -0,0 +1,161 @@
+package portfolio
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ssv"
+)
+
+// testSSVOperator serves the validators of SSV operator 42 on mainnet, 100 per
+// page like the SSV API.
+type testSSVOperator struct {
+	client *ssv.Client
+	down   atomic.Bool
+
+	mu         sync.Mutex
+	validators []string // JSON objects of the validators
+}
+
+// newTestSSVOperator creates an operator managing the validators of keys.
+func newTestSSVOperator(t *testing.T, keys []string) *testSSVOperator {
+	t.Helper()
+	o := &testSSVOperator{}
+	o.manage(keys)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if o.down.Load() || r.URL.Path != "/mainnet/validators/in_operator/42" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("perPage"))
+		pages := (len(o.validators) + perPage - 1) / perPage
+		start := min((page-1)*perPage, len(o.validators))
+		end := min(start+perPage, len(o.validators))
+		fmt.Fprintf(w, `{"validators":[%s],"pagination":{"page":%d,"pages":%d}}`, strings.Join(o.validators[start:end], ","), page, pages)
+	}))
+	t.Cleanup(server.Close)
+	o.client = ssv.NewClient(server.URL, time.Second)
+	return o
+}
+
+// manage replaces the validators of the operator with those of keys.
+func (o *testSSVOperator) manage(keys []string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.validators = nil
+	for _, k := range keys {
+		o.validators = append(o.validators, fmt.Sprintf(`{"public_key":%q}`, strings.TrimPrefix(k, "0x")))
+	}
+}
+
+// set replaces the JSON object of the i-th validator.
+func (o *testSSVOperator) set(i int, validator string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.validators[i] = validator
+}
+
+func TestSSVSyncer_Sync(t *testing.T) {
+	ctx := context.Background()
+	operator := newTestSSVOperator(t, []string{testPublicKey(1), testPublicKey(2), testPublicKey(3)})
+
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Pubkey(testPublicKey(1)).Build(),
+		beaconchatest.Validator(2).Pubkey(testPublicKey(2)).Build(),
+		beaconchatest.Validator(4).Pubkey(testPublicKey(4)).Build(),
+	)
+	r := newTestRegistry(t, Portfolio{Name: "operator", Chain: "mainnet", SSVOperatorID: 42})
+	if !r.HasSSVOperators() {
+		t.Fatal("expected a portfolio with an SSV operator")
+	}
+	syncer := NewSSVSyncer(r, fake, operator.client)
+	p, _ := r.Get("operator")
+
+	// Key 3 has no validator yet
+	if err := syncer.Sync(ctx, p); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got, _ := r.Get("operator"); !slices.Equal(got.ValidatorIds, []int{1, 2}) {
+		t.Errorf("expected operator validators, got %v", got.ValidatorIds)
+	}
+
+	operator.manage([]string{testPublicKey(2), testPublicKey(4)})
+	if err := syncer.Sync(ctx, p); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got, _ := r.Get("operator"); !slices.Equal(got.ValidatorIds, []int{2, 4}) {
+		t.Errorf("expected validator 1 replaced by 4, got %v", got.ValidatorIds)
+	}
+}
+
+func TestSSVSyncer_Pagination(t *testing.T) {
+	// 250 validators take 3 pages of the SSV API
+	var keys []string
+	fake := beaconchatest.New()
+	for i := 1; i <= 250; i++ {
+		key := fmt.Sprintf("0x%096x", i)
+		keys = append(keys, key)
+		fake.AddValidators("mainnet", beaconchatest.Validator(i).Pubkey(key).Build())
+	}
+	operator := newTestSSVOperator(t, keys)
+	r := newTestRegistry(t, Portfolio{Name: "operator", Chain: "mainnet", SSVOperatorID: 42})
+	p, _ := r.Get("operator")
+
+	if err := NewSSVSyncer(r, fake, operator.client).Sync(context.Background(), p); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	got, _ := r.Get("operator")
+	if len(got.ValidatorIds) != 250 || got.ValidatorIds[0] != 1 || got.ValidatorIds[249] != 250 {
+		t.Errorf("expected the validators of all pages, got %d: %v", len(got.ValidatorIds), got.ValidatorIds)
+	}
+}
+
+func TestSSVSyncer_ClustersNoLongerRun(t *testing.T) {
+	ctx := context.Background()
+	operator := newTestSSVOperator(t, []string{testPublicKey(1), testPublicKey(2), testPublicKey(3)})
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Pubkey(testPublicKey(1)).Build(),
+		beaconchatest.Validator(2).Pubkey(testPublicKey(2)).Build(),
+		beaconchatest.Validator(3).Pubkey(testPublicKey(3)).Build(),
+	)
+	r := newTestRegistry(t, Portfolio{Name: "operator", Chain: "mainnet", SSVOperatorID: 42})
+	syncer := NewSSVSyncer(r, fake, operator.client)
+	p, _ := r.Get("operator")
+	if err := syncer.Sync(ctx, p); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	// The API keeps listing validators of liquidated clusters and removed ones
+	operator.set(0, fmt.Sprintf(`{"public_key":%q,"is_liquidated":true}`, strings.TrimPrefix(testPublicKey(1), "0x")))
+	operator.set(2, fmt.Sprintf(`{"public_key":%q,"is_deleted":true}`, strings.TrimPrefix(testPublicKey(3), "0x")))
+	if err := syncer.Sync(ctx, p); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got, _ := r.Get("operator"); !slices.Equal(got.ValidatorIds, []int{2}) {
+		t.Errorf("expected only the validator still run, got %v", got.ValidatorIds)
+	}
+
+	// An operator whose clusters are all gone has no validators left
+	operator.manage(nil)
+	if err := syncer.Sync(ctx, p); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got, _ := r.Get("operator"); len(got.ValidatorIds) != 0 {
+		t.Errorf("expected no validators, got %v", got.ValidatorIds)
+	}
+}
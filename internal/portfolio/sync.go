@@ -0,0 +1,158 @@
+package portfolio
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ens"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// RegistrySyncer keeps the portfolios with an operator registry in sync with the
+// validators currently withdrawing to the registry address.
+type RegistrySyncer struct {
+	registry *Registry
+	client   beaconcha.Provider
+	store    store.Store   // Optional, receives key added/removed events
+	names    *ens.Resolver // Optional, resolves registries given as ENS names
+}
+
+// NewRegistrySyncer creates a syncer for the portfolios in r. The store may be nil,
+// in which case changes are applied without recording events. The ENS resolver may
+// be nil, in which case registries given as ENS names fail to sync.
+func NewRegistrySyncer(r *Registry, client beaconcha.Provider, st store.Store, names *ens.Resolver) *RegistrySyncer {
+	return &RegistrySyncer{registry: r, client: client, store: st, names: names}
+}
+
+// HasRegistries reports whether any portfolio in r has an operator registry.
+func (r *Registry) HasRegistries() bool {
+	for _, p := range r.All() {
+		if p.RegistryAddress != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Run syncs immediately and then every interval until the context is canceled.
+func (s *RegistrySyncer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.SyncAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncAll syncs every portfolio with an operator registry. Failures are logged
+// and retried on the next run, keeping the last known validators.
+func (s *RegistrySyncer) SyncAll(ctx context.Context) {
+	for _, p := range s.registry.portfolios {
+		if p.RegistryAddress == "" {
+			continue
+		}
+		if err := s.Sync(ctx, p); err != nil {
+			slog.Error("registry sync failed", "portfolio", p.Name, "registry", p.RegistryAddress, "error", err)
+		}
+	}
+}
+
+// Sync re-resolves the validators of the portfolio's operator registry and records
+// an event for each validator added or removed since the last sync. The first sync
+// after startup only establishes the known set, so changes made while the server
+// was down are applied without events.
+func (s *RegistrySyncer) Sync(ctx context.Context, p Portfolio) error {
+	address, err := s.registryAddress(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	validators, err := s.client.GetValidatorsByWithdrawalAddress(ctx, p.Chain, address)
+	if err != nil {
+		return fmt.Errorf("resolve registry: %w", err)
+	}
+
+	current := make([]int, 0, len(validators))
+	for _, v := range validators {
+		if v.Validator.Index != nil {
+			current = append(current, *v.Validator.Index)
+		}
+	}
+	slices.Sort(current)
+
+	previous, known := s.registry.resolvedValidators(p.Name)
+	s.registry.setResolved(p.Name, current)
+	if !known {
+		slog.Info("registry resolved", "portfolio", p.Name, "validators", len(current))
+		return nil
+	}
+
+	events := diffRegistry(p, previous, current, time.Now().UTC())
+	if len(events) == 0 {
+		return nil
+	}
+	slog.Info("registry changed", "portfolio", p.Name, "changes", len(events))
+
+	if s.store == nil {
+		return nil
+	}
+	if err := s.store.RecordEvents(ctx, events); err != nil {
+		return fmt.Errorf("record registry events: %w", err)
+	}
+	return nil
+}
+
+// registryAddress returns the address of the portfolio's operator registry,
+// resolving ENS names on every sync so that a name moved to a new contract is
+// followed.
+func (s *RegistrySyncer) registryAddress(ctx context.Context, p Portfolio) (string, error) {
+	if ens.IsAddress(p.RegistryAddress) {
+		return p.RegistryAddress, nil
+	}
+	if s.names == nil {
+		return "", fmt.Errorf("registry %q is an ENS name, which requires EXECUTION_RPC_URL", p.RegistryAddress)
+	}
+	address, err := s.names.Resolve(ctx, p.RegistryAddress)
+	if err != nil {
+		return "", fmt.Errorf("resolve ENS name: %w", err)
+	}
+	return address, nil
+}
+
+// diffRegistry returns the events turning the sorted previous set into the sorted current set.
+func diffRegistry(p Portfolio, previous, current []int, now time.Time) []store.Event {
+	var events []store.Event
+	for _, id := range current {
+		if _, found := slices.BinarySearch(previous, id); !found {
+			events = append(events, store.Event{
+				Time:           now,
+				Chain:          p.Chain,
+				ValidatorIndex: id,
+				Type:           store.EventRegistryKeyAdded,
+				To:             p.RegistryAddress,
+			})
+		}
+	}
+	for _, id := range previous {
+		if _, found := slices.BinarySearch(current, id); !found {
+			events = append(events, store.Event{
+				Time:           now,
+				Chain:          p.Chain,
+				ValidatorIndex: id,
+				Type:           store.EventRegistryKeyRemoved,
+				From:           p.RegistryAddress,
+			})
+		}
+	}
+	return events
+}
@@ -0,0 +1,168 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+const registryAddress = "0x00000000219ab540356cbb839cbe05303d7705fa"
+
+func TestRegistrySyncer_Sync(t *testing.T) {
+	ctx := context.Background()
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).WithdrawalAddress(registryAddress).Build(),
+		beaconchatest.Validator(2).WithdrawalAddress(registryAddress).Build(),
+		beaconchatest.Validator(3).WithdrawalAddress("0x0000000000000000000000000000000000000001").Build(),
+	)
+
+	r, err := NewRegistry([]Portfolio{{Name: "pool", Chain: "mainnet", ValidatorIds: []int{7}, RegistryAddress: registryAddress}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := store.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncer := NewRegistrySyncer(r, fake, st, nil)
+	p, _ := r.Get("pool")
+
+	// The first sync establishes the known set without events
+	if err := syncer.Sync(ctx, p); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got, _ := r.Get("pool"); !slices.Equal(got.ValidatorIds, []int{7, 1, 2}) {
+		t.Errorf("expected configured and resolved validators, got %v", got.ValidatorIds)
+	}
+
+	fake.RemoveValidators("mainnet", 1)
+	fake.AddValidators("mainnet", beaconchatest.Validator(4).WithdrawalAddress(registryAddress).Build())
+	if err := syncer.Sync(ctx, p); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got, _ := r.Get("pool"); !slices.Equal(got.ValidatorIds, []int{7, 2, 4}) {
+		t.Errorf("expected validator 1 replaced by 4, got %v", got.ValidatorIds)
+	}
+
+	events, err := st.Events(ctx, store.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", events)
+	}
+	if e := events[0]; e.ValidatorIndex != 4 || e.Type != store.EventRegistryKeyAdded || e.To != registryAddress {
+		t.Errorf("unexpected added event: %+v", e)
+	}
+	if e := events[1]; e.ValidatorIndex != 1 || e.Type != store.EventRegistryKeyRemoved || e.From != registryAddress {
+		t.Errorf("unexpected removed event: %+v", e)
+	}
+}
+
+// testPublicKey returns a distinct public key of a validator for each b below 16.
+func testPublicKey(b byte) string {
+	return "0x" + strings.Repeat(string("0123456789abcdef"[b]), 96)
+}
+
+func TestSyncers_KeepOnFailure(t *testing.T) {
+	errDown := errors.New("upstream down")
+	tests := []struct {
+		name string
+		// setup returns a registry with portfolio "p", its syncer's Sync, and a func
+		// making the next sync fail
+		setup func(t *testing.T) (*Registry, func(context.Context, Portfolio) error, func())
+	}{
+		{
+			name: "registry",
+			setup: func(t *testing.T) (*Registry, func(context.Context, Portfolio) error, func()) {
+				fake := beaconchatest.New()
+				fake.AddValidators("mainnet", beaconchatest.Validator(1).WithdrawalAddress(registryAddress).Build())
+				r := newTestRegistry(t, Portfolio{Name: "p", Chain: "mainnet", RegistryAddress: registryAddress})
+				return r, NewRegistrySyncer(r, fake, nil, nil).Sync, func() {
+					fake.FailNext(beaconchatest.MethodGetValidatorsByAddress, errDown)
+				}
+			},
+		},
+		{
+			name: "keystore",
+			setup: func(t *testing.T) (*Registry, func(context.Context, Portfolio) error, func()) {
+				dir := t.TempDir()
+				writeTestKeystore(t, dir, 1)
+				fake := beaconchatest.New()
+				fake.AddValidators("mainnet", beaconchatest.Validator(1).Pubkey(testPublicKey(1)).Build())
+				r := newTestRegistry(t, Portfolio{Name: "p", Chain: "mainnet", KeystoreDir: dir})
+				return r, NewKeystoreSyncer(r, fake).Sync, func() {
+					// Only keys not resolved before are looked up
+					writeTestKeystore(t, dir, 2)
+					fake.FailNext(beaconchatest.MethodGetValidatorsByKey, errDown)
+				}
+			},
+		},
+		{
+			name: "ssv",
+			setup: func(t *testing.T) (*Registry, func(context.Context, Portfolio) error, func()) {
+				operator := newTestSSVOperator(t, []string{testPublicKey(1)})
+				fake := beaconchatest.New()
+				fake.AddValidators("mainnet", beaconchatest.Validator(1).Pubkey(testPublicKey(1)).Build())
+				r := newTestRegistry(t, Portfolio{Name: "p", Chain: "mainnet", SSVOperatorID: 42})
+				return r, NewSSVSyncer(r, fake, operator.client).Sync, func() { operator.down.Store(true) }
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			r, sync, fail := tt.setup(t)
+			p, _ := r.Get("p")
+
+			if err := sync(ctx, p); err != nil {
+				t.Fatalf("Sync failed: %v", err)
+			}
+			before, _ := r.Get("p")
+			if len(before.ValidatorIds) == 0 {
+				t.Fatal("expected validators after the first sync")
+			}
+
+			fail()
+			if err := sync(ctx, p); err == nil {
+				t.Error("expected error, got nil")
+			}
+			if after, _ := r.Get("p"); !slices.Equal(after.ValidatorIds, before.ValidatorIds) {
+				t.Errorf("expected validators %v unchanged, got %v", before.ValidatorIds, after.ValidatorIds)
+			}
+		})
+	}
+}
+
+// newTestRegistry creates a registry of portfolios.
+func newTestRegistry(t *testing.T, portfolios ...Portfolio) *Registry {
+	t.Helper()
+	r, err := NewRegistry(portfolios)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestRegistrySyncer_ENSWithoutResolver(t *testing.T) {
+	r, err := NewRegistry([]Portfolio{{Name: "pool", Chain: "mainnet", RegistryAddress: "pool.eth"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := beaconchatest.New()
+	syncer := NewRegistrySyncer(r, fake, nil, nil)
+	p, _ := r.Get("pool")
+
+	if err := syncer.Sync(context.Background(), p); err == nil {
+		t.Error("expected an error for an ENS name without a resolver")
+	}
+	if fake.Calls(beaconchatest.MethodGetValidatorsByAddress) != 0 {
+		t.Error("expected no upstream lookup")
+	}
+}
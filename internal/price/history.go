@@ -0,0 +1,163 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+)
+
+// dayLayout keys the price history by UTC day.
+const dayLayout = "2006-01-02"
+
+// HistoricalProvider is implemented by providers that can quote the ETH price of a past day.
+type HistoricalProvider interface {
+	Provider
+	// DailyQuote returns the ETH price in currency for the UTC day containing day.
+	DailyQuote(ctx context.Context, currency string, day time.Time) (Quote, error)
+}
+
+// historyEntry is a row of the price history table.
+type historyEntry struct {
+	Price    float64 `json:"price"`
+	Provider string  `json:"provider"`
+}
+
+// DailyPrices returns the ETH price in currency for each of the given days, keyed by
+// YYYY-MM-DD. Past days are served from the price history table and missing ones are
+// fetched from the historical providers in order and added to it. The current day
+// is not final yet and uses the current price instead.
+//
+// Days no provider can price are left out of the result.
+func (s *Service) DailyPrices(ctx context.Context, currency string, days []time.Time) (map[string]float64, error) {
+	currency = strings.ToLower(currency)
+	today := time.Now().UTC().Format(dayLayout)
+
+	result := make(map[string]float64, len(days))
+	var missing []time.Time
+
+	s.mu.Lock()
+	for _, d := range days {
+		key := d.UTC().Format(dayLayout)
+		if e, ok := s.history[currency][key]; ok {
+			result[key] = e.Price
+			cost.AddCacheHit(ctx)
+		} else if key != today {
+			missing = append(missing, d)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, d := range days {
+		if d.UTC().Format(dayLayout) == today {
+			current, err := s.Price(ctx, currency)
+			if err != nil {
+				return nil, err
+			}
+			result[today] = current.Price
+			break
+		}
+	}
+
+	added := false
+	for _, d := range missing {
+		q, err := s.dailyQuote(ctx, currency, d)
+		if err != nil {
+			slog.Warn("no historical price", "currency", currency, "day", d.Format(dayLayout), "error", err)
+			continue
+		}
+
+		key := d.UTC().Format(dayLayout)
+		result[key] = q.Price
+
+		s.mu.Lock()
+		if s.history[currency] == nil {
+			s.history[currency] = make(map[string]historyEntry)
+		}
+		s.history[currency][key] = historyEntry{Price: q.Price, Provider: q.Provider}
+		s.mu.Unlock()
+		added = true
+	}
+
+	if added {
+		if err := s.saveHistory(); err != nil {
+			slog.Error("failed to save price history", "error", err)
+		}
+	}
+
+	return result, nil
+}
+
+// dailyQuote asks each historical provider in order for the price of day.
+func (s *Service) dailyQuote(ctx context.Context, currency string, day time.Time) (Quote, error) {
+	var errs []error
+	for _, p := range s.providers {
+		hp, ok := p.(HistoricalProvider)
+		if !ok {
+			continue
+		}
+		if err := s.wait(ctx, p); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		q, err := hp.DailyQuote(ctx, currency, day)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		return q, nil
+	}
+	if len(errs) == 0 {
+		return Quote{}, errors.New("no historical price providers configured")
+	}
+	return Quote{}, errors.Join(errs...)
+}
+
+// LoadHistory reads the price history table from path and persists new prices to it.
+// A missing file starts an empty table.
+func (s *Service) LoadHistory(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.historyPath = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read price history: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.history); err != nil {
+		return fmt.Errorf("decode price history: %w", err)
+	}
+	return nil
+}
+
+// saveHistory atomically writes the price history table, if a path is configured.
+func (s *Service) saveHistory() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.historyPath == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(s.history)
+	if err != nil {
+		return fmt.Errorf("encode price history: %w", err)
+	}
+
+	tmp := s.historyPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write price history: %w", err)
+	}
+	return os.Rename(tmp, s.historyPath)
+}
@@ -0,0 +1,207 @@
+// Package price provides ETH fiat prices from pluggable providers with failover.
+package price
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+	"golang.org/x/time/rate"
+)
+
+// ErrUnsupportedCurrency is returned by providers that cannot quote the requested currency.
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+// Quote is the price of one ETH in a fiat currency.
+type Quote struct {
+	Currency string    // Lowercase currency code, e.g. "usd"
+	Price    float64   // Price of 1 ETH
+	Provider string    // Name of the provider that returned the quote
+	Time     time.Time // When the price was observed
+}
+
+// Provider returns ETH prices from a single source.
+type Provider interface {
+	// Name identifies the provider in quotes and logs.
+	Name() string
+	// Quote returns the current ETH price in currency.
+	Quote(ctx context.Context, currency string) (Quote, error)
+}
+
+// Result is a quote together with its freshness.
+type Result struct {
+	Quote
+	// Stale is set when every provider failed and the last known price was returned.
+	Stale bool
+}
+
+// Service returns prices from the first provider that succeeds, caching each
+// currency for the TTL. When all providers fail, the last known price is returned
+// marked as stale, as long as it is younger than maxStaleness.
+type Service struct {
+	providers    []Provider
+	ttl          time.Duration
+	maxStaleness time.Duration
+
+	limiters map[string]*rate.Limiter // By provider name, see SetRateLimits
+
+	mu          sync.Mutex
+	cache       map[string]cacheEntry
+	history     map[string]map[string]historyEntry // currency -> day -> price
+	historyPath string
+}
+
+// cacheEntry is a quote together with when it was fetched.
+// The quote time may be older than the fetch, e.g. for on-chain feeds.
+type cacheEntry struct {
+	quote   Quote
+	fetched time.Time
+}
+
+// NewService creates a price service querying the providers in order.
+func NewService(providers []Provider, ttl, maxStaleness time.Duration) *Service {
+	return &Service{
+		providers:    providers,
+		ttl:          ttl,
+		maxStaleness: maxStaleness,
+		cache:        make(map[string]cacheEntry),
+		history:      make(map[string]map[string]historyEntry),
+	}
+}
+
+// SetRateLimits spaces the calls to each named provider at least the given
+// interval apart, e.g. to stay within the free tier of an API. Calls wait for
+// their turn, as long as their context allows.
+func (s *Service) SetRateLimits(intervals map[string]time.Duration) {
+	s.limiters = make(map[string]*rate.Limiter, len(intervals))
+	for name, interval := range intervals {
+		s.limiters[name] = rate.NewLimiter(rate.Every(interval), 1)
+	}
+}
+
+// wait blocks until p may be called.
+func (s *Service) wait(ctx context.Context, p Provider) error {
+	limiter, ok := s.limiters[p.Name()]
+	if !ok {
+		return nil
+	}
+	if err := limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("rate limit: %w", err)
+	}
+	return nil
+}
+
+// ParseRateLimits parses minimum intervals between the calls to providers in the
+// form "coingecko=2s,kraken=1s".
+func ParseRateLimits(s string) (map[string]time.Duration, error) {
+	intervals := make(map[string]time.Duration)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q: expected provider=interval", entry)
+		}
+		interval, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %q: interval must be a positive duration", entry)
+		}
+		intervals[strings.TrimSpace(name)] = interval
+	}
+	return intervals, nil
+}
+
+// Price returns the ETH price in currency.
+func (s *Service) Price(ctx context.Context, currency string) (Result, error) {
+	currency = strings.ToLower(currency)
+
+	s.mu.Lock()
+	cached, ok := s.cache[currency]
+	s.mu.Unlock()
+
+	if ok && time.Since(cached.fetched) < s.ttl {
+		cost.AddCacheHit(ctx)
+		return Result{Quote: cached.quote}, nil
+	}
+
+	var errs []error
+	for _, p := range s.providers {
+		if err := s.wait(ctx, p); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		q, err := p.Quote(ctx, currency)
+		if err != nil {
+			if !errors.Is(err, ErrUnsupportedCurrency) {
+				slog.Warn("price provider failed", "provider", p.Name(), "currency", currency, "error", err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+
+		s.mu.Lock()
+		s.cache[currency] = cacheEntry{quote: q, fetched: time.Now()}
+		s.mu.Unlock()
+
+		return Result{Quote: q}, nil
+	}
+
+	if ok && time.Since(cached.fetched) < s.maxStaleness {
+		cost.AddCacheHit(ctx)
+		return Result{Quote: cached.quote, Stale: true}, nil
+	}
+
+	if len(errs) == 0 {
+		return Result{}, errors.New("no price providers configured")
+	}
+	return Result{}, fmt.Errorf("all price providers failed: %w", errors.Join(errs...))
+}
+
+// ProviderConfig holds the settings needed to construct the built-in providers.
+type ProviderConfig struct {
+	CoinGeckoBaseURL string
+	CoinGeckoAPIKey  string
+	KrakenBaseURL    string
+	CoinbaseBaseURL  string
+	ExecutionRPCURL  string
+	ChainlinkFeed    string
+	StaticPrices     map[string]float64 // Prices of the static provider by currency
+	Timeout          time.Duration
+}
+
+// NewProviders constructs the named providers in order.
+// Supported names are "coingecko", "kraken", "coinbase", "chainlink" and "static".
+func NewProviders(names []string, cfg ProviderConfig) ([]Provider, error) {
+	providers := make([]Provider, 0, len(names))
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case "coingecko":
+			providers = append(providers, NewCoinGecko(cfg.CoinGeckoBaseURL, cfg.CoinGeckoAPIKey, cfg.Timeout))
+		case "kraken":
+			providers = append(providers, NewKraken(cfg.KrakenBaseURL, cfg.Timeout))
+		case "coinbase":
+			providers = append(providers, NewCoinbase(cfg.CoinbaseBaseURL, cfg.Timeout))
+		case "chainlink":
+			if cfg.ExecutionRPCURL == "" {
+				return nil, errors.New("chainlink price provider requires an execution RPC URL")
+			}
+			providers = append(providers, NewChainlink(cfg.ExecutionRPCURL, cfg.ChainlinkFeed, cfg.Timeout))
+		case "static":
+			if len(cfg.StaticPrices) == 0 {
+				return nil, errors.New("static price provider requires prices")
+			}
+			providers = append(providers, NewStatic(cfg.StaticPrices))
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown price provider %q", name)
+		}
+	}
+	return providers, nil
+}
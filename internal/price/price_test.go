@@ -0,0 +1,291 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+)
+
+type fakeProvider struct {
+	name  string
+	price float64
+	err   error
+	calls int
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) Quote(ctx context.Context, currency string) (Quote, error) {
+	f.calls++
+	if f.err != nil {
+		return Quote{}, f.err
+	}
+	return Quote{Currency: currency, Price: f.price, Provider: f.name, Time: time.Now()}, nil
+}
+
+func TestService_Failover(t *testing.T) {
+	primary := &fakeProvider{name: "primary", err: errors.New("down")}
+	secondary := &fakeProvider{name: "secondary", price: 3000}
+
+	s := NewService([]Provider{primary, secondary}, time.Minute, time.Hour)
+
+	result, err := s.Price(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("Price failed: %v", err)
+	}
+	if result.Provider != "secondary" || result.Price != 3000 || result.Stale {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	// Served from cache within the TTL
+	if _, err := s.Price(context.Background(), "usd"); err != nil {
+		t.Fatalf("Price failed: %v", err)
+	}
+	if secondary.calls != 1 {
+		t.Errorf("expected 1 call to secondary provider, got %d", secondary.calls)
+	}
+}
+
+func TestService_StaleFallback(t *testing.T) {
+	p := &fakeProvider{name: "only", price: 2500}
+	s := NewService([]Provider{p}, 0, time.Hour)
+
+	if _, err := s.Price(context.Background(), "eur"); err != nil {
+		t.Fatalf("Price failed: %v", err)
+	}
+
+	p.err = errors.New("down")
+	result, err := s.Price(context.Background(), "eur")
+	if err != nil {
+		t.Fatalf("expected stale price, got error: %v", err)
+	}
+	if !result.Stale || result.Price != 2500 {
+		t.Errorf("expected stale price 2500, got %+v", result)
+	}
+
+	// Too old to be served as stale
+	s.maxStaleness = 0
+	if _, err := s.Price(context.Background(), "eur"); err == nil {
+		t.Error("expected error once the last known price is too old")
+	}
+}
+
+func TestChainlink_Quote(t *testing.T) {
+	// answer = 3000.12345678 USD with 8 decimals, updatedAt = 1700000000
+	word := func(v uint64) string { return fmt.Sprintf("%064x", v) }
+	result := "0x" + word(1) + word(300012345678) + word(1699999990) + word(1700000000) + word(1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":"%s"}`, result)
+	}))
+	defer srv.Close()
+
+	c := NewChainlink(srv.URL, ChainlinkETHUSDFeed, time.Second)
+
+	q, err := c.Quote(context.Background(), "usd")
+	if err != nil {
+		t.Fatalf("Quote failed: %v", err)
+	}
+	if q.Price != 3000.12345678 || q.Time.Unix() != 1700000000 {
+		t.Errorf("unexpected quote: %+v", q)
+	}
+
+	if _, err := c.Quote(context.Background(), "eur"); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("expected ErrUnsupportedCurrency for eur, got %v", err)
+	}
+}
+
+func TestKraken_Quote(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.RawQuery, "pair=ETHEUR") {
+			fmt.Fprint(w, `{"error":["EQuery:Unknown asset pair"]}`)
+			return
+		}
+		fmt.Fprint(w, `{"error":[],"result":{"XETHZEUR":{"c":["2750.50","0.01"]}}}`)
+	}))
+	defer srv.Close()
+
+	k := NewKraken(srv.URL, time.Second)
+
+	q, err := k.Quote(context.Background(), "eur")
+	if err != nil {
+		t.Fatalf("Quote failed: %v", err)
+	}
+	if q.Price != 2750.50 {
+		t.Errorf("expected 2750.50, got %v", q.Price)
+	}
+
+	if _, err := k.Quote(context.Background(), "xyz"); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("expected ErrUnsupportedCurrency, got %v", err)
+	}
+}
+
+type fakeHistoricalProvider struct {
+	fakeProvider
+	daily map[string]float64
+}
+
+func (f *fakeHistoricalProvider) DailyQuote(ctx context.Context, currency string, day time.Time) (Quote, error) {
+	f.calls++
+	p, ok := f.daily[day.Format(dayLayout)]
+	if !ok {
+		return Quote{}, errors.New("no data")
+	}
+	return Quote{Currency: currency, Price: p, Provider: f.name, Time: day}, nil
+}
+
+func TestService_DailyPrices(t *testing.T) {
+	today := startOfDay(time.Now())
+	day1 := today.AddDate(0, 0, -2)
+	day2 := today.AddDate(0, 0, -1)
+
+	p := &fakeHistoricalProvider{
+		fakeProvider: fakeProvider{name: "hist", price: 3100},
+		daily:        map[string]float64{day1.Format(dayLayout): 2900},
+	}
+	path := t.TempDir() + "/prices.json"
+
+	s := NewService([]Provider{p}, time.Minute, time.Hour)
+	if err := s.LoadHistory(path); err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+
+	prices, err := s.DailyPrices(context.Background(), "usd", []time.Time{day1, day2, today})
+	if err != nil {
+		t.Fatalf("DailyPrices failed: %v", err)
+	}
+	if prices[day1.Format(dayLayout)] != 2900 || prices[today.Format(dayLayout)] != 3100 {
+		t.Errorf("unexpected prices: %v", prices)
+	}
+	if _, ok := prices[day2.Format(dayLayout)]; ok {
+		t.Errorf("expected no price for %s", day2.Format(dayLayout))
+	}
+
+	// A new service loads the stored day without asking the provider again
+	other := &fakeHistoricalProvider{fakeProvider: fakeProvider{name: "other"}}
+	s = NewService([]Provider{other}, time.Minute, time.Hour)
+	if err := s.LoadHistory(path); err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	prices, err = s.DailyPrices(context.Background(), "usd", []time.Time{day1})
+	if err != nil {
+		t.Fatalf("DailyPrices failed: %v", err)
+	}
+	if prices[day1.Format(dayLayout)] != 2900 || other.calls != 0 {
+		t.Errorf("expected stored price without provider calls, got %v after %d calls", prices, other.calls)
+	}
+}
+
+func TestCoinbase_Quote(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.String() {
+		case "/v2/prices/ETH-EUR/spot":
+			fmt.Fprint(w, `{"data":{"amount":"2750.50","base":"ETH","currency":"EUR"}}`)
+		case "/v2/prices/ETH-EUR/spot?date=2026-01-02":
+			fmt.Fprint(w, `{"data":{"amount":"3012.25","base":"ETH","currency":"EUR"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":[{"id":"not_found","message":"Invalid currency"}]}`)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewCoinbase(srv.URL, time.Second)
+
+	q, err := c.Quote(context.Background(), "eur")
+	if err != nil {
+		t.Fatalf("Quote failed: %v", err)
+	}
+	if q.Price != 2750.50 || q.Provider != "coinbase" {
+		t.Errorf("expected 2750.50 from coinbase, got %+v", q)
+	}
+
+	q, err = c.DailyQuote(context.Background(), "eur", time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("DailyQuote failed: %v", err)
+	}
+	if q.Price != 3012.25 || !q.Time.Equal(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 3012.25 at the start of the day, got %+v", q)
+	}
+
+	if _, err := c.Quote(context.Background(), "xyz"); err == nil {
+		t.Error("expected an error for an unknown currency")
+	}
+}
+
+func TestStatic(t *testing.T) {
+	prices, err := ParseStaticPrices("USD=3000, eur=2750.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewService([]Provider{&fakeProvider{name: "down", err: errors.New("down")}, NewStatic(prices)}, time.Minute, time.Hour)
+
+	r, err := s.Price(context.Background(), "eur")
+	if err != nil {
+		t.Fatalf("Price failed: %v", err)
+	}
+	if r.Price != 2750.5 || r.Provider != "static" {
+		t.Errorf("expected the static price after the failed provider, got %+v", r)
+	}
+	if _, err := s.Price(context.Background(), "gbp"); err == nil {
+		t.Error("expected an error for a currency without a static price")
+	}
+
+	for _, invalid := range []string{"usd", "usd=0", "usd=abc"} {
+		if _, err := ParseStaticPrices(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestService_RateLimits(t *testing.T) {
+	limited := &fakeProvider{name: "limited", price: 2500}
+	s := NewService([]Provider{limited, &fakeProvider{name: "backup", price: 2400}}, 0, time.Hour)
+	intervals, err := ParseRateLimits("limited=1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetRateLimits(intervals)
+
+	if r, err := s.Price(context.Background(), "usd"); err != nil || r.Provider != "limited" {
+		t.Fatalf("expected the first call to go to the limited provider, got %+v, %v", r, err)
+	}
+
+	// A call that cannot wait for its turn fails over
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	r, err := s.Price(ctx, "usd")
+	if err != nil || r.Provider != "backup" {
+		t.Errorf("expected the backup provider within the rate limit, got %+v, %v", r, err)
+	}
+	if limited.calls != 1 {
+		t.Errorf("expected 1 call to the limited provider, got %d", limited.calls)
+	}
+
+	if _, err := ParseRateLimits("limited=fast"); err == nil {
+		t.Error("expected an invalid interval to be rejected")
+	}
+}
+
+func TestService_CountsCacheHits(t *testing.T) {
+	p := &fakeProvider{name: "only", price: 2500}
+	s := NewService([]Provider{p}, time.Minute, time.Hour)
+
+	ctx, counter := cost.WithCounter(context.Background())
+	for i := 0; i < 3; i++ {
+		if _, err := s.Price(ctx, "usd"); err != nil {
+			t.Fatalf("Price failed: %v", err)
+		}
+	}
+
+	if counter.CacheHits() != 2 {
+		t.Errorf("expected 2 cache hits, got %d", counter.CacheHits())
+	}
+}
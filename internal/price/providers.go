@@ -0,0 +1,440 @@
+package price
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/tracing"
+)
+
+// CoinGecko quotes prices from the CoinGecko simple price API.
+type CoinGecko struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewCoinGecko creates a CoinGecko provider. The API key is optional.
+func NewCoinGecko(baseURL, apiKey string, timeout time.Duration) *CoinGecko {
+	return &CoinGecko{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name implements Provider.
+func (c *CoinGecko) Name() string { return "coingecko" }
+
+// Quote implements Provider.
+func (c *CoinGecko) Quote(ctx context.Context, currency string) (Quote, error) {
+	url := fmt.Sprintf("%s/api/v3/simple/price?ids=ethereum&vs_currencies=%s", c.baseURL, currency)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Quote{}, fmt.Errorf("create request: %w", err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("x-cg-demo-api-key", c.apiKey)
+	}
+
+	var response map[string]map[string]float64
+	if err := doJSON(c.httpClient, req, &response); err != nil {
+		return Quote{}, err
+	}
+
+	p, ok := response["ethereum"][currency]
+	if !ok {
+		return Quote{}, ErrUnsupportedCurrency
+	}
+
+	return Quote{Currency: currency, Price: p, Provider: c.Name(), Time: time.Now()}, nil
+}
+
+// DailyQuote implements HistoricalProvider using the coin history endpoint,
+// which returns the price at 00:00 UTC of the given day.
+func (c *CoinGecko) DailyQuote(ctx context.Context, currency string, day time.Time) (Quote, error) {
+	url := fmt.Sprintf("%s/api/v3/coins/ethereum/history?date=%s&localization=false", c.baseURL, day.UTC().Format("02-01-2006"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Quote{}, fmt.Errorf("create request: %w", err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("x-cg-demo-api-key", c.apiKey)
+	}
+
+	var response struct {
+		MarketData struct {
+			CurrentPrice map[string]float64 `json:"current_price"`
+		} `json:"market_data"`
+	}
+	if err := doJSON(c.httpClient, req, &response); err != nil {
+		return Quote{}, err
+	}
+
+	p, ok := response.MarketData.CurrentPrice[currency]
+	if !ok {
+		return Quote{}, ErrUnsupportedCurrency
+	}
+
+	return Quote{Currency: currency, Price: p, Provider: c.Name(), Time: startOfDay(day)}, nil
+}
+
+// Kraken quotes prices from the Kraken public ticker.
+type Kraken struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewKraken creates a Kraken provider.
+func NewKraken(baseURL string, timeout time.Duration) *Kraken {
+	return &Kraken{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name implements Provider.
+func (k *Kraken) Name() string { return "kraken" }
+
+// Quote implements Provider.
+func (k *Kraken) Quote(ctx context.Context, currency string) (Quote, error) {
+	pair := "ETH" + strings.ToUpper(currency)
+	url := fmt.Sprintf("%s/0/public/Ticker?pair=%s", k.baseURL, pair)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Quote{}, fmt.Errorf("create request: %w", err)
+	}
+
+	var response struct {
+		Error  []string `json:"error"`
+		Result map[string]struct {
+			LastTrade []string `json:"c"` // [price, lot volume]
+		} `json:"result"`
+	}
+	if err := doJSON(k.httpClient, req, &response); err != nil {
+		return Quote{}, err
+	}
+
+	if len(response.Error) > 0 {
+		// Unknown pairs are reported as "EQuery:Unknown asset pair"
+		if strings.Contains(response.Error[0], "Unknown asset pair") {
+			return Quote{}, ErrUnsupportedCurrency
+		}
+		return Quote{}, fmt.Errorf("kraken error: %s", strings.Join(response.Error, "; "))
+	}
+
+	// The result is keyed by Kraken's internal pair name (e.g. XETHZUSD)
+	for _, ticker := range response.Result {
+		if len(ticker.LastTrade) == 0 {
+			break
+		}
+		p, err := strconv.ParseFloat(ticker.LastTrade[0], 64)
+		if err != nil {
+			return Quote{}, fmt.Errorf("parse price: %w", err)
+		}
+		return Quote{Currency: currency, Price: p, Provider: k.Name(), Time: time.Now()}, nil
+	}
+
+	return Quote{}, fmt.Errorf("kraken returned no ticker for %s", pair)
+}
+
+// DailyQuote implements HistoricalProvider using daily OHLC candles.
+// The closing price of the day is returned. Kraken only serves the last 720 candles.
+func (k *Kraken) DailyQuote(ctx context.Context, currency string, day time.Time) (Quote, error) {
+	pair := "ETH" + strings.ToUpper(currency)
+	start := startOfDay(day)
+	// since is exclusive, so ask for candles after the second before the day starts
+	url := fmt.Sprintf("%s/0/public/OHLC?pair=%s&interval=1440&since=%d", k.baseURL, pair, start.Unix()-1)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Quote{}, fmt.Errorf("create request: %w", err)
+	}
+
+	var response struct {
+		Error  []string                   `json:"error"`
+		Result map[string]json.RawMessage `json:"result"`
+	}
+	if err := doJSON(k.httpClient, req, &response); err != nil {
+		return Quote{}, err
+	}
+
+	if len(response.Error) > 0 {
+		if strings.Contains(response.Error[0], "Unknown asset pair") {
+			return Quote{}, ErrUnsupportedCurrency
+		}
+		return Quote{}, fmt.Errorf("kraken error: %s", strings.Join(response.Error, "; "))
+	}
+
+	for name, raw := range response.Result {
+		// The result also contains "last", the cursor for the next request
+		if name == "last" {
+			continue
+		}
+
+		// Candles are [time, open, high, low, close, vwap, volume, count]
+		var candles [][]any
+		if err := json.Unmarshal(raw, &candles); err != nil {
+			return Quote{}, fmt.Errorf("decode candles: %w", err)
+		}
+		for _, c := range candles {
+			if len(c) < 5 {
+				continue
+			}
+			ts, ok := c[0].(float64)
+			if !ok || int64(ts) != start.Unix() {
+				continue
+			}
+			closeStr, _ := c[4].(string)
+			p, err := strconv.ParseFloat(closeStr, 64)
+			if err != nil {
+				return Quote{}, fmt.Errorf("parse price: %w", err)
+			}
+			return Quote{Currency: currency, Price: p, Provider: k.Name(), Time: start}, nil
+		}
+	}
+
+	return Quote{}, fmt.Errorf("kraken returned no candle for %s", start.Format(dayLayout))
+}
+
+// Coinbase quotes prices from the Coinbase spot price API.
+type Coinbase struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewCoinbase creates a Coinbase provider.
+func NewCoinbase(baseURL string, timeout time.Duration) *Coinbase {
+	return &Coinbase{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name implements Provider.
+func (c *Coinbase) Name() string { return "coinbase" }
+
+// Quote implements Provider.
+func (c *Coinbase) Quote(ctx context.Context, currency string) (Quote, error) {
+	p, err := c.spot(ctx, currency, "")
+	if err != nil {
+		return Quote{}, err
+	}
+	return Quote{Currency: currency, Price: p, Provider: c.Name(), Time: time.Now()}, nil
+}
+
+// DailyQuote implements HistoricalProvider using the spot price of the given day.
+func (c *Coinbase) DailyQuote(ctx context.Context, currency string, day time.Time) (Quote, error) {
+	p, err := c.spot(ctx, currency, day.UTC().Format(dayLayout))
+	if err != nil {
+		return Quote{}, err
+	}
+	return Quote{Currency: currency, Price: p, Provider: c.Name(), Time: startOfDay(day)}, nil
+}
+
+// spot returns the spot price of ETH in currency, on date if set.
+func (c *Coinbase) spot(ctx context.Context, currency, date string) (float64, error) {
+	url := fmt.Sprintf("%s/v2/prices/ETH-%s/spot", c.baseURL, strings.ToUpper(currency))
+	if date != "" {
+		url += "?date=" + date
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+
+	var response struct {
+		Data struct {
+			Amount   string `json:"amount"`
+			Currency string `json:"currency"`
+		} `json:"data"`
+	}
+	if err := doJSON(c.httpClient, req, &response); err != nil {
+		return 0, err
+	}
+	if !strings.EqualFold(response.Data.Currency, currency) {
+		return 0, ErrUnsupportedCurrency
+	}
+
+	p, err := strconv.ParseFloat(response.Data.Amount, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse price: %w", err)
+	}
+	return p, nil
+}
+
+// Static quotes fixed prices, e.g. for deployments without access to price APIs
+// or as a last resort behind them. It does not quote past days.
+type Static struct {
+	prices map[string]float64
+}
+
+// NewStatic creates a provider quoting the given prices, keyed by lowercase currency.
+func NewStatic(prices map[string]float64) *Static {
+	return &Static{prices: prices}
+}
+
+// Name implements Provider.
+func (s *Static) Name() string { return "static" }
+
+// Quote implements Provider.
+func (s *Static) Quote(ctx context.Context, currency string) (Quote, error) {
+	p, ok := s.prices[currency]
+	if !ok {
+		return Quote{}, ErrUnsupportedCurrency
+	}
+	return Quote{Currency: currency, Price: p, Provider: s.Name(), Time: time.Now()}, nil
+}
+
+// ParseStaticPrices parses prices in the form "usd=3000,eur=2750".
+func ParseStaticPrices(s string) (map[string]float64, error) {
+	prices := make(map[string]float64)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		currency, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid price %q: expected currency=price", entry)
+		}
+		p, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || p <= 0 {
+			return nil, fmt.Errorf("invalid price %q: price must be a positive number", entry)
+		}
+		prices[strings.ToLower(strings.TrimSpace(currency))] = p
+	}
+	return prices, nil
+}
+
+// ChainlinkETHUSDFeed is the address of the Chainlink ETH/USD price feed on mainnet.
+const ChainlinkETHUSDFeed = "0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419"
+
+// latestRoundDataSelector is the function selector of latestRoundData().
+const latestRoundDataSelector = "0xfeaf968c"
+
+// Chainlink reads the ETH/USD price from a Chainlink aggregator through an execution layer JSON-RPC endpoint.
+type Chainlink struct {
+	rpcURL      string
+	feedAddress string
+	decimals    int
+	httpClient  *http.Client
+}
+
+// NewChainlink creates a Chainlink provider reading the ETH/USD feed (8 decimals) at feedAddress.
+func NewChainlink(rpcURL, feedAddress string, timeout time.Duration) *Chainlink {
+	return &Chainlink{
+		rpcURL:      rpcURL,
+		feedAddress: feedAddress,
+		decimals:    8,
+		httpClient:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Name implements Provider.
+func (c *Chainlink) Name() string { return "chainlink" }
+
+// Quote implements Provider. Only USD is supported.
+func (c *Chainlink) Quote(ctx context.Context, currency string) (Quote, error) {
+	if currency != "usd" {
+		return Quote{}, ErrUnsupportedCurrency
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "eth_call",
+		"params": []any{
+			map[string]string{"to": c.feedAddress, "data": latestRoundDataSelector},
+			"latest",
+		},
+	})
+	if err != nil {
+		return Quote{}, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.rpcURL, bytes.NewReader(payload))
+	if err != nil {
+		return Quote{}, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var response struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := doJSON(c.httpClient, req, &response); err != nil {
+		return Quote{}, err
+	}
+	if response.Error != nil {
+		return Quote{}, fmt.Errorf("rpc error: %s", response.Error.Message)
+	}
+
+	// latestRoundData returns (roundId, answer, startedAt, updatedAt, answeredInRound), 32 bytes each
+	data, err := hex.DecodeString(strings.TrimPrefix(response.Result, "0x"))
+	if err != nil || len(data) < 5*32 {
+		return Quote{}, fmt.Errorf("unexpected latestRoundData result %q", response.Result)
+	}
+
+	answer := new(big.Int).SetBytes(data[32:64])
+	updatedAt := new(big.Int).SetBytes(data[96:128]).Int64()
+
+	p, _ := new(big.Float).Quo(
+		new(big.Float).SetInt(answer),
+		new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(c.decimals)), nil)),
+	).Float64()
+
+	return Quote{Currency: currency, Price: p, Provider: c.Name(), Time: time.Unix(updatedAt, 0)}, nil
+}
+
+// startOfDay truncates t to 00:00 UTC.
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// doJSON performs the request and decodes a JSON response body into v.
+func doJSON(client *http.Client, req *http.Request, v any) (err error) {
+	ctx, span := tracing.StartSpan(req.Context(), req.Method+" "+req.URL.Host)
+	defer func() { span.End(err != nil) }()
+
+	req.Header.Set("Accept", "application/json")
+	tracing.Inject(ctx, req.Header)
+
+	cost.AddUpstreamCall(ctx)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
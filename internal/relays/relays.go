@@ -0,0 +1,227 @@
+// Package relays checks the validator registrations held by MEV-boost relays
+// through their data API.
+package relays
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/tracing"
+)
+
+// Relay is a MEV-boost relay.
+type Relay struct {
+	Name string
+	URL  string
+}
+
+// MajorRelays are the major mainnet relays.
+var MajorRelays = []Relay{
+	{Name: "flashbots", URL: "https://boost-relay.flashbots.net"},
+	{Name: "ultrasound", URL: "https://relay.ultrasound.money"},
+	{Name: "bloxroute-max-profit", URL: "https://bloxroute.max-profit.blxrbdn.com"},
+	{Name: "bloxroute-regulated", URL: "https://bloxroute.regulated.blxrbdn.com"},
+	{Name: "aestus", URL: "https://mainnet.aestus.live"},
+	{Name: "agnostic", URL: "https://agnostic-relay.net"},
+	{Name: "titan", URL: "https://titanrelay.xyz"},
+}
+
+// maxConcurrent is the number of registrations queried at once.
+const maxConcurrent = 8
+
+// ParseRelays parses relays in the form "flashbots=https://boost-relay.flashbots.net,...",
+// or "major" for MajorRelays. An empty string returns no relays.
+func ParseRelays(s string) ([]Relay, error) {
+	if strings.TrimSpace(s) == "major" {
+		return MajorRelays, nil
+	}
+	var relays []Relay
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, rawURL, ok := strings.Cut(entry, "=")
+		name, rawURL = strings.TrimSpace(name), strings.TrimSpace(rawURL)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid relay %q: expected name=url", entry)
+		}
+		if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid relay %q: url must be an http(s) url", entry)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate relay %q", name)
+		}
+		seen[name] = true
+		relays = append(relays, Relay{Name: name, URL: strings.TrimSuffix(rawURL, "/")})
+	}
+	return relays, nil
+}
+
+// Registration is the registration of a validator at a relay. Err is set when
+// the relay could not be queried.
+type Registration struct {
+	Relay        string
+	Registered   bool
+	FeeRecipient string
+	GasLimit     uint64
+	Timestamp    time.Time // When the validator signed the registration
+	Err          error
+}
+
+// Checker queries the registrations of validators at a set of relays. Results are
+// cached per validator for the cache TTL, unless a relay failed.
+type Checker struct {
+	relays     []Relay
+	cacheTTL   time.Duration
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry // By lowercase public key
+}
+
+type cacheEntry struct {
+	registrations []Registration
+	expires       time.Time
+}
+
+// NewChecker creates a checker querying relays.
+func NewChecker(relays []Relay, cacheTTL, timeout time.Duration) *Checker {
+	return &Checker{
+		relays:     relays,
+		cacheTTL:   cacheTTL,
+		httpClient: &http.Client{Timeout: timeout},
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+// Check returns the registrations of each of the validators with the public keys
+// pubkeys at every relay, in the order of the relays, keyed by public key.
+func (c *Checker) Check(ctx context.Context, pubkeys []string) map[string][]Registration {
+	result := make(map[string][]Registration, len(pubkeys))
+	var pending []string
+	c.mu.Lock()
+	for _, pubkey := range pubkeys {
+		if e, ok := c.cache[strings.ToLower(pubkey)]; ok && time.Now().Before(e.expires) {
+			result[pubkey] = e.registrations
+			cost.AddCacheHit(ctx)
+			continue
+		}
+		pending = append(pending, pubkey)
+	}
+	c.mu.Unlock()
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrent)
+	for _, pubkey := range pending {
+		registrations := make([]Registration, len(c.relays))
+		for i, relay := range c.relays {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				registrations[i] = c.query(ctx, relay, pubkey)
+			}()
+		}
+		result[pubkey] = registrations
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, pubkey := range pending {
+		failed := false
+		for _, r := range result[pubkey] {
+			failed = failed || r.Err != nil
+		}
+		if !failed {
+			c.cache[strings.ToLower(pubkey)] = cacheEntry{registrations: result[pubkey], expires: time.Now().Add(c.cacheTTL)}
+		}
+	}
+	return result
+}
+
+// query returns the registration of the validator with the public key pubkey at relay.
+func (c *Checker) query(ctx context.Context, relay Relay, pubkey string) Registration {
+	registration := Registration{Relay: relay.Name}
+	message, err := c.get(ctx, relay, pubkey)
+	switch {
+	case errors.Is(err, errNotRegistered):
+	case err != nil:
+		registration.Err = err
+	default:
+		registration.Registered = true
+		registration.FeeRecipient = message.FeeRecipient
+		registration.GasLimit, _ = strconv.ParseUint(message.GasLimit, 10, 64)
+		if ts, err := strconv.ParseInt(message.Timestamp, 10, 64); err == nil {
+			registration.Timestamp = time.Unix(ts, 0).UTC()
+		}
+	}
+	return registration
+}
+
+// errNotRegistered is returned by get for validators a relay has no registration of.
+var errNotRegistered = errors.New("not registered")
+
+// registrationMessage is the signed message of a validator registration.
+// Numbers are decimal strings.
+type registrationMessage struct {
+	FeeRecipient string `json:"fee_recipient"`
+	GasLimit     string `json:"gas_limit"`
+	Timestamp    string `json:"timestamp"`
+	Pubkey       string `json:"pubkey"`
+}
+
+// get fetches a registration from GET /relay/v1/data/validator_registration.
+// Relays answer 400 or 404 for unknown validators.
+func (c *Checker) get(ctx context.Context, relay Relay, pubkey string) (_ registrationMessage, err error) {
+	ctx, span := tracing.StartSpan(ctx, "relay GET validator_registration")
+	defer func() { span.End(err != nil && !errors.Is(err, errNotRegistered)) }()
+
+	u := relay.URL + "/relay/v1/data/validator_registration?" + url.Values{"pubkey": {pubkey}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return registrationMessage{}, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	tracing.Inject(ctx, req.Header)
+
+	cost.AddUpstreamCall(ctx)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return registrationMessage{}, fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return registrationMessage{}, fmt.Errorf("read response: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusBadRequest, http.StatusNotFound:
+		return registrationMessage{}, errNotRegistered
+	default:
+		return registrationMessage{}, fmt.Errorf("returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response struct {
+		Message registrationMessage `json:"message"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return registrationMessage{}, fmt.Errorf("decode response: %w", err)
+	}
+	return response.Message, nil
+}
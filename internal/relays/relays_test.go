@@ -0,0 +1,102 @@
+package relays
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newRelay serves a relay data API holding a registration of registered, and
+// answering with status for any other validator.
+func newRelay(t *testing.T, registered string, status int, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path != "/relay/v1/data/validator_registration" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("pubkey") != registered {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"code":400,"message":"no registration found for validator"}`))
+			return
+		}
+		w.Write([]byte(`{"message":{"fee_recipient":"0xd8da6bf26964af9d7eed9e03e53415d37aa96045","gas_limit":"36000000","timestamp":"1700000000","pubkey":"` + registered + `"},"signature":"0x00"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChecker_Check(t *testing.T) {
+	var calls atomic.Int32
+	good := newRelay(t, "0xa1", http.StatusBadRequest, &calls)
+	other := newRelay(t, "0xb2", http.StatusNotFound, &calls)
+	broken := newRelay(t, "", http.StatusInternalServerError, &calls)
+
+	c := NewChecker([]Relay{{Name: "good", URL: good.URL}, {Name: "other", URL: other.URL}}, time.Hour, time.Second)
+	result := c.Check(context.Background(), []string{"0xa1", "0xb2"})
+
+	a1 := result["0xa1"]
+	if len(a1) != 2 || !a1[0].Registered || a1[1].Registered || a1[0].Err != nil || a1[1].Err != nil {
+		t.Fatalf("expected 0xa1 registered at good only, got %+v", a1)
+	}
+	if r := a1[0]; r.Relay != "good" || r.FeeRecipient != "0xd8da6bf26964af9d7eed9e03e53415d37aa96045" || r.GasLimit != 36000000 || !r.Timestamp.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("unexpected registration %+v", r)
+	}
+	if b2 := result["0xb2"]; len(b2) != 2 || b2[0].Registered || !b2[1].Registered {
+		t.Errorf("expected 0xb2 registered at other only, got %+v", b2)
+	}
+
+	// Results are cached
+	c.Check(context.Background(), []string{"0xa1"})
+	if n := calls.Load(); n != 4 {
+		t.Errorf("expected 4 relay calls, got %d", n)
+	}
+
+	// Failures are reported and not cached
+	c = NewChecker([]Relay{{Name: "broken", URL: broken.URL}}, time.Hour, time.Second)
+	for i := 0; i < 2; i++ {
+		if r := c.Check(context.Background(), []string{"0xa1"})["0xa1"]; len(r) != 1 || r[0].Err == nil || r[0].Registered {
+			t.Errorf("expected an error, got %+v", r)
+		}
+	}
+	if n := calls.Load(); n != 6 {
+		t.Errorf("expected failed checks to be retried, got %d relay calls", n)
+	}
+}
+
+func TestParseRelays(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr string
+	}{
+		{input: "", want: 0},
+		{input: "major", want: len(MajorRelays)},
+		{input: "flashbots=https://boost-relay.flashbots.net/, local=http://localhost:18550", want: 2},
+		{input: "flashbots", wantErr: "name=url"},
+		{input: "local=localhost:18550", wantErr: "http(s) url"},
+		{input: "a=https://a.example,a=https://b.example", wantErr: "duplicate"},
+	}
+	for _, tt := range tests {
+		relays, err := ParseRelays(tt.input)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseRelays(%q): expected error %q, got %v", tt.input, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || len(relays) != tt.want {
+			t.Errorf("ParseRelays(%q) = %d relays, %v; want %d", tt.input, len(relays), err, tt.want)
+		}
+		for _, r := range relays {
+			if strings.HasSuffix(r.URL, "/") {
+				t.Errorf("ParseRelays(%q): expected %s without trailing slash", tt.input, r.URL)
+			}
+		}
+	}
+}
@@ -0,0 +1,302 @@
+// Package reports generates recurring income and performance summaries of
+// portfolios on cron schedules, delivers them over the alert channels and keeps
+// them for download.
+package reports
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/alerts"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+// maxReports is the number of reports kept. The oldest are dropped first.
+const maxReports = 500
+
+// Source provides the summaries reports are made of.
+type Source interface {
+	GetPortfolioReport(ctx context.Context, name, period string) (models.PortfolioReport, error)
+}
+
+// AlertSource provides the alerts listed in reports.
+type AlertSource interface {
+	Alerts(status string) []models.Alert
+}
+
+// periodLengths are the lengths of the report periods.
+var periodLengths = map[string]time.Duration{
+	"daily":  24 * time.Hour,
+	"weekly": 7 * 24 * time.Hour,
+}
+
+// ScheduleConfig configures a recurring report of a portfolio.
+type ScheduleConfig struct {
+	Name      string   `json:"name"`
+	Portfolio string   `json:"portfolio"`
+	Period    string   `json:"period"`   // daily or weekly
+	Schedule  string   `json:"schedule"` // Cron expression in UTC
+	Channels  []string `json:"channels,omitempty"`
+}
+
+// Config is the content of a report schedules file.
+type Config struct {
+	Reports []ScheduleConfig `json:"reports"`
+}
+
+// LoadFile reads report schedules from a JSON file. An empty path returns an
+// empty configuration.
+func LoadFile(path string) (Config, error) {
+	if path == "" {
+		return Config{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read report schedules: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("decode report schedules: %w", err)
+	}
+	return cfg, nil
+}
+
+// schedule is a configured report and when it runs next.
+type schedule struct {
+	config   ScheduleConfig
+	schedule Schedule
+	next     time.Time
+}
+
+// Scheduler generates reports when their schedules are due. It is safe for
+// concurrent use.
+type Scheduler struct {
+	source    Source
+	alerts    AlertSource // nil lists no alerts
+	channels  map[string]alerts.ReportNotifier
+	schedules []*schedule
+
+	mu      sync.Mutex
+	reports []models.PortfolioReport // Oldest first
+	path    string
+}
+
+// NewScheduler validates the schedules against the portfolios and channels and
+// creates a scheduler for them.
+func NewScheduler(cfg Config, portfolios *portfolio.Registry, notifiers map[string]alerts.Notifier, source Source) (*Scheduler, error) {
+	s := &Scheduler{source: source, channels: make(map[string]alerts.ReportNotifier)}
+
+	now := time.Now()
+	names := make(map[string]bool)
+	for _, c := range cfg.Reports {
+		if c.Name == "" {
+			return nil, fmt.Errorf("report schedule must have a name")
+		}
+		if names[c.Name] {
+			return nil, fmt.Errorf("duplicate report schedule %q", c.Name)
+		}
+		names[c.Name] = true
+
+		if _, ok := portfolios.Get(c.Portfolio); !ok {
+			return nil, fmt.Errorf("report schedule %q: unknown portfolio %q", c.Name, c.Portfolio)
+		}
+		if _, ok := periodLengths[c.Period]; !ok {
+			return nil, fmt.Errorf("report schedule %q: period must be one of: daily, weekly", c.Name)
+		}
+		parsed, err := ParseSchedule(c.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("report schedule %q: %w", c.Name, err)
+		}
+		next := parsed.Next(now)
+		if next.IsZero() {
+			return nil, fmt.Errorf("report schedule %q: schedule %q never runs", c.Name, c.Schedule)
+		}
+		for _, name := range c.Channels {
+			notifier, ok := notifiers[name]
+			if !ok {
+				return nil, fmt.Errorf("report schedule %q: unknown channel %q", c.Name, name)
+			}
+			n, ok := notifier.(alerts.ReportNotifier)
+			if !ok {
+				return nil, fmt.Errorf("report schedule %q: channel %q cannot deliver reports", c.Name, name)
+			}
+			s.channels[name] = n
+		}
+
+		s.schedules = append(s.schedules, &schedule{config: c, schedule: parsed, next: next})
+	}
+	return s, nil
+}
+
+// SetAlerts lists the alerts of the portfolio in every report: those fired during
+// its period and those still unresolved.
+func (s *Scheduler) SetAlerts(source AlertSource) {
+	s.alerts = source
+}
+
+// LoadReports reads the stored reports from path and persists new reports to it.
+// A missing file starts with no reports.
+func (s *Scheduler) LoadReports(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.path = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read reports: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.reports); err != nil {
+		return fmt.Errorf("decode reports: %w", err)
+	}
+	return nil
+}
+
+// Run generates the reports whose schedules are due until the context is canceled.
+// Runs missed while the server was down are skipped.
+func (s *Scheduler) Run(ctx context.Context) {
+	if len(s.schedules) == 0 {
+		return
+	}
+
+	for {
+		next := s.schedules[0].next
+		for _, sc := range s.schedules[1:] {
+			if sc.next.Before(next) {
+				next = sc.next
+			}
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		for _, sc := range s.schedules {
+			if !sc.next.After(now) {
+				s.Generate(ctx, sc.config)
+				sc.next = sc.schedule.Next(now)
+			}
+		}
+	}
+}
+
+// Generate produces a report for the schedule, keeps it and delivers it to the
+// channels of the schedule. Failures are logged, and a report that cannot be
+// generated is skipped until the next run.
+func (s *Scheduler) Generate(ctx context.Context, c ScheduleConfig) (models.PortfolioReport, bool) {
+	report, err := s.source.GetPortfolioReport(ctx, c.Portfolio, c.Period)
+	if err != nil {
+		slog.Error("failed to generate report", "schedule", c.Name, "portfolio", c.Portfolio, "error", err)
+		return models.PortfolioReport{}, false
+	}
+
+	var id [8]byte
+	rand.Read(id[:])
+	report.ID = hex.EncodeToString(id[:])
+	report.Schedule = c.Name
+	report.GeneratedAt = time.Now().UTC()
+	if s.alerts != nil {
+		report.Alerts = periodAlerts(s.alerts.Alerts(""), c.Portfolio, report.GeneratedAt.Add(-periodLengths[c.Period]))
+	}
+	s.store(report)
+
+	for _, name := range c.Channels {
+		if err := s.channels[name].NotifyReport(ctx, report); err != nil {
+			slog.Error("failed to deliver report", "schedule", c.Name, "channel", name, "error", err)
+		}
+	}
+	return report, true
+}
+
+// periodAlerts returns the alerts of the portfolio fired since start or still
+// unresolved, keeping their order.
+func periodAlerts(all []models.Alert, portfolioName string, start time.Time) []models.Alert {
+	var result []models.Alert
+	for _, a := range all {
+		if a.Portfolio == portfolioName && (!a.FiredAt.Before(start) || a.ResolvedAt == nil) {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// store keeps a report and persists the reports if a path is set.
+func (s *Scheduler) store(report models.PortfolioReport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reports = append(s.reports, report)
+	if len(s.reports) > maxReports {
+		s.reports = s.reports[len(s.reports)-maxReports:]
+	}
+	if s.path == "" {
+		return
+	}
+
+	data, err := json.Marshal(s.reports)
+	if err != nil {
+		slog.Error("failed to encode reports", "error", err)
+		return
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		slog.Error("failed to save reports", "error", err)
+		return
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		slog.Error("failed to save reports", "error", err)
+	}
+}
+
+// Reports lists the stored reports, newest first.
+func (s *Scheduler) Reports() []models.ReportSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]models.ReportSummary, 0, len(s.reports))
+	for i := len(s.reports) - 1; i >= 0; i-- {
+		r := s.reports[i]
+		result = append(result, models.ReportSummary{
+			ID:          r.ID,
+			Schedule:    r.Schedule,
+			Portfolio:   r.Portfolio,
+			Period:      r.Period,
+			GeneratedAt: r.GeneratedAt,
+			URL:         "/reports/" + r.ID,
+		})
+	}
+	return result
+}
+
+// Report returns a stored report.
+func (s *Scheduler) Report(id string) (models.PortfolioReport, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.reports {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return models.PortfolioReport{}, false
+}
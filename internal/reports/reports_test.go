@@ -0,0 +1,170 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/alerts"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+// fakeSource serves a fixed summary, or fails with err.
+type fakeSource struct {
+	err error
+}
+
+func (f *fakeSource) GetPortfolioReport(ctx context.Context, name, period string) (models.PortfolioReport, error) {
+	if f.err != nil {
+		return models.PortfolioReport{}, f.err
+	}
+	return models.PortfolioReport{
+		Portfolio:       name,
+		Chain:           "mainnet",
+		Period:          period,
+		DashboardTotals: models.DashboardTotals{Validators: 2},
+		Rewards:         models.ValidatorRewards{Total: "1000"},
+	}, nil
+}
+
+// recorder is an alert channel collecting the reports it receives.
+type recorder struct {
+	reports []models.PortfolioReport
+}
+
+func (r *recorder) Notify(ctx context.Context, n alerts.Notification) error { return nil }
+
+func (r *recorder) NotifyReport(ctx context.Context, report models.PortfolioReport) error {
+	r.reports = append(r.reports, report)
+	return nil
+}
+
+func newTestScheduler(t *testing.T, source Source, schedules ...ScheduleConfig) (*Scheduler, *recorder, error) {
+	t.Helper()
+	registry, err := portfolio.NewRegistry([]portfolio.Portfolio{
+		{Name: "home", Chain: "mainnet", ValidatorIds: []int{1, 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &recorder{}
+	s, err := NewScheduler(Config{Reports: schedules}, registry, map[string]alerts.Notifier{"test": rec}, source)
+	return s, rec, err
+}
+
+func TestNewScheduler_Validation(t *testing.T) {
+	valid := ScheduleConfig{Name: "weekly", Portfolio: "home", Period: "weekly", Schedule: "0 8 * * 1", Channels: []string{"test"}}
+
+	tests := []struct {
+		name   string
+		modify func(c *ScheduleConfig)
+		errMsg string
+	}{
+		{"missing name", func(c *ScheduleConfig) { c.Name = "" }, "must have a name"},
+		{"unknown portfolio", func(c *ScheduleConfig) { c.Portfolio = "work" }, "unknown portfolio"},
+		{"unknown period", func(c *ScheduleConfig) { c.Period = "hourly" }, "period must be"},
+		{"invalid schedule", func(c *ScheduleConfig) { c.Schedule = "0 8 * *" }, "expected 5 fields"},
+		{"never runs", func(c *ScheduleConfig) { c.Schedule = "0 0 30 2 *" }, "never runs"},
+		{"unknown channel", func(c *ScheduleConfig) { c.Channels = []string{"email"} }, "unknown channel"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid
+			tt.modify(&c)
+			if _, _, err := newTestScheduler(t, &fakeSource{}, c); err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+
+	if _, _, err := newTestScheduler(t, &fakeSource{}, valid, valid); err == nil {
+		t.Error("expected duplicate schedules to be rejected")
+	}
+}
+
+func TestScheduler_Generate(t *testing.T) {
+	schedule := ScheduleConfig{Name: "daily-home", Portfolio: "home", Period: "daily", Schedule: "@daily", Channels: []string{"test"}}
+	s, rec, err := newTestScheduler(t, &fakeSource{}, schedule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "reports.json")
+	if err := s.LoadReports(path); err != nil {
+		t.Fatal(err)
+	}
+
+	report, ok := s.Generate(context.Background(), schedule)
+	if !ok {
+		t.Fatal("expected a report")
+	}
+	if report.ID == "" || report.Schedule != "daily-home" || report.GeneratedAt.IsZero() {
+		t.Errorf("expected an identified report, got %+v", report)
+	}
+	if len(rec.reports) != 1 || rec.reports[0].ID != report.ID {
+		t.Errorf("expected the report delivered to its channel, got %d", len(rec.reports))
+	}
+
+	// Reports survive restarts
+	restarted, _, err := newTestScheduler(t, &fakeSource{}, schedule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.LoadReports(path); err != nil {
+		t.Fatal(err)
+	}
+	list := restarted.Reports()
+	if len(list) != 1 || list[0].URL != "/reports/"+report.ID {
+		t.Fatalf("expected the stored report, got %+v", list)
+	}
+	if got, ok := restarted.Report(report.ID); !ok || got.Rewards.Total != "1000" {
+		t.Errorf("expected the stored report, got %+v", got)
+	}
+
+	// A report that cannot be generated is neither kept nor delivered
+	failing, rec, err := newTestScheduler(t, &fakeSource{err: errors.New("upstream unavailable")}, schedule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := failing.Generate(context.Background(), schedule); ok || len(failing.Reports()) != 0 || len(rec.reports) != 0 {
+		t.Error("expected a failed report to be skipped")
+	}
+}
+
+// fakeAlerts serves fixed alerts.
+type fakeAlerts []models.Alert
+
+func (f fakeAlerts) Alerts(status string) []models.Alert { return f }
+
+func TestScheduler_Generate_Alerts(t *testing.T) {
+	schedule := ScheduleConfig{Name: "daily-home", Portfolio: "home", Period: "daily", Schedule: "@daily", Channels: []string{"test"}}
+	s, rec, err := newTestScheduler(t, &fakeSource{}, schedule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	resolved := now.Add(-40 * time.Hour)
+	s.SetAlerts(fakeAlerts{
+		{ID: "recent", Portfolio: "home", FiredAt: now.Add(-time.Hour), ResolvedAt: &now},
+		{ID: "other-portfolio", Portfolio: "lab", FiredAt: now.Add(-time.Hour)},
+		{ID: "still-open", Portfolio: "home", FiredAt: now.Add(-72 * time.Hour)},
+		{ID: "old", Portfolio: "home", FiredAt: now.Add(-48 * time.Hour), ResolvedAt: &resolved},
+	})
+
+	s.Generate(context.Background(), schedule)
+
+	if len(rec.reports) != 1 {
+		t.Fatalf("expected a report, got %d", len(rec.reports))
+	}
+	var ids []string
+	for _, a := range rec.reports[0].Alerts {
+		ids = append(ids, a.ID)
+	}
+	if strings.Join(ids, ",") != "recent,still-open" {
+		t.Errorf("expected the alerts of the period and the open ones, got %v", ids)
+	}
+}
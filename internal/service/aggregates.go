@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/budget"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// aggregateCacheEntry is a rewards or performance aggregate fetched during a given
+// epoch.
+type aggregateCacheEntry struct {
+	epoch   int64
+	fetched time.Time
+	value   any // *models.BeaconchainRewardsAggregateResponse or *models.BeaconchainPerformanceAggregateResponse
+}
+
+// aggregateCacheKey identifies an aggregate over the validators with the given IDs,
+// regardless of their order.
+func aggregateCacheKey(section, chain, evalRange string, ids []int) string {
+	sorted := store.SortedIndices(ids)
+	parts := make([]string, len(sorted))
+	for i, id := range sorted {
+		parts[i] = strconv.Itoa(id)
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", section, chain, evalRange, strings.Join(parts, ","))
+}
+
+// rewardsAggregate returns the rewards aggregate of the validators, from the cache
+// if it was fetched during the current epoch. Totals upstream left out are filled in.
+// Custom windows are summed from the daily rewards.
+func (s *ValidatorService) rewardsAggregate(ctx context.Context, chain string, ids []int, evalRange string) (*models.BeaconchainRewardsAggregateResponse, error) {
+	if from, to, ok := parseWindowRange(evalRange); ok {
+		return s.windowRewards(ctx, chain, ids, evalRange, from, to, time.Now())
+	}
+	return cachedAggregate(ctx, s, aggregateCacheKey(sectionRewards, chain, evalRange, ids), chain, func() (*models.BeaconchainRewardsAggregateResponse, error) {
+		rewards, err := s.beaconchainClient.GetRewardsAggregate(ctx, chain, ids, evalRange)
+		if rewards != nil {
+			fillRewardTotals(&rewards.Data)
+		}
+		return rewards, err
+	})
+}
+
+// performanceAggregate returns the performance aggregate of the validators, from
+// the cache if it was fetched during the current epoch.
+func (s *ValidatorService) performanceAggregate(ctx context.Context, chain string, ids []int, evalRange string) (*models.BeaconchainPerformanceAggregateResponse, error) {
+	return cachedAggregate(ctx, s, aggregateCacheKey(sectionPerformance, chain, evalRange, ids), chain, func() (*models.BeaconchainPerformanceAggregateResponse, error) {
+		return s.beaconchainClient.GetPerformanceAggregate(ctx, chain, ids, evalRange)
+	})
+}
+
+// cachedAggregate returns the aggregate cached under key during the current epoch
+// of chain, or fetches and caches it. Callers get a copy they may modify.
+func cachedAggregate[T any](ctx context.Context, s *ValidatorService, key, chain string, fetch func() (*T, error)) (*T, error) {
+	epoch, err := chainspec.LastCompletedEpoch(chain, time.Now())
+	if err != nil {
+		return fetch()
+	}
+
+	if e, ok := s.aggregateCache.Get(key); ok && e.epoch == epoch {
+		if v, ok := e.value.(*T); ok {
+			cost.AddCacheHit(ctx)
+			c := *v
+			return &c, nil
+		}
+	}
+
+	v, err := fetch()
+	if err != nil || v == nil {
+		return v, err
+	}
+	c := *v
+	s.aggregateCache.Add(key, aggregateCacheEntry{epoch: epoch, fetched: time.Now(), value: &c})
+	return v, nil
+}
+
+// rewardsFallback returns the rewards aggregate of the validators after the
+// aggregate endpoint failed with err: summed from their daily rewards, or else
+// the latest cached aggregate up to maxStaleAge old. Returns nil when neither is
+// available, or when err means no further upstream calls should be made.
+func (s *ValidatorService) rewardsFallback(ctx context.Context, chain string, ids []int, evalRange string, err error) *models.BeaconchainRewardsAggregateResponse {
+	if !fallbackAllowed(ctx, err) {
+		return nil
+	}
+	if _, _, ok := parseWindowRange(evalRange); ok {
+		// Already computed from the daily rewards
+		return staleAggregate[models.BeaconchainRewardsAggregateResponse](ctx, s, aggregateCacheKey(sectionRewards, chain, evalRange, ids))
+	}
+	slog.Warn("rewards aggregate failed, computing it from daily rewards", "chain", chain, "range", evalRange, "error", err)
+
+	entries, err := s.beaconchainClient.GetDailyRewards(ctx, chain, ids, evalRange)
+	if err == nil && len(entries) > 0 {
+		return sumRewardsHistory(entries)
+	}
+	if err != nil {
+		slog.Warn("failed to fetch daily rewards", "chain", chain, "error", err)
+	}
+	return staleAggregate[models.BeaconchainRewardsAggregateResponse](ctx, s, aggregateCacheKey(sectionRewards, chain, evalRange, ids))
+}
+
+// performanceFallback returns the latest cached performance aggregate of the
+// validators, up to maxStaleAge old, after the aggregate endpoint failed with err.
+// Performance has no per-day source to compute it from.
+func (s *ValidatorService) performanceFallback(ctx context.Context, chain string, ids []int, evalRange string, err error) *models.BeaconchainPerformanceAggregateResponse {
+	if !fallbackAllowed(ctx, err) {
+		return nil
+	}
+	slog.Warn("performance aggregate failed, serving the cached aggregate", "chain", chain, "range", evalRange, "error", err)
+	return staleAggregate[models.BeaconchainPerformanceAggregateResponse](ctx, s, aggregateCacheKey(sectionPerformance, chain, evalRange, ids))
+}
+
+// fallbackAllowed reports whether a failed aggregate may be replaced. Once the
+// request is canceled or the credit budget is spent, the whole request falls back
+// to cached data instead.
+func fallbackAllowed(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && !errors.Is(err, budget.ErrExhausted)
+}
+
+// staleAggregate returns a copy of the aggregate cached under key, from any epoch,
+// if it is at most maxStaleAge old.
+func staleAggregate[T any](ctx context.Context, s *ValidatorService, key string) *T {
+	e, ok := s.aggregateCache.Get(key)
+	if !ok || time.Since(e.fetched) > maxStaleAge {
+		return nil
+	}
+	v, ok := e.value.(*T)
+	if !ok {
+		return nil
+	}
+	cost.AddCacheHit(ctx)
+	cost.AddStaleHit(ctx)
+	c := *v
+	return &c
+}
+
+// sumRewardsHistory adds up the rewards of daily entries into an aggregate over
+// their combined range. The aggregate is not finalized if any entry is not.
+func sumRewardsHistory(entries []models.BeaconchainRewardsHistoryEntry) *models.BeaconchainRewardsAggregateResponse {
+	result := &models.BeaconchainRewardsAggregateResponse{
+		Range: models.BeaconchainResultRange{
+			Slot:      models.BeaconchainSlotRange{Start: entries[0].Range.Slot.Start, End: entries[len(entries)-1].Range.Slot.End},
+			Epoch:     models.BeaconchainEpochRange{Start: entries[0].Range.Epoch.Start, End: entries[len(entries)-1].Range.Epoch.End},
+			Timestamp: models.BeaconchainTimestampRange{Start: entries[0].Range.Timestamp.Start, End: entries[len(entries)-1].Range.Timestamp.End},
+		},
+	}
+	for _, e := range entries {
+		rewards := e.Rewards
+		fillRewardTotals(&rewards)
+		beaconcha.AddRewards(&result.Data, rewards)
+		if !finalized(rewards.Finality) {
+			result.Data.Finality = rewards.Finality
+		}
+	}
+	return result
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestGetValidatorData_AggregateCache(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2)...)
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+
+	// Only the rewards are fetched for a rewards selection
+	req := models.ValidatorRequest{ValidatorIds: []int{1, 2}, Chain: "mainnet", Range: "24h", Fields: []string{"rewards"}}
+	if _, err := s.GetValidatorData(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	// The whole response in the same epoch only fetches the performance, whatever the order of the IDs
+	ctx, counter := cost.WithCounter(context.Background())
+	req = models.ValidatorRequest{ValidatorIds: []int{2, 1}, Chain: "mainnet", Range: "24h"}
+	if _, err := s.GetValidatorData(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if n := fake.Calls(beaconchatest.MethodGetRewardsAggregate); n != 1 {
+		t.Errorf("expected the rewards to be fetched once, got %d calls", n)
+	}
+	if n := fake.Calls(beaconchatest.MethodGetPerformanceAggregate); n != 1 {
+		t.Errorf("expected the performance to be fetched once, got %d calls", n)
+	}
+	if counter.CacheHits() == 0 {
+		t.Error("expected the cached rewards to count as a cache hit")
+	}
+
+	// Other ranges are fetched
+	req.Range = "7d"
+	if _, err := s.GetValidatorData(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if n := fake.Calls(beaconchatest.MethodGetRewardsAggregate); n != 2 {
+		t.Errorf("expected the rewards of another range to be fetched, got %d calls", n)
+	}
+	if stats := s.CacheStats(); stats.Aggregates.Entries != 4 {
+		t.Errorf("expected 4 cached aggregates, got %d", stats.Aggregates.Entries)
+	}
+}
+
+func TestCachedAggregate_Copy(t *testing.T) {
+	fake := beaconchatest.New()
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+
+	rewards, err := s.rewardsAggregate(context.Background(), "mainnet", []int{1}, "24h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewards.Data.Total = "modified" // As when inactivity leaks are excluded
+
+	cached, err := s.rewardsAggregate(context.Background(), "mainnet", []int{1}, "24h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.Data.Total == "modified" {
+		t.Error("expected the cached aggregate to be unaffected by changes of callers")
+	}
+}
+
+func TestGetValidatorData_AggregateFallback(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1)...)
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	req := models.ValidatorRequest{ValidatorIds: []int{1}, Chain: "mainnet", Range: "7d"}
+
+	// Cache the performance, then let it expire with the epoch
+	cached, err := s.GetValidatorData(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := aggregateCacheKey(sectionPerformance, "mainnet", "7d", []int{1})
+	entry, ok := s.aggregateCache.Get(key)
+	if !ok {
+		t.Fatal("expected the performance to be cached")
+	}
+	entry.epoch--
+	s.aggregateCache.Add(key, entry)
+	s.aggregateCache.RemoveFunc(func(k string, _ aggregateCacheEntry) bool { return k != key })
+	s.responseCache.RemoveFunc(func(string, responseCacheEntry) bool { return true })
+
+	fake.SetError(beaconchatest.MethodGetRewardsAggregate, errors.New("upstream error"))
+	fake.SetError(beaconchatest.MethodGetPerformanceAggregate, errors.New("upstream error"))
+	fake.SetDailyRewards("mainnet",
+		models.BeaconchainRewardsHistoryEntry{Rewards: models.BeaconchainRewardsData{
+			TotalReward: "3000", TotalPenalty: "1000",
+			Attestation: models.BeaconchainAttestationRewards{Head: models.BeaconchainRewardBreakdown{Reward: "3000", Penalty: "1000"}},
+		}},
+		models.BeaconchainRewardsHistoryEntry{Rewards: models.BeaconchainRewardsData{
+			Total: "500", TotalReward: "500", TotalPenalty: "0", Finality: "not_finalized",
+			Attestation: models.BeaconchainAttestationRewards{
+				Head:           models.BeaconchainRewardBreakdown{Total: "500", Reward: "500"},
+				InclusionDelay: &models.BeaconchainInclusionDelay{Total: "7"},
+			},
+		}},
+	)
+
+	resp, err := s.GetValidatorData(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := resp.FallbackSections, []string{sectionRewards, sectionPerformance}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected fallback sections %v, got %v", want, got)
+	}
+	rewards := resp.Rewards
+	if rewards.Total != "2500" || rewards.TotalReward != "3500" || rewards.Attestations.Head != "2500" {
+		t.Errorf("expected the daily rewards to be summed, got total %q, reward %q, head %q", rewards.Total, rewards.TotalReward, rewards.Attestations.Head)
+	}
+	if rewards.Attestations.Total != "2507" {
+		t.Errorf("expected the attestation total to include the inclusion delay of the second day, got %q", rewards.Attestations.Total)
+	}
+	if rewards.Finalized == nil || *rewards.Finalized {
+		t.Error("expected the sum of a not finalized day not to be finalized")
+	}
+	if !reflect.DeepEqual(resp.Performance, cached.Performance) {
+		t.Errorf("expected the cached performance %+v, got %+v", cached.Performance, resp.Performance)
+	}
+	if stats := s.CacheStats(); stats.Responses.Entries != 0 {
+		t.Error("expected a response with fallback sections not to be cached")
+	}
+
+	// Without daily rewards the request fails
+	fake.SetError(beaconchatest.MethodGetDailyRewards, errors.New("upstream error"))
+	if _, err := s.GetValidatorData(context.Background(), req); err == nil {
+		t.Error("expected an error without any source of the rewards")
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"cmp"
+	"math/big"
+	"slices"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/anomaly"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// epochsPerSlashingsVector is the number of epochs between a slashing and the
+// earliest withdrawable epoch of the slashed validator.
+const epochsPerSlashingsVector = 8192
+
+// excludeMassSlashings removes validators slashed during a known mass slashing from the
+// aggregate IDs.
+func (s *ValidatorService) excludeMassSlashings(chain string, validatorIds []int, validators []models.BeaconchainValidatorData) ([]int, *models.AnomalyReport) {
+	report := &models.AnomalyReport{
+		Windows:                       []string{},
+		ExcludedValidators:            []int{},
+		InactivityLeakPenaltyExcluded: "0",
+	}
+
+	excluded := make(map[int]bool)
+	for _, v := range validators {
+		if !v.Slashed || v.Validator.Index == nil {
+			continue
+		}
+		epoch, ok := slashingEpoch(v.LifeCycleEpochs)
+		if !ok {
+			continue
+		}
+		w, ok := s.anomalyFilter.MassSlashing(chain, epoch)
+		if !ok {
+			continue
+		}
+		excluded[*v.Validator.Index] = true
+		if !slices.Contains(report.Windows, w.Name) {
+			report.Windows = append(report.Windows, w.Name)
+		}
+	}
+
+	if len(excluded) == 0 {
+		return validatorIds, report
+	}
+
+	aggregateIds := make([]int, 0, len(validatorIds)-len(excluded))
+	for _, id := range validatorIds {
+		if excluded[id] {
+			report.ExcludedValidators = append(report.ExcludedValidators, id)
+			continue
+		}
+		aggregateIds = append(aggregateIds, id)
+	}
+
+	return aggregateIds, report
+}
+
+// slashingEpoch estimates the epoch a slashed validator was slashed in. Slashing sets
+// the withdrawable epoch to EPOCHS_PER_SLASHINGS_VECTOR epochs after the slashing,
+// unless the exit queue pushes it further, and initiates the exit, so the earlier of
+// the two is the slashing epoch up to the exit queue delay. It is only an estimate:
+// when the exit was queued before the slashing, the exit epoch may precede it.
+func slashingEpoch(epochs models.BeaconchainLifeCycleEpochs) (int64, bool) {
+	var epoch int64
+	ok := false
+	if epochs.Withdrawable != nil {
+		epoch, ok = *epochs.Withdrawable-epochsPerSlashingsVector, true
+	}
+	if epochs.Exit != nil && (!ok || *epochs.Exit < epoch) {
+		epoch, ok = *epochs.Exit, true
+	}
+	return epoch, ok
+}
+
+// excludeInactivityLeaks adds inactivity leak penalties back to the rewards for the
+// part of the returned range that overlaps known inactivity leaks, so the aggregate
+// reflects the validators' own performance rather than the network's failure to
+// finalize. The aggregate does not tell when the penalty was incurred, so it is
+// spread evenly over the epochs of the range.
+func (s *ValidatorService) excludeInactivityLeaks(chain string, rewards *models.BeaconchainRewardsAggregateResponse, report *models.AnomalyReport) {
+	if rewards == nil || report == nil || rewards.Range.Epoch.End < rewards.Range.Epoch.Start {
+		return
+	}
+
+	leaks := s.anomalyFilter.InactivityLeaks(chain, rewards.Range.Epoch.Start, rewards.Range.Epoch.End)
+	if len(leaks) == 0 {
+		return
+	}
+
+	start, end := rewards.Range.Epoch.Start, rewards.Range.Epoch.End
+	total := amount.ParseOrZero(rewards.Data.Attestation.InactivityLeakPenalty)
+	penalty := total
+	if overlap := leakEpochs(leaks, start, end); overlap < end-start+1 {
+		scaled := new(big.Int).Mul(total.Big(), big.NewInt(overlap))
+		penalty = amount.FromBig(scaled).Div(end - start + 1)
+	}
+	if penalty.IsZero() {
+		return
+	}
+
+	for _, w := range leaks {
+		if !slices.Contains(report.Windows, w.Name) {
+			report.Windows = append(report.Windows, w.Name)
+		}
+	}
+
+	data := &rewards.Data
+	data.Total = amount.ParseOrZero(data.Total).Add(penalty).String()
+	data.TotalPenalty = amount.ParseOrZero(data.TotalPenalty).Sub(penalty).String()
+	data.Attestation.Total = amount.ParseOrZero(data.Attestation.Total).Add(penalty).String()
+	data.Attestation.InactivityLeakPenalty = total.Sub(penalty).String()
+
+	report.InactivityLeakPenaltyExcluded = penalty.String()
+}
+
+// leakEpochs returns how many epochs of [start, end] lie within any of the leaks.
+func leakEpochs(leaks []anomaly.Window, start, end int64) int64 {
+	var count int64
+	next := start // First epoch not counted yet
+	for _, w := range sortedByStart(leaks) {
+		from, to := max(w.StartEpoch, next), min(w.EndEpoch, end)
+		if from > to {
+			continue
+		}
+		count += to - from + 1
+		next = to + 1
+	}
+	return count
+}
+
+// sortedByStart returns a copy of windows ordered by start epoch.
+func sortedByStart(windows []anomaly.Window) []anomaly.Window {
+	sorted := slices.Clone(windows)
+	slices.SortFunc(sorted, func(a, b anomaly.Window) int {
+		return cmp.Compare(a.StartEpoch, b.StartEpoch)
+	})
+	return sorted
+}
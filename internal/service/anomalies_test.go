@@ -0,0 +1,129 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/anomaly"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func newAnomalyTestService(t *testing.T) *ValidatorService {
+	t.Helper()
+	filter, err := anomaly.NewFilter([]anomaly.Window{
+		{Chain: "hoodi", Name: "mass-slashing", Kind: anomaly.KindMassSlashing, StartEpoch: 100, EndEpoch: 110},
+		{Chain: "hoodi", Name: "leak", Kind: anomaly.KindInactivityLeak, StartEpoch: 200, EndEpoch: 300},
+	})
+	if err != nil {
+		t.Fatalf("NewFilter failed: %v", err)
+	}
+	return NewValidatorService(nil, filter, nil, nil, nil, nil)
+}
+
+func TestExcludeMassSlashings(t *testing.T) {
+	s := newAnomalyTestService(t)
+
+	idx := func(i int) *int { return &i }
+	epoch := func(e int64) *int64 { return &e }
+
+	validators := []models.BeaconchainValidatorData{
+		// Slashed at epoch 105 and exiting after a long exit queue
+		{Validator: models.BeaconchainValidatorInfo{Index: idx(1)}, Slashed: true, LifeCycleEpochs: models.BeaconchainLifeCycleEpochs{Exit: epoch(600), Withdrawable: epoch(105 + 8192)}},
+		{Validator: models.BeaconchainValidatorInfo{Index: idx(2)}, Slashed: true, LifeCycleEpochs: models.BeaconchainLifeCycleEpochs{Exit: epoch(500), Withdrawable: epoch(500 + 8192)}},
+		{Validator: models.BeaconchainValidatorInfo{Index: idx(3)}, Slashed: false},
+	}
+
+	ids, report := s.excludeMassSlashings("hoodi", []int{1, 2, 3}, validators)
+	if !reflect.DeepEqual(ids, []int{2, 3}) {
+		t.Errorf("expected aggregate IDs [2 3], got %v", ids)
+	}
+	if !reflect.DeepEqual(report.ExcludedValidators, []int{1}) {
+		t.Errorf("expected excluded validators [1], got %v", report.ExcludedValidators)
+	}
+	if !reflect.DeepEqual(report.Windows, []string{"mass-slashing"}) {
+		t.Errorf("expected windows [mass-slashing], got %v", report.Windows)
+	}
+
+	// Windows only apply to their own chain
+	ids, report = s.excludeMassSlashings("mainnet", []int{1, 2, 3}, validators)
+	if len(ids) != 3 || len(report.ExcludedValidators) != 0 {
+		t.Errorf("expected no exclusions on mainnet, got ids %v, excluded %v", ids, report.ExcludedValidators)
+	}
+}
+
+func TestSlashingEpoch(t *testing.T) {
+	epoch := func(e int64) *int64 { return &e }
+
+	tests := []struct {
+		name   string
+		epochs models.BeaconchainLifeCycleEpochs
+		want   int64
+		ok     bool
+	}{
+		{"short exit queue", models.BeaconchainLifeCycleEpochs{Exit: epoch(104), Withdrawable: epoch(100 + 8192)}, 100, true},
+		{"long exit queue", models.BeaconchainLifeCycleEpochs{Exit: epoch(9000), Withdrawable: epoch(9256)}, 1064, true},
+		{"exit only", models.BeaconchainLifeCycleEpochs{Exit: epoch(105)}, 105, true},
+		{"unknown", models.BeaconchainLifeCycleEpochs{}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := slashingEpoch(tt.epochs)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("expected %d, %v, got %d, %v", tt.want, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestExcludeInactivityLeaks(t *testing.T) {
+	s := newAnomalyTestService(t)
+
+	tests := []struct {
+		name       string
+		start, end int64
+		excluded   string // Leak penalty added back, out of 300
+		windows    []string
+	}{
+		{"within the leak", 220, 280, "300", []string{"leak"}},
+		// Epochs 250-300 of 250-349 overlap the leak
+		{"partial overlap", 250, 349, "153", []string{"leak"}},
+		{"no overlap", 301, 400, "0", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rewards := &models.BeaconchainRewardsAggregateResponse{
+				Data: models.BeaconchainRewardsData{
+					Total:        "700",
+					TotalPenalty: "500",
+					Attestation: models.BeaconchainAttestationRewards{
+						Total:                 "600",
+						InactivityLeakPenalty: "300",
+					},
+				},
+				Range: models.BeaconchainResultRange{
+					Epoch: models.BeaconchainEpochRange{Start: tt.start, End: tt.end},
+				},
+			}
+			report := &models.AnomalyReport{Windows: []string{}, InactivityLeakPenaltyExcluded: "0"}
+
+			s.excludeInactivityLeaks("hoodi", rewards, report)
+
+			excluded := amount.ParseOrZero(tt.excluded)
+			want := models.BeaconchainRewardsData{
+				Total:        amount.Wei(700).Add(excluded).String(),
+				TotalPenalty: amount.Wei(500).Sub(excluded).String(),
+				Attestation: models.BeaconchainAttestationRewards{
+					Total:                 amount.Wei(600).Add(excluded).String(),
+					InactivityLeakPenalty: amount.Wei(300).Sub(excluded).String(),
+				},
+			}
+			if !reflect.DeepEqual(rewards.Data, want) {
+				t.Errorf("expected adjusted rewards %+v, got %+v", want, rewards.Data)
+			}
+			if report.InactivityLeakPenaltyExcluded != tt.excluded || !reflect.DeepEqual(report.Windows, tt.windows) {
+				t.Errorf("unexpected report: %+v", report)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// ErrHistoryDisabled is returned by queries that need the history store when none is configured.
+var ErrHistoryDisabled = errors.New("history is not enabled")
+
+// GetAttestationTrend returns the attestation effectiveness recorded for exactly the
+// given set of validators over the last days, averaged per hour or day bucket.
+// Only samples fetched with the given evaluation range are included, since samples
+// of different ranges are not comparable.
+func (s *ValidatorService) GetAttestationTrend(ctx context.Context, chain string, validatorIds []int, evalRange, bucket string, days int) (models.AttestationTrendResponse, error) {
+	if s.store == nil {
+		return models.AttestationTrendResponse{}, ErrHistoryDisabled
+	}
+
+	now := time.Now().UTC()
+	samples, err := s.store.AttestationSamples(ctx, store.Query{
+		Chain:            chain,
+		ValidatorIndices: validatorIds,
+		From:             now.AddDate(0, 0, -days),
+	})
+	if err != nil {
+		return models.AttestationTrendResponse{}, fmt.Errorf("load attestation samples: %w", err)
+	}
+
+	return buildAttestationTrend(samples, evalRange, bucket), nil
+}
+
+// buildAttestationTrend averages the samples of evalRange per bucket. Samples must be ordered by time.
+func buildAttestationTrend(samples []store.AttestationSample, evalRange, bucket string) models.AttestationTrendResponse {
+	size := time.Hour
+	if bucket == "day" {
+		size = 24 * time.Hour
+	}
+
+	response := models.AttestationTrendResponse{
+		Range:   evalRange,
+		Bucket:  bucket,
+		Buckets: []models.AttestationTrendBucket{},
+	}
+
+	var current *models.AttestationTrendBucket
+	for _, sample := range samples {
+		if sample.Range != evalRange || sample.Assigned == 0 {
+			continue
+		}
+
+		start := sample.Time.UTC().Truncate(size)
+		if current == nil || !current.Start.Equal(start) {
+			response.Buckets = append(response.Buckets, models.AttestationTrendBucket{Start: start})
+			current = &response.Buckets[len(response.Buckets)-1]
+		}
+
+		// Keep running means so each sample weighs the same
+		current.Samples++
+		n := float64(current.Samples)
+		effectiveness := float64(sample.Included) / float64(sample.Assigned)
+		current.Effectiveness += (effectiveness - current.Effectiveness) / n
+		current.AvgInclusionDelay += (sample.AvgInclusionDelay - current.AvgInclusionDelay) / n
+	}
+
+	return response
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+func TestBuildAttestationTrend(t *testing.T) {
+	t0 := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)
+
+	samples := []store.AttestationSample{
+		{Time: t0.Add(5 * time.Minute), Range: "24h", Included: 100, Assigned: 100, AvgInclusionDelay: 1.0},
+		{Time: t0.Add(40 * time.Minute), Range: "24h", Included: 90, Assigned: 100, AvgInclusionDelay: 1.2},
+		// Other ranges are not comparable and are skipped
+		{Time: t0.Add(50 * time.Minute), Range: "7d", Included: 10, Assigned: 100, AvgInclusionDelay: 3.0},
+		{Time: t0.Add(3 * time.Hour), Range: "24h", Included: 80, Assigned: 100, AvgInclusionDelay: 1.5},
+	}
+
+	hourly := buildAttestationTrend(samples, "24h", "hour")
+	if len(hourly.Buckets) != 2 {
+		t.Fatalf("expected 2 hourly buckets, got %d: %+v", len(hourly.Buckets), hourly.Buckets)
+	}
+	first := hourly.Buckets[0]
+	if !first.Start.Equal(t0) || first.Samples != 2 || math.Abs(first.Effectiveness-0.95) > 1e-9 || math.Abs(first.AvgInclusionDelay-1.1) > 1e-9 {
+		t.Errorf("unexpected first bucket: %+v", first)
+	}
+	if !hourly.Buckets[1].Start.Equal(t0.Add(3*time.Hour)) || hourly.Buckets[1].Effectiveness != 0.8 {
+		t.Errorf("unexpected second bucket: %+v", hourly.Buckets[1])
+	}
+
+	daily := buildAttestationTrend(samples, "24h", "day")
+	if len(daily.Buckets) != 1 || daily.Buckets[0].Samples != 3 || math.Abs(daily.Buckets[0].Effectiveness-0.9) > 1e-9 {
+		t.Errorf("unexpected daily buckets: %+v", daily.Buckets)
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// buildIncomeSplit attributes the net rewards to consensus issuance, priority fees
+// and MEV. The aggregate only reports EL rewards as one sum, so the proposed blocks
+// of the window are fetched to tell locally built blocks from relayed ones. That
+// call is skipped when no EL rewards were earned. Returns nil if the blocks cannot
+// be fetched, or if the rewards are missing.
+func (s *ValidatorService) buildIncomeSplit(ctx context.Context, chain string, validatorIds []int, evalRange string, rewards models.ValidatorRewards) *models.IncomeSplit {
+	if rewards.Total == "" {
+		return nil
+	}
+
+	el := amount.ParseOrZero(rewards.Proposals.ExecutionLayerReward)
+	split := &models.IncomeSplit{
+		Consensus:    amount.ParseOrZero(rewards.Total).Sub(el).String(),
+		PriorityFees: "0",
+		MEV:          "0",
+	}
+	if el.IsZero() {
+		return split
+	}
+
+	var blocks []models.BeaconchainBlock
+	var err error
+	if from, to, ok := parseWindowRange(evalRange); ok {
+		blocks, err = s.windowBlocks(ctx, chain, validatorIds, from, to, time.Now())
+	} else {
+		blocks, err = s.rangeBlocks(ctx, chain, validatorIds, evalRange)
+	}
+	if err != nil {
+		slog.Warn("failed to fetch blocks for income split", "chain", chain, "error", err)
+		return nil
+	}
+
+	var priorityFees, mev amount.Amount
+	for _, b := range blocks {
+		if b.Status != "proposed" {
+			continue
+		}
+		split.Blocks++
+		if b.Relay != nil {
+			split.MEVBlocks++
+		}
+		priorityFees = priorityFees.Add(amount.ParseOrZero(b.ExecutionReward))
+		mev = mev.Add(amount.ParseOrZero(b.MevReward))
+	}
+	split.PriorityFees = priorityFees.String()
+	split.MEV = mev.String()
+	return split
+}
+
+// rangeBlocks returns the block proposal duties of the validators over evalRange,
+// from the cache if they were fetched during the current epoch. The income split
+// and the block value luck share them.
+func (s *ValidatorService) rangeBlocks(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainBlock, error) {
+	blocks, err := cachedAggregate(ctx, s, aggregateCacheKey("blocks", chain, evalRange, validatorIds), chain, func() (*[]models.BeaconchainBlock, error) {
+		blocks, err := s.beaconchainClient.GetBlocks(ctx, chain, validatorIds, evalRange)
+		if err != nil {
+			return nil, err
+		}
+		return &blocks, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return *blocks, nil
+}
+
+// GetBlocks returns the block proposal duties of the validators over evalRange,
+// proposed, missed or orphaned.
+func (s *ValidatorService) GetBlocks(ctx context.Context, chain string, validatorIds []int, evalRange string) ([]models.BeaconchainBlock, error) {
+	release, err := s.acquireQueueSlot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("queue wait: %w", err)
+	}
+	defer release()
+
+	blocks, err := s.beaconchainClient.GetBlocks(ctx, chain, validatorIds, evalRange)
+	if err != nil {
+		return nil, fmt.Errorf("fetch blocks: %w", err)
+	}
+	return blocks, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestIncomeSplit(t *testing.T) {
+	tests := []struct {
+		name      string
+		elReward  string
+		blocks    []models.BeaconchainBlock
+		want      models.IncomeSplit
+		wantCalls int
+	}{
+		{
+			name:     "no proposals",
+			elReward: "0",
+			want:     models.IncomeSplit{Consensus: "1000", PriorityFees: "0", MEV: "0"},
+		},
+		{
+			name:     "local and relayed blocks",
+			elReward: "600",
+			blocks: []models.BeaconchainBlock{
+				beaconchatest.Block(1, 100, "100"),
+				beaconchatest.RelayedBlock(2, 200, "500", "flashbots"),
+				func() models.BeaconchainBlock {
+					b := beaconchatest.Block(1, 300, "0")
+					b.Status = "missed"
+					return b
+				}(),
+				beaconchatest.Block(9, 400, "700"), // Not requested
+			},
+			want:      models.IncomeSplit{Consensus: "400", PriorityFees: "100", MEV: "500", Blocks: 2, MEVBlocks: 1},
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := beaconchatest.New()
+			fake.AddValidators("mainnet", beaconchatest.Validator(1).Build(), beaconchatest.Validator(2).Build())
+			fake.SetRewards("mainnet", "24h", models.BeaconchainRewardsAggregateResponse{
+				Data: models.BeaconchainRewardsData{
+					Total:    "1000",
+					Proposal: models.BeaconchainProposalRewards{ExecutionLayerReward: tt.elReward},
+				},
+			})
+			fake.AddBlocks("mainnet", tt.blocks...)
+
+			s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+			resp, err := s.GetValidatorData(context.Background(), models.ValidatorRequest{ValidatorIds: []int{1, 2}, Chain: "mainnet", Range: "24h"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Rewards.Income == nil {
+				t.Fatal("expected an income split")
+			}
+			if *resp.Rewards.Income != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, *resp.Rewards.Income)
+			}
+			if got := fake.Calls(beaconchatest.MethodGetBlocks); got != tt.wantCalls {
+				t.Errorf("expected %d blocks calls, got %d", tt.wantCalls, got)
+			}
+		})
+	}
+}
+
+func TestIncomeSplit_BlocksUnavailable(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validator(1).Build())
+	fake.SetRewards("mainnet", "24h", models.BeaconchainRewardsAggregateResponse{
+		Data: models.BeaconchainRewardsData{
+			Total:    "1000",
+			Proposal: models.BeaconchainProposalRewards{ExecutionLayerReward: "600"},
+		},
+	})
+	fake.FailNext(beaconchatest.MethodGetBlocks, errors.New("upstream down"))
+
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	resp, err := s.GetValidatorData(context.Background(), models.ValidatorRequest{ValidatorIds: []int{1}, Chain: "mainnet", Range: "24h"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Rewards.Income != nil {
+		t.Errorf("expected no income split, got %+v", *resp.Rewards.Income)
+	}
+	if resp.Rewards.Total != "1000" {
+		t.Errorf("expected the rewards to be served, got %q", resp.Rewards.Total)
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/budget"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// errBackfillBudget stops a backfill once the upstream budget runs low.
+var errBackfillBudget = errors.New("upstream budget low")
+
+// RunBackfill backfills the history of the portfolio validators over the last
+// days UTC days immediately and then every interval until the context is
+// canceled, so history of validators added to a portfolio is not empty for its
+// first weeks. See Backfill.
+func (s *ValidatorService) RunBackfill(ctx context.Context, days int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	tried := make(map[string]bool)
+	for {
+		s.backfill(ctx, days, time.Now(), tried)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// backfill records a snapshot of each portfolio validator for every day of the
+// last days UTC days, before today, without one. Its balance is that at the end
+// of the last epoch of the day, from the upstream balance history. Each upstream
+// call waits until no request is queued, and the backfill stops once the budget
+// runs low, to continue on a later run. Days in tried, which upstream had no
+// balance for, are not requested again.
+func (s *ValidatorService) backfill(ctx context.Context, days int, now time.Time, tried map[string]bool) {
+	if s.store == nil || days <= 0 {
+		return
+	}
+
+	byChain := make(map[string]map[int]bool)
+	for _, p := range s.portfolios.All() {
+		if byChain[p.Chain] == nil {
+			byChain[p.Chain] = make(map[int]bool)
+		}
+		for _, id := range p.ValidatorIds {
+			byChain[p.Chain][id] = true
+		}
+	}
+	chains := make([]string, 0, len(byChain))
+	for chain := range byChain {
+		chains = append(chains, chain)
+	}
+	sort.Strings(chains)
+
+	for _, chain := range chains {
+		ids := make([]int, 0, len(byChain[chain]))
+		for id := range byChain[chain] {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+
+		recorded, err := s.backfillChain(ctx, chain, ids, days, now, tried)
+		if recorded > 0 {
+			slog.Info("backfilled history", "chain", chain, "snapshots", recorded)
+		}
+		if errors.Is(err, errBackfillBudget) || errors.Is(err, budget.ErrExhausted) {
+			slog.Info("backfill paused to save upstream credits", "chain", chain)
+			return
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("failed to backfill history", "chain", chain, "error", err)
+		}
+	}
+}
+
+// backfillChain backfills the validators ids of chain and returns the number of
+// snapshots recorded.
+func (s *ValidatorService) backfillChain(ctx context.Context, chain string, ids []int, days int, now time.Time, tried map[string]bool) (int, error) {
+	spec, err := chainspec.ForChain(chain)
+	if err != nil {
+		return 0, err
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -days)
+
+	existing, err := s.store.Snapshots(ctx, store.Query{Chain: chain, ValidatorIndices: ids, From: from})
+	if err != nil {
+		return 0, fmt.Errorf("read history: %w", err)
+	}
+	covered := make(map[string]bool)
+	for _, snap := range existing {
+		covered[backfillKey(chain, snap.ValidatorIndex, snap.Time.UTC().Truncate(24*time.Hour))] = true
+	}
+	var missing []int
+	for _, id := range ids {
+		for day := from; day.Before(today); day = day.AddDate(0, 0, 1) {
+			if key := backfillKey(chain, id, day); !covered[key] && !tried[key] {
+				missing = append(missing, id)
+				break
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return 0, nil
+	}
+
+	// The lifecycle epochs tell the days the validators were active
+	var validators []models.BeaconchainValidatorData
+	err = s.backfillCall(ctx, func() (err error) {
+		validators, err = s.beaconchainClient.GetValidators(ctx, chain, missing)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("fetch validators: %w", err)
+	}
+
+	recorded := 0
+	for _, v := range validators {
+		if v.Validator.Index == nil || !isSet(v.LifeCycleEpochs.Activation) {
+			continue
+		}
+		var snapshots []store.Snapshot
+		for day := from; day.Before(today); day = day.AddDate(0, 0, 1) {
+			key := backfillKey(chain, *v.Validator.Index, day)
+			epoch, err := spec.LastCompletedEpoch(day.AddDate(0, 0, 1).Add(-time.Second))
+			if covered[key] || tried[key] || err != nil || epoch < *v.LifeCycleEpochs.Activation {
+				continue
+			}
+
+			var entries []models.BeaconchainBalanceHistoryEntry
+			err = s.backfillCall(ctx, func() (err error) {
+				entries, err = s.beaconchainClient.GetBalanceHistory(ctx, chain, *v.Validator.Index, epoch, epoch)
+				return err
+			})
+			if err != nil {
+				if recordErr := s.store.BackfillSnapshots(ctx, snapshots); recordErr != nil {
+					return recorded, recordErr
+				}
+				return recorded + len(snapshots), fmt.Errorf("fetch balance history: %w", err)
+			}
+			tried[key] = true
+			for _, e := range entries {
+				if e.Epoch == epoch {
+					snapshots = append(snapshots, backfilledSnapshot(spec, chain, v, e))
+				}
+			}
+		}
+		if err := s.store.BackfillSnapshots(ctx, snapshots); err != nil {
+			return recorded, fmt.Errorf("record history: %w", err)
+		}
+		recorded += len(snapshots)
+	}
+	return recorded, nil
+}
+
+// backfillCall makes an upstream call once no request is queued and the budget
+// is not running low.
+func (s *ValidatorService) backfillCall(ctx context.Context, call func() error) error {
+	if s.budget.Low() {
+		return errBackfillBudget
+	}
+	release, err := s.acquireIdleQueueSlot(ctx)
+	if err != nil {
+		return fmt.Errorf("queue wait: %w", err)
+	}
+	defer release()
+	return call()
+}
+
+// backfilledSnapshot returns the snapshot of v at the end of the epoch of e. The
+// validator is assumed online until its exit epoch and exited after it.
+func backfilledSnapshot(spec chainspec.Spec, chain string, v models.BeaconchainValidatorData, e models.BeaconchainBalanceHistoryEntry) store.Snapshot {
+	var exitEpoch int64
+	if v.LifeCycleEpochs.Exit != nil {
+		exitEpoch = *v.LifeCycleEpochs.Exit
+	}
+	exited := isSet(v.LifeCycleEpochs.Exit) && e.Epoch >= exitEpoch
+	status := "active_online"
+	if exited {
+		status = "exited"
+	}
+	return store.Snapshot{
+		Time:             spec.EpochStart(e.Epoch + 1).UTC(),
+		Chain:            chain,
+		ValidatorIndex:   *v.Validator.Index,
+		Status:           status,
+		Online:           !exited,
+		Slashed:          v.Slashed && exited, // Slashed validators are exited soon after
+		CurrentBalance:   e.Balance,
+		EffectiveBalance: e.EffectiveBalance,
+		ActivationEpoch:  *v.LifeCycleEpochs.Activation,
+		ExitEpoch:        exitEpoch,
+		Backfilled:       true,
+	}
+}
+
+// backfillKey identifies the snapshot of a validator on a UTC day.
+func backfillKey(chain string, index int, day time.Time) string {
+	return chain + "/" + strconv.Itoa(index) + "/" + day.Format(dayLayout)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/budget"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+func TestBackfill(t *testing.T) {
+	ctx := context.Background()
+	spec, _ := chainspec.ForChain("mainnet")
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	today := now.Truncate(24 * time.Hour)
+	// dayEpoch returns the last epoch of the day days before today
+	dayEpoch := func(days int) int64 {
+		epoch, _ := spec.LastCompletedEpoch(today.AddDate(0, 0, 1-days).Add(-time.Second))
+		return epoch
+	}
+	balance := func(epoch int64, wei string) models.BeaconchainBalanceHistoryEntry {
+		return models.BeaconchainBalanceHistoryEntry{Epoch: epoch, Balance: wei, EffectiveBalance: "32000000000000000000"}
+	}
+
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Build(),
+		beaconchatest.Validator(2).Activation(dayEpoch(2)).Build(), // Activated the day before yesterday
+		beaconchatest.Validator(3).Exit(dayEpoch(2)).Build(),
+	)
+	fake.AddBalanceHistory("mainnet", 1, balance(dayEpoch(3), "32001000000000000000"), balance(dayEpoch(1), "32003000000000000000")) // None 2 days ago
+	fake.AddBalanceHistory("mainnet", 2, balance(dayEpoch(2), "32000000000000000000"), balance(dayEpoch(1), "32000000000000000000"))
+	fake.AddBalanceHistory("mainnet", 3, balance(dayEpoch(3), "32002000000000000000"), balance(dayEpoch(2), "32002000000000000000"), balance(dayEpoch(1), "0"))
+
+	st, err := store.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Validator 3 was fetched yesterday
+	fetched := store.Snapshot{Time: today.Add(-time.Hour), Chain: "mainnet", ValidatorIndex: 3, Status: "exited"}
+	if err := st.RecordSnapshots(ctx, []store.Snapshot{fetched}); err != nil {
+		t.Fatal(err)
+	}
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{
+		{Name: "a", Chain: "mainnet", ValidatorIds: []int{1, 2}},
+		{Name: "b", Chain: "mainnet", ValidatorIds: []int{2, 3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewValidatorService(fake, nil, st, nil, portfolios, nil)
+
+	tried := make(map[string]bool)
+	s.backfill(ctx, 3, now, tried)
+
+	snapshots, err := st.Snapshots(ctx, store.Query{Chain: "mainnet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[int][]store.Snapshot)
+	for _, snap := range snapshots {
+		got[snap.ValidatorIndex] = append(got[snap.ValidatorIndex], snap)
+	}
+	if v1 := got[1]; len(v1) != 2 || v1[0].CurrentBalance != "32001000000000000000" || !v1[0].Backfilled || v1[0].Status != "active_online" || !v1[0].Online {
+		t.Errorf("expected validator 1 backfilled on the days with balances, got %+v", v1)
+	}
+	if v2 := got[2]; len(v2) != 2 || !v2[0].Time.Equal(spec.EpochStart(dayEpoch(2)+1)) {
+		t.Errorf("expected validator 2 backfilled from its activation, got %+v", v2)
+	}
+	if v3 := got[3]; len(v3) != 3 || v3[0].Status != "active_online" || v3[1].Status != "exited" || v3[1].Online || v3[2].Backfilled {
+		t.Errorf("expected validator 3 backfilled until its fetched snapshot, got %+v", v3)
+	}
+	if events, _ := st.Events(ctx, store.Query{}); len(events) != 0 {
+		t.Errorf("expected no events from backfilling, got %+v", events)
+	}
+	// 3 days of validator 1, 2 of validator 2 and 2 of validator 3
+	if calls := fake.Calls(beaconchatest.MethodGetBalanceHistory); calls != 7 {
+		t.Errorf("expected 7 balance history calls, got %d", calls)
+	}
+
+	// Days backfilled or without balances are not requested again
+	s.backfill(ctx, 3, now, tried)
+	if calls := fake.Calls(beaconchatest.MethodGetBalanceHistory); calls != 7 {
+		t.Errorf("expected no more balance history calls, got %d", calls)
+	}
+}
+
+func TestBackfill_BudgetLow(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validator(1).Build())
+	st, err := store.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{{Name: "a", Chain: "mainnet", ValidatorIds: []int{1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	credits := budget.NewManager(10, 0.5, nil)
+	for range 6 {
+		credits.Charge("validators")
+	}
+	s := NewValidatorService(fake, nil, st, nil, portfolios, nil)
+	s.SetBudget(credits)
+
+	s.backfill(context.Background(), 30, time.Now(), make(map[string]bool))
+	if calls := fake.Calls(beaconchatest.MethodGetValidators) + fake.Calls(beaconchatest.MethodGetBalanceHistory); calls != 0 {
+		t.Errorf("expected no upstream calls with the budget low, got %d", calls)
+	}
+}
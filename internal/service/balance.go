@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// balanceCacheEntry is a balance history computed up to a given epoch.
+type balanceCacheEntry struct {
+	endEpoch int64
+	response models.BalanceHistoryResponse
+}
+
+// GetBalanceHistory returns the validator's balance at the end of each of the last
+// epochs completed epochs. Results are cached until the next epoch completes, so
+// repeated requests for the same validator do not reach the upstream API.
+func (s *ValidatorService) GetBalanceHistory(ctx context.Context, chain string, validatorId, epochs int) (models.BalanceHistoryResponse, error) {
+	endEpoch, err := chainspec.LastCompletedEpoch(chain, time.Now())
+	if err != nil {
+		return models.BalanceHistoryResponse{}, err
+	}
+	startEpoch := max(endEpoch-int64(epochs)+1, 0)
+
+	key := chain + "/" + strconv.Itoa(validatorId) + "/" + strconv.Itoa(epochs)
+
+	cached, ok := s.balanceCache.Get(key)
+	if ok && cached.endEpoch == endEpoch {
+		cost.AddCacheHit(ctx)
+		return cached.response, nil
+	}
+
+	release, err := s.acquireQueueSlot(ctx)
+	if err != nil {
+		return models.BalanceHistoryResponse{}, fmt.Errorf("queue wait: %w", err)
+	}
+	defer release()
+
+	slog.Debug("fetching balance history", "validator", validatorId, "startEpoch", startEpoch, "endEpoch", endEpoch)
+
+	entries, err := s.beaconchainClient.GetBalanceHistory(ctx, chain, validatorId, startEpoch, endEpoch)
+	if err != nil {
+		return models.BalanceHistoryResponse{}, fmt.Errorf("fetch balance history: %w", err)
+	}
+
+	response := buildBalanceHistory(chain, validatorId, entries)
+
+	// Entries computed before the latest epoch are never served again
+	s.balanceCache.RemoveFunc(func(_ string, e balanceCacheEntry) bool { return e.endEpoch < endEpoch })
+	s.balanceCache.Add(key, balanceCacheEntry{endEpoch: endEpoch, response: response})
+
+	return response, nil
+}
+
+// buildBalanceHistory converts the upstream entries into a response ordered by epoch.
+func buildBalanceHistory(chain string, validatorId int, entries []models.BeaconchainBalanceHistoryEntry) models.BalanceHistoryResponse {
+	balances := make([]models.EpochBalance, 0, len(entries))
+	for _, e := range entries {
+		balances = append(balances, models.EpochBalance{
+			Epoch:            e.Epoch,
+			Balance:          e.Balance,
+			EffectiveBalance: e.EffectiveBalance,
+		})
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Epoch < balances[j].Epoch })
+
+	return models.BalanceHistoryResponse{
+		ValidatorIndex: validatorId,
+		Chain:          chain,
+		Epochs:         balances,
+	}
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestBuildBalanceHistory(t *testing.T) {
+	entries := []models.BeaconchainBalanceHistoryEntry{
+		{Epoch: 11, Balance: "32000000002000000000", EffectiveBalance: "32000000000000000000"},
+		{Epoch: 10, Balance: "32000000001000000000", EffectiveBalance: "32000000000000000000"},
+	}
+
+	got := buildBalanceHistory("mainnet", 7, entries)
+
+	if got.ValidatorIndex != 7 || got.Chain != "mainnet" {
+		t.Errorf("unexpected validator: %+v", got)
+	}
+	if len(got.Epochs) != 2 || got.Epochs[0].Epoch != 10 || got.Epochs[1].Balance != "32000000002000000000" {
+		t.Errorf("expected epochs ordered oldest first, got %+v", got.Epochs)
+	}
+}
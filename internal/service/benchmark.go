@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// networkCacheTTL is how long network averages are cached. They move slowly, so an
+// hour-old average is still a fair comparison and costs one upstream call per hour.
+const networkCacheTTL = time.Hour
+
+// rangeDays is the length of the evaluation ranges that can be annualized.
+var rangeDays = map[string]float64{"24h": 1, "7d": 7, "30d": 30, "90d": 90}
+
+// networkCacheEntry holds the network averages of a chain and range.
+type networkCacheEntry struct {
+	fetched time.Time
+	values  models.BenchmarkValues
+}
+
+// buildBenchmark compares response with the network averages over the range of
+// req. Network averages are best effort: without them there is no benchmark.
+func (s *ValidatorService) buildBenchmark(ctx context.Context, req models.ValidatorRequest, response models.ValidatorResponse) *models.Benchmark {
+	network, ok := s.networkAverages(ctx, req.Chain, req.Range)
+	if !ok {
+		return nil
+	}
+
+	return &models.Benchmark{
+		Fleet: models.BenchmarkValues{
+			Beaconscore:              response.Performance.Beaconscore,
+			AttestationEffectiveness: ratio(response.Performance.Attestations.Included, response.Performance.Attestations.Assigned),
+			APR:                      fleetAPR(req.Range, response),
+		},
+		Network: network,
+	}
+}
+
+// networkAverages returns the averages of the network over evalRange, from cache
+// when fetched within networkCacheTTL. Near the end of the credit budget an older
+// entry is preferred over fetching.
+func (s *ValidatorService) networkAverages(ctx context.Context, chain, evalRange string) (models.BenchmarkValues, bool) {
+	key := chain + "/" + evalRange
+	s.networkMu.Lock()
+	cached, ok := s.networkCache[key]
+	s.networkMu.Unlock()
+	if ok && (time.Since(cached.fetched) < networkCacheTTL || s.budget.Low()) {
+		cost.AddCacheHit(ctx)
+		return cached.values, true
+	}
+
+	performance, err := s.beaconchainClient.GetNetworkPerformance(ctx, chain, evalRange)
+	if err != nil {
+		slog.Warn("failed to fetch network performance", "chain", chain, "range", evalRange, "error", err)
+		return cached.values, ok
+	}
+
+	attestations := performance.Data.Duties.Attestation
+	values := models.BenchmarkValues{
+		Beaconscore:              performance.Data.Beaconscore.Total,
+		AttestationEffectiveness: ratio(attestations.Included, attestations.Assigned),
+		APR:                      performance.Data.APR,
+	}
+
+	s.networkMu.Lock()
+	s.networkCache[key] = networkCacheEntry{fetched: time.Now(), values: values}
+	s.networkMu.Unlock()
+	return values, true
+}
+
+// fleetAPR annualizes the net rewards of response over the effective balance of
+// its active validators. The all_time range has no fixed length, so it has no APR.
+func fleetAPR(evalRange string, response models.ValidatorResponse) *float64 {
+	days, ok := rangeDays[evalRange]
+	if !ok {
+		return nil
+	}
+	rewards, err := amount.Parse(response.Rewards.Total)
+	if err != nil {
+		return nil
+	}
+
+	var staked amount.Amount
+	for _, o := range response.Validators {
+		if !strings.HasPrefix(o.Status, "active") {
+			continue
+		}
+		staked = staked.Add(amount.ParseOrZero(o.EffectiveBalance))
+	}
+	percent, ok := rewards.Percent(staked)
+	if !ok {
+		return nil
+	}
+
+	apr := percent / 100 * 365 / days
+	return &apr
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestBenchmark(t *testing.T) {
+	score, apr := 0.98, 0.03
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Build(),
+		beaconchatest.Validator(2).Build(),
+		beaconchatest.Validator(3).Pending(10).Build(),
+	)
+	fake.SetRewards("mainnet", "24h", models.BeaconchainRewardsAggregateResponse{
+		Data: models.BeaconchainRewardsData{Total: "6400000000000000"}, // 0.0001 of 64 ETH
+	})
+	fake.SetPerformance("mainnet", "24h", models.BeaconchainPerformanceAggregateResponse{
+		Data: models.BeaconchainPerformanceData{Duties: models.BeaconchainPerformanceDuties{
+			Attestation: models.BeaconchainAttestationDuties{Assigned: 100, Included: 99, Missed: 1},
+		}},
+	})
+	fake.SetNetworkPerformance("mainnet", "24h", models.BeaconchainNetworkPerformanceResponse{
+		Data: models.BeaconchainNetworkPerformance{
+			Beaconscore: models.BeaconchainBeaconscore{Total: &score},
+			Duties: models.BeaconchainPerformanceDuties{
+				Attestation: models.BeaconchainAttestationDuties{Assigned: 1000, Included: 980, Missed: 20},
+			},
+			APR: &apr,
+		},
+	})
+
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+	req := models.ValidatorRequest{ValidatorIds: []int{1, 2, 3}, Chain: "mainnet", Range: "24h"}
+
+	resp, err := s.GetValidatorData(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b := resp.Benchmark
+	if b == nil {
+		t.Fatal("expected a benchmark")
+	}
+	// The pending validator earns nothing, so it does not count towards the stake
+	if b.Fleet.APR == nil || math.Abs(*b.Fleet.APR-0.0365) > 1e-9 {
+		t.Errorf("expected a fleet APR of 0.0365, got %v", b.Fleet.APR)
+	}
+	if b.Fleet.AttestationEffectiveness == nil || *b.Fleet.AttestationEffectiveness != 0.99 {
+		t.Errorf("expected a fleet attestation effectiveness of 0.99, got %v", b.Fleet.AttestationEffectiveness)
+	}
+	if b.Network.Beaconscore == nil || *b.Network.Beaconscore != score || b.Network.APR == nil || *b.Network.APR != apr {
+		t.Errorf("unexpected network values: %+v", b.Network)
+	}
+	if b.Network.AttestationEffectiveness == nil || *b.Network.AttestationEffectiveness != 0.98 {
+		t.Errorf("expected a network attestation effectiveness of 0.98, got %v", b.Network.AttestationEffectiveness)
+	}
+
+	// Network averages are cached across requests
+	if _, err := s.GetValidatorData(ctx, models.ValidatorRequest{ValidatorIds: []int{1}, Chain: "mainnet", Range: "24h"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := fake.Calls(beaconchatest.MethodGetNetworkPerformance); n != 1 {
+		t.Errorf("expected 1 network performance call, got %d", n)
+	}
+}
+
+func TestBenchmark_NetworkUnavailable(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1)...)
+	fake.SetError(beaconchatest.MethodGetNetworkPerformance, errors.New("unavailable"))
+
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	resp, err := s.GetValidatorData(context.Background(), models.ValidatorRequest{ValidatorIds: []int{1}, Chain: "mainnet", Range: "all_time"})
+	if err != nil {
+		t.Fatalf("expected the response without a benchmark, got error: %v", err)
+	}
+	if resp.Benchmark != nil {
+		t.Errorf("expected no benchmark, got %+v", resp.Benchmark)
+	}
+}
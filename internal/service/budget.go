@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/budget"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// maxStaleAge is how long a cached validator response may be served once the
+// upstream budget runs low.
+const maxStaleAge = 24 * time.Hour
+
+// SetBudget makes validator requests prefer cached data, even from earlier epochs,
+// once the credits remaining in b run low or are exhausted. b should be the
+// manager charged by the Beaconcha client.
+func (s *ValidatorService) SetBudget(b *budget.Manager) {
+	s.budget = b
+}
+
+// Usage returns the upstream credits spent today.
+func (s *ValidatorService) Usage() models.UsageResponse {
+	return s.budget.Usage()
+}
+
+// staleResponse returns the most recent cached response of the request, if it is
+// at most maxStaleAge old. Unless the budget is exhausted, a response with data
+// that was not finalized is refetched once that data has finalized.
+func (s *ValidatorService) staleResponse(ctx context.Context, req models.ValidatorRequest, exhausted bool) (models.ValidatorResponse, bool) {
+	cached, ok := s.responseCache.Get(responseCacheKey(req))
+	if !ok || time.Since(cached.fetched) > maxStaleAge {
+		return models.ValidatorResponse{}, false
+	}
+	if !exhausted && !cached.finalized {
+		if epoch, err := chainspec.LastCompletedEpoch(req.Chain, time.Now()); err == nil && epoch >= cached.epoch+finalityEpochs {
+			return models.ValidatorResponse{}, false
+		}
+	}
+
+	slog.Info("serving cached validator data to save upstream credits",
+		"chain", req.Chain,
+		"validators", len(req.ValidatorIds),
+		"fetched", cached.fetched,
+	)
+	cost.AddCacheHit(ctx)
+	if epoch, err := chainspec.LastCompletedEpoch(req.Chain, time.Now()); err != nil || cached.epoch < epoch {
+		cost.AddStaleHit(ctx)
+	}
+
+	response := cached.response
+	response.Fiat = s.buildFiat(ctx, req.Currency, response.Validators, response.Rewards)
+	return response, true
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/budget"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestGetValidatorData_Budget(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validator(1).Build())
+	req := models.ValidatorRequest{ValidatorIds: []int{1}, Chain: "mainnet", Range: "30d"}
+
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	b := budget.NewManager(4, 0.5, nil)
+	s.SetBudget(b)
+
+	if _, err := s.GetValidatorData(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Pretend the cached response is from an earlier epoch
+	ageResponses(s, 1)
+
+	// With the budget low, the earlier response is served without upstream calls
+	for range 3 {
+		b.Charge("validators")
+	}
+	calls := fake.Calls(beaconchatest.MethodGetValidators)
+	ctx, counter := cost.WithCounter(context.Background())
+	if _, err := s.GetValidatorData(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fake.Calls(beaconchatest.MethodGetValidators); got != calls {
+		t.Errorf("expected no upstream calls, got %d", got-calls)
+	}
+	if counter.StaleHits() != 1 {
+		t.Errorf("expected 1 stale hit, got %d", counter.StaleHits())
+	}
+
+	// Without cached data the exhausted budget is reported
+	exhausted := fmt.Errorf("fetch validators: %w", budget.ErrExhausted)
+	fake.FailNext(beaconchatest.MethodGetValidators, exhausted)
+	other := models.ValidatorRequest{ValidatorIds: []int{2}, Chain: "mainnet", Range: "30d"}
+	if _, err := s.GetValidatorData(context.Background(), other); !errors.Is(err, budget.ErrExhausted) {
+		t.Errorf("expected ErrExhausted, got %v", err)
+	}
+}
+
+func TestStaleResponse_MaxAge(t *testing.T) {
+	s := NewValidatorService(beaconchatest.New(), nil, nil, nil, nil, nil)
+	req := models.ValidatorRequest{ValidatorIds: []int{1}, Chain: "mainnet", Range: "30d"}
+	s.responseCache.Add(responseCacheKey(req), responseCacheEntry{fetched: time.Now().Add(-maxStaleAge - time.Minute)})
+
+	if _, ok := s.staleResponse(context.Background(), req, false); ok {
+		t.Error("expected no stale response older than maxStaleAge")
+	}
+}
+
+// ageResponses moves the cached responses of s back by the given number of epochs.
+func ageResponses(s *ValidatorService, epochs int64) {
+	aged := make(map[string]responseCacheEntry)
+	s.responseCache.RemoveFunc(func(key string, e responseCacheEntry) bool {
+		e.epoch -= epochs
+		aged[key] = e
+		return true
+	})
+	for key, e := range aged {
+		s.responseCache.Add(key, e)
+	}
+}
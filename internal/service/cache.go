@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cache"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// Default limits of each in-memory cache.
+const (
+	defaultCacheMaxEntries = 1000
+	defaultCacheMaxBytes   = 64 << 20
+)
+
+// SetCacheLimits bounds the validator response, aggregate and balance history
+// caches to maxEntries entries and maxBytes bytes each, evicting the least recently
+// used entries first. A limit of 0 disables it. Cached entries are dropped.
+func (s *ValidatorService) SetCacheLimits(maxEntries int, maxBytes int64) {
+	s.responseCache = cache.New(maxEntries, maxBytes, jsonSize[responseCacheEntry](func(e responseCacheEntry) any { return e.response }))
+	s.aggregateCache = cache.New(maxEntries, maxBytes, jsonSize[aggregateCacheEntry](func(e aggregateCacheEntry) any { return e.value }))
+	s.balanceCache = cache.New(maxEntries, maxBytes, jsonSize[balanceCacheEntry](func(e balanceCacheEntry) any { return e.response }))
+}
+
+// CacheStats returns the size, limits and counters of the in-memory caches.
+func (s *ValidatorService) CacheStats() models.CacheStatsResponse {
+	return models.CacheStatsResponse{
+		Responses:      s.responseCache.Stats(),
+		Aggregates:     s.aggregateCache.Stats(),
+		BalanceHistory: s.balanceCache.Stats(),
+	}
+}
+
+// jsonSize returns a size function measuring the JSON encoding of the value
+// returned by data, which approximates the memory it holds.
+func jsonSize[V any](data func(V) any) func(V) int64 {
+	return func(v V) int64 {
+		b, err := json.Marshal(data(v))
+		if err != nil {
+			return 0
+		}
+		return int64(len(b))
+	}
+}
+
+// cacheSnapshot is the content of a cache file, entries least recently used first.
+type cacheSnapshot struct {
+	Responses  []cachedResponse `json:"responses"`
+	Balances   []cachedBalance  `json:"balances"`
+	Identities []cachedIdentity `json:"identities,omitempty"`
+}
+
+// cachedResponse is a persisted responseCacheEntry.
+type cachedResponse struct {
+	Key       string                   `json:"key"`
+	Epoch     int64                    `json:"epoch"`
+	Fetched   time.Time                `json:"fetched"`
+	Finalized bool                     `json:"finalized"`
+	Response  models.ValidatorResponse `json:"response"`
+}
+
+// cachedBalance is a persisted balanceCacheEntry.
+type cachedBalance struct {
+	Key      string                        `json:"key"`
+	EndEpoch int64                         `json:"endEpoch"`
+	Response models.BalanceHistoryResponse `json:"response"`
+}
+
+// cachedIdentity is a persisted validatorIdentity.
+type cachedIdentity struct {
+	Chain          string `json:"chain"`
+	ValidatorIndex int    `json:"validatorIndex"`
+	validatorIdentity
+}
+
+// LoadCache restores the caches from a file written by SaveCache, so a restart
+// does not refetch every validator through the upstream rate limit. A missing file
+// is not an error. Responses older than maxStaleAge are left out.
+func (s *ValidatorService) LoadCache(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read cache: %w", err)
+	}
+
+	var snapshot cacheSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("decode cache: %w", err)
+	}
+
+	for _, r := range snapshot.Responses {
+		if time.Since(r.Fetched) > maxStaleAge {
+			continue
+		}
+		s.responseCache.Add(r.Key, responseCacheEntry{
+			epoch:     r.Epoch,
+			fetched:   r.Fetched,
+			finalized: r.Finalized,
+			response:  r.Response,
+		})
+	}
+	for _, b := range snapshot.Balances {
+		s.balanceCache.Add(b.Key, balanceCacheEntry{endEpoch: b.EndEpoch, response: b.Response})
+	}
+
+	s.identityMu.Lock()
+	defer s.identityMu.Unlock()
+	for _, i := range snapshot.Identities {
+		if s.identities[i.Chain] == nil {
+			s.identities[i.Chain] = make(map[int]validatorIdentity)
+		}
+		s.identities[i.Chain][i.ValidatorIndex] = i.validatorIdentity
+	}
+	return nil
+}
+
+// SaveCache atomically writes the cached responses, balance histories and the
+// identities of searchable validators to path.
+func (s *ValidatorService) SaveCache(path string) error {
+	var snapshot cacheSnapshot
+	for _, item := range s.responseCache.Items() {
+		e := item.Value
+		snapshot.Responses = append(snapshot.Responses, cachedResponse{
+			Key:       item.Key,
+			Epoch:     e.epoch,
+			Fetched:   e.fetched,
+			Finalized: e.finalized,
+			Response:  e.response,
+		})
+	}
+	for _, item := range s.balanceCache.Items() {
+		snapshot.Balances = append(snapshot.Balances, cachedBalance{
+			Key:      item.Key,
+			EndEpoch: item.Value.endEpoch,
+			Response: item.Value.response,
+		})
+	}
+	s.identityMu.Lock()
+	for chain, identities := range s.identities {
+		for index, identity := range identities {
+			snapshot.Identities = append(snapshot.Identities, cachedIdentity{Chain: chain, ValidatorIndex: index, validatorIdentity: identity})
+		}
+	}
+	s.identityMu.Unlock()
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("encode cache: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write cache: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// RunCacheSnapshots saves the caches to path every interval until the context is
+// canceled. Failures are logged and retried on the next interval.
+func (s *ValidatorService) RunCacheSnapshots(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.SaveCache(path); err != nil {
+			slog.Error("failed to save cache", "error", err)
+		}
+	}
+}
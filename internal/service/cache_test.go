@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestSaveLoadCache(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2)...)
+	fake.SetRewards("mainnet", "7d", models.BeaconchainRewardsAggregateResponse{
+		Data: models.BeaconchainRewardsData{Total: "2000"},
+	})
+	req := models.ValidatorRequest{ValidatorIds: []int{1, 2}, Chain: "mainnet", Range: "7d"}
+	path := filepath.Join(t.TempDir(), "cache.json")
+	ctx := context.Background()
+
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	if _, err := s.GetValidatorData(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.SaveCache(path); err != nil {
+		t.Fatalf("SaveCache failed: %v", err)
+	}
+
+	// A restarted service serves the cached response without upstream calls
+	restarted := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	if err := restarted.LoadCache(path); err != nil {
+		t.Fatalf("LoadCache failed: %v", err)
+	}
+	calls := fake.Calls(beaconchatest.MethodGetValidators)
+	resp, err := restarted.cachedValidatorData(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fake.Calls(beaconchatest.MethodGetValidators); got != calls {
+		t.Errorf("expected no upstream calls, got %d", got-calls)
+	}
+	if resp.Rewards.Total != "2000" || len(resp.Validators) != 2 {
+		t.Errorf("unexpected restored response: %+v", resp)
+	}
+
+	// A missing file leaves the caches empty
+	empty := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	if err := empty.LoadCache(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("expected no error for a missing file, got %v", err)
+	}
+}
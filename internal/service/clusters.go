@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+// ErrUnknownCluster is returned when a requested cluster is not configured.
+var ErrUnknownCluster = errors.New("unknown cluster")
+
+// GetClusters returns summaries of the named portfolios run by distributed
+// validator clusters, or of all of them without names. Each summary aggregates
+// the cluster's validators and splits its rewards between its operators by
+// their shares. Data is shared with the dashboard through the response cache,
+// and a cluster that fails to load is reported in its summary.
+func (s *ValidatorService) GetClusters(ctx context.Context, names []string, evalRange string) (models.ClustersResponse, error) {
+	var clusters []portfolio.Portfolio
+	if len(names) == 0 {
+		for _, p := range s.portfolios.All() {
+			if p.Cluster != nil {
+				clusters = append(clusters, p)
+			}
+		}
+	}
+	for _, name := range names {
+		p, ok := s.portfolios.Get(name)
+		if !ok || p.Cluster == nil {
+			return models.ClustersResponse{}, fmt.Errorf("%w: %s", ErrUnknownCluster, name)
+		}
+		clusters = append(clusters, p)
+	}
+
+	response := models.ClustersResponse{Range: evalRange, Clusters: make([]models.ClusterSummary, 0, len(clusters))}
+	for _, p := range clusters {
+		summary := models.ClusterSummary{
+			Name:           p.Name,
+			Chain:          p.Chain,
+			Protocol:       p.Cluster.Protocol,
+			Threshold:      p.Cluster.Threshold,
+			FaultTolerance: p.Cluster.FaultTolerance(),
+		}
+		data, err := s.cachedValidatorData(ctx, portfolioRequest(p, evalRange))
+		if err != nil {
+			slog.Error("failed to fetch cluster", "cluster", p.Name, "error", err)
+			summary.Error = "failed to fetch validator data"
+			summary.Operators = clusterOperators(p.Cluster, amount.Amount{})
+			response.Clusters = append(response.Clusters, summary)
+			continue
+		}
+
+		summary.DashboardTotals = dashboardTotals(data.Validators)
+		summary.Rewards = data.Rewards.Total
+		summary.Beaconscore = data.Performance.Beaconscore
+		summary.Attestations = data.Performance.Attestations
+		summary.Proposals = data.Performance.Proposals
+		summary.Operators = clusterOperators(p.Cluster, amount.ParseOrZero(data.Rewards.Total))
+		response.Clusters = append(response.Clusters, summary)
+	}
+	return response, nil
+}
+
+// clusterOperators returns the operators of c with their share of rewards.
+func clusterOperators(c *portfolio.Cluster, rewards amount.Amount) []models.ClusterOperator {
+	operators := make([]models.ClusterOperator, len(c.Operators))
+	for i, o := range c.Operators {
+		operators[i] = models.ClusterOperator{
+			Name:    o.Name,
+			ID:      o.ID,
+			Share:   o.Share,
+			Rewards: rewards.Scale(o.Share).String(),
+		}
+	}
+	return operators
+}
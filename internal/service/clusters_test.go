@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+func TestGetClusters(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Build(),
+		beaconchatest.Validator(2).Offline().Build(),
+		beaconchatest.Validator(3).Build(),
+	)
+	fake.SetRewards("mainnet", "30d", models.BeaconchainRewardsAggregateResponse{
+		Data: models.BeaconchainRewardsData{Total: "1000"},
+	})
+
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{
+		{Name: "solo", Chain: "mainnet", ValidatorIds: []int{3}},
+		{Name: "dv", Chain: "mainnet", ValidatorIds: []int{1, 2}, Cluster: &portfolio.Cluster{
+			Protocol: portfolio.ProtocolObol,
+			Operators: []portfolio.ClusterOperator{
+				{Name: "alice", ID: "enr:-alice", Share: 0.5},
+				{Name: "bob", Share: 0.25},
+				{Name: "carol", Share: 0.125},
+				{Name: "dave", Share: 0.125},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewValidatorService(fake, nil, nil, nil, portfolios, nil)
+	ctx := context.Background()
+
+	resp, err := s.GetClusters(ctx, nil, "30d")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Clusters) != 1 {
+		t.Fatalf("expected only the cluster portfolio, got %+v", resp.Clusters)
+	}
+	dv := resp.Clusters[0]
+	if dv.Name != "dv" || dv.Protocol != "obol" || dv.Threshold != 3 || dv.FaultTolerance != 1 {
+		t.Errorf("unexpected cluster: %+v", dv)
+	}
+	if dv.Validators != 2 || dv.Online != 1 || dv.Offline != 1 || dv.Rewards != "1000" {
+		t.Errorf("unexpected cluster totals: %+v", dv)
+	}
+	wantRewards := []string{"500", "250", "125", "125"}
+	for i, o := range dv.Operators {
+		if o.Rewards != wantRewards[i] {
+			t.Errorf("operator %s: expected rewards %s, got %s", o.Name, wantRewards[i], o.Rewards)
+		}
+	}
+	if dv.Operators[0].ID != "enr:-alice" {
+		t.Errorf("expected operator ID, got %+v", dv.Operators[0])
+	}
+
+	for _, name := range []string{"solo", "missing"} {
+		if _, err := s.GetClusters(ctx, []string{name}, "30d"); !errors.Is(err, ErrUnknownCluster) {
+			t.Errorf("%s: expected ErrUnknownCluster, got %v", name, err)
+		}
+	}
+}
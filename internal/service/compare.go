@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/labels"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// ErrEmptyGroup is returned when no validators have the label of a compared group.
+var ErrEmptyGroup = errors.New("no validators in group")
+
+// IsLabelGroup reports whether a compared group selects validators by label,
+// rather than naming a portfolio.
+func IsLabelGroup(group string) bool {
+	return strings.ContainsAny(group, ":=")
+}
+
+// Compare returns the aggregates of each group side by side. A group is a portfolio
+// name, or a key:value label selecting validators on chain. Group data fetched during
+// the current epoch is served from cache. A group that fails to load is reported in
+// its comparison instead of failing the others.
+func (s *ValidatorService) Compare(ctx context.Context, chain string, groups []string, evalRange string) (models.CompareResponse, error) {
+	requests := make([]models.ValidatorRequest, len(groups))
+	for i, group := range groups {
+		if !IsLabelGroup(group) {
+			p, ok := s.portfolios.Get(group)
+			if !ok {
+				return models.CompareResponse{}, fmt.Errorf("%w: %s", ErrUnknownPortfolio, group)
+			}
+			requests[i] = portfolioRequest(p, evalRange)
+			continue
+		}
+
+		sel, err := labels.ParseSelector(group)
+		if err != nil {
+			return models.CompareResponse{}, err
+		}
+		ids := s.labels.Select(chain, []labels.Selector{sel})
+		if len(ids) == 0 {
+			return models.CompareResponse{}, fmt.Errorf("%w: no validators on %s have label %s", ErrEmptyGroup, chain, group)
+		}
+		requests[i] = models.ValidatorRequest{
+			ValidatorIds:     ids,
+			Chain:            chain,
+			Range:            evalRange,
+			ExcludeAnomalies: chain == "hoodi", // Same default as GET /validator
+		}
+	}
+
+	response := models.CompareResponse{Range: evalRange, Groups: make([]models.GroupComparison, 0, len(groups))}
+	for i, req := range requests {
+		comparison := models.GroupComparison{Group: groups[i], Chain: req.Chain}
+		data, err := s.cachedValidatorData(ctx, req)
+		if err != nil {
+			slog.Error("failed to fetch group", "group", groups[i], "error", err)
+			comparison.Error = "failed to fetch validator data"
+			response.Groups = append(response.Groups, comparison)
+			continue
+		}
+
+		comparison.DashboardTotals = dashboardTotals(data.Validators)
+		comparison.Rewards = data.Rewards.Total
+		comparison.RewardsPerValidator = "0"
+		if len(data.Validators) > 0 {
+			comparison.RewardsPerValidator = amount.ParseOrZero(data.Rewards.Total).Div(int64(len(data.Validators))).String()
+		}
+		comparison.Beaconscore = data.Performance.Beaconscore
+		comparison.AttestationMissRate = ratio(data.Performance.Attestations.Missed, data.Performance.Attestations.Assigned)
+		comparison.ProposalMissRate = ratio(data.Performance.Proposals.Missed, data.Performance.Proposals.Assigned)
+		comparison.SyncMissRate = ratio(data.Performance.SyncCommittees.Missed, data.Performance.SyncCommittees.Assigned)
+		response.Groups = append(response.Groups, comparison)
+	}
+	return response, nil
+}
+
+// ratio returns n over d, or nil if d is zero, e.g. missed over assigned duties.
+func ratio(n, d int) *float64 {
+	if d == 0 {
+		return nil
+	}
+	r := float64(n) / float64(d)
+	return &r
+}
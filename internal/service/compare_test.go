@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+func TestCompare(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2, 3)...)
+	fake.SetRewards("mainnet", "7d", models.BeaconchainRewardsAggregateResponse{
+		Data: models.BeaconchainRewardsData{Total: "3000"},
+	})
+	fake.SetPerformance("mainnet", "7d", models.BeaconchainPerformanceAggregateResponse{
+		Data: models.BeaconchainPerformanceData{Duties: models.BeaconchainPerformanceDuties{
+			Attestation: models.BeaconchainAttestationDuties{Assigned: 200, Missed: 5},
+		}},
+	})
+
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{
+		{Name: "home", Chain: "mainnet", ValidatorIds: []int{1, 2, 3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewValidatorService(fake, nil, nil, nil, portfolios, nil)
+	for _, id := range []int{1, 2} {
+		if _, err := s.SetValidatorLabels("mainnet", id, map[string]string{"client": "teku"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+
+	resp, err := s.Compare(ctx, "mainnet", []string{"home", "client:teku"}, "7d")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(resp.Groups))
+	}
+
+	home, teku := resp.Groups[0], resp.Groups[1]
+	if home.Group != "home" || home.Validators != 3 || home.RewardsPerValidator != "1000" {
+		t.Errorf("unexpected home comparison: %+v", home)
+	}
+	if teku.Group != "client:teku" || teku.Validators != 2 || teku.RewardsPerValidator != "1500" {
+		t.Errorf("unexpected teku comparison: %+v", teku)
+	}
+	if teku.AttestationMissRate == nil || *teku.AttestationMissRate != 0.025 {
+		t.Errorf("expected an attestation miss rate of 0.025, got %v", teku.AttestationMissRate)
+	}
+	if teku.ProposalMissRate != nil {
+		t.Errorf("expected no proposal miss rate without proposals, got %v", *teku.ProposalMissRate)
+	}
+
+	if _, err := s.Compare(ctx, "mainnet", []string{"client:lighthouse"}, "7d"); !errors.Is(err, ErrEmptyGroup) {
+		t.Errorf("expected ErrEmptyGroup, got %v", err)
+	}
+	if _, err := s.Compare(ctx, "mainnet", []string{"office"}, "7d"); !errors.Is(err, ErrUnknownPortfolio) {
+		t.Errorf("expected ErrUnknownPortfolio, got %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// ErrUnknownPortfolio is returned when a requested portfolio is not configured.
+var ErrUnknownPortfolio = errors.New("unknown portfolio")
+
+// Recent events shown on the dashboard.
+const (
+	dashboardEventWindow = 7 * 24 * time.Hour
+	dashboardEventLimit  = 20
+)
+
+// responseCacheEntry is a validator response fetched during a given epoch.
+type responseCacheEntry struct {
+	epoch     int64
+	fetched   time.Time
+	finalized bool // Whether no entry was flagged as not finalized
+	response  models.ValidatorResponse
+}
+
+// responseCacheKey identifies the data of a validator request, regardless of the
+// order of the IDs. Fiat values are not part of the cached data.
+func responseCacheKey(req models.ValidatorRequest) string {
+	ids := store.SortedIndices(req.ValidatorIds)
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return fmt.Sprintf("%s/%s/%t/%s", req.Chain, req.Range, req.ExcludeAnomalies, strings.Join(parts, ","))
+}
+
+// cacheResponse keeps a fetched response. It is served as current until the next
+// epoch completes, and as stale data for up to maxStaleAge when the budget runs low.
+func (s *ValidatorService) cacheResponse(req models.ValidatorRequest, response models.ValidatorResponse) {
+	now := time.Now()
+	epoch, err := chainspec.LastCompletedEpoch(req.Chain, now)
+	if err != nil {
+		return
+	}
+	response.Fiat = nil
+
+	s.responseCache.RemoveFunc(func(_ string, e responseCacheEntry) bool {
+		return e.epoch < epoch && now.Sub(e.fetched) > maxStaleAge
+	})
+	s.responseCache.Add(responseCacheKey(req), responseCacheEntry{
+		epoch:     epoch,
+		fetched:   now,
+		finalized: responseFinalized(response),
+		response:  response,
+	})
+}
+
+// cachedValidatorData returns the data of the request from the cache if it was
+// fetched during the current epoch, and fetches it otherwise. Cached responses get
+// the current labels, pre-signed exit flags, doppelganger flags, uptime and reward
+// rate anomalies.
+func (s *ValidatorService) cachedValidatorData(ctx context.Context, req models.ValidatorRequest) (models.ValidatorResponse, error) {
+	epoch, err := chainspec.LastCompletedEpoch(req.Chain, time.Now())
+	if err != nil {
+		return models.ValidatorResponse{}, err
+	}
+
+	cached, ok := s.responseCache.Get(responseCacheKey(req))
+	if ok && cached.epoch == epoch {
+		cost.AddCacheHit(ctx)
+		response := cached.response
+		s.annotate(req, &response)
+		s.addUptime(ctx, req, &response)
+		s.addRewardAnomalies(ctx, req, &response)
+		return response, nil
+	}
+
+	return s.GetValidatorData(ctx, req)
+}
+
+// portfolioRequest returns the validator request for the data of portfolio p.
+func portfolioRequest(p portfolio.Portfolio, evalRange string) models.ValidatorRequest {
+	return models.ValidatorRequest{
+		ValidatorIds:     p.ValidatorIds,
+		Chain:            p.Chain,
+		Range:            evalRange,
+		ExcludeAnomalies: p.Chain == "hoodi", // Same default as GET /validator
+	}
+}
+
+// GetPortfolioData returns the validator data of the named portfolio, from cache
+// if it was fetched during the current epoch.
+func (s *ValidatorService) GetPortfolioData(ctx context.Context, name, evalRange string) (models.ValidatorResponse, error) {
+	p, ok := s.portfolios.Get(name)
+	if !ok {
+		return models.ValidatorResponse{}, fmt.Errorf("%w: %s", ErrUnknownPortfolio, name)
+	}
+	return s.cachedValidatorData(ctx, portfolioRequest(p, evalRange))
+}
+
+// GetDashboard returns summaries of the named portfolios, a rollup per chain and
+// their recent events. No names selects every configured portfolio. Portfolio data
+// fetched during the current epoch, by this or any other request, is served from cache.
+// A portfolio that fails to load is reported in its summary instead of failing the dashboard.
+func (s *ValidatorService) GetDashboard(ctx context.Context, names []string, evalRange string) (models.DashboardResponse, error) {
+	portfolios := s.portfolios.All()
+	if len(names) > 0 {
+		portfolios = make([]portfolio.Portfolio, 0, len(names))
+		for _, name := range names {
+			p, ok := s.portfolios.Get(name)
+			if !ok {
+				return models.DashboardResponse{}, fmt.Errorf("%w: %s", ErrUnknownPortfolio, name)
+			}
+			portfolios = append(portfolios, p)
+		}
+	}
+
+	response := models.DashboardResponse{
+		Range:        evalRange,
+		Portfolios:   make([]models.PortfolioSummary, 0, len(portfolios)),
+		RecentEvents: []models.DashboardEvent{},
+	}
+
+	// Overviews per chain and validator, shared between portfolios for the rollup
+	overviews := make(map[string]map[string]models.ValidatorOverview)
+	for _, p := range portfolios {
+		req := portfolioRequest(p, evalRange)
+
+		summary := models.PortfolioSummary{Name: p.Name, Chain: p.Chain}
+		data, err := s.cachedValidatorData(ctx, req)
+		if err != nil {
+			slog.Error("failed to fetch portfolio", "portfolio", p.Name, "error", err)
+			summary.Error = "failed to fetch validator data"
+			response.Portfolios = append(response.Portfolios, summary)
+			continue
+		}
+
+		summary.DashboardTotals = dashboardTotals(data.Validators)
+		summary.Rewards = data.Rewards.Total
+		summary.Beaconscore = data.Performance.Beaconscore
+		response.Portfolios = append(response.Portfolios, summary)
+
+		if overviews[p.Chain] == nil {
+			overviews[p.Chain] = make(map[string]models.ValidatorOverview)
+		}
+		for id, o := range data.Validators {
+			overviews[p.Chain][id] = o
+		}
+	}
+
+	response.Rollup = make(map[string]models.DashboardTotals, len(overviews))
+	for chain, o := range overviews {
+		response.Rollup[chain] = dashboardTotals(o)
+	}
+
+	events, err := s.recentEvents(ctx, portfolios, dashboardEventWindow, dashboardEventLimit)
+	if err != nil {
+		// Events are best effort, like the history they come from
+		slog.Error("failed to load recent events", "error", err)
+	} else {
+		response.RecentEvents = events
+	}
+
+	return response, nil
+}
+
+// dashboardTotals counts the validators by state and sums their balances.
+func dashboardTotals(overviews map[string]models.ValidatorOverview) models.DashboardTotals {
+	totals := models.DashboardTotals{Validators: len(overviews)}
+	var balance amount.Amount
+	for _, o := range overviews {
+		switch {
+		case o.Slashed:
+			totals.Slashed++
+		case o.Online:
+			totals.Online++
+		default:
+			totals.Offline++
+		}
+		if o.PresignedExit {
+			totals.PresignedExits++
+		}
+		balance = balance.Add(amount.ParseOrZero(o.CurrentBalance))
+	}
+	totals.TotalBalance = balance.String()
+	return totals
+}
+
+// recentEvents returns up to limit of the events of the portfolio validators
+// recorded over the last window, newest first. Without a store there are no
+// events.
+func (s *ValidatorService) recentEvents(ctx context.Context, portfolios []portfolio.Portfolio, window time.Duration, limit int) ([]models.DashboardEvent, error) {
+	if s.store == nil || len(portfolios) == 0 {
+		return []models.DashboardEvent{}, nil
+	}
+
+	// Portfolio names per chain and validator
+	members := make(map[string]map[int][]string)
+	for _, p := range portfolios {
+		if members[p.Chain] == nil {
+			members[p.Chain] = make(map[int][]string)
+		}
+		for _, id := range p.ValidatorIds {
+			members[p.Chain][id] = append(members[p.Chain][id], p.Name)
+		}
+	}
+
+	now := time.Now().UTC()
+	var result []models.DashboardEvent
+	for chain, validators := range members {
+		ids := make([]int, 0, len(validators))
+		for id := range validators {
+			ids = append(ids, id)
+		}
+
+		events, err := s.store.Events(ctx, store.Query{
+			Chain:            chain,
+			ValidatorIndices: ids,
+			From:             now.Add(-window),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("load events: %w", err)
+		}
+
+		for _, e := range events {
+			result = append(result, models.DashboardEvent{
+				Time:           e.Time,
+				Chain:          e.Chain,
+				ValidatorIndex: e.ValidatorIndex,
+				Portfolios:     validators[e.ValidatorIndex],
+				Type:           string(e.Type),
+				From:           e.From,
+				To:             e.To,
+			})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Time.After(result[j].Time) })
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	if result == nil {
+		result = []models.DashboardEvent{}
+	}
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+func TestGetDashboard(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Build(),
+		beaconchatest.Validator(2).Offline().Build(),
+		beaconchatest.Validator(3).Slashed(100).Build(),
+	)
+	fake.SetRewards("mainnet", "30d", models.BeaconchainRewardsAggregateResponse{
+		Data: models.BeaconchainRewardsData{Total: "1500"},
+	})
+
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{
+		{Name: "home", Chain: "mainnet", ValidatorIds: []int{1, 2}},
+		{Name: "office", Chain: "mainnet", ValidatorIds: []int{2, 3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewValidatorService(fake, nil, nil, nil, portfolios, nil)
+	ctx := context.Background()
+
+	resp, err := s.GetDashboard(ctx, nil, "30d")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(resp.Portfolios) != 2 {
+		t.Fatalf("expected 2 portfolios, got %d", len(resp.Portfolios))
+	}
+	home := resp.Portfolios[0]
+	if home.Name != "home" || home.Online != 1 || home.Offline != 1 || home.Rewards != "1500" {
+		t.Errorf("unexpected home summary: %+v", home)
+	}
+
+	// Validator 2 is in both portfolios but counted once
+	rollup := resp.Rollup["mainnet"]
+	want := models.DashboardTotals{Validators: 3, Online: 1, Offline: 1, Slashed: 1, TotalBalance: "96000000000000000000"}
+	if rollup != want {
+		t.Errorf("expected rollup %+v, got %+v", want, rollup)
+	}
+
+	// A second dashboard in the same epoch is served from cache
+	calls := fake.Calls(beaconchatest.MethodGetValidators)
+	if _, err := s.GetDashboard(ctx, []string{"home"}, "30d"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fake.Calls(beaconchatest.MethodGetValidators); got != calls {
+		t.Errorf("expected no upstream calls, got %d", got-calls)
+	}
+
+	if _, err := s.GetDashboard(ctx, []string{"missing"}, "30d"); !errors.Is(err, ErrUnknownPortfolio) {
+		t.Errorf("expected ErrUnknownPortfolio, got %v", err)
+	}
+}
+
+func TestGetDashboard_PortfolioFailure(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1)...)
+	fake.FailNext(beaconchatest.MethodGetValidators, errors.New("upstream down"))
+
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{
+		{Name: "broken", Chain: "mainnet", ValidatorIds: []int{1}},
+		{Name: "fine", Chain: "mainnet", ValidatorIds: []int{1, 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewValidatorService(fake, nil, nil, nil, portfolios, nil)
+	resp, err := s.GetDashboard(context.Background(), nil, "7d")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Portfolios[0].Error == "" {
+		t.Error("expected an error on the failed portfolio")
+	}
+	if resp.Portfolios[1].Error != "" || resp.Portfolios[1].Validators != 1 {
+		t.Errorf("unexpected summary: %+v", resp.Portfolios[1])
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"errors"
+)
+
+// Sections of a response that are left out when the request deadline passes
+// before they are fetched, or when they are not selected.
+const (
+	sectionOverview    = "overview"
+	sectionRewards     = "rewards"
+	sectionPerformance = "performance"
+	sectionFiat        = "fiat"
+	sectionBenchmark   = "benchmark"
+	sectionIncome      = "income"
+	sectionAnomalies   = "anomalies"
+	sectionPrevious    = "previous"
+	sectionLuck        = "luck"
+)
+
+// deadlineExceeded reports whether err was caused by the deadline of ctx passing,
+// rather than by the client going away or an upstream failure.
+func deadlineExceeded(ctx context.Context, err error) bool {
+	return err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
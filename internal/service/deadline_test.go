@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/anomaly"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestGetValidatorData_Deadline(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1)...)
+	fake.SetLatency(50 * time.Millisecond)
+	// A known mass slashing makes the aggregates wait for the validators
+	filter, err := anomaly.NewFilter([]anomaly.Window{
+		{Chain: "mainnet", Name: "slashings", Kind: anomaly.KindMassSlashing, StartEpoch: 100, EndEpoch: 110},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewValidatorService(fake, filter, nil, nil, nil, nil)
+	req := models.ValidatorRequest{ValidatorIds: []int{1}, Chain: "mainnet", Range: "24h", ExcludeAnomalies: true}
+
+	// The validators arrive in time, the aggregates do not
+	ctx, cancel := context.WithTimeout(context.Background(), 80*time.Millisecond)
+	defer cancel()
+	response, err := s.GetValidatorData(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Validators) != 1 {
+		t.Errorf("expected the fetched validator, got %d", len(response.Validators))
+	}
+	want := []string{sectionRewards, sectionPerformance, sectionBenchmark}
+	if !slices.Equal(response.TimedOutSections, want) {
+		t.Errorf("expected timed out sections %v, got %v", want, response.TimedOutSections)
+	}
+
+	// A partial response is not served from the cache later
+	if _, ok := s.staleResponse(context.Background(), req, true); ok {
+		t.Error("expected the partial response not to be cached")
+	}
+
+	// Without the validators there is nothing to return
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.GetValidatorData(ctx, req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// A complete response fetched earlier is served instead
+	fake.SetLatency(0)
+	if _, err := s.GetValidatorData(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	fake.SetLatency(50 * time.Millisecond)
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	response, err = s.GetValidatorData(ctx, req)
+	if err != nil || len(response.TimedOutSections) != 0 {
+		t.Errorf("expected the cached response, got %v (timed out %v)", err, response.TimedOutSections)
+	}
+}
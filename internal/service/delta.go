@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// deltaLookback is how far before since the state of a validator at since is
+// looked for. Validators without a snapshot in that window count as changed.
+const deltaLookback = 24 * time.Hour
+
+// GetValidatorDelta returns the validators whose status, balance or online flag
+// differs between their latest snapshot and their last snapshot at or before
+// since. It reads the history store only, so it makes no upstream calls.
+func (s *ValidatorService) GetValidatorDelta(ctx context.Context, chain string, validatorIds []int, since time.Time) (models.ValidatorDeltaResponse, error) {
+	if s.store == nil {
+		return models.ValidatorDeltaResponse{}, ErrHistoryDisabled
+	}
+
+	snapshots, err := s.store.Snapshots(ctx, store.Query{
+		Chain:            chain,
+		ValidatorIndices: validatorIds,
+		From:             since.Add(-deltaLookback),
+	})
+	if err != nil {
+		return models.ValidatorDeltaResponse{}, fmt.Errorf("load snapshots: %w", err)
+	}
+	return buildValidatorDelta(snapshots, since), nil
+}
+
+// buildValidatorDelta compares the latest snapshot of each validator with its last
+// one at or before since. Snapshots must be ordered by time.
+func buildValidatorDelta(snapshots []store.Snapshot, since time.Time) models.ValidatorDeltaResponse {
+	baseline := make(map[int]store.Snapshot)
+	latest := make(map[int]store.Snapshot)
+	response := models.ValidatorDeltaResponse{Since: since, AsOf: since, Validators: map[string]models.ValidatorDelta{}}
+	for _, snap := range snapshots {
+		if !snap.Time.After(since) {
+			baseline[snap.ValidatorIndex] = snap
+			continue
+		}
+		latest[snap.ValidatorIndex] = snap
+		if snap.Time.After(response.AsOf) {
+			response.AsOf = snap.Time
+		}
+	}
+
+	for index, snap := range latest {
+		var changed []string
+		prev, ok := baseline[index]
+		if !ok || prev.Status != snap.Status {
+			changed = append(changed, "status")
+		}
+		if !ok || prev.CurrentBalance != snap.CurrentBalance {
+			changed = append(changed, "balance")
+		}
+		if !ok || prev.Online != snap.Online {
+			changed = append(changed, "online")
+		}
+		if len(changed) == 0 {
+			continue
+		}
+		response.Validators[strconv.Itoa(index)] = models.ValidatorDelta{
+			Status:         snap.Status,
+			CurrentBalance: snap.CurrentBalance,
+			Online:         snap.Online,
+			Changed:        changed,
+			Time:           snap.Time,
+		}
+	}
+	return response
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+func TestGetValidatorDelta(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	since := now.Add(-time.Hour)
+
+	st, err := store.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := []store.Snapshot{
+		{Time: since.Add(-time.Minute), Chain: "mainnet", ValidatorIndex: 1, Status: "active_online", Online: true, CurrentBalance: "32"},
+		{Time: since.Add(-time.Minute), Chain: "mainnet", ValidatorIndex: 2, Status: "active_online", Online: true, CurrentBalance: "32"},
+		{Time: since.Add(-time.Minute), Chain: "mainnet", ValidatorIndex: 3, Status: "active_online", Online: true, CurrentBalance: "32"},
+	}
+	after := []store.Snapshot{
+		{Time: now, Chain: "mainnet", ValidatorIndex: 1, Status: "active_online", Online: true, CurrentBalance: "32"},
+		{Time: now, Chain: "mainnet", ValidatorIndex: 2, Status: "active_offline", Online: false, CurrentBalance: "31"},
+		{Time: now, Chain: "mainnet", ValidatorIndex: 4, Status: "pending_queued", CurrentBalance: "32"},
+	}
+	for _, snapshots := range [][]store.Snapshot{before, after} {
+		if err := st.RecordSnapshots(ctx, snapshots); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewValidatorService(nil, nil, st, nil, nil, nil)
+	delta, err := s.GetValidatorDelta(ctx, "mainnet", []int{1, 2, 3, 4}, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !delta.AsOf.Equal(now) {
+		t.Errorf("expected the delta as of %v, got %v", now, delta.AsOf)
+	}
+	if len(delta.Validators) != 2 {
+		t.Fatalf("expected 2 changed validators, got %+v", delta.Validators)
+	}
+	if got, want := delta.Validators["2"].Changed, []string{"status", "balance", "online"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected validator 2 to change %v, got %v", want, got)
+	}
+	if v := delta.Validators["2"]; v.Status != "active_offline" || v.CurrentBalance != "31" || v.Online {
+		t.Errorf("expected the latest state of validator 2, got %+v", v)
+	}
+	if got := delta.Validators["4"].Changed; len(got) != 3 {
+		t.Errorf("expected a validator first recorded after since to change everything, got %v", got)
+	}
+
+	// Nothing changed since the latest snapshot
+	delta, err = s.GetValidatorDelta(ctx, "mainnet", []int{1, 2, 3, 4}, delta.AsOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(delta.Validators) != 0 {
+		t.Errorf("expected no changes since the latest snapshot, got %+v", delta.Validators)
+	}
+}
+
+func TestGetValidatorDelta_NoStore(t *testing.T) {
+	s := NewValidatorService(nil, nil, nil, nil, nil, nil)
+	if _, err := s.GetValidatorDelta(context.Background(), "mainnet", []int{1}, time.Now()); err != ErrHistoryDisabled {
+		t.Errorf("expected ErrHistoryDisabled, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/diversity"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// oneThird is the share above which a client bug can prevent finality.
+const oneThird = 1.0 / 3
+
+// SetNetworkDiversity sets the client diversity of the networks fleets are compared against.
+func (s *ValidatorService) SetNetworkDiversity(n *diversity.Network) {
+	s.diversity = n
+}
+
+// GetDiversity returns the distribution of the values of the label over the
+// validators of the named portfolio, or over all validators with labels on chain
+// when name is empty, next to the network distribution if it is known. Client
+// names are compared case-insensitively.
+func (s *ValidatorService) GetDiversity(chain, name, label string) (models.DiversityResponse, error) {
+	response := models.DiversityResponse{Chain: chain, Portfolio: name, Label: label, Clients: []models.ClientShare{}}
+
+	values := make(map[int]string)
+	if name != "" {
+		p, ok := s.portfolios.Get(name)
+		if !ok {
+			return models.DiversityResponse{}, fmt.Errorf("%w: %s", ErrUnknownPortfolio, name)
+		}
+		response.Chain = p.Chain
+		for _, id := range p.ValidatorIds {
+			values[id] = s.labels.Get(p.Chain, id)[label]
+		}
+	} else {
+		for id, l := range s.labels.All(chain) {
+			values[id] = l[label]
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, value := range values {
+		if value == "" {
+			response.Unlabeled++
+			continue
+		}
+		counts[strings.ToLower(value)]++
+		response.Validators++
+	}
+
+	network, known := s.diversity.Get(response.Chain, label)
+	if known {
+		response.NetworkSource = network.Source
+		response.NetworkUpdatedAt = network.UpdatedAt
+		for client := range network.Shares {
+			if _, ok := counts[client]; !ok {
+				counts[client] = 0
+			}
+		}
+	}
+
+	for client, n := range counts {
+		share := models.ClientShare{Client: client, Validators: n}
+		if response.Validators > 0 {
+			share.Share = float64(n) / float64(response.Validators)
+		}
+		share.OverOneThird = share.Share > oneThird
+		if ns, ok := network.Shares[client]; ok {
+			share.NetworkShare = &ns
+		} else if known {
+			zero := 0.0
+			share.NetworkShare = &zero
+		}
+		response.Clients = append(response.Clients, share)
+	}
+	slices.SortFunc(response.Clients, func(a, b models.ClientShare) int {
+		if c := cmp.Compare(b.Validators, a.Validators); c != 0 {
+			return c
+		}
+		if a.NetworkShare != nil && b.NetworkShare != nil {
+			if c := cmp.Compare(*b.NetworkShare, *a.NetworkShare); c != 0 {
+				return c
+			}
+		}
+		return strings.Compare(a.Client, b.Client)
+	})
+	return response, nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/diversity"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+func TestGetDiversity(t *testing.T) {
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{
+		{Name: "home", Chain: "mainnet", ValidatorIds: []int{1, 2, 3, 4, 5}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	network, err := diversity.NewNetwork([]diversity.Stats{
+		{Chain: "mainnet", Label: "client", Shares: map[string]float64{"lighthouse": 0.4, "prysm": 0.3, "nimbus": 0.05}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewValidatorService(beaconchatest.New(), nil, nil, nil, portfolios, nil)
+	s.SetNetworkDiversity(network)
+	for index, client := range map[int]string{1: "Lighthouse", 2: "lighthouse", 3: "teku", 6: "prysm"} {
+		if err := s.labels.Set("mainnet", index, map[string]string{"client": client}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.labels.Set("mainnet", 4, map[string]string{"machine": "node-3"}); err != nil {
+		t.Fatal(err)
+	}
+	share := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name          string
+		portfolio     string
+		wantLabeled   int
+		wantUnlabeled int
+		wantClients   []models.ClientShare
+	}{
+		{
+			name:          "portfolio",
+			portfolio:     "home",
+			wantLabeled:   3,
+			wantUnlabeled: 2,
+			wantClients: []models.ClientShare{
+				{Client: "lighthouse", Validators: 2, Share: 2.0 / 3, NetworkShare: share(0.4), OverOneThird: true},
+				{Client: "teku", Validators: 1, Share: 1.0 / 3, NetworkShare: share(0)},
+				{Client: "prysm", Validators: 0, Share: 0, NetworkShare: share(0.3)},
+				{Client: "nimbus", Validators: 0, Share: 0, NetworkShare: share(0.05)},
+			},
+		},
+		{
+			name:          "labeled validators",
+			wantLabeled:   4,
+			wantUnlabeled: 1,
+			wantClients: []models.ClientShare{
+				{Client: "lighthouse", Validators: 2, Share: 0.5, NetworkShare: share(0.4), OverOneThird: true},
+				{Client: "prysm", Validators: 1, Share: 0.25, NetworkShare: share(0.3)},
+				{Client: "teku", Validators: 1, Share: 0.25, NetworkShare: share(0)},
+				{Client: "nimbus", Validators: 0, Share: 0, NetworkShare: share(0.05)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetDiversity("mainnet", tt.portfolio, "client")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Validators != tt.wantLabeled || got.Unlabeled != tt.wantUnlabeled {
+				t.Errorf("expected %d labeled and %d unlabeled validators, got %d and %d", tt.wantLabeled, tt.wantUnlabeled, got.Validators, got.Unlabeled)
+			}
+			if !reflect.DeepEqual(got.Clients, tt.wantClients) {
+				t.Errorf("expected clients %+v, got %+v", tt.wantClients, got.Clients)
+			}
+		})
+	}
+
+	if _, err := s.GetDiversity("", "office", "client"); !errors.Is(err, ErrUnknownPortfolio) {
+		t.Errorf("expected ErrUnknownPortfolio, got %v", err)
+	}
+}
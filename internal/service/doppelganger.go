@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// doppelgangerRetention is how long a validator stays flagged after conflicting
+// attestations of it were last seen.
+const doppelgangerRetention = 24 * time.Hour
+
+// maxDoppelgangerEpochs caps the epochs scanned per chain in one check, e.g. after
+// the server was down.
+const maxDoppelgangerEpochs = 8
+
+// RunDoppelgangerChecks checks the portfolio validators for doppelgangers
+// immediately and then every interval until the context is canceled.
+func (s *ValidatorService) RunDoppelgangerChecks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.CheckDoppelgangers(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckDoppelgangers scans the attestations of every portfolio validator in the
+// epochs since the previous check, and flags validators with conflicting
+// attestations for the same epoch: a validator attests once per epoch, so these
+// were signed by two instances of it, which gets it slashed once the conflict is
+// reported. Attestations of an epoch may be included during the next one, so the
+// latest epoch scanned is the one before the last completed epoch. The first check
+// only scans that epoch.
+func (s *ValidatorService) CheckDoppelgangers(ctx context.Context, now time.Time) {
+	chains := make(map[string][]int)
+	for _, p := range s.portfolios.All() {
+		chains[p.Chain] = append(chains[p.Chain], p.ValidatorIds...)
+	}
+
+	for chain, ids := range chains {
+		if ctx.Err() != nil {
+			return
+		}
+		last, err := chainspec.LastCompletedEpoch(chain, now)
+		if err != nil {
+			continue
+		}
+		end := last - 1
+
+		s.doppelgangerMu.Lock()
+		scanned, ok := s.doppelgangerScanned[chain]
+		s.doppelgangerMu.Unlock()
+		start := end
+		if ok {
+			start = max(scanned+1, end-maxDoppelgangerEpochs+1)
+		}
+		if start > end || start < 0 {
+			continue
+		}
+
+		slices.Sort(ids)
+		suspects, err := s.detectDoppelgangers(ctx, chain, slices.Compact(ids), start, end)
+		if err != nil {
+			slog.Warn("failed to check for doppelgangers", "chain", chain, "startEpoch", start, "endEpoch", end, "error", err)
+			continue
+		}
+		for index := range suspects {
+			slog.Error("possible doppelganger: conflicting attestations", "chain", chain, "validator", index, "epoch", suspects[index].Epoch)
+		}
+		s.recordDoppelgangers(chain, suspects, end, now)
+	}
+}
+
+// detectDoppelgangers returns the validators with more than one distinct attestation
+// included for an epoch from startEpoch to endEpoch, keyed by index. Validators are
+// fetched in batches, each taking its turn in the request queue.
+func (s *ValidatorService) detectDoppelgangers(ctx context.Context, chain string, ids []int, startEpoch, endEpoch int64) (map[int]models.DoppelgangerSuspicion, error) {
+	type vote struct {
+		slot                 int64
+		committee            int
+		head, source, target string
+	}
+	type duty struct {
+		index int
+		epoch int64
+	}
+
+	suspects := make(map[int]models.DoppelgangerSuspicion)
+	for start := 0; start < len(ids); start += reportBatchSize {
+		batch := ids[start:min(start+reportBatchSize, len(ids))]
+
+		release, err := s.acquireQueueSlot(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("queue wait: %w", err)
+		}
+		attestations, err := s.beaconchainClient.GetAttestations(ctx, chain, batch, startEpoch, endEpoch)
+		release()
+		if err != nil {
+			return nil, fmt.Errorf("fetch attestations: %w", err)
+		}
+
+		votes := make(map[duty]map[vote]bool)
+		for _, a := range attestations {
+			if a.Validator.Index == nil {
+				continue
+			}
+			d := duty{*a.Validator.Index, a.Epoch}
+			if votes[d] == nil {
+				votes[d] = make(map[vote]bool)
+			}
+			votes[d][vote{a.Slot, a.CommitteeIndex, a.BeaconBlockRoot, a.SourceRoot, a.TargetRoot}] = true
+		}
+		for d, v := range votes {
+			if len(v) > 1 && d.epoch >= suspects[d.index].Epoch {
+				suspects[d.index] = models.DoppelgangerSuspicion{Epoch: d.epoch, Attestations: len(v)}
+			}
+		}
+	}
+	return suspects, nil
+}
+
+// recordDoppelgangers flags the suspects of a check that scanned chain up to
+// endEpoch, and drops flags older than the retention.
+func (s *ValidatorService) recordDoppelgangers(chain string, suspects map[int]models.DoppelgangerSuspicion, endEpoch int64, now time.Time) {
+	s.doppelgangerMu.Lock()
+	defer s.doppelgangerMu.Unlock()
+
+	s.doppelgangerScanned[chain] = endEpoch
+	flagged := s.doppelgangers[chain]
+	if flagged == nil {
+		flagged = make(map[int]models.DoppelgangerSuspicion)
+		s.doppelgangers[chain] = flagged
+	}
+	for index, suspect := range suspects {
+		suspect.DetectedAt = now.UTC()
+		flagged[index] = suspect
+	}
+	for index, suspect := range flagged {
+		if now.Sub(suspect.DetectedAt) > doppelgangerRetention {
+			delete(flagged, index)
+		}
+	}
+}
+
+// doppelganger returns the flag of a validator, if it is suspected to run twice.
+func (s *ValidatorService) doppelganger(chain string, index int) *models.DoppelgangerSuspicion {
+	s.doppelgangerMu.Lock()
+	defer s.doppelgangerMu.Unlock()
+
+	suspect, ok := s.doppelgangers[chain][index]
+	if !ok {
+		return nil
+	}
+	return &suspect
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+func TestCheckDoppelgangers(t *testing.T) {
+	spec, err := chainspec.ForChain("mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Epoch 1000 completes at the start of epoch 1001, so epoch 999 is scanned first
+	now := spec.EpochStart(1001).Add(time.Minute)
+	slot := func(epoch int64) int64 { return epoch * 32 }
+
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2, 3)...)
+	fake.AddAttestations("mainnet",
+		beaconchatest.Attestation(1, slot(999)+3, "0xaa"),
+		beaconchatest.Attestation(2, slot(999)+5, "0xaa"),
+		beaconchatest.Attestation(2, slot(999)+5, "0xaa"), // Included twice, same vote
+		beaconchatest.Attestation(3, slot(999)+7, "0xaa"),
+		beaconchatest.Attestation(3, slot(999)+7, "0xbb"), // Conflicting vote
+		beaconchatest.Attestation(1, slot(1000)+3, "0xcc"),
+		beaconchatest.Attestation(1, slot(1000)+9, "0xdd"), // Conflicting slot
+	)
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{
+		{Name: "home", Chain: "mainnet", ValidatorIds: []int{1, 2}},
+		{Name: "office", Chain: "mainnet", ValidatorIds: []int{2, 3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewValidatorService(fake, nil, nil, nil, portfolios, nil)
+	ctx := context.Background()
+
+	s.CheckDoppelgangers(ctx, now)
+	if s.doppelganger("mainnet", 1) != nil || s.doppelganger("mainnet", 2) != nil {
+		t.Error("expected validators 1 and 2 not to be flagged after epoch 999")
+	}
+	if got := s.doppelganger("mainnet", 3); got == nil || got.Epoch != 999 || got.Attestations != 2 {
+		t.Errorf("expected validator 3 to be flagged for epoch 999, got %+v", got)
+	}
+
+	// The next check scans the following epoch only
+	s.CheckDoppelgangers(ctx, now.Add(spec.EpochDuration()))
+	if got := s.doppelganger("mainnet", 1); got == nil || got.Epoch != 1000 {
+		t.Errorf("expected validator 1 to be flagged for epoch 1000, got %+v", got)
+	}
+	if got := fake.Calls(beaconchatest.MethodGetAttestations); got != 2 {
+		t.Errorf("expected 2 attestation fetches, got %d", got)
+	}
+
+	// Flags are part of validator responses and expire after the retention
+	resp, err := s.GetPortfolioData(ctx, "office", "24h")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Validators["3"].Doppelganger == nil || resp.Validators["2"].Doppelganger != nil {
+		t.Errorf("expected only validator 3 to be flagged, got %+v", resp.Validators)
+	}
+	s.recordDoppelgangers("mainnet", map[int]models.DoppelgangerSuspicion{}, 1000, now.Add(doppelgangerRetention+time.Hour))
+	if s.doppelganger("mainnet", 3) != nil {
+		t.Error("expected the flag of validator 3 to expire")
+	}
+}
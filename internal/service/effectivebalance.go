@@ -0,0 +1,103 @@
+package service
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// Effective balance parameters of the consensus spec (Electra), in wei.
+var (
+	effectiveBalanceIncrement = big.NewInt(1e18)
+	minActivationBalance      = new(big.Int).Mul(big.NewInt(32), effectiveBalanceIncrement)
+	maxEffectiveBalance       = new(big.Int).Mul(big.NewInt(2048), effectiveBalanceIncrement)
+	// The effective balance drops once the balance falls 0.25 ETH below it, and
+	// rises once the balance exceeds it by more than 1.25 ETH.
+	hysteresisDownward = big.NewInt(25e16)
+	hysteresisUpward   = big.NewInt(125e16)
+	oneGwei            = big.NewInt(1e9)
+)
+
+// Flags of the effective balance headroom.
+const (
+	flagTopUpRecommended           = "topup_recommended"
+	flagEffectiveBalanceDecreasing = "effective_balance_decreasing"
+)
+
+// buildHeadroom compares the effective balance of a pending or active validator with
+// its maximum and works out the deposits that raise it. Returns nil for other
+// validators and when the balances are unknown.
+func buildHeadroom(v models.BeaconchainValidatorData) *models.EffectiveBalanceHeadroom {
+	if v.Slashed || !(strings.HasPrefix(v.Status, "active") || strings.HasPrefix(v.Status, "pending")) || strings.HasSuffix(v.Status, "exiting") {
+		return nil
+	}
+	balance, ok := new(big.Int).SetString(v.Balances.Current, 10)
+	if !ok {
+		return nil
+	}
+	effective, ok := new(big.Int).SetString(v.Balances.Effective, 10)
+	if !ok {
+		return nil
+	}
+
+	compounding := v.WithdrawalCredentials.Prefix == "0x02"
+	maxBalance := minActivationBalance
+	if compounding {
+		maxBalance = maxEffectiveBalance
+	}
+
+	pending := nextEffectiveBalance(balance, effective, maxBalance)
+	utilization, _ := new(big.Rat).SetFrac(effective, maxBalance).Float64()
+	h := &models.EffectiveBalanceHeadroom{
+		MaxEffectiveBalance:     maxBalance.String(),
+		Headroom:                new(big.Int).Sub(maxBalance, effective).String(),
+		Utilization:             utilization,
+		PendingEffectiveBalance: pending.String(),
+	}
+
+	if pending.Cmp(effective) < 0 {
+		h.Flags = append(h.Flags, flagEffectiveBalanceDecreasing)
+	}
+	if pending.Cmp(minActivationBalance) < 0 {
+		h.Flags = append(h.Flags, flagTopUpRecommended)
+		h.TopUpAmount = topUpAmount(balance, pending, minActivationBalance).String()
+	}
+	if compounding && pending.Cmp(maxBalance) < 0 {
+		next := new(big.Int).Add(pending, effectiveBalanceIncrement)
+		h.NextIncrementTopUp = topUpAmount(balance, pending, next).String()
+	}
+	return h
+}
+
+// nextEffectiveBalance applies the hysteresis of the epoch transition to the
+// effective balance.
+func nextEffectiveBalance(balance, effective, maxBalance *big.Int) *big.Int {
+	lower := new(big.Int).Add(balance, hysteresisDownward)
+	upper := new(big.Int).Add(effective, hysteresisUpward)
+	if lower.Cmp(effective) >= 0 && upper.Cmp(balance) >= 0 {
+		return effective
+	}
+
+	next := new(big.Int).Sub(balance, new(big.Int).Mod(balance, effectiveBalanceIncrement))
+	if next.Cmp(maxBalance) > 0 {
+		return new(big.Int).Set(maxBalance)
+	}
+	return next
+}
+
+// topUpAmount returns the deposit after which the effective balance rises from
+// effective to target: the balance must reach target and exceed the upward
+// hysteresis threshold.
+func topUpAmount(balance, effective, target *big.Int) *big.Int {
+	needed := new(big.Int).Add(effective, hysteresisUpward)
+	needed.Add(needed, oneGwei) // Balances are kept in gwei on chain
+	if needed.Cmp(target) < 0 {
+		needed.Set(target)
+	}
+	amount := needed.Sub(needed, balance)
+	if amount.Sign() < 0 {
+		return new(big.Int)
+	}
+	return amount
+}
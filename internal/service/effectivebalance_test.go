@@ -0,0 +1,112 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestBuildHeadroom(t *testing.T) {
+	const address = "0x00000000219ab540356cbb839cbe05303d7705fa"
+
+	tests := []struct {
+		name      string
+		validator models.BeaconchainValidatorData
+		want      *models.EffectiveBalanceHeadroom
+	}{
+		{
+			name:      "full 0x01 validator",
+			validator: beaconchatest.Validator(1).Balances("32010000000000000000", "32000000000000000000").Build(),
+			want: &models.EffectiveBalanceHeadroom{
+				MaxEffectiveBalance:     "32000000000000000000",
+				Headroom:                "0",
+				Utilization:             1,
+				PendingEffectiveBalance: "32000000000000000000",
+			},
+		},
+		{
+			name:      "0x01 validator within the hysteresis",
+			validator: beaconchatest.Validator(1).Balances("31800000000000000000", "31000000000000000000").Build(),
+			want: &models.EffectiveBalanceHeadroom{
+				MaxEffectiveBalance:     "32000000000000000000",
+				Headroom:                "1000000000000000000",
+				Utilization:             31.0 / 32,
+				PendingEffectiveBalance: "31000000000000000000",
+				TopUpAmount:             "450000001000000000", // Up to 32.25 ETH and 1 gwei
+				Flags:                   []string{flagTopUpRecommended},
+			},
+		},
+		{
+			name:      "0x01 validator falling below the threshold",
+			validator: beaconchatest.Validator(1).Balances("31700000000000000000", "32000000000000000000").Build(),
+			want: &models.EffectiveBalanceHeadroom{
+				MaxEffectiveBalance:     "32000000000000000000",
+				Headroom:                "0",
+				Utilization:             1,
+				PendingEffectiveBalance: "31000000000000000000",
+				TopUpAmount:             "550000001000000000",
+				Flags:                   []string{flagEffectiveBalanceDecreasing, flagTopUpRecommended},
+			},
+		},
+		{
+			name:      "compounding validator",
+			validator: beaconchatest.Validator(1).Compounding(address).Balances("40500000000000000000", "40000000000000000000").Build(),
+			want: &models.EffectiveBalanceHeadroom{
+				MaxEffectiveBalance:     "2048000000000000000000",
+				Headroom:                "2008000000000000000000",
+				Utilization:             40.0 / 2048,
+				PendingEffectiveBalance: "40000000000000000000",
+				NextIncrementTopUp:      "750000001000000000", // Up to 41.25 ETH and 1 gwei
+			},
+		},
+		{
+			name:      "compounding validator past the threshold",
+			validator: beaconchatest.Validator(1).Compounding(address).Balances("42300000000000000000", "40000000000000000000").Build(),
+			want: &models.EffectiveBalanceHeadroom{
+				MaxEffectiveBalance:     "2048000000000000000000",
+				Headroom:                "2008000000000000000000",
+				Utilization:             40.0 / 2048,
+				PendingEffectiveBalance: "42000000000000000000",
+				NextIncrementTopUp:      "950000001000000000", // Up to 43.25 ETH and 1 gwei
+			},
+		},
+		{
+			name:      "compounding validator at the maximum",
+			validator: beaconchatest.Validator(1).Compounding(address).Balances("2050000000000000000000", "2048000000000000000000").Build(),
+			want: &models.EffectiveBalanceHeadroom{
+				MaxEffectiveBalance:     "2048000000000000000000",
+				Headroom:                "0",
+				Utilization:             1,
+				PendingEffectiveBalance: "2048000000000000000000",
+			},
+		},
+		{
+			name:      "exited validator",
+			validator: beaconchatest.Validator(1).Status("exited").Build(),
+		},
+		{
+			name:      "slashed validator",
+			validator: beaconchatest.Validator(1).Slashed(10).Build(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildHeadroom(tt.validator)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("expected no headroom, got %+v", *got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("expected a headroom")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", *tt.want, *got)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// addENSNames sets the primary ENS name of each withdrawal address. ENS lives on
+// mainnet, so names are only resolved for mainnet validators.
+func (s *ValidatorService) addENSNames(ctx context.Context, chain string, overviews map[string]models.ValidatorOverview) {
+	if s.names == nil || chain != "mainnet" {
+		return
+	}
+
+	// Validators of one operator usually share a withdrawal address
+	names := make(map[string]string)
+	for id, overview := range overviews {
+		address := overview.WithdrawalCredentials.Address
+		if address == nil {
+			continue
+		}
+		key := strings.ToLower(*address)
+		name, ok := names[key]
+		if !ok {
+			var err error
+			name, err = s.names.LookupAddress(ctx, *address)
+			if err != nil {
+				slog.Warn("failed to look up ENS name", "address", *address, "error", err)
+			}
+			names[key] = name
+		}
+		if name != "" {
+			overview.WithdrawalCredentials.ENSName = name
+			overviews[id] = overview
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"strconv"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/exits"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// SetExits replaces the in-memory pre-signed exit store, e.g. with one persisted to disk.
+func (s *ValidatorService) SetExits(e *exits.Store) {
+	s.exits = e
+}
+
+// PresignedExits returns the pre-signed exit records of every validator on chain.
+func (s *ValidatorService) PresignedExits(chain string) models.PresignedExitsResponse {
+	response := models.PresignedExitsResponse{Chain: chain, Validators: make(map[string]models.PresignedExit)}
+	for index, r := range s.exits.All(chain) {
+		response.Validators[strconv.Itoa(index)] = r
+	}
+	return response
+}
+
+// SetPresignedExit records that a validator has a pre-signed exit stored.
+func (s *ValidatorService) SetPresignedExit(chain string, index int, r models.PresignedExit) (models.ValidatorPresignedExit, error) {
+	if err := s.exits.Set(chain, index, r); err != nil {
+		return models.ValidatorPresignedExit{}, err
+	}
+	return models.ValidatorPresignedExit{Chain: chain, ValidatorIndex: index, PresignedExit: r}, nil
+}
+
+// DeletePresignedExit removes the pre-signed exit record of a validator and reports
+// whether it had one.
+func (s *ValidatorService) DeletePresignedExit(chain string, index int) (bool, error) {
+	return s.exits.Delete(chain, index)
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/price"
+)
+
+// GetPrice returns the current ETH price in currency.
+func (s *ValidatorService) GetPrice(ctx context.Context, currency string) (models.FiatPrice, error) {
+	result, err := s.prices.Price(ctx, currency)
+	if err != nil {
+		return models.FiatPrice{}, err
+	}
+	return fiatPrice(result), nil
+}
+
+// buildFiat values the total balance and net rewards in currency.
+// Price failures are logged and leave the fiat section out rather than failing the request.
+func (s *ValidatorService) buildFiat(ctx context.Context, currency string, overviews map[string]models.ValidatorOverview, rewards models.ValidatorRewards) *models.FiatValues {
+	if currency == "" || s.prices == nil {
+		return nil
+	}
+
+	result, err := s.prices.Price(ctx, currency)
+	if err != nil {
+		slog.Warn("failed to fetch fiat price", "currency", currency, "error", err)
+		return nil
+	}
+
+	var totalBalance amount.Amount
+	for _, o := range overviews {
+		totalBalance = totalBalance.Add(amount.ParseOrZero(o.CurrentBalance))
+	}
+
+	return &models.FiatValues{
+		FiatPrice:    fiatPrice(result),
+		TotalBalance: totalBalance.ETH() * result.Price,
+		Rewards:      amount.ParseOrZero(rewards.Total).ETH() * result.Price,
+	}
+}
+
+func fiatPrice(r price.Result) models.FiatPrice {
+	return models.FiatPrice{
+		Currency:  r.Currency,
+		Price:     r.Price,
+		Provider:  r.Provider,
+		UpdatedAt: r.Time.UTC(),
+		Stale:     r.Stale,
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// Sections of a validator response that can be selected with
+// ValidatorRequest.Fields, and their names in the JSON response.
+var fieldSections = map[string]string{
+	"overview":    "validators",
+	"rewards":     "rewards",
+	"performance": "performance",
+	"anomalies":   "anomalies",
+	"fiat":        "fiat",
+	"benchmark":   "benchmark",
+	"groups":      "groups",
+	"previous":    "previous",
+}
+
+// maxFields is the number of selectors a request may list.
+const maxFields = 32
+
+// ParseFields parses a comma-separated list of field selectors, each a section
+// such as "overview" or "rewards", optionally followed by a path into it such as
+// "performance.attestations". Paths into overview or groups select the field of
+// every validator or group. An empty list selects everything.
+func ParseFields(param string) ([]string, error) {
+	if param == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, f := range strings.Split(param, ",") {
+		f = strings.TrimSpace(f)
+		path := strings.Split(f, ".")
+		section, ok := fieldSections[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q, sections are: overview, rewards, performance, anomalies, fiat, benchmark, groups, previous", f)
+		}
+		if _, err := selectPath(responseType, nil, append([]string{section}, path[1:]...)); err != nil {
+			return nil, fmt.Errorf("unknown field %q", f)
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) > maxFields {
+		return nil, fmt.Errorf("at most %d fields may be selected", maxFields)
+	}
+	return fields, nil
+}
+
+// fieldSet is the parsed selection of a request.
+type fieldSet []string
+
+// all reports whether the selection covers the whole response.
+func (f fieldSet) all() bool {
+	return len(f) == 0
+}
+
+// wants reports whether any part of section is selected.
+func (f fieldSet) wants(section string) bool {
+	if f.all() {
+		return true
+	}
+	for _, field := range f {
+		if field == section || strings.HasPrefix(field, section+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// wantsField reports whether field of section is selected, directly or as part
+// of the whole section.
+func (f fieldSet) wantsField(section, field string) bool {
+	if f.all() {
+		return true
+	}
+	for _, s := range f {
+		if s == section || s == section+"."+field || strings.HasPrefix(s, section+"."+field+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// responseType is the type field paths are resolved against.
+var responseType = reflect.TypeOf(models.ValidatorResponse{})
+
+// SelectFields reduces a response, a models.ValidatorResponse or its JSON form, to
+// the selected fields, in the shape of its JSON encoding. TimedOutSections,
+// FallbackSections and Window are always kept.
+func SelectFields(response any, fields []string) (map[string]any, error) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep integers exact
+	var full map[string]any
+	if err := decoder.Decode(&full); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]any)
+	for _, f := range fields {
+		path := strings.Split(f, ".")
+		path[0] = fieldSections[path[0]]
+		selected, err := selectPath(responseType, full, path)
+		if err != nil {
+			return nil, err
+		}
+		if m, ok := selected.(map[string]any); ok {
+			merge(result, m)
+		}
+	}
+	for _, k := range []string{"timedOutSections", "fallbackSections", "window"} {
+		if v, ok := full[k]; ok {
+			result[k] = v
+		}
+	}
+	return result, nil
+}
+
+// selectPath returns the part of src, a JSON value of type t, on path, keeping its
+// enclosing objects. Maps are entered without consuming a path element, so the
+// path applies to each of their values. With a nil src it only validates path.
+func selectPath(t reflect.Type, src any, path []string) (any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if len(path) == 0 {
+		return src, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Map:
+		entries, _ := src.(map[string]any)
+		if src == nil {
+			_, err := selectPath(t.Elem(), nil, path)
+			return nil, err
+		}
+		result := make(map[string]any, len(entries))
+		for k, v := range entries {
+			selected, err := selectPath(t.Elem(), v, path)
+			if err != nil {
+				return nil, err
+			}
+			if selected == nil {
+				selected = map[string]any{} // Keep the key of entries without the field
+			}
+			result[k] = selected
+		}
+		return result, nil
+	case reflect.Struct:
+		field, ok := jsonField(t, path[0])
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", path[0])
+		}
+		obj, _ := src.(map[string]any)
+		value, present := obj[path[0]]
+		selected, err := selectPath(field.Type, value, path[1:])
+		if err != nil || src == nil || !present {
+			return nil, err
+		}
+		return map[string]any{path[0]: selected}, nil
+	default:
+		return nil, fmt.Errorf("%q has no fields", path[0])
+	}
+}
+
+// jsonField returns the field of struct type t encoded under name, including the
+// fields of embedded structs.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.Anonymous && tag == "" {
+			if inner, ok := jsonField(f.Type, name); ok {
+				return inner, true
+			}
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		if tag == name && tag != "-" && f.IsExported() {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// merge adds the fields of src to dst, merging objects present in both.
+func merge(dst, src map[string]any) {
+	for k, v := range src {
+		if existing, ok := dst[k].(map[string]any); ok {
+			if m, ok := v.(map[string]any); ok {
+				merge(existing, m)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		param   string
+		want    int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"overview,rewards", 2, false},
+		{"performance.attestations", 1, false},
+		{"performance.attestations.included", 1, false},
+		{"overview.status, overview.currentBalance", 2, false},
+		{"benchmark", 1, false},
+		{"unknown", 0, true},
+		{"rewards.unknown", 0, true},
+		{"overview.status.more", 0, true},
+		{"rewards,", 0, true},
+	}
+	for _, tt := range tests {
+		fields, err := ParseFields(tt.param)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFields(%q) error = %v, wantErr %v", tt.param, err, tt.wantErr)
+			continue
+		}
+		if len(fields) != tt.want {
+			t.Errorf("ParseFields(%q) = %v, want %d fields", tt.param, fields, tt.want)
+		}
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	response := models.ValidatorResponse{
+		Validators: map[string]models.ValidatorOverview{
+			"1": {Status: "active_online", CurrentBalance: "32000000000"},
+			"2": {Status: "exited", CurrentBalance: "0"},
+		},
+		Performance: models.ValidatorPerformance{
+			Attestations: models.AttestationDuties{Included: 9, Assigned: 10},
+		},
+		TimedOutSections: []string{sectionBenchmark},
+	}
+
+	selected, err := SelectFields(response, []string{"overview.status", "performance.attestations"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(selected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Validators       map[string]map[string]any `json:"validators"`
+		Performance      map[string]json.RawMessage
+		Rewards          *json.RawMessage `json:"rewards"`
+		TimedOutSections []string         `json:"timedOutSections"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Validators) != 2 || len(got.Validators["1"]) != 1 || got.Validators["2"]["status"] != "exited" {
+		t.Errorf("expected only the status of each validator, got %v", got.Validators)
+	}
+	if len(got.Performance) != 1 || got.Performance["attestations"] == nil {
+		t.Errorf("expected only the attestations of performance, got %s", data)
+	}
+	if got.Rewards != nil {
+		t.Errorf("expected no rewards, got %s", data)
+	}
+	if len(got.TimedOutSections) != 1 {
+		t.Errorf("expected the timed out sections to be kept, got %v", got.TimedOutSections)
+	}
+}
+
+func TestGetValidatorData_Fields(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1)...)
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	req := models.ValidatorRequest{ValidatorIds: []int{1}, Chain: "mainnet", Range: "24h", Fields: []string{"overview"}}
+
+	if _, err := s.GetValidatorData(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if n := fake.Calls(beaconchatest.MethodGetRewardsAggregate); n != 0 {
+		t.Errorf("expected rewards not to be fetched, got %d calls", n)
+	}
+	if n := fake.Calls(beaconchatest.MethodGetPerformanceAggregate); n != 0 {
+		t.Errorf("expected performance not to be fetched, got %d calls", n)
+	}
+
+	// A partial response does not answer requests for the whole response
+	if _, ok := s.staleResponse(context.Background(), req, true); ok {
+		t.Error("expected the partial response not to be cached")
+	}
+
+	req.Fields = []string{"performance.attestations"}
+	if _, err := s.GetValidatorData(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if n := fake.Calls(beaconchatest.MethodGetRewardsAggregate); n != 0 {
+		t.Errorf("expected rewards not to be fetched, got %d calls", n)
+	}
+	if n := fake.Calls(beaconchatest.MethodGetPerformanceAggregate); n != 1 {
+		t.Errorf("expected performance to be fetched once, got %d calls", n)
+	}
+}
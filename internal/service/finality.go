@@ -0,0 +1,36 @@
+package service
+
+import "github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+
+// finalityEpochs is how many epochs after it completes an epoch is finalized while
+// the chain finalizes normally.
+const finalityEpochs = 2
+
+// finalized reports whether Beaconcha reported data as finalized. Data without a
+// finality is treated as final.
+func finalized(finality string) bool {
+	return finality == "" || finality == "finalized"
+}
+
+// finalityFlag returns the finalized flag of a response entry: false for data that
+// is not final yet, and nil otherwise, so only provisional data is flagged.
+func finalityFlag(finality string) *bool {
+	if finalized(finality) {
+		return nil
+	}
+	f := false
+	return &f
+}
+
+// responseFinalized reports whether no entry of response is flagged as not finalized.
+func responseFinalized(response models.ValidatorResponse) bool {
+	if response.Rewards.Finalized != nil || response.Performance.Finalized != nil {
+		return false
+	}
+	for _, o := range response.Validators {
+		if o.Finalized != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/budget"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestGetValidatorData_Finality(t *testing.T) {
+	validator := beaconchatest.Validator(1).Build()
+	validator.Finality = "not_finalized"
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", validator)
+	fake.SetRewards("mainnet", "24h", models.BeaconchainRewardsAggregateResponse{
+		Data: models.BeaconchainRewardsData{Total: "1000", Finality: "not_finalized"},
+	})
+	fake.SetPerformance("mainnet", "24h", models.BeaconchainPerformanceAggregateResponse{
+		Data: models.BeaconchainPerformanceData{Finality: "finalized"},
+	})
+	req := models.ValidatorRequest{ValidatorIds: []int{1}, Chain: "mainnet", Range: "24h"}
+
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	b := budget.NewManager(4, 0.5, nil)
+	s.SetBudget(b)
+
+	resp, err := s.GetValidatorData(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f := resp.Validators["1"].Finalized; f == nil || *f {
+		t.Errorf("expected the validator flagged as not finalized, got %v", f)
+	}
+	if f := resp.Rewards.Finalized; f == nil || *f {
+		t.Errorf("expected the rewards flagged as not finalized, got %v", f)
+	}
+	if resp.Performance.Finalized != nil {
+		t.Errorf("expected finalized performance not to be flagged, got %v", *resp.Performance.Finalized)
+	}
+
+	// With the budget low, the cached response is served until its data finalized
+	for range 3 {
+		b.Charge("validators")
+	}
+	ageResponses(s, 1)
+	calls := fake.Calls(beaconchatest.MethodGetValidators)
+	if _, err := s.GetValidatorData(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fake.Calls(beaconchatest.MethodGetValidators); got != calls {
+		t.Errorf("expected the unfinalized response to be served, got %d upstream calls", got-calls)
+	}
+
+	ageResponses(s, finalityEpochs)
+	if _, err := s.GetValidatorData(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fake.Calls(beaconchatest.MethodGetValidators); got != calls+1 {
+		t.Errorf("expected the finalized data to be refetched, got %d upstream calls", got-calls)
+	}
+}
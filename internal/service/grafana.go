@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// ErrInvalidGrafanaTarget is returned for Grafana targets that cannot be parsed.
+var ErrInvalidGrafanaTarget = errors.New("invalid target")
+
+// Metrics served to Grafana.
+const (
+	grafanaBalance          = "balance"                   // Summed balance in ETH
+	grafanaEffectiveBalance = "effective_balance"         // Summed effective balance in ETH
+	grafanaRewards          = "rewards"                   // ETH earned during each interval
+	grafanaOnline           = "online"                    // Active validators online
+	grafanaAttestations     = "attestation_effectiveness" // Share of attestations included
+)
+
+// grafanaMetrics lists the metrics in the order they are offered by search.
+var grafanaMetrics = []string{grafanaBalance, grafanaEffectiveBalance, grafanaRewards, grafanaOnline, grafanaAttestations}
+
+// grafanaAttestationRange is the evaluation range of the attestation samples
+// served to Grafana. Samples of different ranges are not comparable, and the last
+// day follows changes closely enough to plot.
+const grafanaAttestationRange = "24h"
+
+// GrafanaTarget is a parsed Grafana target: a metric of a portfolio, or of the
+// validators of a chain.
+type GrafanaTarget struct {
+	Portfolio    string // Empty for a chain and validators
+	Chain        string
+	ValidatorIds []int
+	Metric       string
+}
+
+// ParseGrafanaTarget parses a target such as "main/balance", a metric of a
+// portfolio, or "mainnet:1,2/rewards", a metric of the validators of a chain.
+func ParseGrafanaTarget(target string) (GrafanaTarget, error) {
+	i := strings.LastIndex(target, "/")
+	if i < 0 {
+		return GrafanaTarget{}, fmt.Errorf("%w %q: expected <portfolio>/<metric> or <chain>:<ids>/<metric>", ErrInvalidGrafanaTarget, target)
+	}
+	source, metric := target[:i], target[i+1:]
+	if !slices.Contains(grafanaMetrics, metric) {
+		return GrafanaTarget{}, fmt.Errorf("%w %q: metrics are: %s", ErrInvalidGrafanaTarget, target, strings.Join(grafanaMetrics, ", "))
+	}
+
+	chain, ids, ok := strings.Cut(source, ":")
+	if !ok {
+		if source == "" {
+			return GrafanaTarget{}, fmt.Errorf("%w %q: missing portfolio", ErrInvalidGrafanaTarget, target)
+		}
+		return GrafanaTarget{Portfolio: source, Metric: metric}, nil
+	}
+	t := GrafanaTarget{Chain: chain, Metric: metric}
+	for _, part := range strings.Split(ids, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return GrafanaTarget{}, fmt.Errorf("%w %q: invalid validator ID %q", ErrInvalidGrafanaTarget, target, part)
+		}
+		t.ValidatorIds = append(t.ValidatorIds, id)
+	}
+	return t, nil
+}
+
+// GrafanaSearch lists the targets of every metric of the configured portfolios
+// that contain query.
+func (s *ValidatorService) GrafanaSearch(query string) ([]string, error) {
+	if s.store == nil {
+		return nil, ErrHistoryDisabled
+	}
+
+	targets := []string{}
+	for _, p := range s.portfolios.All() {
+		for _, metric := range grafanaMetrics {
+			target := p.Name + "/" + metric
+			if strings.Contains(strings.ToLower(target), strings.ToLower(query)) {
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets, nil
+}
+
+// GetGrafanaSeries returns the series of target from from to to, one point per
+// interval with recorded history. It reads the history store only, so it makes no
+// upstream calls.
+func (s *ValidatorService) GetGrafanaSeries(ctx context.Context, target GrafanaTarget, from, to time.Time, interval time.Duration) ([][2]float64, error) {
+	if s.store == nil {
+		return nil, ErrHistoryDisabled
+	}
+
+	chain, ids := target.Chain, target.ValidatorIds
+	if target.Portfolio != "" {
+		p, ok := s.portfolios.Get(target.Portfolio)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPortfolio, target.Portfolio)
+		}
+		chain, ids = p.Chain, p.ValidatorIds
+	}
+
+	if target.Metric == grafanaAttestations {
+		samples, err := s.store.AttestationSamples(ctx, store.Query{Chain: chain, ValidatorIndices: ids, From: from, To: to})
+		if err != nil {
+			return nil, fmt.Errorf("load attestation samples: %w", err)
+		}
+		return attestationSeries(samples, interval), nil
+	}
+
+	// Snapshots before from give the state of validators not recorded since
+	snapshots, err := s.store.Snapshots(ctx, store.Query{Chain: chain, ValidatorIndices: ids, From: from.Add(-deltaLookback), To: to})
+	if err != nil {
+		return nil, fmt.Errorf("load snapshots: %w", err)
+	}
+	return snapshotSeries(snapshots, target.Metric, from, interval), nil
+}
+
+// snapshotSeries computes metric at the end of each interval with snapshots from
+// from on, as a point at the start of the interval. Validators count with their
+// latest snapshot, and rewards with the balance changes counted by rewardChange.
+// Snapshots must be ordered by time.
+func snapshotSeries(snapshots []store.Snapshot, metric string, from time.Time, interval time.Duration) [][2]float64 {
+	points := [][2]float64{}
+	latest := make(map[int]store.Snapshot)
+	earned := new(big.Int)
+	var bucket time.Time
+
+	emit := func() {
+		var value float64
+		switch metric {
+		case grafanaBalance, grafanaEffectiveBalance:
+			var total amount.Amount
+			for _, snap := range latest {
+				balance := snap.CurrentBalance
+				if metric == grafanaEffectiveBalance {
+					balance = snap.EffectiveBalance
+				}
+				total = total.Add(amount.ParseOrZero(balance))
+			}
+			value = total.ETH()
+		case grafanaRewards:
+			value = amount.FromBig(earned).ETH()
+		case grafanaOnline:
+			for _, snap := range latest {
+				if snap.Online && strings.HasPrefix(snap.Status, "active") {
+					value++
+				}
+			}
+		}
+		points = append(points, [2]float64{value, float64(bucket.UnixMilli())})
+	}
+
+	for _, snap := range snapshots {
+		prev, seen := latest[snap.ValidatorIndex]
+		if snap.Time.Before(from) {
+			latest[snap.ValidatorIndex] = snap
+			continue
+		}
+
+		start := snap.Time.Truncate(interval)
+		if !start.Equal(bucket) {
+			if !bucket.IsZero() {
+				emit()
+			}
+			bucket = start
+			earned.SetInt64(0)
+		}
+		latest[snap.ValidatorIndex] = snap
+		if seen {
+			if change, ok := rewardChange(prev, snap); ok {
+				earned.Add(earned, change)
+			}
+		}
+	}
+	if !bucket.IsZero() {
+		emit()
+	}
+	return points
+}
+
+// attestationSeries averages the effectiveness of the samples of
+// grafanaAttestationRange per interval, in percent. Samples must be ordered by
+// time.
+func attestationSeries(samples []store.AttestationSample, interval time.Duration) [][2]float64 {
+	points := [][2]float64{}
+	var bucket time.Time
+	var sum float64
+	var n int
+	for _, sample := range samples {
+		if sample.Range != grafanaAttestationRange || sample.Assigned == 0 {
+			continue
+		}
+		start := sample.Time.Truncate(interval)
+		if !start.Equal(bucket) {
+			if n > 0 {
+				points = append(points, [2]float64{100 * sum / float64(n), float64(bucket.UnixMilli())})
+			}
+			bucket, sum, n = start, 0, 0
+		}
+		sum += float64(sample.Included) / float64(sample.Assigned)
+		n++
+	}
+	if n > 0 {
+		points = append(points, [2]float64{100 * sum / float64(n), float64(bucket.UnixMilli())})
+	}
+	return points
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+func TestParseGrafanaTarget(t *testing.T) {
+	tests := []struct {
+		target  string
+		want    GrafanaTarget
+		wantErr bool
+	}{
+		{target: "main/balance", want: GrafanaTarget{Portfolio: "main", Metric: "balance"}},
+		{target: "mainnet:1, 2/rewards", want: GrafanaTarget{Chain: "mainnet", ValidatorIds: []int{1, 2}, Metric: "rewards"}},
+		{target: "main", wantErr: true},
+		{target: "/balance", wantErr: true},
+		{target: "main/apr", wantErr: true},
+		{target: "mainnet:1,x/online", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got, err := ParseGrafanaTarget(tt.target)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidGrafanaTarget) {
+					t.Errorf("expected ErrInvalidGrafanaTarget, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSnapshotSeries(t *testing.T) {
+	start := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	// Validator 1 earns 1e15 wei an hour; validator 2 loses 1 ETH to a withdrawal
+	// in its second hour and is recorded before the range only
+	snapshots := []store.Snapshot{
+		{Time: start.Add(-time.Hour), ValidatorIndex: 2, Status: "active_online", Online: true, CurrentBalance: "32000000000000000000", EffectiveBalance: "32000000000000000000"},
+	}
+	for _, snap := range balanceSnapshots(1, start, 3, func(int) int64 { return 1e15 }) {
+		snap.Online = true
+		snap.EffectiveBalance = "32000000000000000000"
+		snapshots = append(snapshots, snap)
+	}
+	snapshots = append(snapshots, store.Snapshot{Time: start.Add(2 * time.Hour), ValidatorIndex: 2, Status: "active_offline", CurrentBalance: "31000000000000000000", EffectiveBalance: "31000000000000000000"})
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].Time.Before(snapshots[j].Time) })
+
+	ms := func(hours int) float64 { return float64(start.Add(time.Duration(hours) * time.Hour).UnixMilli()) }
+	tests := []struct {
+		metric   string
+		interval time.Duration
+		want     [][2]float64
+	}{
+		{metric: "balance", interval: time.Hour, want: [][2]float64{{64, ms(0)}, {64.001, ms(1)}, {63.002, ms(2)}, {63.003, ms(3)}}},
+		{metric: "effective_balance", interval: 2 * time.Hour, want: [][2]float64{{64, ms(0)}, {63, ms(2)}}},
+		{metric: "rewards", interval: 2 * time.Hour, want: [][2]float64{{0.001, ms(0)}, {0.002, ms(2)}}},
+		{metric: "online", interval: time.Hour, want: [][2]float64{{2, ms(0)}, {2, ms(1)}, {1, ms(2)}, {1, ms(3)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.metric, func(t *testing.T) {
+			got := snapshotSeries(snapshots, tt.metric, start, tt.interval)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i][1] != tt.want[i][1] || math.Abs(got[i][0]-tt.want[i][0]) > 1e-9 {
+					t.Errorf("expected %v, got %v", tt.want, got)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestGetGrafanaSeries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Hour)
+
+	st, err := store.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, snap := range balanceSnapshots(1, now.Add(-3*time.Hour), 3, func(int) int64 { return 1e15 }) {
+		if err := st.RecordSnapshots(ctx, []store.Snapshot{snap}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, sample := range []store.AttestationSample{
+		{Time: now.Add(-2 * time.Hour), Chain: "mainnet", ValidatorIndices: []int{1}, Range: "24h", Included: 90, Assigned: 100},
+		{Time: now.Add(-2*time.Hour + time.Minute), Chain: "mainnet", ValidatorIndices: []int{1}, Range: "24h", Included: 100, Assigned: 100},
+		{Time: now.Add(-time.Hour), Chain: "mainnet", ValidatorIndices: []int{1}, Range: "7d", Included: 50, Assigned: 100},
+	} {
+		if err := st.RecordAttestationSample(ctx, sample); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{
+		{Name: "home", Chain: "mainnet", ValidatorIds: []int{1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewValidatorService(nil, nil, st, nil, portfolios, nil)
+
+	targets, err := s.GrafanaSearch("Balance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"home/balance", "home/effective_balance"}; !reflect.DeepEqual(targets, want) {
+		t.Errorf("expected targets %v, got %v", want, targets)
+	}
+
+	points, err := s.GetGrafanaSeries(ctx, GrafanaTarget{Portfolio: "home", Metric: "rewards"}, now.Add(-2*time.Hour), now.Add(time.Minute), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 3 || math.Abs(points[0][0]-0.001) > 1e-9 || points[0][1] != float64(now.Add(-2*time.Hour).UnixMilli()) {
+		t.Errorf("expected 3 hourly rewards of 0.001 ETH, got %v", points)
+	}
+
+	points, err = s.GetGrafanaSeries(ctx, GrafanaTarget{Chain: "mainnet", ValidatorIds: []int{1}, Metric: "attestation_effectiveness"}, now.Add(-3*time.Hour), now, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 || math.Abs(points[0][0]-95) > 1e-9 {
+		t.Errorf("expected one point averaging the 24h samples to 95%%, got %v", points)
+	}
+
+	if _, err := s.GetGrafanaSeries(ctx, GrafanaTarget{Portfolio: "away", Metric: "balance"}, now.Add(-time.Hour), now, time.Hour); !errors.Is(err, ErrUnknownPortfolio) {
+		t.Errorf("expected ErrUnknownPortfolio, got %v", err)
+	}
+}
+
+func TestGetGrafanaSeries_NoStore(t *testing.T) {
+	s := NewValidatorService(nil, nil, nil, nil, nil, nil)
+	if _, err := s.GrafanaSearch(""); err != ErrHistoryDisabled {
+		t.Errorf("expected ErrHistoryDisabled, got %v", err)
+	}
+	if _, err := s.GetGrafanaSeries(context.Background(), GrafanaTarget{Portfolio: "home", Metric: "balance"}, time.Now().Add(-time.Hour), time.Now(), time.Hour); err != ErrHistoryDisabled {
+		t.Errorf("expected ErrHistoryDisabled, got %v", err)
+	}
+}
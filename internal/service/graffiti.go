@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/graffiti"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// GetGraffiti returns the graffiti of the blocks the validators proposed over
+// evalRange and the clients inferred from it. With a policy, every graffiti is
+// checked against it. The blocks are shared with the income split through the
+// per-epoch aggregate cache. Requests are processed in the same FIFO queue as
+// GetValidatorData.
+func (s *ValidatorService) GetGraffiti(ctx context.Context, chain string, validatorIds []int, evalRange string, policy *regexp.Regexp) (models.GraffitiResponse, error) {
+	release, err := s.acquireQueueSlot(ctx)
+	if err != nil {
+		return models.GraffitiResponse{}, fmt.Errorf("queue wait: %w", err)
+	}
+	defer release()
+
+	blocks, err := s.rangeBlocks(ctx, chain, validatorIds, evalRange)
+	if err != nil {
+		return models.GraffitiResponse{}, fmt.Errorf("fetch blocks: %w", err)
+	}
+	response := buildGraffiti(blocks, policy)
+	response.Range = evalRange
+	return response, nil
+}
+
+// buildGraffiti groups the graffiti of the proposed blocks by validator.
+func buildGraffiti(blocks []models.BeaconchainBlock, policy *regexp.Regexp) models.GraffitiResponse {
+	response := models.GraffitiResponse{
+		Validators: make(map[string]models.ValidatorGraffiti),
+		Clients:    make(map[string]int),
+	}
+	if policy != nil {
+		response.Policy = policy.String()
+	}
+
+	proposed := make([]models.BeaconchainBlock, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Status == "proposed" && b.Validator.Index != nil {
+			proposed = append(proposed, b)
+		}
+	}
+	sort.Slice(proposed, func(i, j int) bool { return proposed[i].Slot > proposed[j].Slot })
+
+	for _, b := range proposed {
+		text := graffiti.Clean(b.Graffiti)
+		clients := graffiti.Fingerprint(text)
+		block := models.BlockGraffiti{
+			Slot:            b.Slot,
+			BlockNumber:     b.BlockNumber,
+			Graffiti:        text,
+			ConsensusClient: clients.Consensus,
+			ExecutionClient: clients.Execution,
+		}
+
+		id := strconv.Itoa(*b.Validator.Index)
+		v := response.Validators[id]
+		if policy != nil {
+			compliant := policy.MatchString(text)
+			block.Compliant = &compliant
+			if v.Compliant == nil || *v.Compliant {
+				v.Compliant = &compliant
+			}
+			if !compliant {
+				response.Violations++
+			}
+		}
+		if v.ConsensusClient == "" {
+			v.ConsensusClient = clients.Consensus
+		}
+		if v.ExecutionClient == "" {
+			v.ExecutionClient = clients.Execution
+		}
+		v.Blocks = append(v.Blocks, block)
+		response.Validators[id] = v
+
+		if clients.Consensus == "" {
+			response.Clients["unknown"]++
+		} else {
+			response.Clients[clients.Consensus]++
+		}
+	}
+	return response
+}
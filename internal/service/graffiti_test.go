@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestGetGraffiti(t *testing.T) {
+	block := func(index int, slot int64, graffiti string) models.BeaconchainBlock {
+		b := beaconchatest.Block(index, slot, "0")
+		b.Graffiti = graffiti
+		return b
+	}
+	missed := block(2, 400, "")
+	missed.Status = "missed"
+	fake := beaconchatest.New()
+	fake.AddBlocks("mainnet",
+		block(1, 100, "fleet-a GE4a2bLH1b2c"),
+		block(1, 300, "fleet-a NM4aTK1b\x00\x00"),
+		block(2, 200, "hello"),
+		missed,
+	)
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		name       string
+		policy     *regexp.Regexp
+		violations int
+		compliant  map[string]bool
+	}{
+		{name: "no policy"},
+		{name: "policy", policy: regexp.MustCompile(`^fleet-a\b`), violations: 1, compliant: map[string]bool{"1": true, "2": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.GetGraffiti(context.Background(), "mainnet", []int{1, 2}, "30d", tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v1 := resp.Validators["1"]
+			if len(v1.Blocks) != 2 || v1.Blocks[0].Slot != 300 || v1.Blocks[0].Graffiti != "fleet-a NM4aTK1b" {
+				t.Fatalf("expected validator 1 blocks latest first, got %+v", v1.Blocks)
+			}
+			if v1.ConsensusClient != "teku" || v1.ExecutionClient != "nethermind" || v1.Blocks[1].ConsensusClient != "lighthouse" {
+				t.Errorf("expected clients of the latest block, got %+v", v1)
+			}
+			if v2 := resp.Validators["2"]; len(v2.Blocks) != 1 || v2.ConsensusClient != "" {
+				t.Errorf("expected one block of validator 2 without clients, got %+v", v2)
+			}
+			if resp.Clients["teku"] != 1 || resp.Clients["lighthouse"] != 1 || resp.Clients["unknown"] != 1 {
+				t.Errorf("unexpected client counts %v", resp.Clients)
+			}
+			if resp.Violations != tt.violations || resp.Range != "30d" {
+				t.Errorf("expected %d violations over 30d, got %+v", tt.violations, resp)
+			}
+			for id, v := range resp.Validators {
+				want, ok := tt.compliant[id]
+				if (v.Compliant != nil) != ok || (ok && *v.Compliant != want) {
+					t.Errorf("validator %s: expected compliant %v (set %v), got %v", id, want, ok, v.Compliant)
+				}
+			}
+		})
+	}
+}
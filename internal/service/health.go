@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/budget"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// healthRange is the window of the performance the health score is computed from.
+const healthRange = "24h"
+
+// Components of the health score, each scored from 0 to 1.
+const (
+	healthOnline      = "online"      // 1 if online
+	healthBeaconscore = "beaconscore" // BeaconScore over the last day
+	healthMisses      = "misses"      // Share of the duties of the last day not missed
+	healthBalance     = "balance"     // 0 if the effective balance is falling, 0.5 if below 32 ETH
+	healthSlashing    = "slashing"    // 0 if slashed or suspected to run twice
+)
+
+// DefaultHealthWeights are the weights of the health score components unless
+// SetHealthWeights changes them.
+var DefaultHealthWeights = map[string]float64{
+	healthOnline:      30,
+	healthBeaconscore: 30,
+	healthMisses:      20,
+	healthBalance:     10,
+	healthSlashing:    10,
+}
+
+// ParseHealthWeights parses health score weights such as "online=40,misses=10".
+// Components left out keep their default weight, and a weight of 0 leaves a
+// component out of the score.
+func ParseHealthWeights(s string) (map[string]float64, error) {
+	weights := make(map[string]float64, len(DefaultHealthWeights))
+	for c, w := range DefaultHealthWeights {
+		weights[c] = w
+	}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok {
+			return nil, fmt.Errorf("invalid weight %q: expected component=weight", entry)
+		}
+		if _, known := DefaultHealthWeights[name]; !known {
+			return nil, fmt.Errorf("unknown component %q, components are: online, beaconscore, misses, balance, slashing", name)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight %q: must be a non-negative number", entry)
+		}
+		weights[name] = weight
+	}
+
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("at least one weight must be positive")
+	}
+	return weights, nil
+}
+
+// SetHealthWeights sets the weights of the health score components.
+func (s *ValidatorService) SetHealthWeights(weights map[string]float64) {
+	s.healthWeights = weights
+}
+
+// GetHealth scores the health of each active validator from 0 to 100, and of the
+// fleet as the mean of its validators. The performance of each validator is
+// fetched on its own, from the cache if it was fetched during the current epoch;
+// a validator whose performance cannot be fetched is scored without it.
+func (s *ValidatorService) GetHealth(ctx context.Context, chain string, validatorIds []int) (models.HealthResponse, error) {
+	data, err := s.GetValidatorData(ctx, models.ValidatorRequest{
+		ValidatorIds: validatorIds,
+		Chain:        chain,
+		Range:        healthRange,
+		Fields:       []string{"overview"},
+	})
+	if err != nil {
+		return models.HealthResponse{}, err
+	}
+
+	performance := make(map[int]*models.BeaconchainPerformanceAggregateResponse)
+	if s.healthWeights[healthBeaconscore] > 0 || s.healthWeights[healthMisses] > 0 {
+		if performance, err = s.healthPerformance(ctx, chain, validatorIds, data.Validators); err != nil {
+			return models.HealthResponse{}, err
+		}
+	}
+
+	response := models.HealthResponse{
+		Chain:      chain,
+		Range:      healthRange,
+		Weights:    s.healthWeights,
+		Validators: make(map[string]models.HealthScore, len(data.Validators)),
+	}
+	var scored []models.HealthScore
+	for _, id := range validatorIds {
+		key := strconv.Itoa(id)
+		o, ok := data.Validators[key]
+		if !ok {
+			continue
+		}
+		score := scoreHealth(o, performance[id], s.healthWeights)
+		response.Validators[key] = score
+		if score.Score != nil {
+			scored = append(scored, score)
+		}
+	}
+	response.Fleet = fleetHealth(scored)
+	return response, nil
+}
+
+// healthPerformance fetches the performance of each active validator in the
+// request queue. Only running out of budget or time fails the request.
+func (s *ValidatorService) healthPerformance(ctx context.Context, chain string, validatorIds []int, overviews map[string]models.ValidatorOverview) (map[int]*models.BeaconchainPerformanceAggregateResponse, error) {
+	release, err := s.acquireQueueSlot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("queue wait: %w", err)
+	}
+	defer release()
+
+	result := make(map[int]*models.BeaconchainPerformanceAggregateResponse, len(validatorIds))
+	for _, id := range validatorIds {
+		if !strings.HasPrefix(overviews[strconv.Itoa(id)].Status, "active") {
+			continue
+		}
+		p, err := s.performanceAggregate(ctx, chain, []int{id}, healthRange)
+		if errors.Is(err, budget.ErrExhausted) || ctx.Err() != nil {
+			return nil, fmt.Errorf("fetch performance of validator %d: %w", id, err)
+		}
+		if err != nil {
+			slog.Warn("failed to fetch validator performance", "validator", id, "error", err)
+			continue
+		}
+		result[id] = p
+	}
+	return result, nil
+}
+
+// scoreHealth scores a validator from its overview and performance, which may be
+// nil. The score is the weighted mean of the components that are known, so an
+// unknown component does not count against the validator. Only active validators
+// are scored, and slashed validators score 0.
+func scoreHealth(o models.ValidatorOverview, p *models.BeaconchainPerformanceAggregateResponse, weights map[string]float64) models.HealthScore {
+	if !strings.HasPrefix(o.Status, "active") {
+		return models.HealthScore{}
+	}
+
+	components := map[string]float64{
+		healthOnline:   boolScore(o.Online),
+		healthSlashing: boolScore(!o.Slashed && o.Doppelganger == nil),
+		healthBalance:  1,
+	}
+	if h := o.EffectiveBalanceHeadroom; h != nil {
+		switch {
+		case slices.Contains(h.Flags, flagEffectiveBalanceDecreasing):
+			components[healthBalance] = 0
+		case slices.Contains(h.Flags, flagTopUpRecommended):
+			components[healthBalance] = 0.5
+		}
+	}
+	if p != nil {
+		if b := p.Data.Beaconscore.Total; b != nil {
+			components[healthBeaconscore] = min(max(*b, 0), 1)
+		}
+		d := p.Data.Duties
+		if assigned := d.Attestation.Assigned + d.Proposal.Assigned + d.SyncCommittee.Assigned; assigned > 0 {
+			missed := d.Attestation.Missed + d.Proposal.Missed + d.SyncCommittee.Missed
+			components[healthMisses] = 1 - float64(missed)/float64(assigned)
+		}
+	}
+
+	score := 0.0
+	if !o.Slashed {
+		var sum, total float64
+		for c, v := range components {
+			sum += weights[c] * v
+			total += weights[c]
+		}
+		if total > 0 {
+			score = 100 * sum / total
+		}
+	}
+	return models.HealthScore{Score: &score, Components: components}
+}
+
+// fleetHealth averages the scores and components of the scored validators.
+func fleetHealth(scores []models.HealthScore) models.HealthScore {
+	if len(scores) == 0 {
+		return models.HealthScore{}
+	}
+
+	var total float64
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, s := range scores {
+		total += *s.Score
+		for c, v := range s.Components {
+			sums[c] += v
+			counts[c]++
+		}
+	}
+	mean := total / float64(len(scores))
+	fleet := models.HealthScore{Score: &mean, Components: make(map[string]float64, len(sums))}
+	for c, sum := range sums {
+		fleet.Components[c] = sum / float64(counts[c])
+	}
+	return fleet
+}
+
+// boolScore scores a condition as 1 if it holds and 0 otherwise.
+func boolScore(ok bool) float64 {
+	if ok {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestParseHealthWeights(t *testing.T) {
+	weights, err := ParseHealthWeights("online=50, misses=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if weights["online"] != 50 || weights["misses"] != 0 || weights["beaconscore"] != DefaultHealthWeights["beaconscore"] {
+		t.Errorf("unexpected weights %v", weights)
+	}
+
+	for _, s := range []string{"uptime=10", "online", "online=-1", "online=0,beaconscore=0,misses=0,balance=0,slashing=0"} {
+		if _, err := ParseHealthWeights(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
+
+func TestScoreHealth(t *testing.T) {
+	beaconscore := 0.9
+	performance := &models.BeaconchainPerformanceAggregateResponse{Data: models.BeaconchainPerformanceData{
+		Beaconscore: models.BeaconchainBeaconscore{Total: &beaconscore},
+		Duties: models.BeaconchainPerformanceDuties{
+			Attestation: models.BeaconchainAttestationDuties{Assigned: 225, Missed: 5},
+			Proposal:    models.BeaconchainProposalDuties{Assigned: 1, Missed: 1},
+		},
+	}}
+
+	tests := []struct {
+		name        string
+		overview    models.ValidatorOverview
+		performance *models.BeaconchainPerformanceAggregateResponse
+		want        float64 // -1 when not scored
+	}{
+		// 30*1 + 30*0.9 + 20*(1-6/226) + 10*1 + 10*1 over 100
+		{"online", models.ValidatorOverview{Status: "active_online", Online: true}, performance, 96.469},
+		{"offline", models.ValidatorOverview{Status: "active_offline"}, performance, 66.469},
+		// Only online, balance and slashing are known: 30 of 50
+		{"offline without performance", models.ValidatorOverview{Status: "active_offline"}, nil, 40},
+		{"effective balance decreasing", models.ValidatorOverview{
+			Status: "active_online", Online: true,
+			EffectiveBalanceHeadroom: &models.EffectiveBalanceHeadroom{Flags: []string{flagEffectiveBalanceDecreasing, flagTopUpRecommended}},
+		}, nil, 80},
+		{"doppelganger", models.ValidatorOverview{Status: "active_online", Online: true, Doppelganger: &models.DoppelgangerSuspicion{}}, nil, 80},
+		{"slashed", models.ValidatorOverview{Status: "active_online", Online: true, Slashed: true}, performance, 0},
+		{"pending", models.ValidatorOverview{Status: "pending"}, performance, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scoreHealth(tt.overview, tt.performance, DefaultHealthWeights).Score
+			if tt.want < 0 {
+				if got != nil {
+					t.Errorf("expected no score, got %v", *got)
+				}
+				return
+			}
+			if got == nil || math.Abs(*got-tt.want) > 0.001 {
+				t.Errorf("got score %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetHealth(t *testing.T) {
+	beaconscore := 1.0
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Build(),
+		beaconchatest.Validator(2).Offline().Build(),
+		beaconchatest.Validator(3).Pending(10).Build(),
+	)
+	fake.SetPerformance("mainnet", "24h", models.BeaconchainPerformanceAggregateResponse{Data: models.BeaconchainPerformanceData{
+		Beaconscore: models.BeaconchainBeaconscore{Total: &beaconscore},
+	}})
+
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	health, err := s.GetHealth(context.Background(), "mainnet", []int{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The pending validator is neither scored nor fetched
+	if n := fake.Calls(beaconchatest.MethodGetPerformanceAggregate); n != 2 {
+		t.Errorf("expected the performance of the 2 active validators, got %d calls", n)
+	}
+	if score := health.Validators["3"].Score; score != nil {
+		t.Errorf("expected no score for the pending validator, got %v", *score)
+	}
+	// The offline validator has no duties to miss: 50 of 80
+	for key, want := range map[string]float64{"1": 100, "2": 62.5} {
+		if score := health.Validators[key].Score; score == nil || math.Abs(*score-want) > 1e-9 {
+			t.Errorf("expected %v for validator %s, got %+v", want, key, health.Validators[key])
+		}
+	}
+	if score := health.Fleet.Score; score == nil || math.Abs(*score-81.25) > 1e-9 {
+		t.Errorf("expected a fleet score of 81.25, got %+v", health.Fleet)
+	}
+	if online := health.Fleet.Components["online"]; online != 0.5 {
+		t.Errorf("expected half the fleet online, got %v", online)
+	}
+}
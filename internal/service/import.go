@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/deposit"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// ErrWrongNetwork is returned for deposit data of another network than the
+// watchlist's chain.
+var ErrWrongNetwork = errors.New("deposit data is for another network")
+
+// statusUnknown is the status of imported keys the beacon chain does not know yet.
+const statusUnknown = "unknown"
+
+// ImportValidators resolves the public keys to validators and adds them to the
+// portfolio name, the watchlist. Keys the beacon chain does not know yet, whose
+// deposits are not processed, have no index and are not added, so they must be
+// imported again later. Validators are added whether activated or not, and the
+// keys without an activated validator are reported.
+func (s *ValidatorService) ImportValidators(ctx context.Context, name string, keys deposit.Keys) (models.WatchlistImportResponse, error) {
+	p, ok := s.portfolios.Get(name)
+	if !ok {
+		return models.WatchlistImportResponse{}, fmt.Errorf("%w: %s", ErrUnknownPortfolio, name)
+	}
+	if keys.Network != "" && keys.Network != p.Chain {
+		return models.WatchlistImportResponse{}, fmt.Errorf("%w: %s, the watchlist is on %s", ErrWrongNetwork, keys.Network, p.Chain)
+	}
+
+	release, err := s.acquireQueueSlot(ctx)
+	if err != nil {
+		return models.WatchlistImportResponse{}, fmt.Errorf("queue wait: %w", err)
+	}
+	validators, err := s.beaconchainClient.GetValidatorsByPublicKey(ctx, p.Chain, keys.PublicKeys)
+	release()
+	if err != nil {
+		return models.WatchlistImportResponse{}, fmt.Errorf("fetch validators: %w", err)
+	}
+
+	byKey := make(map[string]models.BeaconchainValidatorData, len(validators))
+	for _, v := range validators {
+		if v.Validator.Index != nil {
+			byKey[strings.ToLower(v.Validator.PublicKey)] = v
+		}
+	}
+	response := models.WatchlistImportResponse{
+		Watchlist:    name,
+		Chain:        p.Chain,
+		Keys:         len(keys.PublicKeys),
+		Added:        []int{},
+		Existing:     []int{},
+		NotActivated: []models.ImportedKey{},
+	}
+	var ids []int
+	for _, key := range keys.PublicKeys {
+		v, ok := byKey[key]
+		if !ok {
+			response.NotActivated = append(response.NotActivated, models.ImportedKey{PublicKey: key, Status: statusUnknown})
+			continue
+		}
+		ids = append(ids, *v.Validator.Index)
+		if isPending(v) {
+			response.NotActivated = append(response.NotActivated, models.ImportedKey{PublicKey: key, ValidatorIndex: v.Validator.Index, Status: v.Status})
+		}
+	}
+
+	added, err := s.portfolios.Import(name, ids)
+	if err != nil {
+		return models.WatchlistImportResponse{}, fmt.Errorf("add validators: %w", err)
+	}
+	s.recordIdentities(p.Chain, validators)
+	isAdded := make(map[int]bool, len(added))
+	for _, id := range added {
+		isAdded[id] = true
+	}
+	for _, id := range ids {
+		if isAdded[id] {
+			response.Added = append(response.Added, id)
+		} else {
+			response.Existing = append(response.Existing, id)
+		}
+	}
+	return response, nil
+}
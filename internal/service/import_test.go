@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/deposit"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+func TestImportValidators(t *testing.T) {
+	ctx := context.Background()
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Pubkey("0xaa01").Build(),
+		beaconchatest.Validator(2).Pubkey("0xAA02").Build(),
+		beaconchatest.Validator(3).Pubkey("0xaa03").Pending(5).Build(),
+	)
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{{Name: "ops", Chain: "mainnet", ValidatorIds: []int{1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewValidatorService(fake, nil, nil, nil, portfolios, nil)
+
+	resp, err := s.ImportValidators(ctx, "ops", deposit.Keys{PublicKeys: []string{"0xaa01", "0xaa02", "0xaa03", "0xaa04"}, Network: "mainnet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	three := 3
+	want := models.WatchlistImportResponse{
+		Watchlist: "ops",
+		Chain:     "mainnet",
+		Keys:      4,
+		Added:     []int{2, 3},
+		Existing:  []int{1},
+		NotActivated: []models.ImportedKey{
+			{PublicKey: "0xaa03", ValidatorIndex: &three, Status: "pending_queued"},
+			{PublicKey: "0xaa04", Status: "unknown"},
+		},
+	}
+	if !reflect.DeepEqual(resp, want) {
+		t.Errorf("expected %+v, got %+v", want, resp)
+	}
+	if p, _ := portfolios.Get("ops"); !reflect.DeepEqual(p.ValidatorIds, []int{1, 2, 3}) {
+		t.Errorf("expected the portfolio to hold [1 2 3], got %v", p.ValidatorIds)
+	}
+
+	if _, err := s.ImportValidators(ctx, "missing", deposit.Keys{PublicKeys: []string{"0xaa01"}}); !errors.Is(err, ErrUnknownPortfolio) {
+		t.Errorf("expected ErrUnknownPortfolio, got %v", err)
+	}
+	calls := fake.Calls(beaconchatest.MethodGetValidatorsByKey)
+	if _, err := s.ImportValidators(ctx, "ops", deposit.Keys{PublicKeys: []string{"0xaa01"}, Network: "hoodi"}); !errors.Is(err, ErrWrongNetwork) {
+		t.Errorf("expected ErrWrongNetwork, got %v", err)
+	}
+	if got := fake.Calls(beaconchatest.MethodGetValidatorsByKey); got != calls {
+		t.Errorf("expected no upstream call for deposit data of another network, got %d", got-calls)
+	}
+}
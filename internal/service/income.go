@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// dayLayout formats dates in daily time series.
+const dayLayout = "2006-01-02"
+
+// GetDailyIncome returns the combined CL and EL income of the validators for each
+// of the last days UTC days, including today. When currency is set, each day is
+// valued both at that day's historical price, its cost basis, and at the current
+// price.
+// Requests are processed in the same FIFO queue as GetValidatorData.
+func (s *ValidatorService) GetDailyIncome(ctx context.Context, chain string, validatorIds []int, days int, currency string) (models.DailyIncomeResponse, error) {
+	if len(validatorIds) == 0 || days < 1 {
+		return models.DailyIncomeResponse{Days: []models.DailyIncome{}}, nil
+	}
+
+	release, err := s.acquireQueueSlot(ctx)
+	if err != nil {
+		return models.DailyIncomeResponse{}, fmt.Errorf("queue wait: %w", err)
+	}
+	defer release()
+
+	slog.Debug("fetching daily income", "validators", len(validatorIds), "days", days)
+
+	entries, err := s.beaconchainClient.GetDailyRewards(ctx, chain, validatorIds, windowForDays(days))
+	if err != nil {
+		return models.DailyIncomeResponse{}, fmt.Errorf("fetch daily rewards: %w", err)
+	}
+	for i := range entries {
+		fillRewardTotals(&entries[i].Rewards)
+	}
+
+	response := buildDailyIncome(entries, days, time.Now().UTC())
+
+	if currency != "" && s.prices != nil {
+		s.valueDailyIncome(ctx, currency, &response)
+	}
+
+	return response, nil
+}
+
+// valueDailyIncome adds each day's ETH price and the fiat value of that day's income
+// at that price and at the current one, along with their totals. Days without a
+// known price are left without a cost basis, and all days without a current value
+// when the current price is unavailable.
+func (s *ValidatorService) valueDailyIncome(ctx context.Context, currency string, response *models.DailyIncomeResponse) {
+	days := make([]time.Time, 0, len(response.Days))
+	for _, d := range response.Days {
+		if t, err := time.Parse(dayLayout, d.Date); err == nil {
+			days = append(days, t)
+		}
+	}
+
+	prices, err := s.prices.DailyPrices(ctx, currency, days)
+	if err != nil {
+		slog.Warn("failed to fetch daily prices", "currency", currency, "error", err)
+		prices = nil
+	}
+	var currentPrice *float64
+	if current, err := s.prices.Price(ctx, currency); err != nil {
+		slog.Warn("failed to fetch fiat price", "currency", currency, "error", err)
+	} else {
+		currentPrice = &current.Price
+	}
+	if prices == nil && currentPrice == nil {
+		return
+	}
+
+	response.Currency = currency
+	response.CurrentPrice = currentPrice
+	var costBasis, currentValue float64
+	complete := prices != nil
+	for i := range response.Days {
+		d := &response.Days[i]
+		income := amount.ParseOrZero(d.Total)
+		if currentPrice != nil {
+			value := income.ETH() * *currentPrice
+			d.CurrentValue = &value
+			currentValue += value
+		}
+		p, ok := prices[d.Date]
+		if !ok {
+			complete = complete && income.IsZero()
+			continue
+		}
+		value := income.ETH() * p
+		d.Price = &p
+		d.FiatValue = &value
+		costBasis += value
+	}
+	if complete {
+		response.CostBasis = &costBasis
+	}
+	if currentPrice != nil {
+		response.CurrentValue = &currentValue
+	}
+}
+
+// windowForDays returns the smallest evaluation window covering the given number of days.
+func windowForDays(days int) string {
+	switch {
+	case days <= 1:
+		return "24h"
+	case days <= 7:
+		return "7d"
+	case days <= 30:
+		return "30d"
+	case days <= 90:
+		return "90d"
+	default:
+		return "all_time"
+	}
+}
+
+// buildDailyIncome buckets the reward entries by UTC day and returns the last days
+// days ending at now, filling days without entries with zero income.
+func buildDailyIncome(entries []models.BeaconchainRewardsHistoryEntry, days int, now time.Time) models.DailyIncomeResponse {
+	type income struct{ cl, el amount.Amount }
+
+	byDay := make(map[string]income)
+	for _, e := range entries {
+		day := time.Unix(e.Range.Timestamp.Start, 0).UTC().Format(dayLayout)
+		d := byDay[day]
+		el := amount.ParseOrZero(e.Rewards.Proposal.ExecutionLayerReward)
+		d.cl = d.cl.Add(amount.ParseOrZero(e.Rewards.Total).Sub(el))
+		d.el = d.el.Add(el)
+		byDay[day] = d
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	result := models.DailyIncomeResponse{Days: make([]models.DailyIncome, 0, days)}
+
+	for i := days - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i).Format(dayLayout)
+		d := byDay[day]
+		result.Days = append(result.Days, models.DailyIncome{
+			Date:           day,
+			ConsensusLayer: d.cl.String(),
+			ExecutionLayer: d.el.String(),
+			Total:          d.cl.Add(d.el).String(),
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/price"
+)
+
+func TestBuildDailyIncome(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	day := func(d int) models.BeaconchainResultRange {
+		start := time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC)
+		return models.BeaconchainResultRange{
+			Timestamp: models.BeaconchainTimestampRange{Start: start.Unix(), End: start.AddDate(0, 0, 1).Unix()},
+		}
+	}
+
+	entries := []models.BeaconchainRewardsHistoryEntry{
+		// Outside the requested window
+		{Range: day(1), Rewards: models.BeaconchainRewardsData{Total: "999"}},
+		{Range: day(8), Rewards: models.BeaconchainRewardsData{
+			Total:    "1500",
+			Proposal: models.BeaconchainProposalRewards{ExecutionLayerReward: "1000"},
+		}},
+		{Range: day(10), Rewards: models.BeaconchainRewardsData{Total: "200"}},
+	}
+
+	got := buildDailyIncome(entries, 3, now)
+
+	want := []models.DailyIncome{
+		{Date: "2026-03-08", ConsensusLayer: "500", ExecutionLayer: "1000", Total: "1500"},
+		{Date: "2026-03-09", ConsensusLayer: "0", ExecutionLayer: "0", Total: "0"},
+		{Date: "2026-03-10", ConsensusLayer: "200", ExecutionLayer: "0", Total: "200"},
+	}
+
+	if len(got.Days) != len(want) {
+		t.Fatalf("expected %d days, got %d", len(want), len(got.Days))
+	}
+	for i := range want {
+		if got.Days[i] != want[i] {
+			t.Errorf("day %d: expected %+v, got %+v", i, want[i], got.Days[i])
+		}
+	}
+}
+
+func TestWindowForDays(t *testing.T) {
+	tests := map[int]string{1: "24h", 7: "7d", 8: "30d", 30: "30d", 90: "90d", 120: "all_time"}
+	for days, want := range tests {
+		if got := windowForDays(days); got != want {
+			t.Errorf("windowForDays(%d): expected %s, got %s", days, want, got)
+		}
+	}
+}
+
+// historicalPrices quotes 2000 now and the listed prices on past days.
+type historicalPrices map[string]float64
+
+func (historicalPrices) Name() string { return "test" }
+
+func (historicalPrices) Quote(ctx context.Context, currency string) (price.Quote, error) {
+	return price.Quote{Currency: currency, Price: 2000, Provider: "test", Time: time.Now()}, nil
+}
+
+func (h historicalPrices) DailyQuote(ctx context.Context, currency string, day time.Time) (price.Quote, error) {
+	p, ok := h[day.Format(dayLayout)]
+	if !ok {
+		return price.Quote{}, errors.New("no data")
+	}
+	return price.Quote{Currency: currency, Price: p, Provider: "test", Time: day}, nil
+}
+
+func TestValueDailyIncome(t *testing.T) {
+	today := time.Now().UTC()
+	day := func(ago int) string { return today.AddDate(0, 0, -ago).Format(dayLayout) }
+	eth := "1000000000000000000"
+
+	tests := []struct {
+		name          string
+		prices        historicalPrices
+		firstIncome   string
+		wantCostBasis float64 // 0 for none
+	}{
+		{name: "all days priced", prices: historicalPrices{day(2): 1000, day(1): 1500}, firstIncome: eth, wantCostBasis: 4500},
+		{name: "a day with income unpriced", prices: historicalPrices{day(2): 1000}, firstIncome: eth},
+		{name: "only a day without income unpriced", prices: historicalPrices{day(1): 1500}, firstIncome: "0", wantCostBasis: 3500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prices := price.NewService([]price.Provider{tt.prices}, time.Minute, time.Hour)
+			s := NewValidatorService(nil, nil, nil, prices, nil, nil)
+
+			response := models.DailyIncomeResponse{Days: []models.DailyIncome{
+				{Date: day(2), Total: tt.firstIncome},
+				{Date: day(1), Total: eth},
+				{Date: day(0), Total: eth},
+			}}
+			s.valueDailyIncome(context.Background(), "usd", &response)
+
+			if response.CurrentPrice == nil || *response.CurrentPrice != 2000 {
+				t.Errorf("expected a current price of 2000, got %v", response.CurrentPrice)
+			}
+			for _, d := range response.Days {
+				if d.CurrentValue == nil || (d.Total == eth && *d.CurrentValue != 2000) {
+					t.Errorf("%s: expected the income at the current price, got %v", d.Date, d.CurrentValue)
+				}
+			}
+			if response.CurrentValue == nil {
+				t.Error("expected a total current value")
+			}
+			var costBasis float64
+			if response.CostBasis != nil {
+				costBasis = *response.CostBasis
+			}
+			if costBasis != tt.wantCostBasis {
+				t.Errorf("expected a cost basis of %v, got %v", tt.wantCostBasis, costBasis)
+			}
+		})
+	}
+}
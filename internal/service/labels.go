@@ -0,0 +1,74 @@
+package service
+
+import (
+	"strconv"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/labels"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// SetLabels replaces the in-memory label store, e.g. with one persisted to disk.
+func (s *ValidatorService) SetLabels(l *labels.Store) {
+	s.labels = l
+}
+
+// Labels returns the labels of every labeled validator on chain.
+func (s *ValidatorService) Labels(chain string) models.LabelsResponse {
+	response := models.LabelsResponse{Chain: chain, Validators: make(map[string]map[string]string)}
+	for index, l := range s.labels.All(chain) {
+		response.Validators[strconv.Itoa(index)] = l
+	}
+	return response
+}
+
+// SetValidatorLabels replaces the labels of a validator.
+func (s *ValidatorService) SetValidatorLabels(chain string, index int, l map[string]string) (models.ValidatorLabels, error) {
+	if err := s.labels.Set(chain, index, l); err != nil {
+		return models.ValidatorLabels{}, err
+	}
+	current := s.labels.Get(chain, index)
+	if current == nil {
+		current = map[string]string{}
+	}
+	return models.ValidatorLabels{Chain: chain, ValidatorIndex: index, Labels: current}, nil
+}
+
+// LabeledValidators returns the validators on chain with all of the labels selected.
+func (s *ValidatorService) LabeledValidators(chain string, selectors []labels.Selector) []int {
+	return s.labels.Select(chain, selectors)
+}
+
+// annotate sets the labels, notes, pre-signed exit flag and doppelganger flag of each
+// validator in response and, if requested, the totals per value of the groupBy
+// label. The overviews are copied, since they may be shared with the response cache.
+func (s *ValidatorService) annotate(req models.ValidatorRequest, response *models.ValidatorResponse) {
+	overviews := make(map[string]models.ValidatorOverview, len(response.Validators))
+	for id, o := range response.Validators {
+		if index, err := strconv.Atoi(id); err == nil {
+			o.Labels = s.labels.Get(req.Chain, index)
+			if note, ok := s.notes.Get(req.Chain, index); ok {
+				o.Notes = note.Text
+			}
+			o.PresignedExit = s.exits.Has(req.Chain, index)
+			o.Doppelganger = s.doppelganger(req.Chain, index)
+		}
+		overviews[id] = o
+	}
+	response.Validators = overviews
+
+	if req.GroupBy == "" {
+		return
+	}
+	groups := make(map[string]map[string]models.ValidatorOverview)
+	for id, o := range overviews {
+		value := o.Labels[req.GroupBy]
+		if groups[value] == nil {
+			groups[value] = make(map[string]models.ValidatorOverview)
+		}
+		groups[value][id] = o
+	}
+	response.Groups = make(map[string]models.DashboardTotals, len(groups))
+	for value, o := range groups {
+		response.Groups[value] = dashboardTotals(o)
+	}
+}
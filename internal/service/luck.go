@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cost"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// syncCommitteeSize is the number of validators in a sync committee.
+const syncCommitteeSize = 512
+
+// blockValueSample is the number of network blocks the median block value is
+// taken over, one upstream request.
+const blockValueSample = 100
+
+// A fleet is suspected of a broken MEV-boost setup when it proposed at least
+// mevBoostSuspectBlocks blocks and earned less than mevBoostSuspectLuck percent of
+// the median network block on average. Builder payments make most network blocks
+// worth several times a locally built one, so a fleet missing them falls far
+// below the median, while a few unlucky blocks do not.
+const (
+	mevBoostSuspectBlocks = 5
+	mevBoostSuspectLuck   = 50
+)
+
+// networkSizeEntry holds the size of a chain's active validator set.
+type networkSizeEntry struct {
+	fetched time.Time
+	size    models.BeaconchainNetworkSize
+}
+
+// blockValueEntry holds the median block value of a chain over a range.
+type blockValueEntry struct {
+	fetched time.Time
+	median  amount.Amount
+	sample  int
+}
+
+// buildLuck compares the duties and blocks of the validators ids with those of
+// the network over evalRange. Returns nil when neither can be compared.
+func (s *ValidatorService) buildLuck(ctx context.Context, chain string, ids []int, evalRange string, response models.ValidatorResponse) *models.Luck {
+	luck := &models.Luck{BlockValue: s.buildBlockValue(ctx, chain, ids, evalRange, response)}
+	luck.Proposals, luck.SyncCommittees = s.dutyLucks(ctx, chain, response)
+	if luck.Proposals == nil && luck.BlockValue == nil {
+		return nil
+	}
+	return luck
+}
+
+// dutyLucks compares the proposal and sync committee duties of response with the
+// number expected over its range. Both are drawn in proportion to effective
+// balance, so a validator is expected to get a share of the slots equal to its
+// share of the network's effective balance. The validators' attestation duties,
+// one per active epoch, measure how long they were eligible, at their current mean
+// effective balance against the current network size. Returns nils without
+// attestation duties, active validators or network size.
+func (s *ValidatorService) dutyLucks(ctx context.Context, chain string, response models.ValidatorResponse) (proposals, syncCommittees *models.DutyLuck) {
+	epochs := response.Performance.Attestations.Assigned
+	if epochs == 0 {
+		return nil, nil
+	}
+	spec, err := chainspec.ForChain(chain)
+	if err != nil {
+		return nil, nil
+	}
+
+	var staked amount.Amount
+	active := 0
+	for _, o := range response.Validators {
+		if strings.HasPrefix(o.Status, "active") {
+			staked = staked.Add(amount.ParseOrZero(o.EffectiveBalance))
+			active++
+		}
+	}
+	if active == 0 {
+		return nil, nil
+	}
+	size, ok := s.networkSize(ctx, chain)
+	if !ok {
+		return nil, nil
+	}
+	share, ok := staked.Div(int64(active)).Percent(amount.ParseOrZero(size.ActiveEffectiveBalance))
+	if !ok {
+		return nil, nil
+	}
+
+	slots := float64(epochs) * float64(spec.SlotsPerEpoch) * share / 100
+	return dutyLuck(slots, response.Performance.Proposals.Assigned),
+		dutyLuck(slots*syncCommitteeSize, response.Performance.SyncCommittees.Assigned)
+}
+
+// dutyLuck compares actual duties with expected ones.
+func dutyLuck(expected float64, actual int) *models.DutyLuck {
+	result := &models.DutyLuck{Expected: expected, Actual: actual}
+	if expected > 0 {
+		luck := 100 * float64(actual) / expected
+		result.Luck = &luck
+	}
+	return result
+}
+
+// buildBlockValue compares the average execution layer reward of the blocks the
+// validators ids proposed over evalRange with the median network block over the
+// range. The blocks are only fetched when response has successful proposals.
+// Returns nil for all_time, without proposed blocks, or when the network blocks
+// cannot be fetched.
+func (s *ValidatorService) buildBlockValue(ctx context.Context, chain string, ids []int, evalRange string, response models.ValidatorResponse) *models.BlockValueLuck {
+	days, ok := rangeDays[evalRange]
+	if !ok || response.Performance.Proposals.Successful == 0 {
+		return nil
+	}
+	spec, err := chainspec.ForChain(chain)
+	if err != nil {
+		return nil
+	}
+	blocks, err := s.rangeBlocks(ctx, chain, ids, evalRange)
+	if err != nil {
+		slog.Warn("failed to fetch blocks for block value luck", "chain", chain, "error", err)
+		return nil
+	}
+
+	result := &models.BlockValueLuck{}
+	var total amount.Amount
+	var latest int64
+	for _, b := range blocks {
+		if b.Status != "proposed" {
+			continue
+		}
+		result.Blocks++
+		if b.Relay != nil {
+			result.RelayedBlocks++
+		}
+		total = total.Add(amount.ParseOrZero(b.ExecutionReward)).Add(amount.ParseOrZero(b.MevReward))
+		latest = max(latest, b.BlockNumber)
+	}
+	if result.Blocks == 0 || latest == 0 {
+		return nil
+	}
+
+	span := int64(days*24*3600) / spec.SecondsPerSlot
+	median, sample, ok := s.networkBlockValue(ctx, chain, evalRange, latest, span)
+	if !ok {
+		return nil
+	}
+	average := total.Div(int64(result.Blocks))
+	result.Average, result.NetworkMedian, result.NetworkSample = average.String(), median.String(), sample
+	if luck, ok := average.Percent(median); ok {
+		result.Luck = &luck
+		result.MevBoostSuspect = result.Blocks >= mevBoostSuspectBlocks && luck < mevBoostSuspectLuck
+	}
+	return result
+}
+
+// networkBlockValue returns the median execution layer reward of blockValueSample
+// network blocks spread over the span blocks up to end, and the number of blocks
+// it was taken over, from cache when fetched within networkCacheTTL. Near the end
+// of the credit budget an older entry is preferred over fetching.
+func (s *ValidatorService) networkBlockValue(ctx context.Context, chain, evalRange string, end, span int64) (amount.Amount, int, bool) {
+	key := chain + "/" + evalRange
+	s.networkMu.Lock()
+	cached, ok := s.blockValues[key]
+	s.networkMu.Unlock()
+	if ok && (time.Since(cached.fetched) < networkCacheTTL || s.budget.Low()) {
+		cost.AddCacheHit(ctx)
+		return cached.median, cached.sample, true
+	}
+
+	blocks, err := s.beaconchainClient.GetExecutionBlocks(ctx, chain, sampleBlockNumbers(end, span, blockValueSample))
+	if err != nil {
+		slog.Warn("failed to fetch network blocks", "chain", chain, "range", evalRange, "error", err)
+		return cached.median, cached.sample, ok
+	}
+	if len(blocks) == 0 {
+		return amount.Amount{}, 0, false
+	}
+	rewards := make([]amount.Amount, 0, len(blocks))
+	for _, b := range blocks {
+		rewards = append(rewards, amount.ParseOrZero(b.ProducerReward))
+	}
+	median := medianAmount(rewards)
+
+	s.networkMu.Lock()
+	s.blockValues[key] = blockValueEntry{fetched: time.Now(), median: median, sample: len(blocks)}
+	s.networkMu.Unlock()
+	return median, len(blocks), true
+}
+
+// sampleBlockNumbers returns n block numbers evenly spread over the span blocks
+// up to end, latest first. Missed slots make the span reach a little further back
+// than its slots.
+func sampleBlockNumbers(end, span int64, n int) []int64 {
+	step := max(span/int64(n), 1)
+	numbers := make([]int64, 0, n)
+	for i := int64(0); i < int64(n) && end-i*step > 0; i++ {
+		numbers = append(numbers, end-i*step)
+	}
+	return numbers
+}
+
+// medianAmount returns the median of amounts, the mean of the middle two for an
+// even count. amounts is sorted in place.
+func medianAmount(amounts []amount.Amount) amount.Amount {
+	if len(amounts) == 0 {
+		return amount.Amount{}
+	}
+	sort.Slice(amounts, func(i, j int) bool { return amounts[i].Cmp(amounts[j]) < 0 })
+	mid := len(amounts) / 2
+	if len(amounts)%2 == 1 {
+		return amounts[mid]
+	}
+	return amounts[mid-1].Add(amounts[mid]).Div(2)
+}
+
+// networkSize returns the active validator set of chain, from cache when fetched
+// within networkCacheTTL. Near the end of the credit budget an older entry is
+// preferred over fetching.
+func (s *ValidatorService) networkSize(ctx context.Context, chain string) (models.BeaconchainNetworkSize, bool) {
+	s.networkMu.Lock()
+	cached, ok := s.networkSizes[chain]
+	s.networkMu.Unlock()
+	if ok && (time.Since(cached.fetched) < networkCacheTTL || s.budget.Low()) {
+		cost.AddCacheHit(ctx)
+		return cached.size, true
+	}
+
+	size, err := s.beaconchainClient.GetNetworkSize(ctx, chain)
+	if err != nil {
+		slog.Warn("failed to fetch network size", "chain", chain, "error", err)
+		return cached.size, ok
+	}
+
+	s.networkMu.Lock()
+	s.networkSizes[chain] = networkSizeEntry{fetched: time.Now(), size: *size}
+	s.networkMu.Unlock()
+	return *size, true
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestGetValidatorData_Luck(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Build(),
+		beaconchatest.Validator(2).Build(),
+		beaconchatest.Validator(3).Pending(10).Build(),
+	)
+	for _, r := range []string{"30d", "7d"} {
+		fake.SetPerformance("mainnet", r, models.BeaconchainPerformanceAggregateResponse{
+			Data: models.BeaconchainPerformanceData{Duties: models.BeaconchainPerformanceDuties{
+				Attestation:   models.BeaconchainAttestationDuties{Assigned: 6250, Included: 6250},
+				Proposal:      models.BeaconchainProposalDuties{Assigned: 3, Successful: 3},
+				SyncCommittee: models.BeaconchainSyncCommitteeDuties{Assigned: 512, Successful: 512},
+			}},
+		})
+	}
+	// 32 ETH of 3.2M ETH staked
+	fake.SetNetworkSize("mainnet", models.BeaconchainNetworkSize{ActiveValidators: 100000, ActiveEffectiveBalance: "3200000000000000000000000"})
+
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+	req := models.ValidatorRequest{ValidatorIds: []int{1, 2, 3}, Chain: "mainnet", Range: "30d"}
+	resp, err := s.GetValidatorData(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	luck := resp.Performance.Luck
+	if luck == nil {
+		t.Fatal("expected luck")
+	}
+	tests := []struct {
+		name     string
+		got      *models.DutyLuck
+		expected float64
+		actual   int
+		luck     float64
+	}{
+		// 6250 epochs of 32 slots at a share of 0.001%
+		{name: "proposals", got: luck.Proposals, expected: 2, actual: 3, luck: 150},
+		{name: "sync committees", got: luck.SyncCommittees, expected: 1024, actual: 512, luck: 50},
+	}
+	for _, tt := range tests {
+		if tt.got == nil {
+			t.Errorf("%s: expected luck", tt.name)
+			continue
+		}
+		if math.Abs(tt.got.Expected-tt.expected) > 1e-9 || tt.got.Actual != tt.actual || tt.got.Luck == nil || math.Abs(*tt.got.Luck-tt.luck) > 1e-9 {
+			t.Errorf("%s: expected %v of %v expected duties and %v%% luck, got %+v", tt.name, tt.actual, tt.expected, tt.luck, tt.got)
+		}
+	}
+
+	// The network size is cached
+	req.Range = "7d"
+	if _, err := s.GetValidatorData(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if n := fake.Calls(beaconchatest.MethodGetNetworkSize); n != 1 {
+		t.Errorf("expected the network size to be fetched once, got %d calls", n)
+	}
+
+	// Without attestation duties there is nothing to expect, and no blocks to compare
+	if resp.Performance.Luck = s.buildLuck(ctx, "mainnet", req.ValidatorIds, "all_time", models.ValidatorResponse{Validators: resp.Validators}); resp.Performance.Luck != nil {
+		t.Errorf("expected no luck without duties, got %+v", resp.Performance.Luck)
+	}
+}
+
+func TestGetValidatorData_BlockValue(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2)...)
+	fake.SetRewards("mainnet", "24h", models.BeaconchainRewardsAggregateResponse{
+		Data: models.BeaconchainRewardsData{Total: "50000000000000000", Proposal: models.BeaconchainProposalRewards{ExecutionLayerReward: "50000000000000000"}},
+	})
+	fake.SetPerformance("mainnet", "24h", models.BeaconchainPerformanceAggregateResponse{
+		Data: models.BeaconchainPerformanceData{Duties: models.BeaconchainPerformanceDuties{
+			Proposal: models.BeaconchainProposalDuties{Assigned: 5, Successful: 5},
+		}},
+	})
+	// Five locally built blocks of 0.01 ETH, as if MEV-boost were not running
+	for i := int64(0); i < 5; i++ {
+		b := beaconchatest.Block(int(1+i%2), 9000+i, "10000000000000000")
+		b.BlockNumber = 1000 + i
+		fake.AddBlocks("mainnet", b)
+	}
+	// Network blocks of 0.05 ETH, sampled every 72 blocks over the 7200 slots of a day
+	for n := int64(1004); n > 0; n -= 72 {
+		fake.AddExecutionBlocks("mainnet", models.BeaconchainExecutionBlock{BlockNumber: n, ProducerReward: "50000000000000000"})
+	}
+
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+	req := models.ValidatorRequest{ValidatorIds: []int{1, 2}, Chain: "mainnet", Range: "24h"}
+	resp, err := s.GetValidatorData(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Performance.Luck == nil || resp.Performance.Luck.BlockValue == nil {
+		t.Fatalf("expected the block value luck, got %+v", resp.Performance.Luck)
+	}
+	got := *resp.Performance.Luck.BlockValue
+	if got.Blocks != 5 || got.RelayedBlocks != 0 || got.Average != "10000000000000000" || got.NetworkMedian != "50000000000000000" || got.NetworkSample != 14 {
+		t.Errorf("unexpected block value %+v", got)
+	}
+	if got.Luck == nil || *got.Luck != 20 || !got.MevBoostSuspect {
+		t.Errorf("expected 20%% luck flagged as a broken MEV-boost setup, got %+v", got)
+	}
+
+	// The blocks are shared with the income split and cached with the network median
+	req.Fields = []string{"performance"}
+	if _, err := s.GetValidatorData(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if n := fake.Calls(beaconchatest.MethodGetBlocks); n != 1 {
+		t.Errorf("expected the blocks to be fetched once, got %d calls", n)
+	}
+	if n := fake.Calls(beaconchatest.MethodGetExecutionBlocks); n != 1 {
+		t.Errorf("expected the network blocks to be fetched once, got %d calls", n)
+	}
+}
+
+func TestMedianAmount(t *testing.T) {
+	tests := []struct {
+		amounts []string
+		want    string
+	}{
+		{amounts: nil, want: "0"},
+		{amounts: []string{"30", "10", "20"}, want: "20"},
+		{amounts: []string{"40", "10", "30", "20"}, want: "25"},
+	}
+	for _, tt := range tests {
+		amounts := make([]amount.Amount, 0, len(tt.amounts))
+		for _, a := range tt.amounts {
+			amounts = append(amounts, amount.ParseOrZero(a))
+		}
+		if got := medianAmount(amounts).String(); got != tt.want {
+			t.Errorf("medianAmount(%v) = %s, want %s", tt.amounts, got, tt.want)
+		}
+	}
+}
+
+func TestSampleBlockNumbers(t *testing.T) {
+	if got := sampleBlockNumbers(1000, 300, 3); !slices.Equal(got, []int64{1000, 900, 800}) {
+		t.Errorf("expected evenly spread blocks, got %v", got)
+	}
+	if got := sampleBlockNumbers(150, 300, 3); !slices.Equal(got, []int64{150, 50}) {
+		t.Errorf("expected no blocks before genesis, got %v", got)
+	}
+}
+
+func TestDutyLuck(t *testing.T) {
+	if got := dutyLuck(0, 1); got.Luck != nil {
+		t.Errorf("expected no luck without expected duties, got %v", *got.Luck)
+	}
+	if got := dutyLuck(4, 1); got.Luck == nil || *got.Luck != 25 {
+		t.Errorf("expected 25%% luck, got %+v", got)
+	}
+}
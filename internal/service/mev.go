@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/relays"
+)
+
+// SetRelays enables checks of the MEV-boost relay registrations of mainnet
+// validators, flagging registrations signed more than maxAge ago as stale.
+func (s *ValidatorService) SetRelays(c *relays.Checker, maxAge time.Duration) {
+	s.relays = c
+	s.relayMaxAge = maxAge
+}
+
+// addMEVRegistrations sets the relay registrations of the pending and active
+// validators that are not slashed, which are the ones that may still propose.
+// Relays are only checked on mainnet.
+func (s *ValidatorService) addMEVRegistrations(ctx context.Context, chain string, validators []models.BeaconchainValidatorData, overviews map[string]models.ValidatorOverview) {
+	if s.relays == nil || chain != "mainnet" {
+		return
+	}
+
+	ids := make(map[string]string) // Validator ID by public key
+	var pubkeys []string
+	for _, v := range validators {
+		if v.Validator.Index == nil || v.Validator.PublicKey == "" || v.Slashed ||
+			!(strings.HasPrefix(v.Status, "pending") || strings.HasPrefix(v.Status, "active")) {
+			continue
+		}
+		ids[v.Validator.PublicKey] = strconv.Itoa(*v.Validator.Index)
+		pubkeys = append(pubkeys, v.Validator.PublicKey)
+	}
+	if len(pubkeys) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for pubkey, registrations := range s.relays.Check(ctx, pubkeys) {
+		overview, ok := overviews[ids[pubkey]]
+		if !ok {
+			continue
+		}
+		overview.MEV = s.mevRegistrations(registrations, now)
+		overviews[ids[pubkey]] = overview
+	}
+}
+
+// mevRegistrations converts the registrations of a validator at every relay.
+func (s *ValidatorService) mevRegistrations(registrations []relays.Registration, now time.Time) *models.MEVRegistrations {
+	result := &models.MEVRegistrations{Relays: []string{}, Registrations: make([]models.RelayRegistration, 0, len(registrations))}
+	feeRecipient := ""
+	for _, r := range registrations {
+		registration := models.RelayRegistration{Relay: r.Relay, Registered: r.Registered}
+		switch {
+		case r.Err != nil:
+			registration.Error = r.Err.Error()
+			slog.Warn("failed to check relay registration", "relay", r.Relay, "error", r.Err)
+		case r.Registered:
+			result.Relays = append(result.Relays, r.Relay)
+			registration.FeeRecipient = r.FeeRecipient
+			registration.GasLimit = r.GasLimit
+			if !r.Timestamp.IsZero() {
+				ts := r.Timestamp
+				registration.Timestamp = &ts
+			}
+			registration.Stale = s.relayMaxAge > 0 && now.Sub(r.Timestamp) > s.relayMaxAge
+			result.Stale = result.Stale || registration.Stale
+			if feeRecipient != "" && !strings.EqualFold(feeRecipient, r.FeeRecipient) {
+				result.FeeRecipientMismatch = true
+			}
+			feeRecipient = r.FeeRecipient
+		}
+		result.Registrations = append(result.Registrations, registration)
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/relays"
+)
+
+// newRelayServer serves registrations signed at signed with the fee recipient of
+// each registered public key.
+func newRelayServer(t *testing.T, signed time.Time, feeRecipients map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pubkey := r.URL.Query().Get("pubkey")
+		recipient, ok := feeRecipients[pubkey]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"message":{"fee_recipient":%q,"gas_limit":"36000000","timestamp":"%d","pubkey":%q}}`, recipient, signed.Unix(), pubkey)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetValidatorData_MEVRegistrations(t *testing.T) {
+	validator := func(index int, pubkey string) models.BeaconchainValidatorData {
+		v := beaconchatest.Validator(index).Build()
+		v.Validator.PublicKey = pubkey
+		return v
+	}
+	exited := validator(4, "0xd4")
+	exited.Status = "exited"
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", validator(1, "0xa1"), validator(2, "0xb2"), validator(3, "0xc3"), exited)
+
+	fresh := newRelayServer(t, time.Now().Add(-time.Hour), map[string]string{"0xa1": "0x01", "0xb2": "0x01"})
+	old := newRelayServer(t, time.Now().Add(-30*24*time.Hour), map[string]string{"0xb2": "0x02"})
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	s.SetRelays(relays.NewChecker([]relays.Relay{{Name: "fresh", URL: fresh.URL}, {Name: "old", URL: old.URL}}, time.Hour, time.Second), 7*24*time.Hour)
+
+	resp, err := s.GetValidatorData(context.Background(), models.ValidatorRequest{ValidatorIds: []int{1, 2, 3, 4}, Chain: "mainnet", Range: "24h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		id       string
+		relays   string
+		stale    bool
+		mismatch bool
+	}{
+		{id: "1", relays: "fresh"},
+		{id: "2", relays: "fresh,old", stale: true, mismatch: true},
+		{id: "3", relays: ""},
+	}
+	for _, tt := range tests {
+		mev := resp.Validators[tt.id].MEV
+		if mev == nil {
+			t.Errorf("validator %s: expected relay registrations", tt.id)
+			continue
+		}
+		if got := strings.Join(mev.Relays, ","); got != tt.relays || mev.Stale != tt.stale || mev.FeeRecipientMismatch != tt.mismatch || len(mev.Registrations) != 2 {
+			t.Errorf("validator %s: expected relays %q, stale %v and mismatch %v, got %+v", tt.id, tt.relays, tt.stale, tt.mismatch, mev)
+		}
+	}
+	if r := resp.Validators["2"].MEV.Registrations[1]; !r.Stale || r.FeeRecipient != "0x02" || r.GasLimit != 36000000 || r.Timestamp == nil {
+		t.Errorf("expected the old registration to be stale, got %+v", r)
+	}
+	if resp.Validators["4"].MEV != nil {
+		t.Errorf("expected no check of exited validators, got %+v", resp.Validators["4"].MEV)
+	}
+
+	// Only on mainnet
+	fake.AddValidators("hoodi", validator(1, "0xa1"))
+	resp, err = s.GetValidatorData(context.Background(), models.ValidatorRequest{ValidatorIds: []int{1}, Chain: "hoodi", Range: "24h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Validators["1"].MEV != nil {
+		t.Errorf("expected no relay check on hoodi, got %+v", resp.Validators["1"].MEV)
+	}
+}
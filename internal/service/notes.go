@@ -0,0 +1,75 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/labels"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/notes"
+)
+
+// SetNotes replaces the in-memory note store, e.g. with one persisted to disk.
+func (s *ValidatorService) SetNotes(n *notes.Store) {
+	s.notes = n
+}
+
+// SetValidatorNote replaces the note of a validator. An empty text removes it.
+func (s *ValidatorService) SetValidatorNote(chain string, index int, text string) (models.ValidatorNote, error) {
+	note := models.Note{Text: text, UpdatedAt: time.Now().UTC()}
+	if err := s.notes.Set(chain, index, note); err != nil {
+		return models.ValidatorNote{}, err
+	}
+	current, _ := s.notes.Get(chain, index)
+	return models.ValidatorNote{Chain: chain, ValidatorIndex: index, Note: current}, nil
+}
+
+// SearchValidators returns the validators on chain with all of the labels
+// selected and, unless query is empty, query in their notes or label values,
+// ignoring case. meta are the selectors as given, echoed in the response.
+func (s *ValidatorService) SearchValidators(chain string, meta []string, selectors []labels.Selector, query string) models.ValidatorSearchResponse {
+	allLabels := s.labels.All(chain)
+	allNotes := s.notes.All(chain)
+
+	// Candidates are the validators with any metadata
+	var candidates []int
+	if len(selectors) > 0 {
+		candidates = s.labels.Select(chain, selectors)
+	} else {
+		for index := range allLabels {
+			candidates = append(candidates, index)
+		}
+		for index := range allNotes {
+			if _, ok := allLabels[index]; !ok {
+				candidates = append(candidates, index)
+			}
+		}
+		slices.Sort(candidates)
+	}
+
+	response := models.ValidatorSearchResponse{Chain: chain, Meta: meta, Query: query, Validators: []models.ValidatorMetadata{}}
+	query = strings.ToLower(query)
+	for _, index := range candidates {
+		m := models.ValidatorMetadata{ValidatorIndex: index, Labels: allLabels[index], Notes: allNotes[index].Text}
+		if query != "" && !metadataContains(m, query) {
+			continue
+		}
+		response.Validators = append(response.Validators, m)
+	}
+	return response
+}
+
+// metadataContains reports whether the notes or a label value of m contain the
+// lowercase query, ignoring case.
+func metadataContains(m models.ValidatorMetadata, query string) bool {
+	if strings.Contains(strings.ToLower(m.Notes), query) {
+		return true
+	}
+	for _, value := range m.Labels {
+		if strings.Contains(strings.ToLower(value), query) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// previousSampleTolerance is the share of the range an attestation sample may be
+// recorded before the end of the previous period and still stand for it.
+const previousSampleTolerance = 0.1
+
+// addPrevious sets the aggregates of the period before the range of req, when
+// requested. Upstream only aggregates windows ending now, so the previous rewards
+// are summed from the daily rewards over twice the range, cached per epoch like
+// the aggregates, and the attestation effectiveness and uptime come from the
+// recorded history. The all_time range has no previous period.
+func (s *ValidatorService) addPrevious(ctx context.Context, req models.ValidatorRequest, response *models.ValidatorResponse) {
+	days, ok := rangeDays[req.Range]
+	if !req.ComparePrevious || !ok || !fieldSet(req.Fields).wants(sectionPrevious) {
+		return
+	}
+	if ctx.Err() != nil {
+		response.TimedOutSections = append(slices.Clone(response.TimedOutSections), sectionPrevious)
+		return
+	}
+
+	length := time.Duration(days) * 24 * time.Hour
+	now := time.Now().UTC()
+	previous := &models.PreviousPeriod{From: now.Add(-2 * length), To: now.Add(-length)}
+
+	ids := req.ValidatorIds
+	if response.Anomalies != nil && len(response.Anomalies.ExcludedValidators) > 0 {
+		ids = slices.DeleteFunc(slices.Clone(ids), func(id int) bool {
+			return slices.Contains(response.Anomalies.ExcludedValidators, id)
+		})
+	}
+	rewards, err := s.previousRewards(ctx, req.Chain, ids, req.Range, previous.To)
+	switch {
+	case deadlineExceeded(ctx, err):
+		response.TimedOutSections = append(slices.Clone(response.TimedOutSections), sectionPrevious)
+	case err != nil:
+		slog.Warn("failed to fetch previous rewards", "chain", req.Chain, "range", req.Range, "error", err)
+	case rewards != nil:
+		built := s.buildRewards(rewards)
+		previous.Rewards, previous.MissedRewards = &built.Total, &built.TotalMissed
+		previous.RewardsChange = amountChange(response.Rewards.Total, built.Total)
+		previous.MissedRewardsChange = amountChange(response.Rewards.TotalMissed, built.TotalMissed)
+	}
+
+	if s.store != nil {
+		previous.AttestationEffectiveness = s.previousEffectiveness(ctx, req, previous.To, length)
+		attestations := response.Performance.Attestations
+		previous.AttestationEffectivenessChange = percentChange(ratio(attestations.Included, attestations.Assigned), previous.AttestationEffectiveness)
+		s.addPreviousUptime(ctx, req, response.Performance.Uptime, previous)
+	}
+	response.Previous = previous
+}
+
+// previousRewards returns the rewards of the validators over the UTC days of the
+// range length that start before end, or nil if upstream has none of them.
+func (s *ValidatorService) previousRewards(ctx context.Context, chain string, ids []int, evalRange string, end time.Time) (*models.BeaconchainRewardsAggregateResponse, error) {
+	days := int(rangeDays[evalRange])
+	return cachedAggregate(ctx, s, aggregateCacheKey(sectionPrevious, chain, evalRange, ids), chain, func() (*models.BeaconchainRewardsAggregateResponse, error) {
+		release, err := s.acquireQueueSlot(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("queue wait: %w", err)
+		}
+		defer release()
+
+		entries, err := s.beaconchainClient.GetDailyRewards(ctx, chain, ids, windowForDays(2*days))
+		if err != nil {
+			return nil, err
+		}
+		entries = previousDays(entries, days, end)
+		if len(entries) == 0 {
+			return nil, nil
+		}
+		return sumRewardsHistory(entries), nil
+	})
+}
+
+// previousDays returns the entries of the days UTC days starting before end, in
+// the order of entries.
+func previousDays(entries []models.BeaconchainRewardsHistoryEntry, days int, end time.Time) []models.BeaconchainRewardsHistoryEntry {
+	return windowDays(entries, end.AddDate(0, 0, -days), end)
+}
+
+// previousEffectiveness returns the attestation effectiveness of the latest sample
+// of the same validators and range recorded at most previousSampleTolerance of the
+// range length before end, or nil without one.
+func (s *ValidatorService) previousEffectiveness(ctx context.Context, req models.ValidatorRequest, end time.Time, length time.Duration) *float64 {
+	samples, err := s.store.AttestationSamples(ctx, store.Query{
+		Chain:            req.Chain,
+		ValidatorIndices: req.ValidatorIds,
+		From:             end.Add(-time.Duration(previousSampleTolerance * float64(length))),
+		To:               end,
+	})
+	if err != nil {
+		slog.Error("failed to load previous attestation samples", "error", err)
+		return nil
+	}
+	for i := len(samples) - 1; i >= 0; i-- {
+		if samples[i].Range == req.Range && samples[i].Assigned > 0 {
+			return ratio(samples[i].Included, samples[i].Assigned)
+		}
+	}
+	return nil
+}
+
+// addPreviousUptime sets the uptime over the window before each SLA window of
+// current, the uptime of the response.
+func (s *ValidatorService) addPreviousUptime(ctx context.Context, req models.ValidatorRequest, current map[string]models.Uptime, previous *models.PreviousPeriod) {
+	if len(current) == 0 {
+		return
+	}
+	windows := make([]string, 0, len(current))
+	for w := range current {
+		windows = append(windows, w)
+	}
+	perValidator, err := s.uptimes(ctx, req.Chain, req.ValidatorIds, windows, true)
+	if err != nil {
+		slog.Error("failed to compute previous uptime", "error", err)
+		return
+	}
+
+	previous.Uptime = make(map[string]models.Uptime, len(windows))
+	previous.UptimeChange = make(map[string]float64, len(windows))
+	for _, w := range windows {
+		var total uptime
+		for _, byWindow := range perValidator {
+			total = total.add(byWindow[w])
+		}
+		previous.Uptime[w] = total.model()
+		if change := percentChange(current[w].Uptime, previous.Uptime[w].Uptime); change != nil {
+			previous.UptimeChange[w] = *change
+		}
+	}
+}
+
+// percentChange returns the change from previous to current in percent of
+// previous, or nil if either is unknown or previous is zero.
+func percentChange(current, previous *float64) *float64 {
+	if current == nil || previous == nil || *previous == 0 {
+		return nil
+	}
+	change := 100 * (*current - *previous) / *previous
+	if *previous < 0 {
+		change = -change
+	}
+	return &change
+}
+
+// amountChange returns the change from the wei amount previous to current in
+// percent of previous, or nil if either is invalid or previous is zero. A change
+// from a loss is relative to its size, so less of a loss is an increase.
+func amountChange(current, previous string) *float64 {
+	c, err := amount.Parse(current)
+	if err != nil {
+		return nil
+	}
+	p, err := amount.Parse(previous)
+	if err != nil {
+		return nil
+	}
+	change, ok := c.Sub(p).Percent(p.Abs())
+	if !ok {
+		return nil
+	}
+	return &change
+}
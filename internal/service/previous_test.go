@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// dailyEntry is a daily rewards entry of the UTC day start with net rewards total.
+func dailyEntry(start time.Time, total string) models.BeaconchainRewardsHistoryEntry {
+	return models.BeaconchainRewardsHistoryEntry{
+		Range:   models.BeaconchainResultRange{Timestamp: models.BeaconchainTimestampRange{Start: start.Unix(), End: start.AddDate(0, 0, 1).Unix()}},
+		Rewards: models.BeaconchainRewardsData{Total: total, TotalReward: total, TotalPenalty: "0", TotalMissed: "10"},
+	}
+}
+
+func TestGetValidatorData_Previous(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2)...)
+	fake.SetRewards("mainnet", "24h", models.BeaconchainRewardsAggregateResponse{
+		Data: models.BeaconchainRewardsData{Total: "1200", TotalReward: "1200", TotalPenalty: "0", TotalMissed: "5"},
+	})
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	fake.SetDailyRewards("mainnet",
+		dailyEntry(today.AddDate(0, 0, -3), "700"),
+		dailyEntry(today.AddDate(0, 0, -1), "1000"),
+		dailyEntry(today, "600"),
+	)
+
+	st, err := store.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewValidatorService(fake, nil, st, nil, nil, nil)
+	s.SetSLAWindows(nil)
+	ctx := context.Background()
+	sample := store.AttestationSample{Time: time.Now().UTC().Add(-25 * time.Hour), Chain: "mainnet", ValidatorIndices: []int{1, 2}, Range: "24h", Included: 90, Assigned: 100}
+	if err := st.RecordAttestationSample(ctx, sample); err != nil {
+		t.Fatal(err)
+	}
+
+	req := models.ValidatorRequest{ValidatorIds: []int{1, 2}, Chain: "mainnet", Range: "24h", ComparePrevious: true}
+	resp, err := s.GetValidatorData(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := resp.Previous
+	if p == nil {
+		t.Fatal("expected the previous period")
+	}
+	if p.Rewards == nil || *p.Rewards != "1000" || p.RewardsChange == nil || *p.RewardsChange != 20 {
+		t.Errorf("expected the rewards of yesterday and a 20%% increase, got %v and %v", p.Rewards, p.RewardsChange)
+	}
+	if p.MissedRewards == nil || *p.MissedRewards != "10" || p.MissedRewardsChange == nil || *p.MissedRewardsChange != -50 {
+		t.Errorf("expected halved missed rewards, got %v and %v", p.MissedRewards, p.MissedRewardsChange)
+	}
+	if p.AttestationEffectiveness == nil || *p.AttestationEffectiveness != 0.9 {
+		t.Errorf("expected the effectiveness of the sample a day ago, got %v", p.AttestationEffectiveness)
+	}
+	if got := p.To.Sub(p.From); got != 24*time.Hour {
+		t.Errorf("expected a previous period of 24h, got %s", got)
+	}
+
+	// The previous rewards are cached for the epoch
+	if _, err := s.GetValidatorData(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if n := fake.Calls(beaconchatest.MethodGetDailyRewards); n != 1 {
+		t.Errorf("expected the daily rewards to be fetched once, got %d calls", n)
+	}
+
+	// Only on request, and not for all_time
+	for _, r := range []models.ValidatorRequest{
+		{ValidatorIds: []int{1, 2}, Chain: "mainnet", Range: "24h"},
+		{ValidatorIds: []int{1, 2}, Chain: "mainnet", Range: "all_time", ComparePrevious: true},
+	} {
+		resp, err := s.GetValidatorData(ctx, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Previous != nil {
+			t.Errorf("%+v: expected no previous period, got %+v", r, resp.Previous)
+		}
+	}
+}
+
+func TestChanges(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name      string
+		got, want *float64
+	}{
+		{name: "increase", got: percentChange(f(0.99), f(0.9)), want: f(10)},
+		{name: "unknown", got: percentChange(nil, f(0.9))},
+		{name: "from zero", got: percentChange(f(1), f(0))},
+		{name: "amount decrease", got: amountChange("750", "1000"), want: f(-25)},
+		{name: "smaller loss", got: amountChange("-50", "-100"), want: f(50)},
+		{name: "invalid amount", got: amountChange("", "100")},
+		{name: "amount from zero", got: amountChange("100", "0")},
+	}
+	for _, tt := range tests {
+		switch {
+		case tt.want == nil && tt.got != nil:
+			t.Errorf("%s: expected no change, got %v", tt.name, *tt.got)
+		case tt.want != nil && (tt.got == nil || *tt.got-*tt.want > 1e-9 || *tt.want-*tt.got > 1e-9):
+			t.Errorf("%s: expected %v, got %v", tt.name, *tt.want, tt.got)
+		}
+	}
+}
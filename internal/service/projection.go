@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// GetProjection projects the daily, monthly and annual earnings of the validators,
+// both at their own net reward rate over req.Range and at the network APR, valued
+// in req.Currency if set. Only the overview and rewards of the validators are
+// fetched; the network APR and the price are best effort.
+func (s *ValidatorService) GetProjection(ctx context.Context, req models.ValidatorRequest) (models.ProjectionResponse, error) {
+	data := req
+	data.Fields = []string{"overview", "rewards"}
+	response, err := s.GetValidatorData(ctx, data)
+	if err != nil {
+		return models.ProjectionResponse{}, err
+	}
+
+	var staked amount.Amount
+	for _, o := range response.Validators {
+		if strings.HasPrefix(o.Status, "active") {
+			staked = staked.Add(amount.ParseOrZero(o.EffectiveBalance))
+		}
+	}
+	result := models.ProjectionResponse{Chain: req.Chain, Range: req.Range, StakedBalance: staked.String()}
+
+	if req.Currency != "" && s.prices != nil {
+		if p, err := s.prices.Price(ctx, req.Currency); err != nil {
+			slog.Warn("failed to fetch fiat price", "currency", req.Currency, "error", err)
+		} else {
+			price := fiatPrice(p)
+			result.Price = &price
+		}
+	}
+
+	// Rewards over the range, annualized
+	if rewards, err := amount.Parse(response.Rewards.Total); err == nil {
+		result.Fleet = projectEarnings(rewards.Scale(365/rangeDays[req.Range]), fleetAPR(req.Range, response), result.Price)
+	}
+	if network, ok := s.networkAverages(ctx, req.Chain, req.Range); ok && network.APR != nil {
+		result.Network = projectEarnings(staked.Scale(*network.APR), network.APR, result.Price)
+	}
+	return result, nil
+}
+
+// projectEarnings divides annual earnings into days and months, valued at price if
+// set.
+func projectEarnings(annual amount.Amount, apr *float64, price *models.FiatPrice) *models.Earnings {
+	daily, monthly := annual.Div(365), annual.Div(12)
+	earnings := &models.Earnings{
+		APR:     apr,
+		Daily:   daily.String(),
+		Monthly: monthly.String(),
+		Annual:  annual.String(),
+	}
+	if price != nil {
+		earnings.Fiat = &models.FiatEarnings{
+			Daily:   daily.ETH() * price.Price,
+			Monthly: monthly.ETH() * price.Price,
+			Annual:  annual.ETH() * price.Price,
+		}
+	}
+	return earnings
+}
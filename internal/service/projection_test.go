@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestGetProjection(t *testing.T) {
+	apr := 0.03
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Build(),
+		beaconchatest.Validator(2).Build(),
+		beaconchatest.Validator(3).Pending(10).Build(),
+	)
+	fake.SetRewards("mainnet", "24h", models.BeaconchainRewardsAggregateResponse{
+		Data: models.BeaconchainRewardsData{Total: "6400000000000000"}, // 0.0001 of 64 ETH
+	})
+	fake.SetNetworkPerformance("mainnet", "24h", models.BeaconchainNetworkPerformanceResponse{
+		Data: models.BeaconchainNetworkPerformance{APR: &apr},
+	})
+
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	projection, err := s.GetProjection(context.Background(), models.ValidatorRequest{ValidatorIds: []int{1, 2, 3}, Chain: "mainnet", Range: "24h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The pending validator does not count towards the stake
+	if projection.StakedBalance != "64000000000000000000" {
+		t.Errorf("expected 64 ETH staked, got %s", projection.StakedBalance)
+	}
+	fleet := projection.Fleet
+	if fleet == nil {
+		t.Fatal("expected a projection at the fleet's reward rate")
+	}
+	if fleet.Daily != "6400000000000000" || fleet.Annual != "2336000000000000000" || fleet.Monthly != "194666666666666666" {
+		t.Errorf("unexpected fleet earnings: %+v", fleet)
+	}
+	if fleet.APR == nil || math.Abs(*fleet.APR-0.0365) > 1e-9 {
+		t.Errorf("expected a fleet APR of 0.0365, got %v", fleet.APR)
+	}
+	if fleet.Fiat != nil {
+		t.Error("expected no fiat values without a currency")
+	}
+
+	if projection.Network == nil {
+		t.Fatal("expected a projection at the network APR")
+	}
+	if annual := amount.ParseOrZero(projection.Network.Annual).ETH(); math.Abs(annual-1.92) > 1e-9 {
+		t.Errorf("expected 1.92 ETH a year at the network APR, got %v", annual)
+	}
+
+	// Projections only need the validators and rewards
+	if n := fake.Calls(beaconchatest.MethodGetPerformanceAggregate); n != 0 {
+		t.Errorf("expected no performance calls, got %d", n)
+	}
+}
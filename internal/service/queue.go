@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// activationDelay is the number of epochs between a validator leaving the entry
+// queue and becoming active (1 + MAX_SEED_LOOKAHEAD).
+const activationDelay = 5
+
+// farFutureEpoch is the smallest epoch treated as unset. The spec uses 2^64-1 for
+// epochs that are not scheduled, which upstream may report as a large number.
+const farFutureEpoch = 1 << 62
+
+// addQueueEstimates sets the entry queue position and estimated activation, exit and
+// withdrawable times of pending and exiting validators. The network queues are only
+// fetched when a pending validator has no activation epoch yet; if that fails, the
+// queue positions are left out.
+func (s *ValidatorService) addQueueEstimates(ctx context.Context, chain string, validators []models.BeaconchainValidatorData, overviews map[string]models.ValidatorOverview) {
+	spec, err := chainspec.ForChain(chain)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	lastEpoch, err := spec.LastCompletedEpoch(now)
+	if err != nil {
+		return
+	}
+
+	var queues *models.BeaconchainQueues
+	queuesFetched := false
+	for _, v := range validators {
+		if v.Validator.Index == nil {
+			continue
+		}
+		id := strconv.Itoa(*v.Validator.Index)
+		overview, ok := overviews[id]
+		if !ok {
+			continue
+		}
+
+		if isPending(v) && !isSet(v.LifeCycleEpochs.Activation) && v.QueuePosition != nil && !queuesFetched {
+			queuesFetched = true
+			queues, err = s.beaconchainClient.GetQueues(ctx, chain)
+			if err != nil {
+				slog.Warn("failed to fetch network queues", "chain", chain, "error", err)
+			}
+		}
+
+		estimateQueueTimes(&overview, v, spec, lastEpoch+1, queues)
+		overviews[id] = overview
+	}
+}
+
+// estimateQueueTimes fills in the queue estimates of o. Activation, exit and
+// withdrawable epochs are fixed as soon as the chain assigns them, so only the
+// activation of validators still waiting in the entry queue is extrapolated from
+// the queue position and churn limit. queues may be nil.
+func estimateQueueTimes(o *models.ValidatorOverview, v models.BeaconchainValidatorData, spec chainspec.Spec, currentEpoch int64, queues *models.BeaconchainQueues) {
+	epochTime := func(epoch int64) *time.Time {
+		t := spec.EpochStart(epoch).UTC()
+		return &t
+	}
+
+	if isPending(v) {
+		o.EntryQueuePosition = v.QueuePosition
+		switch {
+		case isSet(v.LifeCycleEpochs.Activation):
+			o.EstimatedActivationTime = epochTime(*v.LifeCycleEpochs.Activation)
+		case v.QueuePosition != nil && queues != nil && queues.EntryQueue.ChurnLimit > 0:
+			// The validator leaves the queue at the end of the epoch in which all validators ahead of it were processed
+			churn := queues.EntryQueue.ChurnLimit
+			epochsInQueue := int64((*v.QueuePosition + churn - 1) / churn)
+			o.EstimatedActivationTime = epochTime(currentEpoch + epochsInQueue - 1 + activationDelay)
+		}
+		return
+	}
+
+	if exit := v.LifeCycleEpochs.Exit; isSet(exit) && *exit >= currentEpoch {
+		o.EstimatedExitTime = epochTime(*exit)
+	}
+	if withdrawable := v.LifeCycleEpochs.Withdrawable; isSet(withdrawable) && *withdrawable >= currentEpoch {
+		o.EstimatedWithdrawableTime = epochTime(*withdrawable)
+	}
+}
+
+// isPending reports whether the validator has not been activated yet.
+func isPending(v models.BeaconchainValidatorData) bool {
+	return strings.HasPrefix(v.Status, "pending")
+}
+
+// isSet reports whether an epoch is scheduled.
+func isSet(epoch *int64) bool {
+	return epoch != nil && *epoch < farFutureEpoch
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestEstimateQueueTimes(t *testing.T) {
+	spec, err := chainspec.ForChain("mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const current = 1000
+	queues := &models.BeaconchainQueues{EntryQueue: models.BeaconchainQueue{Validators: 100, ChurnLimit: 8}}
+	at := func(epoch int64) *time.Time {
+		t := spec.EpochStart(epoch).UTC()
+		return &t
+	}
+
+	tests := []struct {
+		name             string
+		validator        models.BeaconchainValidatorData
+		queues           *models.BeaconchainQueues
+		wantActivation   *time.Time
+		wantExit         *time.Time
+		wantWithdrawable *time.Time
+	}{
+		{name: "front of the queue", validator: beaconchatest.Validator(1).Pending(1).Build(), queues: queues, wantActivation: at(current + 5)},
+		{name: "last of the first batch", validator: beaconchatest.Validator(1).Pending(8).Build(), queues: queues, wantActivation: at(current + 5)},
+		{name: "second batch", validator: beaconchatest.Validator(1).Pending(9).Build(), queues: queues, wantActivation: at(current + 6)},
+		{name: "queue unknown", validator: beaconchatest.Validator(1).Pending(9).Build()},
+		{name: "activation scheduled", validator: beaconchatest.Validator(1).Pending(1).Activation(1003).Build(), wantActivation: at(1003)},
+		{name: "active", validator: beaconchatest.Validator(1).Build(), queues: queues},
+		{name: "exiting", validator: beaconchatest.Validator(1).Exit(1010).Withdrawable(1266).Build(), wantExit: at(1010), wantWithdrawable: at(1266)},
+		{name: "exited, not yet withdrawable", validator: beaconchatest.Validator(1).Exit(900).Withdrawable(1156).Build(), wantWithdrawable: at(1156)},
+		{name: "far future exit", validator: beaconchatest.Validator(1).Exit(1<<63 - 1).Build()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o models.ValidatorOverview
+			estimateQueueTimes(&o, tt.validator, spec, current, tt.queues)
+
+			check := func(field string, got, want *time.Time) {
+				if (got == nil) != (want == nil) || (got != nil && !got.Equal(*want)) {
+					t.Errorf("%s: expected %v, got %v", field, want, got)
+				}
+			}
+			check("activation", o.EstimatedActivationTime, tt.wantActivation)
+			check("exit", o.EstimatedExitTime, tt.wantExit)
+			check("withdrawable", o.EstimatedWithdrawableTime, tt.wantWithdrawable)
+		})
+	}
+}
+
+func TestAddQueueEstimates_FetchesQueuesOnlyWhenNeeded(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validator(1).Build())
+	fake.AddValidators("mainnet", beaconchatest.Validator(2).Pending(3).Build())
+	fake.SetQueues("mainnet", models.BeaconchainQueues{EntryQueue: models.BeaconchainQueue{Validators: 10, ChurnLimit: 8}})
+
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	if _, err := s.GetValidatorData(ctx, models.ValidatorRequest{ValidatorIds: []int{1}, Chain: "mainnet", Range: "7d"}); err != nil {
+		t.Fatal(err)
+	}
+	if calls := fake.Calls(beaconchatest.MethodGetQueues); calls != 0 {
+		t.Errorf("expected no queue calls for active validators, got %d", calls)
+	}
+
+	resp, err := s.GetValidatorData(ctx, models.ValidatorRequest{ValidatorIds: []int{1, 2}, Chain: "mainnet", Range: "7d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := resp.Validators["2"]
+	if pending.EntryQueuePosition == nil || *pending.EntryQueuePosition != 3 || pending.EstimatedActivationTime == nil {
+		t.Errorf("expected queue estimates for the pending validator, got %+v", pending)
+	}
+	if calls := fake.Calls(beaconchatest.MethodGetQueues); calls != 1 {
+		t.Errorf("expected 1 queue call, got %d", calls)
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// defaultPrincipal is the 32 ETH deposit returned by the full withdrawal of a
+// validator without compounding credentials.
+var defaultPrincipal = amount.Ether(32)
+
+// exit is the withdrawable epoch of a validator and the principal its full
+// withdrawal returns.
+type exit struct {
+	withdrawable int64
+	principal    amount.Amount
+}
+
+// ReconcileIncome compares the CL rewards accrued by each validator in the
+// evaluation window against the amounts actually withdrawn in the same window.
+// Validators whose absolute difference exceeds tolerance are flagged.
+//
+// Rewards aggregates are combined across all requested validators upstream, so
+// one rewards-aggregate call is made per validator. Requests are processed in
+// the same FIFO queue as GetValidatorData.
+func (s *ValidatorService) ReconcileIncome(ctx context.Context, chain string, validatorIds []int, evalRange string, tolerance amount.Amount) (models.ReconciliationResponse, error) {
+	if len(validatorIds) == 0 {
+		return models.ReconciliationResponse{}, nil
+	}
+
+	release, err := s.acquireQueueSlot(ctx)
+	if err != nil {
+		return models.ReconciliationResponse{}, fmt.Errorf("queue wait: %w", err)
+	}
+	defer release()
+
+	slog.Debug("reconciling income", "validators", len(validatorIds), "range", evalRange)
+
+	withdrawals, err := s.beaconchainClient.GetWithdrawals(ctx, chain, validatorIds, evalRange)
+	if err != nil {
+		return models.ReconciliationResponse{}, fmt.Errorf("fetch withdrawals: %w", err)
+	}
+
+	// Full withdrawals are told apart by the withdrawable epoch of their validator
+	validators, err := s.beaconchainClient.GetValidators(ctx, chain, validatorIds)
+	if err != nil {
+		return models.ReconciliationResponse{}, fmt.Errorf("fetch validators: %w", err)
+	}
+	exits := make(map[int]exit, len(validators))
+	for _, v := range validators {
+		if v.Validator.Index != nil && v.LifeCycleEpochs.Withdrawable != nil {
+			exits[*v.Validator.Index] = exit{withdrawable: *v.LifeCycleEpochs.Withdrawable, principal: principal(v)}
+		}
+	}
+
+	accrued := make(map[int]amount.Amount, len(validatorIds))
+	for _, id := range validatorIds {
+		rewards, err := s.rewardsAggregate(ctx, chain, []int{id}, evalRange)
+		if err != nil {
+			return models.ReconciliationResponse{}, fmt.Errorf("fetch rewards for validator %d: %w", id, err)
+		}
+		accrued[id] = accruedCLReward(rewards)
+	}
+
+	return buildReconciliation(validatorIds, accrued, withdrawals, exits, evalRange, tolerance), nil
+}
+
+// buildReconciliation matches withdrawals to validators and computes the per-validator
+// and total differences. exits holds the validators that have a withdrawable epoch.
+func buildReconciliation(validatorIds []int, accrued map[int]amount.Amount, withdrawals []models.BeaconchainWithdrawal, exits map[int]exit, evalRange string, tolerance amount.Amount) models.ReconciliationResponse {
+	withdrawn := make(map[int]amount.Amount, len(validatorIds))
+	counts := make(map[int]int, len(validatorIds))
+	for _, w := range withdrawals {
+		if w.Validator.Index == nil {
+			continue
+		}
+		idx := *w.Validator.Index
+		e, exited := exits[idx]
+		withdrawn[idx] = withdrawn[idx].Add(withdrawnReward(w.Amount, exited && w.Epoch >= e.withdrawable, e.principal))
+		counts[idx]++
+	}
+
+	response := models.ReconciliationResponse{
+		Range:      evalRange,
+		Tolerance:  tolerance.String(),
+		Validators: make(map[string]models.ValidatorReconciliation, len(validatorIds)),
+	}
+
+	var totalAccrued, totalWithdrawn amount.Amount
+	totalCount := 0
+
+	for _, id := range validatorIds {
+		a, w := accrued[id], withdrawn[id]
+		response.Validators[strconv.Itoa(id)] = reconcile(a, w, counts[id], tolerance)
+
+		totalAccrued = totalAccrued.Add(a)
+		totalWithdrawn = totalWithdrawn.Add(w)
+		totalCount += counts[id]
+	}
+
+	response.Total = reconcile(totalAccrued, totalWithdrawn, totalCount, tolerance)
+
+	return response
+}
+
+// reconcile builds a reconciliation entry from accrued and withdrawn amounts.
+func reconcile(accrued, withdrawn amount.Amount, count int, tolerance amount.Amount) models.ValidatorReconciliation {
+	diff := accrued.Sub(withdrawn)
+
+	return models.ValidatorReconciliation{
+		AccruedCLReward: accrued.String(),
+		Withdrawn:       withdrawn.String(),
+		Withdrawals:     count,
+		Difference:      diff.String(),
+		Flagged:         diff.Abs().Cmp(tolerance) > 0,
+	}
+}
+
+// accruedCLReward returns the net rewards minus the execution layer proposal rewards,
+// which are paid to the fee recipient rather than the validator balance.
+func accruedCLReward(r *models.BeaconchainRewardsAggregateResponse) amount.Amount {
+	if r == nil {
+		return amount.Amount{}
+	}
+	return amount.ParseOrZero(r.Data.Total).Sub(amount.ParseOrZero(r.Data.Proposal.ExecutionLayerReward))
+}
+
+// principal returns the principal returned by the full withdrawal of v: 32 ETH, or
+// the effective balance of a compounding (0x02) validator above it. Deposits are
+// not available upstream, so a compounding validator's principal includes the
+// rewards it compounded, and falls back to 32 ETH once its balance is withdrawn.
+func principal(v models.BeaconchainValidatorData) amount.Amount {
+	if v.WithdrawalCredentials.Prefix != "0x02" {
+		return defaultPrincipal
+	}
+	if effective := amount.ParseOrZero(v.Balances.Effective); effective.Cmp(defaultPrincipal) > 0 {
+		return effective
+	}
+	return defaultPrincipal
+}
+
+// withdrawnReward returns the reward portion of a withdrawal amount. Full
+// withdrawals, made from the withdrawable epoch of their validator on, return the
+// principal, which is not income; one below the principal is a loss.
+func withdrawnReward(withdrawal string, full bool, principal amount.Amount) amount.Amount {
+	v := amount.ParseOrZero(withdrawal)
+	if !full {
+		return v
+	}
+	return v.Sub(principal)
+}
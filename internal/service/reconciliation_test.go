@@ -0,0 +1,122 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestBuildReconciliation(t *testing.T) {
+	one, two, four := 1, 2, 4
+	accrued := map[int]amount.Amount{
+		1: amount.Wei(5_000_000_000_000_000),
+		2: amount.Wei(1_000_000_000_000_000),
+		4: amount.Wei(-800_000_000_000_000_000),
+	}
+	withdrawals := []models.BeaconchainWithdrawal{
+		{Validator: models.BeaconchainValidatorInfo{Index: &one}, Amount: "3000000000000000"},
+		{Validator: models.BeaconchainValidatorInfo{Index: &one}, Amount: "2000000000000000"},
+		// Full withdrawal: 32 ETH principal plus 0.05 ETH of rewards
+		{Validator: models.BeaconchainValidatorInfo{Index: &two}, Amount: "32050000000000000000", Epoch: 120},
+		// Full withdrawal of a compounding validator after penalties: 0.8 ETH lost
+		{Validator: models.BeaconchainValidatorInfo{Index: &four}, Amount: "63200000000000000000", Epoch: 200},
+		{Validator: models.BeaconchainValidatorInfo{Index: nil}, Amount: "1"},
+	}
+	exits := map[int]exit{
+		2: {withdrawable: 100, principal: amount.Ether(32)},
+		4: {withdrawable: 200, principal: amount.Ether(64)},
+	}
+	tolerance := amount.Wei(10_000_000_000_000_000) // 0.01 ETH
+
+	got := buildReconciliation([]int{1, 2, 3, 4}, accrued, withdrawals, exits, "30d", tolerance)
+
+	v1 := got.Validators["1"]
+	if v1.Withdrawn != "5000000000000000" || v1.Difference != "0" || v1.Withdrawals != 2 || v1.Flagged {
+		t.Errorf("validator 1: unexpected reconciliation %+v", v1)
+	}
+
+	v2 := got.Validators["2"]
+	if v2.Withdrawn != "50000000000000000" || v2.Difference != "-49000000000000000" || !v2.Flagged {
+		t.Errorf("validator 2: unexpected reconciliation %+v", v2)
+	}
+
+	v3 := got.Validators["3"]
+	if v3.AccruedCLReward != "0" || v3.Withdrawn != "0" || v3.Flagged {
+		t.Errorf("validator 3: unexpected reconciliation %+v", v3)
+	}
+
+	// The loss shows as a negative withdrawal matching the accrued penalties
+	v4 := got.Validators["4"]
+	if v4.Withdrawn != "-800000000000000000" || v4.Difference != "0" || v4.Flagged {
+		t.Errorf("validator 4: unexpected reconciliation %+v", v4)
+	}
+
+	if got.Total.Withdrawals != 4 || got.Total.Difference != "-49000000000000000" || !got.Total.Flagged {
+		t.Errorf("total: unexpected reconciliation %+v", got.Total)
+	}
+}
+
+func TestAccruedCLReward(t *testing.T) {
+	r := &models.BeaconchainRewardsAggregateResponse{
+		Data: models.BeaconchainRewardsData{
+			Total: "1500",
+			Proposal: models.BeaconchainProposalRewards{
+				ExecutionLayerReward: "400",
+			},
+		},
+	}
+
+	if got := accruedCLReward(r); got.String() != "1100" {
+		t.Errorf("expected 1100, got %s", got)
+	}
+	if got := accruedCLReward(nil); got.Sign() != 0 {
+		t.Errorf("expected 0 for nil response, got %s", got)
+	}
+}
+
+func TestWithdrawnReward(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount string
+		full   bool
+		want   string
+	}{
+		{"partial", "15000000000000000", false, "15000000000000000"},
+		{"partial above 32 ETH", "33000000000000000000", false, "33000000000000000000"},
+		{"full with rewards", "32050000000000000000", true, "50000000000000000"},
+		{"full of exactly 32 ETH", "32000000000000000000", true, "0"},
+		// An exit after penalties returns less than the principal, a loss
+		{"full below 32 ETH", "31200000000000000000", true, "-800000000000000000"},
+	}
+	for _, tt := range tests {
+		if got := withdrawnReward(tt.amount, tt.full, amount.Ether(32)); got.String() != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestPrincipal(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator models.BeaconchainValidatorData
+		want      string
+	}{
+		{"0x01", validatorWithBalance("0x01", "32000000000000000000"), "32000000000000000000"},
+		{"0x01 after penalties", validatorWithBalance("0x01", "31000000000000000000"), "32000000000000000000"},
+		{"compounding", validatorWithBalance("0x02", "64000000000000000000"), "64000000000000000000"},
+		{"compounding withdrawn", validatorWithBalance("0x02", "0"), "32000000000000000000"},
+	}
+	for _, tt := range tests {
+		if got := principal(tt.validator); got.String() != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func validatorWithBalance(prefix, effective string) models.BeaconchainValidatorData {
+	return models.BeaconchainValidatorData{
+		WithdrawalCredentials: models.BeaconchainWithdrawalCreds{Prefix: prefix},
+		Balances:              models.BeaconchainValidatorBalances{Effective: effective},
+	}
+}
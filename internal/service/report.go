@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+// reportBatchSize is the number of validators fetched per request of a report,
+// matching the page size of the upstream validators endpoint.
+const reportBatchSize = 100
+
+// GetValidatorReport fetches the data of any number of validators in batches.
+// Each batch waits its turn in the request queue, so interactive requests are
+// served between batches. Batches fetched during the current epoch are served from
+// cache. progress is called with the number of validators done after each batch.
+func (s *ValidatorService) GetValidatorReport(ctx context.Context, req models.ValidatorRequest, progress func(done int)) (models.ValidatorReport, error) {
+	report := models.ValidatorReport{
+		Chain:      req.Chain,
+		Range:      req.Range,
+		Validators: make(map[string]models.ValidatorOverview, len(req.ValidatorIds)),
+		Batches:    []models.ValidatorReportBatch{},
+	}
+
+	var rewards amount.Amount
+	for start := 0; start < len(req.ValidatorIds); start += reportBatchSize {
+		end := min(start+reportBatchSize, len(req.ValidatorIds))
+		batch := req
+		batch.ValidatorIds = req.ValidatorIds[start:end]
+
+		data, err := s.cachedValidatorData(ctx, batch)
+		if err != nil {
+			return models.ValidatorReport{}, fmt.Errorf("fetch validators %d-%d: %w", start, end-1, err)
+		}
+
+		for id, o := range data.Validators {
+			report.Validators[id] = o
+		}
+		report.Batches = append(report.Batches, models.ValidatorReportBatch{
+			ValidatorIds: batch.ValidatorIds,
+			Rewards:      data.Rewards,
+			Performance:  data.Performance,
+		})
+		rewards = rewards.Add(amount.ParseOrZero(data.Rewards.Total))
+		progress(end)
+	}
+
+	report.Totals = dashboardTotals(report.Validators)
+	report.Rewards = rewards.String()
+	return report, nil
+}
+
+// reportRanges are the evaluation windows of the periods of scheduled reports.
+var reportRanges = map[string]string{
+	"daily":  "24h",
+	"weekly": "7d",
+}
+
+// reportEventLimit is the number of events a scheduled report lists at most.
+const reportEventLimit = 50
+
+// GetPortfolioReport summarizes the income and performance of the named portfolio
+// over a daily or weekly period. Portfolio data fetched during the current epoch
+// is served from cache.
+func (s *ValidatorService) GetPortfolioReport(ctx context.Context, name, period string) (models.PortfolioReport, error) {
+	evalRange, ok := reportRanges[period]
+	if !ok {
+		return models.PortfolioReport{}, fmt.Errorf("unknown report period %q", period)
+	}
+	p, ok := s.portfolios.Get(name)
+	if !ok {
+		return models.PortfolioReport{}, fmt.Errorf("%w: %s", ErrUnknownPortfolio, name)
+	}
+
+	data, err := s.cachedValidatorData(ctx, portfolioRequest(p, evalRange))
+	if err != nil {
+		return models.PortfolioReport{}, err
+	}
+	report := models.PortfolioReport{
+		Portfolio:       p.Name,
+		Chain:           p.Chain,
+		Period:          period,
+		Range:           evalRange,
+		DashboardTotals: dashboardTotals(data.Validators),
+		Rewards:         data.Rewards,
+		Performance:     data.Performance,
+	}
+	s.addReportHistory(ctx, p, &report)
+	return report, nil
+}
+
+// addReportHistory sets the uptime and events of a report from the recorded
+// history. Like the history, they are best effort.
+func (s *ValidatorService) addReportHistory(ctx context.Context, p portfolio.Portfolio, report *models.PortfolioReport) {
+	if s.store == nil {
+		return
+	}
+
+	perValidator, err := s.uptimes(ctx, p.Chain, p.ValidatorIds, []string{report.Range}, false)
+	if err != nil {
+		slog.Error("failed to compute report uptime", "portfolio", p.Name, "error", err)
+	} else {
+		var total uptime
+		for _, byWindow := range perValidator {
+			total = total.add(byWindow[report.Range])
+		}
+		u := total.model()
+		report.Uptime = &u
+	}
+
+	events, err := s.recentEvents(ctx, []portfolio.Portfolio{p}, slaWindowLengths[report.Range], reportEventLimit)
+	if err != nil {
+		slog.Error("failed to load report events", "portfolio", p.Name, "error", err)
+		return
+	}
+	report.Events = events
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestGetValidatorReport(t *testing.T) {
+	ids := make([]int, 250)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(ids...)...)
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	req := models.ValidatorRequest{ValidatorIds: ids, Chain: "mainnet", Range: "30d"}
+
+	var progress []int
+	report, err := s.GetValidatorReport(context.Background(), req, func(done int) {
+		progress = append(progress, done)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Validators) != 250 || report.Totals.Validators != 250 {
+		t.Errorf("expected 250 validators, got %d (totals %d)", len(report.Validators), report.Totals.Validators)
+	}
+	if len(report.Batches) != 3 || len(report.Batches[2].ValidatorIds) != 50 {
+		t.Fatalf("expected batches of 100, 100 and 50 validators, got %d batches", len(report.Batches))
+	}
+	if len(progress) != 3 || progress[2] != 250 {
+		t.Errorf("expected progress after each batch, got %v", progress)
+	}
+	if got := fake.Calls(beaconchatest.MethodGetRewardsAggregate); got != 3 {
+		t.Errorf("expected one rewards request per batch, got %d", got)
+	}
+
+	// Batches fetched during the current epoch are not fetched again
+	if _, err := s.GetValidatorReport(context.Background(), req, func(int) {}); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.Calls(beaconchatest.MethodGetRewardsAggregate); got != 3 {
+		t.Errorf("expected cached batches, got %d rewards requests", got)
+	}
+}
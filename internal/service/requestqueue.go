@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+// ErrShuttingDown is returned for requests made after Drain was called.
+var ErrShuttingDown = errors.New("service is shutting down")
+
+// acquireQueueSlot gets a ticket and waits until it's our turn.
+// Returns a release function that must be called when done.
+func (s *ValidatorService) acquireQueueSlot(ctx context.Context) (func(), error) {
+	s.queueMu.Lock()
+	if s.draining {
+		s.queueMu.Unlock()
+		return nil, ErrShuttingDown
+	}
+
+	// Get our ticket number
+	myTicket := s.queueTail
+	s.queueTail++
+
+	slog.Debug("request queued", "ticket", myTicket, "queueHead", s.queueHead)
+
+	// Wake up to leave the queue when the context is canceled
+	stop := context.AfterFunc(ctx, s.wakeQueue)
+	defer stop()
+
+	// Wait until it's our turn (our ticket matches head) and no one is active
+	for s.queueHead != myTicket || atomic.LoadInt32(&s.activeCount) > 0 {
+		// Check context before waiting
+		select {
+		case <-ctx.Done():
+			// Give up the ticket so the requests behind it are not stuck
+			if s.abandoned == nil {
+				s.abandoned = make(map[uint64]bool)
+			}
+			s.abandoned[myTicket] = true
+			s.skipAbandoned()
+			s.queueMu.Unlock()
+			return nil, ctx.Err()
+		default:
+		}
+
+		// Wait for signal (releases mutex while waiting)
+		s.queueCond.Wait()
+	}
+
+	// It's our turn - mark as active
+	atomic.StoreInt32(&s.activeCount, 1)
+	s.queueMu.Unlock()
+
+	slog.Debug("request processing", "ticket", myTicket)
+
+	return s.releaseQueueSlot(myTicket), nil
+}
+
+// acquireIdleQueueSlot waits until no request is queued or running and then
+// takes a turn in the queue, so background work never delays a user request by
+// more than the one call it makes per turn.
+func (s *ValidatorService) acquireIdleQueueSlot(ctx context.Context) (func(), error) {
+	s.queueMu.Lock()
+	stop := context.AfterFunc(ctx, s.wakeQueue)
+	defer stop()
+
+	for {
+		if s.draining {
+			s.queueMu.Unlock()
+			return nil, ErrShuttingDown
+		}
+		if s.queueHead == s.queueTail && atomic.LoadInt32(&s.activeCount) == 0 {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			s.queueMu.Unlock()
+			return nil, err
+		}
+		s.queueCond.Wait()
+	}
+
+	// Nobody is waiting, so our ticket is served right away
+	myTicket := s.queueTail
+	s.queueTail++
+	atomic.StoreInt32(&s.activeCount, 1)
+	s.queueMu.Unlock()
+
+	return s.releaseQueueSlot(myTicket), nil
+}
+
+// releaseQueueSlot returns the function releasing the turn of ticket.
+func (s *ValidatorService) releaseQueueSlot(myTicket uint64) func() {
+	return func() {
+		s.queueMu.Lock()
+		s.queueHead++ // Move to next ticket
+		atomic.StoreInt32(&s.activeCount, 0)
+		s.skipAbandoned()
+		s.queueCond.Broadcast() // Wake up all waiters to check their turn
+		s.queueMu.Unlock()
+		slog.Debug("request completed", "ticket", myTicket, "nextTicket", myTicket+1)
+	}
+}
+
+// skipAbandoned moves the head past tickets whose requests left the queue and
+// wakes the waiters if it moved. Callers must hold s.queueMu.
+func (s *ValidatorService) skipAbandoned() {
+	moved := false
+	for s.abandoned[s.queueHead] {
+		delete(s.abandoned, s.queueHead)
+		s.queueHead++
+		moved = true
+	}
+	if moved {
+		s.queueCond.Broadcast()
+	}
+}
+
+// wakeQueue wakes every goroutine waiting on the queue to check its context.
+func (s *ValidatorService) wakeQueue() {
+	s.queueMu.Lock()
+	s.queueCond.Broadcast()
+	s.queueMu.Unlock()
+}
+
+// Drain stops issuing queue tickets and waits until the queued and running
+// requests completed, or the context is done. Later requests fail with
+// ErrShuttingDown.
+func (s *ValidatorService) Drain(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, s.wakeQueue)
+	defer stop()
+
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+	s.draining = true
+
+	for s.queueHead != s.queueTail || atomic.LoadInt32(&s.activeCount) > 0 {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("drain request queue: %d requests left: %w", s.queueTail-s.queueHead, err)
+		}
+		s.queueCond.Wait()
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestAcquireQueueSlot_Canceled(t *testing.T) {
+	s := NewValidatorService(beaconchatest.New(), nil, nil, nil, nil, nil)
+
+	release, err := s.acquireQueueSlot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A request canceled while waiting leaves the queue
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() {
+		_, err := s.acquireQueueSlot(ctx)
+		canceled <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	// The request behind it is served once the running one completes
+	acquired := make(chan struct{})
+	go func() {
+		next, err := s.acquireQueueSlot(context.Background())
+		if err == nil {
+			next()
+		}
+		close(acquired)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	release()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("request behind a canceled one was never served")
+	}
+}
+
+func TestAcquireIdleQueueSlot(t *testing.T) {
+	s := NewValidatorService(beaconchatest.New(), nil, nil, nil, nil, nil)
+
+	release, err := s.acquireQueueSlot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	queued := make(chan func())
+	go func() {
+		next, _ := s.acquireQueueSlot(context.Background())
+		queued <- next
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	idle := make(chan func())
+	go func() {
+		background, _ := s.acquireIdleQueueSlot(context.Background())
+		idle <- background
+	}()
+	time.Sleep(10 * time.Millisecond)
+	release()
+
+	// The queued request goes first, and background work only once it is done
+	next := <-queued
+	select {
+	case <-idle:
+		t.Fatal("background work served while a request was running")
+	case <-time.After(20 * time.Millisecond):
+	}
+	next()
+	select {
+	case background := <-idle:
+		background()
+	case <-time.After(time.Second):
+		t.Fatal("background work never served on an idle queue")
+	}
+}
+
+func TestDrain(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1)...)
+	fake.SetLatency(50 * time.Millisecond)
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	req := models.ValidatorRequest{ValidatorIds: []int{1}, Chain: "mainnet", Range: "24h"}
+
+	done := make(chan error)
+	go func() {
+		_, err := s.GetValidatorData(context.Background(), req)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// Drain waits for the running request, whose concurrent section fetches and
+	// network averages take two rounds of upstream calls
+	start := time.Now()
+	if err := s.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Drain returned after %s, before the running request completed", elapsed)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("expected the running request to complete, got %v", err)
+	}
+
+	// Later requests are refused
+	other := models.ValidatorRequest{ValidatorIds: []int{2}, Chain: "mainnet", Range: "24h"}
+	if _, err := s.GetValidatorData(context.Background(), other); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("expected ErrShuttingDown, got %v", err)
+	}
+}
+
+func TestDrain_Timeout(t *testing.T) {
+	s := NewValidatorService(beaconchatest.New(), nil, nil, nil, nil, nil)
+	release, err := s.acquireQueueSlot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
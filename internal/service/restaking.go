@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/eigenlayer"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// SetRestaking enables the detection of mainnet validators withdrawing to an
+// EigenPod, adding their restaking status to the overview.
+func (s *ValidatorService) SetRestaking(c *eigenlayer.Checker) {
+	s.restaking = c
+}
+
+// addRestaking sets the restaking status of validators whose withdrawal address
+// is an EigenPod. EigenLayer is only checked on mainnet. After a failed lookup the
+// remaining addresses are not checked, as the node is likely unavailable.
+func (s *ValidatorService) addRestaking(ctx context.Context, chain string, overviews map[string]models.ValidatorOverview) {
+	if s.restaking == nil || chain != "mainnet" {
+		return
+	}
+
+	// Validators of one pod share its address
+	pods := make(map[string]*eigenlayer.Pod)
+	failed := false
+	for id, overview := range overviews {
+		address := overview.WithdrawalCredentials.Address
+		if address == nil {
+			continue
+		}
+		key := strings.ToLower(*address)
+		pod, ok := pods[key]
+		if !ok {
+			if failed {
+				continue
+			}
+			var err error
+			pod, err = s.restaking.Lookup(ctx, *address)
+			if err != nil {
+				slog.Warn("failed to check EigenPod, skipping the remaining addresses", "address", *address, "error", err)
+				failed = true
+			}
+			pods[key] = pod
+		}
+		if pod != nil {
+			overview.Restaking = &models.Restaking{
+				Protocol:  "eigenlayer",
+				EigenPod:  pod.Address,
+				PodOwner:  pod.Owner,
+				Delegated: pod.Operator != "",
+				Operator:  pod.Operator,
+			}
+			overviews[id] = overview
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/eigenlayer"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestAddRestaking_SkipsAfterFailure(t *testing.T) {
+	var calls atomic.Int64
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer node.Close()
+
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).WithdrawalAddress("0x1111111111111111111111111111111111111111").Build(),
+		beaconchatest.Validator(2).WithdrawalAddress("0x2222222222222222222222222222222222222222").Build(),
+		beaconchatest.Validator(3).WithdrawalAddress("0x3333333333333333333333333333333333333333").Build(),
+	)
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	s.SetRestaking(eigenlayer.NewChecker(node.URL, time.Hour, time.Second))
+
+	resp, err := s.GetValidatorData(context.Background(), models.ValidatorRequest{ValidatorIds: []int{1, 2, 3}, Chain: "mainnet", Range: "24h"})
+	if err != nil {
+		t.Fatalf("expected the request to succeed without EigenLayer, got %v", err)
+	}
+	if len(resp.Validators) != 3 {
+		t.Errorf("expected 3 overviews, got %d", len(resp.Validators))
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected the lookups to stop after the first failure, got %d calls", n)
+	}
+}
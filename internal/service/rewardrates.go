@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// RewardAnomalyConfig configures the detection of validators whose reward rate
+// deviates from their own baseline or from the fleet.
+type RewardAnomalyConfig struct {
+	Window    time.Duration // Recent period whose reward rate is checked; 0 disables detection
+	Baseline  time.Duration // Period before the window the baseline is taken from
+	Threshold float64       // Deviations, in standard deviations, that are flagged
+}
+
+// DefaultRewardAnomalyConfig is the detection used unless SetRewardAnomalies
+// changes it.
+var DefaultRewardAnomalyConfig = RewardAnomalyConfig{Window: 6 * time.Hour, Baseline: 7 * 24 * time.Hour, Threshold: 3}
+
+// Limits of the reward rate statistics.
+const (
+	// Fewer baseline periods or fleet validators are too few to compare with
+	minRateSamples = 3
+	// Spreads are at least this share of the mean, so that validators earning
+	// alike are not flagged for tiny differences
+	minRelativeSpread = 0.1
+	// MAD of a normal distribution times this is its standard deviation
+	madScale = 1.4826
+)
+
+// maxIntervalLoss is the largest balance loss between two snapshots counted as a
+// penalty. Larger losses are withdrawal sweeps or slashings, and the interval is
+// left out of the reward rate.
+var maxIntervalLoss = big.NewInt(5e15) // 0.005 ETH
+
+// SetRewardAnomalies sets the reward rate anomaly detection.
+func (s *ValidatorService) SetRewardAnomalies(cfg RewardAnomalyConfig) {
+	s.rewardAnomalies = cfg
+}
+
+// addRewardAnomalies flags the validators of response whose reward rate over the
+// detection window deviates from their baseline or from the fleet median, from
+// the balances recorded in the store. Like uptime, this is best effort.
+func (s *ValidatorService) addRewardAnomalies(ctx context.Context, req models.ValidatorRequest, response *models.ValidatorResponse) {
+	cfg := s.rewardAnomalies
+	if s.store == nil || cfg.Window <= 0 || !fieldSet(req.Fields).wants(sectionAnomalies) {
+		return
+	}
+
+	now := time.Now().UTC()
+	snapshots, err := s.store.Snapshots(ctx, store.Query{
+		Chain:            req.Chain,
+		ValidatorIndices: req.ValidatorIds,
+		From:             now.Add(-cfg.Window - cfg.Baseline),
+	})
+	if err != nil {
+		slog.Error("failed to load snapshots for reward anomalies", "error", err)
+		return
+	}
+	history := make(map[int][]store.Snapshot)
+	for _, snap := range snapshots {
+		history[snap.ValidatorIndex] = append(history[snap.ValidatorIndex], snap)
+	}
+
+	anomalies := detectRewardAnomalies(history, cfg, now)
+	if len(anomalies) == 0 {
+		return
+	}
+	report := models.AnomalyReport{Windows: []string{}, ExcludedValidators: []int{}, InactivityLeakPenaltyExcluded: "0"}
+	if response.Anomalies != nil {
+		report = *response.Anomalies // Copied, since it may be shared with the response cache
+	}
+	report.RewardRates = anomalies
+	response.Anomalies = &report
+}
+
+// detectRewardAnomalies compares the reward rate of each validator over the
+// window ending at now with the rates of its earlier periods of the same length,
+// and with the median rate of the fleet. Deviations are measured in
+// standard deviations, estimated robustly across the fleet from the median
+// absolute deviation. Validators are flagged from threshold deviations on.
+func detectRewardAnomalies(history map[int][]store.Snapshot, cfg RewardAnomalyConfig, now time.Time) []models.RewardRateAnomaly {
+	recent := make(map[int]float64)
+	for id, snaps := range history {
+		if rate, ok := rewardRate(snaps, now.Add(-cfg.Window), now); ok {
+			recent[id] = rate
+		}
+	}
+
+	var fleetMedian, fleetSpread float64
+	fleet := len(recent) >= minRateSamples
+	if fleet {
+		rates := make([]float64, 0, len(recent))
+		for _, r := range recent {
+			rates = append(rates, r)
+		}
+		fleetMedian = median(rates)
+		deviations := make([]float64, len(rates))
+		for i, r := range rates {
+			deviations[i] = math.Abs(r - fleetMedian)
+		}
+		fleetSpread = spread(madScale*median(deviations), fleetMedian)
+	}
+
+	var anomalies []models.RewardRateAnomaly
+	for id, rate := range recent {
+		a := models.RewardRateAnomaly{ValidatorIndex: id, Rate: dailyWei(rate)}
+		var worst float64 // Largest deviation, with its sign
+
+		var baseline []float64
+		start := now.Add(-cfg.Window - cfg.Baseline)
+		for end := now.Add(-cfg.Window); !end.Add(-cfg.Window).Before(start); end = end.Add(-cfg.Window) {
+			if r, ok := rewardRate(history[id], end.Add(-cfg.Window), end); ok {
+				baseline = append(baseline, r)
+			}
+		}
+		if mean, std := meanStd(baseline); len(baseline) >= minRateSamples && spread(std, mean) > 0 {
+			score := (rate - mean) / spread(std, mean)
+			daily := dailyWei(mean)
+			a.Baseline, a.BaselineDeviation = &daily, &score
+			worst = score
+		}
+		if fleet && fleetSpread > 0 {
+			score := (rate - fleetMedian) / fleetSpread
+			daily := dailyWei(fleetMedian)
+			a.FleetMedian, a.FleetDeviation = &daily, &score
+			if math.Abs(score) > math.Abs(worst) {
+				worst = score
+			}
+		}
+
+		if math.Abs(worst) >= cfg.Threshold {
+			a.Direction = "above"
+			if worst < 0 {
+				a.Direction = "below"
+			}
+			anomalies = append(anomalies, a)
+		}
+	}
+	sort.Slice(anomalies, func(i, j int) bool { return anomalies[i].ValidatorIndex < anomalies[j].ValidatorIndex })
+	return anomalies
+}
+
+// rewardRate returns the rate a validator earned at from from to to, in wei per
+// hour, from the balance changes between its snapshots while active. Intervals
+// with a larger loss than maxIntervalLoss are left out. It reports false unless
+// the counted intervals cover at least half the period.
+func rewardRate(snaps []store.Snapshot, from, to time.Time) (float64, bool) {
+	earned := new(big.Int)
+	var covered time.Duration
+	for i := 1; i < len(snaps); i++ {
+		prev, cur := snaps[i-1], snaps[i]
+		if prev.Time.Before(from) || cur.Time.After(to) {
+			continue
+		}
+		change, ok := rewardChange(prev, cur)
+		if !ok {
+			continue
+		}
+		earned.Add(earned, change)
+		covered += cur.Time.Sub(prev.Time)
+	}
+	if covered <= 0 || covered < to.Sub(from)/2 {
+		return 0, false
+	}
+	wei, _ := new(big.Float).SetInt(earned).Float64()
+	return wei / covered.Hours(), true
+}
+
+// rewardChange returns the balance change of a validator between two of its
+// snapshots. It reports false unless the validator was active at both, and for
+// losses larger than maxIntervalLoss.
+func rewardChange(prev, cur store.Snapshot) (*big.Int, bool) {
+	if !strings.HasPrefix(prev.Status, "active") || !strings.HasPrefix(cur.Status, "active") {
+		return nil, false
+	}
+	before, ok1 := new(big.Int).SetString(prev.CurrentBalance, 10)
+	after, ok2 := new(big.Int).SetString(cur.CurrentBalance, 10)
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+	change := after.Sub(after, before)
+	if new(big.Int).Neg(change).Cmp(maxIntervalLoss) > 0 {
+		return nil, false
+	}
+	return change, true
+}
+
+// spread returns std, raised to the minimum relative spread of mean.
+func spread(std, mean float64) float64 {
+	return math.Max(std, minRelativeSpread*math.Abs(mean))
+}
+
+// meanStd returns the mean and standard deviation of values, or zeros for none.
+func meanStd(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
+
+// median returns the median of values, which it sorts.
+func median(values []float64) float64 {
+	slices.Sort(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+// dailyWei converts a rate in wei per hour into a decimal wei amount per day.
+func dailyWei(perHour float64) string {
+	daily, _ := big.NewFloat(perHour * 24).Int(nil)
+	return daily.String()
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// balanceSnapshots returns hourly snapshots of a validator from start, its balance
+// changing by perHour(i) wei in hour i.
+func balanceSnapshots(index int, start time.Time, hours int, perHour func(i int) int64) []store.Snapshot {
+	balance := new(big.Int).Mul(big.NewInt(32), big.NewInt(1e18))
+	snaps := make([]store.Snapshot, 0, hours+1)
+	for i := 0; i <= hours; i++ {
+		if i > 0 {
+			balance.Add(balance, big.NewInt(perHour(i-1)))
+		}
+		snaps = append(snaps, store.Snapshot{
+			Time:           start.Add(time.Duration(i) * time.Hour),
+			Chain:          "mainnet",
+			ValidatorIndex: index,
+			Status:         "active_online",
+			CurrentBalance: balance.String(),
+		})
+	}
+	return snaps
+}
+
+func TestDetectRewardAnomalies(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	cfg := RewardAnomalyConfig{Window: 6 * time.Hour, Baseline: 24 * time.Hour, Threshold: 3}
+	start := now.Add(-30 * time.Hour)
+	steady := func(int) int64 { return 1e14 }
+
+	history := map[int][]store.Snapshot{
+		1: balanceSnapshots(1, start, 30, steady),
+		2: balanceSnapshots(2, start, 30, steady),
+		3: balanceSnapshots(3, start, 30, steady),
+		// Offline for the last 6 hours
+		4: balanceSnapshots(4, start, 30, func(i int) int64 {
+			if i >= 24 {
+				return -2e13
+			}
+			return 1e14
+		}),
+		// A withdrawal sweep in the window is not a loss
+		5: balanceSnapshots(5, start, 30, func(i int) int64 {
+			if i == 27 {
+				return -3e16
+			}
+			return 1e14
+		}),
+	}
+
+	anomalies := detectRewardAnomalies(history, cfg, now)
+	if len(anomalies) != 1 {
+		t.Fatalf("expected only validator 4 flagged, got %+v", anomalies)
+	}
+	a := anomalies[0]
+	if a.ValidatorIndex != 4 || a.Direction != "below" {
+		t.Errorf("unexpected anomaly %+v", a)
+	}
+	if a.Rate != "-480000000000000" || a.Baseline == nil || *a.Baseline != "2400000000000000" {
+		t.Errorf("expected a rate of -0.00048 ETH a day against 0.0024, got %s against %v", a.Rate, a.Baseline)
+	}
+	if a.BaselineDeviation == nil || *a.BaselineDeviation > -3 || a.FleetDeviation == nil || *a.FleetDeviation > -3 {
+		t.Errorf("expected deviations beyond the threshold, got %v and %v", a.BaselineDeviation, a.FleetDeviation)
+	}
+}
+
+func TestGetValidatorData_RewardAnomalies(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2, 3)...)
+	st, err := store.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Recorded history of the last hours, validator 3 losing balance
+	now := time.Now().UTC()
+	var snaps []store.Snapshot
+	for _, id := range []int{1, 2, 3} {
+		rate := int64(1e14)
+		if id == 3 {
+			rate = -2e13
+		}
+		snaps = append(snaps, balanceSnapshots(id, now.Add(-6*time.Hour), 5, func(int) int64 { return rate })...)
+	}
+	if err := st.RecordSnapshots(context.Background(), snaps); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewValidatorService(fake, nil, st, nil, nil, nil)
+	s.SetRewardAnomalies(RewardAnomalyConfig{Window: 6 * time.Hour, Baseline: 24 * time.Hour, Threshold: 3})
+	req := models.ValidatorRequest{ValidatorIds: []int{1, 2, 3}, Chain: "mainnet", Range: "24h"}
+	response, err := s.GetValidatorData(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Anomalies == nil || len(response.Anomalies.RewardRates) != 1 || response.Anomalies.RewardRates[0].ValidatorIndex != 3 {
+		t.Fatalf("expected validator 3 flagged against the fleet, got %+v", response.Anomalies)
+	}
+	if a := response.Anomalies.RewardRates[0]; a.Baseline != nil || a.FleetMedian == nil {
+		t.Errorf("expected only the fleet comparison without a baseline, got %+v", a)
+	}
+
+	// Not without the anomalies section
+	req.Fields = []string{"overview"}
+	if response, err = s.GetValidatorData(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if response.Anomalies != nil {
+		t.Errorf("expected no anomalies when not selected, got %+v", response.Anomalies)
+	}
+}
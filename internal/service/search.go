@@ -0,0 +1,210 @@
+package service
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// searchChains are the chains searched when a search names none.
+var searchChains = []string{"mainnet", "hoodi"}
+
+// minSearchHexDigits is the number of hex digits a search needs to match public
+// key and withdrawal address prefixes, so a bare 0x does not match them all.
+const minSearchHexDigits = 4
+
+// Search match types, best first.
+const (
+	searchMatchName              = "name"
+	searchMatchIndex             = "index"
+	searchMatchPubkey            = "pubkey"
+	searchMatchWithdrawalAddress = "withdrawalAddress"
+	searchMatchLabel             = "label"
+	searchMatchNotes             = "notes"
+)
+
+var searchMatchRank = map[string]int{
+	searchMatchName:              0,
+	searchMatchIndex:             1,
+	searchMatchPubkey:            2,
+	searchMatchWithdrawalAddress: 3,
+	searchMatchLabel:             4,
+	searchMatchNotes:             5,
+}
+
+// validatorIdentity is what identifies a validator besides its index, lowercase.
+type validatorIdentity struct {
+	Pubkey            string `json:"pubkey"`
+	WithdrawalAddress string `json:"withdrawalAddress,omitempty"`
+}
+
+// recordIdentities keeps the public keys and withdrawal addresses of the fetched
+// validators that are in a portfolio, labeled or noted, for Search.
+func (s *ValidatorService) recordIdentities(chain string, validators []models.BeaconchainValidatorData) {
+	if len(validators) == 0 {
+		return
+	}
+	scope := s.searchScope(chain)
+
+	s.identityMu.Lock()
+	defer s.identityMu.Unlock()
+	for _, v := range validators {
+		if v.Validator.Index == nil || !scope[*v.Validator.Index] {
+			continue
+		}
+		identity := validatorIdentity{Pubkey: strings.ToLower(v.Validator.PublicKey)}
+		if v.WithdrawalCredentials.Address != nil {
+			identity.WithdrawalAddress = strings.ToLower(*v.WithdrawalCredentials.Address)
+		}
+		if s.identities[chain] == nil {
+			s.identities[chain] = make(map[int]validatorIdentity)
+		}
+		s.identities[chain][*v.Validator.Index] = identity
+	}
+}
+
+// searchScope returns the validators on chain in a portfolio, labeled or noted.
+func (s *ValidatorService) searchScope(chain string) map[int]bool {
+	scope := make(map[int]bool)
+	for _, p := range s.portfolios.All() {
+		if p.Chain == chain {
+			for _, id := range p.ValidatorIds {
+				scope[id] = true
+			}
+		}
+	}
+	for index := range s.labels.All(chain) {
+		scope[index] = true
+	}
+	for index := range s.notes.All(chain) {
+		scope[index] = true
+	}
+	return scope
+}
+
+// Search returns up to limit portfolios and validators on chain, or on every chain
+// if empty, matching query, ignoring case. Portfolios match by name, and the
+// validators in a portfolio, labeled or noted match by index, public key or
+// withdrawal address prefix, label or notes. Public keys and withdrawal addresses
+// are those of the validators fetched before, so a search makes no upstream call.
+func (s *ValidatorService) Search(chain, query string, limit int) models.SearchResponse {
+	chains := searchChains
+	if chain != "" {
+		chains = []string{chain}
+	}
+	q := strings.ToLower(strings.TrimSpace(query))
+
+	var results []models.SearchResult
+	for _, p := range s.portfolios.All() {
+		if slices.Contains(chains, p.Chain) && strings.Contains(strings.ToLower(p.Name), q) {
+			results = append(results, models.SearchResult{
+				Type:         "portfolio",
+				Chain:        p.Chain,
+				Portfolio:    p.Name,
+				ValidatorIds: p.ValidatorIds,
+				Match:        searchMatchName,
+				Value:        p.Name,
+			})
+		}
+	}
+	for _, c := range chains {
+		results = append(results, s.searchValidators(c, q)...)
+	}
+
+	slices.SortStableFunc(results, func(a, b models.SearchResult) int {
+		if r := cmp.Compare(searchMatchRank[a.Match], searchMatchRank[b.Match]); r != 0 {
+			return r
+		}
+		// Portfolios stay in the order they were configured
+		if a.ValidatorIndex == nil || b.ValidatorIndex == nil {
+			return 0
+		}
+		return cmp.Or(cmp.Compare(a.Chain, b.Chain), cmp.Compare(*a.ValidatorIndex, *b.ValidatorIndex))
+	})
+
+	response := models.SearchResponse{Query: query, Results: []models.SearchResult{}}
+	if len(results) > limit {
+		results, response.Truncated = results[:limit], true
+	}
+	response.Results = append(response.Results, results...)
+	return response
+}
+
+// searchValidators returns the validators on chain in the search scope matching
+// the lowercase query, each by its best match.
+func (s *ValidatorService) searchValidators(chain, q string) []models.SearchResult {
+	allLabels := s.labels.All(chain)
+	allNotes := s.notes.All(chain)
+	s.identityMu.Lock()
+	identities := make(map[int]validatorIdentity, len(s.identities[chain]))
+	for index, identity := range s.identities[chain] {
+		identities[index] = identity
+	}
+	s.identityMu.Unlock()
+
+	hexPrefix := isHexPrefix(q)
+	var results []models.SearchResult
+	for index := range s.searchScope(chain) {
+		match, value := "", ""
+		identity := identities[index]
+		switch {
+		case strconv.Itoa(index) == q:
+			match, value = searchMatchIndex, q
+		case hexPrefix && identity.Pubkey != "" && strings.HasPrefix(identity.Pubkey, q):
+			match, value = searchMatchPubkey, identity.Pubkey
+		case hexPrefix && identity.WithdrawalAddress != "" && strings.HasPrefix(identity.WithdrawalAddress, q):
+			match, value = searchMatchWithdrawalAddress, identity.WithdrawalAddress
+		default:
+			if label, ok := matchLabel(allLabels[index], q); ok {
+				match, value = searchMatchLabel, label
+			} else if note := allNotes[index].Text; note != "" && strings.Contains(strings.ToLower(note), q) {
+				match, value = searchMatchNotes, note
+			}
+		}
+		if match == "" {
+			continue
+		}
+		results = append(results, models.SearchResult{
+			Type:           "validator",
+			Chain:          chain,
+			ValidatorIndex: &index,
+			Match:          match,
+			Value:          value,
+		})
+	}
+	return results
+}
+
+// matchLabel returns the first label, as key:value, containing the lowercase
+// query, ignoring case.
+func matchLabel(labels map[string]string, q string) (string, bool) {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if label := key + ":" + labels[key]; strings.Contains(strings.ToLower(label), q) {
+			return label, true
+		}
+	}
+	return "", false
+}
+
+// isHexPrefix reports whether q is 0x followed by enough hex digits to search
+// public keys and withdrawal addresses.
+func isHexPrefix(q string) bool {
+	digits, ok := strings.CutPrefix(q, "0x")
+	if !ok || len(digits) < minSearchHexDigits {
+		return false
+	}
+	for _, c := range digits {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
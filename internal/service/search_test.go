@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Pubkey("0xA1B2C3D4").WithdrawalAddress("0xFEED0001").Build(),
+		beaconchatest.Validator(2).Pubkey("0xa1b2ffff").WithdrawalAddress("0xfeed0002").Build(),
+		beaconchatest.Validator(12).Pubkey("0xc0ffee00").Build(),
+		beaconchatest.Validator(99).Pubkey("0xa1b20000").Build(), // Not in the tenant's data
+	)
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{
+		{Name: "Acme Main", Chain: "mainnet", ValidatorIds: []int{1, 2}},
+		{Name: "acme-test", Chain: "hoodi", ValidatorIds: []int{1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewValidatorService(fake, nil, nil, nil, portfolios, nil)
+	if err := s.labels.Set("mainnet", 12, map[string]string{"customer": "globex", "location": "fra"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SetValidatorNote("mainnet", 2, "Acme spare, moved to new disk"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetValidatorData(ctx, models.ValidatorRequest{ValidatorIds: []int{1, 2, 12, 99}, Chain: "mainnet", Range: "24h"}); err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		match string
+		index int // Of validators
+		name  string
+	}
+	tests := []struct {
+		name  string
+		chain string
+		query string
+		limit int
+		want  []result
+	}{
+		{name: "index", query: "12", want: []result{{match: "index", index: 12}}},
+		{name: "pubkey prefix", query: "0xa1b2", want: []result{{match: "pubkey", index: 1}, {match: "pubkey", index: 2}}},
+		{name: "pubkey prefix ignoring case", query: "0XA1B2C3", want: []result{{match: "pubkey", index: 1}}},
+		{name: "withdrawal address", query: "0xfeed0002", want: []result{{match: "withdrawalAddress", index: 2}}},
+		{name: "short hex", query: "0xa1", want: []result{}},
+		{name: "label value", query: "GLOBEX", want: []result{{match: "label", index: 12}}},
+		{name: "label key and value", query: "location:fra", want: []result{{match: "label", index: 12}}},
+		{name: "portfolio and notes", query: "acme", want: []result{{match: "name", name: "Acme Main"}, {match: "name", name: "acme-test"}, {match: "notes", index: 2}}},
+		{name: "chain", chain: "hoodi", query: "acme", want: []result{{match: "name", name: "acme-test"}}},
+		{name: "limit", query: "acme", limit: 1, want: []result{{match: "name", name: "Acme Main"}}},
+		{name: "no match", query: "initech", want: []result{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := tt.limit
+			if limit == 0 {
+				limit = 20
+			}
+			resp := s.Search(tt.chain, tt.query, limit)
+			got := []result{}
+			for _, r := range resp.Results {
+				res := result{match: r.Match, name: r.Portfolio}
+				if r.ValidatorIndex != nil {
+					res.index = *r.ValidatorIndex
+				}
+				got = append(got, res)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+			if truncated := tt.limit > 0 && len(tt.want) == tt.limit; resp.Truncated != truncated {
+				t.Errorf("expected truncated %v, got %v", truncated, resp.Truncated)
+			}
+		})
+	}
+
+	t.Run("restored from cache", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cache.json")
+		if err := s.SaveCache(path); err != nil {
+			t.Fatal(err)
+		}
+		restored := NewValidatorService(beaconchatest.New(), nil, nil, nil, portfolios, nil)
+		if err := restored.LoadCache(path); err != nil {
+			t.Fatal(err)
+		}
+		resp := restored.Search("", "0xfeed0001", 20)
+		if len(resp.Results) != 1 || resp.Results[0].Match != "withdrawalAddress" {
+			t.Errorf("expected the withdrawal address of validator 1, got %+v", resp.Results)
+		}
+	})
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// slaWindowLengths are the windows uptime can be computed over.
+var slaWindowLengths = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// DefaultSLAWindows are the windows of the uptime in performance responses unless
+// SetSLAWindows changes them.
+var DefaultSLAWindows = []string{"24h", "7d", "30d"}
+
+// ValidSLAWindow reports whether uptime can be computed over window.
+func ValidSLAWindow(window string) bool {
+	_, ok := slaWindowLengths[window]
+	return ok
+}
+
+// SetSLAWindows sets the windows of the uptime in performance responses, and of
+// SLA requests that do not choose their own.
+func (s *ValidatorService) SetSLAWindows(windows []string) {
+	s.slaWindows = windows
+}
+
+// SLAWindows returns the configured SLA windows.
+func (s *ValidatorService) SLAWindows() []string {
+	return s.slaWindows
+}
+
+// uptime is the time a set of validators spent active, and active but offline.
+type uptime struct {
+	active, offline time.Duration
+}
+
+func (u uptime) add(o uptime) uptime {
+	return uptime{active: u.active + o.active, offline: u.offline + o.offline}
+}
+
+// model converts u into its API form, with the uptime as a percentage.
+func (u uptime) model() models.Uptime {
+	result := models.Uptime{
+		ActiveSeconds:  int64(u.active / time.Second),
+		OfflineSeconds: int64(u.offline / time.Second),
+	}
+	if u.active > 0 {
+		percent := 100 * float64(u.active-u.offline) / float64(u.active)
+		result.Uptime = &percent
+	}
+	return result
+}
+
+// GetSLA returns the uptime of the validators over each window, per validator, in
+// total and, if groupBy is set, per value of that label.
+func (s *ValidatorService) GetSLA(ctx context.Context, chain string, validatorIds []int, windows []string, groupBy string) (models.SLAResponse, error) {
+	if s.store == nil {
+		return models.SLAResponse{}, ErrHistoryDisabled
+	}
+
+	perValidator, err := s.uptimes(ctx, chain, validatorIds, windows, false)
+	if err != nil {
+		return models.SLAResponse{}, err
+	}
+
+	response := models.SLAResponse{
+		Chain:      chain,
+		Total:      make(map[string]models.Uptime, len(windows)),
+		Validators: make(map[string]map[string]models.Uptime, len(validatorIds)),
+	}
+	total := make(map[string]uptime, len(windows))
+	groups := make(map[string]map[string]uptime)
+	for _, id := range validatorIds {
+		byWindow := make(map[string]models.Uptime, len(windows))
+		for _, w := range windows {
+			u := perValidator[id][w]
+			byWindow[w] = u.model()
+			total[w] = total[w].add(u)
+		}
+		response.Validators[strconv.Itoa(id)] = byWindow
+
+		if groupBy != "" {
+			value := s.labels.Get(chain, id)[groupBy]
+			if groups[value] == nil {
+				groups[value] = make(map[string]uptime, len(windows))
+			}
+			for _, w := range windows {
+				groups[value][w] = groups[value][w].add(perValidator[id][w])
+			}
+		}
+	}
+	for w, u := range total {
+		response.Total[w] = u.model()
+	}
+	if groupBy != "" {
+		response.Groups = make(map[string]map[string]models.Uptime, len(groups))
+		for value, byWindow := range groups {
+			response.Groups[value] = make(map[string]models.Uptime, len(byWindow))
+			for w, u := range byWindow {
+				response.Groups[value][w] = u.model()
+			}
+		}
+	}
+	return response, nil
+}
+
+// addUptime sets the uptime of the performance section of response, over the
+// configured windows. Like snapshots, this is best effort.
+func (s *ValidatorService) addUptime(ctx context.Context, req models.ValidatorRequest, response *models.ValidatorResponse) {
+	fields := fieldSet(req.Fields)
+	if s.store == nil || len(s.slaWindows) == 0 || !fields.wantsField(sectionPerformance, "uptime") ||
+		slices.Contains(response.TimedOutSections, sectionPerformance) {
+		return
+	}
+
+	perValidator, err := s.uptimes(ctx, req.Chain, req.ValidatorIds, s.slaWindows, false)
+	if err != nil {
+		slog.Error("failed to compute uptime", "error", err)
+		return
+	}
+	response.Performance.Uptime = make(map[string]models.Uptime, len(s.slaWindows))
+	for _, w := range s.slaWindows {
+		var total uptime
+		for _, byWindow := range perValidator {
+			total = total.add(byWindow[w])
+		}
+		response.Performance.Uptime[w] = total.model()
+	}
+}
+
+// uptimes returns the uptime of each validator over each window, or with previous
+// over the window of the same length before it, computed from its status and
+// online transitions. The state before the first transition in the longest window
+// is taken from that transition, or from the latest snapshot for validators
+// without transitions, and assumed to have held since the window started.
+// Validators without either are left out.
+func (s *ValidatorService) uptimes(ctx context.Context, chain string, validatorIds []int, windows []string, previous bool) (map[int]map[string]uptime, error) {
+	now := time.Now().UTC()
+	periods := time.Duration(1)
+	if previous {
+		periods = 2
+	}
+	var longest time.Duration
+	for _, w := range windows {
+		longest = max(longest, periods*slaWindowLengths[w])
+	}
+
+	events, err := s.store.Events(ctx, store.Query{Chain: chain, ValidatorIndices: validatorIds, From: now.Add(-longest)})
+	if err != nil {
+		return nil, fmt.Errorf("load events: %w", err)
+	}
+	snapshots, err := s.store.Snapshots(ctx, store.Query{Chain: chain, ValidatorIndices: validatorIds, From: now.Add(-deltaLookback)})
+	if err != nil {
+		return nil, fmt.Errorf("load snapshots: %w", err)
+	}
+
+	history := make(map[int][]store.Event)
+	for _, e := range events {
+		if e.Type == store.EventStatusChanged || e.Type == store.EventOnlineChanged {
+			history[e.ValidatorIndex] = append(history[e.ValidatorIndex], e)
+		}
+	}
+	latest := make(map[int]store.Snapshot)
+	for _, snap := range snapshots {
+		latest[snap.ValidatorIndex] = snap
+	}
+
+	result := make(map[int]map[string]uptime, len(validatorIds))
+	for _, id := range validatorIds {
+		initial, ok := initialState(history[id], latest, id)
+		if !ok {
+			continue
+		}
+		result[id] = make(map[string]uptime, len(windows))
+		for _, w := range windows {
+			end := now
+			if previous {
+				end = now.Add(-slaWindowLengths[w])
+			}
+			result[id][w] = replayUptime(initial, history[id], end.Add(-slaWindowLengths[w]), end)
+		}
+	}
+	return result, nil
+}
+
+// validatorState is the part of a validator's state that uptime depends on.
+type validatorState struct {
+	status string
+	online bool
+}
+
+// initialState returns the state of a validator before its first transition,
+// taken from the transitions where they cover it and from its latest snapshot
+// otherwise. It reports false if neither is known.
+func initialState(events []store.Event, latest map[int]store.Snapshot, id int) (validatorState, bool) {
+	var state validatorState
+	snap, recorded := latest[id]
+	statusKnown, onlineKnown := recorded, recorded
+	state.status, state.online = snap.Status, snap.Online
+
+	seenStatus, seenOnline := false, false
+	for _, e := range events {
+		switch {
+		case e.Type == store.EventStatusChanged && !seenStatus:
+			state.status, statusKnown, seenStatus = e.From, true, true
+		case e.Type == store.EventOnlineChanged && !seenOnline:
+			state.online, onlineKnown, seenOnline = e.From == "online", true, true
+		}
+	}
+	return state, statusKnown && onlineKnown
+}
+
+// replayUptime applies the transitions, ordered by time, to the initial state and
+// measures the uptime between start and end.
+func replayUptime(state validatorState, events []store.Event, start, end time.Time) uptime {
+	var result uptime
+	from := start
+	measure := func(until time.Time) {
+		if !until.After(from) {
+			return
+		}
+		if strings.HasPrefix(state.status, "active") {
+			result.active += until.Sub(from)
+			if !state.online {
+				result.offline += until.Sub(from)
+			}
+		}
+		from = until
+	}
+
+	for _, e := range events {
+		measure(e.Time)
+		switch e.Type {
+		case store.EventStatusChanged:
+			state.status = e.To
+		case store.EventOnlineChanged:
+			state.online = e.To == "online"
+		}
+	}
+	measure(end)
+	return result
+}
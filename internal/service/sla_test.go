@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+func TestReplayUptime(t *testing.T) {
+	start := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Hour)
+	at := func(h int) time.Time { return start.Add(time.Duration(h) * time.Hour) }
+	online := func(h int, to string) store.Event {
+		from := "online"
+		if to == "online" {
+			from = "offline"
+		}
+		return store.Event{Time: at(h), Type: store.EventOnlineChanged, From: from, To: to}
+	}
+
+	tests := []struct {
+		name        string
+		initial     validatorState
+		events      []store.Event
+		wantActive  time.Duration
+		wantOffline time.Duration
+	}{
+		{name: "online throughout", initial: validatorState{status: "active_online", online: true}, wantActive: 10 * time.Hour},
+		{
+			name:        "offline for two hours",
+			initial:     validatorState{status: "active_online", online: true},
+			events:      []store.Event{online(3, "offline"), online(5, "online")},
+			wantActive:  10 * time.Hour,
+			wantOffline: 2 * time.Hour,
+		},
+		{
+			name:        "offline since before the window",
+			initial:     validatorState{status: "active_offline"},
+			events:      []store.Event{online(-2, "offline"), online(4, "online")},
+			wantActive:  10 * time.Hour,
+			wantOffline: 4 * time.Hour,
+		},
+		{
+			name:    "activated within the window",
+			initial: validatorState{status: "pending_queued"},
+			events: []store.Event{
+				{Time: at(6), Type: store.EventStatusChanged, From: "pending_queued", To: "active_online"},
+				online(6, "online"),
+			},
+			wantActive: 4 * time.Hour,
+		},
+		{
+			name:    "offline after exiting is not downtime",
+			initial: validatorState{status: "active_online", online: true},
+			events: []store.Event{
+				{Time: at(8), Type: store.EventStatusChanged, From: "active_online", To: "exited"},
+				online(8, "offline"),
+			},
+			wantActive: 8 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := replayUptime(tt.initial, tt.events, start, end)
+			if got.active != tt.wantActive || got.offline != tt.wantOffline {
+				t.Errorf("expected %s active and %s offline, got %s and %s", tt.wantActive, tt.wantOffline, got.active, got.offline)
+			}
+		})
+	}
+}
+
+func TestGetSLA(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	st, err := store.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Validator 1 is offline for the last 6 hours, validator 2 online throughout, validator 3 never recorded
+	for _, snapshots := range [][]store.Snapshot{
+		{
+			{Time: now.Add(-12 * time.Hour), Chain: "mainnet", ValidatorIndex: 1, Status: "active_online", Online: true},
+			{Time: now.Add(-12 * time.Hour), Chain: "mainnet", ValidatorIndex: 2, Status: "active_online", Online: true},
+		},
+		{
+			{Time: now.Add(-6 * time.Hour), Chain: "mainnet", ValidatorIndex: 1, Status: "active_offline"},
+			{Time: now.Add(-6 * time.Hour), Chain: "mainnet", ValidatorIndex: 2, Status: "active_online", Online: true},
+		},
+	} {
+		if err := st.RecordSnapshots(ctx, snapshots); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewValidatorService(nil, nil, st, nil, nil, nil)
+	if _, err := s.SetValidatorLabels("mainnet", 1, map[string]string{"machine": "a"}); err != nil {
+		t.Fatal(err)
+	}
+	sla, err := s.GetSLA(ctx, "mainnet", []int{1, 2, 3}, []string{"24h"}, "machine")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertUptime := func(name string, u models.Uptime, want float64) {
+		t.Helper()
+		if u.Uptime == nil || *u.Uptime < want-0.1 || *u.Uptime > want+0.1 {
+			t.Errorf("%s: expected an uptime of %g%%, got %v", name, want, u.Uptime)
+		}
+	}
+	assertUptime("validator 1", sla.Validators["1"]["24h"], 75)
+	assertUptime("validator 2", sla.Validators["2"]["24h"], 100)
+	assertUptime("total", sla.Total["24h"], 87.5)
+	assertUptime("group a", sla.Groups["a"]["24h"], 75)
+	if u := sla.Validators["3"]["24h"]; u.Uptime != nil || u.ActiveSeconds != 0 {
+		t.Errorf("expected no uptime for an unrecorded validator, got %+v", u)
+	}
+}
+
+func TestGetValidatorData_Uptime(t *testing.T) {
+	st, err := store.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1)...)
+	s := NewValidatorService(fake, nil, st, nil, nil, nil)
+	s.SetSLAWindows([]string{"7d"})
+
+	// The first fetch records the snapshot the second one measures from
+	req := models.ValidatorRequest{ValidatorIds: []int{1}, Chain: "mainnet", Range: "24h"}
+	for i := 0; i < 2; i++ {
+		resp, err := s.GetValidatorData(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 1 {
+			if len(resp.Performance.Uptime) != 1 || resp.Performance.Uptime["7d"].Uptime == nil {
+				t.Errorf("expected the 7d uptime, got %+v", resp.Performance.Uptime)
+			}
+		}
+	}
+
+	// Not computed when the performance is not selected
+	req.Fields = []string{"rewards"}
+	resp, err := s.GetValidatorData(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Performance.Uptime != nil {
+		t.Errorf("expected no uptime without the performance section, got %+v", resp.Performance.Uptime)
+	}
+}
+
+func TestGetSLA_NoStore(t *testing.T) {
+	s := NewValidatorService(nil, nil, nil, nil, nil, nil)
+	if _, err := s.GetSLA(context.Background(), "mainnet", []int{1}, DefaultSLAWindows, ""); err != ErrHistoryDisabled {
+		t.Errorf("expected ErrHistoryDisabled, got %v", err)
+	}
+}
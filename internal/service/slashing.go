@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// ErrValidatorNotFound is returned when a single requested validator does not exist on the chain.
+var ErrValidatorNotFound = errors.New("validator not found")
+
+// GetSlashing returns the slashing details of a validator. Details are only fetched
+// for slashed validators. Requests are processed in the same FIFO queue as GetValidatorData.
+func (s *ValidatorService) GetSlashing(ctx context.Context, chain string, validatorId int) (models.SlashingResponse, error) {
+	release, err := s.acquireQueueSlot(ctx)
+	if err != nil {
+		return models.SlashingResponse{}, fmt.Errorf("queue wait: %w", err)
+	}
+	defer release()
+
+	validators, err := s.beaconchainClient.GetValidators(ctx, chain, []int{validatorId})
+	if err != nil {
+		return models.SlashingResponse{}, fmt.Errorf("fetch validator: %w", err)
+	}
+	if len(validators) == 0 {
+		return models.SlashingResponse{}, ErrValidatorNotFound
+	}
+
+	response := models.SlashingResponse{
+		ValidatorIndex: validatorId,
+		Slashed:        validators[0].Slashed,
+	}
+	if !response.Slashed {
+		return response, nil
+	}
+
+	slog.Debug("fetching slashing details", "validator", validatorId)
+
+	slashings, err := s.beaconchainClient.GetSlashings(ctx, chain, validatorId)
+	if err != nil {
+		return models.SlashingResponse{}, fmt.Errorf("fetch slashings: %w", err)
+	}
+
+	// A validator can only be slashed once, but the slashing may not be indexed yet
+	if len(slashings) > 0 {
+		details := buildSlashingDetails(slashings[0])
+		response.Slashing = &details
+	}
+
+	return response, nil
+}
+
+// buildSlashingDetails converts an upstream slashing and classifies the violation.
+func buildSlashingDetails(sl models.BeaconchainSlashing) models.SlashingDetails {
+	details := models.SlashingDetails{
+		Slot:       sl.Slot,
+		Epoch:      sl.Epoch,
+		Time:       time.Unix(sl.Timestamp, 0).UTC(),
+		Reason:     sl.Type,
+		IncludedBy: sl.Proposer,
+		Penalty:    sl.Penalty,
+	}
+
+	if p := sl.ProposerSlashing; p != nil {
+		details.Violation = "double_proposal"
+		for _, h := range []models.BeaconchainBlockHeader{p.Header1, p.Header2} {
+			details.ConflictingHeaders = append(details.ConflictingHeaders, models.SlashingBlockHeader{
+				Slot:       h.Slot,
+				ParentRoot: h.ParentRoot,
+				StateRoot:  h.StateRoot,
+				BodyRoot:   h.BodyRoot,
+			})
+		}
+	}
+
+	if a := sl.AttesterSlashing; a != nil {
+		// Two votes for the same target are a double vote, otherwise one surrounds the other
+		details.Violation = "surround_vote"
+		if a.Attestation1.TargetEpoch == a.Attestation2.TargetEpoch {
+			details.Violation = "double_vote"
+		}
+		for _, d := range []models.BeaconchainAttestationData{a.Attestation1, a.Attestation2} {
+			details.ConflictingAttestations = append(details.ConflictingAttestations, models.SlashingAttestation{
+				Slot:            d.Slot,
+				BeaconBlockRoot: d.BeaconBlockRoot,
+				SourceEpoch:     d.SourceEpoch,
+				TargetEpoch:     d.TargetEpoch,
+				TargetRoot:      d.TargetRoot,
+			})
+		}
+	}
+
+	return details
+}
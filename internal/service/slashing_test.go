@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestGetSlashing(t *testing.T) {
+	slashed := 7
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Build(),
+		beaconchatest.Validator(slashed).Slashed(300).Build(),
+	)
+	fake.AddSlashings("mainnet", models.BeaconchainSlashing{
+		Validator: models.BeaconchainValidatorInfo{Index: &slashed},
+		Slot:      9600,
+		Epoch:     300,
+		Type:      "attester_slashing",
+		Proposer:  42,
+		Penalty:   "1000000000000000000",
+		AttesterSlashing: &models.BeaconchainAttesterSlashing{
+			Attestation1: models.BeaconchainAttestationData{SourceEpoch: 290, TargetEpoch: 299},
+			Attestation2: models.BeaconchainAttestationData{SourceEpoch: 295, TargetEpoch: 299},
+		},
+	})
+
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	resp, err := s.GetSlashing(ctx, "mainnet", slashed)
+	if err != nil {
+		t.Fatalf("GetSlashing failed: %v", err)
+	}
+	if !resp.Slashed || resp.Slashing == nil {
+		t.Fatalf("expected slashing details, got %+v", resp)
+	}
+	if resp.Slashing.Violation != "double_vote" || resp.Slashing.IncludedBy != 42 || len(resp.Slashing.ConflictingAttestations) != 2 {
+		t.Errorf("unexpected details: %+v", resp.Slashing)
+	}
+
+	// Details are only fetched for slashed validators
+	resp, err = s.GetSlashing(ctx, "mainnet", 1)
+	if err != nil {
+		t.Fatalf("GetSlashing failed: %v", err)
+	}
+	if resp.Slashed || resp.Slashing != nil || fake.Calls(beaconchatest.MethodGetSlashings) != 1 {
+		t.Errorf("unexpected response for unslashed validator: %+v", resp)
+	}
+
+	if _, err := s.GetSlashing(ctx, "mainnet", 99); !errors.Is(err, ErrValidatorNotFound) {
+		t.Errorf("expected ErrValidatorNotFound, got %v", err)
+	}
+}
+
+func TestBuildSlashingDetails_Violation(t *testing.T) {
+	tests := []struct {
+		name     string
+		slashing models.BeaconchainSlashing
+		want     string
+	}{
+		{
+			name: "double proposal",
+			slashing: models.BeaconchainSlashing{Type: "proposer_slashing", ProposerSlashing: &models.BeaconchainProposerSlashing{
+				Header1: models.BeaconchainBlockHeader{Slot: 100, BodyRoot: "0x01"},
+				Header2: models.BeaconchainBlockHeader{Slot: 100, BodyRoot: "0x02"},
+			}},
+			want: "double_proposal",
+		},
+		{
+			name: "surround vote",
+			slashing: models.BeaconchainSlashing{Type: "attester_slashing", AttesterSlashing: &models.BeaconchainAttesterSlashing{
+				Attestation1: models.BeaconchainAttestationData{SourceEpoch: 10, TargetEpoch: 20},
+				Attestation2: models.BeaconchainAttestationData{SourceEpoch: 12, TargetEpoch: 18},
+			}},
+			want: "surround_vote",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildSlashingDetails(tt.slashing).Violation; got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// SnapshotExporter receives the snapshots of every fetch, e.g. to write them to a
+// time series database. ExportSnapshots must not block the request.
+type SnapshotExporter interface {
+	ExportSnapshots(snapshots []store.Snapshot)
+}
+
+// SetSnapshotExporter sets an exporter of the snapshots of every fetch. It works
+// with or without the history store.
+func (s *ValidatorService) SetSnapshotExporter(e SnapshotExporter) {
+	s.snapshotExporter = e
+}
+
+// recordSnapshots persists the fetched overviews as snapshots and hands them to
+// the snapshot exporter. Failures are logged rather than returned, since history
+// is best effort and must not fail the request that produced the data.
+func (s *ValidatorService) recordSnapshots(ctx context.Context, chain string, overviews map[string]models.ValidatorOverview) {
+	if (s.store == nil && s.snapshotExporter == nil) || len(overviews) == 0 {
+		return
+	}
+
+	now := time.Now().UTC()
+	snapshots := make([]store.Snapshot, 0, len(overviews))
+	for id, o := range overviews {
+		index, err := strconv.Atoi(id)
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, store.Snapshot{
+			Time:             now,
+			Chain:            chain,
+			ValidatorIndex:   index,
+			Status:           o.Status,
+			Online:           o.Online,
+			Slashed:          o.Slashed,
+			CurrentBalance:   o.CurrentBalance,
+			EffectiveBalance: o.EffectiveBalance,
+			ActivationEpoch:  o.ActivationEpoch,
+			ExitEpoch:        o.ExitEpoch,
+			Unfinalized:      o.Finalized != nil,
+		})
+	}
+
+	if s.snapshotExporter != nil {
+		s.snapshotExporter.ExportSnapshots(snapshots)
+	}
+	if s.store == nil {
+		return
+	}
+	if err := s.store.RecordSnapshots(ctx, snapshots); err != nil {
+		slog.Error("failed to record snapshots", "error", err)
+	}
+}
+
+// recordAttestationSample persists the attestation performance of the requested
+// validators so its trend can be followed across refreshes. Like snapshots, this is
+// best effort.
+func (s *ValidatorService) recordAttestationSample(ctx context.Context, req models.ValidatorRequest, duties models.AttestationDuties) {
+	if s.store == nil || duties.Assigned == 0 {
+		return
+	}
+
+	sample := store.AttestationSample{
+		Time:              time.Now().UTC(),
+		Chain:             req.Chain,
+		ValidatorIndices:  store.SortedIndices(req.ValidatorIds),
+		Range:             req.Range,
+		Included:          duties.Included,
+		Assigned:          duties.Assigned,
+		AvgInclusionDelay: duties.AvgInclusionDelay,
+	}
+
+	if err := s.store.RecordAttestationSample(ctx, sample); err != nil {
+		slog.Error("failed to record attestation sample", "error", err)
+	}
+}
+
+// upstreamEpochDelay is how long after an epoch completes upstream data is assumed
+// to include it, matching the default delay of `vdash watch`.
+const upstreamEpochDelay = time.Minute
+
+// LastRefresh returns when the data of the validators was last fetched, or the zero
+// time if it is unknown, e.g. because no store is configured.
+func (s *ValidatorService) LastRefresh(ctx context.Context, chain string, validatorIds []int) time.Time {
+	if s.store == nil {
+		return time.Time{}
+	}
+	t, err := s.store.LastRefresh(ctx, chain, validatorIds)
+	if err != nil {
+		slog.Error("failed to read last refresh", "error", err)
+		return time.Time{}
+	}
+	return t
+}
+
+// UnchangedSince reports whether a copy of the validators' data taken at since is
+// still current: the data was fetched after the latest epoch became available
+// upstream, and no later than since. Since HTTP dates have second precision, the
+// refresh time is compared truncated to the second.
+func (s *ValidatorService) UnchangedSince(ctx context.Context, chain string, validatorIds []int, since time.Time) bool {
+	refreshed := s.LastRefresh(ctx, chain, validatorIds)
+	if refreshed.IsZero() {
+		return false
+	}
+
+	spec, err := chainspec.ForChain(chain)
+	if err != nil {
+		return false
+	}
+	epoch, err := spec.LastCompletedEpoch(time.Now().Add(-upstreamEpochDelay))
+	if err != nil {
+		return false
+	}
+	available := spec.EpochStart(epoch + 1).Add(upstreamEpochDelay)
+
+	return !refreshed.Before(available) && !since.Before(refreshed.Truncate(time.Second))
+}
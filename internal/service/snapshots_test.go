@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+func TestUnchangedSince(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	stale := now.Add(-time.Hour) // several epochs ago
+
+	tests := []struct {
+		name      string
+		refreshed time.Time // zero for never refreshed
+		since     time.Time
+		want      bool
+	}{
+		{name: "client copy after refresh", refreshed: now, since: now.Add(time.Second), want: true},
+		{name: "client copy in the refresh second", refreshed: now, since: now.Truncate(time.Second), want: true},
+		{name: "client copy before refresh", refreshed: now, since: now.Add(-time.Minute)},
+		{name: "refresh predates latest epoch", refreshed: stale, since: now},
+		{name: "never refreshed", since: now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := store.NewFileStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.refreshed.IsZero() {
+				if err := st.RecordSnapshots(ctx, []store.Snapshot{{Time: tt.refreshed, Chain: "mainnet", ValidatorIndex: 1}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			s := NewValidatorService(nil, nil, st, nil, nil, nil)
+			if got := s.UnchangedSince(ctx, "mainnet", []int{1}, tt.since); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUnchangedSince_NoStore(t *testing.T) {
+	s := NewValidatorService(nil, nil, nil, nil, nil, nil)
+	if s.UnchangedSince(context.Background(), "mainnet", []int{1}, time.Now()) {
+		t.Error("expected data without a store to never be unchanged")
+	}
+}
+
+// exportRecorder collects the snapshots it is handed.
+type exportRecorder struct {
+	snapshots []store.Snapshot
+}
+
+func (r *exportRecorder) ExportSnapshots(snapshots []store.Snapshot) {
+	r.snapshots = append(r.snapshots, snapshots...)
+}
+
+func TestRecordSnapshots_Exporter(t *testing.T) {
+	s := NewValidatorService(nil, nil, nil, nil, nil, nil)
+	rec := &exportRecorder{}
+	s.SetSnapshotExporter(rec)
+
+	s.recordSnapshots(context.Background(), "mainnet", map[string]models.ValidatorOverview{
+		"7": {Status: "active_online", Online: true, CurrentBalance: "32000000000000000000"},
+	})
+
+	if len(rec.snapshots) != 1 {
+		t.Fatalf("expected 1 exported snapshot without a store, got %d", len(rec.snapshots))
+	}
+	if snap := rec.snapshots[0]; snap.Chain != "mainnet" || snap.ValidatorIndex != 7 || !snap.Online || snap.CurrentBalance != "32000000000000000000" {
+		t.Errorf("expected the snapshot of validator 7, got %+v", snap)
+	}
+}
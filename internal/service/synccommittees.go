@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// GetSyncCommittees returns the past and current sync committee assignments of the validators.
+// Requests are processed in the same FIFO queue as GetValidatorData.
+func (s *ValidatorService) GetSyncCommittees(ctx context.Context, chain string, validatorIds []int) (models.SyncCommitteesResponse, error) {
+	if len(validatorIds) == 0 {
+		return models.SyncCommitteesResponse{Assignments: []models.SyncCommitteeAssignment{}}, nil
+	}
+
+	release, err := s.acquireQueueSlot(ctx)
+	if err != nil {
+		return models.SyncCommitteesResponse{}, fmt.Errorf("queue wait: %w", err)
+	}
+	defer release()
+
+	slog.Debug("fetching sync committees", "validators", len(validatorIds))
+
+	assignments, err := s.beaconchainClient.GetSyncCommittees(ctx, chain, validatorIds)
+	if err != nil {
+		return models.SyncCommitteesResponse{}, fmt.Errorf("fetch sync committees: %w", err)
+	}
+
+	// Without a known head no period is reported as current
+	head, err := chainspec.LastCompletedEpoch(chain, time.Now())
+	if err != nil {
+		head = -1
+	}
+
+	return buildSyncCommittees(assignments, head), nil
+}
+
+// buildSyncCommittees converts the upstream assignments, marking periods that
+// contain the epoch after head as current.
+func buildSyncCommittees(assignments []models.BeaconchainSyncCommitteeAssignment, head int64) models.SyncCommitteesResponse {
+	result := make([]models.SyncCommitteeAssignment, 0, len(assignments))
+	for _, a := range assignments {
+		if a.Validator.Index == nil {
+			continue
+		}
+
+		var participation *float64
+		if a.Assigned > 0 {
+			rate := float64(a.Successful) / float64(a.Assigned)
+			participation = &rate
+		}
+
+		rewards := a.Reward
+		if rewards == "" {
+			rewards = "0"
+		}
+
+		result = append(result, models.SyncCommitteeAssignment{
+			ValidatorIndex:    *a.Validator.Index,
+			Period:            a.Period,
+			StartEpoch:        a.Epoch.Start,
+			EndEpoch:          a.Epoch.End,
+			Current:           head >= 0 && a.Epoch.Start <= head+1 && head+1 <= a.Epoch.End,
+			Assigned:          a.Assigned,
+			Successful:        a.Successful,
+			Missed:            a.Missed,
+			ParticipationRate: participation,
+			Rewards:           rewards,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Period != result[j].Period {
+			return result[i].Period > result[j].Period
+		}
+		return result[i].ValidatorIndex < result[j].ValidatorIndex
+	})
+
+	return models.SyncCommitteesResponse{Assignments: result}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestBuildSyncCommittees(t *testing.T) {
+	index := func(i int) models.BeaconchainValidatorInfo { return models.BeaconchainValidatorInfo{Index: &i} }
+
+	assignments := []models.BeaconchainSyncCommitteeAssignment{
+		{Validator: index(2), Period: 100, Epoch: models.BeaconchainEpochRange{Start: 25600, End: 25855}, Assigned: 8192, Successful: 8100, Missed: 92, Reward: "1000"},
+		{Validator: index(1), Period: 120, Epoch: models.BeaconchainEpochRange{Start: 30720, End: 30975}},
+		{Validator: index(1), Period: 100, Epoch: models.BeaconchainEpochRange{Start: 25600, End: 25855}, Assigned: 8192, Successful: 8192, Reward: "2000"},
+	}
+
+	got := buildSyncCommittees(assignments, 30719)
+
+	if len(got.Assignments) != 3 {
+		t.Fatalf("expected 3 assignments, got %d", len(got.Assignments))
+	}
+
+	current := got.Assignments[0]
+	if current.Period != 120 || !current.Current || current.ParticipationRate != nil || current.Rewards != "0" {
+		t.Errorf("unexpected current assignment: %+v", current)
+	}
+
+	past := got.Assignments[1]
+	if past.ValidatorIndex != 1 || past.Current || past.ParticipationRate == nil || *past.ParticipationRate != 1 {
+		t.Errorf("unexpected past assignment: %+v", past)
+	}
+	if got.Assignments[2].ValidatorIndex != 2 {
+		t.Errorf("expected validator 2 last, got %+v", got.Assignments[2])
+	}
+}
+
+func TestGetSyncCommittees(t *testing.T) {
+	one := 1
+	fake := beaconchatest.New()
+	fake.AddSyncCommittees("hoodi", models.BeaconchainSyncCommitteeAssignment{
+		Validator: models.BeaconchainValidatorInfo{Index: &one}, Period: 3, Assigned: 10, Successful: 9, Missed: 1,
+	})
+
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+
+	resp, err := s.GetSyncCommittees(context.Background(), "hoodi", []int{1, 2})
+	if err != nil {
+		t.Fatalf("GetSyncCommittees failed: %v", err)
+	}
+	if len(resp.Assignments) != 1 || *resp.Assignments[0].ParticipationRate != 0.9 {
+		t.Errorf("unexpected assignments: %+v", resp.Assignments)
+	}
+}
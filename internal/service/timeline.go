@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+// GetTimeline returns the state transitions recorded for a validator over the last
+// days, with how long the validator stayed in each status and online state.
+// Transitions are detected between snapshots, so their times are those of the
+// first refresh that saw them.
+func (s *ValidatorService) GetTimeline(ctx context.Context, chain string, validatorId, days int) (models.TimelineResponse, error) {
+	if s.store == nil {
+		return models.TimelineResponse{}, ErrHistoryDisabled
+	}
+
+	now := time.Now().UTC()
+	since := now.AddDate(0, 0, -days)
+	events, err := s.store.Events(ctx, store.Query{
+		Chain:            chain,
+		ValidatorIndices: []int{validatorId},
+		From:             since,
+	})
+	if err != nil {
+		return models.TimelineResponse{}, fmt.Errorf("load events: %w", err)
+	}
+
+	response := buildTimeline(events, now)
+	response.ValidatorIndex = validatorId
+	response.Chain = chain
+	response.Since = since
+	return response, nil
+}
+
+// buildTimeline converts events, ordered by time, into transitions and totals the
+// time spent offline up to now.
+func buildTimeline(events []store.Event, now time.Time) models.TimelineResponse {
+	response := models.TimelineResponse{Transitions: make([]models.TimelineTransition, 0, len(events))}
+
+	// Index of the last transition of each timed type, whose duration the next one ends
+	last := make(map[store.EventType]int)
+	for _, e := range events {
+		if i, ok := last[e.Type]; ok {
+			prev := &response.Transitions[i]
+			duration := int64(e.Time.Sub(prev.Time) / time.Second)
+			prev.DurationSeconds = &duration
+		}
+		if e.Type == store.EventStatusChanged || e.Type == store.EventOnlineChanged {
+			last[e.Type] = len(response.Transitions)
+		}
+		response.Transitions = append(response.Transitions, models.TimelineTransition{
+			Time: e.Time,
+			Type: string(e.Type),
+			From: e.From,
+			To:   e.To,
+		})
+	}
+
+	for _, t := range response.Transitions {
+		if t.Type != string(store.EventOnlineChanged) || t.To != "offline" {
+			continue
+		}
+		if t.DurationSeconds != nil {
+			response.OfflineSeconds += *t.DurationSeconds
+		} else {
+			response.OfflineSeconds += int64(now.Sub(t.Time) / time.Second)
+		}
+	}
+	return response
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+)
+
+func TestGetTimeline(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	st, err := store.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := []struct {
+		ago    time.Duration
+		status string
+		online bool
+	}{
+		{ago: 5 * time.Hour, status: "pending_queued"},
+		{ago: 4 * time.Hour, status: "active_online", online: true},
+		{ago: 3 * time.Hour, status: "active_offline"},
+		{ago: 2 * time.Hour, status: "active_online", online: true},
+		{ago: time.Hour, status: "active_offline"},
+	}
+	for _, state := range states {
+		snap := store.Snapshot{Time: now.Add(-state.ago), Chain: "mainnet", ValidatorIndex: 7, Status: state.status, Online: state.online}
+		if err := st.RecordSnapshots(ctx, []store.Snapshot{snap}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewValidatorService(nil, nil, st, nil, nil, nil)
+	timeline, err := s.GetTimeline(ctx, "mainnet", 7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Four status and four online transitions after the first snapshot
+	if len(timeline.Transitions) != 8 {
+		t.Fatalf("expected 8 transitions, got %+v", timeline.Transitions)
+	}
+	for i, tr := range timeline.Transitions {
+		last := i >= len(timeline.Transitions)-2
+		if last != (tr.DurationSeconds == nil) {
+			t.Errorf("transition %d: expected a duration only for transitions that ended, got %v", i, tr.DurationSeconds)
+		}
+		if tr.DurationSeconds != nil && *tr.DurationSeconds != 3600 {
+			t.Errorf("transition %d: expected 3600 seconds, got %d", i, *tr.DurationSeconds)
+		}
+	}
+
+	// Offline for an hour three hours ago, and since an hour ago
+	if want := int64(2 * 3600); timeline.OfflineSeconds < want || timeline.OfflineSeconds > want+60 {
+		t.Errorf("expected about %d seconds offline, got %d", want, timeline.OfflineSeconds)
+	}
+}
+
+func TestGetTimeline_NoStore(t *testing.T) {
+	s := NewValidatorService(nil, nil, nil, nil, nil, nil)
+	if _, err := s.GetTimeline(context.Background(), "mainnet", 1, 30); err != ErrHistoryDisabled {
+		t.Errorf("expected ErrHistoryDisabled, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/amount"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// fillRewardTotals computes the totals upstream left out of a rewards aggregate
+// from their parts, so that a missing total is not reported as zero. Totals that
+// upstream reported are kept.
+func fillRewardTotals(data *models.BeaconchainRewardsData) {
+	a := &data.Attestation
+	for _, b := range []*models.BeaconchainRewardBreakdown{&a.Head, &a.Source, &a.Target} {
+		fillDifference(&b.Total, b.Reward, b.Penalty)
+	}
+	if a.Total == "" && (a.Head.Total != "" || a.Source.Total != "" || a.Target.Total != "") {
+		total := sumReported(a.Head.Total, a.Source.Total, a.Target.Total)
+		if a.InclusionDelay != nil {
+			total = total.Add(amount.ParseOrZero(a.InclusionDelay.Total))
+		}
+		a.Total = total.Sub(amount.ParseOrZero(a.InactivityLeakPenalty)).String()
+	}
+
+	sc := &data.SyncCommittee
+	fillDifference(&sc.Total, sc.Reward, sc.Penalty)
+
+	p := &data.Proposal
+	if p.Total == "" && (p.ExecutionLayerReward != "" || p.AttestationInclusionReward != "" || p.SyncInclusionReward != "" || p.SlashingInclusionReward != "") {
+		p.Total = sumReported(p.ExecutionLayerReward, p.AttestationInclusionReward, p.SyncInclusionReward, p.SlashingInclusionReward).String()
+	}
+
+	// The net total is the rewards minus the penalties, or else the sum of the duties
+	if !fillDifference(&data.Total, data.TotalReward, data.TotalPenalty) && data.Total == "" &&
+		(a.Total != "" || sc.Total != "" || p.Total != "") {
+		data.Total = sumReported(a.Total, sc.Total, p.Total).String()
+	}
+	if data.TotalReward == "" && data.Total != "" && data.TotalPenalty != "" {
+		data.TotalReward = amount.ParseOrZero(data.Total).Add(amount.ParseOrZero(data.TotalPenalty)).String()
+	}
+	fillDifference(&data.TotalPenalty, data.TotalReward, data.Total)
+}
+
+// fillDifference sets an empty total to a minus b when both are reported, and
+// reports whether it did.
+func fillDifference(total *string, a, b string) bool {
+	if *total != "" || a == "" || b == "" {
+		return false
+	}
+	*total = amount.ParseOrZero(a).Sub(amount.ParseOrZero(b)).String()
+	return true
+}
+
+// sumReported sums amounts, counting those left out as zero.
+func sumReported(parts ...string) amount.Amount {
+	var sum amount.Amount
+	for _, p := range parts {
+		sum = sum.Add(amount.ParseOrZero(p))
+	}
+	return sum
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestFillRewardTotals(t *testing.T) {
+	tests := []struct {
+		name string
+		data models.BeaconchainRewardsData
+		want models.BeaconchainRewardsData
+	}{
+		{
+			name: "reported totals are kept",
+			data: models.BeaconchainRewardsData{Total: "5", TotalReward: "100", TotalPenalty: "30"},
+			want: models.BeaconchainRewardsData{Total: "5", TotalReward: "100", TotalPenalty: "30"},
+		},
+		{
+			name: "net total from rewards and penalties",
+			data: models.BeaconchainRewardsData{TotalReward: "100", TotalPenalty: "130"},
+			want: models.BeaconchainRewardsData{Total: "-30", TotalReward: "100", TotalPenalty: "130"},
+		},
+		{
+			name: "rewards and penalties from the net total",
+			data: models.BeaconchainRewardsData{Total: "-30", TotalPenalty: "130"},
+			want: models.BeaconchainRewardsData{Total: "-30", TotalReward: "100", TotalPenalty: "130"},
+		},
+		{
+			name: "totals from the duties",
+			data: models.BeaconchainRewardsData{
+				Attestation: models.BeaconchainAttestationRewards{
+					Head:                  models.BeaconchainRewardBreakdown{Reward: "10", Penalty: "2"},
+					Source:                models.BeaconchainRewardBreakdown{Total: "20"},
+					Target:                models.BeaconchainRewardBreakdown{Total: "-5"},
+					InactivityLeakPenalty: "3",
+				},
+				SyncCommittee: models.BeaconchainSyncCommitteeRewards{Reward: "4", Penalty: "6"},
+				Proposal:      models.BeaconchainProposalRewards{ExecutionLayerReward: "50", AttestationInclusionReward: "7"},
+			},
+			want: models.BeaconchainRewardsData{
+				Total: "75",
+				Attestation: models.BeaconchainAttestationRewards{
+					Total:                 "20",
+					Head:                  models.BeaconchainRewardBreakdown{Total: "8", Reward: "10", Penalty: "2"},
+					Source:                models.BeaconchainRewardBreakdown{Total: "20"},
+					Target:                models.BeaconchainRewardBreakdown{Total: "-5"},
+					InactivityLeakPenalty: "3",
+				},
+				SyncCommittee: models.BeaconchainSyncCommitteeRewards{Total: "-2", Reward: "4", Penalty: "6"},
+				Proposal:      models.BeaconchainProposalRewards{Total: "57", ExecutionLayerReward: "50", AttestationInclusionReward: "7"},
+			},
+		},
+		{
+			name: "nothing reported stays empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fillRewardTotals(&tt.data)
+			if tt.data != tt.want {
+				t.Errorf("got %+v, want %+v", tt.data, tt.want)
+			}
+		})
+	}
+}
@@ -3,120 +3,268 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
 	"sync"
-	"sync/atomic"
+	"time"
 
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/anomaly"
 	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/budget"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/cache"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/diversity"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/eigenlayer"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/ens"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/exits"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/labels"
 	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/notes"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/price"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/relays"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
 )
 
 // ValidatorService handles validator data aggregation.
 // It uses a FIFO queue to ensure strict request prioritization -
 // each request is fully completed before the next one starts.
 type ValidatorService struct {
-	beaconchainClient *beaconcha.Client
+	beaconchainClient beaconcha.Provider
+	anomalyFilter     *anomaly.Filter
+	store             store.Store      // Optional, records snapshots of every fetch
+	snapshotExporter  SnapshotExporter // Optional, see SetSnapshotExporter
+	prices            *price.Service
+	portfolios        *portfolio.Registry
+	names             *ens.Resolver   // Optional, adds ENS names to withdrawal addresses
+	budget            *budget.Manager // Optional, see SetBudget
+	labels            *labels.Store
+	exits             *exits.Store
+	notes             *notes.Store
+	diversity         *diversity.Network // Optional, see SetNetworkDiversity
+	relays            *relays.Checker    // Optional, see SetRelays
+	relayMaxAge       time.Duration
+	restaking         *eigenlayer.Checker // Optional, see SetRestaking
+	slaWindows        []string            // Windows of the uptime in performance responses
+	healthWeights     map[string]float64  // Weights of the health score components
+	rewardAnomalies   RewardAnomalyConfig
+
+	// Balance history cache, keyed by chain/validator/epochs
+	balanceCache *cache.LRU[balanceCacheEntry]
+
+	// Network averages, keyed by chain/range
+	networkMu    sync.Mutex
+	networkCache map[string]networkCacheEntry
+	networkSizes map[string]networkSizeEntry // Keyed by chain
+	blockValues  map[string]blockValueEntry  // Keyed by chain/range
+
+	// Validator responses of the current epoch, served to the dashboard
+	responseCache *cache.LRU[responseCacheEntry]
+
+	// Rewards and performance aggregates of the current epoch, keyed by section/chain/range/validators
+	aggregateCache *cache.LRU[aggregateCacheEntry]
+
+	// Validators with conflicting attestations per chain, and the last epoch scanned
+	doppelgangerMu      sync.Mutex
+	doppelgangers       map[string]map[int]models.DoppelgangerSuspicion
+	doppelgangerScanned map[string]int64
+
+	// Public keys and withdrawal addresses of searchable validators, per chain
+	identityMu sync.Mutex
+	identities map[string]map[int]validatorIdentity
 
 	// Request queue for strict FIFO ordering
-	queueMu     sync.Mutex // Protects queue operations
-	queueHead   uint64     // Next ticket to be served
-	queueTail   uint64     // Next ticket to be issued
-	queueCond   *sync.Cond // Condition variable for waiting
-	activeCount int32      // Number of requests currently being processed (should be 0 or 1)
+	queueMu     sync.Mutex      // Protects queue operations
+	queueHead   uint64          // Next ticket to be served
+	queueTail   uint64          // Next ticket to be issued
+	queueCond   *sync.Cond      // Condition variable for waiting
+	activeCount int32           // Number of requests currently being processed (should be 0 or 1)
+	abandoned   map[uint64]bool // Tickets whose requests were canceled while waiting
+	draining    bool            // Set by Drain; no tickets are issued
 }
 
 // NewValidatorService creates a new validator service.
-func NewValidatorService(client *beaconcha.Client) *ValidatorService {
+// The store may be nil, in which case no history is recorded, and the ENS resolver
+// may be nil, in which case no names are resolved.
+func NewValidatorService(client beaconcha.Provider, anomalyFilter *anomaly.Filter, st store.Store, prices *price.Service, portfolios *portfolio.Registry, names *ens.Resolver) *ValidatorService {
 	s := &ValidatorService{
 		beaconchainClient: client,
+		anomalyFilter:     anomalyFilter,
+		store:             st,
+		prices:            prices,
+		portfolios:        portfolios,
+		names:             names,
+		labels:            labels.NewStore(),
+		exits:             exits.NewStore(),
+		notes:             notes.NewStore(),
+		networkCache:      make(map[string]networkCacheEntry),
+		networkSizes:      make(map[string]networkSizeEntry),
+		blockValues:       make(map[string]blockValueEntry),
+		slaWindows:        DefaultSLAWindows,
+		healthWeights:     DefaultHealthWeights,
+		rewardAnomalies:   DefaultRewardAnomalyConfig,
+
+		doppelgangers:       make(map[string]map[int]models.DoppelgangerSuspicion),
+		doppelgangerScanned: make(map[string]int64),
+		identities:          make(map[string]map[int]validatorIdentity),
 	}
+	s.SetCacheLimits(defaultCacheMaxEntries, defaultCacheMaxBytes)
 	s.queueCond = sync.NewCond(&s.queueMu)
 	return s
 }
 
-// acquireQueueSlot gets a ticket and waits until it's our turn.
-// Returns a release function that must be called when done.
-func (s *ValidatorService) acquireQueueSlot(ctx context.Context) (func(), error) {
-	s.queueMu.Lock()
-
-	// Get our ticket number
-	myTicket := s.queueTail
-	s.queueTail++
-
-	slog.Debug("request queued", "ticket", myTicket, "queueHead", s.queueHead)
-
-	// Wait until it's our turn (our ticket matches head) and no one is active
-	for s.queueHead != myTicket || atomic.LoadInt32(&s.activeCount) > 0 {
-		// Check context before waiting
-		select {
-		case <-ctx.Done():
-			s.queueMu.Unlock()
-			return nil, ctx.Err()
-		default:
-		}
-
-		// Wait for signal (releases mutex while waiting)
-		s.queueCond.Wait()
+// GetValidatorData fetches and aggregates data for the given validator IDs, along
+// with their labels, pre-signed exit flags, doppelganger flags, uptime, reward
+// rate anomalies and, if requested, the aggregates of the previous period.
+// Requests are processed in strict FIFO order - each request completes all
+// Beaconcha API calls before the next request starts.
+func (s *ValidatorService) GetValidatorData(ctx context.Context, req models.ValidatorRequest) (models.ValidatorResponse, error) {
+	response, err := s.validatorData(ctx, req)
+	if err != nil {
+		return response, err
 	}
-
-	// It's our turn - mark as active
-	atomic.StoreInt32(&s.activeCount, 1)
-	s.queueMu.Unlock()
-
-	slog.Debug("request processing", "ticket", myTicket)
-
-	// Return release function
-	return func() {
-		s.queueMu.Lock()
-		s.queueHead++ // Move to next ticket
-		atomic.StoreInt32(&s.activeCount, 0)
-		s.queueCond.Broadcast() // Wake up all waiters to check their turn
-		s.queueMu.Unlock()
-		slog.Debug("request completed", "ticket", myTicket, "nextTicket", myTicket+1)
-	}, nil
+	s.annotate(req, &response)
+	addWindow(req, &response)
+	s.addUptime(ctx, req, &response)
+	s.addRewardAnomalies(ctx, req, &response)
+	s.addPrevious(ctx, req, &response)
+	return response, nil
 }
 
-// GetValidatorData fetches and aggregates data for the given validator IDs.
-// Requests are processed in strict FIFO order - each request completes all
-// Beaconcha API calls before the next request starts.
-func (s *ValidatorService) GetValidatorData(ctx context.Context, chain string, validatorIds []int, evalRange string) (models.ValidatorResponse, error) {
-	if len(validatorIds) == 0 {
+// validatorData returns the data of the request, from the stale cache when the
+// budget runs low or the request deadline passes before the validators are fetched.
+func (s *ValidatorService) validatorData(ctx context.Context, req models.ValidatorRequest) (models.ValidatorResponse, error) {
+	if len(req.ValidatorIds) == 0 {
 		return models.ValidatorResponse{}, nil
 	}
 
+	// Near the end of the daily budget, earlier data is preferred over spending credits
+	if s.budget.Low() {
+		if stale, ok := s.staleResponse(ctx, req, false); ok {
+			return stale, nil
+		}
+	}
+
 	// Acquire queue slot - blocks until it's our turn
 	release, err := s.acquireQueueSlot(ctx)
 	if err != nil {
+		if deadlineExceeded(ctx, err) {
+			if stale, ok := s.staleResponse(ctx, req, true); ok {
+				return stale, nil
+			}
+		}
 		return models.ValidatorResponse{}, fmt.Errorf("queue wait: %w", err)
 	}
 	defer release()
 
-	slog.Debug("fetching validator data", "validators", len(validatorIds), "range", evalRange)
+	slog.Debug("fetching validator data", "validators", len(req.ValidatorIds), "range", req.Range)
 
 	// Fetch data from Beaconcha (we have exclusive access now)
-	return s.fetchAndAggregate(ctx, chain, validatorIds, evalRange)
+	response, err := s.fetchAndAggregate(ctx, req)
+	if errors.Is(err, budget.ErrExhausted) || deadlineExceeded(ctx, err) {
+		if stale, ok := s.staleResponse(ctx, req, true); ok {
+			return stale, nil
+		}
+	}
+	return response, err
 }
 
 // fetchAndAggregate fetches all required data from Beaconcha and aggregates it.
-func (s *ValidatorService) fetchAndAggregate(ctx context.Context, chain string, validatorIds []int, evalRange string) (models.ValidatorResponse, error) {
+// Once the validators are fetched, sections that miss the request deadline are
+// listed in TimedOutSections instead of failing the request, and such a partial
+// response is not cached.
+func (s *ValidatorService) fetchAndAggregate(ctx context.Context, req models.ValidatorRequest) (models.ValidatorResponse, error) {
+	var (
+		wg            sync.WaitGroup
+		validators    []models.BeaconchainValidatorData
+		validatorsErr error
+	)
 	// Fetch validator overview data (per-validator)
-	validators, err := s.beaconchainClient.GetValidators(ctx, chain, validatorIds)
-	if err != nil {
-		return models.ValidatorResponse{}, fmt.Errorf("fetch validators: %w", err)
+	fetchValidators := func() {
+		validators, validatorsErr = s.beaconchainClient.GetValidators(ctx, req.Chain, req.ValidatorIds)
 	}
 
-	// Fetch aggregated rewards (combined for all validators)
-	rewards, err := s.beaconchainClient.GetRewardsAggregate(ctx, chain, validatorIds, evalRange)
-	if err != nil {
-		return models.ValidatorResponse{}, fmt.Errorf("fetch rewards: %w", err)
+	// Validators caught in a known mass slashing are left out of the aggregates, so
+	// the aggregates wait for the validators only when there are such slashings.
+	// Otherwise all sections are fetched concurrently, still through the rate limit.
+	aggregateIds := req.ValidatorIds
+	var report *models.AnomalyReport
+	if req.ExcludeAnomalies && s.anomalyFilter.HasMassSlashings(req.Chain) {
+		fetchValidators()
+		if validatorsErr != nil {
+			return models.ValidatorResponse{}, fmt.Errorf("fetch validators: %w", validatorsErr)
+		}
+		aggregateIds, report = s.excludeMassSlashings(req.Chain, req.ValidatorIds, validators)
+	} else {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fetchValidators()
+		}()
 	}
 
-	// Fetch aggregated performance (combined for all validators)
-	performance, err := s.beaconchainClient.GetPerformanceAggregate(ctx, chain, validatorIds, evalRange)
-	if err != nil {
-		return models.ValidatorResponse{}, fmt.Errorf("fetch performance: %w", err)
+	fields := fieldSet(req.Fields)
+
+	// Fetch aggregated rewards (combined for all validators) unless no selected
+	// section needs them
+	var (
+		rewards    *models.BeaconchainRewardsAggregateResponse
+		rewardsErr error
+	)
+	if fields.wants(sectionRewards) || fields.wants(sectionFiat) || fields.wants(sectionBenchmark) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rewards, rewardsErr = s.rewardsAggregate(ctx, req.Chain, aggregateIds, req.Range)
+		}()
+	}
+
+	// Fetch aggregated performance (combined for all validators) unless no selected
+	// section needs it
+	var (
+		performance    *models.BeaconchainPerformanceAggregateResponse
+		performanceErr error
+	)
+	if fields.wants(sectionPerformance) || fields.wants(sectionBenchmark) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			performance, performanceErr = s.performanceAggregate(ctx, req.Chain, aggregateIds, req.Range)
+		}()
+	}
+	wg.Wait()
+
+	if validatorsErr != nil {
+		return models.ValidatorResponse{}, fmt.Errorf("fetch validators: %w", validatorsErr)
+	}
+	s.recordIdentities(req.Chain, validators)
+	if req.ExcludeAnomalies && report == nil {
+		_, report = s.excludeMassSlashings(req.Chain, req.ValidatorIds, validators) // Excludes no validators
+	}
+
+	// Failed aggregates are computed from other data where possible, so the headline
+	// numbers do not go blank while an aggregate endpoint is down
+	var timedOut, fallback []string
+	if deadlineExceeded(ctx, rewardsErr) {
+		timedOut = append(timedOut, sectionRewards)
+	} else if rewardsErr != nil {
+		if rewards = s.rewardsFallback(ctx, req.Chain, aggregateIds, req.Range, rewardsErr); rewards == nil {
+			return models.ValidatorResponse{}, fmt.Errorf("fetch rewards: %w", rewardsErr)
+		}
+		fallback = append(fallback, sectionRewards)
+	}
+	if deadlineExceeded(ctx, performanceErr) {
+		timedOut = append(timedOut, sectionPerformance)
+	} else if performanceErr != nil {
+		if performance = s.performanceFallback(ctx, req.Chain, aggregateIds, req.Range, performanceErr); performance == nil {
+			return models.ValidatorResponse{}, fmt.Errorf("fetch performance: %w", performanceErr)
+		}
+		fallback = append(fallback, sectionPerformance)
+	}
+
+	if req.ExcludeAnomalies {
+		s.excludeInactivityLeaks(req.Chain, rewards, report)
 	}
 
 	// Build per-validator overview map
@@ -127,12 +275,64 @@ func (s *ValidatorService) fetchAndAggregate(ctx context.Context, chain string,
 			validatorOverviews[idStr] = s.buildOverview(v)
 		}
 	}
+	if fields.wants(sectionOverview) {
+		s.addQueueEstimates(ctx, req.Chain, validators, validatorOverviews)
+		s.addENSNames(ctx, req.Chain, validatorOverviews)
+	}
+	if fields.wantsField(sectionOverview, "mev") && ctx.Err() == nil {
+		s.addMEVRegistrations(ctx, req.Chain, validators, validatorOverviews)
+	}
+	if fields.wantsField(sectionOverview, "restaking") && ctx.Err() == nil {
+		s.addRestaking(ctx, req.Chain, validatorOverviews)
+	}
 
 	// Build response with per-validator overviews and single aggregated rewards/performance
 	response := models.ValidatorResponse{
 		Validators:  validatorOverviews,
 		Rewards:     s.buildRewards(rewards),
 		Performance: s.buildPerformance(performance),
+		Anomalies:   report,
+	}
+	// Derived sections need upstream calls of their own, which are not started once
+	// the deadline has passed
+	if ctx.Err() == nil {
+		if fields.wantsField(sectionRewards, "income") {
+			response.Rewards.Income = s.buildIncomeSplit(ctx, req.Chain, aggregateIds, req.Range, response.Rewards)
+		}
+		if fields.wants(sectionFiat) {
+			response.Fiat = s.buildFiat(ctx, req.Currency, response.Validators, response.Rewards)
+		}
+		if fields.wants(sectionBenchmark) {
+			response.Benchmark = s.buildBenchmark(ctx, req, response)
+		}
+		if performance != nil && fields.wantsField(sectionPerformance, "luck") {
+			response.Performance.Luck = s.buildLuck(ctx, req.Chain, aggregateIds, req.Range, response)
+		}
+	} else {
+		if rewards != nil && fields.wantsField(sectionRewards, "income") {
+			timedOut = append(timedOut, sectionIncome)
+		}
+		if req.Currency != "" && fields.wants(sectionFiat) {
+			timedOut = append(timedOut, sectionFiat)
+		}
+		if fields.wants(sectionBenchmark) {
+			timedOut = append(timedOut, sectionBenchmark)
+		}
+		if performance != nil && fields.wantsField(sectionPerformance, "luck") {
+			timedOut = append(timedOut, sectionLuck)
+		}
+	}
+	response.TimedOutSections = timedOut
+	response.FallbackSections = fallback
+
+	s.recordSnapshots(ctx, req.Chain, validatorOverviews)
+	if performance != nil {
+		s.recordAttestationSample(ctx, req, response.Performance.Attestations)
+	}
+	// Responses without some sections would be served as complete from cache, and
+	// fallback sections as if upstream had computed them
+	if len(timedOut) == 0 && len(fallback) == 0 && fields.all() {
+		s.cacheResponse(req, response)
 	}
 
 	return response, nil
@@ -170,6 +370,9 @@ func (s *ValidatorService) buildOverview(v models.BeaconchainValidatorData) mode
 		CurrentBalance:        currentBalance,
 		EffectiveBalance:      effectiveBalance,
 		Online:                online,
+		Finalized:             finalityFlag(v.Finality),
+
+		EffectiveBalanceHeadroom: buildHeadroom(v),
 	}
 }
 
@@ -218,6 +421,7 @@ func (s *ValidatorService) buildRewards(r *models.BeaconchainRewardsAggregateRes
 			Penalty:      r.Data.SyncCommittee.Penalty,
 			MissedReward: r.Data.SyncCommittee.MissedReward,
 		},
+		Finalized: finalityFlag(r.Data.Finality),
 	}
 }
 
@@ -252,5 +456,6 @@ func (s *ValidatorService) buildPerformance(p *models.BeaconchainPerformanceAggr
 			IncludedSlashings: p.Data.Duties.Proposal.IncludedSlashings,
 			Beaconscore:       p.Data.Beaconscore.Proposal,
 		},
+		Finalized: finalityFlag(p.Data.Finality),
 	}
 }
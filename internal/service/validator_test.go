@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestGetValidatorData(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet",
+		beaconchatest.Validator(1).Build(),
+		beaconchatest.Validator(2).Offline().Build(),
+	)
+	fake.SetRewards("mainnet", "7d", models.BeaconchainRewardsAggregateResponse{
+		Data: models.BeaconchainRewardsData{Total: "1500"},
+	})
+
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		name    string
+		ids     []int
+		fail    string
+		wantErr bool
+	}{
+		{name: "known validators", ids: []int{1, 2}},
+		{name: "unknown validators are left out", ids: []int{1, 3}},
+		{name: "upstream failure", ids: []int{1}, fail: beaconchatest.MethodGetPerformanceAggregate, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.fail != "" {
+				fake.FailNext(tt.fail, errors.New("upstream down"))
+			}
+
+			resp, err := s.GetValidatorData(context.Background(), models.ValidatorRequest{
+				ValidatorIds: tt.ids,
+				Chain:        "mainnet",
+				Range:        "7d",
+			})
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.Rewards.Total != "1500" {
+				t.Errorf("expected rewards total 1500, got %q", resp.Rewards.Total)
+			}
+			if _, ok := resp.Validators["1"]; !ok {
+				t.Errorf("expected validator 1 in response, got %v", resp.Validators)
+			}
+			if _, ok := resp.Validators["3"]; ok {
+				t.Error("expected unknown validator 3 to be left out")
+			}
+			if v, ok := resp.Validators["2"]; ok && v.Online {
+				t.Error("expected validator 2 to be offline")
+			}
+		})
+	}
+}
+
+func TestGetValidatorData_ConcurrentSections(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1)...)
+	fake.SetLatency(50 * time.Millisecond)
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	req := models.ValidatorRequest{ValidatorIds: []int{1}, Chain: "mainnet", Range: "24h", Fields: []string{"overview", "rewards", "performance"}}
+
+	// The validators, rewards and performance are fetched at once
+	start := time.Now()
+	if _, err := s.GetValidatorData(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= 100*time.Millisecond {
+		t.Errorf("expected the sections to be fetched concurrently, took %s", elapsed)
+	}
+}
+
+func TestGetBalanceHistory_Cached(t *testing.T) {
+	epoch, err := chainspec.LastCompletedEpoch("mainnet", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := beaconchatest.New()
+	fake.AddBalanceHistory("mainnet", 5,
+		models.BeaconchainBalanceHistoryEntry{Epoch: epoch, Balance: "32000000001000000000"},
+		models.BeaconchainBalanceHistoryEntry{Epoch: epoch - 1, Balance: "32000000000000000000"},
+		models.BeaconchainBalanceHistoryEntry{Epoch: epoch - 5, Balance: "31000000000000000000"},
+	)
+
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+
+	for i := 0; i < 3; i++ {
+		resp, err := s.GetBalanceHistory(context.Background(), "mainnet", 5, 2)
+		if err != nil {
+			t.Fatalf("GetBalanceHistory failed: %v", err)
+		}
+		if len(resp.Epochs) != 2 || resp.Epochs[1].Epoch != epoch {
+			t.Fatalf("unexpected epochs: %+v", resp.Epochs)
+		}
+	}
+
+	// Only the first request reaches upstream, unless an epoch completed in between
+	if calls := fake.Calls(beaconchatest.MethodGetBalanceHistory); calls > 2 {
+		t.Errorf("expected cached responses, got %d upstream calls", calls)
+	}
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// WarmCache fetches the data of every portfolio over each of the ranges, so the
+// first requests after a deploy are served from cache instead of queueing behind
+// upstream calls. Data already cached for the current epoch, e.g. restored by
+// LoadCache, is not fetched again. Each fetch takes its own turn in the request
+// queue and goes through the upstream rate limiter, so user requests are
+// interleaved with warming. It returns once done or when the context is canceled.
+func (s *ValidatorService) WarmCache(ctx context.Context, ranges []string) {
+	start := time.Now()
+	var warmed, failed int
+	for _, p := range s.portfolios.All() {
+		for _, evalRange := range ranges {
+			if ctx.Err() != nil {
+				return
+			}
+			if _, err := s.cachedValidatorData(ctx, portfolioRequest(p, evalRange)); err != nil {
+				slog.Warn("failed to warm cache", "portfolio", p.Name, "range", evalRange, "error", err)
+				failed++
+				continue
+			}
+			warmed++
+		}
+	}
+	if warmed+failed > 0 {
+		slog.Info("cache warmed", "requests", warmed, "failed", failed, "duration", time.Since(start))
+	}
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+func TestWarmCache(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2, 3)...)
+	portfolios, err := portfolio.NewRegistry([]portfolio.Portfolio{
+		{Name: "home", Chain: "mainnet", ValidatorIds: []int{1, 2}},
+		{Name: "office", Chain: "mainnet", ValidatorIds: []int{3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewValidatorService(fake, nil, nil, nil, portfolios, nil)
+	ctx := context.Background()
+
+	s.WarmCache(ctx, []string{"24h", "30d"})
+	if got := fake.Calls(beaconchatest.MethodGetValidators); got != 4 {
+		t.Errorf("expected 4 fetches, got %d", got)
+	}
+
+	// Warmed data is served from cache, and warming again fetches nothing
+	if _, err := s.GetPortfolioData(ctx, "office", "30d"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.WarmCache(ctx, []string{"24h", "30d"})
+	if got := fake.Calls(beaconchatest.MethodGetValidators); got != 4 {
+		t.Errorf("expected no further fetches, got %d", got-4)
+	}
+}
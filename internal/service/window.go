@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// Custom windows cover the UTC days from a start date up to an end date, as the
+// evaluation range "2026-01-01/2026-04-01". Upstream only aggregates fixed windows
+// ending now, so their rewards are summed from the daily rewards and their blocks
+// filtered from those of the smallest fixed window covering them.
+
+// windowDateLayout is the layout of the dates of a custom window.
+const windowDateLayout = "2006-01-02"
+
+// MaxWindowAge is how far back a custom window may start: the daily rewards
+// upstream cover the last 90 days.
+const MaxWindowAge = 90 * 24 * time.Hour
+
+// windowSections are the sections available for custom windows. Performance and
+// the benchmark are only aggregated upstream over the fixed windows, and the
+// previous period only compares those.
+var windowSections = []string{sectionOverview, sectionRewards, sectionAnomalies, sectionFiat, "groups"}
+
+// WindowRange returns the evaluation range of the custom window from from to to,
+// widened to whole UTC days as the daily rewards are. The widened window must
+// start within MaxWindowAge of now, and to must not be after now.
+func WindowRange(from, to, now time.Time) (string, error) {
+	switch {
+	case !from.Before(to):
+		return "", errors.New("from must be before to")
+	case to.After(now):
+		return "", errors.New("to must not be in the future")
+	}
+	start := from.UTC().Truncate(24 * time.Hour)
+	end := to.UTC().Truncate(24 * time.Hour)
+	if end.Before(to) {
+		end = end.AddDate(0, 0, 1)
+	}
+	if start.Before(now.Add(-MaxWindowAge)) {
+		return "", fmt.Errorf("from must be within the last %d days", int(MaxWindowAge.Hours()/24))
+	}
+	return start.Format(windowDateLayout) + "/" + end.Format(windowDateLayout), nil
+}
+
+// EpochWindowRange returns the evaluation range of the custom window of the epochs
+// from startEpoch to endEpoch inclusive on chain. The daily rewards cannot be split
+// within a day, so the epochs must be those starting in whole UTC days.
+func EpochWindowRange(chain string, startEpoch, endEpoch int64, now time.Time) (string, error) {
+	if startEpoch < 0 || endEpoch < startEpoch {
+		return "", errors.New("epochs must be non-negative and fromEpoch at most toEpoch")
+	}
+	spec, err := chainspec.ForChain(chain)
+	if err != nil {
+		return "", err
+	}
+	if spec.EpochStart(endEpoch + 1).After(now) {
+		return "", errors.New("toEpoch must be completed")
+	}
+
+	from := spec.EpochStart(startEpoch).UTC().Truncate(24 * time.Hour)
+	if first := firstEpochFrom(spec, from); first != startEpoch {
+		return "", fmt.Errorf("fromEpoch must be the first epoch of a UTC day, such as %d or %d",
+			first, firstEpochFrom(spec, from.AddDate(0, 0, 1)))
+	}
+	to := spec.EpochStart(endEpoch).UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if last := firstEpochFrom(spec, to) - 1; last != endEpoch {
+		return "", fmt.Errorf("toEpoch must be the last epoch of a UTC day, such as %d or %d",
+			firstEpochFrom(spec, to.AddDate(0, 0, -1))-1, last)
+	}
+	return WindowRange(from, to, now)
+}
+
+// LastDayEpoch returns the last epoch of the last completed UTC day on chain, the
+// default end of a custom window of epochs.
+func LastDayEpoch(chain string, now time.Time) (int64, error) {
+	spec, err := chainspec.ForChain(chain)
+	if err != nil {
+		return 0, err
+	}
+	return firstEpochFrom(spec, now.UTC().Truncate(24*time.Hour)) - 1, nil
+}
+
+// parseWindowRange returns the bounds of evalRange if it is a custom window.
+func parseWindowRange(evalRange string) (from, to time.Time, ok bool) {
+	start, end, found := strings.Cut(evalRange, "/")
+	if !found {
+		return time.Time{}, time.Time{}, false
+	}
+	from, err := time.Parse(windowDateLayout, start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	to, err = time.Parse(windowDateLayout, end)
+	if err != nil || !from.Before(to) {
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// WindowFields returns the field selection of a request over a custom window:
+// fields, or the sections available for custom windows when fields selects
+// everything. Selecting a section that is not available is an error.
+func WindowFields(fields []string) ([]string, error) {
+	if len(fields) == 0 {
+		return slices.Clone(windowSections), nil
+	}
+	for _, f := range fields {
+		section, _, _ := strings.Cut(f, ".")
+		if !slices.Contains(windowSections, section) {
+			return nil, fmt.Errorf("%s is not available for custom windows", section)
+		}
+	}
+	return fields, nil
+}
+
+// fetchWindowFor returns the smallest fixed window upstream that covers the UTC
+// days from from up to to as of now. Windows ending after the day of now are
+// refused, as upstream cannot cover them.
+func fetchWindowFor(from, to, now time.Time) (string, error) {
+	if to.After(now.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)) {
+		return "", fmt.Errorf("window ending %s is in the future", to.Format(windowDateLayout))
+	}
+	return windowForDays(int((now.Sub(from) + 24*time.Hour - 1) / (24 * time.Hour))), nil
+}
+
+// windowRewards returns the rewards of the validators over the UTC days from
+// from up to to as of now, or nil if upstream has none of them, cached per epoch
+// like the aggregates.
+func (s *ValidatorService) windowRewards(ctx context.Context, chain string, ids []int, evalRange string, from, to, now time.Time) (*models.BeaconchainRewardsAggregateResponse, error) {
+	fetchWindow, err := fetchWindowFor(from, to, now)
+	if err != nil {
+		return nil, err
+	}
+	return cachedAggregate(ctx, s, aggregateCacheKey(sectionRewards, chain, evalRange, ids), chain, func() (*models.BeaconchainRewardsAggregateResponse, error) {
+		entries, err := s.beaconchainClient.GetDailyRewards(ctx, chain, ids, fetchWindow)
+		if err != nil {
+			return nil, err
+		}
+		entries = windowDays(entries, from, to)
+		if len(entries) == 0 {
+			return nil, nil
+		}
+		return sumRewardsHistory(entries), nil
+	})
+}
+
+// windowDays returns the entries of the UTC days starting from from and before
+// to, in the order of entries.
+func windowDays(entries []models.BeaconchainRewardsHistoryEntry, from, to time.Time) []models.BeaconchainRewardsHistoryEntry {
+	var result []models.BeaconchainRewardsHistoryEntry
+	for _, e := range entries {
+		t := time.Unix(e.Range.Timestamp.Start, 0)
+		if !t.Before(from) && t.Before(to) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// windowBlocks returns the block proposal duties of the validators in the epochs
+// starting from from and before to, as of now.
+func (s *ValidatorService) windowBlocks(ctx context.Context, chain string, ids []int, from, to, now time.Time) ([]models.BeaconchainBlock, error) {
+	spec, err := chainspec.ForChain(chain)
+	if err != nil {
+		return nil, err
+	}
+	fetchWindow, err := fetchWindowFor(from, to, now)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := s.rangeBlocks(ctx, chain, ids, fetchWindow)
+	if err != nil {
+		return nil, err
+	}
+	var result []models.BeaconchainBlock
+	for _, b := range blocks {
+		if start := spec.EpochStart(b.Epoch); !start.Before(from) && start.Before(to) {
+			result = append(result, b)
+		}
+	}
+	return result, nil
+}
+
+// addWindow describes the custom window of req, if any, in response.
+func addWindow(req models.ValidatorRequest, response *models.ValidatorResponse) {
+	from, to, ok := parseWindowRange(req.Range)
+	if !ok {
+		return
+	}
+	window := &models.EvaluationWindow{From: from, To: to}
+	if spec, err := chainspec.ForChain(req.Chain); err == nil {
+		window.StartEpoch = firstEpochFrom(spec, from)
+		window.EndEpoch = firstEpochFrom(spec, to) - 1
+	}
+	response.Window = window
+}
+
+// firstEpochFrom returns the first epoch of spec starting at or after t.
+func firstEpochFrom(spec chainspec.Spec, t time.Time) int64 {
+	elapsed := t.Sub(spec.GenesisTime)
+	if elapsed <= 0 {
+		return 0
+	}
+	duration := spec.EpochDuration()
+	return int64((elapsed + duration - 1) / duration)
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+func TestWindowRange(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     string
+		wantErr  bool
+	}{
+		{name: "whole days", from: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), to: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), want: "2026-02-01/2026-03-01"},
+		{name: "widened to days", from: time.Date(2026, 2, 1, 6, 0, 0, 0, time.UTC), to: time.Date(2026, 2, 3, 6, 0, 0, 0, time.UTC), want: "2026-02-01/2026-02-04"},
+		{name: "to date", from: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), to: now, want: "2026-03-01/2026-03-16"},
+		{name: "other time zone", from: time.Date(2026, 2, 1, 23, 0, 0, 0, time.FixedZone("", -2*3600)), to: time.Date(2026, 2, 3, 0, 0, 0, 0, time.UTC), want: "2026-02-02/2026-02-03"},
+		{name: "reversed", from: now.AddDate(0, 0, -1), to: now.AddDate(0, 0, -2), wantErr: true},
+		{name: "future", from: now.AddDate(0, 0, -1), to: now.Add(time.Hour), wantErr: true},
+		{name: "too old", from: now.Add(-MaxWindowAge - time.Hour), to: now, wantErr: true},
+		// Widened to midnight, the window starts over 90 days ago
+		{name: "too old once widened", from: now.Add(-MaxWindowAge + time.Hour), to: now, wantErr: true},
+		{name: "oldest day", from: now.Add(-MaxWindowAge + 12*time.Hour), to: now, want: "2025-12-16/2026-03-16"},
+	}
+	for _, tt := range tests {
+		got, err := WindowRange(tt.from, tt.to, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+		if tt.wantErr {
+			continue
+		}
+		if _, _, ok := parseWindowRange(got); !ok {
+			t.Errorf("%s: %q does not parse as a window", tt.name, got)
+		}
+	}
+
+	for _, r := range []string{"24h", "all_time", "2026-02-01", "2026-03-01/2026-02-01", "2026-02-01/now"} {
+		if _, _, ok := parseWindowRange(r); ok {
+			t.Errorf("expected %q not to be a window", r)
+		}
+	}
+}
+
+func TestEpochWindowRange(t *testing.T) {
+	spec, _ := chainspec.ForChain("mainnet")
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	start := firstEpochFrom(spec, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	end := firstEpochFrom(spec, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) - 1
+
+	got, err := EpochWindowRange("mainnet", start, end, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "2026-03-01/2026-03-02" {
+		t.Errorf("expected the day of the epochs, got %q", got)
+	}
+	// Epochs within a day cannot be split out of the daily rewards
+	for _, epochs := range [][2]int64{{end, start}, {-1, start}, {start, firstEpochFrom(spec, now)}, {start + 1, end}, {start, end - 1}} {
+		if _, err := EpochWindowRange("mainnet", epochs[0], epochs[1], now); err == nil {
+			t.Errorf("epochs %v: expected an error", epochs)
+		}
+	}
+
+	last, err := LastDayEpoch("mainnet", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := firstEpochFrom(spec, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)) - 1; last != want {
+		t.Errorf("expected the last epoch of yesterday %d, got %d", want, last)
+	}
+	if _, err := EpochWindowRange("mainnet", start, last, now); err != nil {
+		t.Errorf("expected a window up to yesterday, got %v", err)
+	}
+}
+
+func TestFetchWindowFor(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     string
+		wantErr  bool
+	}{
+		{name: "today", from: day(15), to: day(16), want: "24h"},
+		{name: "last week", from: day(9), to: day(15), want: "7d"},
+		{name: "last month", from: day(1), to: day(10), want: "30d"},
+		{name: "future day", from: day(14), to: day(17), wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := fetchWindowFor(tt.from, tt.to, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestWindowFields(t *testing.T) {
+	fields, err := WindowFields(nil)
+	if err != nil || len(fields) != len(windowSections) {
+		t.Errorf("expected the window sections by default, got %v, %v", fields, err)
+	}
+	if fields, err := WindowFields([]string{"rewards.total", "overview"}); err != nil || len(fields) != 2 {
+		t.Errorf("expected the selection to be kept, got %v, %v", fields, err)
+	}
+	for _, f := range []string{"performance", "benchmark.fleet", "previous"} {
+		if _, err := WindowFields([]string{"rewards", f}); err == nil {
+			t.Errorf("%s: expected an error", f)
+		}
+	}
+}
+
+func TestGetValidatorData_Window(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2)...)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	fake.SetDailyRewards("mainnet",
+		dailyEntry(today.AddDate(0, 0, -4), "900"),
+		dailyEntry(today.AddDate(0, 0, -3), "700"),
+		dailyEntry(today.AddDate(0, 0, -2), "300"),
+		dailyEntry(today.AddDate(0, 0, -1), "1000"),
+	)
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	evalRange, err := WindowRange(today.AddDate(0, 0, -3), today.AddDate(0, 0, -1), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, _ := WindowFields(nil)
+	req := models.ValidatorRequest{ValidatorIds: []int{1, 2}, Chain: "mainnet", Range: evalRange, Fields: fields}
+	resp, err := s.GetValidatorData(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Rewards.Total != "1000" {
+		t.Errorf("expected the rewards of the 2 days of the window, got %q", resp.Rewards.Total)
+	}
+	if len(resp.Validators) != 2 {
+		t.Errorf("expected the overviews of 2 validators, got %d", len(resp.Validators))
+	}
+	if n := fake.Calls(beaconchatest.MethodGetPerformanceAggregate); n != 0 {
+		t.Errorf("expected no performance aggregate for a custom window, got %d calls", n)
+	}
+
+	w := resp.Window
+	if w == nil {
+		t.Fatal("expected the window to be described")
+	}
+	spec, _ := chainspec.ForChain("mainnet")
+	if !w.From.Equal(today.AddDate(0, 0, -3)) || !w.To.Equal(today.AddDate(0, 0, -1)) {
+		t.Errorf("expected the window of the 2 days, got %s to %s", w.From, w.To)
+	}
+	if spec.EpochStart(w.StartEpoch).Before(w.From) || !spec.EpochStart(w.EndEpoch).Before(w.To) || spec.EpochStart(w.EndEpoch+1).Before(w.To) {
+		t.Errorf("epochs %d to %d do not start in the window", w.StartEpoch, w.EndEpoch)
+	}
+
+	// Fixed ranges are not described
+	resp, err = s.GetValidatorData(ctx, models.ValidatorRequest{ValidatorIds: []int{1, 2}, Chain: "mainnet", Range: "24h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Window != nil {
+		t.Errorf("expected no window for a fixed range, got %+v", resp.Window)
+	}
+}
+
+func TestWindowBlocks(t *testing.T) {
+	spec, _ := chainspec.ForChain("mainnet")
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from, to := today.AddDate(0, 0, -2), today.AddDate(0, 0, -1)
+	first := firstEpochFrom(spec, from)
+	last := firstEpochFrom(spec, to) - 1
+
+	index := 1
+	fake := beaconchatest.New()
+	for i, epoch := range []int64{first - 1, first, last, last + 1} {
+		fake.AddBlocks("mainnet", models.BeaconchainBlock{Validator: models.BeaconchainValidatorInfo{Index: &index}, Epoch: epoch, Slot: int64(i)})
+	}
+	s := NewValidatorService(fake, nil, nil, nil, nil, nil)
+
+	blocks, err := s.windowBlocks(context.Background(), "mainnet", []int{1}, from, to, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 || blocks[0].Epoch != first || blocks[1].Epoch != last {
+		t.Errorf("expected the blocks of the first and last epochs of the window, got %+v", blocks)
+	}
+}
@@ -0,0 +1,99 @@
+// Package ssv reads the validators managed by SSV network operators from the SSV
+// API.
+package ssv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultURL is the base URL of the public SSV API.
+const DefaultURL = "https://api.ssv.network/api/v4"
+
+// Paging of the validators of an operator.
+const (
+	perPage  = 100
+	maxPages = 500 // 50000 validators
+)
+
+// Client reads operators from the SSV API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client of the SSV API at baseURL.
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), httpClient: &http.Client{Timeout: timeout}}
+}
+
+// validatorsPage is a page of the validators of an operator.
+type validatorsPage struct {
+	Validators []struct {
+		PublicKey    string `json:"public_key"`
+		IsDeleted    bool   `json:"is_deleted"`    // Removed from its cluster
+		IsLiquidated bool   `json:"is_liquidated"` // Its cluster ran out of balance
+	} `json:"validators"`
+	Pagination struct {
+		Pages int `json:"pages"`
+	} `json:"pagination"`
+}
+
+// OperatorValidators returns the public keys, 0x-prefixed lowercase hex, of the
+// validators operator manages on chain, mainnet or hoodi. Validators removed from
+// their cluster or of liquidated clusters are no longer run and left out.
+func (c *Client) OperatorValidators(ctx context.Context, chain string, operator int) ([]string, error) {
+	var keys []string
+	for page := 1; ; page++ {
+		var result validatorsPage
+		if err := c.get(ctx, fmt.Sprintf("/%s/validators/in_operator/%d?page=%d&perPage=%d", url.PathEscape(chain), operator, page, perPage), &result); err != nil {
+			return nil, err
+		}
+		for _, v := range result.Validators {
+			if v.IsDeleted || v.IsLiquidated {
+				continue
+			}
+			key := strings.ToLower(v.PublicKey)
+			if !strings.HasPrefix(key, "0x") {
+				key = "0x" + key
+			}
+			keys = append(keys, key)
+		}
+		if page >= result.Pagination.Pages || len(result.Validators) == 0 {
+			return keys, nil
+		}
+		if page == maxPages {
+			return nil, fmt.Errorf("operator %d manages more than %d validators", operator, maxPages*perPage)
+		}
+	}
+}
+
+// get decodes the JSON response to a GET of path into v.
+func (c *Client) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ssv api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("ssv api: status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode ssv api response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package ssv
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestClient_OperatorValidators(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/mainnet/validators/in_operator/404" {
+			http.Error(w, `{"error":"operator not found"}`, http.StatusNotFound)
+			return
+		}
+		if r.URL.Path != "/mainnet/validators/in_operator/7" || r.URL.Query().Get("perPage") != "100" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		// Two pages, keys with and without 0x, and validators no longer run
+		switch page, _ := strconv.Atoi(r.URL.Query().Get("page")); page {
+		case 1:
+			fmt.Fprint(w, `{"validators":[{"public_key":"AA11"},{"public_key":"0xbb22"}],"pagination":{"total":3,"page":1,"pages":2,"per_page":100}}`)
+		case 2:
+			fmt.Fprint(w, `{"validators":[{"public_key":"cc33"},{"public_key":"dd44","is_deleted":true},{"public_key":"ee55","is_liquidated":true}],"pagination":{"total":5,"page":2,"pages":2,"per_page":100}}`)
+		default:
+			t.Errorf("unexpected page %d", page)
+		}
+	}))
+	defer server.Close()
+	c := NewClient(server.URL+"/", time.Second)
+
+	keys, err := c.OperatorValidators(context.Background(), "mainnet", 7)
+	if err != nil {
+		t.Fatalf("OperatorValidators failed: %v", err)
+	}
+	if want := []string{"0xaa11", "0xbb22", "0xcc33"}; !slices.Equal(keys, want) {
+		t.Errorf("expected %v, got %v", want, keys)
+	}
+
+	if _, err := c.OperatorValidators(context.Background(), "mainnet", 404); err == nil {
+		t.Error("expected an error for an unknown operator")
+	}
+}
@@ -0,0 +1,204 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// historyKinds are the monthly record files a FileStore keeps.
+var historyKinds = []string{"snapshots", "events", "attestations"}
+
+// ObjectStorage is a remote blob store used as the cold tier for aged history.
+type ObjectStorage interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// prefixStorage stores the objects of an ObjectStorage under a key prefix.
+type prefixStorage struct {
+	ObjectStorage
+	prefix string
+}
+
+// WithPrefix returns cold storing its objects under prefix, so the stores of
+// several tenants can share a bucket.
+func WithPrefix(cold ObjectStorage, prefix string) ObjectStorage {
+	return prefixStorage{ObjectStorage: cold, prefix: strings.TrimSuffix(prefix, "/") + "/"}
+}
+
+func (p prefixStorage) Put(ctx context.Context, key string, data []byte) error {
+	return p.ObjectStorage.Put(ctx, p.prefix+key, data)
+}
+
+func (p prefixStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	return p.ObjectStorage.Get(ctx, p.prefix+key)
+}
+
+// SetColdStorage enables moving monthly files older than retentionMonths full
+// months, besides the current one, to cold. Queries covering moved months
+// download them transparently. It must be called before the store is used.
+func (s *FileStore) SetColdStorage(cold ObjectStorage, retentionMonths int) {
+	s.cold = cold
+	s.retentionMonths = retentionMonths
+}
+
+// RunArchiver archives immediately and then every interval until the context is
+// canceled. Without cold storage it returns immediately.
+func (s *FileStore) RunArchiver(ctx context.Context, interval time.Duration) {
+	if s.cold == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now().UTC()
+		cutoff := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -s.retentionMonths, 0)
+		if err := s.Archive(ctx, cutoff); err != nil {
+			slog.Error("history archive failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Archive moves the monthly files of months starting before cutoff to cold
+// storage as gzipped JSON lines under <kind>/<month>.jsonl.gz. A local file is
+// only removed once its upload succeeded.
+func (s *FileStore) Archive(ctx context.Context, cutoff time.Time) error {
+	if s.cold == nil {
+		return errors.New("no cold storage configured")
+	}
+
+	for _, kind := range historyKinds {
+		paths, err := filepath.Glob(filepath.Join(s.dir, kind, "*.jsonl"))
+		if err != nil {
+			return err
+		}
+
+		for _, path := range paths {
+			name := filepath.Base(path)[:len(monthLayout)]
+			month, err := time.Parse(monthLayout, name)
+			if err != nil || !month.Before(cutoff) {
+				continue
+			}
+
+			if err := s.archiveMonth(ctx, kind, name, path); err != nil {
+				return fmt.Errorf("archive %s/%s: %w", kind, name, err)
+			}
+			slog.Info("archived history", "kind", kind, "month", name)
+		}
+	}
+	return nil
+}
+
+// archiveMonth uploads a single monthly file and removes it locally.
+func (s *FileStore) archiveMonth(ctx context.Context, kind, month, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	if err := s.cold.Put(ctx, archiveKey(kind, month), buf.Bytes()); err != nil {
+		return fmt.Errorf("upload: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !slices.Contains(s.archived[kind], month) {
+		s.archived[kind] = append(s.archived[kind], month)
+	}
+	if err := s.writeArchived(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// readArchived decodes the records of a month from cold storage. Months that were
+// never archived have no records.
+func readArchived[T any](ctx context.Context, s *FileStore, kind, month string, fn func(T)) error {
+	s.mu.RLock()
+	archived := slices.Contains(s.archived[kind], month)
+	s.mu.RUnlock()
+	if !archived {
+		return nil
+	}
+	if s.cold == nil {
+		slog.Warn("skipping archived history without cold storage", "kind", kind, "month", month)
+		return nil
+	}
+
+	data, err := s.cold.Get(ctx, archiveKey(kind, month))
+	if err != nil {
+		return fmt.Errorf("download: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	return decodeLines(zr, fn)
+}
+
+func archiveKey(kind, month string) string {
+	return kind + "/" + month + ".jsonl.gz"
+}
+
+func (s *FileStore) archivedPath() string {
+	return filepath.Join(s.dir, "archived.json")
+}
+
+// loadArchived reads the list of archived months, if any.
+func (s *FileStore) loadArchived() error {
+	data, err := os.ReadFile(s.archivedPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read archived months: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.archived); err != nil {
+		return fmt.Errorf("decode archived months: %w", err)
+	}
+	return nil
+}
+
+// writeArchived atomically replaces archived.json. Callers must hold s.mu.
+func (s *FileStore) writeArchived() error {
+	data, err := json.Marshal(s.archived)
+	if err != nil {
+		return fmt.Errorf("encode archived months: %w", err)
+	}
+
+	tmp := s.archivedPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write archived months: %w", err)
+	}
+	return os.Rename(tmp, s.archivedPath())
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryStorage is an in-memory ObjectStorage.
+type memoryStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	failPut bool
+}
+
+func (m *memoryStorage) Put(ctx context.Context, key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failPut {
+		return errors.New("upload failed")
+	}
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memoryStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return data, nil
+}
+
+func TestFileStore_Archive(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cold := &memoryStorage{objects: make(map[string][]byte)}
+
+	st, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.SetColdStorage(cold, 1)
+
+	jan := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	for _, ts := range []time.Time{jan, mar} {
+		snap := Snapshot{Time: ts, Chain: "mainnet", ValidatorIndex: 1, Status: ts.Month().String()}
+		if err := st.RecordSnapshots(ctx, []Snapshot{snap}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A failed upload keeps the local file
+	cold.failPut = true
+	if err := st.Archive(ctx, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("expected upload error, got nil")
+	}
+	if _, err := os.Stat(st.monthPath("snapshots", jan)); err != nil {
+		t.Errorf("expected January to stay local: %v", err)
+	}
+
+	cold.failPut = false
+	if err := st.Archive(ctx, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	if _, err := os.Stat(st.monthPath("snapshots", jan)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected January to be removed locally, got %v", err)
+	}
+	if _, ok := cold.objects["snapshots/2026-01.jsonl.gz"]; !ok {
+		t.Errorf("expected January in cold storage, got %v", cold.objects)
+	}
+	if _, err := os.Stat(st.monthPath("snapshots", mar)); err != nil {
+		t.Errorf("expected March to stay local: %v", err)
+	}
+
+	// Reopen to make sure the archived months are remembered
+	st, err = NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.SetColdStorage(cold, 1)
+
+	all, err := st.Snapshots(ctx, Query{})
+	if err != nil {
+		t.Fatalf("Snapshots failed: %v", err)
+	}
+	if len(all) != 2 || all[0].Status != "January" || all[1].Status != "March" {
+		t.Errorf("expected archived and local snapshots, got %+v", all)
+	}
+
+	recent, err := st.Snapshots(ctx, Query{From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Snapshots failed: %v", err)
+	}
+	if len(recent) != 1 {
+		t.Errorf("expected only March, got %+v", recent)
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	ctx := context.Background()
+	shared := &memoryStorage{objects: make(map[string][]byte)}
+	a := WithPrefix(shared, "tenants/a")
+	b := WithPrefix(shared, "tenants/b/")
+
+	if err := a.Put(ctx, "snapshots/2026-01.jsonl.gz", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := shared.objects["tenants/a/snapshots/2026-01.jsonl.gz"]; !ok {
+		t.Errorf("objects = %v, want key under tenants/a/", shared.objects)
+	}
+	if data, err := a.Get(ctx, "snapshots/2026-01.jsonl.gz"); err != nil || string(data) != "a" {
+		t.Errorf("Get = %q, %v, want a", data, err)
+	}
+	if _, err := b.Get(ctx, "snapshots/2026-01.jsonl.gz"); err == nil {
+		t.Error("object of another prefix is visible")
+	}
+}
@@ -0,0 +1,411 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// monthLayout names the monthly files records are appended to.
+const monthLayout = "2006-01"
+
+// FileStore is a Store that appends snapshots and events to monthly JSON lines files:
+//
+//	<dir>/snapshots/2026-01.jsonl
+//	<dir>/events/2026-01.jsonl
+//	<dir>/attestations/2026-01.jsonl
+//	<dir>/latest.json
+//	<dir>/archived.json
+//
+// latest.json holds the most recent snapshot of each validator so events can be
+// derived without scanning the history on startup. archived.json lists the months
+// moved to cold storage, see SetColdStorage.
+type FileStore struct {
+	dir string
+
+	// Held for writing while records are written, and for reading while month
+	// files are read, so readers never see a partly appended line
+	mu     sync.RWMutex
+	latest map[string]Snapshot // keyed by chain/index
+
+	// Optional cold tier for months older than the local retention
+	cold            ObjectStorage
+	retentionMonths int
+	archived        map[string][]string // months moved to cold storage, keyed by kind
+}
+
+// NewFileStore opens (or creates) a file store in dir.
+func NewFileStore(dir string) (*FileStore, error) {
+	for _, sub := range historyKinds {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("create store directory: %w", err)
+		}
+	}
+
+	s := &FileStore{
+		dir:      dir,
+		latest:   make(map[string]Snapshot),
+		archived: make(map[string][]string),
+	}
+
+	data, err := os.ReadFile(s.latestPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read latest snapshots: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &s.latest); err != nil {
+			return nil, fmt.Errorf("decode latest snapshots: %w", err)
+		}
+	}
+
+	if err := s.loadArchived(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// RecordSnapshots implements Store. A finalized snapshot replaces the latest
+// snapshot of its validator in the history if that one was not finalized.
+func (s *FileStore) RecordSnapshots(ctx context.Context, snapshots []Snapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []Event
+	var superseded []Snapshot
+	for _, snap := range snapshots {
+		key := latestKey(snap.Chain, snap.ValidatorIndex)
+		if prev, ok := s.latest[key]; ok {
+			events = append(events, diff(prev, snap)...)
+			if prev.Unfinalized && !snap.Unfinalized {
+				superseded = append(superseded, prev)
+			}
+		}
+	}
+
+	if err := s.removeSnapshots(superseded); err != nil {
+		return fmt.Errorf("remove unfinalized snapshots: %w", err)
+	}
+
+	if err := appendRecords(s.monthPath("snapshots", snapshots[0].Time), snapshots); err != nil {
+		return fmt.Errorf("append snapshots: %w", err)
+	}
+	if len(events) > 0 {
+		if err := appendRecords(s.monthPath("events", events[0].Time), events); err != nil {
+			return fmt.Errorf("append events: %w", err)
+		}
+	}
+
+	for _, snap := range snapshots {
+		s.latest[latestKey(snap.Chain, snap.ValidatorIndex)] = snap
+	}
+
+	return s.writeLatest()
+}
+
+// BackfillSnapshots implements Store. The snapshots are appended to the files of
+// their months; those of months moved to cold storage are dropped.
+func (s *FileStore) BackfillSnapshots(ctx context.Context, snapshots []Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byMonth := make(map[string][]Snapshot)
+	for _, snap := range snapshots {
+		if slices.Contains(s.archived["snapshots"], snap.Time.UTC().Format(monthLayout)) {
+			continue
+		}
+		path := s.monthPath("snapshots", snap.Time)
+		byMonth[path] = append(byMonth[path], snap)
+	}
+	for path, snaps := range byMonth {
+		if err := appendRecords(path, snaps); err != nil {
+			return fmt.Errorf("append backfilled snapshots: %w", err)
+		}
+	}
+	return nil
+}
+
+// Snapshots implements Store.
+func (s *FileStore) Snapshots(ctx context.Context, q Query) ([]Snapshot, error) {
+	var result []Snapshot
+	err := readMonths(ctx, s, "snapshots", q, func(snap Snapshot) {
+		if q.matches(snap.Chain, snap.ValidatorIndex, snap.Time) {
+			result = append(result, snap)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Time.Before(result[j].Time) })
+	return result, nil
+}
+
+// Events implements Store.
+func (s *FileStore) Events(ctx context.Context, q Query) ([]Event, error) {
+	var result []Event
+	err := readMonths(ctx, s, "events", q, func(e Event) {
+		if q.matches(e.Chain, e.ValidatorIndex, e.Time) {
+			result = append(result, e)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Time.Before(result[j].Time) })
+	return result, nil
+}
+
+// RecordEvents implements Store.
+func (s *FileStore) RecordEvents(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := appendRecords(s.monthPath("events", events[0].Time), events); err != nil {
+		return fmt.Errorf("append events: %w", err)
+	}
+	return nil
+}
+
+// LastRefresh implements Store.
+func (s *FileStore) LastRefresh(ctx context.Context, chain string, indices []int) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var oldest time.Time
+	for _, index := range indices {
+		snap, ok := s.latest[latestKey(chain, index)]
+		if !ok {
+			return time.Time{}, nil
+		}
+		if oldest.IsZero() || snap.Time.Before(oldest) {
+			oldest = snap.Time
+		}
+	}
+	return oldest, nil
+}
+
+// RecordAttestationSample implements Store.
+func (s *FileStore) RecordAttestationSample(ctx context.Context, sample AttestationSample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := appendRecords(s.monthPath("attestations", sample.Time), []AttestationSample{sample}); err != nil {
+		return fmt.Errorf("append attestation sample: %w", err)
+	}
+	return nil
+}
+
+// AttestationSamples implements Store.
+func (s *FileStore) AttestationSamples(ctx context.Context, q Query) ([]AttestationSample, error) {
+	var result []AttestationSample
+	err := readMonths(ctx, s, "attestations", q, func(a AttestationSample) {
+		if q.matchesSet(a.Chain, a.ValidatorIndices, a.Time) {
+			result = append(result, a)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Time.Before(result[j].Time) })
+	return result, nil
+}
+
+// Close implements Store. Writes are synchronous, so there is nothing to flush.
+func (s *FileStore) Close() error {
+	return nil
+}
+
+// Freeze runs fn while no records are written, so the files of the store can be
+// opened in a consistent state, e.g. for a backup.
+func (s *FileStore) Freeze(fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn()
+}
+
+// removeSnapshots rewrites the monthly files holding the given snapshots without
+// them. Months moved to cold storage are left as they are. Callers must hold s.mu.
+func (s *FileStore) removeSnapshots(snapshots []Snapshot) error {
+	remove := make(map[string]map[string]bool) // snapshot keys by month file
+	for _, snap := range snapshots {
+		path := s.monthPath("snapshots", snap.Time)
+		if remove[path] == nil {
+			remove[path] = make(map[string]bool)
+		}
+		remove[path][snapshotKey(snap)] = true
+	}
+
+	for path, keys := range remove {
+		var kept []Snapshot
+		err := readFile(path, func(snap Snapshot) {
+			if !keys[snapshotKey(snap)] {
+				kept = append(kept, snap)
+			}
+		})
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		tmp := path + ".tmp"
+		if err := os.Remove(tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err := appendRecords(tmp, kept); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) latestPath() string {
+	return filepath.Join(s.dir, "latest.json")
+}
+
+func (s *FileStore) monthPath(kind string, t time.Time) string {
+	return filepath.Join(s.dir, kind, t.UTC().Format(monthLayout)+".jsonl")
+}
+
+// writeLatest atomically replaces latest.json. Callers must hold s.mu.
+func (s *FileStore) writeLatest() error {
+	data, err := json.Marshal(s.latest)
+	if err != nil {
+		return fmt.Errorf("encode latest snapshots: %w", err)
+	}
+
+	tmp := s.latestPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write latest snapshots: %w", err)
+	}
+	return os.Rename(tmp, s.latestPath())
+}
+
+func latestKey(chain string, index int) string {
+	return chain + "/" + strconv.Itoa(index)
+}
+
+// snapshotKey identifies a snapshot within the history.
+func snapshotKey(snap Snapshot) string {
+	return latestKey(snap.Chain, snap.ValidatorIndex) + "/" + strconv.FormatInt(snap.Time.UnixNano(), 10)
+}
+
+// appendRecords appends each record as a JSON line to the file at path.
+func appendRecords[T any](path string, records []T) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readMonths decodes every record in the monthly files of kind that may contain
+// records within the query's time range, calling fn for each of them. Months that
+// were moved to cold storage are downloaded.
+func readMonths[T any](ctx context.Context, s *FileStore, kind string, q Query, fn func(T)) error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, kind, "*.jsonl"))
+	if err != nil {
+		return err
+	}
+
+	months := make(map[string]bool)
+	for _, path := range paths {
+		months[filepath.Base(path)[:len(monthLayout)]] = true
+	}
+	s.mu.RLock()
+	for _, month := range s.archived[kind] {
+		months[month] = true
+	}
+	s.mu.RUnlock()
+
+	sorted := make([]string, 0, len(months))
+	for month := range months {
+		sorted = append(sorted, month)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		month, err := time.Parse(monthLayout, name)
+		if err != nil {
+			continue
+		}
+		if !q.To.IsZero() && !month.Before(q.To) {
+			continue
+		}
+		if !q.From.IsZero() && !month.AddDate(0, 1, 0).After(q.From) {
+			continue
+		}
+
+		s.mu.RLock()
+		err = readFile(s.monthPath(kind, month), fn)
+		s.mu.RUnlock()
+		if errors.Is(err, os.ErrNotExist) {
+			// Archived since it was listed, or only in cold storage
+			err = readArchived(ctx, s, kind, name, fn)
+		}
+		if err != nil {
+			return fmt.Errorf("read %s/%s: %w", kind, name, err)
+		}
+	}
+
+	return nil
+}
+
+func readFile[T any](path string, fn func(T)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return decodeLines(f, fn)
+}
+
+// decodeLines decodes every JSON line in r, calling fn for each record.
+func decodeLines[T any](r io.Reader, fn func(T)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record T
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return err
+		}
+		fn(record)
+	}
+	return scanner.Err()
+}
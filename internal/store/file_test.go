@@ -0,0 +1,300 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileStore_RecordSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	st, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+
+	t1 := time.Date(2026, 1, 31, 23, 0, 0, 0, time.UTC)
+	t2 := time.Date(2026, 2, 1, 1, 0, 0, 0, time.UTC)
+
+	first := []Snapshot{
+		{Time: t1, Chain: "mainnet", ValidatorIndex: 1, Status: "active_online", Online: true},
+		{Time: t1, Chain: "mainnet", ValidatorIndex: 2, Status: "active_online", Online: true},
+	}
+	if err := st.RecordSnapshots(ctx, first); err != nil {
+		t.Fatalf("RecordSnapshots failed: %v", err)
+	}
+
+	// Reopen to make sure events are derived from the persisted latest snapshots
+	st, err = NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+
+	second := []Snapshot{
+		{Time: t2, Chain: "mainnet", ValidatorIndex: 1, Status: "active_offline", Online: false},
+		{Time: t2, Chain: "mainnet", ValidatorIndex: 2, Status: "active_online", Online: true, Slashed: true},
+	}
+	if err := st.RecordSnapshots(ctx, second); err != nil {
+		t.Fatalf("RecordSnapshots failed: %v", err)
+	}
+
+	all, err := st.Snapshots(ctx, Query{})
+	if err != nil {
+		t.Fatalf("Snapshots failed: %v", err)
+	}
+	if len(all) != 4 {
+		t.Errorf("expected 4 snapshots, got %d", len(all))
+	}
+
+	feb, err := st.Snapshots(ctx, Query{From: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Snapshots failed: %v", err)
+	}
+	if len(feb) != 2 {
+		t.Errorf("expected 2 snapshots in February, got %d", len(feb))
+	}
+
+	one, err := st.Snapshots(ctx, Query{Chain: "mainnet", ValidatorIndices: []int{1}})
+	if err != nil {
+		t.Fatalf("Snapshots failed: %v", err)
+	}
+	if len(one) != 2 || one[0].ValidatorIndex != 1 || !one[0].Time.Equal(t1) {
+		t.Errorf("unexpected snapshots for validator 1: %+v", one)
+	}
+
+	events, err := st.Events(ctx, Query{})
+	if err != nil {
+		t.Fatalf("Events failed: %v", err)
+	}
+
+	want := []struct {
+		index int
+		typ   EventType
+		from  string
+		to    string
+	}{
+		{1, EventStatusChanged, "active_online", "active_offline"},
+		{1, EventOnlineChanged, "online", "offline"},
+		{2, EventSlashed, "false", "true"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d: %+v", len(want), len(events), events)
+	}
+	for i, w := range want {
+		e := events[i]
+		if e.ValidatorIndex != w.index || e.Type != w.typ || e.From != w.from || e.To != w.to {
+			t.Errorf("event %d: expected %+v, got %+v", i, w, e)
+		}
+	}
+}
+
+func TestFileStore_ConcurrentReadAppend(t *testing.T) {
+	st, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	ctx := context.Background()
+
+	// Batches span several buffered writes, so unlocked readers would see partial lines
+	const batches, perBatch = 30, 100
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for b := 0; b < batches; b++ {
+			snaps := make([]Snapshot, perBatch)
+			for i := range snaps {
+				snaps[i] = Snapshot{Time: start.Add(time.Duration(b) * time.Minute), Chain: "mainnet", ValidatorIndex: i, Status: "active_online", Online: true, CurrentBalance: "32000000000000000000"}
+			}
+			if err := st.RecordSnapshots(ctx, snaps); err != nil {
+				t.Errorf("RecordSnapshots failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for {
+		select {
+		case <-done:
+			all, err := st.Snapshots(ctx, Query{})
+			if err != nil || len(all) != batches*perBatch {
+				t.Errorf("expected %d snapshots, got %d, %v", batches*perBatch, len(all), err)
+			}
+			return
+		default:
+		}
+		all, err := st.Snapshots(ctx, Query{})
+		if err != nil {
+			t.Fatalf("Snapshots during appends failed: %v", err)
+		}
+		if len(all)%perBatch != 0 {
+			t.Fatalf("expected whole batches, got %d snapshots", len(all))
+		}
+	}
+}
+
+func TestFileStore_RecordSnapshots_Unfinalized(t *testing.T) {
+	ctx := context.Background()
+
+	st, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+
+	t1 := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	records := [][]Snapshot{
+		{{Time: t1, Chain: "mainnet", ValidatorIndex: 1, Status: "active_online"}},
+		{{Time: t1.Add(time.Minute), Chain: "mainnet", ValidatorIndex: 1, Status: "active_online", Unfinalized: true}},
+		{{Time: t1.Add(2 * time.Minute), Chain: "mainnet", ValidatorIndex: 2, Status: "active_online", Unfinalized: true}},
+		{{Time: t1.Add(20 * time.Minute), Chain: "mainnet", ValidatorIndex: 1, Status: "active_online"}},
+	}
+	for _, r := range records {
+		if err := st.RecordSnapshots(ctx, r); err != nil {
+			t.Fatalf("RecordSnapshots failed: %v", err)
+		}
+	}
+
+	// The unfinalized snapshot of validator 1 was replaced, the one of validator 2 is kept
+	all, err := st.Snapshots(ctx, Query{})
+	if err != nil {
+		t.Fatalf("Snapshots failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 snapshots, got %d: %+v", len(all), all)
+	}
+	for _, snap := range all {
+		if snap.ValidatorIndex == 1 && snap.Unfinalized {
+			t.Errorf("expected the unfinalized snapshot of validator 1 to be replaced, got %+v", snap)
+		}
+	}
+	if !all[1].Unfinalized || all[1].ValidatorIndex != 2 {
+		t.Errorf("expected the unfinalized snapshot of validator 2, got %+v", all[1])
+	}
+}
+
+func TestFileStore_AttestationSamples(t *testing.T) {
+	ctx := context.Background()
+
+	st, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+
+	t1 := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	samples := []AttestationSample{
+		{Time: t1, Chain: "mainnet", ValidatorIndices: []int{1, 2}, Range: "24h", Included: 450, Assigned: 450},
+		{Time: t1.Add(time.Hour), Chain: "mainnet", ValidatorIndices: []int{1}, Range: "24h", Included: 225, Assigned: 225},
+		{Time: t1.Add(2 * time.Hour), Chain: "mainnet", ValidatorIndices: []int{1, 2}, Range: "24h", Included: 440, Assigned: 450},
+	}
+	for _, sample := range samples {
+		if err := st.RecordAttestationSample(ctx, sample); err != nil {
+			t.Fatalf("RecordAttestationSample failed: %v", err)
+		}
+	}
+
+	// The set must match exactly, regardless of the order of the requested IDs
+	got, err := st.AttestationSamples(ctx, Query{Chain: "mainnet", ValidatorIndices: []int{2, 1}})
+	if err != nil {
+		t.Fatalf("AttestationSamples failed: %v", err)
+	}
+	if len(got) != 2 || got[0].Included != 450 || got[1].Included != 440 {
+		t.Errorf("unexpected samples: %+v", got)
+	}
+
+	all, err := st.AttestationSamples(ctx, Query{})
+	if err != nil {
+		t.Fatalf("AttestationSamples failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("expected 3 samples, got %d", len(all))
+	}
+}
+
+func TestFileStore_LastRefresh(t *testing.T) {
+	ctx := context.Background()
+	st, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+
+	t1 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	if err := st.RecordSnapshots(ctx, []Snapshot{{Time: t1, Chain: "mainnet", ValidatorIndex: 1}, {Time: t1, Chain: "mainnet", ValidatorIndex: 2}}); err != nil {
+		t.Fatalf("RecordSnapshots failed: %v", err)
+	}
+	if err := st.RecordSnapshots(ctx, []Snapshot{{Time: t2, Chain: "mainnet", ValidatorIndex: 1}}); err != nil {
+		t.Fatalf("RecordSnapshots failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		chain   string
+		indices []int
+		want    time.Time
+	}{
+		{name: "single", chain: "mainnet", indices: []int{1}, want: t2},
+		{name: "least recent wins", chain: "mainnet", indices: []int{1, 2}, want: t1},
+		{name: "never recorded", chain: "mainnet", indices: []int{1, 3}},
+		{name: "other chain", chain: "hoodi", indices: []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := st.LastRefresh(ctx, tt.chain, tt.indices)
+			if err != nil {
+				t.Fatalf("LastRefresh failed: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFileStore_BackfillSnapshots(t *testing.T) {
+	ctx := context.Background()
+	st, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+
+	now := time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC)
+	if err := st.RecordSnapshots(ctx, []Snapshot{{Time: now, Chain: "mainnet", ValidatorIndex: 1, Status: "active_offline"}}); err != nil {
+		t.Fatalf("RecordSnapshots failed: %v", err)
+	}
+	backfilled := []Snapshot{
+		{Time: time.Date(2026, 1, 30, 0, 0, 0, 0, time.UTC), Chain: "mainnet", ValidatorIndex: 1, Status: "active_online", Online: true, Backfilled: true},
+		{Time: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), Chain: "mainnet", ValidatorIndex: 1, Status: "active_online", Online: true, Backfilled: true},
+	}
+	if err := st.BackfillSnapshots(ctx, backfilled); err != nil {
+		t.Fatalf("BackfillSnapshots failed: %v", err)
+	}
+
+	all, err := st.Snapshots(ctx, Query{})
+	if err != nil {
+		t.Fatalf("Snapshots failed: %v", err)
+	}
+	if len(all) != 3 || !all[0].Backfilled || !all[1].Backfilled || all[2].Backfilled {
+		t.Fatalf("expected the backfilled snapshots before the fetched one, got %+v", all)
+	}
+	if events, _ := st.Events(ctx, Query{}); len(events) != 0 {
+		t.Errorf("expected no events from backfilled snapshots, got %+v", events)
+	}
+
+	// The next snapshot is compared with the latest fetched one
+	if err := st.RecordSnapshots(ctx, []Snapshot{{Time: now.Add(time.Hour), Chain: "mainnet", ValidatorIndex: 1, Status: "active_offline"}}); err != nil {
+		t.Fatalf("RecordSnapshots failed: %v", err)
+	}
+	if events, _ := st.Events(ctx, Query{}); len(events) != 0 {
+		t.Errorf("expected no events without a change, got %+v", events)
+	}
+}
@@ -0,0 +1,262 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Migrator brings the schema of a store up to date. Migrations are versioned,
+// applied in the order of their versions, and each only once.
+type Migrator interface {
+	// Pending returns the versions of the migrations not applied yet, in order.
+	Pending(ctx context.Context) ([]string, error)
+	// Migrate applies the pending migrations and returns their versions.
+	Migrate(ctx context.Context) ([]string, error)
+}
+
+// postgresMigrations are the schema migrations of the PostgreSQL store, named
+// <version>.sql.
+//
+//go:embed migrations/*.sql
+var postgresMigrations embed.FS
+
+// migrationLock is the advisory lock key instances take while migrating.
+const migrationLock = 7301448232
+
+// PostgresMigrator applies the embedded migrations to a PostgreSQL database,
+// recording the applied versions in its schema_migrations table.
+type PostgresMigrator struct {
+	db         *sql.DB
+	migrations fs.FS
+}
+
+// NewPostgresMigrator creates a migrator of db.
+func NewPostgresMigrator(db *sql.DB) *PostgresMigrator {
+	return &PostgresMigrator{db: db, migrations: postgresMigrations}
+}
+
+// versions returns the versions of the migrations, in order.
+func (m *PostgresMigrator) versions() ([]string, error) {
+	names, err := fs.Glob(m.migrations, "migrations/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("list migrations: %w", err)
+	}
+	versions := make([]string, len(names))
+	for i, name := range names {
+		versions[i] = strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// Pending implements Migrator.
+func (m *PostgresMigrator) Pending(ctx context.Context) ([]string, error) {
+	versions, err := m.versions()
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	if err := m.db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("query migrations: %w", err)
+	}
+	if !exists {
+		return versions, nil
+	}
+	rows, err := m.db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("query migrations: %w", err)
+	}
+	applied, err := scanRows(rows, func(rows *sql.Rows) (string, error) {
+		var version string
+		return version, rows.Scan(&version)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query migrations: %w", err)
+	}
+	return pending(versions, applied), nil
+}
+
+// Migrate implements Migrator. Each migration runs in its own transaction, and
+// instances migrating at the same time take turns through an advisory lock.
+func (m *PostgresMigrator) Migrate(ctx context.Context) ([]string, error) {
+	versions, err := m.versions()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []string
+	for _, version := range versions {
+		ok, err := m.apply(ctx, version)
+		if err != nil {
+			return applied, fmt.Errorf("migrate %s: %w", version, err)
+		}
+		if ok {
+			applied = append(applied, version)
+		}
+	}
+	return applied, nil
+}
+
+// apply applies the migration of version unless it was applied, and reports
+// whether it did.
+func (m *PostgresMigrator) apply(ctx context.Context, version string) (bool, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLock); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    text        PRIMARY KEY,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`); err != nil {
+		return false, err
+	}
+	var applied bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, version).Scan(&applied); err != nil {
+		return false, err
+	}
+	if applied {
+		return false, nil
+	}
+
+	script, err := fs.ReadFile(m.migrations, "migrations/"+version+".sql")
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// fileMigration changes the layout of a file store directory. It must also
+// succeed on an empty directory, as new stores start with every migration
+// pending.
+type fileMigration struct {
+	version string
+	apply   func(dir string) error
+}
+
+// fileMigrations are the migrations of file stores, in order of their versions.
+// The layout has not changed yet.
+var fileMigrations []fileMigration
+
+// FileMigrator applies the migrations of the file store in a directory,
+// recording the applied versions in migrations.json.
+type FileMigrator struct {
+	dir        string
+	migrations []fileMigration
+}
+
+// NewFileMigrator creates a migrator of the file store in dir.
+func NewFileMigrator(dir string) *FileMigrator {
+	return &FileMigrator{dir: dir, migrations: fileMigrations}
+}
+
+// Pending implements Migrator.
+func (m *FileMigrator) Pending(ctx context.Context) ([]string, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	return pending(m.versions(), applied), nil
+}
+
+// Migrate implements Migrator. The store must not be open meanwhile.
+func (m *FileMigrator) Migrate(ctx context.Context) ([]string, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var migrated []string
+	for _, migration := range m.migrations {
+		if slices.Contains(applied, migration.version) {
+			continue
+		}
+		if err := os.MkdirAll(m.dir, 0o755); err != nil {
+			return migrated, fmt.Errorf("create store directory: %w", err)
+		}
+		if err := migration.apply(m.dir); err != nil {
+			return migrated, fmt.Errorf("migrate %s: %w", migration.version, err)
+		}
+		applied = append(applied, migration.version)
+		if err := m.writeApplied(applied); err != nil {
+			return migrated, err
+		}
+		migrated = append(migrated, migration.version)
+	}
+	return migrated, nil
+}
+
+// versions returns the versions of the migrations.
+func (m *FileMigrator) versions() []string {
+	versions := make([]string, len(m.migrations))
+	for i, migration := range m.migrations {
+		versions[i] = migration.version
+	}
+	return versions
+}
+
+func (m *FileMigrator) path() string {
+	return filepath.Join(m.dir, "migrations.json")
+}
+
+// applied returns the versions recorded in migrations.json.
+func (m *FileMigrator) applied() ([]string, error) {
+	data, err := os.ReadFile(m.path())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+	var applied []string
+	if err := json.Unmarshal(data, &applied); err != nil {
+		return nil, fmt.Errorf("decode migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// writeApplied atomically replaces migrations.json.
+func (m *FileMigrator) writeApplied(applied []string) error {
+	data, err := json.Marshal(applied)
+	if err != nil {
+		return fmt.Errorf("encode migrations: %w", err)
+	}
+
+	tmp := m.path() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write migrations: %w", err)
+	}
+	return os.Rename(tmp, m.path())
+}
+
+// pending returns the versions not in applied.
+func pending(versions, applied []string) []string {
+	var result []string
+	for _, version := range versions {
+		if !slices.Contains(applied, version) {
+			result = append(result, version)
+		}
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFileMigrator(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "data")
+	var ran []string
+	migration := func(version string, err error) fileMigration {
+		return fileMigration{version: version, apply: func(string) error {
+			ran = append(ran, version)
+			return err
+		}}
+	}
+	m := NewFileMigrator(dir)
+	m.migrations = []fileMigration{migration("0001_a", nil), migration("0002_b", nil)}
+
+	pending, err := m.Pending(ctx)
+	if err != nil || !slices.Equal(pending, []string{"0001_a", "0002_b"}) {
+		t.Fatalf("expected both migrations pending in a new store, got %v (%v)", pending, err)
+	}
+	applied, err := m.Migrate(ctx)
+	if err != nil || !slices.Equal(applied, []string{"0001_a", "0002_b"}) {
+		t.Fatalf("expected both migrations applied, got %v (%v)", applied, err)
+	}
+
+	// A later version adds a migration, which fails the first time
+	failing := errors.New("disk full")
+	m = NewFileMigrator(dir)
+	m.migrations = []fileMigration{migration("0001_a", nil), migration("0002_b", nil), migration("0003_c", failing)}
+	if pending, _ := m.Pending(ctx); !slices.Equal(pending, []string{"0003_c"}) {
+		t.Errorf("expected only the new migration pending, got %v", pending)
+	}
+	if _, err := m.Migrate(ctx); !errors.Is(err, failing) {
+		t.Fatalf("expected the migration error, got %v", err)
+	}
+	m.migrations[2] = migration("0003_c", nil)
+	if applied, err := m.Migrate(ctx); err != nil || !slices.Equal(applied, []string{"0003_c"}) {
+		t.Errorf("expected the failed migration applied on retry, got %v (%v)", applied, err)
+	}
+	if !slices.Equal(ran, []string{"0001_a", "0002_b", "0003_c", "0003_c"}) {
+		t.Errorf("expected every migration to run once until it succeeds, ran %v", ran)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "migrations.json")); err != nil {
+		t.Errorf("expected the applied migrations recorded: %v", err)
+	}
+}
+
+func TestPostgresMigrator(t *testing.T) {
+	versions, err := NewPostgresMigrator(nil).versions()
+	if err != nil || len(versions) == 0 || versions[0] != "0001_history" || versions[len(versions)-1] != "0002_backfilled" {
+		t.Fatalf("expected the embedded migrations, got %v (%v)", versions, err)
+	}
+
+	st := testPostgresStore(t) // Migrated
+	m := NewPostgresMigrator(st.db)
+	if pending, err := m.Pending(context.Background()); err != nil || len(pending) != 0 {
+		t.Errorf("expected no pending migrations, got %v (%v)", pending, err)
+	}
+	if applied, err := m.Migrate(context.Background()); err != nil || len(applied) != 0 {
+		t.Errorf("expected nothing to migrate again, got %v (%v)", applied, err)
+	}
+}
@@ -0,0 +1,65 @@
+-- Snapshot history, events and attestation samples. Every table is scoped by
+-- tenant, empty without tenants.
+
+CREATE TABLE snapshots (
+    tenant            text        NOT NULL,
+    chain             text        NOT NULL,
+    validator_index   integer     NOT NULL,
+    time              timestamptz NOT NULL,
+    status            text        NOT NULL,
+    online            boolean     NOT NULL,
+    slashed           boolean     NOT NULL,
+    current_balance   text        NOT NULL, -- in wei
+    effective_balance text        NOT NULL, -- in wei
+    activation_epoch  bigint      NOT NULL,
+    exit_epoch        bigint      NOT NULL,
+    unfinalized       boolean     NOT NULL,
+    PRIMARY KEY (tenant, chain, validator_index, time)
+);
+
+CREATE INDEX snapshots_time ON snapshots (tenant, chain, time);
+
+-- The most recent snapshot of each validator, which new snapshots are compared
+-- with to derive events
+CREATE TABLE latest_snapshots (
+    tenant            text        NOT NULL,
+    chain             text        NOT NULL,
+    validator_index   integer     NOT NULL,
+    time              timestamptz NOT NULL,
+    status            text        NOT NULL,
+    online            boolean     NOT NULL,
+    slashed           boolean     NOT NULL,
+    current_balance   text        NOT NULL,
+    effective_balance text        NOT NULL,
+    activation_epoch  bigint      NOT NULL,
+    exit_epoch        bigint      NOT NULL,
+    unfinalized       boolean     NOT NULL,
+    PRIMARY KEY (tenant, chain, validator_index)
+);
+
+CREATE TABLE events (
+    id              bigserial   PRIMARY KEY,
+    tenant          text        NOT NULL,
+    chain           text        NOT NULL,
+    validator_index integer     NOT NULL,
+    time            timestamptz NOT NULL,
+    type            text        NOT NULL,
+    from_value      text        NOT NULL,
+    to_value        text        NOT NULL
+);
+
+CREATE INDEX events_time ON events (tenant, chain, time);
+
+CREATE TABLE attestation_samples (
+    id                  bigserial        PRIMARY KEY,
+    tenant              text             NOT NULL,
+    chain               text             NOT NULL,
+    time                timestamptz      NOT NULL,
+    validator_indices   integer[]        NOT NULL, -- sorted ascending
+    eval_range          text             NOT NULL,
+    included            integer          NOT NULL,
+    assigned            integer          NOT NULL,
+    avg_inclusion_delay double precision NOT NULL
+);
+
+CREATE INDEX attestation_samples_time ON attestation_samples (tenant, chain, time);
@@ -0,0 +1,4 @@
+-- Snapshots reconstructed from the upstream balance history
+
+ALTER TABLE snapshots ADD COLUMN backfilled boolean NOT NULL DEFAULT false;
+ALTER TABLE latest_snapshots ADD COLUMN backfilled boolean NOT NULL DEFAULT false;
@@ -0,0 +1,381 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq" // Registers the postgres driver
+)
+
+// OpenPostgres connects to the PostgreSQL database at url. Its schema is brought
+// up to date by a PostgresMigrator.
+func OpenPostgres(ctx context.Context, url string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	return db, nil
+}
+
+// PostgresStore is a Store keeping the history in PostgreSQL, so several
+// instances can share it. Each tenant's records are kept apart in the same
+// tables.
+type PostgresStore struct {
+	db     *sql.DB
+	tenant string
+}
+
+// NewPostgresStore creates a store of tenant's history in db, which must be
+// migrated, see PostgresMigrator. tenant is empty without tenants.
+func NewPostgresStore(db *sql.DB, tenant string) *PostgresStore {
+	return &PostgresStore{db: db, tenant: tenant}
+}
+
+// snapshotColumns are the columns of the snapshots and latest_snapshots tables,
+// in the order of scanSnapshot.
+const snapshotColumns = `chain, validator_index, time, status, online, slashed, current_balance, effective_balance, activation_epoch, exit_epoch, unfinalized, backfilled`
+
+// RecordSnapshots implements Store. Instances recording snapshots of the same
+// tenant take turns, so each snapshot is compared with the one recorded before.
+func (s *PostgresStore) RecordSnapshots(ctx context.Context, snapshots []Snapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('snapshots/' || $1))`, s.tenant); err != nil {
+		return fmt.Errorf("lock snapshots: %w", err)
+	}
+	latest, err := s.latest(ctx, tx, snapshots)
+	if err != nil {
+		return err
+	}
+
+	var events []Event
+	for _, snap := range snapshots {
+		prev, ok := latest[latestKey(snap.Chain, snap.ValidatorIndex)]
+		if !ok {
+			continue
+		}
+		events = append(events, diff(prev, snap)...)
+		if prev.Unfinalized && !snap.Unfinalized {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM snapshots WHERE tenant = $1 AND chain = $2 AND validator_index = $3 AND time = $4`,
+				s.tenant, prev.Chain, prev.ValidatorIndex, prev.Time); err != nil {
+				return fmt.Errorf("remove unfinalized snapshot: %w", err)
+			}
+		}
+	}
+
+	for _, table := range []string{"snapshots", "latest_snapshots"} {
+		conflict := `(tenant, chain, validator_index, time)`
+		if table == "latest_snapshots" {
+			conflict = `(tenant, chain, validator_index)`
+		}
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO `+table+` (tenant, `+snapshotColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			ON CONFLICT `+conflict+` DO UPDATE SET
+				time = EXCLUDED.time, status = EXCLUDED.status, online = EXCLUDED.online, slashed = EXCLUDED.slashed,
+				current_balance = EXCLUDED.current_balance, effective_balance = EXCLUDED.effective_balance,
+				activation_epoch = EXCLUDED.activation_epoch, exit_epoch = EXCLUDED.exit_epoch, unfinalized = EXCLUDED.unfinalized, backfilled = EXCLUDED.backfilled`)
+		if err != nil {
+			return fmt.Errorf("prepare %s: %w", table, err)
+		}
+		for _, snap := range snapshots {
+			if _, err := stmt.ExecContext(ctx, s.tenant, snap.Chain, snap.ValidatorIndex, snap.Time, snap.Status, snap.Online, snap.Slashed,
+				snap.CurrentBalance, snap.EffectiveBalance, snap.ActivationEpoch, snap.ExitEpoch, snap.Unfinalized, snap.Backfilled); err != nil {
+				stmt.Close()
+				return fmt.Errorf("insert %s: %w", table, err)
+			}
+		}
+		stmt.Close()
+	}
+
+	if err := insertEvents(ctx, tx, s.tenant, events); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit snapshots: %w", err)
+	}
+	return nil
+}
+
+// latest returns the latest snapshots of the validators of snapshots, keyed like
+// latestKey.
+func (s *PostgresStore) latest(ctx context.Context, tx *sql.Tx, snapshots []Snapshot) (map[string]Snapshot, error) {
+	indices := make(map[string][]int64) // By chain
+	for _, snap := range snapshots {
+		indices[snap.Chain] = append(indices[snap.Chain], int64(snap.ValidatorIndex))
+	}
+
+	latest := make(map[string]Snapshot)
+	for chain, ids := range indices {
+		rows, err := tx.QueryContext(ctx, `SELECT `+snapshotColumns+` FROM latest_snapshots
+			WHERE tenant = $1 AND chain = $2 AND validator_index = ANY($3)`, s.tenant, chain, pq.Array(ids))
+		if err != nil {
+			return nil, fmt.Errorf("query latest snapshots: %w", err)
+		}
+		snaps, err := scanRows(rows, scanSnapshot)
+		if err != nil {
+			return nil, fmt.Errorf("query latest snapshots: %w", err)
+		}
+		for _, snap := range snaps {
+			latest[latestKey(snap.Chain, snap.ValidatorIndex)] = snap
+		}
+	}
+	return latest, nil
+}
+
+// BackfillSnapshots implements Store. Snapshots at the time of one already
+// recorded are dropped.
+func (s *PostgresStore) BackfillSnapshots(ctx context.Context, snapshots []Snapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO snapshots (tenant, `+snapshotColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT DO NOTHING`)
+	if err != nil {
+		return fmt.Errorf("prepare snapshots: %w", err)
+	}
+	defer stmt.Close()
+	for _, snap := range snapshots {
+		if _, err := stmt.ExecContext(ctx, s.tenant, snap.Chain, snap.ValidatorIndex, snap.Time, snap.Status, snap.Online, snap.Slashed,
+			snap.CurrentBalance, snap.EffectiveBalance, snap.ActivationEpoch, snap.ExitEpoch, snap.Unfinalized, snap.Backfilled); err != nil {
+			return fmt.Errorf("insert backfilled snapshot: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit backfilled snapshots: %w", err)
+	}
+	return nil
+}
+
+// Snapshots implements Store.
+func (s *PostgresStore) Snapshots(ctx context.Context, q Query) ([]Snapshot, error) {
+	where, args := s.where(q)
+	rows, err := s.db.QueryContext(ctx, `SELECT `+snapshotColumns+` FROM snapshots WHERE `+where+` ORDER BY time`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query snapshots: %w", err)
+	}
+	snaps, err := scanRows(rows, scanSnapshot)
+	if err != nil {
+		return nil, fmt.Errorf("query snapshots: %w", err)
+	}
+	return snaps, nil
+}
+
+// Events implements Store.
+func (s *PostgresStore) Events(ctx context.Context, q Query) ([]Event, error) {
+	where, args := s.where(q)
+	rows, err := s.db.QueryContext(ctx, `SELECT chain, validator_index, time, type, from_value, to_value FROM events
+		WHERE `+where+` ORDER BY time, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+	events, err := scanRows(rows, func(rows *sql.Rows) (Event, error) {
+		var e Event
+		err := rows.Scan(&e.Chain, &e.ValidatorIndex, &e.Time, &e.Type, &e.From, &e.To)
+		e.Time = e.Time.UTC()
+		return e, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+	return events, nil
+}
+
+// RecordEvents implements Store.
+func (s *PostgresStore) RecordEvents(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertEvents(ctx, tx, s.tenant, events); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit events: %w", err)
+	}
+	return nil
+}
+
+// insertEvents inserts the events of tenant.
+func insertEvents(ctx context.Context, tx *sql.Tx, tenant string, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO events (tenant, chain, validator_index, time, type, from_value, to_value)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`)
+	if err != nil {
+		return fmt.Errorf("prepare events: %w", err)
+	}
+	defer stmt.Close()
+	for _, e := range events {
+		if _, err := stmt.ExecContext(ctx, tenant, e.Chain, e.ValidatorIndex, e.Time, string(e.Type), e.From, e.To); err != nil {
+			return fmt.Errorf("insert event: %w", err)
+		}
+	}
+	return nil
+}
+
+// LastRefresh implements Store.
+func (s *PostgresStore) LastRefresh(ctx context.Context, chain string, indices []int) (time.Time, error) {
+	distinct := make(map[int64]bool)
+	for _, i := range indices {
+		distinct[int64(i)] = true
+	}
+	if len(distinct) == 0 {
+		return time.Time{}, nil
+	}
+	ids := make([]int64, 0, len(distinct))
+	for i := range distinct {
+		ids = append(ids, i)
+	}
+
+	var count int
+	var oldest sql.NullTime
+	err := s.db.QueryRowContext(ctx, `SELECT count(*), min(time) FROM latest_snapshots
+		WHERE tenant = $1 AND chain = $2 AND validator_index = ANY($3)`, s.tenant, chain, pq.Array(ids)).Scan(&count, &oldest)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("query last refresh: %w", err)
+	}
+	if count < len(ids) || !oldest.Valid {
+		return time.Time{}, nil
+	}
+	return oldest.Time.UTC(), nil
+}
+
+// RecordAttestationSample implements Store.
+func (s *PostgresStore) RecordAttestationSample(ctx context.Context, sample AttestationSample) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO attestation_samples
+		(tenant, chain, time, validator_indices, eval_range, included, assigned, avg_inclusion_delay)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		s.tenant, sample.Chain, sample.Time, pq.Array(int64s(sample.ValidatorIndices)), sample.Range,
+		sample.Included, sample.Assigned, sample.AvgInclusionDelay)
+	if err != nil {
+		return fmt.Errorf("insert attestation sample: %w", err)
+	}
+	return nil
+}
+
+// AttestationSamples implements Store.
+func (s *PostgresStore) AttestationSamples(ctx context.Context, q Query) ([]AttestationSample, error) {
+	set := q.ValidatorIndices
+	q.ValidatorIndices = nil
+	where, args := s.where(q)
+	if len(set) > 0 {
+		args = append(args, pq.Array(int64s(SortedIndices(set))))
+		where += ` AND validator_indices = $` + strconv.Itoa(len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT chain, time, validator_indices, eval_range, included, assigned, avg_inclusion_delay
+		FROM attestation_samples WHERE `+where+` ORDER BY time, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query attestation samples: %w", err)
+	}
+	samples, err := scanRows(rows, func(rows *sql.Rows) (AttestationSample, error) {
+		var a AttestationSample
+		var indices pq.Int64Array
+		err := rows.Scan(&a.Chain, &a.Time, &indices, &a.Range, &a.Included, &a.Assigned, &a.AvgInclusionDelay)
+		a.Time = a.Time.UTC()
+		a.ValidatorIndices = make([]int, len(indices))
+		for i, index := range indices {
+			a.ValidatorIndices[i] = int(index)
+		}
+		return a, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query attestation samples: %w", err)
+	}
+	return samples, nil
+}
+
+// Close implements Store. The database is shared between tenants and closed by
+// its owner, so there is nothing to release.
+func (s *PostgresStore) Close() error {
+	return nil
+}
+
+// where returns the condition selecting the records of the store's tenant that
+// match q, from a table with chain, validator_index and time columns, and its
+// arguments.
+func (s *PostgresStore) where(q Query) (string, []any) {
+	conditions := []string{`tenant = $1`}
+	args := []any{s.tenant}
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, condition+` $`+strconv.Itoa(len(args)))
+	}
+	if q.Chain != "" {
+		add(`chain =`, q.Chain)
+	}
+	if len(q.ValidatorIndices) > 0 {
+		args = append(args, pq.Array(int64s(q.ValidatorIndices)))
+		conditions = append(conditions, `validator_index = ANY($`+strconv.Itoa(len(args))+`)`)
+	}
+	if !q.From.IsZero() {
+		add(`time >=`, q.From)
+	}
+	if !q.To.IsZero() {
+		add(`time <`, q.To)
+	}
+	return strings.Join(conditions, ` AND `), args
+}
+
+// scanSnapshot scans a row of snapshotColumns.
+func scanSnapshot(rows *sql.Rows) (Snapshot, error) {
+	var snap Snapshot
+	err := rows.Scan(&snap.Chain, &snap.ValidatorIndex, &snap.Time, &snap.Status, &snap.Online, &snap.Slashed,
+		&snap.CurrentBalance, &snap.EffectiveBalance, &snap.ActivationEpoch, &snap.ExitEpoch, &snap.Unfinalized, &snap.Backfilled)
+	snap.Time = snap.Time.UTC()
+	return snap, err
+}
+
+// scanRows scans every row with scan and closes rows.
+func scanRows[T any](rows *sql.Rows, scan func(*sql.Rows) (T, error)) ([]T, error) {
+	defer rows.Close()
+	var result []T
+	for rows.Next() {
+		record, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, record)
+	}
+	return result, rows.Err()
+}
+
+// int64s converts indices for pq.Array.
+func int64s(indices []int) []int64 {
+	result := make([]int64, len(indices))
+	for i, index := range indices {
+		result[i] = int64(index)
+	}
+	return result
+}
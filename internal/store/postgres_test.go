@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPostgresStore_Where(t *testing.T) {
+	s := NewPostgresStore(nil, "acme")
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		q     Query
+		where string
+		args  int
+	}{
+		{name: "tenant only", where: "tenant = $1", args: 1},
+		{name: "chain", q: Query{Chain: "mainnet"}, where: "tenant = $1 AND chain = $2", args: 2},
+		{
+			name:  "all",
+			q:     Query{Chain: "hoodi", ValidatorIndices: []int{3, 1}, From: from, To: from.AddDate(0, 1, 0)},
+			where: "tenant = $1 AND chain = $2 AND validator_index = ANY($3) AND time >= $4 AND time < $5",
+			args:  5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := s.where(tt.q)
+			if where != tt.where || len(args) != tt.args {
+				t.Errorf("expected %q with %d args, got %q with %d", tt.where, tt.args, where, len(args))
+			}
+			if args[0] != "acme" {
+				t.Errorf("expected the tenant as first argument, got %v", args[0])
+			}
+		})
+	}
+}
+
+// testPostgresStore returns a store of a tenant of its own in the database at
+// TEST_DATABASE_URL, skipping the test without one.
+func testPostgresStore(t *testing.T) *PostgresStore {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := OpenPostgres(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := NewPostgresMigrator(db).Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return NewPostgresStore(db, fmt.Sprintf("test-%d", time.Now().UnixNano()))
+}
+
+func TestPostgresStore_RecordSnapshots(t *testing.T) {
+	st := testPostgresStore(t)
+	ctx := context.Background()
+	t1 := time.Date(2026, 1, 31, 23, 0, 0, 0, time.UTC)
+	t2 := t1.Add(2 * time.Hour)
+
+	if err := st.RecordSnapshots(ctx, []Snapshot{
+		{Time: t1, Chain: "mainnet", ValidatorIndex: 1, Status: "active_online", Online: true, CurrentBalance: "32000000000000000000"},
+		{Time: t1, Chain: "mainnet", ValidatorIndex: 2, Status: "active_online", Online: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.RecordSnapshots(ctx, []Snapshot{
+		{Time: t2, Chain: "mainnet", ValidatorIndex: 1, Status: "active_offline", CurrentBalance: "32000000000000000000"},
+		{Time: t2, Chain: "mainnet", ValidatorIndex: 2, Status: "active_online", Online: true, Slashed: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := st.Snapshots(ctx, Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 || !all[0].Time.Equal(t1) || all[0].CurrentBalance != "32000000000000000000" {
+		t.Fatalf("expected 4 snapshots from %s, got %+v", t1, all)
+	}
+	one, err := st.Snapshots(ctx, Query{Chain: "mainnet", ValidatorIndices: []int{1}, From: t2})
+	if err != nil || len(one) != 1 || one[0].Status != "active_offline" {
+		t.Errorf("expected the latest snapshot of validator 1, got %+v (%v)", one, err)
+	}
+
+	events, err := st.Events(ctx, Query{Chain: "mainnet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := make(map[EventType]int)
+	for _, e := range events {
+		types[e.Type]++
+	}
+	if types[EventOnlineChanged] != 1 || types[EventSlashed] != 1 {
+		t.Errorf("expected an online change and a slashed event, got %+v", events)
+	}
+
+	backfilled := Snapshot{Time: t1.AddDate(0, 0, -1), Chain: "mainnet", ValidatorIndex: 1, Status: "active_online", Online: true, Backfilled: true}
+	if err := st.BackfillSnapshots(ctx, []Snapshot{backfilled}); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := st.Snapshots(ctx, Query{ValidatorIndices: []int{1}}); len(all) != 3 || !all[0].Backfilled {
+		t.Errorf("expected the backfilled snapshot first, got %+v", all)
+	}
+
+	last, err := st.LastRefresh(ctx, "mainnet", []int{1, 2})
+	if err != nil || !last.Equal(t2) {
+		t.Errorf("expected last refresh %s, got %s (%v)", t2, last, err)
+	}
+	if last, _ := st.LastRefresh(ctx, "mainnet", []int{1, 3}); !last.IsZero() {
+		t.Errorf("expected no last refresh with an unknown validator, got %s", last)
+	}
+}
+
+func TestPostgresStore_AttestationSamples(t *testing.T) {
+	st := testPostgresStore(t)
+	ctx := context.Background()
+	now := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, sample := range []AttestationSample{
+		{Time: now, Chain: "mainnet", ValidatorIndices: []int{1, 2}, Range: "24h", Included: 9, Assigned: 10, AvgInclusionDelay: 1.1},
+		{Time: now, Chain: "mainnet", ValidatorIndices: []int{3}, Range: "24h", Included: 5, Assigned: 5, AvgInclusionDelay: 1},
+	} {
+		if err := st.RecordAttestationSample(ctx, sample); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	samples, err := st.AttestationSamples(ctx, Query{Chain: "mainnet", ValidatorIndices: []int{2, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1 || samples[0].Included != 9 || len(samples[0].ValidatorIndices) != 2 {
+		t.Errorf("expected the sample of validators 1 and 2, got %+v", samples)
+	}
+}
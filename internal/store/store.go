@@ -0,0 +1,182 @@
+// Package store persists historical validator snapshots and the events derived from them.
+package store
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Snapshot is the state of a single validator at the time it was fetched.
+type Snapshot struct {
+	Time             time.Time `json:"time"`
+	Chain            string    `json:"chain"`
+	ValidatorIndex   int       `json:"validatorIndex"`
+	Status           string    `json:"status"`
+	Online           bool      `json:"online"`
+	Slashed          bool      `json:"slashed"`
+	CurrentBalance   string    `json:"currentBalance"`   // in wei
+	EffectiveBalance string    `json:"effectiveBalance"` // in wei
+	ActivationEpoch  int64     `json:"activationEpoch"`
+	ExitEpoch        int64     `json:"exitEpoch"`
+	// Unfinalized marks a snapshot of state that was not finalized yet. It is
+	// replaced by the next finalized snapshot of the validator.
+	Unfinalized bool `json:"unfinalized,omitempty"`
+	// Backfilled marks a snapshot reconstructed from the upstream balance history
+	// rather than fetched. Its status and online flag are assumed from the
+	// activation and exit epochs.
+	Backfilled bool `json:"backfilled,omitempty"`
+}
+
+// EventType identifies what changed between two snapshots of a validator.
+type EventType string
+
+const (
+	// EventStatusChanged is recorded when the validator status changes.
+	EventStatusChanged EventType = "status_changed"
+	// EventOnlineChanged is recorded when the validator goes online or offline.
+	EventOnlineChanged EventType = "online_changed"
+	// EventSlashed is recorded when the validator is first seen slashed.
+	EventSlashed EventType = "slashed"
+	// EventRegistryKeyAdded is recorded when a validator joins an operator registry.
+	// To holds the registry address.
+	EventRegistryKeyAdded EventType = "registry_key_added"
+	// EventRegistryKeyRemoved is recorded when a validator leaves an operator registry.
+	// From holds the registry address.
+	EventRegistryKeyRemoved EventType = "registry_key_removed"
+)
+
+// Event is a change in a validator's state, detected between two snapshots or
+// reported by an operator registry.
+type Event struct {
+	Time           time.Time `json:"time"`
+	Chain          string    `json:"chain"`
+	ValidatorIndex int       `json:"validatorIndex"`
+	Type           EventType `json:"type"`
+	From           string    `json:"from"`
+	To             string    `json:"to"`
+}
+
+// AttestationSample is the combined attestation performance of a set of validators
+// over an evaluation range, as observed on one refresh.
+type AttestationSample struct {
+	Time              time.Time `json:"time"`
+	Chain             string    `json:"chain"`
+	ValidatorIndices  []int     `json:"validatorIndices"` // sorted ascending
+	Range             string    `json:"range"`
+	Included          int       `json:"included"`
+	Assigned          int       `json:"assigned"`
+	AvgInclusionDelay float64   `json:"avgInclusionDelay"`
+}
+
+// Query selects snapshots or events. Zero values match everything.
+type Query struct {
+	Chain            string
+	ValidatorIndices []int
+	From             time.Time // inclusive
+	To               time.Time // exclusive
+}
+
+// Store persists snapshots and events.
+type Store interface {
+	// RecordSnapshots stores the snapshots and the events derived from comparing
+	// them with the previous snapshot of each validator.
+	RecordSnapshots(ctx context.Context, snapshots []Snapshot) error
+	// BackfillSnapshots stores snapshots of the past without deriving events or
+	// replacing the latest snapshot of their validators.
+	BackfillSnapshots(ctx context.Context, snapshots []Snapshot) error
+	// Snapshots returns the snapshots matching the query ordered by time.
+	Snapshots(ctx context.Context, q Query) ([]Snapshot, error)
+	// Events returns the events matching the query ordered by time.
+	Events(ctx context.Context, q Query) ([]Event, error)
+	// RecordEvents stores events that are not derived from snapshots.
+	RecordEvents(ctx context.Context, events []Event) error
+	// LastRefresh returns the time the least recently refreshed of the validators
+	// was last recorded, or the zero time if any of them has never been recorded.
+	LastRefresh(ctx context.Context, chain string, indices []int) (time.Time, error)
+	// RecordAttestationSample stores an attestation performance sample.
+	RecordAttestationSample(ctx context.Context, sample AttestationSample) error
+	// AttestationSamples returns the samples matching the query ordered by time.
+	// A sample matches ValidatorIndices only if it covers exactly that set of validators.
+	AttestationSamples(ctx context.Context, q Query) ([]AttestationSample, error)
+	// Close flushes pending writes and releases resources.
+	Close() error
+}
+
+// diff returns the events describing the changes from prev to next.
+func diff(prev, next Snapshot) []Event {
+	var events []Event
+
+	newEvent := func(t EventType, from, to string) Event {
+		return Event{
+			Time:           next.Time,
+			Chain:          next.Chain,
+			ValidatorIndex: next.ValidatorIndex,
+			Type:           t,
+			From:           from,
+			To:             to,
+		}
+	}
+
+	if prev.Status != next.Status {
+		events = append(events, newEvent(EventStatusChanged, prev.Status, next.Status))
+	}
+	if prev.Online != next.Online {
+		events = append(events, newEvent(EventOnlineChanged, onlineLabel(prev.Online), onlineLabel(next.Online)))
+	}
+	if !prev.Slashed && next.Slashed {
+		events = append(events, newEvent(EventSlashed, "false", "true"))
+	}
+
+	return events
+}
+
+func onlineLabel(online bool) string {
+	if online {
+		return "online"
+	}
+	return "offline"
+}
+
+// matches reports whether a record for the given chain, validator and time matches the query.
+func (q Query) matches(chain string, index int, t time.Time) bool {
+	if q.Chain != "" && q.Chain != chain {
+		return false
+	}
+	if !q.From.IsZero() && t.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !t.Before(q.To) {
+		return false
+	}
+	if len(q.ValidatorIndices) == 0 {
+		return true
+	}
+	for _, i := range q.ValidatorIndices {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesSet reports whether a record covering exactly the given sorted validator
+// set matches the query.
+func (q Query) matchesSet(chain string, indices []int, t time.Time) bool {
+	unscoped := q
+	unscoped.ValidatorIndices = nil
+	if !unscoped.matches(chain, 0, t) {
+		return false
+	}
+	if len(q.ValidatorIndices) == 0 {
+		return true
+	}
+	return slices.Equal(SortedIndices(q.ValidatorIndices), indices)
+}
+
+// SortedIndices returns a sorted copy of indices, as stored in attestation samples.
+func SortedIndices(indices []int) []int {
+	sorted := slices.Clone(indices)
+	slices.Sort(sorted)
+	return sorted
+}
@@ -0,0 +1,201 @@
+// Package tenant lets one deployment serve several independent operators. Each
+// tenant authenticates with its own API key and is served by its own handler, so
+// its portfolios, labels, alerts and history are kept apart from other tenants.
+package tenant
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// versionPrefix matches the API version prefix of a path, e.g. /v1/.
+var versionPrefix = regexp.MustCompile(`^/v[0-9]+/`)
+
+// Tenant configures an operator served by the deployment. Only the SHA-256 hash
+// of its API key is configured, so the tenants file does not hold secrets.
+type Tenant struct {
+	Name                string `json:"name"`         // Also the name of its data directory
+	APIKeySHA256        string `json:"apiKeySha256"` // Hex-encoded SHA-256 of the API key
+	PortfoliosFile      string `json:"portfoliosFile,omitempty"`
+	AlertRulesFile      string `json:"alertRulesFile,omitempty"`
+	ReportSchedulesFile string `json:"reportSchedulesFile,omitempty"`
+	HeartbeatURL        string `json:"heartbeatUrl,omitempty"`     // Pinged while the tenant's fleet is healthy
+	HeartbeatFailURL    string `json:"heartbeatFailUrl,omitempty"` // Pinged while it is not
+}
+
+// Validate checks that the tenants have well-formed, unique names and key hashes.
+func Validate(tenants []Tenant) error {
+	names := make(map[string]bool)
+	keys := make(map[string]bool)
+	for _, t := range tenants {
+		if !namePattern.MatchString(t.Name) {
+			return fmt.Errorf("tenant name %q must be 1-63 lowercase letters, digits or '-'", t.Name)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("duplicate tenant %q", t.Name)
+		}
+		names[t.Name] = true
+
+		hash := strings.ToLower(t.APIKeySHA256)
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("tenant %q: apiKeySha256 must be a hex-encoded SHA-256 hash", t.Name)
+		}
+		if keys[hash] {
+			return fmt.Errorf("tenant %q: API key is already used by another tenant", t.Name)
+		}
+		keys[hash] = true
+
+		for _, u := range []string{t.HeartbeatURL, t.HeartbeatFailURL} {
+			if u == "" {
+				continue
+			}
+			if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return fmt.Errorf("tenant %q: heartbeat URL must be an http or https URL, got %q", t.Name, u)
+			}
+		}
+		if t.HeartbeatFailURL != "" && t.HeartbeatURL == "" {
+			return fmt.Errorf("tenant %q: heartbeatFailUrl requires heartbeatUrl", t.Name)
+		}
+	}
+	return nil
+}
+
+// LoadFile reads tenants from a JSON file containing an array of tenants. An
+// empty path returns no tenants, which serves a single operator without API keys.
+func LoadFile(path string) ([]Tenant, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read tenants: %w", err)
+	}
+
+	var tenants []Tenant
+	if err := json.Unmarshal(data, &tenants); err != nil {
+		return nil, fmt.Errorf("decode tenants: %w", err)
+	}
+	if err := Validate(tenants); err != nil {
+		return nil, err
+	}
+	return tenants, nil
+}
+
+// HashKey returns the hex-encoded SHA-256 hash of an API key, as configured in
+// apiKeySha256.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the name of the tenant a request is
+// served for.
+func NewContext(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, contextKey{}, name)
+}
+
+// FromContext returns the name of the tenant a request is served for, or an
+// empty string without tenants.
+func FromContext(ctx context.Context) string {
+	name, _ := ctx.Value(contextKey{}).(string)
+	return name
+}
+
+// route is the handler of a tenant.
+type route struct {
+	tenant  string
+	handler http.Handler
+}
+
+// Router dispatches requests to the handler of the tenant whose API key they
+// carry, either as "Authorization: Bearer <key>" or in the X-API-Key header.
+// The health check, the dashboard UI and CORS preflights need no key and are
+// served by the public handler. Requests of a tenant carry its name in their
+// context, see FromContext.
+type Router struct {
+	public http.Handler
+	routes map[[sha256.Size]byte]route
+}
+
+// NewRouter creates a router serving unauthenticated requests with public.
+func NewRouter(public http.Handler) *Router {
+	return &Router{public: public, routes: make(map[[sha256.Size]byte]route)}
+}
+
+// Add serves the requests of tenant t with handler. The tenant must have passed
+// Validate.
+func (rt *Router) Add(t Tenant, handler http.Handler) {
+	var hash [sha256.Size]byte
+	hex.Decode(hash[:], []byte(strings.ToLower(t.APIKeySHA256)))
+	rt.routes[hash] = route{tenant: t.Name, handler: handler}
+}
+
+// ServeHTTP implements http.Handler.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isPublic(r) {
+		rt.public.ServeHTTP(w, r)
+		return
+	}
+
+	key := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); key == "" && auth != "" {
+		if scheme, token, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			key = strings.TrimSpace(token)
+		}
+	}
+	if key == "" {
+		unauthorized(w, "An API key is required")
+		return
+	}
+
+	route, ok := rt.routes[sha256.Sum256([]byte(key))]
+	if !ok {
+		unauthorized(w, "Unknown API key")
+		return
+	}
+	route.handler.ServeHTTP(w, r.WithContext(NewContext(r.Context(), route.tenant)))
+}
+
+// isPublic reports whether r may be served without an API key, under any API
+// version.
+func isPublic(r *http.Request) bool {
+	if r.Method == http.MethodOptions {
+		return true
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	p := r.URL.Path
+	if loc := versionPrefix.FindStringIndex(p); loc != nil {
+		p = p[loc[1]-1:]
+	}
+	return p == "/health" || p == "/" || p == "/types.ts" || strings.HasPrefix(p, "/assets/")
+}
+
+// unauthorized writes a 401 error in the format of the API errors.
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(models.APIError{
+		Error:   "unauthorized",
+		Message: message,
+		Code:    http.StatusUnauthorized,
+	})
+}
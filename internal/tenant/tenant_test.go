@@ -0,0 +1,114 @@
+package tenant
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	keyA, keyB := HashKey("key-a"), HashKey("key-b")
+
+	tests := []struct {
+		name    string
+		tenants []Tenant
+		wantErr string
+	}{
+		{"valid", []Tenant{{Name: "acme", APIKeySHA256: keyA}, {Name: "solo-staker", APIKeySHA256: strings.ToUpper(keyB)}}, ""},
+		{"bad name", []Tenant{{Name: "../acme", APIKeySHA256: keyA}}, "tenant name"},
+		{"empty name", []Tenant{{APIKeySHA256: keyA}}, "tenant name"},
+		{"duplicate name", []Tenant{{Name: "acme", APIKeySHA256: keyA}, {Name: "acme", APIKeySHA256: keyB}}, "duplicate tenant"},
+		{"plain key", []Tenant{{Name: "acme", APIKeySHA256: "key-a"}}, "apiKeySha256"},
+		{"shared key", []Tenant{{Name: "acme", APIKeySHA256: keyA}, {Name: "other", APIKeySHA256: strings.ToUpper(keyA)}}, "already used"},
+		{"heartbeat", []Tenant{{Name: "acme", APIKeySHA256: keyA, HeartbeatURL: "https://hc-ping.com/uuid", HeartbeatFailURL: "https://hc-ping.com/uuid/fail"}}, ""},
+		{"bad heartbeat URL", []Tenant{{Name: "acme", APIKeySHA256: keyA, HeartbeatURL: "hc-ping.com/uuid"}}, "heartbeat URL"},
+		{"fail URL alone", []Tenant{{Name: "acme", APIKeySHA256: keyA, HeartbeatFailURL: "https://hc-ping.com/uuid/fail"}}, "requires heartbeatUrl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.tenants)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	if tenants, err := LoadFile(""); err != nil || tenants != nil {
+		t.Fatalf("LoadFile(\"\") = %v, %v, want no tenants", tenants, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	data := `[{"name": "acme", "apiKeySha256": "` + HashKey("secret") + `", "portfoliosFile": "acme/portfolios.json"}]`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tenants, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tenants) != 1 || tenants[0].Name != "acme" || tenants[0].PortfoliosFile != "acme/portfolios.json" {
+		t.Errorf("tenants = %+v", tenants)
+	}
+}
+
+func TestRouter(t *testing.T) {
+	serve := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name + ":" + FromContext(r.Context())))
+		})
+	}
+	router := NewRouter(serve("public"))
+	router.Add(Tenant{Name: "a", APIKeySHA256: HashKey("key-a")}, serve("a"))
+	router.Add(Tenant{Name: "b", APIKeySHA256: strings.ToUpper(HashKey("key-b"))}, serve("b"))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		header     string
+		value      string
+		wantStatus int
+		wantBody   string
+	}{
+		{"bearer token", "GET", "/labels", "Authorization", "Bearer key-a", http.StatusOK, "a:a"},
+		{"api key header", "GET", "/labels", "X-API-Key", "key-b", http.StatusOK, "b:b"},
+		{"lowercase scheme", "PUT", "/validator/1/labels", "Authorization", "bearer key-b", http.StatusOK, "b:b"},
+		{"missing key", "GET", "/labels", "", "", http.StatusUnauthorized, "unauthorized"},
+		{"unknown key", "GET", "/labels", "X-API-Key", "key-c", http.StatusUnauthorized, "unauthorized"},
+		{"basic auth", "GET", "/labels", "Authorization", "Basic a2V5LWE=", http.StatusUnauthorized, "unauthorized"},
+		{"health", "GET", "/health", "", "", http.StatusOK, "public:"},
+		{"versioned health", "GET", "/v1/health", "", "", http.StatusOK, "public:"},
+		{"versioned data", "GET", "/v1/labels", "", "", http.StatusUnauthorized, "unauthorized"},
+		{"ui", "GET", "/assets/app.js", "", "", http.StatusOK, "public"},
+		{"preflight", "OPTIONS", "/labels", "", "", http.StatusOK, "public"},
+		{"health with key", "GET", "/health", "X-API-Key", "key-a", http.StatusOK, "public"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+// Package tracing propagates W3C trace context through the API and records sampled spans to the log.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Sampling modes.
+const (
+	// ModeAlways records every trace.
+	ModeAlways = "always"
+	// ModeRatio records a fixed fraction of traces, chosen by trace ID.
+	ModeRatio = "ratio"
+	// ModeErrorsOnly records only spans that failed.
+	ModeErrorsOnly = "errors-only"
+)
+
+// TraceparentHeader is the W3C trace context header.
+const TraceparentHeader = "traceparent"
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid reports whether both IDs are set, as required by the W3C spec.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceIDString returns the trace ID in hex, or an empty string for an invalid span context.
+func (sc SpanContext) TraceIDString() string {
+	if !sc.IsValid() {
+		return ""
+	}
+	return hex.EncodeToString(sc.TraceID[:])
+}
+
+// Traceparent formats the span context as a version 00 traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := 0
+	if sc.Sampled {
+		flags = 1
+	}
+	return fmt.Sprintf("00-%x-%x-%02x", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceparent parses a traceparent header value.
+// It returns false for malformed values and the invalid version ff.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+	// Version 00 has exactly four fields; later versions may append more
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	var flags [1]byte
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return SpanContext{}, false
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, true
+}
+
+// Sampler decides which traces are recorded.
+// Traces whose incoming parent is sampled are always recorded, so a sampling
+// decision made by a caller is honoured end to end.
+type Sampler struct {
+	mode  string
+	ratio float64
+}
+
+// NewSampler creates a sampler for one of the sampling modes. The ratio is only
+// used by ModeRatio. Any other mode only follows the incoming sampling decision.
+func NewSampler(mode string, ratio float64) *Sampler {
+	return &Sampler{mode: mode, ratio: ratio}
+}
+
+// shouldSample makes the sampling decision when a root span starts.
+func (s *Sampler) shouldSample(parent SpanContext, traceID [16]byte) bool {
+	if parent.IsValid() && parent.Sampled {
+		return true
+	}
+	switch s.mode {
+	case ModeAlways:
+		return true
+	case ModeRatio:
+		return traceIDBelowRatio(traceID, s.ratio)
+	default:
+		return false
+	}
+}
+
+// traceIDBelowRatio deterministically selects the given fraction of trace IDs,
+// compatible with the OpenTelemetry TraceIdRatioBased sampler.
+func traceIDBelowRatio(traceID [16]byte, ratio float64) bool {
+	if ratio >= 1 {
+		return true
+	}
+	if ratio <= 0 {
+		return false
+	}
+	bound := uint64(ratio * (1 << 63))
+	return binary.BigEndian.Uint64(traceID[8:16])>>1 < bound
+}
+
+// Span is an operation within a trace. A nil Span is valid and does nothing.
+type Span struct {
+	name    string
+	context SpanContext
+	parent  [8]byte
+	start   time.Time
+	sampler *Sampler
+	attrs   []any
+}
+
+type spanKey struct{}
+
+// SpanFromContext returns the current span, or nil if the request is not traced.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// StartSpan starts a child of the current span. Without a current span it returns ctx and a nil span.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		name: name,
+		context: SpanContext{
+			TraceID: parent.context.TraceID,
+			SpanID:  newSpanID(),
+			Sampled: parent.context.Sampled,
+		},
+		parent:  parent.context.SpanID,
+		start:   time.Now(),
+		sampler: parent.sampler,
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Context returns the span's identity.
+func (sp *Span) Context() SpanContext {
+	if sp == nil {
+		return SpanContext{}
+	}
+	return sp.context
+}
+
+// SetAttributes adds slog key-value pairs to the span's log record.
+func (sp *Span) SetAttributes(args ...any) {
+	if sp == nil {
+		return
+	}
+	sp.attrs = append(sp.attrs, args...)
+}
+
+// End finishes the span and logs it if it was sampled. In errors-only mode failed
+// spans are logged as well.
+func (sp *Span) End(failed bool) {
+	if sp == nil {
+		return
+	}
+	if !sp.context.Sampled && !(failed && sp.sampler.mode == ModeErrorsOnly) {
+		return
+	}
+
+	args := []any{
+		"name", sp.name,
+		"trace_id", sp.context.TraceIDString(),
+		"span_id", hex.EncodeToString(sp.context.SpanID[:]),
+		"duration", time.Since(sp.start).String(),
+		"error", failed,
+	}
+	if sp.parent != [8]byte{} {
+		args = append(args, "parent_span_id", hex.EncodeToString(sp.parent[:]))
+	}
+	slog.Info("span", append(args, sp.attrs...)...)
+}
+
+// Inject writes the traceparent header for an outgoing request made within the current span.
+func Inject(ctx context.Context, header http.Header) {
+	if span := SpanFromContext(ctx); span != nil {
+		header.Set(TraceparentHeader, span.context.Traceparent())
+	}
+}
+
+// Middleware starts a root span for every request, continuing the trace of an
+// incoming traceparent header. Responses with a 5xx status mark the span failed.
+func (s *Sampler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parent, _ := ParseTraceparent(r.Header.Get(TraceparentHeader))
+
+		traceID := parent.TraceID
+		if !parent.IsValid() {
+			traceID = newTraceID()
+		}
+
+		span := &Span{
+			name: r.Method + " " + r.URL.Path,
+			context: SpanContext{
+				TraceID: traceID,
+				SpanID:  newSpanID(),
+				Sampled: s.shouldSample(parent, traceID),
+			},
+			parent:  parent.SpanID,
+			start:   time.Now(),
+			sampler: s,
+		}
+
+		wrapped := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r.WithContext(context.WithValue(r.Context(), spanKey{}, span)))
+
+		span.SetAttributes("status", wrapped.statusCode)
+		span.End(wrapped.statusCode >= http.StatusInternalServerError)
+	})
+}
+
+// statusRecorder captures the response status code.
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (rw *statusRecorder) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rw *statusRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func newTraceID() [16]byte {
+	var id [16]byte
+	for id == [16]byte{} {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() [8]byte {
+	var id [8]byte
+	for id == [8]byte{} {
+		rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		valid   bool
+		sampled bool
+	}{
+		{name: "sampled", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", valid: true, sampled: true},
+		{name: "not sampled", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", valid: true},
+		{name: "future version with extra field", value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", valid: true, sampled: true},
+		{name: "version 00 with extra field", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{name: "invalid version", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "zero trace id", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "zero span id", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{name: "short trace id", value: "00-4bf92f3577b34da6-00f067aa0ba902b7-01"},
+		{name: "not hex", value: "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01"},
+		{name: "empty", value: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := ParseTraceparent(tt.value)
+			if ok != tt.valid {
+				t.Fatalf("expected valid=%v, got %v", tt.valid, ok)
+			}
+			if ok && sc.Sampled != tt.sampled {
+				t.Errorf("expected sampled=%v, got %v", tt.sampled, sc.Sampled)
+			}
+		})
+	}
+}
+
+func TestSampler_ShouldSample(t *testing.T) {
+	low := [16]byte{8: 0x00, 9: 0x01}
+	high := [16]byte{8: 0xff, 9: 0xff}
+	sampledParent, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	tests := []struct {
+		name    string
+		sampler *Sampler
+		parent  SpanContext
+		traceID [16]byte
+		want    bool
+	}{
+		{name: "always", sampler: NewSampler(ModeAlways, 0), traceID: high, want: true},
+		{name: "ratio below bound", sampler: NewSampler(ModeRatio, 0.5), traceID: low, want: true},
+		{name: "ratio above bound", sampler: NewSampler(ModeRatio, 0.5), traceID: high, want: false},
+		{name: "ratio zero", sampler: NewSampler(ModeRatio, 0), traceID: low, want: false},
+		{name: "errors only", sampler: NewSampler(ModeErrorsOnly, 0), traceID: low, want: false},
+		{name: "sampled parent", sampler: NewSampler(ModeErrorsOnly, 0), parent: sampledParent, traceID: high, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sampler.shouldSample(tt.parent, tt.traceID); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMiddleware_PropagatesTraceparent(t *testing.T) {
+	incoming := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	var outgoing http.Header
+	handler := NewSampler(ModeErrorsOnly, 0).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := StartSpan(r.Context(), "upstream")
+		defer span.End(false)
+
+		outgoing = http.Header{}
+		Inject(ctx, outgoing)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/validator", nil)
+	req.Header.Set(TraceparentHeader, incoming)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := outgoing.Get(TraceparentHeader)
+	if !strings.HasPrefix(got, "00-4bf92f3577b34da6a3ce929d0e0e4736-") || !strings.HasSuffix(got, "-01") {
+		t.Errorf("expected outgoing traceparent in the incoming trace, got %q", got)
+	}
+	if got == incoming {
+		t.Error("expected a new span ID on the outgoing request")
+	}
+}
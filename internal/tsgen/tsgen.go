@@ -0,0 +1,225 @@
+// Package tsgen generates TypeScript declarations from the Go source of the API
+// models, so the dashboard frontend can use the exact shapes the API encodes.
+package tsgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+)
+
+// Generate returns a TypeScript module declaring one export for each exported type
+// in the Go source file. Structs become interfaces following their JSON encoding:
+// json tag names are used, `json:"-"` and unexported fields are skipped, omitempty
+// fields are optional and pointers are nullable. Embedded structs are extended.
+func Generate(filename string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filename, err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by cmd/typegen. DO NOT EDIT.\n")
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if !ts.Name.IsExported() {
+				continue
+			}
+
+			doc := ts.Doc
+			if doc == nil && len(gen.Specs) == 1 {
+				doc = gen.Doc
+			}
+
+			buf.WriteString("\n")
+			writeComment(&buf, "", doc)
+			if err := writeType(&buf, ts); err != nil {
+				return nil, fmt.Errorf("type %s: %w", ts.Name.Name, err)
+			}
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeType writes the declaration of a single type.
+func writeType(buf *bytes.Buffer, ts *ast.TypeSpec) error {
+	st, ok := ts.Type.(*ast.StructType)
+	if !ok {
+		t, err := tsType(ts.Type)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "export type %s = %s;\n", ts.Name.Name, t)
+		return nil
+	}
+
+	var extends []string
+	var fields bytes.Buffer
+	for _, field := range st.Fields.List {
+		name, omitempty, skip := jsonField(field)
+		if skip {
+			continue
+		}
+
+		// Embedded structs without a json name are flattened into the parent
+		if len(field.Names) == 0 && name == "" {
+			embedded, err := tsType(field.Type)
+			if err != nil {
+				return err
+			}
+			extends = append(extends, embedded)
+			continue
+		}
+
+		t, err := tsType(field.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+		if _, isPointer := field.Type.(*ast.StarExpr); isPointer && !omitempty {
+			t += " | null"
+		}
+		optional := ""
+		if omitempty {
+			optional = "?"
+		}
+
+		doc := field.Doc
+		if doc == nil {
+			doc = field.Comment
+		}
+		writeComment(&fields, "  ", doc)
+		fmt.Fprintf(&fields, "  %s%s: %s;\n", name, optional, t)
+	}
+
+	fmt.Fprintf(buf, "export interface %s", ts.Name.Name)
+	if len(extends) > 0 {
+		fmt.Fprintf(buf, " extends %s", strings.Join(extends, ", "))
+	}
+	buf.WriteString(" {\n")
+	buf.Write(fields.Bytes())
+	buf.WriteString("}\n")
+	return nil
+}
+
+// jsonField returns the JSON name of a field and whether it is omitted when empty.
+// The name is empty for embedded fields without a json name. skip is set for
+// fields that are never encoded.
+func jsonField(field *ast.Field) (name string, omitempty, skip bool) {
+	if len(field.Names) > 0 {
+		if !field.Names[0].IsExported() {
+			return "", false, true
+		}
+		name = field.Names[0].Name
+	}
+
+	if field.Tag == nil {
+		return name, false, false
+	}
+	tag := reflect.StructTag(strings.Trim(field.Tag.Value, "`")).Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	tagName, opts, _ := strings.Cut(tag, ",")
+	if tagName != "" {
+		name = tagName
+	}
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
+
+// tsType maps a Go type expression to its TypeScript equivalent.
+func tsType(expr ast.Expr) (string, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return "string", nil
+		case "bool":
+			return "boolean", nil
+		case "int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64",
+			"float32", "float64":
+			return "number", nil
+		case "any":
+			return "unknown", nil
+		}
+		if t.IsExported() {
+			return t.Name, nil
+		}
+		return "", fmt.Errorf("unsupported type %s", t.Name)
+	case *ast.StarExpr:
+		return tsType(t.X)
+	case *ast.ArrayType:
+		elem, err := tsType(t.Elt)
+		if err != nil {
+			return "", err
+		}
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]", nil
+	case *ast.MapType:
+		value, err := tsType(t.Value)
+		if err != nil {
+			return "", err
+		}
+		return "Record<string, " + value + ">", nil
+	case *ast.SelectorExpr:
+		// time.Time encodes as an RFC 3339 string
+		if pkg, ok := t.X.(*ast.Ident); ok && pkg.Name == "time" && t.Sel.Name == "Time" {
+			return "string", nil
+		}
+		// json.RawMessage holds arbitrary JSON
+		if pkg, ok := t.X.(*ast.Ident); ok && pkg.Name == "json" && t.Sel.Name == "RawMessage" {
+			return "unknown", nil
+		}
+		return "", fmt.Errorf("unsupported type %s", t.Sel.Name)
+	case *ast.InterfaceType:
+		return "unknown", nil
+	default:
+		return "", fmt.Errorf("unsupported type expression %T", expr)
+	}
+}
+
+// writeComment writes a Go comment group as a JSDoc comment.
+func writeComment(buf *bytes.Buffer, indent string, doc *ast.CommentGroup) {
+	if doc == nil {
+		return
+	}
+	text := strings.TrimSpace(doc.Text())
+	if text == "" {
+		return
+	}
+
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(buf, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+	fmt.Fprintf(buf, "%s/**\n", indent)
+	for _, line := range lines {
+		if line == "" {
+			fmt.Fprintf(buf, "%s *\n", indent)
+			continue
+		}
+		fmt.Fprintf(buf, "%s * %s\n", indent, line)
+	}
+	fmt.Fprintf(buf, "%s */\n", indent)
+}
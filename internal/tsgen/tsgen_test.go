@@ -0,0 +1,95 @@
+package tsgen
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	src := `package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Base has shared fields.
+type Base struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+// Item is an item.
+type Item struct {
+	Base
+	Name     string            ` + "`json:\"name\"`" + ` // Display name
+	Price    *float64          ` + "`json:\"price\"`" + `
+	Note     *string           ` + "`json:\"note,omitempty\"`" + `
+	Tags     []string          ` + "`json:\"tags\"`" + `
+	Attrs    map[string]int    ` + "`json:\"attrs\"`" + `
+	Created  time.Time         ` + "`json:\"created\"`" + `
+	Raw      json.RawMessage   ` + "`json:\"raw,omitempty\"`" + `
+	Internal string            ` + "`json:\"-\"`" + `
+	hidden   string
+	Untagged bool
+}
+
+// Kind is a kind.
+type Kind string
+`
+
+	out, err := Generate("models.go", []byte(src))
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	got := string(out)
+
+	for _, want := range []string{
+		"/** Item is an item. */\nexport interface Item extends Base {\n",
+		"  /** Display name */\n  name: string;\n",
+		"  price: number | null;\n",
+		"  note?: string;\n",
+		"  tags: string[];\n",
+		"  attrs: Record<string, number>;\n",
+		"  created: string;\n",
+		"  raw?: unknown;\n",
+		"  Untagged: boolean;\n",
+		"export type Kind = string;\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"Internal", "hidden"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("expected %s to be skipped", unwanted)
+		}
+	}
+}
+
+func TestGenerate_UnsupportedType(t *testing.T) {
+	src := "package models\n\ntype Item struct {\n\tC chan int `json:\"c\"`\n}\n"
+	if _, err := Generate("models.go", []byte(src)); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+}
+
+// TestServedTypesUpToDate fails when the API models changed without regenerating
+// the served declarations.
+func TestServedTypesUpToDate(t *testing.T) {
+	src, err := os.ReadFile("../models/api.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Generate("../models/api.go", src)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	got, err := os.ReadFile("../web/static/types.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Error("internal/web/static/types.ts is out of date, run: go generate ./internal/web")
+	}
+}
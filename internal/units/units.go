@@ -0,0 +1,186 @@
+// Package units converts the wei amounts of API responses into gwei or ETH. Amount
+// fields are marked with a unit:"wei" struct tag and stay decimal strings, so
+// converted amounts keep their full precision. Fiat amounts are marked with
+// unit:"fiat" and only change when a locale is requested.
+package units
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/locale"
+)
+
+// Unit is a denomination of ether amounts.
+type Unit string
+
+// Supported units.
+const (
+	Wei  Unit = "wei"
+	Gwei Unit = "gwei"
+	ETH  Unit = "eth"
+)
+
+// decimals is the number of decimal places of each unit relative to wei.
+var decimals = map[Unit]int{Wei: 0, Gwei: 9, ETH: 18}
+
+// Parse parses a unit name. An empty name is wei, the unit of the API.
+func Parse(name string) (Unit, error) {
+	if name == "" {
+		return Wei, nil
+	}
+	u := Unit(strings.ToLower(name))
+	if _, ok := decimals[u]; !ok {
+		return "", fmt.Errorf("must be one of: eth, gwei, wei")
+	}
+	return u, nil
+}
+
+// Format converts a decimal wei amount into u, without trailing zeros, e.g.
+// "-1500000000000000000" is "-1.5" ETH. Amounts that are not integers, such as
+// empty strings, are returned unchanged.
+func Format(wei string, u Unit) string {
+	v, ok := new(big.Int).SetString(wei, 10)
+	if !ok || decimals[u] == 0 {
+		return wei
+	}
+
+	sign := ""
+	if v.Sign() < 0 {
+		sign = "-"
+		v.Neg(v)
+	}
+	digits := v.String()
+	if n := decimals[u] + 1 - len(digits); n > 0 {
+		digits = strings.Repeat("0", n) + digits
+	}
+	point := len(digits) - decimals[u]
+	whole, fraction := digits[:point], strings.TrimRight(digits[point:], "0")
+	if fraction == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + fraction
+}
+
+// Convert returns the JSON form of v with every amount tagged unit:"wei"
+// converted into u. v is returned unchanged for wei.
+func Convert(v any, u Unit) (any, error) {
+	return ConvertLocalized(v, u, locale.Locale{})
+}
+
+// ConvertLocalized is Convert that also writes the converted amounts with the
+// separators of loc, and the fiat amounts tagged unit:"fiat" as strings rounded
+// to cents, e.g. "1.234,50" in German. Amounts in wei are not localized, since
+// they are meant for machines rather than readers.
+func ConvertLocalized(v any, u Unit, loc locale.Locale) (any, error) {
+	if (decimals[u] == 0 && loc.IsZero()) || v == nil {
+		return v, nil
+	}
+	f := formatter{unit: u, locale: loc}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep integers exact
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return f.convert(reflect.TypeOf(v), doc), nil
+}
+
+// formatter converts the amounts of a response.
+type formatter struct {
+	unit   Unit
+	locale locale.Locale
+}
+
+// amount formats a wei amount.
+func (f formatter) amount(wei string) string {
+	converted := Format(wei, f.unit)
+	if decimals[f.unit] == 0 {
+		return converted
+	}
+	return f.locale.FormatDecimal(converted)
+}
+
+// fiat formats a fiat amount, a JSON number, or returns it unchanged without a
+// locale.
+func (f formatter) fiat(v any) any {
+	n, ok := v.(json.Number)
+	if !ok || f.locale.IsZero() {
+		return v
+	}
+	value, err := n.Float64()
+	if err != nil {
+		return v
+	}
+	return f.locale.FormatMoney(value)
+}
+
+// convert converts the amounts in src, a JSON value of type t.
+func (f formatter) convert(t reflect.Type, src any) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Map:
+		if entries, ok := src.(map[string]any); ok {
+			for k, v := range entries {
+				entries[k] = f.convert(t.Elem(), v)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if items, ok := src.([]any); ok {
+			for i, v := range items {
+				items[i] = f.convert(t.Elem(), v)
+			}
+		}
+	case reflect.Struct:
+		if obj, ok := src.(map[string]any); ok {
+			f.convertFields(t, obj)
+		}
+	}
+	return src
+}
+
+// convertFields converts the amounts in obj, the JSON object of struct type t,
+// including the fields of embedded structs.
+func (f formatter) convertFields(t reflect.Type, obj map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && name == "" {
+			if inner := field.Type; inner.Kind() == reflect.Struct {
+				f.convertFields(inner, obj)
+			}
+			continue
+		}
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		value, ok := obj[name]
+		if !ok {
+			continue
+		}
+		switch s, isString := value.(string); {
+		case isString && field.Tag.Get("unit") == string(Wei):
+			obj[name] = f.amount(s)
+		case field.Tag.Get("unit") == "fiat":
+			obj[name] = f.fiat(value)
+		default:
+			obj[name] = f.convert(field.Type, value)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package units
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/locale"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Unit
+		wantErr bool
+	}{
+		{"", Wei, false},
+		{"wei", Wei, false},
+		{"gwei", Gwei, false},
+		{"ETH", ETH, false},
+		{"finney", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Parse(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		wei  string
+		unit Unit
+		want string
+	}{
+		{"32000000000000000000", ETH, "32"},
+		{"1500000000000000000", ETH, "1.5"},
+		{"-1500000000000000000", ETH, "-1.5"},
+		{"12345", ETH, "0.000000000000012345"},
+		{"-12345", Gwei, "-0.000012345"},
+		{"0", ETH, "0"},
+		{"32000000000", Gwei, "32"},
+		{"32000000001", Wei, "32000000001"},
+		{"", ETH, ""},
+		{"n/a", Gwei, "n/a"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.wei, tt.unit); got != tt.want {
+			t.Errorf("Format(%q, %s) = %q, want %q", tt.wei, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	type amounts struct {
+		Total   string `json:"total" unit:"wei"`
+		Penalty string `json:"penalty,omitempty" unit:"wei"`
+		Date    string `json:"date"`
+	}
+	type totals struct {
+		Balance string `json:"balance" unit:"wei"`
+	}
+	type response struct {
+		totals
+		Days       []amounts          `json:"days"`
+		Validators map[string]amounts `json:"validators"`
+		Summary    *amounts           `json:"summary"`
+		Count      int                `json:"count"`
+	}
+
+	converted, err := Convert(response{
+		totals:     totals{Balance: "64000000000000000000"},
+		Days:       []amounts{{Total: "-500000000000000000", Date: "2025-01-01"}},
+		Validators: map[string]amounts{"1": {Total: "1000000000", Penalty: "2000000000"}},
+		Summary:    &amounts{Total: "250000000000000000"},
+		Count:      2,
+	}, ETH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(converted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"balance":"64","count":2,"days":[{"date":"2025-01-01","total":"-0.5"}],"summary":{"date":"","total":"0.25"},"validators":{"1":{"date":"","penalty":"0.000000002","total":"0.000000001"}}}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestConvertLocalized(t *testing.T) {
+	type price struct {
+		Price float64 `json:"price" unit:"fiat"`
+	}
+	type response struct {
+		price
+		Balance string   `json:"balance" unit:"wei"`
+		Value   *float64 `json:"value,omitempty" unit:"fiat"`
+		Count   int      `json:"count"`
+	}
+	value := 1234567.891
+	v := response{price: price{Price: 3000.5}, Balance: "1234500000000000000000", Value: &value, Count: 1200}
+
+	de, err := locale.Parse("de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		unit   Unit
+		locale locale.Locale
+		want   string
+	}{
+		{"eth in german", ETH, de, `{"balance":"1.234,5","count":1200,"price":"3.000,50","value":"1.234.567,89"}`},
+		{"wei in german", Wei, de, `{"balance":"1234500000000000000000","count":1200,"price":"3.000,50","value":"1.234.567,89"}`},
+		{"eth without locale", ETH, locale.Locale{}, `{"balance":"1234.5","count":1200,"price":3000.5,"value":1234567.891}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converted, err := ConvertLocalized(v, tt.unit, tt.locale)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := json.Marshal(converted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("got %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
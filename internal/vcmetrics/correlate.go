@@ -0,0 +1,333 @@
+package vcmetrics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+// farFutureEpoch and above mark lifecycle epochs that are not set.
+const farFutureEpoch = 1 << 62
+
+// batchSize is the number of validators fetched per upstream request.
+const batchSize = 100
+
+// retentionEpochs is how long correlated windows are kept, about a day.
+const retentionEpochs = 225
+
+// maxWindowEpochs caps the epochs between two scrapes correlated; longer gaps,
+// e.g. after the server was down, are skipped.
+const maxWindowEpochs = 64
+
+// lifecycleTTL is how long the activation and exit epochs of validators are
+// reused before they are fetched again.
+const lifecycleTTL = time.Hour
+
+// Source provides the validators and the attestations included on chain.
+type Source interface {
+	GetValidators(ctx context.Context, chain string, validatorIds []int) ([]models.BeaconchainValidatorData, error)
+	GetAttestations(ctx context.Context, chain string, validatorIds []int, startEpoch, endEpoch int64) ([]models.BeaconchainAttestation, error)
+}
+
+// Correlator scrapes the validator client of every portfolio with client
+// metrics and compares the attestations it signed with those included on
+// chain for the portfolio's validators.
+type Correlator struct {
+	portfolios *portfolio.Registry
+	source     Source
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	states map[string]*clientState // By portfolio name
+}
+
+// clientState is what is known about the validator client of a portfolio.
+type clientState struct {
+	client     string
+	scrapeErr  string
+	baseline   *scrape  // Last successful scrape
+	pending    []window // Scraped, awaiting inclusion of their attestations
+	correlated []models.ClientMissWindow
+
+	lifecycle   map[int]lifecycle
+	lifecycleAt time.Time
+}
+
+// scrape is the counters read once the epoch was completed.
+type scrape struct {
+	epoch    int64
+	counters Counters
+}
+
+// window is the signing of the client between two scrapes.
+type window struct {
+	start, end     int64
+	signed, failed int
+}
+
+// lifecycle is the epochs a validator attests from and until.
+type lifecycle struct {
+	activation, exit int64
+}
+
+// NewCorrelator creates a correlator of the portfolios in registry.
+func NewCorrelator(registry *portfolio.Registry, source Source, timeout time.Duration) *Correlator {
+	return &Correlator{
+		portfolios: registry,
+		source:     source,
+		httpClient: &http.Client{Timeout: timeout},
+		states:     make(map[string]*clientState),
+	}
+}
+
+// Run checks immediately and then every interval until the context is canceled.
+func (c *Correlator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.Check(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check scrapes the client of every portfolio with client metrics, and
+// correlates the windows between scrapes whose attestations had the epoch after
+// the window to be included. Failures are logged and retried on the next check.
+func (c *Correlator) Check(ctx context.Context, now time.Time) {
+	for _, p := range c.portfolios.All() {
+		if p.ClientMetricsURL == "" || ctx.Err() != nil {
+			continue
+		}
+		if err := c.check(ctx, p, now); err != nil && ctx.Err() == nil {
+			slog.Warn("failed to correlate validator client metrics", "portfolio", p.Name, "error", err)
+		}
+	}
+}
+
+// check scrapes and correlates the client of p.
+func (c *Correlator) check(ctx context.Context, p portfolio.Portfolio, now time.Time) error {
+	last, err := chainspec.LastCompletedEpoch(p.Chain, now)
+	if err != nil {
+		return err
+	}
+	counters, scrapeErr := Scrape(ctx, c.httpClient, p.ClientMetricsURL)
+
+	c.mu.Lock()
+	st := c.state(p.Name)
+	if scrapeErr != nil {
+		st.scrapeErr = scrapeErr.Error()
+	} else {
+		st.scrapeErr, st.client = "", counters.Client
+		st.addScrape(scrape{epoch: last, counters: counters})
+	}
+	var ready []window
+	for len(st.pending) > 0 && st.pending[0].end < last {
+		ready = append(ready, st.pending[0])
+		st.pending = st.pending[1:]
+	}
+	lifecycles := st.lifecycle
+	if now.Sub(st.lifecycleAt) > lifecycleTTL {
+		lifecycles = nil
+	}
+	c.mu.Unlock()
+
+	if scrapeErr != nil {
+		slog.Warn("failed to scrape validator client metrics", "portfolio", p.Name, "error", scrapeErr)
+	}
+	if len(ready) == 0 {
+		return nil
+	}
+
+	ids := slices.Clone(p.ValidatorIds)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	if lifecycles == nil || slices.ContainsFunc(ids, func(id int) bool { _, ok := lifecycles[id]; return !ok }) {
+		if lifecycles, err = c.fetchLifecycles(ctx, p.Chain, ids); err != nil {
+			return err
+		}
+		c.mu.Lock()
+		st.lifecycle, st.lifecycleAt = lifecycles, now
+		c.mu.Unlock()
+	}
+	included, err := c.fetchIncluded(ctx, p.Chain, ids, ready[0].start, ready[len(ready)-1].end)
+	if err != nil {
+		return err
+	}
+
+	results := make([]models.ClientMissWindow, len(ready))
+	for i, w := range ready {
+		results[i] = correlate(w, ids, lifecycles, included)
+		if r := results[i]; r.NotSigned > 0 || r.NotIncluded > 0 {
+			slog.Warn("attestations missed", "portfolio", p.Name, "startEpoch", r.StartEpoch, "endEpoch", r.EndEpoch,
+				"notSigned", r.NotSigned, "notIncluded", r.NotIncluded)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st.correlated = append(st.correlated, results...)
+	st.correlated = slices.DeleteFunc(st.correlated, func(w models.ClientMissWindow) bool {
+		return w.EndEpoch <= last-retentionEpochs
+	})
+	return nil
+}
+
+// state returns the state of the client of a portfolio. Callers must hold c.mu.
+func (c *Correlator) state(name string) *clientState {
+	st, ok := c.states[name]
+	if !ok {
+		st = &clientState{}
+		c.states[name] = st
+	}
+	return st
+}
+
+// addScrape adds the window since the previous scrape and makes s the baseline
+// of the next one. A scrape in the same epoch as the baseline is ignored.
+func (st *clientState) addScrape(s scrape) {
+	if st.baseline != nil && s.epoch <= st.baseline.epoch {
+		return
+	}
+	if b := st.baseline; b != nil && s.epoch-b.epoch <= maxWindowEpochs {
+		st.pending = append(st.pending, window{
+			start:  b.epoch + 1,
+			end:    s.epoch,
+			signed: int(increase(b.counters.Signed, s.counters.Signed)),
+			failed: int(increase(b.counters.Failed, s.counters.Failed)),
+		})
+	}
+	st.baseline = &s
+}
+
+// increase returns the increase of a counter, which resets to zero when the
+// client restarts.
+func increase(previous, current float64) float64 {
+	if current < previous {
+		return current
+	}
+	return current - previous
+}
+
+// fetchLifecycles returns the activation and exit epochs of the validators ids.
+func (c *Correlator) fetchLifecycles(ctx context.Context, chain string, ids []int) (map[int]lifecycle, error) {
+	lifecycles := make(map[int]lifecycle, len(ids))
+	for start := 0; start < len(ids); start += batchSize {
+		validators, err := c.source.GetValidators(ctx, chain, ids[start:min(start+batchSize, len(ids))])
+		if err != nil {
+			return nil, fmt.Errorf("fetch validators: %w", err)
+		}
+		for _, v := range validators {
+			if v.Validator.Index == nil {
+				continue
+			}
+			l := lifecycle{activation: farFutureEpoch, exit: farFutureEpoch}
+			if e := v.LifeCycleEpochs.Activation; e != nil {
+				l.activation = *e
+			}
+			if e := v.LifeCycleEpochs.Exit; e != nil {
+				l.exit = *e
+			}
+			lifecycles[*v.Validator.Index] = l
+		}
+	}
+	// Validators unknown upstream do not attest
+	for _, id := range ids {
+		if _, ok := lifecycles[id]; !ok {
+			lifecycles[id] = lifecycle{activation: farFutureEpoch, exit: farFutureEpoch}
+		}
+	}
+	return lifecycles, nil
+}
+
+// duty is the attestation of a validator in an epoch.
+type duty struct {
+	index int
+	epoch int64
+}
+
+// fetchIncluded returns the attestations of the validators ids from startEpoch
+// to endEpoch included on chain.
+func (c *Correlator) fetchIncluded(ctx context.Context, chain string, ids []int, startEpoch, endEpoch int64) (map[duty]bool, error) {
+	included := make(map[duty]bool)
+	for start := 0; start < len(ids); start += batchSize {
+		attestations, err := c.source.GetAttestations(ctx, chain, ids[start:min(start+batchSize, len(ids))], startEpoch, endEpoch)
+		if err != nil {
+			return nil, fmt.Errorf("fetch attestations: %w", err)
+		}
+		for _, a := range attestations {
+			if a.Validator.Index != nil {
+				included[duty{*a.Validator.Index, a.Epoch}] = true
+			}
+		}
+	}
+	return included, nil
+}
+
+// correlate compares the attestations signed in w with those expected of the
+// active validators and included on chain. Misses beyond the attestations the
+// client signed are the client's; the rest were signed but not included.
+func correlate(w window, ids []int, lifecycles map[int]lifecycle, included map[duty]bool) models.ClientMissWindow {
+	result := models.ClientMissWindow{StartEpoch: w.start, EndEpoch: w.end, Signed: w.signed, Failed: w.failed}
+	for epoch := w.start; epoch <= w.end; epoch++ {
+		for _, id := range ids {
+			if l := lifecycles[id]; epoch < l.activation || epoch >= l.exit {
+				continue
+			}
+			result.Expected++
+			if included[duty{id, epoch}] {
+				result.Included++
+			}
+		}
+	}
+	missed := result.Expected - result.Included
+	result.NotSigned = min(missed, max(0, result.Expected-result.Signed))
+	result.NotIncluded = missed - result.NotSigned
+	return result
+}
+
+// Misses returns the windows correlated for the client of the portfolio name,
+// oldest first, and their totals. It reports false for portfolios without
+// client metrics.
+func (c *Correlator) Misses(name string) (models.ClientMissesResponse, bool) {
+	p, ok := c.portfolios.Get(name)
+	if !ok || p.ClientMetricsURL == "" {
+		return models.ClientMissesResponse{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.state(name)
+	response := models.ClientMissesResponse{
+		Portfolio:   name,
+		Chain:       p.Chain,
+		Client:      st.client,
+		ScrapeError: st.scrapeErr,
+		Windows:     append([]models.ClientMissWindow{}, st.correlated...),
+	}
+	for _, w := range st.correlated {
+		response.Total.Expected += w.Expected
+		response.Total.Included += w.Included
+		response.Total.Signed += w.Signed
+		response.Total.Failed += w.Failed
+		response.Total.NotSigned += w.NotSigned
+		response.Total.NotIncluded += w.NotIncluded
+	}
+	if n := len(st.correlated); n > 0 {
+		response.Total.StartEpoch, response.Total.EndEpoch = st.correlated[0].StartEpoch, st.correlated[n-1].EndEpoch
+	}
+	return response, true
+}
@@ -0,0 +1,96 @@
+package vcmetrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/portfolio"
+)
+
+func TestCorrelator_Check(t *testing.T) {
+	ctx := context.Background()
+	var signed atomic.Int64
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, "vc_signed_attestations_total{status=\"success\"} %d\n", signed.Load())
+	}))
+	defer server.Close()
+
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validator(1).Build(), beaconchatest.Validator(2).Build())
+	fake.AddAttestations("mainnet",
+		beaconchatest.Attestation(1, 101*32, "0xa"),
+		beaconchatest.Attestation(2, 101*32+5, "0xa"),
+		beaconchatest.Attestation(1, 102*32, "0xb"),
+	)
+	registry, err := portfolio.NewRegistry([]portfolio.Portfolio{{Name: "node", Chain: "mainnet", ValidatorIds: []int{1, 2}, ClientMetricsURL: server.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCorrelator(registry, fake, time.Second)
+
+	spec, err := chainspec.ForChain("mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(epoch int64, counter int64) {
+		signed.Store(counter)
+		c.Check(ctx, spec.EpochStart(epoch+1).Add(time.Second))
+	}
+
+	check(100, 10) // Baseline
+	check(102, 13) // Epochs 101-102 await inclusion
+	if got, _ := c.Misses("node"); len(got.Windows) != 0 {
+		t.Fatalf("expected no window before inclusion, got %+v", got.Windows)
+	}
+	check(103, 15) // Correlates 101-102: one duty missed, 3 of 4 signed
+	check(104, 2)  // The client restarted; correlates 103: both signed, none included
+
+	// A failed scrape still correlates 104, scraped before
+	down.Store(true)
+	check(105, 0)
+
+	got, ok := c.Misses("node")
+	if !ok {
+		t.Fatal("expected client metrics for node")
+	}
+	want := []models.ClientMissWindow{
+		{StartEpoch: 101, EndEpoch: 102, Expected: 4, Included: 3, Signed: 3, NotSigned: 1},
+		{StartEpoch: 103, EndEpoch: 103, Expected: 2, Signed: 2, NotIncluded: 2},
+		{StartEpoch: 104, EndEpoch: 104, Expected: 2, Signed: 2, NotIncluded: 2},
+	}
+	if len(got.Windows) != len(want) {
+		t.Fatalf("expected %d windows, got %+v", len(want), got.Windows)
+	}
+	for i := range want {
+		if got.Windows[i] != want[i] {
+			t.Errorf("window %d: expected %+v, got %+v", i, want[i], got.Windows[i])
+		}
+	}
+	total := models.ClientMissWindow{StartEpoch: 101, EndEpoch: 104, Expected: 8, Included: 3, Signed: 7, NotSigned: 1, NotIncluded: 4}
+	if got.Total != total {
+		t.Errorf("expected total %+v, got %+v", total, got.Total)
+	}
+	if got.Client != "lighthouse" || got.ScrapeError == "" {
+		t.Errorf("expected lighthouse with a scrape error, got %q and %q", got.Client, got.ScrapeError)
+	}
+	if n := fake.Calls(beaconchatest.MethodGetValidators); n != 1 {
+		t.Errorf("expected lifecycles fetched once, got %d calls", n)
+	}
+
+	if _, ok := c.Misses("other"); ok {
+		t.Error("expected no client metrics for an unknown portfolio")
+	}
+}
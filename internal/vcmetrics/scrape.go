@@ -0,0 +1,170 @@
+// Package vcmetrics scrapes the attestation signing counters of a validator client
+// from its Prometheus metrics and correlates local signing with the attestations
+// included on chain, telling misses the client caused from those the network did.
+package vcmetrics
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported is returned for metrics without any known signing counter.
+var ErrUnsupported = errors.New("no known attestation signing metrics")
+
+// maxMetricsBytes caps the metrics read per scrape.
+const maxMetricsBytes = 16 << 20
+
+// Counters are the attestation signing counters of a validator client, summed
+// over all its validators. Counters reset when the client restarts.
+type Counters struct {
+	Client string  // Client the metrics were recognized as, e.g. lighthouse
+	Signed float64 // Attestations signed and published
+	Failed float64 // Attestations the client failed to sign or publish
+}
+
+// signingMetric is a counter of attestations of a client. Series with a label
+// value of status other than success count failures.
+type signingMetric struct {
+	client string
+	name   string
+	status string // Label whose value tells success from failure, if any
+	failed bool
+}
+
+// signingMetrics are the known signing counters, by client.
+var signingMetrics = []signingMetric{
+	{client: "lighthouse", name: "vc_signed_attestations_total", status: "status"},
+	{client: "prysm", name: "validator_successful_attestations"},
+	{client: "prysm", name: "validator_failed_attestations", failed: true},
+	{client: "lodestar", name: "vc_published_attestations_total"},
+	{client: "nimbus", name: "beacon_attestations_sent_total"},
+}
+
+// Scrape reads the signing counters from the Prometheus metrics at url.
+func Scrape(ctx context.Context, client *http.Client, url string) (Counters, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Counters{}, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/plain")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Counters{}, fmt.Errorf("scrape metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Counters{}, fmt.Errorf("scrape metrics: status %d", resp.StatusCode)
+	}
+	return Parse(io.LimitReader(resp.Body, maxMetricsBytes))
+}
+
+// Parse reads the signing counters from metrics in the Prometheus text format.
+// The client is that of the first known counter found.
+func Parse(r io.Reader) (Counters, error) {
+	var counters Counters
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		name, labels, value, ok := parseSample(line)
+		if !ok {
+			continue
+		}
+		for _, m := range signingMetrics {
+			if m.name != name || (counters.Client != "" && m.client != counters.Client) {
+				continue
+			}
+			counters.Client = m.client
+			if m.failed || (m.status != "" && labels[m.status] != "success") {
+				counters.Failed += value
+			} else {
+				counters.Signed += value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Counters{}, fmt.Errorf("read metrics: %w", err)
+	}
+	if counters.Client == "" {
+		return Counters{}, ErrUnsupported
+	}
+	return counters, nil
+}
+
+// parseSample parses a sample line: a metric name, optional labels in braces, a
+// value and an optional timestamp.
+func parseSample(line string) (string, map[string]string, float64, bool) {
+	name, rest := line, ""
+	var labels map[string]string
+	if i := strings.IndexAny(line, "{ \t"); i >= 0 {
+		name, rest = line[:i], line[i:]
+	}
+	if strings.HasPrefix(rest, "{") {
+		var ok bool
+		labels, rest, ok = parseLabels(rest[1:])
+		if !ok {
+			return "", nil, 0, false
+		}
+	}
+	fields := strings.Fields(rest)
+	if name == "" || len(fields) == 0 {
+		return "", nil, 0, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, false
+	}
+	return name, labels, value, true
+}
+
+// parseLabels parses label pairs up to the closing brace and returns the rest of
+// the line after it.
+func parseLabels(s string) (map[string]string, string, bool) {
+	labels := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if strings.HasPrefix(s, "}") {
+			return labels, s[1:], true
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 || eq+1 >= len(s) || s[eq+1] != '"' {
+			return nil, "", false
+		}
+		key := strings.TrimSpace(s[:eq])
+		s = s[eq+2:]
+
+		var value strings.Builder
+		closed := false
+		for i := 0; i < len(s); i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			if s[i] == '"' {
+				s, closed = s[i+1:], true
+				break
+			}
+			value.WriteByte(s[i])
+		}
+		if !closed {
+			return nil, "", false
+		}
+		labels[key] = value.String()
+	}
+}
@@ -0,0 +1,61 @@
+package vcmetrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics string
+		want    Counters
+		wantErr error
+	}{
+		{
+			name: "lighthouse",
+			metrics: `# HELP vc_signed_attestations_total Total count of attempted Attestation signings
+# TYPE vc_signed_attestations_total counter
+vc_signed_attestations_total{status="success"} 1200
+vc_signed_attestations_total{status="slashable"} 2
+vc_signed_attestations_total{status="unknown_pubkey"} 1
+`,
+			want: Counters{Client: "lighthouse", Signed: 1200, Failed: 3},
+		},
+		{
+			name: "prysm per validator",
+			metrics: `validator_successful_attestations{pubkey="0xa1"} 10
+validator_successful_attestations{pubkey="0xb2"} 12 1700000000000
+validator_failed_attestations{pubkey="0xb2"} 1
+`,
+			want: Counters{Client: "prysm", Signed: 22, Failed: 1},
+		},
+		{name: "nimbus", metrics: "beacon_attestations_sent_total 42\n", want: Counters{Client: "nimbus", Signed: 42}},
+		{
+			name:    "escaped labels",
+			metrics: `vc_signed_attestations_total{note="a \"quoted\", value",status="success"} 5` + "\n",
+			want:    Counters{Client: "lighthouse", Signed: 5},
+		},
+		{name: "unknown client", metrics: "process_cpu_seconds_total 3.5\n", wantErr: ErrUnsupported},
+		{name: "malformed lines skipped", metrics: "vc_signed_attestations_total{status=\"success\" 5\nbeacon_attestations_sent_total x\n", wantErr: ErrUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.metrics))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// Validator dashboard UI. Consumes GET /validator and renders the response, and
+// GET /search for the search box.
+(function () {
+  "use strict";
+
+  const WEI_PER_ETH = 10n ** 18n;
+
+  const form = document.getElementById("query");
+  const statusEl = document.getElementById("status");
+  const results = document.getElementById("results");
+  const apiKey = document.getElementById("apikey");
+
+  // The API key is kept in the browser rather than in the bookmarkable URL
+  apiKey.value = localStorage.getItem("apiKey") || "";
+
+  // formatEth converts a wei string into an ETH string with the given precision.
+  function formatEth(wei, decimals = 5) {
+    if (wei === undefined || wei === null || wei === "") return "-";
+    let v;
+    try {
+      v = BigInt(wei);
+    } catch (e) {
+      return "-";
+    }
+    const negative = v < 0n;
+    if (negative) v = -v;
+    const whole = v / WEI_PER_ETH;
+    const frac = (v % WEI_PER_ETH).toString().padStart(18, "0").slice(0, decimals);
+    return (negative ? "-" : "") + whole.toString() + "." + frac;
+  }
+
+  function formatScore(score) {
+    return score === null || score === undefined ? "-" : (score * 100).toFixed(2) + "%";
+  }
+
+  function badgeClass(status) {
+    if (status.startsWith("active_online") || status === "online") return "ok";
+    if (status.includes("slashed") || status.includes("offline")) return "bad";
+    if (status.startsWith("pending") || status.startsWith("exiting")) return "warn";
+    return "";
+  }
+
+  function cell(text, className) {
+    const td = document.createElement("td");
+    td.textContent = text;
+    if (className) td.className = className;
+    return td;
+  }
+
+  function renderValidators(validators) {
+    const tbody = document.getElementById("validators");
+    tbody.replaceChildren();
+
+    Object.keys(validators)
+      .sort((a, b) => Number(a) - Number(b))
+      .forEach((id) => {
+        const v = validators[id];
+        const tr = document.createElement("tr");
+        tr.appendChild(cell(id));
+
+        const status = v.slashed ? "slashed" : v.status;
+        const badge = document.createElement("span");
+        badge.className = "badge " + badgeClass(status);
+        badge.textContent = status;
+        const statusCell = document.createElement("td");
+        statusCell.appendChild(badge);
+        tr.appendChild(statusCell);
+
+        tr.appendChild(cell(formatEth(v.currentBalance)));
+        tr.appendChild(cell(formatEth(v.effectiveBalance, 2)));
+        tr.appendChild(cell(String(v.activationEpoch)));
+        tr.appendChild(cell((v.withdrawalCredentials && v.withdrawalCredentials.address) || "-", "mono"));
+        tbody.appendChild(tr);
+      });
+  }
+
+  function renderList(el, entries) {
+    el.replaceChildren();
+    entries.forEach(([label, value]) => {
+      const dt = document.createElement("dt");
+      dt.textContent = label;
+      const dd = document.createElement("dd");
+      dd.textContent = value;
+      el.append(dt, dd);
+    });
+  }
+
+  function renderRewards(r) {
+    renderList(document.getElementById("rewards"), [
+      ["Net rewards", formatEth(r.total) + " ETH"],
+      ["Rewards", formatEth(r.totalReward) + " ETH"],
+      ["Penalties", formatEth(r.totalPenalty) + " ETH"],
+      ["Missed", formatEth(r.totalMissed) + " ETH"],
+      ["Attestations", formatEth(r.attestations.total) + " ETH"],
+      ["Proposals", formatEth(r.proposals.total) + " ETH"],
+      ["Sync committees", formatEth(r.syncCommittees.total) + " ETH"],
+    ]);
+  }
+
+  function renderPerformance(p) {
+    const a = p.attestations;
+    renderList(document.getElementById("performance"), [
+      ["BeaconScore", formatScore(p.beaconscore)],
+      ["Attestations", a.included + " / " + a.assigned + " (" + a.missed + " missed)"],
+      ["Avg inclusion delay", a.avgInclusionDelay.toFixed(3)],
+      ["Proposals", p.proposals.successful + " / " + p.proposals.assigned],
+      ["Sync duties", p.syncCommittees.successful + " / " + p.syncCommittees.assigned],
+    ]);
+  }
+
+  function setStatus(text, isError) {
+    statusEl.hidden = !text;
+    statusEl.textContent = text || "";
+    statusEl.classList.toggle("error", Boolean(isError));
+  }
+
+  async function load(params) {
+    setStatus("Loading… requests are queued to respect the upstream rate limit.");
+    results.hidden = true;
+
+    try {
+      const headers = apiKey.value ? { "X-API-Key": apiKey.value } : {};
+      const resp = await fetch("/v1/validator?" + params.toString(), { headers });
+      const body = await resp.json();
+      if (!resp.ok) {
+        setStatus(body.message || body.error || "Request failed", true);
+        return;
+      }
+      renderValidators(body.validators || {});
+      renderRewards(body.rewards);
+      renderPerformance(body.performance);
+      results.hidden = false;
+      setStatus("");
+    } catch (e) {
+      setStatus("Request failed: " + e.message, true);
+    }
+  }
+
+  form.addEventListener("submit", (event) => {
+    event.preventDefault();
+    localStorage.setItem("apiKey", apiKey.value);
+    const params = new URLSearchParams(new FormData(form));
+    history.replaceState(null, "", "?" + params.toString());
+    load(params);
+  });
+
+  // The search box lists matching validators and portfolios; picking one loads it
+  const searchInput = document.getElementById("search");
+  const searchResults = document.getElementById("search-results");
+  let searchTimer;
+
+  function searchLabel(r) {
+    const name = r.type === "portfolio" ? "Portfolio " + r.portfolio : "Validator " + r.validatorIndex;
+    return name + " (" + r.chain + ") - " + r.match + ": " + r.value;
+  }
+
+  function renderSearch(results) {
+    searchResults.replaceChildren();
+    results.forEach((r) => {
+      const li = document.createElement("li");
+      const button = document.createElement("button");
+      button.type = "button";
+      button.textContent = searchLabel(r);
+      button.addEventListener("click", () => {
+        const ids = r.type === "portfolio" ? r.validatorIds : [r.validatorIndex];
+        document.getElementById("ids").value = ids.join(",");
+        document.getElementById("chain").value = r.chain;
+        searchResults.hidden = true;
+        form.requestSubmit();
+      });
+      li.appendChild(button);
+      searchResults.appendChild(li);
+    });
+    searchResults.hidden = results.length === 0;
+  }
+
+  async function search(q) {
+    try {
+      const headers = apiKey.value ? { "X-API-Key": apiKey.value } : {};
+      const resp = await fetch("/search?" + new URLSearchParams({ q }).toString(), { headers });
+      const body = await resp.json();
+      // Answers to earlier keystrokes may arrive late
+      if (q !== searchInput.value.trim()) return;
+      renderSearch(resp.ok ? body.results : []);
+    } catch (e) {
+      renderSearch([]);
+    }
+  }
+
+  searchInput.addEventListener("input", () => {
+    clearTimeout(searchTimer);
+    const q = searchInput.value.trim();
+    if (!q) {
+      renderSearch([]);
+      return;
+    }
+    searchTimer = setTimeout(() => search(q), 250);
+  });
+
+  // Restore a previous query from the URL so dashboards can be bookmarked
+  const initial = new URLSearchParams(location.search);
+  if (initial.get("ids")) {
+    ["ids", "chain", "range"].forEach((name) => {
+      if (initial.get(name)) document.getElementById(name).value = initial.get(name);
+    });
+    load(initial);
+  }
+})();
@@ -0,0 +1,1459 @@
+// Code generated by cmd/typegen. DO NOT EDIT.
+
+/** ValidatorRequest represents the incoming request body for POST /validator. */
+export interface ValidatorRequest {
+  /**
+   * ValidatorIds is a list of unique validator indices.
+   * Minimum: 1, Maximum: 100
+   */
+  validatorIds: number[];
+  /** Chain is the target chain for the request. Allowed values: "mainnet", "hoodi". */
+  chain: string;
+  /**
+   * Range is the evaluation window for aggregates. Allowed values: "24h", "7d", "30d", "90d", "all_time",
+   * or a custom window of UTC days such as "2026-01-01/2026-04-01", the end date excluded.
+   */
+  range: string;
+  /** ExcludeAnomalies excludes known network incidents from the aggregates. */
+  excludeAnomalies: boolean;
+  /** Currency optionally requests fiat values in the given currency, e.g. "usd". */
+  currency?: string;
+  /**
+   * Labels optionally selects the validators with all of these labels, as
+   * "key:value", instead of or in addition to ValidatorIds.
+   */
+  labels?: string[];
+  /** GroupBy optionally requests totals per value of the label with this key. */
+  groupBy?: string;
+  /**
+   * Fields optionally limits the response to these sections or paths into them,
+   * e.g. "overview" or "performance.attestations". Sections left out are not
+   * fetched upstream.
+   */
+  fields?: string[];
+  /**
+   * ComparePrevious requests the aggregates of the period before the range, of
+   * the same length, along with their changes.
+   */
+  comparePrevious?: boolean;
+}
+
+/** ValidatorResponse contains per-validator overviews and aggregated rewards/performance. */
+export interface ValidatorResponse {
+  /** Validators contains per-validator overview data keyed by validator ID. */
+  validators: Record<string, ValidatorOverview>;
+  /** Rewards contains aggregated rewards for all requested validators. */
+  rewards: ValidatorRewards;
+  /** Performance contains aggregated performance for all requested validators. */
+  performance: ValidatorPerformance;
+  /** Anomalies describes the known incidents excluded from the aggregates, if any. */
+  anomalies?: AnomalyReport;
+  /** Fiat contains fiat values of the balances and rewards when a currency was requested. */
+  fiat?: FiatValues;
+  /**
+   * Benchmark compares the aggregates with the average network validator over the
+   * same range, when the network averages are available.
+   */
+  benchmark?: Benchmark;
+  /**
+   * Groups contains totals per value of the groupBy label when requested. Validators
+   * without the label are grouped under an empty key.
+   */
+  groups?: Record<string, DashboardTotals>;
+  /** Previous contains the aggregates of the period before the range when requested. */
+  previous?: PreviousPeriod;
+  /**
+   * TimedOutSections lists the sections left out because the request timeout passed
+   * before they were fetched, e.g. "rewards" or "benchmark".
+   */
+  timedOutSections?: string[];
+  /**
+   * FallbackSections lists the sections whose Beaconcha aggregate failed and that
+   * were computed from per-day data or taken from an earlier fetch instead.
+   */
+  fallbackSections?: string[];
+  /**
+   * Window describes the custom evaluation window of the aggregates, when one
+   * was requested.
+   */
+  window?: EvaluationWindow;
+}
+
+/**
+ * EvaluationWindow is a custom evaluation window: the UTC days from From up to
+ * To, and the epochs starting in them.
+ */
+export interface EvaluationWindow {
+  from: string;
+  /** Excluded */
+  to: string;
+  startEpoch: number;
+  endEpoch: number;
+}
+
+/**
+ * AnomalyReport describes which known network incidents were excluded from the
+ * aggregates, and which validators earn at an anomalous rate.
+ */
+export interface AnomalyReport {
+  /** Names of the incident windows applied */
+  windows: string[];
+  /** Validators slashed during a mass slashing */
+  excludedValidators: number[];
+  /** Leak penalty removed from rewards in wei */
+  inactivityLeakPenaltyExcluded: string;
+  /** Validators whose recent reward rate deviates */
+  rewardRates?: RewardRateAnomaly[];
+}
+
+/**
+ * RewardRateAnomaly is a validator whose reward rate over the recent window
+ * deviates from its own baseline or from the fleet median. Rates are in wei per
+ * day and deviations in standard deviations; the comparisons without enough
+ * recorded history are left out.
+ */
+export interface RewardRateAnomaly {
+  validatorIndex: number;
+  /** above or below, of the larger deviation */
+  direction: string;
+  rate: string;
+  /** Mean rate of the earlier windows */
+  baseline?: string;
+  baselineDeviation?: number;
+  fleetMedian?: string;
+  fleetDeviation?: number;
+}
+
+/**
+ * PreviousPeriod contains the aggregates of the period of the same length before
+ * the evaluation range, e.g. the 7 days before the last 7, and the percent change
+ * of the current aggregates over them. A change is null when either value is
+ * unknown or the previous one is zero.
+ */
+export interface PreviousPeriod {
+  from: string;
+  to: string;
+  /** Net rewards of the UTC days in the period, null when unavailable */
+  rewards: string | null;
+  /** Percent change of the net rewards */
+  rewardsChange: number | null;
+  /** Missed rewards of the UTC days in the period */
+  missedRewards: string | null;
+  missedRewardsChange: number | null;
+  /** Included over assigned attestations, from the recorded history */
+  attestationEffectiveness: number | null;
+  attestationEffectivenessChange: number | null;
+  /** By SLA window, each over the window before it */
+  uptime?: Record<string, Uptime>;
+  /** By SLA window, left out when unknown */
+  uptimeChange?: Record<string, number>;
+}
+
+/** ValidatorOverview contains basic validator state information. */
+export interface ValidatorOverview {
+  slashed: boolean;
+  status: string;
+  withdrawalCredentials: WithdrawalCredentials;
+  activationEpoch: number;
+  exitEpoch: number;
+  /** in wei */
+  currentBalance: string;
+  /** in wei */
+  effectiveBalance: string;
+  online: boolean;
+  /** User-defined labels, e.g. machine=node-3 */
+  labels?: Record<string, string>;
+  /** User-defined freeform notes */
+  notes?: string;
+  /** A pre-signed voluntary exit is stored */
+  presignedExit: boolean;
+  /** false until the state is finalized */
+  finalized?: boolean;
+  /** Effective balance headroom, only set for pending and active validators that are not slashed */
+  effectiveBalanceHeadroom?: EffectiveBalanceHeadroom;
+  /** Set while the validator is suspected to run in two places */
+  doppelganger?: DoppelgangerSuspicion;
+  /**
+   * MEV-boost relay registrations, only set for pending and active validators on
+   * mainnet when relay checks are enabled
+   */
+  mev?: MEVRegistrations;
+  /**
+   * Restaking status of the EigenPod the validator withdraws to, only set on
+   * mainnet when an execution RPC is configured
+   */
+  restaking?: Restaking;
+  /** Queue estimates, only set for pending and exiting validators */
+  entryQueuePosition?: number;
+  estimatedActivationTime?: string;
+  estimatedExitTime?: string;
+  estimatedWithdrawableTime?: string;
+}
+
+/**
+ * EffectiveBalanceHeadroom describes how much of the maximum effective balance a
+ * validator earns on, and what it takes to raise its effective balance.
+ */
+export interface EffectiveBalanceHeadroom {
+  /** 32 ETH, or 2048 ETH for compounding (0x02) credentials, in wei */
+  maxEffectiveBalance: string;
+  /** Max minus effective balance in wei */
+  headroom: string;
+  /** Effective over max effective balance */
+  utilization: number;
+  /** Effective balance after the next hysteresis update in wei */
+  pendingEffectiveBalance: string;
+  /** Deposit that restores a 32 ETH effective balance, in wei */
+  topUpAmount?: string;
+  /** Deposit that raises a compounding validator's effective balance by 1 ETH, in wei */
+  nextIncrementTopUp?: string;
+  /** topup_recommended, effective_balance_decreasing */
+  flags?: string[];
+}
+
+/**
+ * DoppelgangerSuspicion describes conflicting attestations of a validator, which
+ * mean its keys sign in two places and precede a slashing.
+ */
+export interface DoppelgangerSuspicion {
+  /** Latest epoch with conflicting attestations */
+  epoch: number;
+  /** Distinct attestations included for that epoch */
+  attestations: number;
+  detectedAt: string;
+}
+
+/** MEVRegistrations describes the registrations of a validator at MEV-boost relays. */
+export interface MEVRegistrations {
+  /** Relays the validator is registered with */
+  relays: string[];
+  /** At every relay checked */
+  registrations: RelayRegistration[];
+  /** Some registration is older than the maximum age */
+  stale?: boolean;
+  /** Relays hold different fee recipients */
+  feeRecipientMismatch?: boolean;
+}
+
+/** RelayRegistration is the registration of a validator at one relay. */
+export interface RelayRegistration {
+  relay: string;
+  registered: boolean;
+  feeRecipient?: string;
+  gasLimit?: number;
+  /** When the validator signed the registration */
+  timestamp?: string;
+  stale?: boolean;
+  /** Set when the relay could not be queried */
+  error?: string;
+}
+
+/**
+ * Restaking describes the EigenLayer restaking of a validator whose withdrawal
+ * credentials point to an EigenPod.
+ */
+export interface Restaking {
+  /** eigenlayer */
+  protocol: string;
+  eigenPod: string;
+  podOwner: string;
+  delegated: boolean;
+  /** Operator the pod owner delegated to */
+  operator?: string;
+}
+
+/** WithdrawalCredentials contains the type and address for withdrawals. */
+export interface WithdrawalCredentials {
+  type: string;
+  prefix: string;
+  credential: string;
+  address?: string;
+  /**
+   * ENSName is the primary ENS name of Address, resolved on mainnet when an
+   * execution RPC is configured.
+   */
+  ensName?: string;
+}
+
+/** ValidatorRewards contains all-time reward/penalty information. */
+export interface ValidatorRewards {
+  /** Net rewards (rewards - penalties) in wei */
+  total: string;
+  /** Total rewards earned in wei */
+  totalReward: string;
+  /** Total penalties in wei */
+  totalPenalty: string;
+  /** Total missed rewards in wei */
+  totalMissed: string;
+  proposals: ProposalRewards;
+  attestations: AttestationRewards;
+  syncCommittees: SyncCommitteeRewards;
+  /** Net rewards by revenue stream */
+  income?: IncomeSplit;
+  /** false until the range is finalized */
+  finalized?: boolean;
+}
+
+/**
+ * IncomeSplit attributes the rewards of a window to consensus layer issuance,
+ * execution layer priority fees and MEV relay payments.
+ */
+export interface IncomeSplit {
+  /** Net CL rewards (total minus EL rewards) in wei */
+  consensus: string;
+  /** Priority fees of locally built blocks in wei */
+  priorityFees: string;
+  /** Builder payments of relayed blocks in wei */
+  mev: string;
+  /** Blocks proposed in the window */
+  blocks: number;
+  /** Blocks delivered by a relay */
+  mevBlocks: number;
+}
+
+/** ProposalRewards contains reward breakdown for block proposals. */
+export interface ProposalRewards {
+  /** Total proposal rewards in wei */
+  total: string;
+  /** EL rewards in wei */
+  executionLayerReward: string;
+  /** Attestation inclusion in wei */
+  attestationInclusionReward: string;
+  /** Sync inclusion in wei */
+  syncInclusionReward: string;
+  /** Slashing inclusion in wei */
+  slashingInclusionReward: string;
+  /** Missed CL rewards in wei */
+  missedClReward: string;
+  /** Missed EL rewards in wei */
+  missedElReward: string;
+}
+
+/** AttestationRewards contains reward breakdown for attestations. */
+export interface AttestationRewards {
+  /** Total attestation rewards in wei */
+  total: string;
+  /** Head vote rewards in wei */
+  head: string;
+  /** Source vote rewards in wei */
+  source: string;
+  /** Target vote rewards in wei */
+  target: string;
+  /** Inactivity leak penalty in wei */
+  inactivityLeakPenalty: string;
+}
+
+/** SyncCommitteeRewards contains reward breakdown for sync committee duties. */
+export interface SyncCommitteeRewards {
+  /** Net sync committee rewards in wei */
+  total: string;
+  /** Sync committee rewards in wei */
+  reward: string;
+  /** Sync committee penalties in wei */
+  penalty: string;
+  /** Missed sync committee rewards in wei */
+  missedReward: string;
+}
+
+/** ValidatorPerformance contains all-time performance metrics. */
+export interface ValidatorPerformance {
+  /** Overall BeaconScore (0-1) */
+  beaconscore: number | null;
+  attestations: AttestationDuties;
+  syncCommittees: SyncCommitteeDuties;
+  proposals: ProposalDuties;
+  /** false until the range is finalized */
+  finalized?: boolean;
+  /** Uptime of the validators by SLA window, e.g. "7d", from the recorded history */
+  uptime?: Record<string, Uptime>;
+  /**
+   * Luck compares the proposal and sync committee duties with their expected
+   * number, when the network size is available
+   */
+  luck?: Luck;
+}
+
+/**
+ * Luck compares the duties the validators were assigned with the number expected
+ * from their share of the network's effective balance over the range, and the
+ * execution layer rewards of their blocks with the blocks of the network.
+ */
+export interface Luck {
+  proposals?: DutyLuck;
+  /** In slots, like the sync committee duties */
+  syncCommittees?: DutyLuck;
+  blockValue?: BlockValueLuck;
+}
+
+/** DutyLuck is the expected and actual number of duties of one kind. */
+export interface DutyLuck {
+  expected: number;
+  actual: number;
+  /** Actual over expected in percent, null when none were expected */
+  luck: number | null;
+}
+
+/**
+ * BlockValueLuck compares the average execution layer reward of the blocks the
+ * validators proposed with the median block of the network.
+ */
+export interface BlockValueLuck {
+  /** Proposed blocks */
+  blocks: number;
+  /** Proposed blocks delivered by a relay */
+  relayedBlocks: number;
+  /** Priority fees and builder payments per block in wei */
+  average: string;
+  /** Reward of the median network block in wei */
+  networkMedian: string;
+  /** Network blocks the median is taken over */
+  networkSample: number;
+  /** Average over network median in percent, null when the median is zero */
+  luck: number | null;
+  /** Enough blocks far enough below the network to suggest a broken MEV-boost setup */
+  mevBoostSuspect?: boolean;
+}
+
+/** AttestationDuties contains attestation performance metrics. */
+export interface AttestationDuties {
+  /** Total attestation duties assigned */
+  assigned: number;
+  /** Attestations included on-chain */
+  included: number;
+  /** Missed attestations */
+  missed: number;
+  /** Correct head votes */
+  correctHead: number;
+  /** Correct source votes */
+  correctSource: number;
+  /** Correct target votes */
+  correctTarget: number;
+  /** Average inclusion delay in slots */
+  avgInclusionDelay: number;
+  /** Attestation-specific BeaconScore */
+  beaconscore: number | null;
+}
+
+/** SyncCommitteeDuties contains sync committee performance metrics. */
+export interface SyncCommitteeDuties {
+  /** Total sync committee duties assigned */
+  assigned: number;
+  /** Successfully performed duties */
+  successful: number;
+  /** Missed duties */
+  missed: number;
+  /** Sync committee-specific BeaconScore */
+  beaconscore: number | null;
+}
+
+/** ProposalDuties contains block proposal performance metrics. */
+export interface ProposalDuties {
+  /** Total proposal duties assigned */
+  assigned: number;
+  /** Successfully proposed blocks */
+  successful: number;
+  /** Missed/orphaned proposals */
+  missed: number;
+  /** Slashing proofs included */
+  includedSlashings: number;
+  /** Proposal-specific BeaconScore */
+  beaconscore: number | null;
+}
+
+/** Envelope wraps a successful response when requested with envelope=true. */
+export interface Envelope {
+  data: unknown;
+}
+
+/** APIError represents an error response from the API. */
+export interface APIError {
+  error: string;
+  message?: string;
+  code: number;
+}
+
+/** ReconciliationResponse compares accrued consensus layer rewards against withdrawn amounts. */
+export interface ReconciliationResponse {
+  /** Range is the evaluation window the comparison covers. */
+  range: string;
+  /** Tolerance is the maximum absolute difference in wei before a validator is flagged. */
+  tolerance: string;
+  /** Validators contains the per-validator reconciliation keyed by validator ID. */
+  validators: Record<string, ValidatorReconciliation>;
+  /** Total contains the reconciliation summed over all requested validators. */
+  total: ValidatorReconciliation;
+}
+
+/** ValidatorReconciliation contains accrued vs withdrawn amounts for a single validator or a group. */
+export interface ValidatorReconciliation {
+  /** CL rewards accrued in the window, in wei */
+  accruedClReward: string;
+  /** Rewards withdrawn in the window (principal excluded), in wei */
+  withdrawn: string;
+  /** Number of withdrawals processed in the window */
+  withdrawals: number;
+  /** Accrued minus withdrawn, in wei */
+  difference: string;
+  /** Whether the difference exceeds the tolerance */
+  flagged: boolean;
+}
+
+/** FiatPrice is the price of one ETH in a fiat currency. */
+export interface FiatPrice {
+  currency: string;
+  price: number;
+  provider: string;
+  updatedAt: string;
+  /** Set when all providers failed and the last known price is used */
+  stale: boolean;
+}
+
+/** FiatValues contains fiat values of the validator balances and aggregated rewards. */
+export interface FiatValues extends FiatPrice {
+  /** Sum of current balances */
+  totalBalance: number;
+  /** Net rewards in the evaluation window */
+  rewards: number;
+}
+
+/** DailyIncomeResponse contains the combined income of the requested validators per UTC day. */
+export interface DailyIncomeResponse {
+  /** Currency is the fiat currency of the price and value fields, if requested. */
+  currency?: string;
+  /** CurrentPrice is the ETH price now, which CurrentValue fields are valued at. */
+  currentPrice?: number;
+  /**
+   * CostBasis is the income of all days valued at the price of the day it was
+   * earned. Only set when every day with income has a known price.
+   */
+  costBasis?: number;
+  /** CurrentValue is the income of all days valued at the current price. */
+  currentValue?: number;
+  /** Days contains one entry per day, oldest first, including days without income. */
+  days: DailyIncome[];
+}
+
+/** DailyIncome contains the income earned on a single UTC day. */
+export interface DailyIncome {
+  /** UTC day formatted as YYYY-MM-DD */
+  date: string;
+  /** Net CL rewards in wei */
+  consensusLayer: string;
+  /** EL proposal rewards in wei */
+  executionLayer: string;
+  /** CL + EL income in wei */
+  total: string;
+  /** Price is the ETH price on that day, when a currency was requested and a price is known. */
+  price?: number;
+  /** FiatValue is the total income valued at that day's price, its cost basis. */
+  fiatValue?: number;
+  /** CurrentValue is the total income valued at the current price. */
+  currentValue?: number;
+}
+
+/** BalanceHistoryResponse is the per-epoch balance history of a single validator. */
+export interface BalanceHistoryResponse {
+  validatorIndex: number;
+  chain: string;
+  /** Oldest first */
+  epochs: EpochBalance[];
+}
+
+/** EpochBalance contains a validator's balances at the end of an epoch. */
+export interface EpochBalance {
+  epoch: number;
+  /** in wei */
+  balance: string;
+  /** in wei */
+  effectiveBalance: string;
+}
+
+/**
+ * ValidatorDeltaResponse lists the validators whose status, balance or online flag
+ * changed since a time, based on the recorded snapshots.
+ */
+export interface ValidatorDeltaResponse {
+  since: string;
+  /** Latest snapshot of the validators; pass as since on the next request */
+  asOf: string;
+  /** Changed validators by index */
+  validators: Record<string, ValidatorDelta>;
+}
+
+/** ValidatorDelta is the latest recorded state of a changed validator. */
+export interface ValidatorDelta {
+  status: string;
+  /** in wei */
+  currentBalance: string;
+  online: boolean;
+  /** "status", "balance" and/or "online"; all three for validators not recorded by since */
+  changed: string[];
+  /** When the state was recorded */
+  time: string;
+}
+
+/** TimelineResponse lists the recorded state transitions of a single validator. */
+export interface TimelineResponse {
+  validatorIndex: number;
+  chain: string;
+  /** Start of the timeline */
+  since: string;
+  /** Time offline since the first recorded online flip, up to now */
+  offlineSeconds: number;
+  /** Oldest first */
+  transitions: TimelineTransition[];
+}
+
+/**
+ * TimelineTransition is a change of a validator's state, detected between two
+ * snapshots or reported by an operator registry.
+ */
+export interface TimelineTransition {
+  time: string;
+  /** status_changed, online_changed, slashed, registry_key_added or registry_key_removed */
+  type: string;
+  from: string;
+  to: string;
+  /**
+   * Seconds the validator stayed in To, until the next transition of the same
+   * type. Only set for status and online transitions, and omitted while the
+   * validator is still in To.
+   */
+  durationSeconds?: number;
+}
+
+/**
+ * SLAResponse is the uptime of validators over SLA windows, each map keyed by
+ * window, e.g. "30d".
+ */
+export interface SLAResponse {
+  chain: string;
+  /** All requested validators */
+  total: Record<string, Uptime>;
+  /** By validator index */
+  validators: Record<string, Record<string, Uptime>>;
+  /** By value of the groupBy label */
+  groups?: Record<string, Record<string, Uptime>>;
+}
+
+/** Uptime is how long validators were online while active over a window. */
+export interface Uptime {
+  /** Percentage of the active time spent online; null without recorded active time */
+  uptime: number | null;
+  /** Time spent active, summed over validators */
+  activeSeconds: number;
+  /** Time spent active but offline, summed over validators */
+  offlineSeconds: number;
+}
+
+/** HealthResponse scores the health of validators from 0 to 100. */
+export interface HealthResponse {
+  chain: string;
+  /** Window of the performance components */
+  range: string;
+  /** Weight of each component */
+  weights: Record<string, number>;
+  /** Mean of the scored validators */
+  fleet: HealthScore;
+  /** By validator index */
+  validators: Record<string, HealthScore>;
+}
+
+/**
+ * HealthScore is a weighted mean of health components, each scored from 0 to 1.
+ * Only active validators are scored.
+ */
+export interface HealthScore {
+  /** 0-100, null when not scored */
+  score: number | null;
+  /** Known components only */
+  components?: Record<string, number>;
+}
+
+/** AttestationTrendResponse is the attestation effectiveness of a set of validators over time. */
+export interface AttestationTrendResponse {
+  /** Evaluation range of the underlying samples */
+  range: string;
+  /** "hour" or "day" */
+  bucket: string;
+  buckets: AttestationTrendBucket[];
+}
+
+/**
+ * AttestationTrendBucket averages the samples recorded within one bucket.
+ * Buckets without samples are omitted.
+ */
+export interface AttestationTrendBucket {
+  start: string;
+  samples: number;
+  /** Mean included/assigned ratio, 0-1 */
+  effectiveness: number;
+  avgInclusionDelay: number;
+}
+
+/** SyncCommitteesResponse lists the sync committee assignments of the requested validators. */
+export interface SyncCommitteesResponse {
+  /** Assignments are ordered newest period first, then by validator index. */
+  assignments: SyncCommitteeAssignment[];
+}
+
+/** SyncCommitteeAssignment is a validator's membership in the sync committee of one period. */
+export interface SyncCommitteeAssignment {
+  validatorIndex: number;
+  period: number;
+  startEpoch: number;
+  endEpoch: number;
+  /** The period is still in progress */
+  current: boolean;
+  assigned: number;
+  successful: number;
+  missed: number;
+  /** successful/assigned, null before the first slot */
+  participationRate: number | null;
+  /** in wei */
+  rewards: string;
+}
+
+/**
+ * GraffitiResponse lists the graffiti of the blocks the requested validators
+ * proposed over a range, with the clients inferred from it.
+ */
+export interface GraffitiResponse {
+  range: string;
+  /** Pattern the graffiti was checked against */
+  policy?: string;
+  /** By validator index, only validators that proposed */
+  validators: Record<string, ValidatorGraffiti>;
+  /** Blocks per consensus client, "unknown" when not inferred */
+  clients: Record<string, number>;
+  /** Blocks whose graffiti does not match the policy */
+  violations?: number;
+}
+
+/** ValidatorGraffiti is the graffiti of the blocks a validator proposed. */
+export interface ValidatorGraffiti {
+  /** Latest first */
+  blocks: BlockGraffiti[];
+  /** Of the latest block it could be inferred from */
+  consensusClient?: string;
+  /** Of the latest block it could be inferred from */
+  executionClient?: string;
+  /** All blocks match the policy, only set with a policy */
+  compliant?: boolean;
+}
+
+/** BlockGraffiti is the graffiti of a proposed block. */
+export interface BlockGraffiti {
+  slot: number;
+  blockNumber?: number;
+  graffiti: string;
+  consensusClient?: string;
+  executionClient?: string;
+  /** Only set with a policy */
+  compliant?: boolean;
+}
+
+/** SlashingResponse describes whether and how a validator was slashed. */
+export interface SlashingResponse {
+  validatorIndex: number;
+  slashed: boolean;
+  /** Slashing is null when the validator is not slashed or the details are not available yet. */
+  slashing: SlashingDetails | null;
+}
+
+/** SlashingDetails describes the slashing of a validator. */
+export interface SlashingDetails {
+  slot: number;
+  epoch: number;
+  time: string;
+  /** "attester_slashing" or "proposer_slashing" */
+  reason: string;
+  /** "double_proposal", "double_vote" or "surround_vote" */
+  violation: string;
+  includedBy: number;
+  /** in wei */
+  penalty: string;
+  /** The conflicting messages signed by the validator, depending on the reason */
+  conflictingHeaders?: SlashingBlockHeader[];
+  conflictingAttestations?: SlashingAttestation[];
+}
+
+/** SlashingBlockHeader is a block header signed by a slashed proposer. */
+export interface SlashingBlockHeader {
+  slot: number;
+  parentRoot: string;
+  stateRoot: string;
+  bodyRoot: string;
+}
+
+/** SlashingAttestation is attestation data signed by a slashed attester. */
+export interface SlashingAttestation {
+  slot: number;
+  beaconBlockRoot: string;
+  sourceEpoch: number;
+  targetEpoch: number;
+  targetRoot: string;
+}
+
+/** DashboardResponse is everything a landing page needs for a set of portfolios. */
+export interface DashboardResponse {
+  range: string;
+  portfolios: PortfolioSummary[];
+  /** Rollup combines all portfolios per chain. Validators in several portfolios are counted once. */
+  rollup: Record<string, DashboardTotals>;
+  recentEvents: DashboardEvent[];
+}
+
+/** DashboardTotals counts validators by state and sums their balances. */
+export interface DashboardTotals {
+  validators: number;
+  online: number;
+  offline: number;
+  slashed: number;
+  /** in wei */
+  totalBalance: string;
+  /** PresignedExits counts the validators with a pre-signed voluntary exit stored */
+  presignedExits: number;
+}
+
+/** PortfolioSummary summarizes a single portfolio. */
+export interface PortfolioSummary extends DashboardTotals {
+  name: string;
+  chain: string;
+  /** total over the range in wei */
+  rewards: string;
+  beaconscore: number | null;
+  /** Error is set when the portfolio could not be fetched; the other portfolios are still returned. */
+  error?: string;
+}
+
+/** DashboardEvent is a recent change in the state of a portfolio validator. */
+export interface DashboardEvent {
+  time: string;
+  chain: string;
+  validatorIndex: number;
+  portfolios: string[];
+  type: string;
+  from: string;
+  to: string;
+}
+
+/** UsageResponse reports the upstream credits spent today against the daily budget. */
+export interface UsageResponse {
+  /** False when no daily budget is configured */
+  enabled: boolean;
+  dailyCredits: number;
+  spent: number;
+  remaining: number;
+  /** Low is set once the remaining credits fall below the reserve; cached data is then preferred. */
+  low: boolean;
+  /** Upstream requests refused today for lack of credits */
+  refused: number;
+  resetsAt: string;
+  endpoints: Record<string, EndpointUsage>;
+}
+
+/** EndpointUsage is the upstream usage of a single endpoint today. */
+export interface EndpointUsage {
+  requests: number;
+  credits: number;
+}
+
+/** CacheStatsResponse reports the in-memory caches of the service. */
+export interface CacheStatsResponse {
+  /** Validator responses */
+  responses: CacheStats;
+  /** Rewards and performance aggregates */
+  aggregates: CacheStats;
+  /** Balance histories */
+  balanceHistory: CacheStats;
+}
+
+/** CacheStats reports the size, limits and counters of a cache since startup. */
+export interface CacheStats {
+  entries: number;
+  /** Approximate size of the cached values */
+  bytes: number;
+  /** 0 when unlimited */
+  maxEntries: number;
+  /** 0 when unlimited */
+  maxBytes: number;
+  hits: number;
+  misses: number;
+  /** Entries dropped to stay within the limits */
+  evictions: number;
+}
+
+/**
+ * AlertRule is a condition checked periodically against the data of a portfolio,
+ * e.g. "beaconscore < 0.9 for 3 checks", with the channels notified when it fires
+ * and resolves.
+ */
+export interface AlertRule {
+  name: string;
+  portfolio: string;
+  condition: string;
+  /** Evaluation range of rewards and performance, 24h by default */
+  range?: string;
+  /** info, warning or critical; warning by default */
+  severity?: string;
+  channels: string[];
+}
+
+/** AlertRuleStatus is a rule with the outcome of its latest check. */
+export interface AlertRuleStatus extends AlertRule {
+  firing: boolean;
+  /** Consecutive checks the condition held */
+  matches: number;
+  /** Metric value at the latest check */
+  value?: number;
+  lastCheck?: string;
+  lastError?: string;
+  /** Whether the latest check fell in a mute window */
+  muted: boolean;
+}
+
+/** AlertRulesResponse lists the alert rules in the order they were added. */
+export interface AlertRulesResponse {
+  rules: AlertRuleStatus[];
+}
+
+/** Alert is an incident of a rule firing, from the check it fired on until it resolved. */
+export interface Alert {
+  id: string;
+  rule: string;
+  portfolio: string;
+  chain: string;
+  severity: string;
+  condition: string;
+  /** open, acknowledged or resolved */
+  status: string;
+  /** Metric value when the rule fired */
+  value: number;
+  validators?: number[];
+  firedAt: string;
+  ackedAt?: string;
+  ackedBy?: string;
+  resolvedAt?: string;
+}
+
+/** AlertsResponse lists alerts, most recently fired first. */
+export interface AlertsResponse {
+  alerts: Alert[];
+}
+
+/** AlertAckRequest is the optional body of an acknowledgement. */
+export interface AlertAckRequest {
+  /** Who acknowledged the alert */
+  by?: string;
+}
+
+/**
+ * AlertMute silences alerts on a portfolio, or on validators of a chain, between
+ * Start and End, e.g. during planned client upgrades.
+ */
+export interface AlertMute {
+  id: string;
+  portfolio?: string;
+  /** Chain of Validators */
+  chain?: string;
+  validators?: number[];
+  /** Now if not set when the mute is created */
+  start: string;
+  end: string;
+  reason?: string;
+}
+
+/** AlertMutesResponse lists the current and scheduled mute windows by start time. */
+export interface AlertMutesResponse {
+  mutes: AlertMute[];
+}
+
+/** ValidatorLabels are the user-defined labels of a validator. */
+export interface ValidatorLabels {
+  chain: string;
+  validatorIndex: number;
+  labels: Record<string, string>;
+}
+
+/**
+ * LabelsResponse lists the labels of every labeled validator on a chain, keyed by
+ * validator index.
+ */
+export interface LabelsResponse {
+  chain: string;
+  validators: Record<string, Record<string, string>>;
+}
+
+/** Note is the freeform note of a validator. */
+export interface Note {
+  text: string;
+  updatedAt: string;
+}
+
+/** ValidatorNote is the note of a validator. */
+export interface ValidatorNote extends Note {
+  chain: string;
+  validatorIndex: number;
+}
+
+/**
+ * ValidatorSearchResponse lists the validators on a chain whose labels and notes
+ * match a search, by index.
+ */
+export interface ValidatorSearchResponse {
+  chain: string;
+  /** key:value labels all matched */
+  meta?: string[];
+  /** Text matched in notes or label values */
+  q?: string;
+  validators: ValidatorMetadata[];
+}
+
+/** ValidatorMetadata is the user-defined metadata of a validator. */
+export interface ValidatorMetadata {
+  validatorIndex: number;
+  labels?: Record<string, string>;
+  notes?: string;
+}
+
+/**
+ * SearchResponse lists the portfolios and validators matching a search, best
+ * matches first.
+ */
+export interface SearchResponse {
+  q: string;
+  results: SearchResult[];
+  /** More matched than the limit */
+  truncated?: boolean;
+}
+
+/** SearchResult is a portfolio or a validator matching a search. */
+export interface SearchResult {
+  /** portfolio or validator */
+  type: string;
+  chain: string;
+  portfolio?: string;
+  /** Validators of a portfolio */
+  validatorIds?: number[];
+  validatorIndex?: number;
+  /** Field matched: name, index, pubkey, withdrawalAddress, label or notes */
+  match: string;
+  /** Content of the field matched, e.g. the label as key:value */
+  value: string;
+}
+
+/**
+ * WatchlistImportResponse reports the validators of uploaded public keys added to
+ * a watchlist, that is a portfolio.
+ */
+export interface WatchlistImportResponse {
+  watchlist: string;
+  chain: string;
+  /** Distinct public keys uploaded */
+  keys: number;
+  /** Validators added to the watchlist */
+  added: number[];
+  /** Validators already in the watchlist */
+  existing: number[];
+  /** Keys without an activated validator yet */
+  notActivated: ImportedKey[];
+}
+
+/** ImportedKey is an uploaded public key whose validator is not activated yet. */
+export interface ImportedKey {
+  publicKey: string;
+  /** Unset until the deposit is processed */
+  validatorIndex?: number;
+  /** Upstream status, or unknown before the deposit is processed */
+  status: string;
+}
+
+/**
+ * PresignedExit is the metadata of a pre-signed voluntary exit message kept by the
+ * operator. The message itself is never sent to the server.
+ */
+export interface PresignedExit {
+  /** When the message was signed and stored */
+  storedAt: string;
+  /** Where the message is kept, e.g. a vault path */
+  location?: string;
+}
+
+/** ValidatorPresignedExit is the pre-signed exit record of a validator. */
+export interface ValidatorPresignedExit extends PresignedExit {
+  chain: string;
+  validatorIndex: number;
+}
+
+/**
+ * PresignedExitsResponse lists the pre-signed exit records of a chain, keyed by
+ * validator index.
+ */
+export interface PresignedExitsResponse {
+  chain: string;
+  validators: Record<string, PresignedExit>;
+}
+
+/** StateImport describes a state archive staged by POST /admin/import. */
+export interface StateImport {
+  /** When the archive was exported */
+  createdAt: string;
+  /** Data files staged */
+  files: number;
+  /** Total size of the data files */
+  bytes: number;
+  /** Configuration files in the archive, not imported */
+  config: string[];
+  restartRequired: boolean;
+}
+
+/**
+ * AuditEntry records a change made through the API: who made it, when, and the
+ * request that made it.
+ */
+export interface AuditEntry {
+  time: string;
+  /** Tenant whose API key was used */
+  tenant?: string;
+  clientIp: string;
+  method: string;
+  path: string;
+  query?: string;
+  /** JSON request body, omitted when larger than 8 KiB */
+  body?: unknown;
+  status: number;
+}
+
+/** AuditResponse lists audit entries, newest first. */
+export interface AuditResponse {
+  entries: AuditEntry[];
+}
+
+/**
+ * DiversityResponse compares the client distribution of a fleet, taken from a
+ * validator label, with the network.
+ */
+export interface DiversityResponse {
+  chain: string;
+  portfolio?: string;
+  /** Label key holding the client */
+  label: string;
+  /** Validators with the label */
+  validators: number;
+  /** Validators without the label */
+  unlabeled: number;
+  /** Most validators first */
+  clients: ClientShare[];
+  /** Publisher of the network shares */
+  networkSource?: string;
+  /** When the network shares were published */
+  networkUpdatedAt?: string;
+}
+
+/** ClientShare is the share of a client in a fleet and in the network. */
+export interface ClientShare {
+  client: string;
+  validators: number;
+  /** Of the validators with the label, 0-1 */
+  share: number;
+  /** Of the network validators, when known */
+  networkShare?: number;
+  /** The fleet share exceeds 1/3 */
+  overOneThird: boolean;
+}
+
+/** CompareResponse compares the aggregates of groups of validators side by side. */
+export interface CompareResponse {
+  range: string;
+  /** In the requested order */
+  groups: GroupComparison[];
+}
+
+/**
+ * GroupComparison is the aggregated performance of a group of validators: a
+ * portfolio, or the validators with a label.
+ */
+export interface GroupComparison extends DashboardTotals {
+  /** Portfolio name or key:value label selector */
+  group: string;
+  chain: string;
+  /** Net rewards over the range in wei */
+  rewards: string;
+  /** in wei, for groups of different sizes */
+  rewardsPerValidator: string;
+  beaconscore: number | null;
+  /** Missed over assigned duties, null without duties */
+  attestationMissRate: number | null;
+  proposalMissRate: number | null;
+  syncMissRate: number | null;
+  /** Error is set when the group could not be fetched; the other groups are still returned. */
+  error?: string;
+}
+
+/**
+ * Benchmark compares the requested validators with the average validator of the
+ * network over the same range.
+ */
+export interface Benchmark {
+  fleet: BenchmarkValues;
+  network: BenchmarkValues;
+}
+
+/** BenchmarkValues are performance figures of a set of validators over a range. */
+export interface BenchmarkValues {
+  beaconscore: number | null;
+  /** Included over assigned attestations */
+  attestationEffectiveness: number | null;
+  /** Annualized net rewards over effective balance */
+  apr: number | null;
+}
+
+/**
+ * ProjectionResponse projects the earnings of validators from their recent rewards
+ * and from the network APR.
+ */
+export interface ProjectionResponse {
+  chain: string;
+  /** Window of the recent rewards */
+  range: string;
+  /** Effective balance of the active validators in wei */
+  stakedBalance: string;
+  /** Set when a currency was requested and a price is available */
+  price?: FiatPrice;
+  /** At the validators' own reward rate; null when the rewards are unavailable */
+  fleet: Earnings | null;
+  /** At the network APR; null when it is unavailable */
+  network: Earnings | null;
+}
+
+/** Earnings are projected earnings at a constant rate. Months are a twelfth of a year. */
+export interface Earnings {
+  /** Annualized net rewards over the staked balance */
+  apr: number | null;
+  daily: string;
+  monthly: string;
+  annual: string;
+  /** In the requested currency */
+  fiat?: FiatEarnings;
+}
+
+/** FiatEarnings are projected earnings valued at the current price. */
+export interface FiatEarnings {
+  daily: number;
+  monthly: number;
+  annual: number;
+}
+
+/** ValidatorReportRequest is the body of POST /jobs/validator-report. */
+export interface ValidatorReportRequest {
+  validatorIds: number[];
+  chain: string;
+  /** Defaults to all_time */
+  range?: string;
+  /** include or exclude, with the same default as GET /validator */
+  anomalies?: string;
+}
+
+/** Job is the status of a background job. */
+export interface Job {
+  id: string;
+  /** e.g. validator-report */
+  type: string;
+  /** queued, running, done or failed */
+  status: string;
+  /** Items processed so far, e.g. validators */
+  done: number;
+  total: number;
+  error?: string;
+  createdAt: string;
+  startedAt?: string;
+  finishedAt?: string;
+  /** When a finished job and its result are removed */
+  expiresAt?: string;
+  /** Set once the job is done */
+  resultUrl?: string;
+}
+
+/**
+ * ValidatorReport is the result of a validator report job: the data of every
+ * requested validator, fetched in batches of up to 100 validators.
+ */
+export interface ValidatorReport {
+  chain: string;
+  range: string;
+  validators: Record<string, ValidatorOverview>;
+  totals: DashboardTotals;
+  /** Net rewards of all batches in wei */
+  rewards: string;
+  /**
+   * Batches holds the rewards and performance of each batch, since upstream
+   * aggregates them per request.
+   */
+  batches: ValidatorReportBatch[];
+}
+
+/** ValidatorReportBatch is the aggregated rewards and performance of a batch of a report. */
+export interface ValidatorReportBatch {
+  validatorIds: number[];
+  rewards: ValidatorRewards;
+  performance: ValidatorPerformance;
+}
+
+/** GrafanaSearchRequest is the body of POST /grafana/search. */
+export interface GrafanaSearchRequest {
+  /** Text the listed targets must contain */
+  target: string;
+}
+
+/**
+ * GrafanaQueryRequest is the body of POST /grafana/query, as sent by the Grafana
+ * JSON datasources. Fields the service does not use are ignored.
+ */
+export interface GrafanaQueryRequest {
+  range: GrafanaRange;
+  /** Suggested spacing of the points */
+  intervalMs: number;
+  /** Most points the panel can show */
+  maxDataPoints: number;
+  targets: GrafanaTarget[];
+}
+
+/** GrafanaRange is the time range of a Grafana query. */
+export interface GrafanaRange {
+  from: string;
+  to: string;
+}
+
+/** GrafanaTarget is a queried series, e.g. "main/balance". */
+export interface GrafanaTarget {
+  target: string;
+  refId: string;
+  /** Hidden targets are not queried */
+  hide: boolean;
+}
+
+/** GrafanaTimeSeries is a series of [value, Unix milliseconds] points. */
+export interface GrafanaTimeSeries {
+  target: string;
+  datapoints: number[][];
+}
+
+/**
+ * PortfolioReport summarizes the income and performance of a portfolio over the
+ * period of a report schedule.
+ */
+export interface PortfolioReport extends DashboardTotals {
+  id: string;
+  /** Name of the schedule that produced the report */
+  schedule: string;
+  portfolio: string;
+  chain: string;
+  /** daily or weekly */
+  period: string;
+  /** Evaluation window of the figures: 24h or 7d */
+  range: string;
+  generatedAt: string;
+  rewards: ValidatorRewards;
+  performance: ValidatorPerformance;
+  /**
+   * Uptime over the range and the notable events of the period, newest first,
+   * from the recorded history
+   */
+  uptime?: Uptime;
+  events?: DashboardEvent[];
+  /** Fired during the period or still unresolved */
+  alerts?: Alert[];
+}
+
+/** ReportSummary identifies a stored report in a listing. */
+export interface ReportSummary {
+  id: string;
+  schedule: string;
+  portfolio: string;
+  period: string;
+  generatedAt: string;
+  url: string;
+}
+
+/** ReportsResponse lists the stored reports, newest first. */
+export interface ReportsResponse {
+  reports: ReportSummary[];
+}
+
+/**
+ * ClientMissesResponse compares the attestations signed by the validator client of
+ * a portfolio with those included on chain, over about the last day.
+ */
+export interface ClientMissesResponse {
+  portfolio: string;
+  chain: string;
+  /** Client recognized from the metrics */
+  client?: string;
+  /** Error of the last scrape, if it failed */
+  scrapeError?: string;
+  total: ClientMissWindow;
+  /** Oldest first */
+  windows: ClientMissWindow[];
+}
+
+/**
+ * ClientMissWindow counts the attestation duties of a portfolio in the epochs
+ * between two scrapes of its validator client.
+ */
+export interface ClientMissWindow {
+  startEpoch: number;
+  endEpoch: number;
+  /** Duties of the active validators */
+  expected: number;
+  /** Attestations included on chain */
+  included: number;
+  /** Attestations the client signed */
+  signed: number;
+  /** Attestations the client failed to sign or publish */
+  failed: number;
+  /** Missed because the client did not sign */
+  notSigned: number;
+  /** Missed although signed, not included by the network */
+  notIncluded: number;
+}
+
+/** ClustersResponse is the response of GET /clusters. */
+export interface ClustersResponse {
+  range: string;
+  clusters: ClusterSummary[];
+}
+
+/**
+ * ClusterSummary aggregates the validators of a distributed validator cluster,
+ * which its operators run together rather than one per validator.
+ */
+export interface ClusterSummary extends DashboardTotals {
+  /** Portfolio name */
+  name: string;
+  chain: string;
+  /** obol or ssv */
+  protocol: string;
+  /** Operators needed to sign */
+  threshold: number;
+  /** Operators that may be down without missing duties */
+  faultTolerance: number;
+  /** Net rewards over the range in wei */
+  rewards: string;
+  beaconscore: number | null;
+  attestations: AttestationDuties;
+  proposals: ProposalDuties;
+  operators: ClusterOperator[];
+  /** Error is set when the cluster could not be fetched; the other clusters are still returned. */
+  error?: string;
+}
+
+/** ClusterOperator is an operator of a cluster with its share of the rewards. */
+export interface ClusterOperator {
+  name: string;
+  id?: string;
+  /** Share of the cluster's rewards, 0-1 */
+  share: number;
+  /** Share of the net rewards over the range in wei */
+  rewards: string;
+}
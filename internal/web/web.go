@@ -0,0 +1,33 @@
+// Package web serves the embedded single-page dashboard UI.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+)
+
+//go:generate go run ../../cmd/typegen -in ../models/api.go -out static/types.ts
+
+//go:embed static
+var staticFiles embed.FS
+
+// Handler returns an HTTP handler serving the dashboard UI.
+// It serves index.html at "/", the scripts and styles under "/assets/" and the
+// TypeScript declarations of the API models at "/types.ts".
+func Handler() http.Handler {
+	root, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		// The embedded directory is fixed at compile time
+		panic(err)
+	}
+	files := http.FileServer(http.FS(root))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Some systems map .ts to MPEG transport streams
+		if path.Ext(r.URL.Path) == ".ts" {
+			w.Header().Set("Content-Type", "application/typescript; charset=utf-8")
+		}
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,237 @@
+// Package client is a Go client for the validator-dashboard API.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/chainspec"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+)
+
+// API models returned by the client.
+type (
+	ValidatorRequest       = models.ValidatorRequest
+	ValidatorResponse      = models.ValidatorResponse
+	ValidatorOverview      = models.ValidatorOverview
+	BalanceHistoryResponse = models.BalanceHistoryResponse
+	EpochBalance           = models.EpochBalance
+)
+
+// Error is an error response from the API.
+type Error struct {
+	StatusCode int
+	Code       string // e.g. "validation_error"
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("server returned %d: %s", e.StatusCode, e.Message)
+}
+
+// Client calls a validator-dashboard server.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	apiKey     string
+}
+
+// New creates a client for the server at baseURL, e.g. "http://localhost:8080".
+// The timeout applies to each request; the server serves requests one at a time,
+// so it should leave room for queueing.
+func New(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// SetAPIKey sends key as a bearer token with every request, as servers with
+// tenants require. It must be called before the client is used.
+func (c *Client) SetAPIKey(key string) {
+	c.apiKey = key
+}
+
+// GetValidators fetches the overviews and aggregated rewards and performance of
+// the requested validators from GET /v1/validator. Unlike the endpoint, which excludes
+// anomalies on testnets by default, req.ExcludeAnomalies is always sent explicitly.
+func (c *Client) GetValidators(ctx context.Context, req ValidatorRequest) (ValidatorResponse, error) {
+	var response ValidatorResponse
+	_, err := c.getValidators(ctx, req, time.Time{}, &response)
+	return response, err
+}
+
+// GetHistory fetches the balance of a validator at the end of each of the last
+// epochs completed epochs from GET /v1/validator/{id}/balance-history.
+func (c *Client) GetHistory(ctx context.Context, chain string, validatorId, epochs int) (BalanceHistoryResponse, error) {
+	query := url.Values{}
+	query.Set("chain", chain)
+	query.Set("epochs", strconv.Itoa(epochs))
+
+	var response BalanceHistoryResponse
+	_, err := c.get(ctx, "/v1/validator/"+strconv.Itoa(validatorId)+"/balance-history", query, time.Time{}, &response)
+	return response, err
+}
+
+// Update is a validator response delivered by StreamUpdates, or the error that
+// prevented fetching one.
+type Update struct {
+	Time     time.Time
+	Response ValidatorResponse
+	Err      error
+}
+
+// StreamUpdates fetches the requested validators immediately and then on every
+// refresh until ctx is done, when the channel is closed. An interval of zero
+// refreshes shortly after every epoch. Refreshes send the time of the previous
+// response as If-Modified-Since, and only changed responses and errors are delivered.
+func (c *Client) StreamUpdates(ctx context.Context, req ValidatorRequest, interval time.Duration) (<-chan Update, error) {
+	var ticker *time.Ticker
+	var ticks <-chan time.Time
+	var epochs <-chan int64
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+		ticks = ticker.C
+	} else {
+		spec, err := chainspec.ForChain(req.Chain)
+		if err != nil {
+			return nil, err
+		}
+		epochs = spec.EpochTicker(ctx, time.Minute)
+	}
+
+	updates := make(chan Update)
+	go func() {
+		defer close(updates)
+		if ticker != nil {
+			defer ticker.Stop()
+		}
+
+		var lastModified time.Time
+		for {
+			var response ValidatorResponse
+			modified, err := c.getValidators(ctx, req, lastModified, &response)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil || modified != nil {
+				if modified != nil {
+					lastModified = *modified
+				}
+				select {
+				case updates <- Update{Time: time.Now(), Response: response, Err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticks:
+			case <-epochs:
+			}
+		}
+	}()
+	return updates, nil
+}
+
+// getValidators calls GET /v1/validator. See get for the results.
+func (c *Client) getValidators(ctx context.Context, req ValidatorRequest, ifModifiedSince time.Time, out any) (*time.Time, error) {
+	ids := make([]string, len(req.ValidatorIds))
+	for i, id := range req.ValidatorIds {
+		ids[i] = strconv.Itoa(id)
+	}
+
+	query := url.Values{}
+	query.Set("ids", strings.Join(ids, ","))
+	query.Set("chain", req.Chain)
+	// Custom windows such as 2026-01-01/2026-04-01 are sent as their bounds
+	if from, to, ok := strings.Cut(req.Range, "/"); ok {
+		query.Set("from", from)
+		query.Set("to", to)
+	} else if req.Range != "" {
+		query.Set("range", req.Range)
+	}
+	if req.Currency != "" {
+		query.Set("currency", req.Currency)
+	}
+	if req.ExcludeAnomalies {
+		query.Set("anomalies", "exclude")
+	} else {
+		query.Set("anomalies", "include")
+	}
+	for _, label := range req.Labels {
+		query.Add("label", label)
+	}
+	if req.GroupBy != "" {
+		query.Set("groupBy", req.GroupBy)
+	}
+	if req.ComparePrevious {
+		query.Set("compare", "previous")
+	}
+
+	return c.get(ctx, "/v1/validator", query, ifModifiedSince, out)
+}
+
+// get decodes the JSON response of a GET request into out. It returns nil without
+// decoding when the server answers 304 Not Modified, and otherwise the response's
+// Last-Modified time, or the current time if the server did not send one.
+func (c *Client) get(ctx context.Context, path string, query url.Values, ifModifiedSince time.Time, out any) (*time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	if !ifModifiedSince.IsZero() {
+		req.Header.Set("If-Modified-Since", ifModifiedSince.UTC().Format(http.TimeFormat))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		var payload models.APIError
+		if err := json.Unmarshal(body, &payload); err == nil {
+			apiErr.Code = payload.Error
+			apiErr.Message = payload.Message
+		}
+		return nil, apiErr
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	modified := time.Now()
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		modified = t
+	}
+	return &modified, nil
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/api"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/beaconcha/beaconchatest"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/config"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/models"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/service"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/store"
+	"github.com/Marketen/validator-dashboard-beaconcha/internal/tenant"
+)
+
+// newTestServer runs the API against a fake Beaconcha with validators 1 and 2.
+func newTestServer(t *testing.T, st store.Store) (*Client, *beaconchatest.Fake) {
+	t.Helper()
+
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1, 2)...)
+	fake.SetRewards("mainnet", "7d", models.BeaconchainRewardsAggregateResponse{
+		Data: models.BeaconchainRewardsData{Total: "1500"},
+	})
+
+	svc := service.NewValidatorService(fake, nil, st, nil, nil, nil)
+	handler := api.NewHandler(svc, &config.Config{MaxValidatorIDs: 100})
+	srv := httptest.NewServer(handler.Router())
+	t.Cleanup(srv.Close)
+
+	return New(srv.URL, 10*time.Second), fake
+}
+
+func TestClient_GetValidators(t *testing.T) {
+	c, _ := newTestServer(t, nil)
+	ctx := context.Background()
+
+	resp, err := c.GetValidators(ctx, ValidatorRequest{ValidatorIds: []int{1, 2}, Chain: "mainnet", Range: "7d"})
+	if err != nil {
+		t.Fatalf("GetValidators failed: %v", err)
+	}
+	if len(resp.Validators) != 2 || resp.Rewards.Total != "1500" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	// Custom windows are sent as from and to
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02")
+	resp, err = c.GetValidators(ctx, ValidatorRequest{ValidatorIds: []int{1, 2}, Chain: "mainnet", Range: yesterday + "/" + yesterday})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Code != "validation_error" || !strings.Contains(apiErr.Message, "from must be before to") {
+		t.Errorf("expected the window to be validated, got %v", err)
+	}
+	today := time.Now().UTC().Format("2006-01-02")
+	resp, err = c.GetValidators(ctx, ValidatorRequest{ValidatorIds: []int{1, 2}, Chain: "mainnet", Range: yesterday + "/" + today})
+	if err != nil {
+		t.Fatalf("GetValidators over a window failed: %v", err)
+	}
+	if resp.Window == nil || resp.Window.From.Format("2006-01-02") != yesterday {
+		t.Errorf("expected the window of yesterday, got %+v", resp.Window)
+	}
+
+	_, err = c.GetValidators(ctx, ValidatorRequest{ValidatorIds: []int{1}, Chain: "gnosis", Range: "7d"})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 400 || apiErr.Code != "validation_error" {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestClient_APIKey(t *testing.T) {
+	fake := beaconchatest.New()
+	fake.AddValidators("mainnet", beaconchatest.Validators(1)...)
+	handler := api.NewHandler(service.NewValidatorService(fake, nil, nil, nil, nil, nil), &config.Config{MaxValidatorIDs: 100})
+	router := tenant.NewRouter(handler.Router())
+	router.Add(tenant.Tenant{Name: "acme", APIKeySHA256: tenant.HashKey("secret")}, handler.Router())
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	req := ValidatorRequest{ValidatorIds: []int{1}, Chain: "mainnet", Range: "7d"}
+
+	c := New(srv.URL, 10*time.Second)
+	_, err := c.GetValidators(ctx, req)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 401 {
+		t.Errorf("expected 401 without a key, got %v", err)
+	}
+
+	c.SetAPIKey("wrong")
+	if _, err := c.GetValidators(ctx, req); !errors.As(err, &apiErr) || apiErr.StatusCode != 401 {
+		t.Errorf("expected 401 with a key of no tenant, got %v", err)
+	}
+
+	c.SetAPIKey("secret")
+	resp, err := c.GetValidators(ctx, req)
+	if err != nil {
+		t.Fatalf("GetValidators with the tenant's key failed: %v", err)
+	}
+	if len(resp.Validators) != 1 {
+		t.Errorf("expected 1 validator, got %+v", resp.Validators)
+	}
+	if _, err := c.GetHistory(ctx, "mainnet", 1, 10); errors.As(err, &apiErr) && apiErr.StatusCode == 401 {
+		t.Errorf("expected the key to be sent with every request, got %v", err)
+	}
+}
+
+func TestClient_StreamUpdates(t *testing.T) {
+	st, err := store.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, fake := newTestServer(t, st)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates, err := c.StreamUpdates(ctx, ValidatorRequest{ValidatorIds: []int{1}, Chain: "mainnet", Range: "7d"}, 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := <-updates
+	if first.Err != nil || len(first.Response.Validators) != 1 {
+		t.Fatalf("unexpected first update: %+v", first)
+	}
+
+	// Refreshes within the same epoch are answered with 304 and not delivered
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case u := <-updates:
+		t.Errorf("expected no update while unchanged, got %+v", u)
+	default:
+	}
+	if calls := fake.Calls(beaconchatest.MethodGetValidators); calls != 1 {
+		t.Errorf("expected 1 upstream fetch, got %d", calls)
+	}
+
+	cancel()
+	for range updates {
+	}
+}